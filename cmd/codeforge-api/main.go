@@ -59,6 +59,7 @@ func main() {
 
 	fmt.Printf("Starting CodeForge API Server\n")
 	fmt.Printf("📡 Server: http://localhost:%d\n", *port)
+	fmt.Printf("🖥️  Web UI: http://localhost:%d/\n", *port)
 	fmt.Printf("🔗 Health: http://localhost:%d/api/v1/health\n", *port)
 	fmt.Printf("📊 Metrics: http://localhost:%d/api/v1/events/metrics\n", *port)
 	fmt.Printf("🔌 WebSocket: ws://localhost:%d/api/v1/chat/ws/{sessionId}\n", *port)
@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/agentrun"
+	"github.com/entrepeneur4lyf/codeforge/internal/chat"
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+	"github.com/entrepeneur4lyf/codeforge/internal/privacy"
+	"github.com/spf13/cobra"
+)
+
+// agentCmd carries out a task as a planned series of steps that can be
+// resumed after a crash or ctrl+c
+var agentCmd = &cobra.Command{
+	Use:   "agent [task]",
+	Short: "Carry out a coding task step by step, resumably",
+	Long: `Plan a coding task as a series of steps and carry them out one at a time,
+writing each step's edits to the working directory. The files edits replace
+are backed up first, as in the chat.
+
+The run is saved after every step. If it's interrupted by ctrl+c, a crash or
+a failed request, continue it with --resume and the run ID it printed, or
+find the ID with --list. Before resuming, every file the run read or changed
+is checked against the hash it left; if any changed, the run stops and lists
+them, and --force continues from the files as they are.
+
+--bundle exports a run for a teammate to audit: a tarball of every request
+sent with the model's parameters, the hashes of the files sent as context,
+the responses and the diffs applied. A run's ID is a hash of its task, model,
+directory and the hashes of the project's files when it started, so runs with
+the same inputs share an ID and it can be recomputed from the bundle's
+run.json, which records the start time separately. Of runs sharing an ID,
+--resume and --bundle take the latest.
+
+Examples:
+  codeforge agent "add a --timeout flag to the serve command"
+  codeforge agent --list
+  codeforge agent --resume 3fa9c2d17b01e4a5
+  codeforge agent --bundle 3fa9c2d1 -o run.tar.gz`,
+	Args: cobra.ArbitraryArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		list, _ := cmd.Flags().GetBool("list")
+		resumeID, _ := cmd.Flags().GetString("resume")
+		force, _ := cmd.Flags().GetBool("force")
+		runModel, _ := cmd.Flags().GetString("model")
+		bundleID, _ := cmd.Flags().GetString("bundle")
+		output, _ := cmd.Flags().GetString("output")
+
+		cfg := config.Get()
+		if cfg == nil {
+			return fmt.Errorf("configuration not loaded")
+		}
+		if list {
+			return listAgentRuns(cfg.AgentRunDir())
+		}
+		if bundleID != "" {
+			return writeRunBundle(cfg.AgentRunDir(), bundleID, output)
+		}
+		if privacy.IsEphemeral() {
+			return fmt.Errorf("agent runs are saved to disk to be resumable, so --ephemeral can't be used")
+		}
+
+		var run *agentrun.Run
+		task := strings.TrimSpace(strings.Join(args, " "))
+		switch {
+		case resumeID != "" && task != "":
+			return fmt.Errorf("give a task or --resume, not both")
+		case resumeID != "":
+			var err error
+			if run, err = agentrun.Load(cfg.AgentRunDir(), resumeID); err != nil {
+				return err
+			}
+			if run.Status == agentrun.StatusDone {
+				return fmt.Errorf("run %s is already done", run.ID)
+			}
+			if err := run.Verify(force); err != nil {
+				return err
+			}
+			if runModel != "" {
+				run.Model = runModel
+			}
+			if next := run.Next(); next >= 0 {
+				fmt.Printf("Resuming run %s at step %d of %d: %s\n", run.ID, next+1, len(run.Steps), run.Task)
+			} else {
+				fmt.Printf("Resuming run %s: %s\n", run.ID, run.Task)
+			}
+		case task != "":
+			if runModel == "" {
+				runModel = chat.GetDefaultModel()
+			}
+			run = agentrun.New(cfg.AgentRunDir(), workingDir, task, runModel)
+			fmt.Printf("Agent run %s\n", run.ID)
+		default:
+			return fmt.Errorf("give a task, or --resume a run")
+		}
+
+		handler, err := chat.NewHandlerForModel(run.Model, chat.GetAPIKeyForModel(run.Model), "")
+		if err != nil {
+			return err
+		}
+
+		activeRun.Store(run)
+		defer activeRun.Store(nil)
+		if err := agentrun.Execute(context.Background(), handler, run, cfg.EditBackupDir(), os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Run %s stopped; continue it with: codeforge agent --resume %s\n", run.ID, run.ID)
+			return err
+		}
+		fmt.Printf("\nRun %s is done.\n", run.ID)
+		return nil
+	},
+}
+
+// activeRun is the agent run in progress, so ctrl+c can say how to resume
+// it
+var activeRun atomic.Pointer[agentrun.Run]
+
+// listAgentRuns prints the saved agent runs, latest first
+func listAgentRuns(dir string) error {
+	runs, err := agentrun.List(dir)
+	if err != nil {
+		return err
+	}
+	if len(runs) == 0 {
+		fmt.Println("No agent runs")
+		return nil
+	}
+	for _, r := range runs {
+		done := len(r.Steps)
+		if next := r.Next(); next >= 0 {
+			done = next
+		}
+		task := r.Task
+		if len(task) > 60 {
+			task = task[:57] + "..."
+		}
+		fmt.Printf("%s  %s  %-7s  %d/%d steps  %s\n", r.ID, r.Created.Format("2006-01-02 15:04"), r.Status, done, len(r.Steps), task)
+	}
+	return nil
+}
+
+// writeRunBundle exports an agent run as a reproducibility bundle
+func writeRunBundle(dir, id, output string) error {
+	run, err := agentrun.Load(dir, id)
+	if err != nil {
+		return err
+	}
+	if output == "" {
+		output = "codeforge-run-" + run.ID + ".tar.gz"
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	if err := run.WriteBundle(f); err != nil {
+		f.Close()
+		os.Remove(output)
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Wrote run %s to %s\n", run.ID, output)
+	return nil
+}
+
+func init() {
+	agentCmd.Flags().String("resume", "", "Continue the agent run with this ID (or ID prefix)")
+	agentCmd.Flags().Bool("force", false, "Resume even though files changed since the run stopped")
+	agentCmd.Flags().Bool("list", false, "List saved agent runs")
+	agentCmd.Flags().String("bundle", "", "Export the agent run with this ID (or ID prefix) as a tarball for auditing")
+	agentCmd.Flags().StringP("output", "o", "", "File to write the --bundle tarball to (default: codeforge-run-<id>.tar.gz)")
+	agentCmd.Flags().StringP("model", "m", "", "Model that plans and carries out the task (default: the default model, or the run's)")
+
+	rootCmd.AddCommand(agentCmd)
+}
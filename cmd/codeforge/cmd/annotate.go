@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/annotate"
+	"github.com/entrepeneur4lyf/codeforge/internal/chat"
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+	"github.com/entrepeneur4lyf/codeforge/internal/fileedit"
+	"github.com/entrepeneur4lyf/codeforge/internal/lsp"
+	"github.com/spf13/cobra"
+)
+
+// annotateCmd writes doc comments for the exported symbols of packages
+var annotateCmd = &cobra.Command{
+	Use:   "annotate [packages]",
+	Short: "Write doc comments for exported symbols that lack them",
+	Long: `Find the exported functions, methods, types, constants and variables without
+doc comments in the packages given (default: the current one; ./... for all
+below it), and have the model write them. Signatures come from the Go
+language server when one is configured, so the comments match what the code
+declares, and from the source otherwise.
+
+The comments are written in batches. Each batch is shown as a patch and
+applied only when you approve it; the files it changes are backed up first,
+as in the chat. --yes applies every batch without asking, and --dry-run only
+prints the patches, for git apply.
+
+Styles:
+  godoc  Go doc comments: sentences starting with the symbol's name
+  brief  One sentence starting with the symbol's name
+
+Examples:
+  codeforge annotate ./internal/api --style godoc
+  codeforge annotate ./internal/... --batch 5
+  codeforge annotate ./internal/api --dry-run > docs.patch`,
+	Args: cobra.ArbitraryArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		style, _ := cmd.Flags().GetString("style")
+		batchSize, _ := cmd.Flags().GetInt("batch")
+		yes, _ := cmd.Flags().GetBool("yes")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		annotateModel, _ := cmd.Flags().GetString("model")
+
+		cfg := config.Get()
+		if cfg == nil {
+			return fmt.Errorf("configuration not loaded")
+		}
+		if _, ok := annotate.Styles[style]; !ok {
+			return fmt.Errorf("unknown style %q; use one of %s", style, strings.Join(annotate.StyleNames(), ", "))
+		}
+		if batchSize < 1 {
+			return fmt.Errorf("--batch must be at least 1")
+		}
+		if len(args) == 0 {
+			args = []string{"."}
+		}
+
+		symbols, err := annotate.Find(workingDir, args)
+		if err != nil {
+			return err
+		}
+		if len(symbols) == 0 {
+			fmt.Fprintln(os.Stderr, "Every exported symbol is documented.")
+			return nil
+		}
+		fmt.Fprintf(os.Stderr, "%d exported symbols lack doc comments.\n", len(symbols))
+
+		ctx := context.Background()
+		if ls := goLanguageServer(ctx, cfg); ls != nil {
+			annotate.Signatures(ctx, ls, workingDir, symbols)
+		} else {
+			fmt.Fprintln(os.Stderr, "No Go language server is ready; signatures are read from the source.")
+		}
+
+		if annotateModel == "" {
+			annotateModel = chat.GetDefaultModel()
+		}
+		handler, err := chat.NewHandlerForModel(annotateModel, chat.GetAPIKeyForModel(annotateModel), "")
+		if err != nil {
+			return err
+		}
+
+		scanner := bufio.NewScanner(os.Stdin)
+		batches := (len(symbols) + batchSize - 1) / batchSize
+		applied := 0
+		for i := 0; i < batches; i++ {
+			batch := symbols[i*batchSize : min((i+1)*batchSize, len(symbols))]
+			fmt.Fprintf(os.Stderr, "\nBatch %d of %d: documenting %d symbols...\n", i+1, batches, len(batch))
+			docs, err := annotate.Generate(ctx, handler, style, batch)
+			if err != nil {
+				return fmt.Errorf("batch %d: %w", i+1, err)
+			}
+			changes, err := annotate.Plan(workingDir, docs)
+			if err != nil {
+				return fmt.Errorf("batch %d: %w", i+1, err)
+			}
+			if len(changes) == 0 {
+				fmt.Fprintln(os.Stderr, "The model wrote no comments for this batch.")
+				continue
+			}
+			for _, c := range changes {
+				fmt.Print(c.Diff())
+			}
+			if dryRun {
+				continue
+			}
+
+			if !yes {
+				fmt.Fprintf(os.Stderr, "\nApply %d comments to %d files? [y/N/q] ", len(docs), len(changes))
+				if !scanner.Scan() {
+					break
+				}
+				answer := strings.ToLower(strings.TrimSpace(scanner.Text()))
+				if answer == "q" || answer == "quit" {
+					break
+				}
+				if answer != "y" && answer != "yes" {
+					continue
+				}
+			}
+			backups, err := fileedit.Apply(workingDir, changes, cfg.EditBackupDir())
+			if err != nil {
+				return fmt.Errorf("batch %d: %w", i+1, err)
+			}
+			applied += len(docs)
+			if backups != "" {
+				fmt.Fprintf(os.Stderr, "Applied. The originals are in %s\n", backups)
+			}
+		}
+
+		if !dryRun {
+			fmt.Fprintf(os.Stderr, "\nAdded %d doc comments.\n", applied)
+		}
+		return nil
+	},
+}
+
+// goLanguageServer returns the configured Go language server once it's
+// ready, or nil when there's none or it doesn't start in time
+func goLanguageServer(ctx context.Context, cfg *config.Config) *lsp.Client {
+	if _, ok := cfg.LSP["go"]; !ok {
+		return nil
+	}
+	manager := lsp.GetManager()
+	if manager == nil {
+		return nil
+	}
+	deadline := time.Now().Add(30 * time.Second)
+	for {
+		if client := manager.GetClientForLanguage("go"); client != nil {
+			return client
+		}
+		if time.Now().After(deadline) || ctx.Err() != nil {
+			return nil
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+}
+
+func init() {
+	annotateCmd.Flags().String("style", "godoc", "Comment style: "+strings.Join(annotate.StyleNames(), ", "))
+	annotateCmd.Flags().Int("batch", 10, "Symbols documented per batch")
+	annotateCmd.Flags().BoolP("yes", "y", false, "Apply every batch without asking")
+	annotateCmd.Flags().Bool("dry-run", false, "Print the patches without applying them")
+	annotateCmd.Flags().StringP("model", "m", "", "Model that writes the comments (default: the default model)")
+
+	rootCmd.AddCommand(annotateCmd)
+}
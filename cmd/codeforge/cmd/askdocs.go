@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/chat"
+	"github.com/entrepeneur4lyf/codeforge/internal/docs"
+	"github.com/entrepeneur4lyf/codeforge/internal/embeddings"
+	"github.com/entrepeneur4lyf/codeforge/internal/vectordb"
+	"github.com/spf13/cobra"
+)
+
+// askDocsCmd answers questions from the documentation knowledge base only
+var askDocsCmd = &cobra.Command{
+	Use:   "ask-docs [question]",
+	Short: "Answer a question from the project's documentation only",
+	Long: `Answer a question, such as "how does our deploy process work", from the
+ingested documentation alone. Code is never searched, and every answer lists
+the documents and headings it cites; when the documentation doesn't cover the
+question, the answer says so instead of guessing.
+
+Run with --ingest first, and again after the documentation changes, to add the
+files and directories in docs.paths (docs and README.md by default) to the
+knowledge base. --path ingests other paths instead.`,
+	Args: cobra.ArbitraryArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ingest, _ := cmd.Flags().GetBool("ingest")
+		paths, _ := cmd.Flags().GetStringArray("path")
+		limit, _ := cmd.Flags().GetInt("limit")
+		model, _ := cmd.Flags().GetString("model")
+
+		question := strings.TrimSpace(strings.Join(args, " "))
+		if question == "" && !ingest {
+			return fmt.Errorf("ask a question, or use --ingest to add documentation")
+		}
+		if codeforgeApp == nil {
+			return fmt.Errorf("application not initialized")
+		}
+		vdb := vectordb.Get()
+		if vdb == nil {
+			return fmt.Errorf("vector database not available")
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		if ingest {
+			if len(paths) == 0 {
+				paths = codeforgeApp.Config.Docs.Paths
+			}
+			report, err := docs.Ingest(ctx, vdb, embeddings.GetEmbedding, workingDir, paths)
+			if err != nil {
+				return fmt.Errorf("failed to ingest documentation: %w", err)
+			}
+			fmt.Fprintf(os.Stderr, "Ingested %d sections from %d documents\n", report.Sections, report.Files)
+			if question == "" {
+				return nil
+			}
+		}
+
+		sources, err := docs.Retrieve(ctx, vdb, embeddings.GetEmbedding, question, limit)
+		if err != nil {
+			return err
+		}
+		if len(sources) == 0 {
+			fmt.Println(docs.NotCovered)
+			fmt.Fprintln(os.Stderr, "No ingested documentation matches the question; run with --ingest if it hasn't been added.")
+			return nil
+		}
+
+		if model == "" {
+			model = chat.GetDefaultModel()
+		}
+		handler, err := chat.NewHandlerForModel(model, chat.GetAPIKeyForModel(model), "")
+		if err != nil {
+			return err
+		}
+		answer, err := docs.Answer(ctx, handler, question, sources)
+		if err != nil {
+			return err
+		}
+		fmt.Println(answer)
+		if answer == docs.NotCovered {
+			return nil
+		}
+
+		// Citations are mandatory, so an answer without any is flagged
+		cited := docs.Cited(answer, sources)
+		if len(cited) == 0 {
+			fmt.Println("\nWarning: the answer cites no sources; check it against the sections searched:")
+			cited = sources
+		} else {
+			fmt.Println("\nSources:")
+		}
+		for _, s := range cited {
+			fmt.Printf("  [%d] %s (lines %d-%d)\n", s.N, s.Section.Cite(), s.Section.StartLine, s.Section.EndLine)
+		}
+		return nil
+	},
+}
+
+func init() {
+	askDocsCmd.Flags().Bool("ingest", false, "Add the documentation in docs.paths to the knowledge base first")
+	askDocsCmd.Flags().StringArray("path", nil, "File or directory to ingest instead of docs.paths (repeatable)")
+	askDocsCmd.Flags().Int("limit", 6, "Most documentation sections to answer from")
+	askDocsCmd.Flags().StringP("model", "m", "", "Model that writes the answer (default: the default model)")
+
+	rootCmd.AddCommand(askDocsCmd)
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/bench"
+	"github.com/entrepeneur4lyf/codeforge/internal/vectordb"
+	"github.com/spf13/cobra"
+)
+
+// benchCmd groups the performance benchmarks
+var benchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "Benchmark indexing and search on the current repository",
+}
+
+// benchIndexCmd measures chunking and embedding throughput
+var benchIndexCmd = &cobra.Command{
+	Use:   "index",
+	Short: "Measure chunking throughput and embedding rate",
+	Long: `Chunk every source file in the working directory and embed a sample of the
+chunks with the configured embedding provider, then report throughput and
+embedding latency. Nothing is written to the index.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		maxEmbeds, _ := cmd.Flags().GetInt("embed")
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		if !asJSON {
+			fmt.Fprintf(os.Stderr, "Chunking %s and embedding up to %d chunks...\n", workingDir, maxEmbeds)
+		}
+		report, err := bench.Index(ctx, workingDir, maxEmbeds)
+		if err != nil {
+			return err
+		}
+		return printBenchReport(report, asJSON, report.Write)
+	},
+}
+
+// benchSearchCmd measures search latency against the index
+var benchSearchCmd = &cobra.Command{
+	Use:   "search",
+	Short: "Measure P50/P95 search latency against the index",
+	Long: `Run search queries against the code index and report latency percentiles
+for embedding the query, the vector search and both together. Without
+--query a fixed set of typical code search queries is used.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		queries, _ := cmd.Flags().GetStringArray("query")
+		iterations, _ := cmd.Flags().GetInt("iterations")
+		results, _ := cmd.Flags().GetInt("results")
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		vdb := vectordb.Get()
+		if vdb == nil {
+			return fmt.Errorf("vector database not available")
+		}
+		if len(queries) == 0 {
+			queries = bench.DefaultQueries
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		report, err := bench.Search(ctx, vdb, queries, iterations, results)
+		if err != nil {
+			return err
+		}
+		return printBenchReport(report, asJSON, report.Write)
+	},
+}
+
+// printBenchReport prints a report as JSON or text
+func printBenchReport(report any, asJSON bool, write func(w io.Writer)) error {
+	if !asJSON {
+		write(os.Stdout)
+		return nil
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+func init() {
+	benchIndexCmd.Flags().Int("embed", 200, "Most chunks to embed, sampled across the repository (0 for all)")
+	benchIndexCmd.Flags().Bool("json", false, "Print the report as JSON")
+
+	benchSearchCmd.Flags().StringArrayP("query", "q", nil, "Query to search for (repeatable)")
+	benchSearchCmd.Flags().Int("iterations", 5, "Times to run each query")
+	benchSearchCmd.Flags().Int("results", 10, "Results to request per search")
+	benchSearchCmd.Flags().Bool("json", false, "Print the report as JSON")
+
+	benchCmd.AddCommand(benchIndexCmd)
+	benchCmd.AddCommand(benchSearchCmd)
+	rootCmd.AddCommand(benchCmd)
+}
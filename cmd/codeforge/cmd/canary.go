@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+	"github.com/entrepeneur4lyf/codeforge/internal/llm/canary"
+	"github.com/spf13/cobra"
+)
+
+// canaryCmd reports how a canary model compares with the baseline
+var canaryCmd = &cobra.Command{
+	Use:   "canary",
+	Short: "Compare a candidate model with the current one",
+	Long: `Report the quality signals logged by a canary experiment.
+
+A canary experiment sends a share of requests to a candidate model and logs,
+per model, how many requests failed, how long they took, how often the user
+resent the same message and how responses were rated. Enable one with:
+
+  codeforge config set canary.enabled true
+  codeforge config set canary.candidate openai/gpt-4.1
+  codeforge config set canary.percent 20`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg := config.Get()
+		if cfg == nil {
+			return fmt.Errorf("configuration not loaded")
+		}
+
+		if cfg.Canary.Enabled && cfg.Canary.Candidate != "" {
+			fmt.Printf("Sending %d%% of requests to %s\n", cfg.Canary.Percent, cfg.ResolveModel(cfg.Canary.Candidate))
+		} else {
+			fmt.Println("No canary experiment is running")
+		}
+
+		path := cfg.CanaryLogPath()
+		events, err := canary.ReadEvents(path)
+		if err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			fmt.Printf("No canary events in %s\n", path)
+			return nil
+		}
+
+		fmt.Println()
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ARM\tMODEL\tREQUESTS\tERRORS\tRETRIES\tAVG LATENCY\tRATED UP")
+		for _, s := range canary.Summarize(events) {
+			approval := "-"
+			if a := s.Approval(); a >= 0 {
+				approval = fmt.Sprintf("%.0f%% of %d", a*100, s.ThumbsUp+s.ThumbsDown)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%d\t%.0f%%\t%.0f%%\t%s\t%s\n",
+				s.Arm, s.Model, s.Requests, s.ErrorRate()*100, s.RetryRate()*100, s.AvgLatency(), approval)
+		}
+		return w.Flush()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(canaryCmd)
+}
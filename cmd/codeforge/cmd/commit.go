@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/chat"
+	"github.com/entrepeneur4lyf/codeforge/internal/git"
+	"github.com/spf13/cobra"
+)
+
+// commitCmd commits the staged changes with a message the model writes
+var commitCmd = &cobra.Command{
+	Use:   "commit [paths]",
+	Short: "Commit the staged changes with a message the model writes",
+	Long: `Stage the files given (or every change with --all), have the model write a
+commit message from the staged diff, and commit with it once you approve.
+--yes commits without asking, and --dry-run only prints the message.
+
+Examples:
+  codeforge commit
+  codeforge commit --all
+  codeforge commit internal/api docs/api_usage.md -m gpt-4o
+  codeforge commit --dry-run`,
+	Args: cobra.ArbitraryArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		all, _ := cmd.Flags().GetBool("all")
+		yes, _ := cmd.Flags().GetBool("yes")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		commitModel, _ := cmd.Flags().GetString("model")
+
+		if !git.IsGitInstalled() {
+			return fmt.Errorf("git is not installed")
+		}
+		repo := git.NewRepository(workingDir)
+		if !repo.IsGitRepository() {
+			return fmt.Errorf("%s is not a git repository", workingDir)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+
+		if all || len(args) > 0 {
+			if err := repo.Stage(ctx, args...); err != nil {
+				return err
+			}
+		}
+
+		if commitModel == "" {
+			commitModel = chat.GetDefaultModel()
+		}
+		handler, err := chat.NewHandlerForModel(commitModel, chat.GetAPIKeyForModel(commitModel), "")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stderr, "Writing a commit message for the staged changes...")
+		message, err := git.NewCommitMessageGeneratorFor(handler).GenerateCommitMessage(ctx, repo, true)
+		if err != nil {
+			return err
+		}
+		if dryRun {
+			fmt.Println(message)
+			return nil
+		}
+
+		if !yes {
+			fmt.Printf("\n%s\n\nCommit with this message? [y/N] ", message)
+			scanner := bufio.NewScanner(os.Stdin)
+			if !scanner.Scan() {
+				return nil
+			}
+			if answer := strings.ToLower(strings.TrimSpace(scanner.Text())); answer != "y" && answer != "yes" {
+				fmt.Println("Not committed.")
+				return nil
+			}
+		}
+
+		commit, err := repo.Commit(ctx, message)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Committed %s %s\n", commit.ShortHash, commit.Subject)
+		return nil
+	},
+}
+
+func init() {
+	commitCmd.Flags().BoolP("all", "a", false, "Stage every change first, untracked files included")
+	commitCmd.Flags().BoolP("yes", "y", false, "Commit without asking")
+	commitCmd.Flags().Bool("dry-run", false, "Print the message without committing")
+	commitCmd.Flags().StringP("model", "m", "", "Model that writes the message (default: the default model)")
+
+	rootCmd.AddCommand(commitCmd)
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// configCmd groups commands that read and write configuration
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Read and write configuration",
+	Long: `Read and write CodeForge configuration.
+
+Settings in .codeforge.yaml in the project root override the global config,
+so each repository can have its own defaults:
+
+  codeforge config set model claude-sonnet-4-20250514
+  codeforge config set provider ollama
+
+Model aliases name provider/model pairs and can be used anywhere a model is
+accepted, such as --model:
+
+  codeforge config set modelAliases.smart anthropic/claude-sonnet-4-20250514
+  codeforge -m smart`,
+}
+
+// configSetCmd writes a setting to the project config
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Set a value in the project config",
+	Long: `Set a value in .codeforge.yaml in the project root.
+
+Keys are the dotted names used in the config file, for example "model",
+"provider" or "files.maxFileSize".`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := config.SetProjectValue(args[0], args[1]); err != nil {
+			return err
+		}
+		fmt.Printf("Set %s = %s in %s\n", args[0], args[1], config.ProjectConfigPath())
+		return nil
+	},
+}
+
+// configGetCmd prints the effective value of a setting
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print the effective value of a setting",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !viper.IsSet(args[0]) {
+			return fmt.Errorf("unknown config key %q", args[0])
+		}
+		fmt.Println(viper.Get(args[0]))
+		return nil
+	},
+}
+
+// configValidateCmd checks config files against the schema
+var configValidateCmd = &cobra.Command{
+	Use:   "validate [file...]",
+	Short: "Check config files for mistakes",
+	Long: `Check config files for unknown keys, values of the wrong type and
+deprecated settings, reporting each with its line.
+
+Without arguments the global config and the project's .codeforge.yaml are
+checked.`,
+	// Runs without loading the config, so a broken one can still be checked
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+	RunE: func(cmd *cobra.Command, args []string) error {
+		files := args
+		if len(files) == 0 {
+			files = config.ConfigFiles(workingDir)
+		}
+		if len(files) == 0 {
+			fmt.Println("No config files found")
+			return nil
+		}
+
+		failed := 0
+		for _, issue := range config.ValidateFiles(files...) {
+			fmt.Println(issue)
+			if issue.Severity == config.SeverityError {
+				failed++
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("config has %d errors", failed)
+		}
+		for _, file := range files {
+			fmt.Printf("Checked %s\n", file)
+		}
+		return nil
+	},
+}
+
+// configSchemaCmd prints the JSON schema of the config files
+var configSchemaCmd = &cobra.Command{
+	Use:               "schema",
+	Short:             "Print the JSON schema of the config files",
+	Args:              cobra.NoArgs,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+	RunE: func(cmd *cobra.Command, args []string) error {
+		data, err := json.MarshalIndent(config.Schema(), "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configSchemaCmd)
+	rootCmd.AddCommand(configCmd)
+}
@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var orgRefresh bool
+
+// configOrgCmd shows the organization config in use
+var configOrgCmd = &cobra.Command{
+	Use:   "org",
+	Short: "Show or refresh the shared organization config",
+	Long: `Show the organization config applied below the global and project configs.
+
+An organization publishes a base config, such as approved models, policies
+and session templates, and developers point the global config at it:
+
+  "org": {
+    "url": "https://config.example.com/codeforge.yaml",
+    "publicKey": "<base64 Ed25519 public key>",
+    "refresh": "24h"
+  }
+
+The URL may also name a file in a git repository, as
+git+https://github.com/example/codeforge-config.git#codeforge.yaml. The
+config must be signed: its signature is fetched from the same URL with .sig
+appended and checked against publicKey. Keys listed under org.locked in the
+org config can't be changed by user or project configs.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if orgRefresh {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			if err := config.RefreshOrgConfig(ctx); err != nil {
+				return err
+			}
+			fmt.Println("Fetched and verified the org config; it applies from the next start")
+			return nil
+		}
+
+		info := config.OrgStatus()
+		if info.URL == "" {
+			fmt.Println("No org config. Set org.url and org.publicKey in the global config to use one.")
+			return nil
+		}
+		fmt.Printf("URL:     %s\n", info.URL)
+		if !info.Applied {
+			fmt.Println("Status:  not applied, see the warnings above")
+			return nil
+		}
+		fmt.Printf("Status:  verified, fetched %s\n", info.FetchedAt.Format(time.RFC1123))
+		if len(info.Locked) > 0 {
+			fmt.Printf("Locked:  %s\n", strings.Join(info.Locked, ", "))
+		}
+		return nil
+	},
+}
+
+// configOrgKeygenCmd creates a signing key pair for an org config
+var configOrgKeygenCmd = &cobra.Command{
+	Use:   "keygen <private-key-file>",
+	Short: "Create a key pair for signing an org config",
+	Long: `Create an Ed25519 key pair. The private key is written to the file, for
+signing with codeforge config org sign; the public key is printed, for
+developers' org.publicKey.`,
+	Args:              cobra.ExactArgs(1),
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+	RunE: func(cmd *cobra.Command, args []string) error {
+		public, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return err
+		}
+		encoded := base64.StdEncoding.EncodeToString(private) + "\n"
+		// O_EXCL keeps an existing key from being replaced by accident
+		f, err := os.OpenFile(args[0], os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err != nil {
+			return err
+		}
+		if _, err := f.WriteString(encoded); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		fmt.Printf("Wrote the private key to %s; keep it secret.\n", args[0])
+		fmt.Printf("Public key for org.publicKey:\n%s\n", base64.StdEncoding.EncodeToString(public))
+		return nil
+	},
+}
+
+var orgSignKey string
+
+// configOrgSignCmd signs an org config
+var configOrgSignCmd = &cobra.Command{
+	Use:               "sign <config-file>",
+	Short:             "Sign an org config, writing <config-file>.sig",
+	Args:              cobra.ExactArgs(1),
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+	RunE: func(cmd *cobra.Command, args []string) error {
+		encoded, err := os.ReadFile(orgSignKey)
+		if err != nil {
+			return err
+		}
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+		if err != nil || len(key) != ed25519.PrivateKeySize {
+			return fmt.Errorf("%s isn't a key from codeforge config org keygen", orgSignKey)
+		}
+
+		doc, err := os.ReadFile(args[0])
+		if err != nil {
+			return err
+		}
+		failed := false
+		for _, issue := range config.ValidateFiles(args[0]) {
+			fmt.Println(issue)
+			failed = failed || issue.Severity == config.SeverityError
+		}
+		if failed {
+			return fmt.Errorf("fix the config before signing it")
+		}
+
+		sigPath := args[0] + ".sig"
+		if err := os.WriteFile(sigPath, config.SignOrgConfig(doc, ed25519.PrivateKey(key)), 0o644); err != nil {
+			return err
+		}
+		fmt.Printf("Wrote %s; publish it next to %s\n", sigPath, args[0])
+		return nil
+	},
+}
+
+func init() {
+	configOrgCmd.Flags().BoolVar(&orgRefresh, "refresh", false, "Fetch the org config now instead of waiting for org.refresh")
+	configOrgSignCmd.Flags().StringVar(&orgSignKey, "key", "", "Private key file from codeforge config org keygen")
+	configOrgSignCmd.MarkFlagRequired("key")
+	configOrgCmd.AddCommand(configOrgKeygenCmd)
+	configOrgCmd.AddCommand(configOrgSignCmd)
+	configCmd.AddCommand(configOrgCmd)
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/llm/providers"
+	"github.com/spf13/cobra"
+)
+
+// copilotCmd groups GitHub Copilot account commands
+var copilotCmd = &cobra.Command{
+	Use:   "copilot",
+	Short: "Use a GitHub Copilot subscription",
+	Long: `Use the models included in a GitHub Copilot subscription.
+
+Log in once with "codeforge copilot login", then select copilot/ models,
+for example:
+
+  codeforge --model copilot/gpt-4o`,
+}
+
+// copilotLoginCmd authorizes CodeForge with the GitHub device flow
+var copilotLoginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "Log in to GitHub Copilot",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		auth := providers.NewCopilotAuth("")
+		code, err := auth.RequestDeviceCode(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Open %s and enter the code %s\n", code.VerificationURI, code.UserCode)
+		fmt.Println("Waiting for authorization...")
+
+		token, err := auth.PollAccessToken(ctx, code)
+		if err != nil {
+			return err
+		}
+
+		// Fail now rather than on the first message if there is no seat
+		if _, _, err := auth.APIToken(ctx); err != nil {
+			return err
+		}
+		if err := providers.SaveCopilotToken(token); err != nil {
+			return fmt.Errorf("failed to save token: %w", err)
+		}
+		fmt.Println("Logged in to GitHub Copilot")
+		return nil
+	},
+}
+
+// copilotLogoutCmd removes the stored token
+var copilotLogoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Remove the stored GitHub Copilot login",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := providers.DeleteCopilotToken(); err != nil {
+			return err
+		}
+		fmt.Println("Logged out of GitHub Copilot")
+		return nil
+	},
+}
+
+func init() {
+	copilotCmd.AddCommand(copilotLoginCmd)
+	copilotCmd.AddCommand(copilotLogoutCmd)
+	rootCmd.AddCommand(copilotCmd)
+}
@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/chat"
+	"github.com/entrepeneur4lyf/codeforge/internal/daemon"
+	"github.com/entrepeneur4lyf/codeforge/internal/embeddings"
+	"github.com/entrepeneur4lyf/codeforge/internal/ml"
+	"github.com/spf13/cobra"
+)
+
+// noDaemon keeps a one-shot prompt in this process even when a daemon runs
+var noDaemon bool
+
+// daemonClient is set when a one-shot prompt is handed to the workspace's
+// daemon instead of being answered here
+var daemonClient *daemon.Client
+
+// daemonCmd keeps indexes, language servers and provider clients warm for
+// one-shot prompts
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Keep CodeForge warm so one-shot prompts start instantly",
+	Long: `Run CodeForge in the background for the working directory, keeping its code
+index, language servers, ML context and provider clients ready.
+
+While it runs, one-shot prompts in the same directory (codeforge "question"
+or piped input) are answered by the daemon over a unix socket under
+~/.codeforge/daemon, skipping start-up entirely. The TUI, interactive chat
+and prompts given --template, --session, --resume, --env, --build-command,
+--local-only, --ephemeral, --wire-log or --no-daemon run as usual.
+
+The daemon answers with the API keys and config it was started with;
+restart it after changing them.
+
+Examples:
+  codeforge daemon &
+  codeforge daemon status
+  codeforge daemon stop`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := daemon.SocketPath(workingDir)
+		if err != nil {
+			return err
+		}
+
+		// Open what prompts use now rather than on the first of them
+		embeddings.Get()
+		ml.GetService()
+
+		server := &daemon.Server{
+			Workspace: codeforgeApp.WorkspaceRoot,
+			Handler:   answerDaemonPrompt,
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		fmt.Printf("CodeForge daemon for %s listening on %s\n", server.Workspace, path)
+		return server.Serve(ctx, path)
+	},
+}
+
+// daemonStatusCmd shows whether a daemon runs for the working directory
+var daemonStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether a daemon is running for the working directory",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := dialDaemon()
+		if err != nil {
+			fmt.Println("No daemon is running for this directory")
+			return nil
+		}
+		status, err := client.Status(cmd.Context())
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Daemon running for %s\n", status.Workspace)
+		fmt.Printf("  PID:     %d\n", status.PID)
+		fmt.Printf("  Up:      %s\n", time.Since(status.Started).Round(time.Second))
+		fmt.Printf("  Prompts: %d\n", status.Prompts)
+		return nil
+	},
+}
+
+// daemonStopCmd stops the working directory's daemon
+var daemonStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the daemon running for the working directory",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := dialDaemon()
+		if err != nil {
+			return fmt.Errorf("no daemon is running for this directory")
+		}
+		if err := client.Stop(cmd.Context()); err != nil {
+			return err
+		}
+		fmt.Println("Daemon stopped")
+		return nil
+	},
+}
+
+// dialDaemon connects to the daemon for the working directory
+func dialDaemon() (*daemon.Client, error) {
+	path, err := daemon.SocketPath(workingDir)
+	if err != nil {
+		return nil, err
+	}
+	return daemon.Dial(path)
+}
+
+// canUseDaemon reports whether a command is a one-shot prompt the daemon
+// answers the same as this process would: one given no flags that change
+// how the session is set up
+func canUseDaemon(cmd *cobra.Command, args []string) bool {
+	if cmd.HasParent() || noDaemon {
+		return false
+	}
+	if tuiMode || tuiScript != "" || recordTo != "" || tmplName != "" || sessionID != "" || resume {
+		return false
+	}
+	if len(sessionEnv) > 0 || buildCommand != "" || localOnly || ephemeral || wireLog {
+		return false
+	}
+	return len(args) > 0 || hasStdinInput()
+}
+
+// answerDaemonPrompt answers a prompt handed over by the CLI as the CLI
+// answers one-shot prompts itself
+func answerDaemonPrompt(ctx context.Context, req daemon.Request) (string, error) {
+	modelID := req.Model
+	if modelID != "" {
+		if routed, ok := chat.RouteOffline(modelID); ok {
+			log.Printf("Offline: routing %s to local model %s", modelID, routed)
+			modelID = routed
+		}
+	}
+	return codeforgeApp.ProcessChatMessage(ctx, "cli-session", req.Prompt, modelID)
+}
+
+// promptThroughDaemon hands the prompt in args, or else on stdin, to the
+// daemon and prints the answer
+func promptThroughDaemon(args []string) {
+	prompt := strings.Join(args, " ")
+	if len(args) == 0 {
+		input, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Printf("Error reading stdin: %v\n", err)
+			return
+		}
+		prompt = strings.TrimRight(string(input), "\n")
+		if prompt == "" {
+			fmt.Println("No input received from stdin")
+			return
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	response, err := daemonClient.Prompt(ctx, prompt, model)
+	if err != nil {
+		if quiet {
+			fmt.Printf("Error: %v\n", err)
+		} else {
+			fmt.Printf("Error processing message: %v\n", err)
+		}
+		return
+	}
+	fmt.Println(response)
+}
+
+func init() {
+	rootCmd.Flags().BoolVar(&noDaemon, "no-daemon", false, "Answer here even when a daemon is running for the working directory")
+
+	daemonCmd.AddCommand(daemonStatusCmd)
+	daemonCmd.AddCommand(daemonStopCmd)
+	rootCmd.AddCommand(daemonCmd)
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/atrest"
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+	"github.com/entrepeneur4lyf/codeforge/internal/platform"
+	"github.com/entrepeneur4lyf/codeforge/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+// encryptionCmd manages at-rest encryption of the chat and vector databases
+var encryptionCmd = &cobra.Command{
+	Use:   "encryption",
+	Short: "Manage encryption of stored conversations and embeddings",
+	Long: `Encrypt stored messages, session titles and code embeddings.
+
+Each database gets a random data key, stored next to it wrapped by a master
+key taken from CODEFORGE_ENCRYPTION_KEY or the OS keychain. File paths and
+content hashes stay readable so indexing still works. Data saved before
+encryption was enabled stays unencrypted until it is written again.`,
+}
+
+var encryptionInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Generate a master key and store it in the keychain",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if _, err := atrest.MasterKey(cmd.Context(), "auto"); err == nil {
+			return fmt.Errorf("an encryption key already exists; losing it makes encrypted data unreadable, so it isn't replaced")
+		}
+
+		key, err := atrest.GenerateKey()
+		if err != nil {
+			return err
+		}
+
+		if err := platform.KeychainSet(cmd.Context(), atrest.KeychainService, atrest.KeychainAccount, key); err != nil {
+			fmt.Printf("Could not save the key in the keychain: %v\n", err)
+			fmt.Println("Keep this key somewhere safe and export it before running codeforge:")
+			fmt.Println()
+			fmt.Printf("  export %s=%s\n", atrest.EnvKey, key)
+		} else {
+			fmt.Println("Saved a new encryption key in the keychain")
+		}
+
+		fmt.Println()
+		fmt.Println("Turn encryption on with:")
+		fmt.Println()
+		fmt.Println("  codeforge config set encryption.enabled true")
+		return nil
+	},
+}
+
+var encryptionStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether stored data is encrypted",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg := config.Get()
+		if cfg == nil {
+			return fmt.Errorf("configuration not loaded")
+		}
+
+		if cfg.Encryption.Enabled {
+			fmt.Printf("Encryption: enabled (key source: %s)\n", cfg.Encryption.KeySource)
+		} else {
+			fmt.Println("Encryption: disabled")
+		}
+		if _, err := atrest.MasterKey(cmd.Context(), cfg.Encryption.KeySource); err != nil {
+			fmt.Println("Master key: not found")
+		} else {
+			fmt.Println("Master key: found")
+		}
+
+		databases := []string{filepath.Join(cfg.Data.Directory, "vectors.db")}
+		if chatDB, err := storage.NewPathManager().GetChatDatabasePath(); err == nil {
+			databases = append([]string{chatDB}, databases...)
+		}
+		for _, db := range databases {
+			state := "no data key yet"
+			if _, err := os.Stat(atrest.KeyPath(db)); err == nil {
+				state = "data key " + atrest.KeyPath(db)
+			}
+			fmt.Printf("  %s: %s\n", db, state)
+		}
+		return nil
+	},
+}
+
+func init() {
+	encryptionCmd.AddCommand(encryptionInitCmd)
+	encryptionCmd.AddCommand(encryptionStatusCmd)
+	rootCmd.AddCommand(encryptionCmd)
+}
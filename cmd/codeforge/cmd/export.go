@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/storage"
+	"github.com/entrepeneur4lyf/codeforge/internal/transcript"
+	"github.com/spf13/cobra"
+)
+
+// exportPageSize is how many messages are loaded from the store at a time
+const exportPageSize = 200
+
+// exportCmd writes a saved chat session as Markdown, HTML or JSON
+var exportCmd = &cobra.Command{
+	Use:   "export [session-id]",
+	Short: "Export a chat session",
+	Long: `Export a saved chat session for sharing, with its tool calls and what each
+response cost.
+
+With no argument the most recent session is exported. Use --list to see the
+saved sessions. --format html writes a standalone page with highlighted code
+and collapsible tool calls; markdown and json are also supported.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		list, _ := cmd.Flags().GetBool("list")
+		format, _ := cmd.Flags().GetString("format")
+		output, _ := cmd.Flags().GetString("output")
+
+		if codeforgeApp == nil {
+			return fmt.Errorf("application not initialized")
+		}
+		ctx := context.Background()
+
+		if list {
+			sessions, err := codeforgeApp.GetChatSessions(ctx, "", 50, 0)
+			if err != nil {
+				return fmt.Errorf("failed to list sessions: %w", err)
+			}
+			if len(sessions) == 0 {
+				fmt.Println("No saved sessions")
+				return nil
+			}
+			for _, s := range sessions {
+				fmt.Printf("%s  %s  %3d messages  %s\n", s.ID, s.UpdatedAt.Local().Format("2006-01-02 15:04"), s.MessageCount, s.Title)
+			}
+			return nil
+		}
+
+		var sessionID string
+		if len(args) == 1 {
+			sessionID = args[0]
+		} else {
+			sessions, err := codeforgeApp.GetChatSessions(ctx, "", 1, 0)
+			if err != nil {
+				return fmt.Errorf("failed to list sessions: %w", err)
+			}
+			if len(sessions) == 0 {
+				return fmt.Errorf("no saved sessions to export")
+			}
+			sessionID = sessions[0].ID
+		}
+
+		t, err := loadTranscript(ctx, sessionID)
+		if err != nil {
+			return err
+		}
+
+		if output == "" || output == "-" {
+			return transcript.Write(os.Stdout, t, strings.ToLower(format))
+		}
+		f, err := os.Create(output)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", output, err)
+		}
+		if err := transcript.Write(f, t, strings.ToLower(format)); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Exported %d messages to %s\n", len(t.Messages), output)
+		return nil
+	},
+}
+
+// loadTranscript reads a session and all of its messages from the chat store
+func loadTranscript(ctx context.Context, sessionID string) (transcript.Transcript, error) {
+	session, err := codeforgeApp.GetChatSession(ctx, sessionID)
+	if err != nil {
+		return transcript.Transcript{}, fmt.Errorf("failed to load session %s: %w", sessionID, err)
+	}
+
+	t := transcript.Transcript{Session: session}
+	for offset := 0; ; {
+		batch, err := codeforgeApp.GetChatMessages(ctx, sessionID, exportPageSize, offset)
+		if err != nil {
+			return transcript.Transcript{}, fmt.Errorf("failed to load messages: %w", err)
+		}
+		t.Messages = append(t.Messages, batch.Messages...)
+		if !batch.HasMore || len(batch.Messages) == 0 {
+			break
+		}
+		offset += len(batch.Messages)
+	}
+	if t.Messages == nil {
+		t.Messages = []storage.Message{}
+	}
+	return t, nil
+}
+
+func init() {
+	exportCmd.Flags().StringP("format", "f", transcript.FormatMarkdown, "Export format: "+strings.Join(transcript.Formats, ", "))
+	exportCmd.Flags().StringP("output", "o", "", "Write to a file instead of stdout")
+	exportCmd.Flags().Bool("list", false, "List saved sessions, most recent first")
+
+	rootCmd.AddCommand(exportCmd)
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/embeddings"
+	"github.com/entrepeneur4lyf/codeforge/internal/indexer"
+	"github.com/entrepeneur4lyf/codeforge/internal/vectordb"
+	"github.com/spf13/cobra"
+)
+
+// indexCmd builds the code index of the working directory
+var indexCmd = &cobra.Command{
+	Use:   "index",
+	Short: "Index the working directory for code search",
+	Long: `Walk the working directory, split each source file into chunks along its
+functions and types, embed the chunks with the configured embedding provider
+and store them in the code index that search and chat context use.
+
+Running it again only redoes what changed. Files with the size and
+modification time they were indexed with are skipped without being read, and
+ones whose content hash still matches aren't chunked again. In changed files,
+only the chunks whose content changed are embedded again. Files deleted since
+the last run are removed from the index. --force reindexes and embeds
+everything. Files excluded by files.exclude and the usual build and
+dependency directories are left out.
+
+--watch keeps the index fresh after the first pass: changed files are
+reindexed and deleted ones removed until ctrl+c.
+
+Examples:
+  codeforge index
+  codeforge index --watch`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		force, _ := cmd.Flags().GetBool("force")
+		watchFiles, _ := cmd.Flags().GetBool("watch")
+		debounce, _ := cmd.Flags().GetDuration("debounce")
+
+		vdb := vectordb.Get()
+		if vdb == nil {
+			return fmt.Errorf("vector database not available")
+		}
+		ix := indexer.New(workingDir, vdb, embeddings.GetCodeEmbedding)
+		ix.Force = force
+		ix.Progress = func(done, total int, file string) {
+			fmt.Fprintf(os.Stderr, "\r\033[K[%d/%d] %s", done, total, file)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		provider, dimensions, _ := embeddings.GetCurrentProvider()
+		fmt.Fprintf(os.Stderr, "Indexing %s with %s embeddings (%d dimensions)\n", workingDir, provider, dimensions)
+		report, err := ix.Index(ctx)
+		fmt.Fprint(os.Stderr, "\r\033[K")
+		if err != nil {
+			return err
+		}
+		printIndexReport(report)
+		if report.Files > 0 && report.Chunks == 0 && report.EmbedFailures > 0 {
+			return fmt.Errorf("no chunks could be embedded: %s", report.EmbedError)
+		}
+		if !watchFiles {
+			return nil
+		}
+
+		fmt.Fprintln(os.Stderr, "Watching for changes; press ctrl+c to stop.")
+		ix.Progress = nil
+		return ix.Watch(ctx, debounce, func(report *indexer.Report, err error) {
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Index error: %v\n", err)
+			}
+			if report != nil {
+				printIndexReport(report)
+			}
+		})
+	},
+}
+
+// printIndexReport prints what a run of the indexer did
+func printIndexReport(r *indexer.Report) {
+	fmt.Fprintf(os.Stderr, "%s  indexed %d files (%d chunks", time.Now().Format("15:04:05"), r.Files, r.Chunks)
+	if r.Reused > 0 {
+		fmt.Fprintf(os.Stderr, ", %d of them unchanged", r.Reused)
+	}
+	fmt.Fprint(os.Stderr, ")")
+	if r.Skipped > 0 {
+		fmt.Fprintf(os.Stderr, ", %d unchanged files skipped", r.Skipped)
+	}
+	if r.Removed > 0 {
+		fmt.Fprintf(os.Stderr, ", removed %d", r.Removed)
+	}
+	fmt.Fprintf(os.Stderr, " in %s\n", r.Duration.Round(time.Millisecond))
+	if r.EmbedFailures > 0 {
+		fmt.Fprintf(os.Stderr, "%d chunks couldn't be embedded and were left out: %s\n", r.EmbedFailures, r.EmbedError)
+	}
+}
+
+func init() {
+	indexCmd.Flags().Bool("force", false, "Reindex and embed files and chunks even if they haven't changed")
+	indexCmd.Flags().Bool("watch", false, "Keep reindexing files as they change")
+	indexCmd.Flags().Duration("debounce", indexer.DefaultDebounce, "How long files must stay unchanged before they're reindexed")
+
+	rootCmd.AddCommand(indexCmd)
+}
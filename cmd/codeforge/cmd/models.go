@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/llm/providers"
+	"github.com/spf13/cobra"
+)
+
+// catalogEntry is a single model in the models listing
+type catalogEntry struct {
+	Provider string `json:"provider"`
+	ID       string `json:"id"`
+	Name     string `json:"name,omitempty"`
+}
+
+// providerCatalog is one provider's section in the models listing
+type providerCatalog struct {
+	Provider string         `json:"provider"`
+	Models   []catalogEntry `json:"models"`
+	Stale    bool           `json:"stale"`
+	Banner   string         `json:"banner,omitempty"`
+	Error    string         `json:"error,omitempty"`
+}
+
+// modelsCmd lists model catalogs for configured providers
+var modelsCmd = &cobra.Command{
+	Use:   "models",
+	Short: "List available models",
+	Long: `List the model catalogs of configured providers and locally installed Ollama models.
+
+When providers are unreachable, the last cached catalogs are shown with a
+staleness banner instead of an empty list.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		providerFilter, _ := cmd.Flags().GetString("provider")
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		var catalogs []providerCatalog
+		for _, name := range []string{"openai", "anthropic", "gemini", "openrouter", "ollama"} {
+			if providerFilter != "" && providerFilter != name {
+				continue
+			}
+			if catalog, ok := loadProviderCatalog(ctx, name); ok {
+				catalogs = append(catalogs, catalog)
+			}
+		}
+
+		if asJSON {
+			data, err := json.MarshalIndent(catalogs, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to encode models: %w", err)
+			}
+			fmt.Println(string(data))
+			return nil
+		}
+
+		if len(catalogs) == 0 {
+			fmt.Println("No providers configured. Set a provider API key or start Ollama.")
+			return nil
+		}
+
+		if !providers.IsOnline() {
+			fmt.Println("⚠ No network connection detected")
+			fmt.Println()
+		}
+
+		for _, catalog := range catalogs {
+			fmt.Printf("%s (%d models)\n", catalog.Provider, len(catalog.Models))
+			if catalog.Banner != "" {
+				fmt.Printf("  ⚠ %s\n", catalog.Banner)
+			}
+			if catalog.Error != "" {
+				fmt.Printf("  error: %s\n", catalog.Error)
+			}
+			for _, m := range catalog.Models {
+				if m.Name != "" && m.Name != m.ID {
+					fmt.Printf("  %-50s %s\n", m.ID, m.Name)
+				} else {
+					fmt.Printf("  %s\n", m.ID)
+				}
+			}
+			fmt.Println()
+		}
+
+		return nil
+	},
+}
+
+// loadProviderCatalog loads a provider's catalog, reporting false when the
+// provider isn't configured
+func loadProviderCatalog(ctx context.Context, provider string) (providerCatalog, bool) {
+	catalog := providerCatalog{Provider: provider}
+
+	switch provider {
+	case "openai":
+		apiKey := os.Getenv("OPENAI_API_KEY")
+		if apiKey == "" {
+			return catalog, false
+		}
+		models, err := providers.GetOpenAIModels(ctx, apiKey)
+		if err != nil {
+			catalog.Error = err.Error()
+		}
+		for _, m := range models {
+			catalog.Models = append(catalog.Models, catalogEntry{Provider: provider, ID: m.ID})
+		}
+	case "anthropic":
+		apiKey := os.Getenv("ANTHROPIC_API_KEY")
+		if apiKey == "" {
+			return catalog, false
+		}
+		models, err := providers.GetAnthropicModels(ctx, apiKey)
+		if err != nil {
+			catalog.Error = err.Error()
+		}
+		for _, m := range models {
+			catalog.Models = append(catalog.Models, catalogEntry{Provider: provider, ID: m.ID, Name: m.DisplayName})
+		}
+	case "gemini":
+		apiKey := os.Getenv("GEMINI_API_KEY")
+		if apiKey == "" {
+			return catalog, false
+		}
+		models, err := providers.GetGeminiModels(ctx, apiKey)
+		if err != nil {
+			catalog.Error = err.Error()
+		}
+		for _, m := range models {
+			catalog.Models = append(catalog.Models, catalogEntry{Provider: provider, ID: m.ID, Name: m.DisplayName})
+		}
+	case "openrouter":
+		apiKey := os.Getenv("OPENROUTER_API_KEY")
+		if apiKey == "" {
+			return catalog, false
+		}
+		models, err := providers.GetOpenRouterModels(ctx, apiKey)
+		if err != nil {
+			catalog.Error = err.Error()
+		}
+		for _, m := range models {
+			catalog.Models = append(catalog.Models, catalogEntry{Provider: provider, ID: m.ID, Name: m.Name})
+		}
+	case "ollama":
+		names, err := providers.ListOllamaModels(ctx)
+		if err != nil {
+			return catalog, false
+		}
+		for _, name := range names {
+			catalog.Models = append(catalog.Models, catalogEntry{Provider: provider, ID: "ollama/" + name, Name: name})
+		}
+	}
+
+	if status, ok := providers.GetCatalogStatus(provider); ok && status.Stale {
+		catalog.Stale = true
+		catalog.Banner = status.Banner()
+	}
+
+	return catalog, true
+}
+
+func init() {
+	modelsCmd.Flags().StringP("provider", "p", "", "Only list models for this provider")
+	modelsCmd.Flags().Bool("json", false, "Output as JSON")
+
+	rootCmd.AddCommand(modelsCmd)
+}
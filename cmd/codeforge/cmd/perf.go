@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/bench"
+	"github.com/entrepeneur4lyf/codeforge/internal/perf"
+	"github.com/spf13/cobra"
+)
+
+// perfCmd groups the profiling tools
+var perfCmd = &cobra.Command{
+	Use:   "perf",
+	Short: "Profile CodeForge for performance triage",
+}
+
+// perfReportCmd groups the workloads a profile can be taken of
+var perfReportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Profile indexing or an agent run and summarize the top costs",
+	Long: `Run a workload while sampling CPU and heap, then list the functions that
+took the most CPU time and allocated the most memory, with the peak heap in
+use. --out keeps cpu.pprof and heap.pprof for a closer look with go tool pprof.
+
+A running API server serves the same profiles to admins under
+/api/v1/debug/pprof/.
+
+Examples:
+  codeforge perf report index
+  codeforge perf report prompt "explain the indexer" --out ./profiles`,
+}
+
+// perfIndexCmd profiles chunking and embedding the working directory
+var perfIndexCmd = &cobra.Command{
+	Use:   "index",
+	Short: "Profile chunking and embedding the working directory",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		maxEmbeds, _ := cmd.Flags().GetInt("embed")
+		return runPerfReport(cmd, "index "+workingDir, func(ctx context.Context) error {
+			_, err := bench.Index(ctx, workingDir, maxEmbeds)
+			return err
+		})
+	},
+}
+
+// perfPromptCmd profiles answering a prompt
+var perfPromptCmd = &cobra.Command{
+	Use:   "prompt <text>",
+	Short: "Profile answering a prompt, tool calls included",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		prompt := strings.Join(args, " ")
+		return runPerfReport(cmd, "prompt", func(ctx context.Context) error {
+			_, err := codeforgeApp.ProcessChatMessage(ctx, "perf-session", prompt, model)
+			return err
+		})
+	},
+}
+
+// runPerfReport profiles task and prints the report
+func runPerfReport(cmd *cobra.Command, name string, task func(ctx context.Context) error) error {
+	top, _ := cmd.Flags().GetInt("top")
+	out, _ := cmd.Flags().GetString("out")
+	asJSON, _ := cmd.Flags().GetBool("json")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if !asJSON {
+		fmt.Fprintf(os.Stderr, "Profiling %s...\n", name)
+	}
+	report, err := perf.Run(ctx, name, perf.Options{Top: top, Dir: out}, task)
+	if err != nil {
+		return err
+	}
+	return printBenchReport(report, asJSON, report.Write)
+}
+
+func init() {
+	perfIndexCmd.Flags().Int("embed", 200, "Most chunks to embed, sampled across the repository (0 for all)")
+
+	perfReportCmd.PersistentFlags().Int("top", 15, "Functions to list for CPU and allocations")
+	perfReportCmd.PersistentFlags().String("out", "", "Directory to save cpu.pprof and heap.pprof in")
+	perfReportCmd.PersistentFlags().Bool("json", false, "Print the report as JSON")
+
+	perfReportCmd.AddCommand(perfIndexCmd)
+	perfReportCmd.AddCommand(perfPromptCmd)
+	perfCmd.AddCommand(perfReportCmd)
+	rootCmd.AddCommand(perfCmd)
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/builder"
+	"github.com/entrepeneur4lyf/codeforge/internal/chat"
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+	"github.com/entrepeneur4lyf/codeforge/internal/refactor"
+	"github.com/spf13/cobra"
+)
+
+// refactorCmd carries out a refactor and checks it with the build and tests
+var refactorCmd = &cobra.Command{
+	Use:   "refactor <goal>",
+	Short: "Refactor the project and iterate until the build and tests pass",
+	Long: `Have the model plan a refactor of the working directory and carry it out:
+symbols are renamed through the Go language server, so every use changes with
+the declaration, files are moved, and the rest is edited as in the chat. The
+files changed are backed up first.
+
+The project is then built and tested. Failures go back to the model with the
+files they point at, and its fixes are made and checked the same way, until
+everything passes or --retries rounds of fixes have been tried. The build and
+test commands are those of the project's language unless --build and --test
+say otherwise; --test "" skips the tests.
+
+When the checks still fail, the changes are left for you to finish, or undone
+with --revert.
+
+Examples:
+  codeforge refactor "extract ChatStorage into its own package"
+  codeforge refactor "rename Server.handleX to handleExport" --retries 5
+  codeforge refactor "split app.go by concern" --test "go test ./internal/..."`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		retries, _ := cmd.Flags().GetInt("retries")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		revert, _ := cmd.Flags().GetBool("revert")
+		refactorModel, _ := cmd.Flags().GetString("model")
+
+		cfg := config.Get()
+		if cfg == nil {
+			return fmt.Errorf("configuration not loaded")
+		}
+		if retries < 0 {
+			return fmt.Errorf("--retries can't be negative")
+		}
+
+		build, test, err := builder.Commands(workingDir)
+		if err != nil && !cmd.Flags().Changed("build") && !cmd.Flags().Changed("test") {
+			return fmt.Errorf("%w; say how to check the refactor with --build and --test", err)
+		}
+		if cmd.Flags().Changed("build") {
+			build, _ = cmd.Flags().GetString("build")
+		}
+		if cmd.Flags().Changed("test") {
+			test, _ = cmd.Flags().GetString("test")
+		}
+		var commands []string
+		for _, command := range []string{build, test} {
+			if strings.TrimSpace(command) != "" {
+				commands = append(commands, command)
+			}
+		}
+
+		if refactorModel == "" {
+			refactorModel = chat.GetDefaultModel()
+		}
+		handler, err := chat.NewHandlerForModel(refactorModel, chat.GetAPIKeyForModel(refactorModel), "")
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		r := &refactor.Refactor{
+			Root:      workingDir,
+			Goal:      strings.Join(args, " "),
+			Handler:   handler,
+			Commands:  commands,
+			Retries:   retries,
+			Timeout:   timeout,
+			BackupDir: cfg.EditBackupDir(),
+			Out:       os.Stdout,
+		}
+		if ls := goLanguageServer(ctx, cfg); ls != nil {
+			r.Server = ls
+		} else {
+			fmt.Fprintln(os.Stderr, "No Go language server is ready; renames are made as edits.")
+		}
+
+		report, err := r.Run(ctx)
+		if err == nil && !report.Passed {
+			err = fmt.Errorf("the checks still fail after %d attempts:\n%s", report.Attempts, report.Failure)
+		}
+		if err != nil {
+			if revert && len(report.Files) > 0 {
+				if rerr := r.Revert(); rerr != nil {
+					return fmt.Errorf("%w\nundoing the changes failed too: %v", err, rerr)
+				}
+				fmt.Fprintf(os.Stderr, "\nUndid the changes to %d files.\n", len(report.Files))
+			} else if len(report.Backups) > 0 {
+				fmt.Fprintf(os.Stderr, "\nThe changes are left in place; the originals are in %s\n", strings.Join(report.Backups, ", "))
+			}
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "\nDone after %d attempts; changed %d files.\n", report.Attempts, len(report.Files))
+		if len(report.Backups) > 0 {
+			fmt.Fprintf(os.Stderr, "The originals are in %s\n", strings.Join(report.Backups, ", "))
+		}
+		return nil
+	},
+}
+
+func init() {
+	refactorCmd.Flags().Int("retries", refactor.DefaultRetries, "Rounds of fixes to try when the build or tests fail")
+	refactorCmd.Flags().String("build", "", "Build command (default: the project language's)")
+	refactorCmd.Flags().String("test", "", "Test command (default: the project language's)")
+	refactorCmd.Flags().Duration("timeout", 10*time.Minute, "Time allowed for each build or test run")
+	refactorCmd.Flags().Bool("revert", false, "Undo every change when the checks still fail")
+	refactorCmd.Flags().StringP("model", "m", "", "Model that plans the refactor (default: the default model)")
+
+	rootCmd.AddCommand(refactorCmd)
+}
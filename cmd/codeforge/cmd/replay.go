@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+	"github.com/entrepeneur4lyf/codeforge/internal/llm/wirelog"
+	"github.com/spf13/cobra"
+)
+
+// replayCmd re-renders a generation recorded in the wire log
+var replayCmd = &cobra.Command{
+	Use:   "replay [log]",
+	Short: "Replay a recorded provider response",
+	Long: `Re-render a generation recorded by the wire log (--wire-log or wireLog.enabled)
+without calling the provider again.
+
+With no argument the most recent log is replayed. Use --list to see the
+available logs and --raw to print each chunk with its timing, which helps
+debug streaming and parsing issues.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		list, _ := cmd.Flags().GetBool("list")
+		raw, _ := cmd.Flags().GetBool("raw")
+		speed, _ := cmd.Flags().GetFloat64("speed")
+		showRequest, _ := cmd.Flags().GetBool("request")
+
+		cfg := config.Get()
+		if cfg == nil {
+			return fmt.Errorf("configuration not loaded")
+		}
+		dir := cfg.WireLogDir()
+
+		logs, err := wirelog.List(dir)
+		if err != nil {
+			return fmt.Errorf("failed to list wire logs: %w", err)
+		}
+
+		if list {
+			if len(logs) == 0 {
+				fmt.Printf("No wire logs in %s\n", dir)
+				return nil
+			}
+			for _, path := range logs {
+				fmt.Println(path)
+			}
+			return nil
+		}
+
+		var path string
+		switch {
+		case len(args) == 1:
+			path = args[0]
+			if _, err := os.Stat(path); os.IsNotExist(err) {
+				path = filepath.Join(dir, args[0])
+			}
+		case len(logs) > 0:
+			path = logs[0]
+		default:
+			return fmt.Errorf("no wire logs in %s; run with --wire-log to record generations", dir)
+		}
+
+		log, err := wirelog.Read(path)
+		if err != nil {
+			return err
+		}
+
+		if log.Request != nil {
+			fmt.Fprintf(os.Stderr, "Replaying %s (%s via %s)\n\n", filepath.Base(path), log.Request.Model, log.Request.Provider)
+			if showRequest {
+				printLoggedRequest(log.Request)
+			}
+		}
+
+		if raw {
+			for _, e := range log.Entries {
+				data, _ := json.Marshal(e.Chunk)
+				fmt.Printf("+%6dms %s\n", e.OffsetMs, data)
+			}
+		} else {
+			renderReplay(log.Replay(context.Background(), speed))
+		}
+
+		if log.Error != "" {
+			fmt.Fprintf(os.Stderr, "\nGeneration failed: %s\n", log.Error)
+		} else if !log.Done {
+			fmt.Fprintln(os.Stderr, "\nGeneration was interrupted before the stream completed")
+		}
+
+		return nil
+	},
+}
+
+// renderReplay prints a replayed stream the way chat renders live responses
+func renderReplay(stream llm.ApiStream) {
+	var usage *llm.ApiStreamUsageChunk
+	var finishReason string
+	for chunk := range stream {
+		switch c := chunk.(type) {
+		case llm.ApiStreamTextChunk:
+			fmt.Print(c.Text)
+		case llm.ApiStreamReasoningChunk:
+			fmt.Printf("\n[Thinking: %s]\n", c.Reasoning)
+		case llm.ApiStreamToolCallDeltaChunk:
+			if c.Name != "" {
+				fmt.Printf("\n[Tool call: %s] ", c.Name)
+			}
+			fmt.Print(c.Arguments)
+		case llm.ApiStreamUsageChunk:
+			usage = &c
+		case llm.ApiStreamFinishChunk:
+			finishReason = c.Reason
+		}
+	}
+
+	if usage != nil {
+		fmt.Printf("\n\nTokens: %d input, %d output", usage.InputTokens, usage.OutputTokens)
+		if usage.TotalCost != nil && *usage.TotalCost > 0 {
+			fmt.Printf(" | Cost: $%.4f", *usage.TotalCost)
+		}
+	}
+	if finishReason != "" {
+		fmt.Printf("\nFinished: %s", finishReason)
+	}
+	fmt.Println()
+}
+
+// printLoggedRequest prints the system prompt and messages of a logged request
+func printLoggedRequest(req *wirelog.Request) {
+	fmt.Println("System prompt:")
+	fmt.Println(req.SystemPrompt)
+	fmt.Println()
+
+	fmt.Println("Messages:")
+	pretty, err := json.MarshalIndent(req.Messages, "", "  ")
+	if err != nil {
+		pretty = req.Messages
+	}
+	fmt.Println(string(pretty))
+	fmt.Println()
+	fmt.Println("Response:")
+}
+
+func init() {
+	replayCmd.Flags().Bool("list", false, "List recorded wire logs, newest first")
+	replayCmd.Flags().Bool("raw", false, "Print each chunk with its offset instead of rendering")
+	replayCmd.Flags().Float64("speed", 0, "Replay speed relative to the original stream (0 = instant)")
+	replayCmd.Flags().Bool("request", false, "Also print the logged request")
+
+	rootCmd.AddCommand(replayCmd)
+}
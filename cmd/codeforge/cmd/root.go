@@ -4,24 +4,30 @@ import (
 	"bufio"
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/entrepeneur4lyf/codeforge/internal/app"
 	"github.com/entrepeneur4lyf/codeforge/internal/chat"
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
 	"github.com/entrepeneur4lyf/codeforge/internal/embeddings"
 	"github.com/entrepeneur4lyf/codeforge/internal/llm"
 	"github.com/entrepeneur4lyf/codeforge/internal/llm/providers"
+	"github.com/entrepeneur4lyf/codeforge/internal/llm/tools"
 	"github.com/entrepeneur4lyf/codeforge/internal/lsp"
 	"github.com/entrepeneur4lyf/codeforge/internal/ml"
+	"github.com/entrepeneur4lyf/codeforge/internal/privacy"
 	"github.com/entrepeneur4lyf/codeforge/internal/project"
 	"github.com/entrepeneur4lyf/codeforge/internal/tui"
+	"github.com/google/uuid"
 	"github.com/spf13/cobra"
 )
 
@@ -31,12 +37,26 @@ var (
 )
 
 var (
-	quiet    bool
-	model    string
-	provider string
-	format   string
-	tuiMode  bool
-	logFile  *os.File // For cleanup
+	quiet     bool
+	model     string
+	provider  string
+	format    string
+	tuiMode   bool
+	tuiScript string
+	playDelay time.Duration
+	recordTo  string
+	tmplName  string
+	localOnly bool
+	wireLog   bool
+	ephemeral bool
+	sessionID string
+	resume    bool
+	noTUI     bool
+	noEmoji   bool
+	logFile   *os.File // For cleanup
+
+	sessionEnv   []string // KEY=value pairs given with --env
+	buildCommand string
 )
 
 // Global app instance for integrated systems
@@ -49,6 +69,12 @@ func setupLogging(workingDir string, debug bool) error {
 		return nil
 	}
 
+	// Logs quote messages, so ephemeral sessions don't keep them
+	if ephemeral {
+		log.SetOutput(io.Discard)
+		return nil
+	}
+
 	// Create .codeforge directory if it doesn't exist
 	logDir := filepath.Join(workingDir, ".codeforge")
 	if err := os.MkdirAll(logDir, 0755); err != nil {
@@ -77,6 +103,13 @@ func cleanupLogging() {
 	}
 }
 
+// scrubEphemeralArtifacts removes files an ephemeral session had to write
+func scrubEphemeralArtifacts() {
+	if err := privacy.ScrubArtifacts(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to remove temporary files: %v\n", err)
+	}
+}
+
 // autoGenerateProjectOverview automatically analyzes existing projects
 func autoGenerateProjectOverview() {
 	if codeforgeApp == nil {
@@ -103,6 +136,12 @@ func animatedSpinner(message string, done chan bool) {
 	frameIndex := 0
 	dotCount := 0
 
+	// Redrawing frames in place confuses screen readers and dumb terminals
+	if chat.PlainOutput() {
+		<-done
+		return
+	}
+
 	ticker := time.NewTicker(100 * time.Millisecond)
 	defer ticker.Stop()
 
@@ -239,56 +278,167 @@ Features:
 	SilenceErrors:     false,
 	Args:              cobra.ArbitraryArgs, // Accept any arguments
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// A daemon running for the working directory answers one-shot
+		// prompts with everything below already started
+		if canUseDaemon(cmd, args) {
+			if client, err := dialDaemon(); err == nil {
+				daemonClient = client
+				return nil
+			}
+		}
+
+		// Plain sequential output for screen readers and dumb terminals
+		if noTUI || chat.PlainTerminal() {
+			if tuiMode && !noTUI {
+				fmt.Println("This terminal can't run the TUI (TERM=dumb); using plain output")
+			}
+			tuiMode = false
+			chat.SetPlainOutput(true, noEmoji)
+		} else if noEmoji {
+			chat.SetPlainOutput(false, true)
+		}
+
+		// Keep conversations and anything derived from them off disk
+		if ephemeral {
+			if wireLog || sessionID != "" || resume {
+				return fmt.Errorf("--ephemeral can't be combined with --wire-log, --session or --resume")
+			}
+			privacy.SetEphemeral(true)
+		}
+
 		// Setup logging to file (unless in debug mode)
 		if err := setupLogging(workingDir, debug); err != nil {
 			return fmt.Errorf("failed to setup logging: %w", err)
 		}
 
-		// Initialize CodeForge application with all integrated systems
-		appConfig := &app.AppConfig{
-			WorkspaceRoot:     workingDir,
-			EnablePermissions: true,
-			EnableContextMgmt: true,
-			Debug:             debug,
-		}
+		// Commands such as tokens and models only need the config
+		var cfg *config.Config
+		if needsApp(cmd) {
+			// Initialize CodeForge application with all integrated systems
+			appConfig := &app.AppConfig{
+				WorkspaceRoot:     workingDir,
+				EnablePermissions: true,
+				EnableContextMgmt: true,
+				Debug:             debug,
+				Ephemeral:         ephemeral,
+			}
 
-		ctx := context.Background()
-		var err error
-		codeforgeApp, err = app.NewApp(ctx, appConfig)
-		if err != nil {
-			return fmt.Errorf("failed to initialize CodeForge app: %w", err)
+			var err error
+			codeforgeApp, err = app.NewApp(context.Background(), appConfig)
+			for _, issue := range config.Issues() {
+				fmt.Fprintf(os.Stderr, "Config %s\n", issue)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to initialize CodeForge app: %w", err)
+			}
+			cfg = codeforgeApp.Config
+		} else {
+			var err error
+			cfg, err = config.Load(workingDir, debug)
+			for _, issue := range config.Issues() {
+				fmt.Fprintf(os.Stderr, "Config %s\n", issue)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
 		}
 
-		// Initialize LLM manager
-		if err := llm.Initialize(codeforgeApp.Config); err != nil {
-			return fmt.Errorf("failed to initialize LLM providers: %w", err)
+		// Icons the terminal can't render are left out of chat output too
+		if cfg.Display.ASCIIOnly {
+			chat.SetPlainOutput(chat.PlainOutput(), true)
 		}
 
-		// Start background model fetching for all providers
-		providers.InitializeBackgroundFetching()
+		// Restrict all model and embedding traffic to local endpoints
+		if localOnly {
+			config.SetLocalOnly(true)
+		}
 
-		// Initialize embedding service
-		if err := embeddings.Initialize(codeforgeApp.Config); err != nil {
-			return fmt.Errorf("failed to initialize embedding service: %w", err)
+		// Log provider requests and responses for replay
+		if wireLog {
+			cfg.WireLog.Enabled = true
 		}
 
-		// Initialize LSP manager
-		if err := lsp.Initialize(codeforgeApp.Config); err != nil {
-			return fmt.Errorf("failed to initialize LSP clients: %w", err)
+		// Initialize LLM manager
+		if err := llm.Initialize(cfg); err != nil {
+			return fmt.Errorf("failed to initialize LLM providers: %w", err)
 		}
 
-		// Initialize ML service silently for model context (graceful degradation if it fails)
-		ml.Initialize(codeforgeApp.Config) // Ignore errors - ML is for model context only
+		// Embeddings, language servers and ML context start when first
+		// used, so one-off prompts and commands don't wait for them
+		embeddings.InitializeOnFirstUse(cfg)
+		if cfg.Embedding.HyDE {
+			embeddings.SetCodeWriter(func(ctx context.Context, query string) (string, error) {
+				return chat.NewCodeWriter(chat.GetDefaultModel())(ctx, query)
+			})
+		}
+		ml.InitializeOnFirstUse(cfg)
+
+		// Sessions that stay open warm up what they'll soon use: model
+		// catalogs (never fetched in local-only mode), LM Studio, Jan and
+		// Ollama servers on this machine and the language servers
+		if longRunning(cmd, args) {
+			if !config.IsLocalOnly() {
+				providers.InitializeBackgroundFetching()
+			}
+			providers.StartLocalDiscovery()
+			if err := lsp.Initialize(cfg); err != nil {
+				return fmt.Errorf("failed to initialize LSP clients: %w", err)
+			}
+		} else {
+			lsp.InitializeOnFirstUse(cfg)
+		}
 
 		// Auto-analyze existing projects (new projects handled by model tool)
-		// Skip in TUI mode to avoid delays
-		if !tuiMode {
+		// before answering a prompt; the TUI, scripts and quiet output skip
+		// it to avoid delays
+		if !cmd.HasParent() && !tuiMode && tuiScript == "" && recordTo == "" && !quiet {
 			autoGenerateProjectOverview()
 		}
 
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
+		if daemonClient != nil {
+			promptThroughDaemon(args)
+			return
+		}
+
+		// Set the session up for a kind of task
+		if tmplName != "" {
+			if err := applyTemplate(tmplName); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		// Continue a saved conversation: the one named, else the latest
+		if resume {
+			if err := resumeSession(args); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			startInteractiveMode()
+			return
+		}
+
+		// Drive the TUI from a script instead of the keyboard
+		if tuiScript != "" {
+			if err := runTUIScript(tuiScript); err != nil {
+				fmt.Printf("Error running TUI script: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		// Save the keys pressed in the TUI as a script
+		if recordTo != "" {
+			if err := recordTUI(recordTo); err != nil {
+				fmt.Printf("Error recording TUI session: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+
 		// Check if TUI mode is requested
 		if tuiMode {
 			fmt.Println("Starting TUI mode...")
@@ -298,7 +448,17 @@ Features:
 			}
 			return
 		}
-		
+
+		// Route explicitly chosen cloud models to a local model when offline
+		if model != "" {
+			if routed, ok := chat.RouteOffline(model); ok {
+				if !quiet {
+					fmt.Printf("Offline: routing %s to local model %s\n", model, routed)
+				}
+				model = routed
+			}
+		}
+
 		// Handle different input modes like Gemini CLI
 		if len(args) > 0 {
 			// Direct prompt mode: codeforge "question"
@@ -326,11 +486,27 @@ func init() {
 	// Add flags for the new CLI pattern
 	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "Enable debug mode")
 	rootCmd.PersistentFlags().StringVar(&workingDir, "wd", wd, "Working directory")
+	rootCmd.PersistentFlags().BoolVar(&localOnly, "local-only", false, "Restrict all LLM and embedding traffic to Ollama/local endpoints")
+	rootCmd.PersistentFlags().BoolVar(&noTUI, "no-tui", false, "Plain sequential output for screen readers and dumb terminals (no full-screen UI or cursor movement)")
+	rootCmd.PersistentFlags().BoolVar(&noEmoji, "no-emoji", false, "Leave emoji out of output")
+	rootCmd.PersistentFlags().BoolVar(&wireLog, "wire-log", false, "Log provider requests and responses (API keys removed) for codeforge replay")
+	rootCmd.PersistentFlags().BoolVar(&ephemeral, "ephemeral", false, "Never save the conversation to disk and remove temporary files on exit")
 	rootCmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "Quiet mode - output only the answer")
 	rootCmd.Flags().StringVarP(&model, "model", "m", "", "Specify the model to use")
 	rootCmd.Flags().StringVarP(&provider, "provider", "p", "", "Specify the provider (anthropic, openai, openrouter, etc.)")
 	rootCmd.Flags().StringVar(&format, "format", "text", "Output format (text, json, markdown)")
 	rootCmd.Flags().BoolVar(&tuiMode, "tui", false, "Start in TUI (Terminal User Interface) mode")
+	rootCmd.Flags().StringVar(&tuiScript, "script", "", "Run the TUI headlessly from a script file (- for stdin), printing its snapshots")
+	rootCmd.Flags().DurationVar(&playDelay, "play", 0, "Replay --script in the real TUI with this pause between keys, for demos")
+	rootCmd.Flags().StringVar(&recordTo, "record", "", "Start the TUI and save the keys pressed to a script file for --script")
+	rootCmd.Flags().StringVar(&tmplName, "template", "", "Start from a session template such as bugfix, review or explore")
+	rootCmd.RegisterFlagCompletionFunc("template", func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return config.TemplateNames(), cobra.ShellCompDirectiveNoFileComp
+	})
+	rootCmd.Flags().StringVar(&sessionID, "session", "", "Continue a saved chat session, e.g. one started in the web UI")
+	rootCmd.Flags().BoolVar(&resume, "resume", false, "Continue the latest saved chat session, or the one whose ID (or ID prefix) follows")
+	rootCmd.Flags().StringArrayVar(&sessionEnv, "env", nil, "Set an environment variable (KEY=value) for commands run in this session; repeatable")
+	rootCmd.Flags().StringVar(&buildCommand, "build-command", "", "Command that builds the project, instead of the detected language's")
 
 }
 
@@ -341,6 +517,7 @@ func Execute() {
 			codeforgeApp.Close()
 		}
 		cleanupLogging()
+		scrubEphemeralArtifacts()
 	}()
 
 	if err := rootCmd.Execute(); err != nil {
@@ -351,8 +528,9 @@ func Execute() {
 
 // handleDirectPrompt processes a direct prompt with integrated CodeForge app
 func handleDirectPrompt(prompt string) {
-	// Use integrated app if available
-	if codeforgeApp != nil {
+	// Use integrated app if available; templates pin files and add
+	// instructions, which only chat sessions support
+	if codeforgeApp != nil && sessionTemplate == nil {
 		ctx := context.Background()
 		response, err := codeforgeApp.ProcessChatMessage(ctx, "cli-session", prompt, model)
 		if err != nil {
@@ -388,16 +566,16 @@ func handleDirectPrompt(prompt string) {
 			fmt.Println("Error: No API key found")
 			fmt.Println("Please set one of these environment variables:")
 			fmt.Println("")
-			fmt.Println("🌐 Multi-Provider Platforms:")
+			fmt.Println(chat.Icon("🌐") + "Multi-Provider Platforms:")
 			fmt.Println("  - OPENROUTER_API_KEY (300+ models from 50+ providers)")
 			fmt.Println("")
-			fmt.Println("🏢 Direct Provider Keys:")
+			fmt.Println(chat.Icon("🏢") + "Direct Provider Keys:")
 			fmt.Println("  - ANTHROPIC_API_KEY (Claude models)")
 			fmt.Println("  - OPENAI_API_KEY (GPT models)")
 			fmt.Println("  - GEMINI_API_KEY (Gemini models)")
 			fmt.Println("  - GROQ_API_KEY (ultra-fast inference)")
 			fmt.Println("")
-			fmt.Println("⚡ Additional Providers:")
+			fmt.Println(chat.Icon("⚡") + "Additional Providers:")
 			fmt.Println("  - TOGETHER_API_KEY (Together AI)")
 			fmt.Println("  - FIREWORKS_API_KEY (Fireworks AI)")
 			fmt.Println("  - DEEPSEEK_API_KEY (DeepSeek)")
@@ -408,6 +586,7 @@ func handleDirectPrompt(prompt string) {
 			fmt.Println("  - SAMBANOVA_API_KEY (SambaNova)")
 			fmt.Println("")
 			fmt.Println("Tip: OPENROUTER_API_KEY gives you access to the most models!")
+			fmt.Println("With a GitHub Copilot subscription, run: codeforge copilot login")
 		}
 		os.Exit(1)
 	}
@@ -423,6 +602,14 @@ func handleDirectPrompt(prompt string) {
 		os.Exit(1)
 	}
 
+	if sessionTemplate != nil {
+		session.ApplyTemplate(*sessionTemplate)
+	}
+	if err := applyWorkspace(session); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Process the message
 	response, err := session.ProcessMessage(prompt)
 	if err != nil {
@@ -442,6 +629,143 @@ func handleDirectPrompt(prompt string) {
 	}
 }
 
+// resumeSession picks the saved session --resume continues: the one whose
+// ID or ID prefix is the argument, else the latest
+func resumeSession(args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("--resume takes at most one session ID")
+	}
+	if sessionID != "" {
+		return fmt.Errorf("--resume and --session both choose a session; use one")
+	}
+	if tuiMode || tuiScript != "" || recordTo != "" {
+		return fmt.Errorf("--resume continues a chat in the terminal, not the TUI")
+	}
+	if codeforgeApp == nil || codeforgeApp.ChatStore == nil {
+		return fmt.Errorf("saved sessions aren't available")
+	}
+
+	ref := ""
+	if len(args) == 1 {
+		ref = args[0]
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	id, err := chat.FindStoredSession(ctx, codeforgeApp.ChatStore, ref)
+	if err != nil {
+		return err
+	}
+	sessionID = id
+	return nil
+}
+
+// applyWorkspace runs a chat session in the directory given with --wd, with
+// the variables given with --env and the --build-command
+func applyWorkspace(session *chat.ChatSession) error {
+	ws := tools.Workspace{Dir: workingDir, BuildCommand: buildCommand}
+	for _, pair := range sessionEnv {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return fmt.Errorf("--env %q isn't of the form KEY=value", pair)
+		}
+		if ws.Env == nil {
+			ws.Env = make(map[string]string)
+		}
+		ws.Env[key] = value
+	}
+	return session.SetWorkspace(ws)
+}
+
+// sessionTemplate is the template chosen with --template, if any
+var sessionTemplate *config.SessionTemplate
+
+// applyTemplate looks up a session template, choosing its model unless one
+// was given and limiting the tools offered to the ones it lists
+func applyTemplate(name string) error {
+	t, err := config.Template(name)
+	if err != nil {
+		return err
+	}
+	if model == "" && t.Model != "" {
+		model = t.Model
+		if provider == "" {
+			provider = t.Provider
+		}
+	}
+	if codeforgeApp != nil {
+		if err := codeforgeApp.RestrictTools(t.Tools); err != nil {
+			return fmt.Errorf("template %s: %w", name, err)
+		}
+	}
+	sessionTemplate = &t
+	return nil
+}
+
+// runTUIScript runs the TUI from the script at path, or stdin for "-",
+// headlessly or, with --play, in the terminal
+func runTUIScript(path string) error {
+	script := os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		script = f
+	} else if playDelay > 0 {
+		return fmt.Errorf("--play needs a script file; stdin is the terminal's input")
+	}
+
+	if playDelay > 0 {
+		return tui.Play(codeforgeApp, script, playDelay)
+	}
+	return tui.RunScript(codeforgeApp, script, os.Stdout)
+}
+
+// recordTUI runs the TUI, saving the keys pressed to the script at path
+func recordTUI(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := tui.Record(codeforgeApp, f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Printf("Recorded session to %s; replay it with codeforge --script %s\n", path, path)
+	return nil
+}
+
+// needsApp reports whether a command uses the app: its chat store, code
+// index, tools or MCP servers
+func needsApp(cmd *cobra.Command) bool {
+	if !cmd.HasParent() || cmd == daemonCmd {
+		return true // Chat, and the daemon answering it; not daemon status or stop
+	}
+	// Subcommands go by the top-level command they're under
+	for cmd.Parent().HasParent() {
+		cmd = cmd.Parent()
+	}
+	switch cmd {
+	case serveCmd, askDocsCmd, exportCmd, benchCmd, perfCmd, indexCmd:
+		return true
+	}
+	return false
+}
+
+// longRunning reports whether a command stays open long enough to start
+// background work up front: the TUI, interactive chat, the API server and
+// the daemon
+func longRunning(cmd *cobra.Command, args []string) bool {
+	if cmd == serveCmd || cmd == daemonCmd {
+		return true
+	}
+	return !cmd.HasParent() && (tuiMode || recordTo != "" || resume || len(args) == 0 && tuiScript == "" && !hasStdinInput())
+}
+
 func hasStdinInput() bool {
 	// Check if stdin is not a terminal (pipe or redirect)
 	stat, err := os.Stdin.Stat()
@@ -494,16 +818,16 @@ func startInteractiveMode() {
 		fmt.Println("Error: No API key found")
 		fmt.Println("Please set one of these environment variables:")
 		fmt.Println("")
-		fmt.Println("🌐 Multi-Provider Platforms:")
+		fmt.Println(chat.Icon("🌐") + "Multi-Provider Platforms:")
 		fmt.Println("  - OPENROUTER_API_KEY (300+ models from 50+ providers)")
 		fmt.Println("")
-		fmt.Println("🏢 Direct Provider Keys:")
+		fmt.Println(chat.Icon("🏢") + "Direct Provider Keys:")
 		fmt.Println("  - ANTHROPIC_API_KEY (Claude models)")
 		fmt.Println("  - OPENAI_API_KEY (GPT models)")
 		fmt.Println("  - GEMINI_API_KEY (Gemini models)")
 		fmt.Println("  - GROQ_API_KEY (ultra-fast inference)")
 		fmt.Println("")
-		fmt.Println("⚡ Additional Providers:")
+		fmt.Println(chat.Icon("⚡") + "Additional Providers:")
 		fmt.Println("  - TOGETHER_API_KEY, FIREWORKS_API_KEY, DEEPSEEK_API_KEY")
 		fmt.Println("  - COHERE_API_KEY, MISTRAL_API_KEY, PERPLEXITY_API_KEY")
 		fmt.Println("  - CEREBRAS_API_KEY, SAMBANOVA_API_KEY")
@@ -519,13 +843,48 @@ func startInteractiveMode() {
 		os.Exit(1)
 	}
 
+	if sessionTemplate != nil {
+		session.ApplyTemplate(*sessionTemplate)
+	}
+	if err := applyWorkspace(session); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Persist the conversation so it can be continued in the web UI
+	if codeforgeApp != nil && codeforgeApp.ChatStore != nil {
+		id := sessionID
+		if id == "" {
+			id = uuid.New().String()
+		}
+		restored, err := session.AttachStore(codeforgeApp.ChatStore, id)
+		if err != nil {
+			if sessionID != "" {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Warning: conversation won't be saved: %v\n", err)
+		} else if sessionID != "" && !quiet {
+			fmt.Printf("Continuing session %s: %d earlier messages restored\n", id, restored)
+		}
+	}
+
+	if ephemeral && !quiet {
+		fmt.Println("Ephemeral session: nothing will be saved")
+	}
+
 	// Start interactive chat
+	interactiveChat.Store(session)
 	if err := session.StartInteractive(); err != nil {
 		fmt.Printf("Error in interactive mode: %v\n", err)
 		os.Exit(1)
 	}
 }
 
+// interactiveChat is the interactive chat session, whose usage is summed up
+// when it's interrupted
+var interactiveChat atomic.Pointer[chat.ChatSession]
+
 // init sets up signal handling for graceful shutdown
 func init() {
 	// Set up signal handling for graceful shutdown
@@ -535,12 +894,21 @@ func init() {
 	go func() {
 		<-c
 		fmt.Println("\nShutting down gracefully...")
+		if session := interactiveChat.Load(); session != nil && !quiet {
+			if summary := session.UsageSummary(); summary != "" {
+				fmt.Println(summary)
+			}
+		}
+		if run := activeRun.Load(); run != nil {
+			fmt.Printf("Run %s stopped; continue it with: codeforge agent --resume %s\n", run.ID, run.ID)
+		}
 
 		// Shutdown ML service
 		ml.Shutdown()
 
 		// Cleanup logging
 		cleanupLogging()
+		scrubEphemeralArtifacts()
 
 		os.Exit(0)
 	}()
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/api"
+	"github.com/spf13/cobra"
+)
+
+// serveCmd runs the API server with the embedded web UI
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the web UI and API",
+	Long: `Start the CodeForge API server and serve the browser UI at /.
+
+The UI is built into the binary, so nothing else needs to be installed. Chat
+sessions are shared with the terminal: continue one with --session <id>.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		port, _ := cmd.Flags().GetInt("port")
+
+		server := api.NewServerWithApp(codeforgeApp.Config, codeforgeApp)
+		if server == nil {
+			return fmt.Errorf("failed to create API server")
+		}
+
+		fmt.Printf("CodeForge web UI: http://localhost:%d/\n", port)
+		fmt.Printf("API: http://localhost:%d/api/v1\n", port)
+
+		if err := server.Start(port); err != nil {
+			return fmt.Errorf("server failed: %w", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	serveCmd.Flags().IntP("port", "p", 47000, "Port to serve on")
+	rootCmd.AddCommand(serveCmd)
+}
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/chat"
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+	"github.com/entrepeneur4lyf/codeforge/internal/tokens"
+	"github.com/spf13/cobra"
+)
+
+// tokenCount is the token count of one input
+type tokenCount struct {
+	Source string `json:"source"`
+	tokens.Result
+}
+
+// tokensCmd counts tokens in files or stdin
+var tokensCmd = &cobra.Command{
+	Use:   "tokens <file|->...",
+	Short: "Count tokens in files or stdin",
+	Long: `Count tokens the way a model sees them.
+
+OpenAI models are counted with their BPE tokenizer, which is downloaded and
+cached on first use. Claude models use Anthropic's token counting API when
+ANTHROPIC_API_KEY is set. Other models, and any model when offline, use
+heuristic estimates. The method used is shown with each count.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		modelID, _ := cmd.Flags().GetString("model")
+		useAPI, _ := cmd.Flags().GetBool("api")
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		if modelID == "" {
+			modelID = chat.GetDefaultModel()
+		}
+		modelID = config.ResolveModel(modelID)
+
+		family := tokens.FamilyOf(modelID)
+		if family == tokens.FamilyOpenAI && !tokens.Warm(modelID) && !asJSON {
+			fmt.Fprintln(os.Stderr, "Tokenizer unavailable; falling back to estimates")
+		}
+
+		apiKey := os.Getenv("ANTHROPIC_API_KEY")
+		if useAPI && (family != tokens.FamilyAnthropic || apiKey == "") {
+			return fmt.Errorf("--api requires a Claude model and ANTHROPIC_API_KEY")
+		}
+		if useAPI && config.IsLocalOnly() {
+			return config.LocalOnlyError("Anthropic token counting")
+		}
+
+		var counts []tokenCount
+		total := 0
+		for _, source := range args {
+			text, err := readTokenSource(source)
+			if err != nil {
+				return err
+			}
+
+			result := tokens.Count(text, modelID)
+			if useAPI {
+				ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+				result, err = tokens.CountAnthropic(ctx, apiKey, modelID, "", text)
+				cancel()
+				if err != nil {
+					return err
+				}
+			}
+
+			counts = append(counts, tokenCount{Source: source, Result: result})
+			total += result.Count
+		}
+
+		if asJSON {
+			data, err := json.MarshalIndent(counts, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to encode token counts: %w", err)
+			}
+			fmt.Println(string(data))
+			return nil
+		}
+
+		for _, c := range counts {
+			method := string(c.Method)
+			if c.Encoding != "" {
+				method += " " + c.Encoding
+			}
+			fmt.Printf("%8d  %s (%s)\n", c.Count, c.Source, method)
+		}
+		if len(counts) > 1 {
+			fmt.Printf("%8d  total\n", total)
+		}
+		fmt.Printf("\nModel: %s\n", modelID)
+
+		return nil
+	},
+}
+
+// readTokenSource reads a file, or stdin for "-"
+func readTokenSource(source string) (string, error) {
+	if source == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("failed to read stdin: %w", err)
+		}
+		return string(data), nil
+	}
+
+	data, err := os.ReadFile(source)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", source, err)
+	}
+	return string(data), nil
+}
+
+func init() {
+	tokensCmd.Flags().StringP("model", "m", "", "Model whose tokenizer to use (default: configured model)")
+	tokensCmd.Flags().Bool("api", false, "Use Anthropic's token counting API for Claude models")
+	tokensCmd.Flags().Bool("json", false, "Output as JSON")
+
+	rootCmd.AddCommand(tokensCmd)
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/chat"
+	"github.com/entrepeneur4lyf/codeforge/internal/watch"
+	"github.com/spf13/cobra"
+)
+
+// watchCmd reruns a command on file changes and explains its failures
+var watchCmd = &cobra.Command{
+	Use:   "watch -- <command> [args...]",
+	Short: "Rerun a command on file changes and explain failures",
+	Long: `Run a command, then run it again whenever a project file changes. When it
+fails, the model explains what went wrong and suggests a fix, using the
+command output and the uncommitted diff of the files that just changed.
+
+Files excluded by files.exclude and the usual build and dependency
+directories don't trigger reruns.
+
+Example:
+  codeforge watch -- go test ./...`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		modelFlag, _ := cmd.Flags().GetString("model")
+		providerFlag, _ := cmd.Flags().GetString("provider")
+		noExplain, _ := cmd.Flags().GetBool("no-explain")
+		debounce, _ := cmd.Flags().GetDuration("debounce")
+
+		runner := &watch.Runner{
+			Root:     workingDir,
+			Command:  args,
+			Debounce: debounce,
+			Out:      os.Stdout,
+		}
+
+		if !noExplain {
+			selectedModel := modelFlag
+			if selectedModel == "" {
+				selectedModel = chat.GetDefaultModel()
+			}
+			handler, err := chat.NewHandlerForModel(selectedModel, chat.GetAPIKeyForModel(selectedModel), providerFlag)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failures won't be explained: %v\n", err)
+			} else {
+				runner.Explain = watch.NewExplainer(handler, workingDir)
+			}
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		return runner.Run(ctx)
+	},
+}
+
+func init() {
+	watchCmd.Flags().StringP("model", "m", "", "Model to explain failures with")
+	watchCmd.Flags().StringP("provider", "p", "", "Provider to explain failures with")
+	watchCmd.Flags().Bool("no-explain", false, "Only rerun the command, without explaining failures")
+	watchCmd.Flags().Duration("debounce", watch.DefaultDebounce, "How long files must stay unchanged before a rerun")
+
+	rootCmd.AddCommand(watchCmd)
+}
@@ -0,0 +1,138 @@
+package agentrun
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteBundle writes the run as a gzipped tarball for auditing what it
+// did and why. Under a directory named for the run it holds:
+//
+//	run.json              the saved run: task, model, start time, plan,
+//	                      steps, and the hashes of its inputs and results
+//	plan.md               the request the plan came from, and the plan
+//	steps/NN-request.md   each step's request, with the model's parameters
+//	                      and the hashes of the files sent with it
+//	steps/NN-response.md  the model's response
+//	steps/NN.diff         the changes the step applied
+//	SHA256SUMS            the hashes of the files above, for sha256sum -c
+//
+// The bundle's content depends only on the run, so exporting a run twice
+// gives the same bundle.
+func (r *Run) WriteBundle(w io.Writer) error {
+	manifest, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	var files []bundleFile
+	add := func(name, content string) {
+		if content != "" {
+			files = append(files, bundleFile{name, content})
+		}
+	}
+	add("run.json", string(manifest)+"\n")
+	add("plan.md", r.planMarkdown())
+	for i, step := range r.Steps {
+		add(fmt.Sprintf("steps/%02d-request.md", i+1), stepRequestMarkdown(i, step))
+		add(fmt.Sprintf("steps/%02d-response.md", i+1), step.Response)
+		add(fmt.Sprintf("steps/%02d.diff", i+1), step.Diff)
+	}
+	var sums strings.Builder
+	for _, f := range files {
+		fmt.Fprintf(&sums, "%s  %s\n", hashOf(f.content), f.name)
+	}
+	add("SHA256SUMS", sums.String())
+
+	// A zero gzip header time keeps the bundle reproducible
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	for _, f := range files {
+		header := &tar.Header{
+			Name:    r.ID + "/" + f.name,
+			Mode:    0o644,
+			Size:    int64(len(f.content)),
+			ModTime: r.Updated,
+			Format:  tar.FormatPAX,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(tw, f.content); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+type bundleFile struct {
+	name, content string
+}
+
+// planMarkdown describes the request the plan came from and the plan
+func (r *Run) planMarkdown() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Run %s\n\n", r.ID)
+	fmt.Fprintf(&sb, "Task: %s\nModel: %s\nDirectory: %s\nStarted: %s\nStatus: %s\n",
+		r.Task, r.Model, r.Dir, r.Created.UTC().Format("2006-01-02 15:04:05.000000000 UTC"), r.Status)
+	if r.Error != "" {
+		fmt.Fprintf(&sb, "Error: %s\n", r.Error)
+	}
+	sb.WriteString("\n## Plan\n\n")
+	for i, step := range r.Steps {
+		fmt.Fprintf(&sb, "%d. %s (%s)\n", i+1, step.Title, step.Status)
+	}
+	if r.Plan != nil {
+		sb.WriteString("\n" + requestMarkdown(r.Plan))
+	}
+	return sb.String()
+}
+
+// stepRequestMarkdown describes the request sent for step i
+func stepRequestMarkdown(i int, step Step) string {
+	if step.Request == nil {
+		return ""
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Step %d: %s\n\n", i+1, step.Title)
+	if len(step.Context) > 0 {
+		sb.WriteString("Files sent, by SHA-256:\n\n")
+		for _, f := range step.Context {
+			hash := f.Hash
+			if hash == "" {
+				hash = "(doesn't exist)"
+			}
+			fmt.Fprintf(&sb, "- %s %s\n", f.Path, hash)
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString(requestMarkdown(step.Request))
+	return sb.String()
+}
+
+// requestMarkdown describes a request: the model's parameters, the system
+// prompt and the messages
+func requestMarkdown(req *Request) string {
+	var sb strings.Builder
+	info := req.Model.Info
+	fmt.Fprintf(&sb, "## Model\n\n%s", req.Model.ID)
+	if req.Model.Provider != "" {
+		fmt.Fprintf(&sb, " (%s)", req.Model.Provider)
+	}
+	fmt.Fprintf(&sb, ", max tokens %d, context window %d", info.MaxTokens, info.ContextWindow)
+	if info.Temperature != nil {
+		fmt.Fprintf(&sb, ", temperature %g", *info.Temperature)
+	}
+	fmt.Fprintf(&sb, "\n\n## System\n\n%s\n", req.System)
+	for _, msg := range req.Messages {
+		fmt.Fprintf(&sb, "\n## %s\n\n%s\n", msg.Role, msg.Content)
+	}
+	return sb.String()
+}
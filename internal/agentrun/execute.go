@@ -0,0 +1,264 @@
+package agentrun
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/fileedit"
+	"github.com/entrepeneur4lyf/codeforge/internal/ignore"
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+	"github.com/entrepeneur4lyf/codeforge/internal/tasks"
+)
+
+const (
+	maxPlanFiles = 500       // Project files listed for planning
+	maxFileBytes = 64 * 1024 // Content of a file included with a step
+)
+
+const stepPrompt = `You are CodeForge, carrying out a coding task one planned step at a time in the developer's project. You can't run commands or open files: the current content of the files a step names comes with it.
+
+Make the step's changes as unified diffs, with --- a/path and +++ b/path headers and /dev/null for new and deleted files, or as SEARCH/REPLACE blocks with the file's path on the line before them. Paths are relative to the project root. Change only what the step calls for, and say briefly what you changed.`
+
+// Execute carries out the steps of a run that aren't done, planning them
+// first for a new run. The edits of each step are written to the run's
+// directory, with the files they replace backed up in backupDir, and the run
+// is saved after every step. When a step fails or ctx is cancelled, the run
+// is saved as stopped, ready to resume from that step.
+func Execute(ctx context.Context, handler llm.ApiHandler, r *Run, backupDir string, out io.Writer) error {
+	rec := &recorder{ApiHandler: handler}
+	r.Status, r.Error = StatusRunning, ""
+	if len(r.Steps) == 0 {
+		fmt.Fprintln(out, "Planning...")
+		plan, err := tasks.Plan(ctx, rec, r.planRequest())
+		r.Plan = rec.last
+		if err != nil {
+			return r.stop(err)
+		}
+		for _, task := range plan {
+			r.Steps = append(r.Steps, Step{Task: task, Status: StepPending})
+		}
+		fmt.Fprintln(out, tasks.Checklist(plan))
+	}
+	if err := r.Save(); err != nil {
+		return err
+	}
+
+	for i := r.Next(); i >= 0; i = r.Next() {
+		fmt.Fprintf(out, "\nStep %d/%d: %s\n", i+1, len(r.Steps), r.Steps[i].Title)
+		if err := r.runStep(ctx, rec, i, backupDir, out); err != nil {
+			return r.stop(fmt.Errorf("step %d: %w", i+1, err))
+		}
+	}
+	r.Status = StatusDone
+	return r.Save()
+}
+
+// stop saves the run as stopped by err
+func (r *Run) stop(err error) error {
+	r.Status, r.Error = StatusStopped, err.Error()
+	if serr := r.Save(); serr != nil {
+		return errors.Join(err, serr)
+	}
+	return err
+}
+
+// runStep asks the model to carry out step i and writes its edits. Nobody
+// reviews them, so they go through fileedit.Plan like the chat's do: edits
+// the project's code policies block, or that reach outside the project
+// through symlinks, stop the run. The changes are saved before they're
+// written, so a crash partway through can be told apart from a workspace
+// changed by hand.
+func (r *Run) runStep(ctx context.Context, handler *recorder, i int, backupDir string, out io.Writer) error {
+	step := &r.Steps[i]
+	messages, sent := r.messages(i)
+	stream, err := handler.CreateMessage(ctx, stepPrompt, messages)
+	step.Request, step.Context = handler.last, sent
+	if err != nil {
+		return err
+	}
+	collector, err := llm.NewStreamProcessor(ctx).ProcessStream(stream)
+	if err != nil {
+		return err
+	}
+	step.Response = collector.GetFullText()
+	fmt.Fprintln(out, strings.TrimSpace(step.Response))
+
+	edits, err := fileedit.Extract(step.Response)
+	if err != nil {
+		return err
+	}
+	changes, err := fileedit.Plan(r.Dir, edits)
+	if err != nil {
+		return err
+	}
+	var diff strings.Builder
+	step.Changes = nil
+	for _, c := range changes {
+		diff.WriteString(c.Diff())
+		change := FileChange{Path: c.Path}
+		if c.Existed {
+			change.Before = hashOf(c.Before)
+		}
+		if !c.Delete {
+			change.After = hashOf(c.After)
+		}
+		step.Changes = append(step.Changes, change)
+	}
+	step.Diff = diff.String()
+	step.Status = StepApplying
+	if err := r.Save(); err != nil {
+		return err
+	}
+
+	if len(changes) > 0 {
+		if _, err := fileedit.Apply(r.Dir, changes, backupDir); err != nil {
+			// Apply writes every file or none
+			step.reset()
+			return err
+		}
+		paths := make([]string, len(changes))
+		for j, c := range changes {
+			paths[j] = c.Path
+		}
+		fmt.Fprintf(out, "Changed %s\n", strings.Join(paths, ", "))
+	}
+	r.finishStep(step)
+	return r.Save()
+}
+
+// messages returns the conversation for step i: the task and plan, the
+// steps done so far with the model's responses, and step i with the
+// current content of its files, which are returned too
+func (r *Run) messages(i int) ([]llm.Message, []FileHash) {
+	var plan strings.Builder
+	fmt.Fprintf(&plan, "%s\n\nThe plan:\n", r.Task)
+	for j, step := range r.Steps {
+		fmt.Fprintf(&plan, "%d. %s\n", j+1, step.Title)
+	}
+	plan.WriteString("\n")
+
+	var messages []llm.Message
+	for j := 0; j < i; j++ {
+		messages = append(messages,
+			text("user", fmt.Sprintf("Carry out step %d: %s", j+1, r.Steps[j].Title)),
+			text("assistant", r.Steps[j].Response))
+	}
+
+	step := r.Steps[i]
+	var request strings.Builder
+	fmt.Fprintf(&request, "Carry out step %d: %s\n", i+1, step.Title)
+	if step.Details != "" {
+		fmt.Fprintf(&request, "%s\n", step.Details)
+	}
+	var sent []FileHash
+	for _, path := range step.Files {
+		section, file := r.fileSection(path)
+		request.WriteString("\n" + section)
+		if file.Path != "" {
+			sent = append(sent, file)
+		}
+	}
+	messages = append(messages, text("user", request.String()))
+
+	// The task and plan lead the conversation
+	first := messages[0].Content[0].(llm.TextBlock)
+	messages[0].Content[0] = llm.TextBlock{Text: plan.String() + first.Text}
+	return messages, sent
+}
+
+// fileSection returns a file's current content for a step's request, and
+// the file's hash; the path is empty when the file can't be sent
+func (r *Run) fileSection(path string) (string, FileHash) {
+	rel, err := r.rel(path)
+	if err != nil {
+		return fmt.Sprintf("%s: %v\n", path, err), FileHash{}
+	}
+	data, err := os.ReadFile(filepath.Join(r.Dir, filepath.FromSlash(rel)))
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return fmt.Sprintf("%s doesn't exist yet.\n", rel), FileHash{Path: rel}
+	case err != nil:
+		return fmt.Sprintf("%s can't be read: %v\n", rel, err), FileHash{}
+	case len(data) > maxFileBytes:
+		return fmt.Sprintf("%s (first %d bytes):\n```\n%s\n```\n", rel, maxFileBytes, data[:maxFileBytes]), FileHash{Path: rel, Hash: hashOf(string(data))}
+	}
+	content := string(data)
+	if !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return fmt.Sprintf("%s:\n```\n%s```\n", rel, content), FileHash{Path: rel, Hash: hashOf(string(data))}
+}
+
+// planRequest returns the task with the project's files, for planning
+func (r *Run) planRequest() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s\n\nFiles in the project:\n", r.Task)
+
+	for i, rel := range projectFiles(r.Dir) {
+		if i == maxPlanFiles {
+			sb.WriteString("...\n")
+			break
+		}
+		fmt.Fprintf(&sb, "%s\n", rel)
+	}
+	return sb.String()
+}
+
+// projectFiles returns the files under dir, relative to it and slash
+// separated, skipping hidden and ignored ones
+func projectFiles(dir string) []string {
+	excludes := ignore.Default()
+	var files []string
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(dir, path)
+		if rel == "." {
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") || excludes.Match(rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Type().IsRegular() {
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	return files
+}
+
+// recorder passes requests on to a handler, keeping the last one it sent
+type recorder struct {
+	llm.ApiHandler
+	last *Request
+}
+
+func (h *recorder) CreateMessage(ctx context.Context, systemPrompt string, messages []llm.Message) (llm.ApiStream, error) {
+	h.last = &Request{Model: h.GetModel(), System: systemPrompt}
+	for _, msg := range messages {
+		var parts []string
+		for _, block := range msg.Content {
+			if t, ok := block.(llm.TextBlock); ok {
+				parts = append(parts, t.Text)
+			} else {
+				parts = append(parts, "["+block.Type()+"]")
+			}
+		}
+		h.last.Messages = append(h.last.Messages, Message{Role: msg.Role, Content: strings.Join(parts, "\n")})
+	}
+	return h.ApiHandler.CreateMessage(ctx, systemPrompt, messages)
+}
+
+func text(role, content string) llm.Message {
+	return llm.Message{Role: role, Content: []llm.ContentBlock{llm.TextBlock{Text: content}}}
+}
@@ -0,0 +1,340 @@
+// Package agentrun carries out a coding task as a planned series of steps,
+// saving the run after each one so a crash or interruption can be resumed.
+// Every file a step reads or changes is hashed, and a run only resumes on a
+// workspace that still matches what it left. A run keeps every request it
+// sent and every diff it applied, and can be exported as a bundle for
+// auditing.
+package agentrun
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/fileutil"
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+	"github.com/entrepeneur4lyf/codeforge/internal/tasks"
+)
+
+// Run statuses
+const (
+	StatusRunning = "running" // Also the status of a run whose process died
+	StatusStopped = "stopped" // Interrupted or failed; resumable
+	StatusDone    = "done"
+)
+
+// Step statuses
+const (
+	StepPending  = "pending"
+	StepApplying = "applying" // The step's edits are being written
+	StepDone     = "done"
+)
+
+// FileChange is a file a step is writing, by its content's hash before and
+// after ("" when the file doesn't exist)
+type FileChange struct {
+	Path   string `json:"path"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// FileHash is a file sent to the model, by its content's hash ("" when the
+// file doesn't exist)
+type FileHash struct {
+	Path string `json:"path"`
+	Hash string `json:"hash"`
+}
+
+// Request is a request sent to the model, as it was sent
+type Request struct {
+	Model    llm.ModelResponse `json:"model"` // ID, limits and parameters
+	System   string            `json:"system"`
+	Messages []Message         `json:"messages"`
+}
+
+// Message is the text of a message in a request
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Step is one step of a run's plan
+type Step struct {
+	tasks.Task
+	Status   string       `json:"status"`
+	Request  *Request     `json:"request,omitempty"`
+	Context  []FileHash   `json:"context,omitempty"` // Files sent with the request
+	Response string       `json:"response,omitempty"`
+	Changes  []FileChange `json:"changes,omitempty"`
+	Diff     string       `json:"diff,omitempty"` // The changes, as a unified diff
+	Finished time.Time    `json:"finished,omitempty"`
+}
+
+// Run is an agent run: its task, plan and progress
+type Run struct {
+	ID      string            `json:"id"`
+	Task    string            `json:"task"`
+	Model   string            `json:"model"`
+	Dir     string            `json:"dir"` // Working directory the run edits
+	Status  string            `json:"status"`
+	Error   string            `json:"error,omitempty"`
+	Plan    *Request          `json:"plan_request,omitempty"` // The request the plan came from
+	Steps   []Step            `json:"steps"`
+	Hashes  map[string]string `json:"hashes"` // Files the run has read or changed, by hash as it left them
+	Inputs  map[string]string `json:"inputs"` // The project's files when the run started, by hash
+	Created time.Time         `json:"created"`
+	Updated time.Time         `json:"updated"`
+
+	path string // File the run is saved to
+}
+
+// New starts a run of task in dir, saved in runsDir
+func New(runsDir, dir, task, model string) *Run {
+	created := time.Now()
+	inputs := make(map[string]string)
+	for _, rel := range projectFiles(dir) {
+		if data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(rel))); err == nil {
+			inputs[rel] = hashOf(string(data))
+		}
+	}
+	id := RunID(task, model, dir, inputs)
+	return &Run{
+		ID:      id,
+		Task:    task,
+		Model:   model,
+		Dir:     dir,
+		Status:  StatusRunning,
+		Hashes:  make(map[string]string),
+		Inputs:  inputs,
+		Created: created,
+		// Runs of the same task on the same files share an ID, so the file
+		// is named for the start time too
+		path: filepath.Join(runsDir, created.UTC().Format("20060102-150405.000000000")+"-"+id+".json"),
+	}
+}
+
+// RunID returns the ID of a run of task with model in dir, whose files had
+// the given hashes when it started: a hash of all of them, so runs with the
+// same inputs get the same ID and a run's ID can be checked against what it
+// says it was started with
+func RunID(task, model, dir string, inputs map[string]string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00", task, model, dir)
+	paths := make([]string, 0, len(inputs))
+	for path := range inputs {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		fmt.Fprintf(h, "%s\x00%s\n", path, inputs[path])
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// Load reads the run with the given ID, or the only one whose ID starts
+// with it, from runsDir. Of runs sharing an ID, the latest is read.
+func Load(runsDir, id string) (*Run, error) {
+	runs, err := List(runsDir)
+	if err != nil {
+		return nil, err
+	}
+	var found []*Run
+	seen := make(map[string]bool)
+	for _, r := range runs {
+		if r.ID == id {
+			return r, nil
+		}
+		if strings.HasPrefix(r.ID, id) && !seen[r.ID] {
+			seen[r.ID] = true
+			found = append(found, r)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("no agent run %s", id)
+	case 1:
+		return found[0], nil
+	default:
+		return nil, fmt.Errorf("%d agent runs start with %s; give more of the ID", len(found), id)
+	}
+}
+
+// List returns the runs saved in runsDir, latest first
+func List(runsDir string) ([]*Run, error) {
+	entries, err := os.ReadDir(runsDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var runs []*Run
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		path := filepath.Join(runsDir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var r Run
+		if err := json.Unmarshal(data, &r); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if r.Hashes == nil {
+			r.Hashes = make(map[string]string)
+		}
+		r.path = path
+		runs = append(runs, &r)
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].Created.After(runs[j].Created) })
+	return runs, nil
+}
+
+// Save writes the run to its file, atomically so a crash leaves the last
+// saved state
+func (r *Run) Save() error {
+	r.Updated = time.Now()
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	return fileutil.WriteFile(r.path, data)
+}
+
+// Next returns the index of the first step not done, or -1
+func (r *Run) Next() int {
+	for i, step := range r.Steps {
+		if step.Status != StepDone {
+			return i
+		}
+	}
+	return -1
+}
+
+// Verify checks that the workspace is as the run left it before it's
+// resumed. A step stopped while its edits were being written is finished
+// if they all were, and redone if none were. Any other difference is an
+// error listing the files that changed; force accepts the workspace as it
+// is instead.
+func (r *Run) Verify(force bool) error {
+	for i := range r.Steps {
+		step := &r.Steps[i]
+		if step.Status != StepApplying {
+			continue
+		}
+		written, unwritten := 0, 0
+		for _, c := range step.Changes {
+			switch hash, err := r.hash(c.Path); {
+			case err != nil:
+				return err
+			case hash == c.After:
+				written++
+			case hash == c.Before:
+				unwritten++
+			}
+		}
+		switch {
+		case written == len(step.Changes):
+			r.finishStep(step)
+		case unwritten == len(step.Changes) || force:
+			step.reset()
+		default:
+			paths := make([]string, len(step.Changes))
+			for j, c := range step.Changes {
+				paths[j] = c.Path
+			}
+			return fmt.Errorf("step %d stopped partway through writing its edits; check %s and resume with --force",
+				i+1, strings.Join(paths, ", "))
+		}
+	}
+
+	var changed []string
+	for path, want := range r.Hashes {
+		hash, err := r.hash(path)
+		if err != nil {
+			return err
+		}
+		if hash != want {
+			if force {
+				r.Hashes[path] = hash
+				continue
+			}
+			changed = append(changed, path)
+		}
+	}
+	if len(changed) > 0 {
+		sort.Strings(changed)
+		return fmt.Errorf("files changed since run %s stopped: %s; resume with --force to continue from them as they are",
+			r.ID, strings.Join(changed, ", "))
+	}
+	return nil
+}
+
+// finishStep marks a step done and records the hashes of the files it read
+// and changed
+func (r *Run) finishStep(step *Step) {
+	for _, path := range step.Files {
+		if rel, err := r.rel(path); err == nil {
+			if hash, err := r.hash(rel); err == nil {
+				r.Hashes[rel] = hash
+			}
+		}
+	}
+	for _, c := range step.Changes {
+		r.Hashes[c.Path] = c.After
+	}
+	step.Status, step.Finished = StepDone, time.Now()
+}
+
+// reset returns a step to pending, forgetting the attempt at it
+func (s *Step) reset() {
+	s.Status, s.Request, s.Context = StepPending, nil, nil
+	s.Response, s.Changes, s.Diff = "", nil, ""
+}
+
+// rel returns path relative to the run's directory, refusing paths outside it
+func (r *Run) rel(path string) (string, error) {
+	rel := filepath.Clean(filepath.FromSlash(path))
+	if filepath.IsAbs(rel) {
+		var err error
+		if rel, err = filepath.Rel(r.Dir, rel); err != nil {
+			return "", err
+		}
+	}
+	if rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside %s", path, r.Dir)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// hash returns the hash of a file in the run's directory, or "" when it
+// doesn't exist
+func (r *Run) hash(rel string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(r.Dir, filepath.FromSlash(rel)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return hashOf(string(data)), nil
+}
+
+func hashOf(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
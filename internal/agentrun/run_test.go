@@ -0,0 +1,237 @@
+package agentrun
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+)
+
+// scriptHandler answers requests with its replies in order, failing when
+// they run out
+type scriptHandler struct {
+	replies []string
+}
+
+func (h *scriptHandler) CreateMessage(context.Context, string, []llm.Message) (llm.ApiStream, error) {
+	if len(h.replies) == 0 {
+		return nil, errors.New("connection lost")
+	}
+	ch := make(chan llm.ApiStreamChunk, 1)
+	ch <- llm.ApiStreamTextChunk{Text: h.replies[0]}
+	close(ch)
+	h.replies = h.replies[1:]
+	return ch, nil
+}
+
+func (h *scriptHandler) GetModel() llm.ModelResponse {
+	return llm.ModelResponse{ID: "test/model"}
+}
+
+func (h *scriptHandler) GetApiStreamUsage() (*llm.ApiStreamUsageChunk, error) {
+	return nil, nil
+}
+
+const plan = `[
+  {"title": "Say hi", "files": ["main.go"]},
+  {"title": "Add a greet package", "files": ["greet/greet.go"]}
+]`
+
+const sayHi = "main.go\n<<<<<<< SEARCH\n\tprintln(\"hello\")\n=======\n\tprintln(\"hi\")\n>>>>>>> REPLACE\n"
+
+const addGreet = "--- /dev/null\n+++ b/greet/greet.go\n@@ -0,0 +1 @@\n+package greet\n"
+
+func TestResume(t *testing.T) {
+	dir, runsDir, backups := t.TempDir(), t.TempDir(), t.TempDir()
+	mainGo := filepath.Join(dir, "main.go")
+	if err := os.WriteFile(mainGo, []byte("package main\n\nfunc main() {\n\tprintln(\"hello\")\n}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The connection drops after the first step
+	r := New(runsDir, dir, "Greet with hi", "test/model")
+	err := Execute(context.Background(), &scriptHandler{replies: []string{plan, sayHi}}, r, backups, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "step 2: connection lost") {
+		t.Fatalf("Execute = %v", err)
+	}
+
+	saved, err := Load(runsDir, r.ID[:len(r.ID)-2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id := RunID(saved.Task, saved.Model, saved.Dir, saved.Inputs); id != saved.ID {
+		t.Errorf("RunID of the saved run = %s, want %s", id, saved.ID)
+	}
+	if saved.Status != StatusStopped || saved.Next() != 1 || saved.Steps[0].Status != StepDone || saved.Hashes["main.go"] == "" {
+		t.Fatalf("saved run = %+v", saved)
+	}
+
+	// Resuming refuses a workspace changed since
+	original, _ := os.ReadFile(mainGo)
+	os.WriteFile(mainGo, []byte("package main\n"), 0o644)
+	if err := saved.Verify(false); err == nil || !strings.Contains(err.Error(), "main.go") {
+		t.Errorf("Verify of a changed file = %v", err)
+	}
+	os.WriteFile(mainGo, original, 0o644)
+	if err := saved.Verify(false); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Execute(context.Background(), &scriptHandler{replies: []string{addGreet}}, saved, backups, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if greet, _ := os.ReadFile(filepath.Join(dir, "greet", "greet.go")); string(greet) != "package greet\n" {
+		t.Errorf("greet.go = %q", greet)
+	}
+	if saved.Status != StatusDone || saved.Next() != -1 {
+		t.Errorf("finished run = %+v", saved)
+	}
+}
+
+func TestExecuteRefusesUnsafeEdits(t *testing.T) {
+	dir, outside := t.TempDir(), t.TempDir()
+	mainGo := "package main\n\nfunc main() {\n\tprintln(\"hello\")\n}\n"
+	os.WriteFile(filepath.Join(dir, "main.go"), []byte(mainGo), 0o644)
+	os.MkdirAll(filepath.Join(dir, ".codeforge"), 0o755)
+	os.WriteFile(filepath.Join(dir, ".codeforge", "policies.json"), []byte(`{"bannedAPIs": [{"pattern": "\\bexec\\("}]}`), 0o644)
+	if err := os.Symlink(outside, filepath.Join(dir, "greet")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+
+	banned := "main.go\n<<<<<<< SEARCH\n\tprintln(\"hello\")\n=======\n\texec(\"hello\")\n>>>>>>> REPLACE\n"
+	for reply, want := range map[string]string{banned: "project policy", addGreet: "symlink"} {
+		r := New(t.TempDir(), dir, "Greet", "test/model")
+		err := Execute(context.Background(), &scriptHandler{replies: []string{plan, reply}}, r, t.TempDir(), io.Discard)
+		if err == nil || !strings.Contains(err.Error(), want) || r.Status != StatusStopped || r.Steps[0].Status != StepPending {
+			t.Errorf("Execute = %v, want a %s error; run %+v", err, want, r)
+		}
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "main.go")); string(data) != mainGo {
+		t.Errorf("main.go = %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(outside, "greet.go")); err == nil {
+		t.Error("a file was written outside the project")
+	}
+}
+
+func TestRunID(t *testing.T) {
+	dir, runsDir := t.TempDir(), t.TempDir()
+	os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0o644)
+
+	first := New(runsDir, dir, "task", "test/model")
+	second := New(runsDir, dir, "task", "test/model")
+	if first.ID != second.ID {
+		t.Errorf("runs with the same inputs got IDs %s and %s", first.ID, second.ID)
+	}
+	if first.Inputs["main.go"] != hashOf("package main\n") {
+		t.Errorf("inputs = %v", first.Inputs)
+	}
+	if err := first.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if runs, _ := List(runsDir); len(runs) != 2 {
+		t.Errorf("a run with the same ID replaced the other; %d saved", len(runs))
+	}
+
+	if other := New(runsDir, dir, "other task", "test/model"); other.ID == first.ID {
+		t.Error("a different task got the same ID")
+	}
+	os.WriteFile(filepath.Join(dir, "main.go"), []byte("package other\n"), 0o644)
+	if changed := New(runsDir, dir, "task", "test/model"); changed.ID == first.ID {
+		t.Error("changed files got the same ID")
+	}
+}
+
+func TestVerifyInterruptedWrite(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a.go"), []byte("new"), 0o644)
+
+	// The crash came after a.go was written and before the step was saved
+	// as done; b.go, which the step deletes, is gone too
+	r := New(t.TempDir(), dir, "task", "test/model")
+	r.Steps = []Step{{Status: StepApplying, Changes: []FileChange{
+		{Path: "a.go", Before: hashOf("old"), After: hashOf("new")},
+		{Path: "b.go", Before: hashOf("gone")},
+	}}}
+	if err := r.Verify(false); err != nil {
+		t.Fatal(err)
+	}
+	if r.Steps[0].Status != StepDone || r.Hashes["a.go"] != hashOf("new") {
+		t.Errorf("finished step = %+v, hashes %v", r.Steps[0], r.Hashes)
+	}
+
+	// Only some files written can't be sorted out without help
+	r.Hashes = map[string]string{}
+	r.Steps[0].Status = StepApplying
+	r.Steps[0].Changes[1].Path = "c.go"
+	os.WriteFile(filepath.Join(dir, "c.go"), []byte("gone"), 0o644)
+	if err := r.Verify(false); err == nil {
+		t.Error("partly written step was verified")
+	}
+	if err := r.Verify(true); err != nil || r.Steps[0].Status != StepPending {
+		t.Errorf("forced Verify = %v, step %+v", err, r.Steps[0])
+	}
+}
+
+func TestBundle(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() {\n\tprintln(\"hello\")\n}\n"), 0o644)
+	r := New(t.TempDir(), dir, "Greet with hi", "test/model")
+	if err := Execute(context.Background(), &scriptHandler{replies: []string{plan, sayHi, addGreet}}, r, t.TempDir(), io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if ctx := r.Steps[0].Context; len(ctx) != 1 || ctx[0].Path != "main.go" || ctx[0].Hash == "" {
+		t.Errorf("context of step 1 = %+v", ctx)
+	}
+	if r.Plan == nil || r.Steps[1].Request == nil || len(r.Steps[1].Request.Messages) != 3 {
+		t.Fatalf("requests = %+v, %+v", r.Plan, r.Steps[1].Request)
+	}
+
+	var bundle bytes.Buffer
+	if err := r.WriteBundle(&bundle); err != nil {
+		t.Fatal(err)
+	}
+	var again bytes.Buffer
+	r.WriteBundle(&again)
+	if !bytes.Equal(bundle.Bytes(), again.Bytes()) {
+		t.Error("exporting the run twice gave different bundles")
+	}
+
+	gz, err := gzip.NewReader(&bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string]string)
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := io.ReadAll(tr)
+		files[strings.TrimPrefix(header.Name, r.ID+"/")] = string(content)
+	}
+	if !strings.Contains(files["steps/01.diff"], "+\tprintln(\"hi\")") || !strings.Contains(files["steps/02.diff"], "+++ b/greet/greet.go") {
+		t.Errorf("diffs = %q, %q", files["steps/01.diff"], files["steps/02.diff"])
+	}
+	if !strings.Contains(files["steps/01-request.md"], "- main.go "+r.Steps[0].Context[0].Hash) {
+		t.Errorf("request of step 1 = %s", files["steps/01-request.md"])
+	}
+	if !strings.Contains(files["SHA256SUMS"], hashOf(files["run.json"])+"  run.json") {
+		t.Errorf("SHA256SUMS = %s", files["SHA256SUMS"])
+	}
+}
@@ -0,0 +1,325 @@
+// Package annotate writes doc comments for the exported Go symbols that
+// lack them. It finds the symbols, has the model write their comments from
+// the signatures the language server reports, and turns the comments into
+// changes to review and apply like any other edit.
+package annotate
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/fileedit"
+	"github.com/entrepeneur4lyf/codeforge/internal/ignore"
+)
+
+// Symbol kinds
+const (
+	KindFunc   = "func"
+	KindMethod = "method"
+	KindType   = "type"
+	KindConst  = "const"
+	KindVar    = "var"
+)
+
+// maxSignature caps the source sent for a symbol, such as a large struct or
+// a long block of constants
+const maxSignature = 2000
+
+// Symbol is an exported declaration without a doc comment
+type Symbol struct {
+	File      string // Relative to the root, slash separated
+	Name      string // Methods are named Type.Method
+	Kind      string
+	Line      int    // Position of the name, from 1
+	Column    int    // Byte offset of the name in its line, from 1
+	Signature string // The declaration without its body
+
+	insert int // Line the comment goes above, from 1
+}
+
+// key identifies a symbol in its file across edits that move it
+func (s Symbol) key() string {
+	return s.Kind + " " + s.Name
+}
+
+// Find returns the exported symbols without doc comments in the packages
+// named by patterns, relative to root. A pattern ending in /... includes
+// the packages below it. Tests and generated files are skipped.
+func Find(root string, patterns []string) ([]Symbol, error) {
+	excludes := ignore.Default()
+	var symbols []Symbol
+	seen := make(map[string]bool)
+	for _, pattern := range patterns {
+		dir, recursive := strings.CutSuffix(filepath.ToSlash(pattern), "/...")
+		if dir == "..." {
+			dir, recursive = ".", true
+		}
+		start := filepath.Join(root, filepath.FromSlash(dir))
+		if info, err := os.Stat(start); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("%s is not a package directory", pattern)
+		}
+
+		err := filepath.WalkDir(start, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+			rel = filepath.ToSlash(rel)
+			if d.IsDir() {
+				if path != start && (!recursive || d.Name() == "testdata" || excludes.Match(rel)) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !strings.HasSuffix(rel, ".go") || strings.HasSuffix(rel, "_test.go") || seen[rel] {
+				return nil
+			}
+			seen[rel] = true
+
+			src, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			found, err := findInFile(rel, src)
+			if err != nil {
+				return err
+			}
+			symbols = append(symbols, found...)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return symbols, nil
+}
+
+// findInFile returns the exported symbols without doc comments in a file
+func findInFile(rel string, src []byte) ([]Symbol, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, rel, src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+	if ast.IsGenerated(file) {
+		return nil, nil
+	}
+
+	var symbols []Symbol
+	add := func(name *ast.Ident, fullName, kind string, insertAt, from, to token.Pos) {
+		pos := fset.Position(name.Pos())
+		signature := string(src[fset.Position(from).Offset:fset.Position(to).Offset])
+		if len(signature) > maxSignature {
+			signature = signature[:maxSignature] + "\n// ..."
+		}
+		symbols = append(symbols, Symbol{
+			File:      rel,
+			Name:      fullName,
+			Kind:      kind,
+			Line:      pos.Line,
+			Column:    pos.Column,
+			Signature: strings.TrimSpace(signature),
+			insert:    fset.Position(insertAt).Line,
+		})
+	}
+
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Doc != nil || !decl.Name.IsExported() {
+				continue
+			}
+			name, kind := decl.Name.Name, KindFunc
+			if decl.Recv != nil && len(decl.Recv.List) > 0 {
+				recv := receiverType(decl.Recv.List[0].Type)
+				if !ast.IsExported(recv) {
+					continue
+				}
+				name, kind = recv+"."+name, KindMethod
+			}
+			end := decl.End()
+			if decl.Body != nil {
+				end = decl.Body.Lbrace
+			}
+			add(decl.Name, name, kind, decl.Pos(), decl.Pos(), end)
+
+		case *ast.GenDecl:
+			// A group's comment documents the specs in it
+			if decl.Doc != nil || decl.Tok == token.IMPORT {
+				continue
+			}
+			grouped := decl.Lparen.IsValid()
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					if !spec.Name.IsExported() || spec.Doc != nil || (grouped && spec.Comment != nil) {
+						continue
+					}
+					if grouped {
+						add(spec.Name, spec.Name.Name, KindType, spec.Pos(), spec.Pos(), spec.End())
+					} else {
+						add(spec.Name, spec.Name.Name, KindType, decl.Pos(), decl.Pos(), decl.End())
+					}
+				case *ast.ValueSpec:
+					name := exportedName(spec.Names)
+					if name == nil || spec.Doc != nil || (grouped && spec.Comment != nil) {
+						continue
+					}
+					kind := KindVar
+					if decl.Tok == token.CONST {
+						kind = KindConst
+					}
+					if grouped {
+						add(name, name.Name, kind, spec.Pos(), spec.Pos(), spec.End())
+					} else {
+						add(name, name.Name, kind, decl.Pos(), decl.Pos(), decl.End())
+					}
+				}
+			}
+		}
+	}
+	return symbols, nil
+}
+
+// receiverType returns the name of a method's receiver type
+func receiverType(expr ast.Expr) string {
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.Ident:
+			return e.Name
+		default:
+			return ""
+		}
+	}
+}
+
+func exportedName(names []*ast.Ident) *ast.Ident {
+	for _, name := range names {
+		if name.IsExported() {
+			return name
+		}
+	}
+	return nil
+}
+
+// Plan works out the changes that add the comments in docs, by symbol, to
+// the files under root, without writing anything. Symbols are found again
+// in the files as they are now, so earlier changes to them don't matter;
+// symbols no longer there or documented since are skipped.
+func Plan(root string, docs map[Symbol]string) ([]fileedit.Change, error) {
+	byFile := make(map[string]map[string]string)
+	for sym, doc := range docs {
+		if byFile[sym.File] == nil {
+			byFile[sym.File] = make(map[string]string)
+		}
+		byFile[sym.File][sym.key()] = doc
+	}
+	files := make([]string, 0, len(byFile))
+	for file := range byFile {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	var changes []fileedit.Change
+	for _, file := range files {
+		src, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(file)))
+		if err != nil {
+			return nil, err
+		}
+		symbols, err := findInFile(file, src)
+		if err != nil {
+			return nil, err
+		}
+
+		lines := strings.SplitAfter(string(src), "\n")
+		comments := make(map[int]string)
+		for _, sym := range symbols {
+			if doc, ok := byFile[file][sym.key()]; ok {
+				line := lines[sym.insert-1]
+				indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+				comments[sym.insert] = Comment(doc, indent)
+			}
+		}
+		if len(comments) == 0 {
+			continue
+		}
+
+		var after strings.Builder
+		for i, line := range lines {
+			after.WriteString(comments[i+1])
+			after.WriteString(line)
+		}
+		changes = append(changes, fileedit.Change{Path: file, Before: string(src), After: after.String(), Existed: true})
+	}
+	return changes, nil
+}
+
+// commentWidth is where comment text is wrapped, not counting indentation
+const commentWidth = 77
+
+// Comment formats doc as a // comment indented by indent, wrapping its
+// paragraphs. Indented lines, such as code, are kept as they are.
+func Comment(doc, indent string) string {
+	var sb strings.Builder
+	line := func(text string) {
+		if text == "" {
+			sb.WriteString(indent + "//\n")
+			return
+		}
+		sb.WriteString(indent + "// " + text + "\n")
+	}
+
+	var words []string
+	flush := func() {
+		current := ""
+		for _, word := range words {
+			if current != "" && len(current)+1+len(word) > commentWidth {
+				line(current)
+				current = ""
+			}
+			if current != "" {
+				current += " "
+			}
+			current += word
+		}
+		if current != "" {
+			line(current)
+		}
+		words = nil
+	}
+
+	for _, text := range strings.Split(strings.TrimSpace(doc), "\n") {
+		text = strings.TrimRight(strings.TrimPrefix(strings.TrimPrefix(text, "//"), " "), " \t")
+		switch {
+		case text == "":
+			flush()
+			line("")
+		case strings.HasPrefix(text, "\t") || strings.HasPrefix(text, "  "):
+			// Code blocks are indented with a tab, as gofmt writes them
+			flush()
+			sb.WriteString(indent + "//\t" + strings.TrimLeft(text, " \t") + "\n")
+		default:
+			words = append(words, strings.Fields(text)...)
+		}
+	}
+	flush()
+	return sb.String()
+}
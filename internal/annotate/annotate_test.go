@@ -0,0 +1,138 @@
+package annotate
+
+import (
+	"context"
+	"go/format"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+	"go.lsp.dev/protocol"
+)
+
+const source = `package shop
+
+// Cart holds items
+type Cart struct{ items []string }
+
+func (c *Cart) Add(item string) {
+	c.items = append(c.items, item)
+}
+
+func (c *Cart) count() int { return len(c.items) }
+
+func New() *Cart { return &Cart{} }
+
+const (
+	MaxItems = 10 // Most items a cart holds
+	MinItems = 1
+)
+
+type (
+	Price int
+)
+
+var Default = New()
+`
+
+// replyHandler answers every request with reply
+type replyHandler struct {
+	reply string
+}
+
+func (h *replyHandler) CreateMessage(context.Context, string, []llm.Message) (llm.ApiStream, error) {
+	ch := make(chan llm.ApiStreamChunk, 1)
+	ch <- llm.ApiStreamTextChunk{Text: h.reply}
+	close(ch)
+	return ch, nil
+}
+
+func (h *replyHandler) GetModel() llm.ModelResponse {
+	return llm.ModelResponse{ID: "test/model"}
+}
+
+func (h *replyHandler) GetApiStreamUsage() (*llm.ApiStreamUsageChunk, error) {
+	return nil, nil
+}
+
+// hoverServer describes every symbol with the same signature
+type hoverServer struct{}
+
+func (hoverServer) OpenFile(context.Context, string) error { return nil }
+
+func (hoverServer) GetHover(_ context.Context, _ string, line, _ int) (*protocol.Hover, error) {
+	if line != 5 {
+		return nil, nil
+	}
+	return &protocol.Hover{Contents: protocol.MarkupContent{Value: "```go\nfunc (c *Cart) Add(item string)\n```\n\nAdd on pkg.go.dev"}}, nil
+}
+
+func TestAnnotate(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "shop"), 0o755)
+	os.WriteFile(filepath.Join(root, "shop", "shop.go"), []byte(source), 0o644)
+	os.WriteFile(filepath.Join(root, "shop", "shop_test.go"), []byte("package shop\n\nfunc Helper() {}\n"), 0o644)
+
+	symbols, err := Find(root, []string{"./shop"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, sym := range symbols {
+		names = append(names, sym.Kind+" "+sym.Name)
+	}
+	want := "method Cart.Add,func New,const MinItems,type Price,var Default"
+	if got := strings.Join(names, ","); got != want {
+		t.Fatalf("symbols = %s, want %s", got, want)
+	}
+
+	Signatures(context.Background(), hoverServer{}, root, symbols)
+	if symbols[0].Signature != "func (c *Cart) Add(item string)" || symbols[1].Signature != "func New() *Cart" {
+		t.Errorf("signatures = %q, %q", symbols[0].Signature, symbols[1].Signature)
+	}
+
+	reply := "```json\n[" +
+		`{"name": "Cart.Add", "doc": "Add puts an item in the cart."},` +
+		`{"name": "New", "doc": "New returns an empty cart, ready to use. It's the cart every shop starts with, and it has no items until Add is called.\n\nFor example:\n\n\tcart := New()"},` +
+		`{"name": "MinItems", "doc": "MinItems is the fewest items a cart checks out with."},` +
+		`{"name": "Price", "doc": "Price is an amount in cents."}` +
+		"]\n```"
+	docs, err := Generate(context.Background(), &replyHandler{reply: reply}, "godoc", symbols)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 4 {
+		t.Errorf("got %d docs", len(docs))
+	}
+
+	// The file changed since the symbols were found
+	os.WriteFile(filepath.Join(root, "shop", "shop.go"), []byte("// Package shop sells things\n"+source), 0o644)
+	changes, err := Plan(root, docs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].Path != "shop/shop.go" {
+		t.Fatalf("changes = %+v", changes)
+	}
+	after := changes[0].After
+	for _, comment := range []string{
+		"// Add puts an item in the cart.\nfunc (c *Cart) Add",
+		"// New returns an empty cart, ready to use. It's the cart every shop starts\n// with, and it has no items until Add is called.\n//\n// For example:\n//\n//\tcart := New()\nfunc New()",
+		"\t// MinItems is the fewest items a cart checks out with.\n\tMinItems = 1",
+		"\t// Price is an amount in cents.\n\tPrice int",
+		"\nvar Default",
+	} {
+		if !strings.Contains(after, comment) {
+			t.Errorf("missing %q in:\n%s", comment, after)
+		}
+	}
+	if formatted, err := format.Source([]byte(after)); err != nil || string(formatted) != after {
+		t.Errorf("annotated file isn't gofmt clean: %v", err)
+	}
+
+	if _, err := Generate(context.Background(), &replyHandler{reply: reply}, "javadoc", symbols); err == nil {
+		t.Error("unknown style accepted")
+	}
+}
@@ -0,0 +1,149 @@
+package annotate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+	"go.lsp.dev/protocol"
+)
+
+// Styles are the comment styles Generate writes, by name
+var Styles = map[string]string{
+	"godoc": "Write each comment the way Go doc comments are written: full sentences, the first starting with the symbol's name " +
+		"(the method's name for methods) and saying what it is or does. Add a second paragraph only for what a caller must know, " +
+		"such as errors returned, concurrency or ownership.",
+	"brief": "Write each comment as a single sentence starting with the symbol's name (the method's name for methods) " +
+		"and saying what it is or does.",
+}
+
+// LanguageServer is the part of a language server client Signatures uses
+type LanguageServer interface {
+	OpenFile(ctx context.Context, path string) error
+	GetHover(ctx context.Context, path string, line, character int) (*protocol.Hover, error)
+}
+
+// Signatures replaces the signatures read from the source with the ones
+// the language server reports, which resolve embedded types and aliases.
+// Symbols it can't describe keep theirs.
+func Signatures(ctx context.Context, ls LanguageServer, root string, symbols []Symbol) {
+	opened := make(map[string]bool)
+	for i, sym := range symbols {
+		path := filepath.Join(root, filepath.FromSlash(sym.File))
+		if !opened[path] {
+			opened[path] = true
+			if err := ls.OpenFile(ctx, path); err != nil {
+				continue
+			}
+		}
+		hover, err := ls.GetHover(ctx, path, sym.Line-1, sym.Column-1)
+		if err != nil || hover == nil {
+			continue
+		}
+		if signature := hoverSignature(hover.Contents.Value); signature != "" {
+			symbols[i].Signature = signature
+		}
+	}
+}
+
+// hoverSignature returns the code block of a hover, where servers such as
+// gopls put the declaration
+func hoverSignature(hover string) string {
+	_, rest, found := strings.Cut(hover, "```")
+	if !found {
+		return ""
+	}
+	// Skip the fence's language
+	_, rest, _ = strings.Cut(rest, "\n")
+	code, _, found := strings.Cut(rest, "```")
+	if !found {
+		return ""
+	}
+	return strings.TrimSpace(code)
+}
+
+const generatePrompt = `You write doc comments for exported Go declarations. You're given each declaration's file, kind, name and signature.
+
+%s
+
+Don't restate the signature or types, and don't invent behavior the signature doesn't suggest; keep to what the name and signature tell a reader. Reply with only a JSON array with an entry for each declaration, in the same order, named exactly as given:
+[{"name": "Name", "doc": "Name does ..."}]
+
+The doc is the comment's text without the // markers.`
+
+// Generate asks the model behind handler for doc comments for symbols in
+// the given style, returning them by symbol. Symbols the model skips get
+// none.
+func Generate(ctx context.Context, handler llm.ApiHandler, style string, symbols []Symbol) (map[Symbol]string, error) {
+	instructions, ok := Styles[style]
+	if !ok {
+		return nil, fmt.Errorf("unknown style %q; use one of %s", style, strings.Join(StyleNames(), ", "))
+	}
+
+	var sb strings.Builder
+	for i, sym := range symbols {
+		fmt.Fprintf(&sb, "%d. %s %s in %s:\n```go\n%s\n```\n\n", i+1, sym.Kind, sym.Name, sym.File, sym.Signature)
+	}
+	messages := []llm.Message{{
+		Role:    "user",
+		Content: []llm.ContentBlock{llm.TextBlock{Text: sb.String()}},
+	}}
+	stream, err := handler.CreateMessage(ctx, fmt.Sprintf(generatePrompt, instructions), messages)
+	if err != nil {
+		return nil, err
+	}
+	var reply strings.Builder
+	for chunk := range stream {
+		if text, ok := chunk.(llm.ApiStreamTextChunk); ok {
+			reply.WriteString(text.Text)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return parse(reply.String(), symbols)
+}
+
+// parse reads the model's JSON reply, tolerating fences and text around
+// the array
+func parse(reply string, symbols []Symbol) (map[Symbol]string, error) {
+	start, end := strings.Index(reply, "["), strings.LastIndex(reply, "]")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("the model didn't reply with doc comments")
+	}
+	var parsed []struct {
+		Name string `json:"name"`
+		Doc  string `json:"doc"`
+	}
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &parsed); err != nil {
+		return nil, fmt.Errorf("the model's doc comments are invalid: %w", err)
+	}
+
+	byName := make(map[string]string)
+	for _, p := range parsed {
+		if doc := strings.TrimSpace(p.Doc); doc != "" {
+			byName[p.Name] = doc
+		}
+	}
+	docs := make(map[Symbol]string)
+	for _, sym := range symbols {
+		if doc, ok := byName[sym.Name]; ok {
+			docs[sym] = doc
+		}
+	}
+	return docs, nil
+}
+
+// StyleNames returns the names of the styles, sorted
+func StyleNames() []string {
+	names := make([]string, 0, len(Styles))
+	for name := range Styles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/permissions"
+	"github.com/gorilla/mux"
+)
+
+// ApprovalDecisionRequest is the optional body of an approve or deny request
+type ApprovalDecisionRequest struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+// setupApprovalQueue creates the approval queue, which broadcasts its changes
+// to WebSocket clients, and routes service's permission requests that need a
+// decision to it; service may be nil, leaving only shell commands to approve
+func (s *Server) setupApprovalQueue(service *permissions.PermissionService) {
+	s.approvals = permissions.NewApprovalQueue(permissions.DefaultApprovalTimeout)
+	s.approvals.OnEvent(func(event string, approval permissions.Approval) {
+		s.connectionManager.BroadcastToAll(WebSocketMessage{
+			Type: event,
+			Data: map[string]interface{}{
+				"approval":  approval,
+				"timestamp": time.Now().Unix(),
+			},
+		})
+	})
+	if service != nil {
+		service.SetRequestHandler(s.approvals.Handle)
+	}
+}
+
+// handleApprovals handles GET /approvals
+func (s *Server) handleApprovals(w http.ResponseWriter, r *http.Request) {
+	if s.approvals == nil {
+		s.writeJSON(w, map[string]interface{}{"approvals": []permissions.Approval{}})
+		return
+	}
+
+	approvals := s.approvals.Pending()
+	s.writeJSON(w, map[string]interface{}{
+		"approvals": approvals,
+		"count":     len(approvals),
+	})
+}
+
+// handleApproval handles GET /approvals/{id}
+func (s *Server) handleApproval(w http.ResponseWriter, r *http.Request) {
+	if s.approvals == nil {
+		s.writeError(w, "Approval queue not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	approval, ok := s.approvals.Get(mux.Vars(r)["id"])
+	if !ok {
+		s.writeError(w, "Approval not found", http.StatusNotFound)
+		return
+	}
+	s.writeJSON(w, approval)
+}
+
+// handleApprove handles POST /approvals/{id}/approve
+func (s *Server) handleApprove(w http.ResponseWriter, r *http.Request) {
+	s.resolveApproval(w, r, true)
+}
+
+// handleDeny handles POST /approvals/{id}/deny
+func (s *Server) handleDeny(w http.ResponseWriter, r *http.Request) {
+	s.resolveApproval(w, r, false)
+}
+
+// resolveApproval records a decision for a queued approval
+func (s *Server) resolveApproval(w http.ResponseWriter, r *http.Request, approved bool) {
+	if s.approvals == nil {
+		s.writeError(w, "Approval queue not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req ApprovalDecisionRequest
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.writeError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+	if req.Reason == "" {
+		req.Reason = "Denied by user"
+		if approved {
+			req.Reason = "Approved by user"
+		}
+	}
+
+	response, err := s.approvals.Resolve(mux.Vars(r)["id"], approved, "api", req.Reason)
+	if err != nil {
+		s.writeError(w, "Approval not found or already resolved", http.StatusNotFound)
+		return
+	}
+	s.writeJSON(w, response)
+}
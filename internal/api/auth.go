@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -18,6 +19,10 @@ type LocalhostAuth struct {
 	sessions map[string]*Session
 	tokens   map[string]*Token
 	mu       sync.RWMutex
+
+	// allowRemote serves sessions from other hosts, for SSO users. Token
+	// login stays localhost-only.
+	allowRemote bool
 }
 
 // Session represents an authenticated session
@@ -29,6 +34,8 @@ type Session struct {
 	ExpiresAt time.Time `json:"expires_at"`
 	IPAddress string    `json:"ip_address"`
 	UserAgent string    `json:"user_agent"`
+	User      string    `json:"user,omitempty"` // SSO user; empty for localhost token sessions
+	Role      string    `json:"role"`
 }
 
 // Token represents an API token
@@ -58,6 +65,16 @@ func (auth *LocalhostAuth) isLocalhost(r *http.Request) bool {
 	// Get the real IP address
 	ip := auth.getRealIP(r)
 
+	// A server reachable from other hosts can't trust forwarding headers,
+	// which any client can set, and a proxied request isn't local even when
+	// the proxy is
+	if auth.allowRemote {
+		if r.Header.Get("X-Forwarded-For") != "" || r.Header.Get("X-Real-IP") != "" {
+			return false
+		}
+		ip, _, _ = net.SplitHostPort(r.RemoteAddr)
+	}
+
 	// Parse the IP
 	parsedIP := net.ParseIP(ip)
 	if parsedIP == nil {
@@ -123,6 +140,16 @@ func (auth *LocalhostAuth) CreateSession(r *http.Request) (*Session, error) {
 		return nil, fmt.Errorf("authentication only available for localhost connections")
 	}
 
+	return auth.newSession(r, "", RoleAdmin)
+}
+
+// CreateUserSession creates a session for a user signed in through SSO
+func (auth *LocalhostAuth) CreateUserSession(r *http.Request, user, role string) (*Session, error) {
+	return auth.newSession(r, user, role)
+}
+
+// newSession creates a session bound to the client's address and user agent
+func (auth *LocalhostAuth) newSession(r *http.Request, user, role string) (*Session, error) {
 	// Generate session ID, token, and salt
 	sessionID, err := auth.generateSecureToken()
 	if err != nil {
@@ -152,6 +179,8 @@ func (auth *LocalhostAuth) CreateSession(r *http.Request) (*Session, error) {
 		ExpiresAt: time.Now().Add(24 * time.Hour), // 24 hour sessions
 		IPAddress: ipAddress,
 		UserAgent: userAgent,
+		User:      user,
+		Role:      role,
 	}
 
 	// Create token hash with multi-factor salt
@@ -269,8 +298,8 @@ func (auth *LocalhostAuth) AuthMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		// Verify localhost
-		if !auth.isLocalhost(r) {
+		// Verify localhost, unless SSO users may connect from elsewhere
+		if !auth.allowRemote && !auth.isLocalhost(r) {
 			http.Error(w, "Access denied: localhost only", http.StatusForbidden)
 			return
 		}
@@ -292,6 +321,10 @@ func (auth *LocalhostAuth) AuthMiddleware(next http.Handler) http.Handler {
 			http.Error(w, "Authentication required", http.StatusUnauthorized)
 			return
 		}
+		if !session.allows(r) {
+			http.Error(w, fmt.Sprintf("Access denied: not permitted for the %s role", session.Role), http.StatusForbidden)
+			return
+		}
 
 		// Add session to request context
 		r = r.WithContext(WithSession(r.Context(), session))
@@ -328,6 +361,39 @@ func (auth *LocalhostAuth) cleanupExpiredSessions() {
 	}
 }
 
+// Sessions returns the active sessions, oldest first
+func (auth *LocalhostAuth) Sessions() []Session {
+	auth.mu.RLock()
+	defer auth.mu.RUnlock()
+
+	sessions := make([]Session, 0, len(auth.sessions))
+	now := time.Now()
+	for _, session := range auth.sessions {
+		if now.Before(session.ExpiresAt) {
+			sessions = append(sessions, *session)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].CreatedAt.Before(sessions[j].CreatedAt) })
+	return sessions
+}
+
+// Revoke ends a session and invalidates its tokens
+func (auth *LocalhostAuth) Revoke(sessionID string) bool {
+	auth.mu.Lock()
+	defer auth.mu.Unlock()
+
+	if _, exists := auth.sessions[sessionID]; !exists {
+		return false
+	}
+	delete(auth.sessions, sessionID)
+	for tokenHash, tokenEntry := range auth.tokens {
+		if tokenEntry.SessionID == sessionID {
+			delete(auth.tokens, tokenHash)
+		}
+	}
+	return true
+}
+
 // GetStats returns authentication statistics
 func (auth *LocalhostAuth) GetStats() map[string]interface{} {
 	auth.mu.RLock()
@@ -2,8 +2,13 @@ package api
 
 import (
 	"encoding/json"
+	"log"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
+
+	"github.com/gorilla/mux"
 )
 
 // LoginRequest represents a login request
@@ -27,6 +32,8 @@ type AuthStatusResponse struct {
 	ExpiresAt     time.Time `json:"expires_at,omitempty"`
 	IPAddress     string    `json:"ip_address,omitempty"`
 	CreatedAt     time.Time `json:"created_at,omitempty"`
+	User          string    `json:"user,omitempty"`
+	Role          string    `json:"role,omitempty"`
 }
 
 // handleAuth handles authentication endpoints
@@ -105,6 +112,8 @@ func (s *Server) handleAuthStatus(w http.ResponseWriter, r *http.Request) {
 		ExpiresAt:     session.ExpiresAt,
 		IPAddress:     session.IPAddress,
 		CreatedAt:     session.CreatedAt,
+		User:          session.User,
+		Role:          session.Role,
 	}
 
 	s.writeJSON(w, response)
@@ -133,16 +142,7 @@ func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Remove session and all associated tokens
-	s.auth.mu.Lock()
-	delete(s.auth.sessions, session.ID)
-
-	// Find and remove all tokens for this session
-	for tokenHash, tokenEntry := range s.auth.tokens {
-		if tokenEntry.SessionID == session.ID {
-			delete(s.auth.tokens, tokenHash)
-		}
-	}
-	s.auth.mu.Unlock()
+	s.auth.Revoke(session.ID)
 
 	response := map[string]interface{}{
 		"success": true,
@@ -152,6 +152,89 @@ func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
 	s.writeJSON(w, response)
 }
 
+// SessionInfo describes an active session to admins, without its token
+type SessionInfo struct {
+	ID        string    `json:"id"`
+	User      string    `json:"user,omitempty"`
+	Role      string    `json:"role"`
+	IPAddress string    `json:"ip_address"`
+	UserAgent string    `json:"user_agent"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// handleSessions lists the active sessions
+func (s *Server) handleSessions(w http.ResponseWriter, r *http.Request) {
+	sessions := s.auth.Sessions()
+	infos := make([]SessionInfo, len(sessions))
+	for i, session := range sessions {
+		infos[i] = SessionInfo{
+			ID:        session.ID,
+			User:      session.User,
+			Role:      session.Role,
+			IPAddress: session.IPAddress,
+			UserAgent: session.UserAgent,
+			CreatedAt: session.CreatedAt,
+			ExpiresAt: session.ExpiresAt,
+		}
+	}
+	s.writeJSON(w, map[string]interface{}{"sessions": infos})
+}
+
+// handleRevokeSession ends a session, signing its user out
+func (s *Server) handleRevokeSession(w http.ResponseWriter, r *http.Request) {
+	if !s.auth.Revoke(mux.Vars(r)["id"]) {
+		s.writeError(w, "Session not found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleOIDCLogin sends the browser to the identity provider to sign in
+func (s *Server) handleOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	if s.oidc == nil {
+		s.writeError(w, "Single sign-on is not configured", http.StatusNotFound)
+		return
+	}
+
+	authURL, err := s.oidc.AuthURL(r.Context())
+	if err != nil {
+		s.writeError(w, "Failed to start sign-on: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// handleOIDCCallback finishes a sign-on and hands the web UI a session token
+func (s *Server) handleOIDCCallback(w http.ResponseWriter, r *http.Request) {
+	if s.oidc == nil {
+		s.writeError(w, "Single sign-on is not configured", http.StatusNotFound)
+		return
+	}
+
+	query := r.URL.Query()
+	if e := query.Get("error"); e != "" {
+		s.writeError(w, strings.TrimSpace("Sign-on failed: "+e+" "+query.Get("error_description")), http.StatusUnauthorized)
+		return
+	}
+	user, err := s.oidc.Exchange(r.Context(), query.Get("state"), query.Get("code"))
+	if err != nil {
+		log.Printf("SSO sign-on refused: %v", err)
+		s.writeError(w, "Sign-on failed: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	session, err := s.auth.CreateUserSession(r, user.Name, user.Role)
+	if err != nil {
+		s.writeError(w, "Failed to create session: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	log.Printf("SSO sign-on: %s as %s", user.Name, user.Role)
+
+	// The fragment keeps the token out of server logs and Referer headers
+	http.Redirect(w, r, "/#token="+url.QueryEscape(session.Token), http.StatusFound)
+}
+
 // handleAuthInfo provides information about the authentication system
 func (s *Server) handleAuthInfo(w http.ResponseWriter, r *http.Request) {
 	info := map[string]interface{}{
@@ -178,6 +261,10 @@ func (s *Server) handleAuthInfo(w http.ResponseWriter, r *http.Request) {
 			"hijacking_protection": true,
 		},
 		"stats": s.auth.GetStats(),
+		"oidc": map[string]interface{}{
+			"enabled":   s.oidc != nil,
+			"login_url": "/api/v1/auth/oidc/login",
+		},
 	}
 
 	s.writeJSON(w, info)
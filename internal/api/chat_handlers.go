@@ -1,15 +1,25 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/entrepeneur4lyf/codeforge/internal/chat"
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+	"github.com/entrepeneur4lyf/codeforge/internal/llm/canary"
+	"github.com/entrepeneur4lyf/codeforge/internal/llm/tools"
 	"github.com/entrepeneur4lyf/codeforge/internal/markdown"
+	"github.com/entrepeneur4lyf/codeforge/internal/shellcmd"
+	"github.com/entrepeneur4lyf/codeforge/internal/storage"
 	"github.com/gorilla/mux"
 )
 
@@ -22,6 +32,22 @@ type ChatSession struct {
 	UpdatedAt time.Time `json:"updated_at"`
 	Model     string    `json:"model"`
 	Provider  string    `json:"provider"`
+	Template  string    `json:"template,omitempty"` // Session template the session was created from
+	Project   string    `json:"project,omitempty"`  // Registered project the session belongs to; empty for the server's own
+
+	// Set when the session works on its own checkout instead of the server's
+	WorkingDir   string            `json:"working_dir,omitempty"`
+	Env          map[string]string `json:"-"` // Values may be secrets, so they're never returned
+	BuildCommand string            `json:"build_command,omitempty"`
+}
+
+// workspace returns the working directory, environment and build command the
+// session set, or nil when it uses the server's
+func (cs *ChatSession) workspace() *tools.Workspace {
+	if cs.WorkingDir == "" && len(cs.Env) == 0 && cs.BuildCommand == "" {
+		return nil
+	}
+	return &tools.Workspace{Dir: cs.WorkingDir, Env: cs.Env, BuildCommand: cs.BuildCommand}
 }
 
 // ChatMessage represents a chat message
@@ -33,6 +59,7 @@ type ChatMessage struct {
 	Timestamp time.Time              `json:"timestamp"`
 	Model     string                 `json:"model,omitempty"`
 	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+	Feedback  *storage.Feedback      `json:"feedback,omitempty"` // User's rating of an assistant message
 }
 
 // EnhancedChatMessage represents a chat message with multiple format support
@@ -54,6 +81,20 @@ type ChatRequest struct {
 	Context  map[string]interface{} `json:"context,omitempty"`
 }
 
+// SendMessageResponse is the reply to a sent message: the assistant's
+// message, with the stored user message alongside
+type SendMessageResponse struct {
+	ChatMessage
+	UserMessage ChatMessage `json:"user_message"`
+}
+
+// FeedbackRequest rates an assistant message
+type FeedbackRequest struct {
+	MessageID string `json:"message_id,omitempty"` // Defaults to the last assistant message
+	Rating    string `json:"rating"`               // "good" or "bad"
+	Reason    string `json:"reason,omitempty"`
+}
+
 // WebSocketMessage represents a WebSocket message
 type WebSocketMessage struct {
 	Type    string      `json:"type"`
@@ -62,10 +103,12 @@ type WebSocketMessage struct {
 	EventID string      `json:"event_id,omitempty"`
 }
 
-// ChatStorage manages chat sessions and messages in memory
+// ChatStorage manages chat sessions and messages in memory, optionally
+// writing them through to the persistent store shared with other clients
 type ChatStorage struct {
 	sessions map[string]*ChatSession
 	messages map[string][]ChatMessage
+	store    storage.ChatStore
 	mu       sync.RWMutex
 }
 
@@ -93,6 +136,7 @@ func (cs *ChatStorage) CreateSession(title string) *ChatSession {
 
 	cs.sessions[sessionID] = session
 	cs.messages[sessionID] = []ChatMessage{}
+	cs.persistSession(session)
 	return session
 }
 
@@ -127,16 +171,24 @@ func (cs *ChatStorage) DeleteSession(sessionID string) bool {
 
 	delete(cs.sessions, sessionID)
 	delete(cs.messages, sessionID)
+	cs.deletePersistedSession(sessionID)
 	return true
 }
 
 // AddMessage adds a message to a session
 func (cs *ChatStorage) AddMessage(sessionID string, message ChatMessage) error {
+	_, err := cs.storeMessage(sessionID, message)
+	return err
+}
+
+// storeMessage adds a message to a session, returning it with the ID and
+// time it was stored under
+func (cs *ChatStorage) storeMessage(sessionID string, message ChatMessage) (ChatMessage, error) {
 	cs.mu.Lock()
 	defer cs.mu.Unlock()
 
 	if _, exists := cs.sessions[sessionID]; !exists {
-		return fmt.Errorf("session not found")
+		return ChatMessage{}, fmt.Errorf("session not found")
 	}
 
 	message.ID = fmt.Sprintf("msg-%d", time.Now().UnixNano())
@@ -147,7 +199,8 @@ func (cs *ChatStorage) AddMessage(sessionID string, message ChatMessage) error {
 	// Update session timestamp
 	cs.sessions[sessionID].UpdatedAt = time.Now()
 
-	return nil
+	cs.persistMessage(cs.sessions[sessionID], message)
+	return message, nil
 }
 
 // GetMessages retrieves all messages for a session
@@ -165,20 +218,66 @@ func (cs *ChatStorage) GetMessages(sessionID string) ([]ChatMessage, error) {
 	return result, nil
 }
 
+// SetFeedback rates a message of a session, by default its last assistant
+// message, and returns the rated message
+func (cs *ChatStorage) SetFeedback(sessionID, messageID string, feedback *storage.Feedback) (ChatMessage, error) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if _, exists := cs.sessions[sessionID]; !exists {
+		return ChatMessage{}, fmt.Errorf("session not found")
+	}
+
+	messages := cs.messages[sessionID]
+	for i := len(messages) - 1; i >= 0; i-- {
+		msg := &messages[i]
+		if messageID == "" && msg.Role != "assistant" || messageID != "" && msg.ID != messageID {
+			continue
+		}
+		if msg.Role != "assistant" {
+			return ChatMessage{}, fmt.Errorf("only assistant messages can be rated")
+		}
+
+		feedback.Model = msg.Model
+		msg.Feedback = feedback
+		cs.persistFeedback(msg.ID, feedback)
+		return *msg, nil
+	}
+	return ChatMessage{}, fmt.Errorf("message not found")
+}
+
 // createLLMChatSession creates a real LLM chat session with proper API key integration
-func (s *Server) createLLMChatSession(model string) (*chat.ChatSession, error) {
+func (s *Server) createLLMChatSession(model, provider, template string, ws *tools.Workspace) (*chat.ChatSession, error) {
 	// Get API key for the model using the chat module's logic
-	apiKey := chat.GetAPIKeyForModel(model)
+	keyModel := model
+	if provider != "" && !strings.Contains(model, "/") {
+		keyModel = provider + "/" + model
+	}
+	apiKey := chat.GetAPIKeyForModel(keyModel)
 	if apiKey == "" {
-		return nil, fmt.Errorf("no API key found for model: %s", model)
+		return nil, fmt.Errorf("no API key found for model: %s", keyModel)
 	}
 
 	// Create chat session using the proper chat module
-	session, err := chat.NewChatSession(model, apiKey, "", true, "text")
+	session, err := chat.NewChatSession(model, apiKey, provider, true, "text")
 	if err != nil {
 		return nil, fmt.Errorf("failed to create chat session: %w", err)
 	}
 
+	if template != "" {
+		t, err := config.Template(template)
+		if err != nil {
+			return nil, err
+		}
+		session.ApplyTemplate(t)
+	}
+
+	if ws != nil {
+		if err := session.SetWorkspace(*ws); err != nil {
+			return nil, err
+		}
+	}
+
 	return session, nil
 }
 
@@ -194,7 +293,23 @@ func (s *Server) handleChatSessions(w http.ResponseWriter, r *http.Request) {
 
 // getChatSessions returns all chat sessions
 func (s *Server) getChatSessions(w http.ResponseWriter, r *http.Request) {
-	sessions := s.chatStorage.GetAllSessions()
+	scope, err := s.projectScope(r)
+	if err != nil {
+		s.writeError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	// Each project lists its own sessions. Saved sessions were started in
+	// the server's own project.
+	var sessions []*ChatSession
+	for _, session := range s.chatStorage.GetAllSessions() {
+		if scope.project == nil && session.Project == "" || scope.project != nil && session.Project == scope.project.ID {
+			sessions = append(sessions, session)
+		}
+	}
+	if scope.project == nil {
+		sessions = append(sessions, s.persistedSessions(r.Context())...)
+	}
 
 	s.writeJSON(w, map[string]interface{}{
 		"sessions": sessions,
@@ -208,6 +323,13 @@ func (s *Server) createChatSession(w http.ResponseWriter, r *http.Request) {
 		Title    string `json:"title"`
 		Model    string `json:"model,omitempty"`
 		Provider string `json:"provider,omitempty"`
+		Template string `json:"template,omitempty"` // Session template, such as "bugfix"
+
+		// Tools, builds and commands run here instead of the server's
+		// directory, so one server can work on several checkouts
+		WorkingDir   string            `json:"working_dir,omitempty"`
+		Env          map[string]string `json:"env,omitempty"`
+		BuildCommand string            `json:"build_command,omitempty"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -215,6 +337,48 @@ func (s *Server) createChatSession(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// A session in a registered project works in its directory, with its
+	// default model
+	scope, err := s.projectScope(r)
+	if err != nil {
+		s.writeError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if scope.project != nil {
+		if req.WorkingDir == "" {
+			req.WorkingDir = scope.project.Path
+		}
+		if req.Model == "" && req.Template == "" {
+			req.Model = scope.config.ResolveModel(scope.config.DefaultModelID())
+		}
+	}
+
+	// A template chooses the model unless the request does
+	if req.Template != "" {
+		t, err := config.Template(req.Template)
+		if err != nil {
+			s.writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.Model == "" && t.Model != "" {
+			req.Model = config.ResolveModel(t.Model)
+			req.Provider = t.Provider
+		}
+	}
+
+	if req.WorkingDir != "" {
+		dir, err := filepath.Abs(req.WorkingDir)
+		if err != nil {
+			s.writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			s.writeError(w, fmt.Sprintf("working_dir %s is not a directory", req.WorkingDir), http.StatusBadRequest)
+			return
+		}
+		req.WorkingDir = dir
+	}
+
 	if req.Title == "" {
 		req.Title = "New Chat Session"
 	}
@@ -222,6 +386,13 @@ func (s *Server) createChatSession(w http.ResponseWriter, r *http.Request) {
 	session := s.chatStorage.CreateSession(req.Title)
 	session.Model = req.Model
 	session.Provider = req.Provider
+	session.Template = req.Template
+	session.WorkingDir = req.WorkingDir
+	if scope.project != nil {
+		session.Project = scope.project.ID
+	}
+	session.Env = req.Env
+	session.BuildCommand = req.BuildCommand
 
 	w.WriteHeader(http.StatusCreated)
 	s.writeJSON(w, session)
@@ -242,7 +413,7 @@ func (s *Server) handleChatSession(w http.ResponseWriter, r *http.Request) {
 
 // getChatSession returns a specific chat session
 func (s *Server) getChatSession(w http.ResponseWriter, r *http.Request, sessionID string) {
-	session, exists := s.chatStorage.GetSession(sessionID)
+	session, exists := s.loadPersistedSession(r.Context(), sessionID)
 	if !exists {
 		s.writeError(w, "Session not found", http.StatusNotFound)
 		return
@@ -254,6 +425,7 @@ func (s *Server) getChatSession(w http.ResponseWriter, r *http.Request, sessionI
 // deleteChatSession deletes a chat session
 func (s *Server) deleteChatSession(w http.ResponseWriter, r *http.Request, sessionID string) {
 	// Implement actual session deletion using ChatStorage
+	s.loadPersistedSession(r.Context(), sessionID)
 	if !s.chatStorage.DeleteSession(sessionID) {
 		s.writeError(w, "Session not found", http.StatusNotFound)
 		return
@@ -278,6 +450,7 @@ func (s *Server) handleChatMessages(w http.ResponseWriter, r *http.Request) {
 // getChatMessages returns messages for a session
 func (s *Server) getChatMessages(w http.ResponseWriter, r *http.Request, sessionID string) {
 	// Implement actual message retrieval using ChatStorage
+	s.loadPersistedSession(r.Context(), sessionID)
 	messages, err := s.chatStorage.GetMessages(sessionID)
 	if err != nil {
 		s.writeError(w, err.Error(), http.StatusNotFound)
@@ -290,87 +463,237 @@ func (s *Server) getChatMessages(w http.ResponseWriter, r *http.Request, session
 	})
 }
 
-// sendChatMessage sends a new message in a session
+// sendChatMessage sends a new message in a session, generating the reply
+// with the session's model and provider, and returns both messages
 func (s *Server) sendChatMessage(w http.ResponseWriter, r *http.Request, sessionID string) {
 	var req ChatRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		s.writeError(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
+	if req.Message == "" {
+		s.writeError(w, "Message is required", http.StatusBadRequest)
+		return
+	}
 
-	// Create user message
-	userMessage := ChatMessage{
-		ID:        generateMessageID(),
+	// One generation at a time per session keeps messages in order
+	lock := s.lockChatSession(w, sessionID)
+	if lock == nil {
+		return
+	}
+	defer lock.Release()
+
+	// Load the session if another client started it
+	session, exists := s.loadPersistedSession(r.Context(), sessionID)
+	if !exists {
+		s.writeError(w, "Session not found", http.StatusNotFound)
+		return
+	}
+
+	// The request may pick another model than the session's
+	model, provider := req.Model, req.Provider
+	if model == "" {
+		model, provider = session.Model, session.Provider
+	}
+	if model == "" {
+		model = chat.GetDefaultModel()
+	}
+
+	resp, status, err := s.exchange(r.Context(), session, ChatMessage{
 		SessionID: sessionID,
 		Role:      "user",
 		Content:   req.Message,
-		Timestamp: time.Now(),
+		Model:     model,
 		Metadata:  req.Context,
+	}, provider)
+	if err != nil {
+		s.writeError(w, err.Error(), status)
+		return
 	}
+	s.writeJSON(w, resp)
+}
 
-	// Store user message
-	s.chatStorage.AddMessage(sessionID, userMessage)
+// exchange stores a user message in the session, generates the reply and
+// stores it, returning both. The reply's metadata records its usage and the
+// commands it proposes running. On failure it returns the HTTP status to
+// report.
+func (s *Server) exchange(ctx context.Context, session *ChatSession, message ChatMessage, provider string) (*SendMessageResponse, int, error) {
+	history, _ := s.chatStorage.GetMessages(session.ID)
+	userMessage, err := s.chatStorage.storeMessage(session.ID, message)
+	if err != nil {
+		return nil, http.StatusNotFound, err
+	}
 
-	// Get session to determine model
-	session, exists := s.chatStorage.GetSession(sessionID)
-	if !exists {
-		s.writeError(w, "Session not found", http.StatusNotFound)
+	response, usage, err := s.generateReply(ctx, session, history, message.Content, message.Model, provider)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("Failed to process message: %v", err)
+	}
+
+	metadata := usageMetadata(usage)
+	if commands := shellcmd.Extract(response); len(commands) > 0 {
+		if metadata == nil {
+			metadata = make(map[string]interface{})
+		}
+		metadata["commands"] = commands
+	}
+	assistantMessage, err := s.chatStorage.storeMessage(session.ID, ChatMessage{
+		SessionID: session.ID,
+		Role:      "assistant",
+		Content:   response,
+		Model:     message.Model,
+		Metadata:  metadata,
+	})
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	s.publishStream(session.ID, "chat_response", assistantMessage)
+
+	return &SendMessageResponse{ChatMessage: assistantMessage, UserMessage: userMessage}, http.StatusOK, nil
+}
+
+// generateReply answers a message sent in a session. The app's pipeline,
+// with its context management, answers for the session's default provider;
+// a chat session given the conversation so far answers sessions with a
+// template or an explicit provider, and when the app fails. The reply's
+// usage is returned with it when known.
+func (s *Server) generateReply(ctx context.Context, session *ChatSession, history []ChatMessage, message, model, provider string) (string, *llm.Usage, error) {
+	// The app works in the server's directory, so sessions with their own
+	// get a chat session of their own
+	if s.app != nil && session.Template == "" && provider == "" && session.workspace() == nil {
+		response, usage, err := s.app.ProcessChatMessageWithUsage(ctx, session.ID, message, model)
+		if err == nil {
+			return response, usage, nil
+		}
+		log.Printf("Error processing chat message with app: %v", err)
+	}
+
+	llmSession, err := s.createLLMChatSession(model, provider, session.Template, session.workspace())
+	if err != nil {
+		return "", nil, err
+	}
+	stored := make([]storage.Message, 0, len(history))
+	for _, msg := range history {
+		stored = append(stored, storage.Message{Role: msg.Role, Content: msg.Content})
+	}
+	llmSession.LoadHistory(stored)
+	response, err := llmSession.ProcessMessage(message)
+	if err != nil {
+		return "", nil, err
+	}
+	return response, llmSession.LastUsage(), nil
+}
+
+// handleChatStream handles POST /chat/sessions/{id}/messages/stream, sending
+// a message and streaming the response as Server-Sent Events in the unified
+// stream format: text, reasoning, tool_call_delta, usage and finish_reason
+// events, then done
+func (s *Server) handleChatStream(w http.ResponseWriter, r *http.Request) {
+	sessionID := mux.Vars(r)["id"]
+
+	var req ChatRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Message == "" {
+		s.writeError(w, "Message is required", http.StatusBadRequest)
+		return
+	}
+	if s.app == nil {
+		s.writeError(w, "Chat processing is not available", http.StatusServiceUnavailable)
+		return
+	}
+	if _, ok := w.(http.Flusher); !ok {
+		s.writeError(w, "Streaming is not supported", http.StatusInternalServerError)
 		return
 	}
 
-	// Use default model if none specified
-	model := session.Model
+	lock := s.lockChatSession(w, sessionID)
+	if lock == nil {
+		return
+	}
+	defer lock.Release()
+
+	ctx := r.Context()
+	model := req.Model
+	if session, exists := s.loadPersistedSession(ctx, sessionID); exists {
+		if model == "" {
+			model = session.Model
+		}
+		if ws := session.workspace(); ws != nil {
+			ctx = tools.WithWorkspace(ctx, *ws)
+		}
+	}
 	if model == "" {
 		model = chat.GetDefaultModel()
 	}
 
-	// Create LLM chat session
-	llmSession, err := s.createLLMChatSession(model)
+	_, stream, err := s.app.ProcessChatMessageWithStream(ctx, sessionID, req.Message, model)
 	if err != nil {
-		s.writeError(w, fmt.Sprintf("Failed to create LLM session: %v", err), http.StatusInternalServerError)
+		s.publishStream(sessionID, "error", map[string]string{"error": "Failed to process message"})
+		s.writeError(w, fmt.Sprintf("Failed to process message: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	// Process message with integrated CodeForge app if available
-	var response string
-	if s.app != nil {
-		ctx := r.Context()
-		appResponse, err := s.app.ProcessChatMessage(ctx, sessionID, req.Message, model)
-		if err != nil {
-			log.Printf("Error processing chat message with app: %v", err)
-			// Fallback to LLM session
-			response, err = llmSession.ProcessMessage(req.Message)
-			if err != nil {
-				s.writeError(w, fmt.Sprintf("Failed to process message: %v", err), http.StatusInternalServerError)
-				return
-			}
-		} else {
-			response = appResponse
-		}
-	} else {
-		// Use LLM session directly
-		response, err = llmSession.ProcessMessage(req.Message)
-		if err != nil {
-			s.writeError(w, fmt.Sprintf("Failed to process message: %v", err), http.StatusInternalServerError)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	for chunk := range stream {
+		event := llm.NewStreamEvent(chunk)
+		s.publishStream(sessionID, "stream", event)
+		if err := s.writeSSEEvent(w, SSEEvent{Event: chunk.Type(), Data: event}); err != nil {
+			// The client left; let the generation wind down for followers
+			go func() {
+				for chunk := range stream {
+					s.publishStream(sessionID, "stream", llm.NewStreamEvent(chunk))
+				}
+				s.importLatestMessages(sessionID, 2)
+				if message, ok := s.latestAssistantMessage(sessionID); ok {
+					s.publishStream(sessionID, "chat_response", message)
+				}
+			}()
 			return
 		}
 	}
 
-	// Create assistant message
-	assistantMessage := ChatMessage{
-		ID:        generateMessageID(),
-		SessionID: sessionID,
-		Role:      "assistant",
-		Content:   response,
-		Timestamp: time.Now(),
-		Model:     model,
+	// The app saved the exchange to the store; show it in the session too
+	s.importLatestMessages(sessionID, 2)
+	if message, ok := s.latestAssistantMessage(sessionID); ok {
+		s.publishStream(sessionID, "chat_response", message)
 	}
+	s.writeSSEEvent(w, SSEEvent{Event: "done", Data: map[string]string{"session_id": sessionID}})
+}
 
-	// Store assistant message
-	s.chatStorage.AddMessage(sessionID, assistantMessage)
+// handleChatFeedback handles POST /chat/sessions/{id}/feedback, rating an
+// assistant message as good or bad
+func (s *Server) handleChatFeedback(w http.ResponseWriter, r *http.Request) {
+	sessionID := mux.Vars(r)["id"]
+
+	var req FeedbackRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Rating != storage.FeedbackGood && req.Rating != storage.FeedbackBad {
+		s.writeError(w, `Rating must be "good" or "bad"`, http.StatusBadRequest)
+		return
+	}
 
-	// Return assistant response
-	s.writeJSON(w, assistantMessage)
+	if _, exists := s.loadPersistedSession(r.Context(), sessionID); !exists {
+		s.writeError(w, "Session not found", http.StatusNotFound)
+		return
+	}
+
+	feedback := &storage.Feedback{Rating: req.Rating, Reason: req.Reason, CreatedAt: time.Now()}
+	message, err := s.chatStorage.SetFeedback(sessionID, req.MessageID, feedback)
+	if err != nil {
+		s.writeError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	canary.Default().RecordFeedback(sessionID, req.Rating == storage.FeedbackGood)
+
+	s.writeJSON(w, message)
 }
 
 // sendChatMessageEnhanced handles enhanced chat messages with markdown support
@@ -390,8 +713,14 @@ func (s *Server) sendChatMessageEnhanced(w http.ResponseWriter, r *http.Request)
 	vars := mux.Vars(r)
 	sessionID := vars["sessionID"]
 
+	lock := s.lockChatSession(w, sessionID)
+	if lock == nil {
+		return
+	}
+	defer lock.Release()
+
 	// Get or create session
-	session, exists := s.chatStorage.GetSession(sessionID)
+	session, exists := s.loadPersistedSession(r.Context(), sessionID)
 	if !exists {
 		// Create new session using CreateSession method
 		session = s.chatStorage.CreateSession("Enhanced Chat Session")
@@ -414,6 +743,7 @@ func (s *Server) sendChatMessageEnhanced(w http.ResponseWriter, r *http.Request)
 
 	// Process with AI (using CodeForge app integration)
 	var responseContent string
+	var usage *llm.Usage
 	if s.app != nil {
 		ctx := r.Context()
 		modelID := req.Model
@@ -424,13 +754,13 @@ func (s *Server) sendChatMessageEnhanced(w http.ResponseWriter, r *http.Request)
 			modelID = "default"
 		}
 
-		response, err := s.app.ProcessChatMessage(ctx, sessionID, req.Message, modelID)
+		response, responseUsage, err := s.app.ProcessChatMessageWithUsage(ctx, sessionID, req.Message, modelID)
 		if err != nil {
 			log.Printf("Chat processing error: %v", err)
 			s.writeError(w, "Failed to process message", http.StatusInternalServerError)
 			return
 		}
-		responseContent = response
+		responseContent, usage = response, responseUsage
 	} else {
 		responseContent = "Chat processing is not available - app not initialized"
 	}
@@ -452,11 +782,19 @@ func (s *Server) sendChatMessageEnhanced(w http.ResponseWriter, r *http.Request)
 				"via":      "rest_api",
 			},
 		}
+		for key, value := range usageMetadata(usage) {
+			assistantMessage.Metadata[key] = value
+		}
 		s.chatStorage.AddMessage(sessionID, assistantMessage)
+		s.publishStream(sessionID, "chat_response", assistantMessage)
 		s.writeJSON(w, assistantMessage)
 		return
 	}
 
+	for key, value := range usageMetadata(usage) {
+		enhancedResponse.Metadata[key] = value
+	}
+
 	// Convert enhanced message to regular message for storage
 	assistantMessage := ChatMessage{
 		ID:        enhancedResponse.ID,
@@ -468,6 +806,7 @@ func (s *Server) sendChatMessageEnhanced(w http.ResponseWriter, r *http.Request)
 		Metadata:  enhancedResponse.Metadata,
 	}
 	s.chatStorage.AddMessage(sessionID, assistantMessage)
+	s.publishStream(sessionID, "chat_response", assistantMessage)
 
 	// Return enhanced response with all formats
 	s.writeJSON(w, enhancedResponse)
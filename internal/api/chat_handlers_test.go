@@ -0,0 +1,173 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestSendChatMessage(t *testing.T) {
+	s := NewServer(nil)
+	session := s.chatStorage.CreateSession("Echo")
+	session.Model = "mock/echo"
+
+	send := func(sessionID, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/chat/sessions/"+sessionID+"/messages", strings.NewReader(body))
+		req = mux.SetURLVars(req, map[string]string{"id": sessionID})
+		rec := httptest.NewRecorder()
+		s.handleChatMessages(rec, req)
+		return rec
+	}
+
+	rec := send(session.ID, `{"message": "hello there"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var reply SendMessageResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply.Role != "assistant" || !strings.Contains(reply.Content, "hello there") || reply.Model != "mock/echo" {
+		t.Errorf("reply = %+v", reply.ChatMessage)
+	}
+	if reply.UserMessage.Role != "user" || reply.UserMessage.Content != "hello there" {
+		t.Errorf("user message = %+v", reply.UserMessage)
+	}
+
+	// Both are stored under the IDs returned
+	messages, _ := s.chatStorage.GetMessages(session.ID)
+	if len(messages) != 2 || messages[0].ID != reply.UserMessage.ID || messages[1].ID != reply.ID {
+		t.Errorf("stored %+v", messages)
+	}
+
+	if rec := send(session.ID, `{"message": ""}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("empty message status %d", rec.Code)
+	}
+	if rec := send("missing", `{"message": "hi"}`); rec.Code != http.StatusNotFound {
+		t.Errorf("missing session status %d", rec.Code)
+	}
+}
+
+func TestCreateChatSessionWorkspace(t *testing.T) {
+	s := NewServer(nil)
+	create := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		s.createChatSession(rec, httptest.NewRequest(http.MethodPost, "/api/v1/chat/sessions", strings.NewReader(body)))
+		return rec
+	}
+
+	dir := t.TempDir()
+	rec := create(`{"title": "Checkout", "working_dir": "` + dir + `", "env": {"TOKEN": "secret"}, "build_command": "make"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	if strings.Contains(rec.Body.String(), "secret") {
+		t.Errorf("env values returned: %s", rec.Body)
+	}
+	var session ChatSession
+	if err := json.Unmarshal(rec.Body.Bytes(), &session); err != nil {
+		t.Fatal(err)
+	}
+	stored, _ := s.chatStorage.GetSession(session.ID)
+	ws := stored.workspace()
+	if ws == nil || ws.Dir != dir || ws.Env["TOKEN"] != "secret" || ws.BuildCommand != "make" {
+		t.Errorf("workspace = %+v", ws)
+	}
+
+	if rec := create(`{"working_dir": "` + dir + `/missing"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("missing directory status %d", rec.Code)
+	}
+}
+
+func TestRunCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shell syntax")
+	}
+	if _, err := exec.LookPath("make"); err != nil {
+		t.Skip("make is not installed")
+	}
+	s := NewServer(nil)
+	session := s.chatStorage.CreateSession("Commands")
+	session.Model = "mock/echo"
+	session.WorkingDir = t.TempDir()
+	session.Env = map[string]string{"GREETING": "hi"}
+
+	propose := func(commands interface{}) {
+		if _, err := s.chatStorage.storeMessage(session.ID, ChatMessage{Role: "assistant", Content: "Run it", Metadata: map[string]interface{}{"commands": commands}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// run posts the command and decides the approval it waits for, if any
+	run := func(body string, approve bool) *httptest.ResponseRecorder {
+		done := make(chan *httptest.ResponseRecorder)
+		go func() {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/chat/sessions/"+session.ID+"/commands", strings.NewReader(body))
+			req = mux.SetURLVars(req, map[string]string{"id": session.ID})
+			rec := httptest.NewRecorder()
+			s.handleRunCommand(rec, req)
+			done <- rec
+		}()
+		for {
+			select {
+			case rec := <-done:
+				return rec
+			case <-time.After(10 * time.Millisecond):
+				for _, approval := range s.approvals.Pending() {
+					s.approvals.Resolve(approval.Request.ID, approve, "test", "Decided by test")
+				}
+			}
+		}
+	}
+
+	if rec := run(`{"command": "pwd"}`, true); rec.Code != http.StatusForbidden {
+		t.Errorf("command nobody proposed status %d", rec.Code)
+	}
+	propose([]string{"pwd"})
+	if rec := run(`{"command": "pwd"}`, false); rec.Code != http.StatusForbidden {
+		t.Errorf("denied approval status %d", rec.Code)
+	}
+
+	rec := run(`{"command": "pwd", "reply": true}`, true)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var resp RunCommandResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Result.ExitCode != 0 || strings.TrimSpace(resp.Result.Output) != session.WorkingDir {
+		t.Errorf("result = %+v", resp.Result)
+	}
+	if !strings.Contains(resp.Message.Content, "`pwd`") || resp.Reply == nil || !strings.Contains(resp.Reply.Content, "exited with status 0") {
+		t.Errorf("messages = %+v, %+v", resp.Message, resp.Reply)
+	}
+	if messages, _ := s.chatStorage.GetMessages(session.ID); len(messages) != 3 {
+		t.Errorf("stored %d messages", len(messages))
+	}
+
+	// The session's env reaches the command, proposed here in the form a
+	// message loaded from the chat store has
+	if err := os.WriteFile(filepath.Join(session.WorkingDir, "Makefile"), []byte("greet:\n\t@echo $(GREETING)\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	propose([]interface{}{"make -s greet"})
+	rec = run(`{"command": "make -s greet"}`, true)
+	resp = RunCommandResponse{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.Result == nil || strings.TrimSpace(resp.Result.Output) != "hi" {
+		t.Errorf("status %d: %s", rec.Code, rec.Body)
+	}
+
+	propose([]string{"curl https://example.com"})
+	if rec := run(`{"command": "curl https://example.com"}`, true); rec.Code != http.StatusForbidden {
+		t.Errorf("denied command status %d", rec.Code)
+	}
+}
@@ -0,0 +1,179 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/chat"
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+	"github.com/entrepeneur4lyf/codeforge/internal/permissions"
+	"github.com/entrepeneur4lyf/codeforge/internal/shellcmd"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// RunCommandRequest asks to run a command listed in the "commands" metadata
+// of one of the session's assistant messages
+type RunCommandRequest struct {
+	Command string `json:"command"`
+	// Reply sends the output to the model and returns its reply; otherwise
+	// the output is only stored in the session
+	Reply bool `json:"reply,omitempty"`
+}
+
+// RunCommandResponse is the command's result, the message recording it and,
+// when a reply was asked for, the model's reply
+type RunCommandResponse struct {
+	Result  *shellcmd.Result `json:"result"`
+	Message ChatMessage      `json:"message"`
+	Reply   *ChatMessage     `json:"reply,omitempty"`
+}
+
+// handleRunCommand handles POST /chat/sessions/{id}/commands, running a
+// command an assistant message of the session proposed in its working
+// directory. The command is checked against the shell policy, then waits in
+// the approval queue and runs only once it's approved there.
+func (s *Server) handleRunCommand(w http.ResponseWriter, r *http.Request) {
+	sessionID := mux.Vars(r)["id"]
+
+	var req RunCommandRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Command == "" {
+		s.writeError(w, "Command is required", http.StatusBadRequest)
+		return
+	}
+
+	policy := shellcmd.NewPolicy(config.Get())
+	if err := policy.Check(req.Command); err != nil {
+		s.writeError(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	session, exists := s.loadPersistedSession(r.Context(), sessionID)
+	if !exists {
+		s.writeError(w, "Session not found", http.StatusNotFound)
+		return
+	}
+	if !s.proposedCommand(sessionID, req.Command) {
+		s.writeError(w, "No assistant message in this session proposed the command", http.StatusForbidden)
+		return
+	}
+	if s.approvals == nil {
+		s.writeError(w, "Approval queue not available", http.StatusServiceUnavailable)
+		return
+	}
+	if response := s.approveCommand(r.Context(), session, req.Command); response.Status != permissions.StatusApproved {
+		s.writeError(w, "Command not approved: "+response.Reason, http.StatusForbidden)
+		return
+	}
+
+	// The output joins the conversation, so keep it in order with messages
+	lock := s.lockChatSession(w, sessionID)
+	if lock == nil {
+		return
+	}
+	defer lock.Release()
+
+	var env []string
+	if ws := session.workspace(); ws != nil {
+		env = ws.Environ()
+	}
+	result, err := shellcmd.Run(r.Context(), session.WorkingDir, env, req.Command, policy.Timeout)
+	if err != nil {
+		s.writeError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	model := session.Model
+	if model == "" {
+		model = chat.GetDefaultModel()
+	}
+	message := ChatMessage{
+		SessionID: sessionID,
+		Role:      "user",
+		Content:   result.Message(),
+		Model:     model,
+		Metadata:  map[string]interface{}{"command": req.Command, "exit_code": result.ExitCode},
+	}
+
+	if !req.Reply {
+		stored, err := s.chatStorage.storeMessage(sessionID, message)
+		if err != nil {
+			s.writeError(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		s.writeJSON(w, RunCommandResponse{Result: result, Message: stored})
+		return
+	}
+
+	resp, status, err := s.exchange(r.Context(), session, message, session.Provider)
+	if err != nil {
+		s.writeError(w, err.Error(), status)
+		return
+	}
+	s.writeJSON(w, RunCommandResponse{Result: result, Message: resp.UserMessage, Reply: &resp.ChatMessage})
+}
+
+// proposedCommand reports whether an assistant message of the session lists
+// command in its "commands" metadata
+func (s *Server) proposedCommand(sessionID, command string) bool {
+	messages, err := s.chatStorage.GetMessages(sessionID)
+	if err != nil {
+		return false
+	}
+	for _, message := range messages {
+		if message.Role != "assistant" {
+			continue
+		}
+		// Messages loaded from the chat store carry the JSON decoding
+		switch commands := message.Metadata["commands"].(type) {
+		case []string:
+			for _, c := range commands {
+				if c == command {
+					return true
+				}
+			}
+		case []interface{}:
+			for _, c := range commands {
+				if c == command {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// approveCommand queues command for approval and waits for the decision.
+// The request is denied if the client goes away first.
+func (s *Server) approveCommand(ctx context.Context, session *ChatSession, command string) *permissions.PermissionResponse {
+	req := &permissions.PermissionRequest{
+		ID:        uuid.New().String(),
+		SessionID: session.ID,
+		Type:      permissions.PermissionShellAccess,
+		Resource:  session.WorkingDir,
+		Scope:     permissions.ScopeOneTime,
+		Reason:    "Run " + command,
+		Context: map[string]interface{}{
+			"tool":   "shell",
+			"action": "run",
+			"params": map[string]interface{}{"command": command},
+		},
+		RequestedAt: time.Now(),
+	}
+	stop := context.AfterFunc(ctx, func() {
+		s.approvals.Resolve(req.ID, false, "api", "Request canceled")
+	})
+	defer stop()
+
+	response, err := s.approvals.Handle(req)
+	if err != nil {
+		return &permissions.PermissionResponse{RequestID: req.ID, Status: permissions.StatusDenied, Reason: err.Error()}
+	}
+	return response
+}
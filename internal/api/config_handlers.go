@@ -3,7 +3,15 @@ package api
 import (
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+	"github.com/entrepeneur4lyf/codeforge/internal/embeddings"
+	"github.com/entrepeneur4lyf/codeforge/internal/models"
 )
 
 // ConfigResponse represents the configuration response
@@ -18,7 +26,8 @@ type ConfigResponse struct {
 type LLMConfig struct {
 	DefaultProvider string            `json:"default_provider"`
 	DefaultModel    string            `json:"default_model"`
-	Providers       map[string]bool   `json:"providers"`
+	Providers       map[string]bool   `json:"providers"`           // Enabled state of each configured provider
+	Available       map[string]bool   `json:"available,omitempty"` // Whether each provider can be used now; read-only
 	Settings        map[string]string `json:"settings"`
 }
 
@@ -27,6 +36,7 @@ type EmbeddingConfig struct {
 	Provider   string `json:"provider"`
 	Model      string `json:"model"`
 	Dimensions int    `json:"dimensions"`
+	Active     string `json:"active,omitempty"` // Provider in use, which "auto" resolves to; read-only
 }
 
 // DatabaseConfig represents database configuration
@@ -63,76 +73,68 @@ func (s *Server) handleConfig(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// getConfig returns current configuration
+// getConfig returns the live configuration, the state of the vector
+// database and which LLM providers can actually be used
 func (s *Server) getConfig(w http.ResponseWriter, r *http.Request) {
-	config := ConfigResponse{
+	if s.config == nil {
+		s.writeError(w, "Configuration service not available", http.StatusInternalServerError)
+		return
+	}
+
+	resp := ConfigResponse{
 		LLM: LLMConfig{
-			DefaultProvider: "anthropic",
-			DefaultModel:    "claude-3-5-sonnet-20241022",
-			Providers: map[string]bool{
-				"anthropic":  true,
-				"openai":     true,
-				"openrouter": true,
-				"ollama":     false,
-			},
-			Settings: map[string]string{
-				"temperature": "0.7",
-				"max_tokens":  "4096",
-				"timeout":     "30s",
-			},
+			DefaultProvider: s.config.Provider,
+			DefaultModel:    s.config.Model,
+			Providers:       map[string]bool{},
+			Available:       map[string]bool{},
+			Settings:        map[string]string{},
 		},
 		Embedding: EmbeddingConfig{
-			Provider:   "fallback",
-			Model:      "hash-based",
-			Dimensions: 384,
-		},
-		Database: DatabaseConfig{
-			Type:   "libsql",
-			Path:   "./codeforge.db",
-			Status: "connected",
-			Size:   1024000,
-			Chunks: 1247,
+			Provider: s.config.Embedding.Provider,
+			Model:    s.config.Embedding.Model,
 		},
 		API: APIConfig{
-			Port:    8080,
-			Version: "1.0.0",
-			Debug:   false,
+			Port:    s.port,
+			Version: "v1",
+			Debug:   s.config.Debug,
 		},
 	}
 
-	// Get actual configuration from config service
-	if s.config != nil {
-		// Update LLM configuration from actual config
-		for provider := range s.config.Providers {
-			config.LLM.Providers[string(provider)] = true
-			// Set first available provider as default
-			if config.LLM.DefaultProvider == "anthropic" && string(provider) != "anthropic" {
-				config.LLM.DefaultProvider = string(provider)
-			}
-		}
-
-		// Update database path from actual config
-		if s.config.Data.Directory != "" {
-			config.Database.Path = s.config.Data.Directory + "/codeforge.db"
-		}
+	for name, provider := range s.config.Providers {
+		resp.LLM.Providers[string(name)] = !provider.Disabled
+		resp.LLM.Available[string(name)] = !provider.Disabled && provider.APIKey != ""
+	}
+	// Ollama needs no key, so ask it whether it's running
+	resp.LLM.Available["ollama"] = s.checkOllamaAvailability() == "available"
 
-		// Update debug setting from actual config
-		config.API.Debug = s.config.Debug
+	if name, dimensions, err := embeddings.GetCurrentProvider(); err == nil {
+		resp.Embedding.Active = strings.ToLower(name)
+		resp.Embedding.Dimensions = dimensions
+	}
 
-		// Get vector database stats if available
-		if s.vectorDB != nil {
-			ctx := r.Context()
-			if stats, err := s.vectorDB.GetStats(ctx); err == nil {
-				config.Database.Size = int64(stats.TotalChunks * 1000) // Approximate size
-				config.Database.Chunks = stats.TotalChunks
-			}
+	resp.Database = DatabaseConfig{
+		Type:   "libsql",
+		Path:   indexPath(s.config),
+		Status: "unavailable",
+	}
+	if stats, err := s.indexStats(r.Context(), s.serverScope()); err == nil {
+		resp.Database.Status = "connected"
+		resp.Database.Size = stats.SizeBytes
+		resp.Database.Chunks = stats.Chunks
+		if stats.Embedding.Dimensions > 0 {
+			resp.Embedding.Dimensions = stats.Embedding.Dimensions
 		}
+	} else if s.vectorDB != nil {
+		resp.Database.Status = "error"
 	}
 
-	s.writeJSON(w, config)
+	s.writeJSON(w, resp)
 }
 
-// updateConfig updates configuration
+// updateConfig validates configuration changes, applies them to the live
+// config and saves them in the project config file. Read-only values such
+// as database statistics, the API port and embedding dimensions are
+// ignored, so a client can send back what GET /config returned.
 func (s *Server) updateConfig(w http.ResponseWriter, r *http.Request) {
 	var req ConfigUpdateRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -146,63 +148,171 @@ func (s *Server) updateConfig(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Apply LLM configuration changes
+	changes, err := s.configChanges(&req)
+	if err != nil {
+		s.writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(changes) > 0 {
+		if err := config.SetProjectValues(changes); err != nil {
+			s.writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Most settings are read when they're used; the rest only at startup
+	applied := []string{}
+	restartRequired := []string{}
+	for key := range changes {
+		if config.RequiresRestart(key) {
+			restartRequired = append(restartRequired, key)
+		} else {
+			applied = append(applied, key)
+		}
+	}
+	sort.Strings(applied)
+	sort.Strings(restartRequired)
+
+	message := "Configuration updated"
+	if len(changes) == 0 {
+		message = "No configuration changes"
+	} else if len(restartRequired) > 0 {
+		message = "Configuration saved; restart CodeForge to apply " + strings.Join(restartRequired, ", ")
+	}
+
+	s.writeJSON(w, map[string]interface{}{
+		"success":          true,
+		"message":          message,
+		"applied":          applied,
+		"restart_required": restartRequired,
+		"file":             config.ProjectConfigPath(),
+	})
+}
+
+// configChanges turns an update request into config keys and values,
+// leaving out values that already match the config
+func (s *Server) configChanges(req *ConfigUpdateRequest) (map[string]any, error) {
+	changes := map[string]any{}
+
 	if req.LLM != nil {
-		// Update default provider if specified
-		if req.LLM.DefaultProvider != "" {
+		if req.LLM.DefaultProvider != "" && req.LLM.DefaultProvider != s.config.Provider {
 			// Validate provider exists in available providers
-			providerFound := false
-			for provider := range s.config.Providers {
-				if string(provider) == req.LLM.DefaultProvider {
-					providerFound = true
-					break
+			if _, ok := s.config.Providers[models.ModelProvider(req.LLM.DefaultProvider)]; !ok {
+				return nil, fmt.Errorf("Provider %s not available", req.LLM.DefaultProvider)
+			}
+			changes["provider"] = req.LLM.DefaultProvider
+		}
+		if req.LLM.DefaultModel != "" && req.LLM.DefaultModel != s.config.Model {
+			changes["model"] = req.LLM.DefaultModel
+		}
+
+		for name, enabled := range req.LLM.Providers {
+			provider, ok := s.config.Providers[models.ModelProvider(name)]
+			if !ok {
+				if enabled {
+					return nil, fmt.Errorf("Provider %s not available", name)
 				}
+				continue
 			}
-			if !providerFound {
-				s.writeError(w, fmt.Sprintf("Provider %s not available", req.LLM.DefaultProvider), http.StatusBadRequest)
-				return
+			if provider.Disabled == enabled {
+				changes["providers."+name+".disabled"] = !enabled
 			}
 		}
 
-		// Update provider settings if specified
-		if req.LLM.Settings != nil {
-			// Validate settings (basic validation)
-			if temp, ok := req.LLM.Settings["temperature"]; ok {
-				if temp == "" || temp < "0" || temp > "2" {
-					s.writeError(w, "Invalid temperature value", http.StatusBadRequest)
-					return
-				}
+		if len(req.LLM.Settings) > 0 {
+			keys := make([]string, 0, len(req.LLM.Settings))
+			for key := range req.LLM.Settings {
+				keys = append(keys, key)
 			}
+			sort.Strings(keys)
+			return nil, fmt.Errorf("LLM settings %s can't be configured", strings.Join(keys, ", "))
 		}
 	}
 
-	// Apply embedding configuration changes
 	if req.Embedding != nil {
-		if req.Embedding.Provider != "" {
+		if req.Embedding.Provider != "" && req.Embedding.Provider != s.config.Embedding.Provider {
 			// Validate embedding provider
 			validProviders := []string{"ollama", "openai", "fallback"}
-			valid := false
-			for _, provider := range validProviders {
-				if req.Embedding.Provider == provider {
-					valid = true
-					break
-				}
-			}
-			if !valid {
-				s.writeError(w, fmt.Sprintf("Invalid embedding provider: %s", req.Embedding.Provider), http.StatusBadRequest)
-				return
+			if !slices.Contains(validProviders, req.Embedding.Provider) {
+				return nil, fmt.Errorf("Invalid embedding provider: %s", req.Embedding.Provider)
 			}
+			changes["embedding.provider"] = req.Embedding.Provider
+		}
+		if req.Embedding.Model != "" && req.Embedding.Model != s.config.Embedding.Model {
+			changes["embedding.model"] = req.Embedding.Model
 		}
 	}
 
-	// Configuration changes validated and would be applied here
-	// For now, return success as the actual persistence would require
-	// integration with the config service's save functionality
-	response := map[string]interface{}{
-		"success": true,
-		"message": "Configuration updated successfully",
-		"changes": req,
+	if req.API != nil && req.API.Debug != s.config.Debug {
+		changes["debug"] = req.API.Debug
 	}
 
-	s.writeJSON(w, response)
+	return changes, nil
+}
+
+// ProviderKeyInfo describes a provider's API key without revealing it
+type ProviderKeyInfo struct {
+	Provider string `json:"provider"`
+	Set      bool   `json:"set"`
+	Key      string `json:"key,omitempty"` // Masked
+}
+
+// KeyUpdateRequest replaces provider API keys, by provider
+type KeyUpdateRequest struct {
+	Keys map[string]string `json:"keys"`
+}
+
+// handleConfigKeys handles GET /config/keys and PUT /config/keys, listing
+// and rotating provider API keys without a restart
+func (s *Server) handleConfigKeys(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "GET" {
+		providers := config.KeyProviders()
+		infos := make([]ProviderKeyInfo, len(providers))
+		for i, provider := range providers {
+			key := config.ProviderKey(provider)
+			infos[i] = ProviderKeyInfo{Provider: provider, Set: key != "", Key: maskAPIKey(key)}
+		}
+		s.writeJSON(w, map[string]interface{}{"keys": infos})
+		return
+	}
+
+	var req KeyUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Keys) == 0 {
+		s.writeError(w, `Invalid request body, expected {"keys": {"<provider>": "<key>"}}`, http.StatusBadRequest)
+		return
+	}
+
+	// Check every key first so a bad entry doesn't leave a partial rotation
+	known := config.KeyProviders()
+	updated := make([]string, 0, len(req.Keys))
+	for provider, key := range req.Keys {
+		if !slices.Contains(known, provider) {
+			s.writeError(w, fmt.Sprintf("Unknown provider %q (keys can be set for %s)", provider, strings.Join(known, ", ")), http.StatusBadRequest)
+			return
+		}
+		if strings.TrimSpace(key) == "" {
+			s.writeError(w, fmt.Sprintf("No API key given for %s", provider), http.StatusBadRequest)
+			return
+		}
+		updated = append(updated, provider)
+	}
+	sort.Strings(updated)
+
+	for _, provider := range updated {
+		if err := config.SetProviderKey(provider, req.Keys[provider]); err != nil {
+			s.writeError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	by := "localhost"
+	if session, ok := GetSession(r.Context()); ok && session.User != "" {
+		by = session.User
+	}
+	log.Printf("API keys replaced for %s by %s", strings.Join(updated, ", "), by)
+
+	s.writeJSON(w, map[string]interface{}{
+		"success": true,
+		"updated": updated,
+		"message": "Keys apply from the next request; they aren't saved, so update them where they're stored too",
+	})
 }
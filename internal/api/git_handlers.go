@@ -0,0 +1,184 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/chat"
+	"github.com/entrepeneur4lyf/codeforge/internal/git"
+)
+
+// GitPathsRequest names the files to stage or unstage; none means all
+type GitPathsRequest struct {
+	Paths []string `json:"paths,omitempty"`
+}
+
+// GitDiffResponse is a diff of the working tree or the index
+type GitDiffResponse struct {
+	Staged bool          `json:"staged"`
+	Files  []git.GitDiff `json:"files"`
+	Patch  string        `json:"patch"`
+}
+
+// GitCommitMessageRequest picks the model writing a commit message; the
+// default model when empty
+type GitCommitMessageRequest struct {
+	Model string `json:"model,omitempty"`
+}
+
+// GitCommitMessageResponse is a commit message written from the staged diff
+type GitCommitMessageResponse struct {
+	Message string `json:"message"`
+	Model   string `json:"model"`
+}
+
+// GitCommitRequest commits the staged changes with a message
+type GitCommitRequest struct {
+	Message string `json:"message"`
+}
+
+// gitRepository returns the repository of the request's project, writing
+// the error when there's none
+func (s *Server) gitRepository(w http.ResponseWriter, r *http.Request) *git.Repository {
+	scope, err := s.projectScope(r)
+	if err != nil {
+		s.writeError(w, err.Error(), http.StatusNotFound)
+		return nil
+	}
+	if !git.IsGitInstalled() {
+		s.writeError(w, "Git is not installed", http.StatusServiceUnavailable)
+		return nil
+	}
+	repo := git.NewRepository(scope.dir)
+	if !repo.IsGitRepository() {
+		s.writeError(w, "Project is not a git repository", http.StatusConflict)
+		return nil
+	}
+	return repo
+}
+
+// handleGitStatus handles GET /git/status
+func (s *Server) handleGitStatus(w http.ResponseWriter, r *http.Request) {
+	repo := s.gitRepository(w, r)
+	if repo == nil {
+		return
+	}
+	status, err := repo.GetStatus(r.Context())
+	if err != nil {
+		s.writeError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.writeJSON(w, status)
+}
+
+// handleGitDiff handles GET /git/diff, of the changes not staged or, with
+// staged=true, of the staged ones
+func (s *Server) handleGitDiff(w http.ResponseWriter, r *http.Request) {
+	repo := s.gitRepository(w, r)
+	if repo == nil {
+		return
+	}
+	staged, _ := strconv.ParseBool(r.URL.Query().Get("staged"))
+	files, err := repo.GetDiff(r.Context(), staged)
+	if err != nil {
+		s.writeError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	patch, err := repo.Patch(r.Context(), staged)
+	if err != nil {
+		s.writeError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if files == nil {
+		files = []git.GitDiff{}
+	}
+	s.writeJSON(w, GitDiffResponse{Staged: staged, Files: files, Patch: patch})
+}
+
+// handleGitStage handles POST /git/stage and POST /git/unstage, returning
+// the status afterwards
+func (s *Server) handleGitStage(stage bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		repo := s.gitRepository(w, r)
+		if repo == nil {
+			return
+		}
+		var req GitPathsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.writeError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		var err error
+		if stage {
+			err = repo.Stage(r.Context(), req.Paths...)
+		} else {
+			err = repo.Unstage(r.Context(), req.Paths...)
+		}
+		if err != nil {
+			s.writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		status, err := repo.GetStatus(r.Context())
+		if err != nil {
+			s.writeError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		s.writeJSON(w, status)
+	}
+}
+
+// handleGitCommitMessage handles POST /git/commit-message, having the
+// model write a commit message for the staged changes without committing
+func (s *Server) handleGitCommitMessage(w http.ResponseWriter, r *http.Request) {
+	repo := s.gitRepository(w, r)
+	if repo == nil {
+		return
+	}
+	var req GitCommitMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	model := req.Model
+	if model == "" {
+		model = chat.GetDefaultModel()
+	}
+	handler, err := chat.NewHandlerForModel(model, chat.GetAPIKeyForModel(model), "")
+	if err != nil {
+		s.writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	message, err := git.NewCommitMessageGeneratorFor(handler).GenerateCommitMessage(r.Context(), repo, true)
+	if err != nil {
+		s.writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.writeJSON(w, GitCommitMessageResponse{Message: message, Model: model})
+}
+
+// handleGitCommit handles POST /git/commit, committing the staged changes
+func (s *Server) handleGitCommit(w http.ResponseWriter, r *http.Request) {
+	repo := s.gitRepository(w, r)
+	if repo == nil {
+		return
+	}
+	var req GitCommitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Message == "" {
+		s.writeError(w, "Message is required", http.StatusBadRequest)
+		return
+	}
+
+	commit, err := repo.Commit(r.Context(), req.Message)
+	if err != nil {
+		s.writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.writeJSON(w, commit)
+}
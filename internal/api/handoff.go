@@ -0,0 +1,359 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/storage"
+	"github.com/entrepeneur4lyf/codeforge/internal/transcript"
+)
+
+// webClient tags messages persisted by the API server
+const webClient = "web"
+
+// sessionSyncInterval is how often sessions open in the web UI are checked
+// for messages written by the CLI or TUI
+const sessionSyncInterval = 2 * time.Second
+
+// persistedSessionLimit caps how many saved sessions are listed
+const persistedSessionLimit = 100
+
+// lockChatSession takes the generation lock of a session, so the API and
+// terminals don't answer in it at the same time. It writes a 409 Conflict
+// and returns nil when another client holds the lock.
+func (s *Server) lockChatSession(w http.ResponseWriter, sessionID string) *storage.SessionLock {
+	lock, err := storage.LockSession(sessionID, webClient)
+	if errors.Is(err, storage.ErrSessionBusy) {
+		s.writeError(w, err.Error()+"; try again when it finishes", http.StatusConflict)
+		return nil
+	}
+	if err != nil {
+		log.Printf("Failed to lock session %s: %v", sessionID, err)
+		s.writeError(w, "Failed to lock session", http.StatusInternalServerError)
+		return nil
+	}
+	return lock
+}
+
+// SetStore writes sessions and messages through to store, so conversations
+// started in the web UI can be continued from the CLI and vice versa
+func (cs *ChatStorage) SetStore(store storage.ChatStore) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.store = store
+}
+
+// persistSession saves a session to the store. The caller holds the lock.
+func (cs *ChatStorage) persistSession(session *ChatSession) {
+	if cs.store == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := cs.store.GetSession(ctx, session.ID); err == nil {
+		return
+	}
+	err := cs.store.CreateSession(ctx, &storage.Session{
+		ID:        session.ID,
+		Title:     session.Title,
+		Model:     session.Model,
+		CreatedAt: session.CreatedAt,
+		UpdatedAt: session.UpdatedAt,
+		Metadata:  map[string]interface{}{"created_by": webClient},
+	})
+	if err != nil {
+		log.Printf("Warning: failed to save session %s: %v", session.ID, err)
+	}
+}
+
+// persistMessage saves a message to the store. The caller holds the lock.
+func (cs *ChatStorage) persistMessage(session *ChatSession, message ChatMessage) {
+	if cs.store == nil {
+		return
+	}
+	cs.persistSession(session)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	metadata := map[string]interface{}{"client": webClient}
+	if message.Model != "" {
+		metadata["model"] = message.Model
+	}
+	for _, key := range usageKeys {
+		if value, ok := message.Metadata[key]; ok {
+			metadata[key] = value
+		}
+	}
+	stored := &storage.Message{
+		ID:        message.ID,
+		SessionID: message.SessionID,
+		Role:      message.Role,
+		Content:   message.Content,
+		CreatedAt: message.Timestamp,
+		Metadata:  metadata,
+	}
+	if usage, ok := transcript.MessageUsage(*stored); ok {
+		stored.Tokens = usage.InputTokens + usage.OutputTokens
+	}
+	if err := cs.store.SaveMessage(ctx, stored); err != nil {
+		log.Printf("Warning: failed to save message %s: %v", message.ID, err)
+	}
+}
+
+// persistFeedback saves a message rating to the store. The caller holds the lock.
+func (cs *ChatStorage) persistFeedback(messageID string, feedback *storage.Feedback) {
+	if cs.store == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := cs.store.SetMessageFeedback(ctx, messageID, feedback); err != nil {
+		log.Printf("Warning: failed to save feedback for message %s: %v", messageID, err)
+	}
+}
+
+// deletePersistedSession removes a session from the store. The caller holds the lock.
+func (cs *ChatStorage) deletePersistedSession(sessionID string) {
+	if cs.store == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := cs.store.DeleteSession(ctx, sessionID); err != nil {
+		log.Printf("Warning: failed to delete saved session %s: %v", sessionID, err)
+	}
+}
+
+// importSession adds a saved session and its messages without persisting them
+func (cs *ChatStorage) importSession(session *ChatSession, messages []ChatMessage) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if _, exists := cs.sessions[session.ID]; exists {
+		return
+	}
+	cs.sessions[session.ID] = session
+	cs.messages[session.ID] = messages
+}
+
+// importMessage adds a message written by another client, reporting whether
+// it was new
+func (cs *ChatStorage) importMessage(message ChatMessage) bool {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	session, exists := cs.sessions[message.SessionID]
+	if !exists {
+		return false
+	}
+	for _, m := range cs.messages[message.SessionID] {
+		if m.ID == message.ID {
+			return false
+		}
+	}
+	cs.messages[message.SessionID] = append(cs.messages[message.SessionID], message)
+	session.UpdatedAt = time.Now()
+	return true
+}
+
+// messageIDs returns the IDs of a session's messages
+func (cs *ChatStorage) messageIDs(sessionID string) []string {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+
+	ids := make([]string, len(cs.messages[sessionID]))
+	for i, m := range cs.messages[sessionID] {
+		ids[i] = m.ID
+	}
+	return ids
+}
+
+// fromStoredMessage converts a persisted message for the API
+func fromStoredMessage(msg storage.Message) ChatMessage {
+	model, _ := msg.Metadata["model"].(string)
+	return ChatMessage{
+		ID:        msg.ID,
+		SessionID: msg.SessionID,
+		Role:      msg.Role,
+		Content:   msg.Content,
+		Timestamp: msg.CreatedAt,
+		Model:     model,
+		Metadata:  msg.Metadata,
+		Feedback:  storage.MessageFeedback(msg),
+	}
+}
+
+// chatStore returns the persistent chat store, or nil when there is none
+func (s *Server) chatStore() storage.ChatStore {
+	if s.app == nil {
+		return nil
+	}
+	return s.app.ChatStore
+}
+
+// loadPersistedSession returns a session, loading it and its messages from
+// the persistent store when it was started by another client
+func (s *Server) loadPersistedSession(ctx context.Context, sessionID string) (*ChatSession, bool) {
+	if session, exists := s.chatStorage.GetSession(sessionID); exists {
+		return session, true
+	}
+
+	store := s.chatStore()
+	if store == nil {
+		return nil, false
+	}
+	saved, err := store.GetSession(ctx, sessionID)
+	if err != nil {
+		return nil, false
+	}
+
+	var messages []ChatMessage
+	for offset := 0; ; {
+		batch, err := store.GetMessages(ctx, sessionID, 500, offset)
+		if err != nil {
+			log.Printf("Warning: failed to load messages of session %s: %v", sessionID, err)
+			break
+		}
+		for _, msg := range batch.Messages {
+			messages = append(messages, fromStoredMessage(msg))
+		}
+		offset += len(batch.Messages)
+		if !batch.HasMore || len(batch.Messages) == 0 {
+			break
+		}
+	}
+
+	s.chatStorage.importSession(&ChatSession{
+		ID:        saved.ID,
+		Title:     saved.Title,
+		Status:    "active",
+		CreatedAt: saved.CreatedAt,
+		UpdatedAt: saved.UpdatedAt,
+		Model:     saved.Model,
+	}, messages)
+	return s.chatStorage.GetSession(sessionID)
+}
+
+// importLatestMessages adds the newest saved messages of a session to
+// memory, for exchanges the app wrote to the store itself
+func (s *Server) importLatestMessages(sessionID string, limit int) {
+	store := s.chatStore()
+	if store == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, exists := s.loadPersistedSession(ctx, sessionID); !exists {
+		return
+	}
+	messages, err := store.GetLatestMessages(ctx, sessionID, limit)
+	if err != nil {
+		log.Printf("Warning: failed to load messages of session %s: %v", sessionID, err)
+		return
+	}
+	for _, msg := range messages {
+		s.chatStorage.importMessage(fromStoredMessage(msg))
+	}
+}
+
+// persistedSessions returns saved sessions that aren't loaded in memory
+func (s *Server) persistedSessions(ctx context.Context) []*ChatSession {
+	store := s.chatStore()
+	if store == nil {
+		return nil
+	}
+
+	summaries, err := store.ListSessions(ctx, "", persistedSessionLimit, 0)
+	if err != nil {
+		log.Printf("Warning: failed to list saved sessions: %v", err)
+		return nil
+	}
+
+	var sessions []*ChatSession
+	for _, summary := range summaries {
+		if _, exists := s.chatStorage.GetSession(summary.ID); exists {
+			continue
+		}
+		sessions = append(sessions, &ChatSession{
+			ID:        summary.ID,
+			Title:     summary.Title,
+			Status:    "saved",
+			UpdatedAt: summary.UpdatedAt,
+			Model:     summary.Model,
+		})
+	}
+	return sessions
+}
+
+// syncPersistedSessions pushes messages written by the CLI or TUI to web
+// clients of the same session, until ctx is done
+func (s *Server) syncPersistedSessions(ctx context.Context) {
+	store := s.chatStore()
+	if store == nil {
+		return
+	}
+
+	followers := make(map[string]*storage.Follower)
+	ticker := time.NewTicker(sessionSyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		open := make(map[string]bool)
+		for _, sessionID := range s.connectionManager.ChatSessionIDs() {
+			open[sessionID] = true
+
+			follower, ok := followers[sessionID]
+			if !ok {
+				if _, exists := s.loadPersistedSession(ctx, sessionID); !exists {
+					continue
+				}
+				follower = storage.NewFollower(store, sessionID)
+				follower.MarkSeen(s.chatStorage.messageIDs(sessionID)...)
+				followers[sessionID] = follower
+			}
+
+			messages, err := follower.Poll(ctx)
+			if err != nil {
+				continue
+			}
+			for _, msg := range messages {
+				message := fromStoredMessage(msg)
+				if !s.chatStorage.importMessage(message) {
+					continue
+				}
+				s.connectionManager.BroadcastToSession(sessionID, WebSocketMessage{
+					Type: "message_synced",
+					Data: map[string]interface{}{
+						"message": message,
+						"client":  storage.MessageClient(msg),
+					},
+				})
+			}
+		}
+
+		// Forget sessions no longer open in the web UI
+		for sessionID := range followers {
+			if !open[sessionID] {
+				delete(followers, sessionID)
+			}
+		}
+	}
+}
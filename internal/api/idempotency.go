@@ -0,0 +1,183 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// IdempotencyKeyHeader names the header clients set to retry a message send
+// without starting a second generation
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+const (
+	idempotencyTTL        = 24 * time.Hour // How long a response is replayed for its key
+	maxIdempotencyKeyLen  = 255
+	maxIdempotentBodySize = 10 << 20
+)
+
+// idempotencyEntry is the response to a request sent with an idempotency key
+type idempotencyEntry struct {
+	fingerprint string        // Hash of the request the key was first used for
+	done        chan struct{} // Closed when the response is recorded or discarded
+	discarded   bool          // The request failed and may be tried again
+	status      int
+	contentType string
+	body        []byte
+	expires     time.Time
+}
+
+// idempotencyStore remembers responses by caller and idempotency key
+type idempotencyStore struct {
+	mu      sync.Mutex
+	entries map[string]*idempotencyEntry
+}
+
+func newIdempotencyStore() *idempotencyStore {
+	return &idempotencyStore{entries: make(map[string]*idempotencyEntry)}
+}
+
+// begin returns the entry for the key, and whether the caller is the first
+// to use it and so should handle the request
+func (st *idempotencyStore) begin(key, fingerprint string) (*idempotencyEntry, bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	now := time.Now()
+	for k, e := range st.entries {
+		if now.After(e.expires) {
+			delete(st.entries, k)
+		}
+	}
+
+	if entry, ok := st.entries[key]; ok {
+		return entry, false
+	}
+	entry := &idempotencyEntry{
+		fingerprint: fingerprint,
+		done:        make(chan struct{}),
+		expires:     now.Add(idempotencyTTL),
+	}
+	st.entries[key] = entry
+	return entry, true
+}
+
+// finish records the response for replay. Server errors aren't kept, so a
+// retry tries again.
+func (st *idempotencyStore) finish(key string, entry *idempotencyEntry, status int, contentType string, body []byte) {
+	st.mu.Lock()
+	if status >= http.StatusInternalServerError {
+		entry.discarded = true
+		delete(st.entries, key)
+	} else {
+		entry.status, entry.contentType, entry.body = status, contentType, body
+	}
+	st.mu.Unlock()
+	close(entry.done)
+}
+
+// recordingWriter passes a response through and keeps a copy of it
+type recordingWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rw *recordingWriter) WriteHeader(status int) {
+	if rw.status == 0 {
+		rw.status = status
+	}
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *recordingWriter) Write(b []byte) (int, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	rw.body.Write(b)
+	return rw.ResponseWriter.Write(b)
+}
+
+// idempotent makes a POST handler safe to retry: a request repeating the
+// Idempotency-Key of an earlier one from the same user gets the earlier
+// response instead of being handled again, and a retry that arrives while
+// the first request is still running waits for its response. Requests
+// without the header are handled as usual.
+func (s *Server) idempotent(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if key == "" || r.Method != http.MethodPost {
+			next(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			s.writeError(w, "Idempotency-Key is too long", http.StatusBadRequest)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxIdempotentBodySize+1))
+		if err != nil {
+			s.writeError(w, "Failed to read request body", http.StatusBadRequest)
+			return
+		}
+		if len(body) > maxIdempotentBodySize {
+			s.writeError(w, "Request body is too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		sum := sha256.Sum256([]byte(r.Method + " " + r.URL.Path + "\n" + string(body)))
+		fingerprint := hex.EncodeToString(sum[:])
+
+		// Keys are per user, so clients can't replay each other's responses
+		user := ""
+		if session, ok := GetSession(r.Context()); ok {
+			user = session.User
+		}
+		storeKey := user + "\x00" + key
+
+		for {
+			entry, first := s.idempotency.begin(storeKey, fingerprint)
+			if first {
+				rec := &recordingWriter{ResponseWriter: w}
+				// A handler that panics counts as a server error, so the
+				// retries waiting for it go ahead and the key can be reused
+				status := http.StatusInternalServerError
+				defer func() {
+					s.idempotency.finish(storeKey, entry, status, w.Header().Get("Content-Type"), rec.body.Bytes())
+				}()
+				next(rec, r)
+				// Writing nothing sends 200, as net/http does
+				status = rec.status
+				if status == 0 {
+					status = http.StatusOK
+				}
+				return
+			}
+
+			if entry.fingerprint != fingerprint {
+				s.writeError(w, "Idempotency-Key was already used for a different request", http.StatusUnprocessableEntity)
+				return
+			}
+			select {
+			case <-entry.done:
+			case <-r.Context().Done():
+				return
+			}
+			if entry.discarded {
+				continue // The first attempt failed; handle this one
+			}
+
+			if entry.contentType != "" {
+				w.Header().Set("Content-Type", entry.contentType)
+			}
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(entry.status)
+			w.Write(entry.body)
+			return
+		}
+	}
+}
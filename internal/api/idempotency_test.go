@@ -0,0 +1,129 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestIdempotent(t *testing.T) {
+	s := &Server{idempotency: newIdempotencyStore()}
+	var calls atomic.Int32
+	fail := atomic.Bool{}
+	release := make(chan struct{})
+	handler := s.idempotent(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		<-release
+		if fail.Load() {
+			s.writeError(w, "provider unavailable", http.StatusBadGateway)
+			return
+		}
+		s.writeJSON(w, map[string]int32{"generation": n})
+	})
+	send := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/chat/sessions/s1/messages", strings.NewReader(body))
+		if key != "" {
+			req.Header.Set(IdempotencyKeyHeader, key)
+		}
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec
+	}
+
+	// A retry sent while the first request runs waits for its response
+	var wg sync.WaitGroup
+	recs := make([]*httptest.ResponseRecorder, 2)
+	for i := range recs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			recs[i] = send("k1", `{"message":"hi"}`)
+		}()
+	}
+	close(release)
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Fatalf("handler ran %d times, want once", calls.Load())
+	}
+	if recs[0].Body.String() != recs[1].Body.String() {
+		t.Errorf("responses differ: %q and %q", recs[0].Body, recs[1].Body)
+	}
+	if recs[0].Header().Get("Idempotent-Replayed") == "" && recs[1].Header().Get("Idempotent-Replayed") == "" {
+		t.Error("the retry wasn't marked as replayed")
+	}
+
+	if rec := send("k1", `{"message":"different"}`); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("reused key with a different body: status %d, want 422", rec.Code)
+	}
+	send("", `{"message":"hi"}`)
+	send("", `{"message":"hi"}`)
+	if calls.Load() != 3 {
+		t.Errorf("requests without a key ran %d times in total, want 3", calls.Load())
+	}
+
+	// Failed requests aren't remembered, so a retry generates again
+	fail.Store(true)
+	if rec := send("k2", `{"message":"hi"}`); rec.Code < 500 {
+		t.Fatalf("status %d, want a server error", rec.Code)
+	}
+	fail.Store(false)
+	rec := send("k2", `{"message":"hi"}`)
+	if want := fmt.Sprintf(`{"generation":%d}`, calls.Load()); calls.Load() != 5 || strings.TrimSpace(rec.Body.String()) != want {
+		t.Errorf("retry after a failure: %d calls, body %s", calls.Load(), rec.Body)
+	}
+
+	large := strings.Repeat("x", maxIdempotentBodySize+1)
+	if rec := send("k3", large); rec.Code != http.StatusRequestEntityTooLarge || calls.Load() != 5 {
+		t.Errorf("oversize body: status %d after %d calls", rec.Code, calls.Load())
+	}
+}
+
+func TestIdempotentPanicAndEmptyResponse(t *testing.T) {
+	s := &Server{idempotency: newIdempotencyStore()}
+	var calls atomic.Int32
+	handler := s.idempotent(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			panic("handler failed")
+		}
+		// Writes nothing, which sends 200
+	})
+	send := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/chat/sessions/s1/messages", strings.NewReader("{}"))
+		req.Header.Set(IdempotencyKeyHeader, "k1")
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("the handler's panic was swallowed")
+			}
+		}()
+		send()
+	}()
+
+	// The panic freed the key, so this runs instead of waiting forever
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- send() }()
+	select {
+	case rec := <-done:
+		if rec.Code != http.StatusOK || calls.Load() != 2 {
+			t.Errorf("retry after a panic: status %d after %d calls", rec.Code, calls.Load())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("retry after a panic hung")
+	}
+
+	// The empty response replays as 200 rather than panicking
+	if rec := send(); rec.Code != http.StatusOK || rec.Header().Get("Idempotent-Replayed") == "" || calls.Load() != 2 {
+		t.Errorf("replayed empty response: status %d after %d calls", rec.Code, calls.Load())
+	}
+}
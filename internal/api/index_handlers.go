@@ -0,0 +1,94 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+	"github.com/entrepeneur4lyf/codeforge/internal/embeddings"
+)
+
+// IndexStats describes the code index held in the vector database
+type IndexStats struct {
+	Path        string         `json:"path"`
+	SizeBytes   int64          `json:"size_bytes"`
+	Chunks      int            `json:"chunks"`
+	Files       int            `json:"files"`
+	LastIndexed *time.Time     `json:"last_indexed,omitempty"` // Unset when nothing is indexed
+	Languages   map[string]int `json:"languages"`              // Chunks per language
+	Embedding   IndexEmbedding `json:"embedding"`
+}
+
+// IndexEmbedding describes the embeddings stored in the index
+type IndexEmbedding struct {
+	Provider   string `json:"provider"`
+	Model      string `json:"model"`
+	Dimensions int    `json:"dimensions"`
+}
+
+// handleIndexStats handles GET /index/stats
+func (s *Server) handleIndexStats(w http.ResponseWriter, r *http.Request) {
+	scope, err := s.projectScope(r)
+	if err != nil {
+		s.writeError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	stats, err := s.indexStats(r.Context(), scope)
+	if err != nil {
+		s.writeError(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	s.writeJSON(w, stats)
+}
+
+// indexStats collects statistics about the code index of the scope's project
+func (s *Server) indexStats(ctx context.Context, scope projectScope) (*IndexStats, error) {
+	index, err := s.index(scope)
+	if err != nil {
+		return nil, err
+	}
+	vs, err := index.GetStats(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get index stats: %v", err)
+	}
+
+	stats := &IndexStats{
+		Path:      indexPath(scope.config),
+		SizeBytes: vs.IndexSizeBytes,
+		Chunks:    vs.TotalChunks,
+		Files:     vs.TotalFiles,
+		Languages: vs.Languages,
+		Embedding: IndexEmbedding{Dimensions: vs.Dimension},
+	}
+	if !vs.LastIndexed.IsZero() {
+		stats.LastIndexed = &vs.LastIndexed
+	}
+	if scope.config != nil {
+		stats.Embedding.Provider = scope.config.Embedding.Provider
+		stats.Embedding.Model = scope.config.Embedding.Model
+	}
+	if name, dimensions, err := embeddings.GetCurrentProvider(); err == nil {
+		stats.Embedding.Provider = strings.ToLower(name)
+		if stats.Embedding.Dimensions == 0 {
+			stats.Embedding.Dimensions = dimensions
+		}
+	}
+	return stats, nil
+}
+
+// indexPath returns the vector database file, which lives in the data
+// directory
+func indexPath(cfg *config.Config) string {
+	if cfg == nil {
+		return ""
+	}
+	dataDir := cfg.Data.Directory
+	if !filepath.IsAbs(dataDir) {
+		dataDir = filepath.Join(cfg.WorkingDir, dataDir)
+	}
+	return filepath.Join(dataDir, "vectors.db")
+}
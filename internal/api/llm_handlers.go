@@ -2,13 +2,15 @@ package api
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"net/http"
 	"os"
 	"strings"
 	"time"
 
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+	"github.com/entrepeneur4lyf/codeforge/internal/llm/providers"
+	"github.com/entrepeneur4lyf/codeforge/internal/models"
 	"github.com/gorilla/mux"
 )
 
@@ -45,115 +47,19 @@ func (s *Server) handleLLMProviders(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// getAvailableProviders returns the list of available LLM providers
+// getAvailableProviders returns the LLM providers in the registry
 func (s *Server) getAvailableProviders() []LLMProvider {
-	providers := []LLMProvider{
-		{
-			ID:          "anthropic",
-			Name:        "Anthropic",
-			Description: "Claude models for advanced reasoning",
-			Status:      s.getProviderStatus("anthropic"),
-			ModelCount:  s.getProviderModelCount("anthropic"),
-			LastUpdated: time.Now().Add(-1 * time.Hour),
-		},
-		{
-			ID:          "openai",
-			Name:        "OpenAI",
-			Description: "GPT models for general AI tasks",
-			Status:      s.getProviderStatus("openai"),
-			ModelCount:  s.getProviderModelCount("openai"),
-			LastUpdated: time.Now().Add(-30 * time.Minute),
-		},
-		{
-			ID:          "openrouter",
-			Name:        "OpenRouter",
-			Description: "Access to 300+ models from multiple providers",
-			Status:      s.getProviderStatus("openrouter"),
-			ModelCount:  s.getProviderModelCount("openrouter"),
-			LastUpdated: time.Now().Add(-15 * time.Minute),
-		},
-		{
-			ID:          "ollama",
-			Name:        "Ollama",
-			Description: "Local models for privacy and speed",
-			Status:      s.getProviderStatus("ollama"),
-			ModelCount:  s.getProviderModelCount("ollama"),
-			LastUpdated: time.Now().Add(-5 * time.Minute),
-		},
-		{
-			ID:          "gemini",
-			Name:        "Google Gemini",
-			Description: "Google's multimodal AI models",
-			Status:      s.getProviderStatus("gemini"),
-			ModelCount:  s.getProviderModelCount("gemini"),
-			LastUpdated: time.Now().Add(-45 * time.Minute),
-		},
-		{
-			ID:          "groq",
-			Name:        "Groq",
-			Description: "Ultra-fast inference for open models",
-			Status:      s.getProviderStatus("groq"),
-			ModelCount:  s.getProviderModelCount("groq"),
-			LastUpdated: time.Now().Add(-20 * time.Minute),
-		},
-		{
-			ID:          "mistral",
-			Name:        "Mistral AI",
-			Description: "Mistral's efficient and powerful models",
-			Status:      s.getProviderStatus("mistral"),
-			ModelCount:  s.getProviderModelCount("mistral"),
-			LastUpdated: time.Now().Add(-10 * time.Minute),
-		},
-		{
-			ID:          "together",
-			Name:        "Together AI",
-			Description: "Open source models with fast inference",
-			Status:      s.getProviderStatus("together"),
-			ModelCount:  s.getProviderModelCount("together"),
-			LastUpdated: time.Now().Add(-25 * time.Minute),
-		},
-		{
-			ID:          "fireworks",
-			Name:        "Fireworks AI",
-			Description: "Ultra-fast inference for open models",
-			Status:      s.getProviderStatus("fireworks"),
-			ModelCount:  s.getProviderModelCount("fireworks"),
-			LastUpdated: time.Now().Add(-35 * time.Minute),
-		},
-		{
-			ID:          "deepseek",
-			Name:        "DeepSeek",
-			Description: "Advanced reasoning and coding models",
-			Status:      s.getProviderStatus("deepseek"),
-			ModelCount:  s.getProviderModelCount("deepseek"),
-			LastUpdated: time.Now().Add(-40 * time.Minute),
-		},
-		{
-			ID:          "cohere",
-			Name:        "Cohere",
-			Description: "Enterprise-grade language models",
-			Status:      s.getProviderStatus("cohere"),
-			ModelCount:  s.getProviderModelCount("cohere"),
-			LastUpdated: time.Now().Add(-50 * time.Minute),
-		},
-		{
-			ID:          "perplexity",
-			Name:        "Perplexity",
-			Description: "Search-augmented language models",
-			Status:      s.getProviderStatus("perplexity"),
-			ModelCount:  s.getProviderModelCount("perplexity"),
-			LastUpdated: time.Now().Add(-55 * time.Minute),
-		},
-		{
-			ID:          "replicate",
-			Name:        "Replicate",
-			Description: "Run open source models in the cloud",
-			Status:      s.getProviderStatus("replicate"),
-			ModelCount:  s.getProviderModelCount("replicate"),
-			LastUpdated: time.Now().Add(-60 * time.Minute),
-		},
+	var providers []LLMProvider
+	for _, spec := range config.ListProviders() {
+		providers = append(providers, LLMProvider{
+			ID:          spec.ID,
+			Name:        spec.Name,
+			Description: spec.Description,
+			Status:      s.getProviderStatus(spec.ID),
+			ModelCount:  s.getProviderModelCount(spec.ID),
+			LastUpdated: time.Now(),
+		})
 	}
-
 	return providers
 }
 
@@ -187,49 +93,31 @@ func (s *Server) getProviderModelCount(providerID string) int {
 	case "replicate":
 		return 1 // Replicate models
 	default:
-		return 0
+		return len(s.getLocalServerModels(providerID))
 	}
 }
 
-// getOllamaModelCount checks how many Ollama models are available
+// getOllamaModelCount returns how many models the local Ollama has installed
 func (s *Server) getOllamaModelCount() int {
-	// Check if Ollama endpoint is configured
-	endpoint := os.Getenv("OLLAMA_ENDPOINT")
-	if endpoint == "" {
-		endpoint = "http://localhost:11434"
-	}
+	return len(s.getOllamaModelNames())
+}
 
-	// Actually check Ollama endpoint for available models
+// getOllamaModelNames returns the models installed in the local Ollama, or
+// nil when it isn't running
+func (s *Server) getOllamaModelNames() []string {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	req, err := http.NewRequestWithContext(ctx, "GET", endpoint+"/api/tags", nil)
+	names, err := providers.ListOllamaModels(ctx)
 	if err != nil {
-		return 0
-	}
-
-	client := &http.Client{Timeout: 5 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return 0
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return 0
-	}
-
-	var result struct {
-		Models []struct {
-			Name string `json:"name"`
-		} `json:"models"`
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return 0
+		return nil
 	}
+	return names
+}
 
-	return len(result.Models)
+// ollamaEndpoint returns where the server looks for Ollama
+func ollamaEndpoint() string {
+	return providers.OllamaEndpoint()
 }
 
 // getAllAvailableModels returns all available models from all providers
@@ -255,6 +143,11 @@ func (s *Server) getAllAvailableModels() []LLMModel {
 		models = append(models, s.getOllamaModels()...)
 	}
 
+	// And those of LM Studio, llama.cpp and other OpenAI-compatible servers
+	for _, server := range providers.LocalServers() {
+		models = append(models, s.getLocalServerModels(server.ID)...)
+	}
+
 	return models
 }
 
@@ -280,6 +173,21 @@ func (s *Server) handleLLMModels(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// handleLLMCapabilities returns the capability matrix used to validate
+// requests before they reach a provider
+func (s *Server) handleLLMCapabilities(w http.ResponseWriter, r *http.Request) {
+	registry := models.NewModelRegistry()
+	if s.app != nil && s.app.ModelRegistry != nil {
+		registry = s.app.ModelRegistry
+	}
+
+	matrix := registry.CapabilityMatrix()
+	s.writeJSON(w, map[string]interface{}{
+		"models": matrix,
+		"total":  len(matrix),
+	})
+}
+
 // handleProviderModels returns models for a specific provider
 func (s *Server) handleProviderModels(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -318,7 +226,7 @@ func (s *Server) handleProviderModels(w http.ResponseWriter, r *http.Request) {
 			models = s.getOllamaModels()
 		}
 	default:
-		models = []LLMModel{}
+		models = append([]LLMModel{}, s.getLocalServerModels(providerID)...)
 	}
 
 	s.writeJSON(w, map[string]interface{}{
@@ -478,94 +386,69 @@ func (s *Server) getGroqModels() []LLMModel {
 	}
 }
 
-// getOllamaModels returns available Ollama models
+// getOllamaModels returns the models installed in the local Ollama
 func (s *Server) getOllamaModels() []LLMModel {
-	// Actually query Ollama API for available models
-	endpoint := os.Getenv("OLLAMA_ENDPOINT")
-	if endpoint == "" {
-		endpoint = "http://localhost:11434"
-	}
-
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	req, err := http.NewRequestWithContext(ctx, "GET", endpoint+"/api/tags", nil)
+	installed, err := providers.ListOllamaModelDetails(ctx)
 	if err != nil {
-		return s.getFallbackOllamaModels()
-	}
-
-	client := &http.Client{Timeout: 5 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return s.getFallbackOllamaModels()
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return s.getFallbackOllamaModels()
-	}
-
-	var result struct {
-		Models []struct {
-			Name     string `json:"name"`
-			Size     int64  `json:"size"`
-			Modified string `json:"modified_at"`
-		} `json:"models"`
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return s.getFallbackOllamaModels()
+		return nil
 	}
 
-	var models []LLMModel
-	for _, model := range result.Models {
-		// Parse model name to extract family and size
-		name := model.Name
-		description := fmt.Sprintf("Local Ollama model: %s", name)
-		contextSize := 4096 // Default context size
-
-		// Determine context size based on model name patterns
-		if strings.Contains(name, "llama") {
-			contextSize = 131072 // Llama models typically have large context
-		} else if strings.Contains(name, "codellama") {
-			contextSize = 16384
-		} else if strings.Contains(name, "mistral") {
-			contextSize = 32768
+	models := make([]LLMModel, 0, len(installed))
+	for _, m := range installed {
+		info := m.Info()
+		description := "Local Ollama model"
+		if m.Details.ParameterSize != "" {
+			description += fmt.Sprintf(", %s parameters", m.Details.ParameterSize)
+		}
+		if m.Details.QuantizationLevel != "" {
+			description += fmt.Sprintf(", %s", m.Details.QuantizationLevel)
+		}
+		capabilities := []string{"text", "code", "local"}
+		if strings.Contains(m.Name, "embed") {
+			capabilities = []string{"embedding", "local"}
+		} else if info.SupportsImages {
+			capabilities = append(capabilities, "vision")
 		}
 
 		models = append(models, LLMModel{
-			ID:           name,
-			Name:         strings.Title(strings.ReplaceAll(name, ":", " ")),
+			ID:           m.Name,
+			Name:         m.Name,
 			Provider:     "ollama",
 			Description:  description,
-			ContextSize:  contextSize,
-			Capabilities: []string{"text", "code", "local"},
+			ContextSize:  info.ContextWindow,
+			Capabilities: capabilities,
 		})
 	}
-
 	return models
 }
 
-// getFallbackOllamaModels returns fallback models when Ollama API is not available
-func (s *Server) getFallbackOllamaModels() []LLMModel {
-	return []LLMModel{
-		{
-			ID:           "llama3.1:8b",
-			Name:         "Llama 3.1 8B",
-			Provider:     "ollama",
-			Description:  "Meta's Llama 3.1 8B (local)",
-			ContextSize:  131072,
-			Capabilities: []string{"text", "code", "local"},
-		},
-		{
-			ID:           "codellama:13b",
-			Name:         "Code Llama 13B",
-			Provider:     "ollama",
-			Description:  "Meta's Code Llama 13B (local)",
-			ContextSize:  16384,
-			Capabilities: []string{"code", "local"},
-		},
+// getLocalServerModels returns the models listed by an OpenAI-compatible
+// local server, as LM Studio or llama.cpp, found at startup. Their IDs carry
+// the provider prefix that routes requests to the server.
+func (s *Server) getLocalServerModels(providerID string) []LLMModel {
+	server, ok := providers.LocalServerByID(providerID)
+	if !ok || providerID == "ollama" {
+		return nil
 	}
+
+	models := make([]LLMModel, 0, len(server.Models))
+	for _, name := range server.Models {
+		capabilities := []string{"text", "code", "local"}
+		if strings.Contains(name, "embed") {
+			capabilities = []string{"embedding", "local"}
+		}
+		models = append(models, LLMModel{
+			ID:           providerID + "/" + name,
+			Name:         name,
+			Provider:     providerID,
+			Description:  "Local model via " + server.Name,
+			Capabilities: capabilities,
+		})
+	}
+	return models
 }
 
 // getMistralModels returns available Mistral models
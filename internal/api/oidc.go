@@ -0,0 +1,536 @@
+package api
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	_ "crypto/sha512" // SHA-384 and SHA-512 for RS384, RS512, ES384 and ES512
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+	"github.com/entrepeneur4lyf/codeforge/internal/httpclient"
+)
+
+const (
+	oidcLoginTimeout = 10 * time.Minute // Time allowed between starting a sign-on and the callback
+	oidcClockSkew    = time.Minute      // Leeway for ID token expiry
+)
+
+// OIDCProvider signs users in through an OpenID Connect identity provider
+// with the authorization code flow and PKCE, and maps their ID token claims
+// to a role
+type OIDCProvider struct {
+	config config.OIDCConfig
+	client *http.Client
+
+	mu        sync.Mutex
+	discovery *oidcDiscovery
+	keys      map[string]crypto.PublicKey // Signing keys from the provider's JWKS, by key ID
+	pending   map[string]*oidcLogin       // Sign-ons in progress, by state
+}
+
+// oidcDiscovery is the part of the provider metadata the login flow uses
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// oidcLogin is a sign-on waiting for its callback
+type oidcLogin struct {
+	verifier string // PKCE code verifier
+	nonce    string
+	expires  time.Time
+}
+
+// OIDCUser is a signed-in user and the role their claims map to
+type OIDCUser struct {
+	Name string
+	Role string
+}
+
+// NewOIDCProvider checks the SSO configuration. The identity provider isn't
+// contacted until the first sign-on.
+func NewOIDCProvider(cfg config.OIDCConfig) (*OIDCProvider, error) {
+	if cfg.Issuer == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+		return nil, fmt.Errorf("oidc.issuer, oidc.clientID and oidc.redirectURL are required")
+	}
+	if cfg.ClientSecret == "" {
+		cfg.ClientSecret = os.Getenv("CODEFORGE_OIDC_CLIENT_SECRET")
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"email", "profile"}
+	}
+	if cfg.UserClaim == "" {
+		cfg.UserClaim = "email"
+	}
+	if cfg.GroupsClaim == "" {
+		cfg.GroupsClaim = "groups"
+	}
+
+	// Viper lowercases map keys, so users and groups match case-insensitively
+	users := make(map[string]string, len(cfg.Users))
+	for name, role := range cfg.Users {
+		if roleRank[role] == 0 {
+			return nil, fmt.Errorf("oidc.users: unknown role %q for %s (use admin, developer or viewer)", role, name)
+		}
+		users[strings.ToLower(name)] = role
+	}
+	groups := make(map[string]string, len(cfg.Groups))
+	for name, role := range cfg.Groups {
+		if roleRank[role] == 0 {
+			return nil, fmt.Errorf("oidc.groups: unknown role %q for %s (use admin, developer or viewer)", role, name)
+		}
+		groups[strings.ToLower(name)] = role
+	}
+	if cfg.DefaultRole != "" && roleRank[cfg.DefaultRole] == 0 {
+		return nil, fmt.Errorf("oidc.defaultRole: unknown role %q (use admin, developer or viewer)", cfg.DefaultRole)
+	}
+	cfg.Users, cfg.Groups = users, groups
+
+	return &OIDCProvider{
+		config:  cfg,
+		client:  httpclient.New("oidc", 10*time.Second),
+		keys:    make(map[string]crypto.PublicKey),
+		pending: make(map[string]*oidcLogin),
+	}, nil
+}
+
+// AuthURL starts a sign-on and returns the identity provider's login page
+// to send the browser to
+func (p *OIDCProvider) AuthURL(ctx context.Context) (string, error) {
+	disc, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	state, err := randomString()
+	if err != nil {
+		return "", err
+	}
+	login := &oidcLogin{expires: time.Now().Add(oidcLoginTimeout)}
+	if login.verifier, err = randomString(); err != nil {
+		return "", err
+	}
+	if login.nonce, err = randomString(); err != nil {
+		return "", err
+	}
+
+	p.mu.Lock()
+	for s, l := range p.pending {
+		if time.Now().After(l.expires) {
+			delete(p.pending, s)
+		}
+	}
+	p.pending[state] = login
+	p.mu.Unlock()
+
+	challenge := sha256.Sum256([]byte(login.verifier))
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.config.ClientID},
+		"redirect_uri":          {p.config.RedirectURL},
+		"scope":                 {strings.Join(append([]string{"openid"}, p.config.Scopes...), " ")},
+		"state":                 {state},
+		"nonce":                 {login.nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	sep := "?"
+	if strings.Contains(disc.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return disc.AuthorizationEndpoint + sep + params.Encode(), nil
+}
+
+// Exchange finishes a sign-on from the callback's state and code. It
+// verifies the ID token and returns the user with their role; users with
+// no role are refused.
+func (p *OIDCProvider) Exchange(ctx context.Context, state, code string) (*OIDCUser, error) {
+	p.mu.Lock()
+	login, ok := p.pending[state]
+	delete(p.pending, state)
+	p.mu.Unlock()
+	if !ok || time.Now().After(login.expires) {
+		return nil, fmt.Errorf("unknown or expired sign-on, start again")
+	}
+	if code == "" {
+		return nil, fmt.Errorf("no authorization code in the callback")
+	}
+
+	disc, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.config.RedirectURL},
+		"client_id":     {p.config.ClientID},
+		"code_verifier": {login.verifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, disc.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+	}
+
+	var tokens struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := p.doJSON(req, &tokens); err != nil && tokens.Error == "" {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	if tokens.Error != "" {
+		return nil, fmt.Errorf("token request failed: %s %s", tokens.Error, tokens.ErrorDescription)
+	}
+	if tokens.IDToken == "" {
+		return nil, fmt.Errorf("the identity provider returned no ID token")
+	}
+
+	claims, err := p.verifyIDToken(ctx, tokens.IDToken, login.nonce)
+	if err != nil {
+		return nil, err
+	}
+	return p.userFor(claims)
+}
+
+// userFor maps ID token claims to a user and the most privileged role
+// their name or groups are granted
+func (p *OIDCProvider) userFor(claims map[string]any) (*OIDCUser, error) {
+	name, _ := claims[p.config.UserClaim].(string)
+	if name == "" {
+		return nil, fmt.Errorf("the ID token has no %s claim", p.config.UserClaim)
+	}
+	if p.config.UserClaim == "email" {
+		// An unverified address could belong to anyone
+		if verified, ok := claims["email_verified"].(bool); ok && !verified {
+			return nil, fmt.Errorf("the email address %s isn't verified", name)
+		}
+	}
+
+	role := p.config.Users[strings.ToLower(name)]
+	var groups []string
+	switch g := claims[p.config.GroupsClaim].(type) {
+	case string:
+		groups = []string{g}
+	case []any:
+		for _, v := range g {
+			if s, ok := v.(string); ok {
+				groups = append(groups, s)
+			}
+		}
+	}
+	for _, group := range groups {
+		if r := p.config.Groups[strings.ToLower(group)]; roleRank[r] > roleRank[role] {
+			role = r
+		}
+	}
+	if role == "" {
+		role = p.config.DefaultRole
+	}
+	if role == "" {
+		return nil, fmt.Errorf("%s has no role on this server", name)
+	}
+	return &OIDCUser{Name: name, Role: role}, nil
+}
+
+// verifyIDToken checks the ID token's signature against the provider's
+// keys and its issuer, audience, expiry and nonce, and returns its claims
+func (p *OIDCProvider) verifyIDToken(ctx context.Context, raw, nonce string) (map[string]any, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed ID token")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("malformed ID token header: %w", err)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed ID token signature: %w", err)
+	}
+	key, err := p.publicKey(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySignature(header.Alg, key, []byte(parts[0]+"."+parts[1]), sig); err != nil {
+		return nil, err
+	}
+
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("malformed ID token claims: %w", err)
+	}
+	disc, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if iss, _ := claims["iss"].(string); iss != disc.Issuer {
+		return nil, fmt.Errorf("ID token issued by %q, not %q", iss, disc.Issuer)
+	}
+	if !hasAudience(claims["aud"], p.config.ClientID) {
+		return nil, fmt.Errorf("ID token isn't for client %s", p.config.ClientID)
+	}
+	exp, ok := claims["exp"].(float64)
+	if !ok || time.Now().Add(-oidcClockSkew).After(time.Unix(int64(exp), 0)) {
+		return nil, fmt.Errorf("ID token expired")
+	}
+	if n, _ := claims["nonce"].(string); n != nonce {
+		return nil, fmt.Errorf("ID token nonce doesn't match the sign-on")
+	}
+	return claims, nil
+}
+
+// discover fetches the provider metadata once
+func (p *OIDCProvider) discover(ctx context.Context) (*oidcDiscovery, error) {
+	p.mu.Lock()
+	disc := p.discovery
+	p.mu.Unlock()
+	if disc != nil {
+		return disc, nil
+	}
+
+	issuer := strings.TrimSuffix(p.config.Issuer, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+	disc = &oidcDiscovery{}
+	if err := p.doJSON(req, disc); err != nil {
+		return nil, fmt.Errorf("failed to discover %s: %w", issuer, err)
+	}
+	if strings.TrimSuffix(disc.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("%s describes a different issuer, %s", issuer, disc.Issuer)
+	}
+	if disc.AuthorizationEndpoint == "" || disc.TokenEndpoint == "" || disc.JWKSURI == "" {
+		return nil, fmt.Errorf("%s doesn't support the authorization code flow", issuer)
+	}
+
+	p.mu.Lock()
+	p.discovery = disc
+	p.mu.Unlock()
+	return disc, nil
+}
+
+// publicKey returns the provider's signing key with the ID, fetching the
+// key set again when the key is new so rotated keys are picked up
+func (p *OIDCProvider) publicKey(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	p.mu.Lock()
+	key, ok := p.keys[kid]
+	p.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+
+	disc, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, disc.JWKSURI, nil)
+	if err != nil {
+		return nil, err
+	}
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := p.doJSON(req, &set); err != nil {
+		return nil, fmt.Errorf("failed to fetch signing keys: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		if pub, err := k.publicKey(); err == nil {
+			keys[k.Kid] = pub
+		}
+	}
+	p.mu.Lock()
+	p.keys = keys
+	p.mu.Unlock()
+
+	if key, ok = keys[kid]; !ok {
+		return nil, fmt.Errorf("ID token signed with unknown key %q", kid)
+	}
+	return key, nil
+}
+
+// doJSON sends the request and decodes a JSON response. The body is decoded
+// for error statuses too, since token errors are reported in it.
+func (p *OIDCProvider) doJSON(req *http.Request, v any) error {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	decodeErr := json.Unmarshal(body, v)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %s", req.URL.Host, resp.Status)
+	}
+	return decodeErr
+}
+
+// jsonWebKey is an RSA or elliptic curve key from a JWKS
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+}
+
+// verifySignature checks a JWS signature made with an RSA or ECDSA
+// algorithm. Other algorithms, including none and the HMAC ones, are
+// refused.
+func verifySignature(alg string, key crypto.PublicKey, signed, sig []byte) error {
+	var hash crypto.Hash
+	switch alg[min(2, len(alg)):] {
+	case "256":
+		hash = crypto.SHA256
+	case "384":
+		hash = crypto.SHA384
+	case "512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("unsupported ID token algorithm %q", alg)
+	}
+	h := hash.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(alg, "RS") {
+			break
+		}
+		if err := rsa.VerifyPKCS1v15(pub, hash, digest, sig); err != nil {
+			return fmt.Errorf("ID token signature is invalid")
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		if !strings.HasPrefix(alg, "ES") {
+			break
+		}
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return fmt.Errorf("ID token signature is invalid")
+		}
+		r, s := new(big.Int).SetBytes(sig[:size]), new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(pub, digest, r, s) {
+			return fmt.Errorf("ID token signature is invalid")
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported ID token algorithm %q", alg)
+}
+
+// hasAudience reports whether an aud claim, a string or a list, names the client
+func hasAudience(aud any, clientID string) bool {
+	switch a := aud.(type) {
+	case string:
+		return a == clientID
+	case []any:
+		for _, v := range a {
+			if v == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(data), nil
+}
+
+// randomString returns 256 random bits for states, nonces and PKCE verifiers
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
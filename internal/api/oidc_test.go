@@ -0,0 +1,166 @@
+package api
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+)
+
+// fakeIdP is an identity provider that signs ID tokens with the claims set
+// by the test
+type fakeIdP struct {
+	*httptest.Server
+	key      *rsa.PrivateKey
+	claims   map[string]any
+	verifier string // PKCE verifier from the last token request
+}
+
+func newFakeIdP(t *testing.T) *fakeIdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &fakeIdP{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.URL,
+			"authorization_endpoint": idp.URL + "/authorize",
+			"token_endpoint":         idp.URL + "/token",
+			"jwks_uri":               idp.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "k1",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if id, secret, _ := r.BasicAuth(); id != "codeforge" || secret != "s3cret" || r.FormValue("code") != "good-code" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		idp.verifier = r.FormValue("code_verifier")
+		json.NewEncoder(w).Encode(map[string]string{"id_token": idp.sign(t, idp.claims)})
+	})
+	idp.Server = httptest.NewServer(mux)
+	t.Cleanup(idp.Close)
+	return idp
+}
+
+func (idp *fakeIdP) sign(t *testing.T, claims map[string]any) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "k1"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, idp.key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestOIDCProvider_Exchange(t *testing.T) {
+	idp := newFakeIdP(t)
+	provider, err := NewOIDCProvider(config.OIDCConfig{
+		Issuer:       idp.URL,
+		ClientID:     "codeforge",
+		ClientSecret: "s3cret",
+		RedirectURL:  "https://codeforge.example.com/api/v1/auth/oidc/callback",
+		Users:        map[string]string{"ada@example.com": RoleViewer},
+		Groups:       map[string]string{"platform": RoleAdmin},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// start begins a sign-on and returns its state and nonce
+	start := func() (string, string) {
+		authURL, err := provider.AuthURL(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		u, _ := url.Parse(authURL)
+		if !strings.HasPrefix(authURL, idp.URL+"/authorize?") || u.Query().Get("code_challenge_method") != "S256" {
+			t.Fatalf("AuthURL() = %s", authURL)
+		}
+		return u.Query().Get("state"), u.Query().Get("nonce")
+	}
+	claims := func(nonce string) map[string]any {
+		return map[string]any{
+			"iss":    idp.URL,
+			"aud":    []string{"codeforge", "other"},
+			"exp":    time.Now().Add(time.Hour).Unix(),
+			"nonce":  nonce,
+			"email":  "Ada@example.com",
+			"groups": []string{"Platform"},
+		}
+	}
+
+	state, nonce := start()
+	idp.claims = claims(nonce)
+	user, err := provider.Exchange(context.Background(), state, "good-code")
+	if err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+	if user.Name != "Ada@example.com" || user.Role != RoleAdmin {
+		t.Errorf("Exchange() = %+v, want the group's admin role over the user's viewer role", user)
+	}
+	if idp.verifier == "" {
+		t.Error("no PKCE verifier sent")
+	}
+	if _, err := provider.Exchange(context.Background(), state, "good-code"); err == nil {
+		t.Error("a state was accepted twice")
+	}
+
+	tests := []struct {
+		name   string
+		modify func(map[string]any)
+		want   string
+	}{
+		{"wrong audience", func(c map[string]any) { c["aud"] = "other" }, "isn't for client"},
+		{"expired", func(c map[string]any) { c["exp"] = time.Now().Add(-time.Hour).Unix() }, "expired"},
+		{"wrong nonce", func(c map[string]any) { c["nonce"] = "replayed" }, "nonce"},
+		{"unverified email", func(c map[string]any) { c["email_verified"] = false }, "isn't verified"},
+		{"no role", func(c map[string]any) { c["email"] = "eve@example.com"; delete(c, "groups") }, "has no role"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, nonce := start()
+			idp.claims = claims(nonce)
+			tt.modify(idp.claims)
+			_, err := provider.Exchange(context.Background(), state, "good-code")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Exchange() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	// A token signed by another key is refused
+	_, nonce = start()
+	forged := &fakeIdP{}
+	forged.key, _ = rsa.GenerateKey(rand.Reader, 2048)
+	_, err = provider.verifyIDToken(context.Background(), forged.sign(t, claims(nonce)), nonce)
+	if err == nil || !strings.Contains(err.Error(), "signature is invalid") {
+		t.Errorf("verifyIDToken() with a forged token error = %v", err)
+	}
+}
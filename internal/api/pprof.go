@@ -0,0 +1,25 @@
+package api
+
+import (
+	"net/http"
+	"net/http/pprof"
+
+	"github.com/gorilla/mux"
+)
+
+// registerProfiling serves the runtime's pprof profiles under /debug/pprof on
+// router. They show code paths and memory contents, so secretRoutes limits
+// them to admins.
+func registerProfiling(router *mux.Router) {
+	router.HandleFunc("/debug/pprof/", pprof.Index).Methods("GET")
+	router.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline).Methods("GET")
+	router.HandleFunc("/debug/pprof/profile", pprof.Profile).Methods("GET")
+	router.HandleFunc("/debug/pprof/symbol", pprof.Symbol).Methods("GET", "POST")
+	router.HandleFunc("/debug/pprof/trace", pprof.Trace).Methods("GET")
+
+	// pprof.Index finds named profiles by the path without the /api/v1
+	// prefix, so they're routed here instead
+	router.HandleFunc("/debug/pprof/{profile}", func(w http.ResponseWriter, r *http.Request) {
+		pprof.Handler(mux.Vars(r)["profile"]).ServeHTTP(w, r)
+	}).Methods("GET")
+}
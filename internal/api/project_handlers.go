@@ -12,6 +12,8 @@ import (
 	"strings"
 
 	"github.com/entrepeneur4lyf/codeforge/internal/embeddings"
+	"github.com/entrepeneur4lyf/codeforge/internal/fileutil"
+	"github.com/entrepeneur4lyf/codeforge/internal/ignore"
 )
 
 // ProjectStructure represents the project file structure
@@ -51,6 +53,16 @@ type SearchResult struct {
 	Score    float64                `json:"score"`
 	Matches  []SearchMatch          `json:"matches"`
 	Context  map[string]interface{} `json:"context,omitempty"`
+	Symbol   *SearchSymbol          `json:"symbol,omitempty"` // Symbol enclosing a vector search match
+}
+
+// SearchSymbol identifies the function, method or type a result came from
+type SearchSymbol struct {
+	Name          string `json:"name"`
+	QualifiedName string `json:"qualified_name"` // With receiver or class, e.g. "(cs *ChatStorage) AddMessage"
+	Kind          string `json:"kind"`
+	StartLine     int    `json:"start_line"`
+	EndLine       int    `json:"end_line"`
 }
 
 // SearchMatch represents a match within a file
@@ -64,11 +76,12 @@ type SearchMatch struct {
 
 // handleProjectStructure returns the project file structure
 func (s *Server) handleProjectStructure(w http.ResponseWriter, r *http.Request) {
-	// Implement actual project structure scanning using filesystem
-	workingDir := "."
-	if s.config != nil && s.config.WorkingDir != "" {
-		workingDir = s.config.WorkingDir
+	scope, err := s.projectScope(r)
+	if err != nil {
+		s.writeError(w, err.Error(), http.StatusNotFound)
+		return
 	}
+	workingDir := scope.dir
 
 	// Build directory tree with limited depth to avoid performance issues
 	maxDepth := 3
@@ -162,20 +175,10 @@ func (s *Server) shouldIgnoreDirectory(dirPath string) bool {
 		}
 	}
 
-	// Fallback to common ignore patterns
+	// Fallback to the shared exclude patterns
 	name := filepath.Base(dirPath)
-	ignoreDirs := []string{
-		".git", ".svn", ".hg",
-		"node_modules", "vendor", "target",
-		".vscode", ".idea",
-		"__pycache__", ".pytest_cache",
-		"dist", "build", "out",
-	}
-
-	for _, ignore := range ignoreDirs {
-		if name == ignore {
-			return true
-		}
+	if ignore.Default().Match(name) {
+		return true
 	}
 
 	return strings.HasPrefix(name, ".")
@@ -190,17 +193,10 @@ func (s *Server) shouldIgnoreFile(filePath string) bool {
 		}
 	}
 
-	// Fallback to common ignore patterns
+	// Fallback to the shared exclude patterns
 	name := filepath.Base(filePath)
-	ignoreFiles := []string{
-		".DS_Store", "Thumbs.db",
-		".gitignore", ".gitkeep",
-	}
-
-	for _, ignore := range ignoreFiles {
-		if name == ignore {
-			return true
-		}
+	if ignore.Default().Match(name) {
+		return true
 	}
 
 	// Ignore hidden files
@@ -208,23 +204,8 @@ func (s *Server) shouldIgnoreFile(filePath string) bool {
 		return true
 	}
 
-	// Ignore binary files by extension
-	ext := strings.ToLower(filepath.Ext(name))
-	binaryExts := []string{
-		".exe", ".dll", ".so", ".dylib",
-		".jpg", ".jpeg", ".png", ".gif", ".bmp",
-		".mp3", ".mp4", ".avi", ".mov",
-		".zip", ".tar", ".gz", ".rar",
-		".pdf", ".doc", ".docx",
-	}
-
-	for _, binExt := range binaryExts {
-		if ext == binExt {
-			return true
-		}
-	}
-
-	return false
+	// Ignore binary files by content rather than extension
+	return fileutil.IsBinaryFile(filePath)
 }
 
 // scanProjectFiles scans the project directory for files
@@ -246,7 +227,7 @@ func (s *Server) scanProjectFiles(rootPath, languageFilter, typeFilter string) (
 		}
 
 		// Skip ignored files
-		if s.shouldIgnoreFile(info.Name()) {
+		if s.shouldIgnoreFile(path) {
 			return nil
 		}
 
@@ -341,36 +322,12 @@ func (s *Server) detectLanguage(filePath string) string {
 // countLines counts the number of lines in a text file
 func (s *Server) countLines(filePath string) int {
 	// Only count lines for text files to avoid reading large binary files
-	if s.shouldIgnoreFile(filepath.Base(filePath)) {
-		return 0
-	}
-
-	// Check if it's a text file by extension
-	ext := strings.ToLower(filepath.Ext(filePath))
-	textExts := []string{
-		".go", ".js", ".ts", ".py", ".java", ".cpp", ".c", ".h", ".hpp",
-		".rs", ".php", ".rb", ".sh", ".bash", ".zsh", ".fish", ".ps1",
-		".sql", ".html", ".css", ".scss", ".sass", ".less", ".xml",
-		".json", ".yaml", ".yml", ".toml", ".md", ".txt",
-	}
-
-	isText := false
-	for _, textExt := range textExts {
-		if ext == textExt {
-			isText = true
-			break
-		}
-	}
-
-	if !isText {
+	if s.shouldIgnoreFile(filePath) {
 		return 0
 	}
-
-	file, err := os.Open(filePath)
-	if err != nil {
+	if info, err := os.Stat(filePath); err != nil || info.Size() > fileutil.MaxFileSize() {
 		return 0
 	}
-	defer file.Close()
 
 	// Read file and count lines
 	content, err := os.ReadFile(filePath)
@@ -393,11 +350,12 @@ func (s *Server) handleProjectFiles(w http.ResponseWriter, r *http.Request) {
 	language := r.URL.Query().Get("language")
 	fileType := r.URL.Query().Get("type")
 
-	// Implement actual file scanning
-	workingDir := "."
-	if s.config != nil && s.config.WorkingDir != "" {
-		workingDir = s.config.WorkingDir
+	scope, err := s.projectScope(r)
+	if err != nil {
+		s.writeError(w, err.Error(), http.StatusNotFound)
+		return
 	}
+	workingDir := scope.dir
 
 	files, err := s.scanProjectFiles(workingDir, language, fileType)
 	if err != nil {
@@ -436,6 +394,12 @@ func (s *Server) handleProjectFiles(w http.ResponseWriter, r *http.Request) {
 
 // handleProjectSearch handles project-wide search
 func (s *Server) handleProjectSearch(w http.ResponseWriter, r *http.Request) {
+	scope, err := s.projectScope(r)
+	if err != nil {
+		s.writeError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
 	var req SearchRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		s.writeError(w, "Invalid request body", http.StatusBadRequest)
@@ -452,17 +416,16 @@ func (s *Server) handleProjectSearch(w http.ResponseWriter, r *http.Request) {
 
 	// Implement actual search using vector database and text search
 	var results []SearchResult
-	var err error
 
 	switch req.SearchType {
 	case "vector", "semantic":
-		results, err = s.performVectorSearch(req)
+		results, err = s.performVectorSearch(scope, req)
 	case "text", "literal":
-		results, err = s.performTextSearch(req)
+		results, err = s.performTextSearch(scope.dir, req)
 	default:
 		// Hybrid search: combine vector and text search
-		vectorResults, _ := s.performVectorSearch(req)
-		textResults, _ := s.performTextSearch(req)
+		vectorResults, _ := s.performVectorSearch(scope, req)
+		textResults, _ := s.performTextSearch(scope.dir, req)
 		results = s.combineSearchResults(vectorResults, textResults, req.MaxResults)
 	}
 
@@ -501,8 +464,9 @@ func (s *Server) handleProjectSearch(w http.ResponseWriter, r *http.Request) {
 }
 
 // performVectorSearch performs semantic search using the vector database
-func (s *Server) performVectorSearch(req SearchRequest) ([]SearchResult, error) {
-	if s.vectorDB == nil {
+func (s *Server) performVectorSearch(scope projectScope, req SearchRequest) ([]SearchResult, error) {
+	index, err := s.index(scope)
+	if err != nil {
 		return []SearchResult{}, nil
 	}
 
@@ -520,7 +484,7 @@ func (s *Server) performVectorSearch(req SearchRequest) ([]SearchResult, error)
 	}
 
 	// Search vector database
-	vectorResults, err := s.vectorDB.SearchSimilarChunks(ctx, queryEmbedding, req.MaxResults, filters)
+	vectorResults, err := index.SearchSimilarChunks(ctx, queryEmbedding, req.MaxResults, filters)
 	if err != nil {
 		return []SearchResult{}, err
 	}
@@ -543,18 +507,24 @@ func (s *Server) performVectorSearch(req SearchRequest) ([]SearchResult, error)
 				},
 			},
 		}
+		if symbol, ok := vr.Chunk.EnclosingSymbol(); ok {
+			result.Symbol = &SearchSymbol{
+				Name:          symbol.Name,
+				QualifiedName: symbol.QualifiedName(),
+				Kind:          symbol.Kind,
+				StartLine:     vr.Chunk.Location.StartLine,
+				EndLine:       vr.Chunk.Location.EndLine,
+			}
+			result.Matches[0].Context = vr.Chunk.Describe()
+		}
 		results = append(results, result)
 	}
 
 	return results, nil
 }
 
-// performTextSearch performs literal text search in files
-func (s *Server) performTextSearch(req SearchRequest) ([]SearchResult, error) {
-	workingDir := "."
-	if s.config != nil && s.config.WorkingDir != "" {
-		workingDir = s.config.WorkingDir
-	}
+// performTextSearch performs literal text search in the files under workingDir
+func (s *Server) performTextSearch(workingDir string, req SearchRequest) ([]SearchResult, error) {
 
 	var results []SearchResult
 	query := strings.ToLower(req.Query)
@@ -572,7 +542,7 @@ func (s *Server) performTextSearch(req SearchRequest) ([]SearchResult, error) {
 			return nil
 		}
 
-		if s.shouldIgnoreFile(info.Name()) {
+		if s.shouldIgnoreFile(path) || info.Size() > fileutil.MaxFileSize() {
 			return nil
 		}
 
@@ -661,7 +631,7 @@ func (s *Server) generateQueryEmbedding(ctx context.Context, query string) ([]fl
 		return embedding, nil
 	}
 
-	return embeddings.GetEmbedding(ctx, query)
+	return embeddings.GetQueryEmbedding(ctx, query)
 }
 
 // searchInFile searches for a query string in a file and returns matches
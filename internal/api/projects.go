@@ -0,0 +1,240 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+	"github.com/entrepeneur4lyf/codeforge/internal/vectordb"
+	"github.com/gorilla/mux"
+)
+
+// projectHeader picks the project a request works on, as the project query
+// parameter does. Without either, requests work on the server's own project.
+const projectHeader = "X-CodeForge-Project"
+
+// Project is a repository registered with the server. Each has its own
+// config, code index and chat sessions, so the web UI can switch between
+// repositories without restarting the server.
+type Project struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Path      string    `json:"path"`
+	CreatedAt time.Time `json:"created_at"`
+
+	config *config.Config     // The global config with the project's .codeforge.yaml merged over it
+	index  *vectordb.VectorDB // Opened on first use
+	mu     sync.Mutex
+}
+
+// projectScope is what a request works on: a registered project, or the
+// server's own when project is nil
+type projectScope struct {
+	project *Project
+	dir     string
+	config  *config.Config
+}
+
+// projectRegistry keeps the registered projects by ID
+type projectRegistry struct {
+	mu       sync.RWMutex
+	projects map[string]*Project
+}
+
+func newProjectRegistry() *projectRegistry {
+	return &projectRegistry{projects: make(map[string]*Project)}
+}
+
+// add registers the repository at path, returning the project already
+// registered there if there is one
+func (pr *projectRegistry) add(path, name string) (*Project, bool, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, false, err
+	}
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		return nil, false, fmt.Errorf("%s is not a directory", path)
+	}
+
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+	for _, p := range pr.projects {
+		if p.Path == path {
+			return p, false, nil
+		}
+	}
+
+	projectCfg, err := config.ForProject(path)
+	if err != nil {
+		return nil, false, err
+	}
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return nil, false, err
+	}
+	if name == "" {
+		name = filepath.Base(path)
+	}
+	p := &Project{
+		ID:        hex.EncodeToString(b),
+		Name:      name,
+		Path:      path,
+		CreatedAt: time.Now(),
+		config:    projectCfg,
+	}
+	pr.projects[p.ID] = p
+	return p, true, nil
+}
+
+func (pr *projectRegistry) get(id string) (*Project, bool) {
+	pr.mu.RLock()
+	defer pr.mu.RUnlock()
+	p, ok := pr.projects[id]
+	return p, ok
+}
+
+// list returns the projects, oldest first
+func (pr *projectRegistry) list() []*Project {
+	pr.mu.RLock()
+	defer pr.mu.RUnlock()
+	projects := make([]*Project, 0, len(pr.projects))
+	for _, p := range pr.projects {
+		projects = append(projects, p)
+	}
+	sort.Slice(projects, func(i, j int) bool { return projects[i].CreatedAt.Before(projects[j].CreatedAt) })
+	return projects
+}
+
+// remove unregisters a project and closes its index
+func (pr *projectRegistry) remove(id string) bool {
+	pr.mu.Lock()
+	p, ok := pr.projects[id]
+	delete(pr.projects, id)
+	pr.mu.Unlock()
+	if !ok {
+		return false
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.index != nil {
+		if err := p.index.Close(); err != nil {
+			log.Printf("Warning: failed to close the index of project %s: %v", p.Name, err)
+		}
+		p.index = nil
+	}
+	return true
+}
+
+// openIndex returns the project's code index, opening it in the project's
+// data directory the first time
+func (p *Project) openIndex() (*vectordb.VectorDB, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.index == nil {
+		index, err := vectordb.Open(p.config)
+		if err != nil {
+			return nil, err
+		}
+		p.index = index
+	}
+	return p.index, nil
+}
+
+// projectScope returns the project a request names with the project query
+// parameter or header, or the server's own project when it names none
+func (s *Server) projectScope(r *http.Request) (projectScope, error) {
+	id := r.URL.Query().Get("project")
+	if id == "" {
+		id = r.Header.Get(projectHeader)
+	}
+	if id != "" {
+		p, ok := s.projects.get(id)
+		if !ok {
+			return projectScope{}, fmt.Errorf("project %s not found", id)
+		}
+		return projectScope{project: p, dir: p.Path, config: p.config}, nil
+	}
+	return s.serverScope(), nil
+}
+
+// serverScope is the project the server was started in
+func (s *Server) serverScope() projectScope {
+	scope := projectScope{dir: ".", config: s.config}
+	if s.config != nil && s.config.WorkingDir != "" {
+		scope.dir = s.config.WorkingDir
+	}
+	return scope
+}
+
+// index returns the code index of the scope's project
+func (s *Server) index(scope projectScope) (*vectordb.VectorDB, error) {
+	if scope.project != nil {
+		return scope.project.openIndex()
+	}
+	if s.vectorDB == nil {
+		return nil, fmt.Errorf("Vector database not available")
+	}
+	return s.vectorDB, nil
+}
+
+// handleProjects handles GET /projects and POST /projects
+func (s *Server) handleProjects(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		projects := s.projects.list()
+		s.writeJSON(w, map[string]interface{}{
+			"projects": projects,
+			"total":    len(projects),
+		})
+		return
+	}
+
+	var req struct {
+		Path string `json:"path"`
+		Name string `json:"name,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Path == "" {
+		s.writeError(w, "A path to register is required", http.StatusBadRequest)
+		return
+	}
+
+	project, created, err := s.projects.add(req.Path, req.Name)
+	if err != nil {
+		s.writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if created {
+		w.WriteHeader(http.StatusCreated)
+	}
+	s.writeJSON(w, project)
+}
+
+// handleProject handles GET /projects/{id} and DELETE /projects/{id}.
+// Deleting a project unregisters it; nothing in the repository is removed.
+func (s *Server) handleProject(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if r.Method == http.MethodDelete {
+		if !s.projects.remove(id) {
+			s.writeError(w, "Project not found", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	project, ok := s.projects.get(id)
+	if !ok {
+		s.writeError(w, "Project not found", http.StatusNotFound)
+		return
+	}
+	s.writeJSON(w, project)
+}
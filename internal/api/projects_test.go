@@ -0,0 +1,93 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+	"github.com/gorilla/mux"
+)
+
+func TestProjectRegistry(t *testing.T) {
+	if _, err := config.Load(t.TempDir(), false); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	s := NewServer(nil)
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, config.ProjectConfigFile), []byte("model: qwen2.5-coder\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	register := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		s.handleProjects(rec, httptest.NewRequest(http.MethodPost, "/api/v1/projects", strings.NewReader(body)))
+		return rec
+	}
+
+	rec := register(`{"path": "` + dir + `"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var project Project
+	if err := json.Unmarshal(rec.Body.Bytes(), &project); err != nil {
+		t.Fatal(err)
+	}
+	if project.Name != filepath.Base(dir) || project.Path != dir {
+		t.Errorf("project %q at %q", project.Name, project.Path)
+	}
+	if rec := register(`{"path": "` + dir + `"}`); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), project.ID) {
+		t.Errorf("registering again: status %d: %s", rec.Code, rec.Body)
+	}
+	if rec := register(`{"path": "` + dir + `/missing"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("missing directory status %d", rec.Code)
+	}
+
+	// Sessions are created in and listed for the project the request names
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/chat/sessions", strings.NewReader(`{"title": "Other repo"}`))
+	req.Header.Set(projectHeader, project.ID)
+	s.createChatSession(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create session status %d: %s", rec.Code, rec.Body)
+	}
+	var session ChatSession
+	if err := json.Unmarshal(rec.Body.Bytes(), &session); err != nil {
+		t.Fatal(err)
+	}
+	if session.Project != project.ID || session.WorkingDir != dir || session.Model != "qwen2.5-coder" {
+		t.Errorf("session = %+v", session)
+	}
+
+	list := func(query string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		s.getChatSessions(rec, httptest.NewRequest(http.MethodGet, "/api/v1/chat/sessions"+query, nil))
+		return rec
+	}
+	if rec := list("?project=" + project.ID); !strings.Contains(rec.Body.String(), session.ID) {
+		t.Errorf("project sessions missing %s: %s", session.ID, rec.Body)
+	}
+	if rec := list(""); strings.Contains(rec.Body.String(), session.ID) {
+		t.Errorf("server sessions include the project's: %s", rec.Body)
+	}
+	if rec := list("?project=missing"); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown project status %d", rec.Code)
+	}
+
+	remove := func() int {
+		rec := httptest.NewRecorder()
+		req := mux.SetURLVars(httptest.NewRequest(http.MethodDelete, "/api/v1/projects/"+project.ID, nil), map[string]string{"id": project.ID})
+		s.handleProject(rec, req)
+		return rec.Code
+	}
+	if code := remove(); code != http.StatusNoContent {
+		t.Errorf("delete status %d", code)
+	}
+	if code := remove(); code != http.StatusNotFound {
+		t.Errorf("second delete status %d", code)
+	}
+}
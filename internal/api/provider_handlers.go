@@ -6,8 +6,9 @@ import (
 	"net/http"
 	"os"
 	"strings"
-	"time"
 
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+	"github.com/entrepeneur4lyf/codeforge/internal/llm/providers"
 	"github.com/entrepeneur4lyf/codeforge/internal/models"
 	"github.com/gorilla/mux"
 )
@@ -114,12 +115,12 @@ func (s *Server) handleProviders(w http.ResponseWriter, r *http.Request) {
 				Enabled: s.isProviderEnabled("ollama"),
 				Default: s.isDefaultProvider("llm", "ollama"),
 				Settings: map[string]interface{}{
-					"endpoint": "http://localhost:11434",
+					"endpoint": ollamaEndpoint(),
 					"model":    "llama3.1",
 				},
 				Status:      s.getProviderStatus("ollama"),
 				LastChecked: "2024-06-30T00:30:00Z",
-				Models:      []string{"llama3.1", "codellama", "mistral"},
+				Models:      s.getOllamaModelNames(),
 			},
 		}
 		providers = append(providers, llmProviders...)
@@ -254,10 +255,13 @@ func (s *Server) updateProvider(w http.ResponseWriter, r *http.Request, provider
 	// Update provider configuration
 	if req.Settings != nil {
 		if apiKey, ok := req.Settings["api_key"].(string); ok && apiKey != "" {
-			// Update API key in configuration
-			providerConfig := s.config.Providers[providerType]
-			providerConfig.APIKey = apiKey
-			s.config.Providers[providerType] = providerConfig
+			// Rotate the key for new and running sessions; providers with
+			// other credentials only have the configuration updated
+			if err := config.SetProviderKey(providerID, apiKey); err != nil {
+				providerConfig := s.config.Providers[providerType]
+				providerConfig.APIKey = apiKey
+				s.config.Providers[providerType] = providerConfig
+			}
 		}
 	}
 
@@ -470,44 +474,23 @@ func (s *Server) getProviderSetting(providerID, setting string) string {
 }
 
 func (s *Server) getProviderStatus(providerID string) string {
-	// Check actual provider availability
 	switch providerID {
-	case "anthropic":
-		if s.config != nil {
-			if providerConfig, exists := s.config.Providers[models.ProviderAnthropic]; exists && providerConfig.APIKey != "" {
-				return "configured"
-			}
-		}
-		if getEnvVar("ANTHROPIC_API_KEY") != "" {
-			return "configured"
-		}
-		return "available"
-	case "openai":
-		if s.config != nil {
-			if providerConfig, exists := s.config.Providers[models.ProviderOpenAI]; exists && providerConfig.APIKey != "" {
-				return "configured"
-			}
-		}
-		if getEnvVar("OPENAI_API_KEY") != "" {
-			return "configured"
-		}
-		return "available"
-	case "openrouter":
-		if s.config != nil {
-			if providerConfig, exists := s.config.Providers[models.ProviderOpenRouter]; exists && providerConfig.APIKey != "" {
-				return "configured"
-			}
-		}
-		if getEnvVar("OPENROUTER_API_KEY") != "" {
-			return "configured"
-		}
-		return "available"
 	case "ollama", "ollama-embedding":
 		// Check if Ollama is running by trying to connect
 		return s.checkOllamaAvailability()
-	default:
-		return "available"
 	}
+	spec, ok := config.LookupProvider(providerID)
+	if ok && spec.Local {
+		// Other local servers are available when found at startup
+		if _, found := providers.LocalServerByID(providerID); found {
+			return "available"
+		}
+		return "unavailable"
+	}
+	if ok && spec.APIKey() != "" {
+		return "configured"
+	}
+	return "available"
 }
 
 // Helper functions
@@ -527,22 +510,8 @@ func getEnvVar(name string) string {
 
 // checkOllamaAvailability checks if Ollama is running and available
 func (s *Server) checkOllamaAvailability() string {
-	endpoint := os.Getenv("OLLAMA_ENDPOINT")
-	if endpoint == "" {
-		endpoint = "http://localhost:11434"
-	}
-
-	// Try to connect to Ollama API
-	client := &http.Client{Timeout: 2 * time.Second}
-	resp, err := client.Get(endpoint + "/api/tags")
-	if err != nil {
+	if s.getOllamaModelNames() == nil {
 		return "unavailable"
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == http.StatusOK {
-		return "available"
-	}
-
-	return "unavailable"
+	return "available"
 }
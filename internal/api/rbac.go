@@ -0,0 +1,89 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Roles granted to API sessions. Localhost token sessions are admins; SSO
+// users get the role their claims map to.
+const (
+	RoleAdmin     = "admin"     // Everything, including configuration and tokens
+	RoleDeveloper = "developer" // Chat, edit files and approve tools and commands
+	RoleViewer    = "viewer"    // Search code and read sessions
+)
+
+// roleRank orders roles from least to most privileged
+var roleRank = map[string]int{RoleViewer: 1, RoleDeveloper: 2, RoleAdmin: 3}
+
+// Permission is a capability that roles grant
+type Permission string
+
+const (
+	PermRead   Permission = "read"   // Search code and read sessions, projects and settings
+	PermChat   Permission = "chat"   // Chat with the model, which edits files, and approve its tool calls and commands
+	PermManage Permission = "manage" // Change configuration, providers and environment variables, register projects, manage tokens
+)
+
+// rolePermissions lists the permissions each role grants
+var rolePermissions = map[string][]Permission{
+	RoleViewer:    {PermRead},
+	RoleDeveloper: {PermRead, PermChat},
+	RoleAdmin:     {PermRead, PermChat, PermManage},
+}
+
+// readRoutes are POST endpoints that only read, such as search
+var readRoutes = []string{"/project/search", "/code/analyze", "/code/symbols"}
+
+// manageRoutes need PermManage for any change. Registering a project opens
+// a directory of the server to the API, so it's managed too.
+var manageRoutes = []string{"/config", "/providers", "/projects"}
+
+// secretRoutes need PermManage even to read, since they show secrets, keys
+// and tokens, or, for profiles, the process's memory
+var secretRoutes = []string{"/environment", "/auth/sessions", "/config/keys", "/debug/pprof"}
+
+// hasPermission reports whether the role grants the permission
+func hasPermission(role string, perm Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// requiredPermission returns the permission a request to the API needs
+func requiredPermission(r *http.Request) Permission {
+	_, path, _ := strings.Cut(r.URL.Path, "/api/v1")
+	read := r.Method == http.MethodGet || r.Method == http.MethodHead
+
+	for _, route := range secretRoutes {
+		if strings.HasPrefix(path, route) {
+			return PermManage
+		}
+	}
+	for _, route := range manageRoutes {
+		if !read && strings.HasPrefix(path, route) {
+			return PermManage
+		}
+	}
+	// Chatting over the WebSocket sends messages
+	if strings.HasPrefix(path, "/chat/ws/") {
+		return PermChat
+	}
+	if read {
+		return PermRead
+	}
+	for _, route := range readRoutes {
+		if path == route {
+			return PermRead
+		}
+	}
+	return PermChat
+}
+
+// allows reports whether the session's role permits the request
+func (s *Session) allows(r *http.Request) bool {
+	return hasPermission(s.Role, requiredPermission(r))
+}
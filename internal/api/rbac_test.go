@@ -0,0 +1,41 @@
+package api
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSession_Allows(t *testing.T) {
+	tests := []struct {
+		role, method, path string
+		want               bool
+	}{
+		{RoleAdmin, "PUT", "/api/v1/config", true},
+		{RoleAdmin, "DELETE", "/api/v1/auth/sessions/abc", true},
+		{RoleDeveloper, "POST", "/api/v1/chat/sessions", true},
+		{RoleDeveloper, "POST", "/api/v1/approvals/abc/approve", true},
+		{RoleDeveloper, "GET", "/api/v1/chat/ws/abc", true},
+		{RoleDeveloper, "GET", "/api/v1/config", true},
+		{RoleDeveloper, "PUT", "/api/v1/providers/openai", false},
+		{RoleDeveloper, "POST", "/api/v1/projects", false},
+		{RoleDeveloper, "GET", "/api/v1/projects", true},
+		{RoleAdmin, "POST", "/api/v1/projects", true},
+		{RoleDeveloper, "GET", "/api/v1/environment", false},
+		{RoleDeveloper, "GET", "/api/v1/auth/sessions", false},
+		{RoleDeveloper, "GET", "/api/v1/config/keys", false},
+		{RoleDeveloper, "GET", "/api/v1/debug/pprof/heap", false},
+		{RoleAdmin, "GET", "/api/v1/debug/pprof/profile", true},
+		{RoleViewer, "GET", "/api/v1/chat/sessions/abc/messages", true},
+		{RoleViewer, "POST", "/api/v1/project/search", true},
+		{RoleViewer, "POST", "/api/v1/chat/sessions", false},
+		{RoleViewer, "POST", "/api/v1/approvals/abc/approve", false},
+		{RoleViewer, "GET", "/api/v1/chat/ws/abc", false},
+		{"", "GET", "/api/v1/chat/sessions", false},
+	}
+	for _, tt := range tests {
+		session := &Session{Role: tt.role}
+		if got := session.allows(httptest.NewRequest(tt.method, tt.path, nil)); got != tt.want {
+			t.Errorf("%s %s %s: allows() = %v, want %v", tt.role, tt.method, tt.path, got, tt.want)
+		}
+	}
+}
@@ -1,17 +1,23 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"net/url"
 	"sync"
 	"time"
 
 	"github.com/entrepeneur4lyf/codeforge/internal/app"
 	"github.com/entrepeneur4lyf/codeforge/internal/config"
+	"github.com/entrepeneur4lyf/codeforge/internal/permissions"
 	"github.com/entrepeneur4lyf/codeforge/internal/utils"
 	"github.com/entrepeneur4lyf/codeforge/internal/vectordb"
+	"github.com/entrepeneur4lyf/codeforge/internal/web/ui"
+	"github.com/entrepeneur4lyf/codeforge/internal/webhooks"
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
 )
@@ -98,6 +104,47 @@ func (cm *ConnectionManager) BroadcastToSession(sessionID string, message WebSoc
 	}
 }
 
+// BroadcastToAll sends a message to every chat and notification connection
+func (cm *ConnectionManager) BroadcastToAll(message WebSocketMessage) {
+	cm.mu.RLock()
+	var chatClients []*ChatWebSocketClient
+	for _, connections := range cm.chatConnections {
+		chatClients = append(chatClients, connections...)
+	}
+	var notificationClients []*NotificationWebSocketClient
+	for _, connections := range cm.notificationConnections {
+		notificationClients = append(notificationClients, connections...)
+	}
+	cm.mu.RUnlock()
+
+	for _, conn := range chatClients {
+		select {
+		case conn.send <- message:
+		default:
+			log.Printf("Dropping %s message for blocked chat connection in session %s", message.Type, conn.sessionID)
+		}
+	}
+	for _, conn := range notificationClients {
+		select {
+		case conn.send <- message:
+		default:
+			log.Printf("Dropping %s message for blocked notification connection in session %s", message.Type, conn.sessionID)
+		}
+	}
+}
+
+// ChatSessionIDs returns the sessions with open chat connections
+func (cm *ConnectionManager) ChatSessionIDs() []string {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	ids := make([]string, 0, len(cm.chatConnections))
+	for sessionID := range cm.chatConnections {
+		ids = append(ids, sessionID)
+	}
+	return ids
+}
+
 // GetConnectionStats returns connection statistics
 func (cm *ConnectionManager) GetConnectionStats() map[string]interface{} {
 	cm.mu.RLock()
@@ -185,14 +232,25 @@ type Server struct {
 	app               *app.App // Integrated CodeForge application
 	connectionManager *ConnectionManager
 	gitignoreFilter   *utils.GitIgnoreFilter
+	approvals         *permissions.ApprovalQueue // Pending tool and shell command approvals
+	port              int                        // Port the server listens on; 0 until Start
+	oidc              *OIDCProvider              // SSO sign-on; nil unless oidc is configured
+	idempotency       *idempotencyStore          // Responses to message sends, by Idempotency-Key
+	shares            *shareStore                // Read-only session links, by token
+	streams           *streamHub                 // Recent response events, for clients following sessions
+	projects          *projectRegistry           // Repositories registered besides the one the server started in
 }
 
 // NewServer creates a new API server
 func NewServer(cfg *config.Config) *Server {
-	return &Server{
+	server := &Server{
 		config:            cfg,
 		auth:              NewLocalhostAuth(),
 		chatStorage:       NewChatStorage(),
+		idempotency:       newIdempotencyStore(),
+		shares:            newShareStore(),
+		streams:           newStreamHub(),
+		projects:          newProjectRegistry(),
 		connectionManager: NewConnectionManager(),
 		upgrader: websocket.Upgrader{
 			CheckOrigin: func(r *http.Request) bool {
@@ -201,6 +259,9 @@ func NewServer(cfg *config.Config) *Server {
 			},
 		},
 	}
+	server.setupOIDC()
+	server.setupApprovalQueue(nil)
+	return server
 }
 
 // NewServerWithApp creates a new API server with integrated CodeForge app
@@ -210,6 +271,10 @@ func NewServerWithApp(cfg *config.Config, codeforgeApp *app.App) *Server {
 		vectorDB:          codeforgeApp.VectorDB,
 		auth:              NewLocalhostAuth(),
 		chatStorage:       NewChatStorage(),
+		idempotency:       newIdempotencyStore(),
+		shares:            newShareStore(),
+		streams:           newStreamHub(),
+		projects:          newProjectRegistry(),
 		app:               codeforgeApp,
 		connectionManager: NewConnectionManager(),
 		gitignoreFilter:   utils.NewGitIgnoreFilter(cfg.WorkingDir),
@@ -221,27 +286,89 @@ func NewServerWithApp(cfg *config.Config, codeforgeApp *app.App) *Server {
 		},
 	}
 
+	server.setupOIDC()
+
 	// Set server reference in app for event broadcasting
 	codeforgeApp.SetServer(server)
 
+	// Share sessions with the CLI and TUI through the persistent chat store
+	if codeforgeApp.ChatStore != nil {
+		server.chatStorage.SetStore(codeforgeApp.ChatStore)
+	}
+
+	// Queue tool permission requests and shell commands for approval by API
+	// clients
+	server.setupApprovalQueue(codeforgeApp.PermissionService)
+
 	return server
 }
 
-// isLocalhostOrigin checks if the WebSocket origin is localhost
+// setupOIDC enables SSO when it's configured, which opens the server to
+// other hosts. A configuration error leaves only localhost token auth.
+func (s *Server) setupOIDC() {
+	if s.config == nil || s.config.OIDC.Issuer == "" {
+		return
+	}
+	provider, err := NewOIDCProvider(s.config.OIDC)
+	if err != nil {
+		log.Printf("Warning: single sign-on disabled: %v", err)
+		return
+	}
+	s.oidc = provider
+	s.auth.allowRemote = true
+
+	// The web UI may be served under any host name
+	s.upgrader.CheckOrigin = func(r *http.Request) bool {
+		return isLocalhostOrigin(r) || isSameOrigin(r)
+	}
+}
+
+// isSameOrigin checks if the WebSocket origin is the host the request was sent to
+func isSameOrigin(r *http.Request) bool {
+	origin, err := url.Parse(r.Header.Get("Origin"))
+	return err == nil && origin.Host == r.Host
+}
+
+// isLocalhostOrigin checks if the WebSocket origin is localhost on the port
+// the request was sent to, whichever port the server listens on
 func isLocalhostOrigin(r *http.Request) bool {
 	origin := r.Header.Get("Origin")
 	if origin == "" {
 		return true // Allow connections without origin (like from Postman)
 	}
 
-	// Allow localhost origins
-	return origin == "http://localhost:47000" ||
-		origin == "http://127.0.0.1:47000" ||
-		origin == "http://[::1]:47000"
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	switch u.Hostname() {
+	case "localhost", "127.0.0.1", "::1":
+	default:
+		return false
+	}
+	originPort := u.Port()
+	if originPort == "" {
+		originPort = defaultPort(u.Scheme == "https")
+	}
+	_, requestPort, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		requestPort = defaultPort(r.TLS != nil)
+	}
+	return originPort == requestPort
+}
+
+// defaultPort is the port of a URL that doesn't name one
+func defaultPort(https bool) string {
+	if https {
+		return "443"
+	}
+	return "80"
 }
 
 // Start starts the API server
 func (s *Server) Start(port int) error {
+	s.port = port
+
 	// Initialize dependencies
 	if err := s.initializeDependencies(); err != nil {
 		return fmt.Errorf("failed to initialize dependencies: %w", err)
@@ -249,6 +376,9 @@ func (s *Server) Start(port int) error {
 
 	router := s.setupRoutes()
 
+	// Keep web clients in sync with sessions continued from the CLI or TUI
+	go s.syncPersistedSessions(context.Background())
+
 	addr := fmt.Sprintf(":%d", port)
 	log.Printf("🌐 Starting API server on %s", addr)
 
@@ -291,16 +421,29 @@ func (s *Server) setupRoutes() *mux.Router {
 	// Authentication endpoints (no auth required)
 	api.HandleFunc("/auth", s.handleAuth).Methods("GET", "POST", "DELETE")
 	api.HandleFunc("/auth/info", s.handleAuthInfo).Methods("GET")
+	api.HandleFunc("/auth/oidc/login", s.handleOIDCLogin).Methods("GET")
+	api.HandleFunc("/auth/oidc/callback", s.handleOIDCCallback).Methods("GET")
 
 	// Apply authentication middleware to protected routes
 	protected := api.PathPrefix("").Subrouter()
 	protected.Use(s.auth.AuthMiddleware)
 
+	// Session and token management (protected, admin only)
+	protected.HandleFunc("/auth/sessions", s.handleSessions).Methods("GET")
+	protected.HandleFunc("/auth/sessions/{id}", s.handleRevokeSession).Methods("DELETE")
+
 	// Chat endpoints (protected)
 	protected.HandleFunc("/chat/sessions", s.handleChatSessions).Methods("GET", "POST")
 	protected.HandleFunc("/chat/sessions/{id}", s.handleChatSession).Methods("GET", "DELETE")
-	protected.HandleFunc("/chat/sessions/{id}/messages", s.handleChatMessages).Methods("GET", "POST")
-	protected.HandleFunc("/chat/sessions/{sessionID}/messages/enhanced", s.sendChatMessageEnhanced).Methods("POST")
+	protected.HandleFunc("/chat/sessions/{id}/messages", s.idempotent(s.handleChatMessages)).Methods("GET", "POST")
+	protected.HandleFunc("/chat/sessions/{id}/feedback", s.handleChatFeedback).Methods("POST")
+	protected.HandleFunc("/chat/sessions/{id}/commands", s.handleRunCommand).Methods("POST")
+	protected.HandleFunc("/chat/sessions/{id}/usage", s.handleSessionUsage).Methods("GET")
+	protected.HandleFunc("/chat/sessions/{sessionID}/messages/enhanced", s.idempotent(s.sendChatMessageEnhanced)).Methods("POST")
+	protected.HandleFunc("/chat/sessions/{id}/messages/stream", s.handleChatStream).Methods("POST")
+	protected.HandleFunc("/chat/sessions/{id}/stream", s.handleSessionStream).Methods("GET")
+	protected.HandleFunc("/chat/sessions/{id}/share", s.handleSessionShares).Methods("GET", "POST")
+	protected.HandleFunc("/chat/sessions/{id}/share/{token}", s.handleRevokeSessionShare).Methods("DELETE")
 
 	// WebSocket for real-time chat (protected via token in URL)
 	protected.HandleFunc("/chat/ws/{sessionId}", s.handleChatWebSocket)
@@ -315,11 +458,27 @@ func (s *Server) setupRoutes() *mux.Router {
 	// WebSocket connection management (protected)
 	protected.HandleFunc("/websocket/stats", s.handleWebSocketStats).Methods("GET")
 
-	// Project and file management (protected)
+	// Project registry (protected)
+	protected.HandleFunc("/projects", s.handleProjects).Methods("GET", "POST")
+	protected.HandleFunc("/projects/{id}", s.handleProject).Methods("GET", "DELETE")
+
+	// Project and file management (protected); the project query parameter
+	// picks a registered project
 	protected.HandleFunc("/project/structure", s.handleProjectStructure).Methods("GET")
 	protected.HandleFunc("/project/files", s.handleProjectFiles).Methods("GET")
 	protected.HandleFunc("/project/search", s.handleProjectSearch).Methods("POST")
 
+	// Code index statistics (protected)
+	protected.HandleFunc("/index/stats", s.handleIndexStats).Methods("GET")
+
+	// Git status, staging and commits in the project (protected)
+	protected.HandleFunc("/git/status", s.handleGitStatus).Methods("GET")
+	protected.HandleFunc("/git/diff", s.handleGitDiff).Methods("GET")
+	protected.HandleFunc("/git/stage", s.handleGitStage(true)).Methods("POST")
+	protected.HandleFunc("/git/unstage", s.handleGitStage(false)).Methods("POST")
+	protected.HandleFunc("/git/commit-message", s.handleGitCommitMessage).Methods("POST")
+	protected.HandleFunc("/git/commit", s.handleGitCommit).Methods("POST")
+
 	// Code analysis (protected)
 	protected.HandleFunc("/code/analyze", s.handleCodeAnalysis).Methods("POST")
 	protected.HandleFunc("/code/symbols", s.handleCodeSymbols).Methods("POST")
@@ -328,24 +487,38 @@ func (s *Server) setupRoutes() *mux.Router {
 	protected.HandleFunc("/llm/providers", s.handleLLMProviders).Methods("GET")
 	protected.HandleFunc("/llm/models", s.handleLLMModels).Methods("GET")
 	protected.HandleFunc("/llm/models/{provider}", s.handleProviderModels).Methods("GET")
+	protected.HandleFunc("/llm/capabilities", s.handleLLMCapabilities).Methods("GET")
 
 	// Provider management (protected)
 	protected.HandleFunc("/providers", s.handleProviders).Methods("GET")
 	protected.HandleFunc("/providers/{id}", s.handleProvider).Methods("GET", "PUT", "DELETE")
 	protected.HandleFunc("/providers/embedding", s.handleEmbeddingProvider).Methods("GET", "PUT")
 
+	// Tool approval queue (protected)
+	protected.HandleFunc("/approvals", s.handleApprovals).Methods("GET")
+	protected.HandleFunc("/approvals/{id}", s.handleApproval).Methods("GET")
+	protected.HandleFunc("/approvals/{id}/approve", s.handleApprove).Methods("POST")
+	protected.HandleFunc("/approvals/{id}/deny", s.handleDeny).Methods("POST")
+
 	// Configuration (protected)
 	protected.HandleFunc("/config", s.handleConfig).Methods("GET", "PUT")
+	protected.HandleFunc("/config/keys", s.handleConfigKeys).Methods("GET", "PUT")
 
 	// Environment variables (protected)
 	protected.HandleFunc("/environment", s.handleEnvironment).Methods("GET", "PUT")
 	protected.PathPrefix("/environment/").HandlerFunc(s.handleEnvironmentVariable).Methods("GET", "PUT", "DELETE")
 
+	// Runtime profiles for performance triage (protected, admin only)
+	registerProfiling(protected)
+
 	// Health check (public)
 	api.HandleFunc("/health", s.handleHealth).Methods("GET")
 
-	// Static file serving for web UI
-	router.PathPrefix("/").Handler(http.FileServer(http.Dir("./web/dist/")))
+	// Shared sessions (public; the link's token grants access)
+	api.HandleFunc("/shared/{token}", s.handleSharedSession).Methods("GET")
+
+	// Web UI embedded in the binary
+	router.PathPrefix("/").Handler(ui.Handler())
 
 	return router
 }
@@ -355,7 +528,8 @@ func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Idempotency-Key")
+		w.Header().Set("Access-Control-Expose-Headers", "Idempotent-Replayed")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -375,6 +549,13 @@ func (s *Server) writeJSON(w http.ResponseWriter, data interface{}) {
 }
 
 func (s *Server) writeError(w http.ResponseWriter, message string, code int) {
+	if code >= http.StatusInternalServerError {
+		webhooks.Notify(webhooks.ServerError, fmt.Sprintf("Server error %d: %s", code, message), map[string]interface{}{
+			"status": code,
+			"error":  message,
+		})
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(code)
 	json.NewEncoder(w).Encode(map[string]string{"error": message})
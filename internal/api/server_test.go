@@ -0,0 +1,33 @@
+package api
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIsLocalhostOrigin(t *testing.T) {
+	tests := []struct {
+		host, origin string
+		want         bool
+	}{
+		{"localhost:47000", "http://localhost:47000", true},
+		{"127.0.0.1:8123", "http://127.0.0.1:8123", true},
+		{"localhost:8123", "http://localhost:8123", true},
+		{"[::1]:8123", "http://[::1]:8123", true},
+		{"localhost:8123", "", true},
+		{"localhost:8123", "http://localhost:47000", false},
+		{"localhost:8123", "http://evil.example:8123", false},
+		{"localhost", "http://localhost", true},
+		{"localhost:8123", "not a url\x7f", false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/api/v1/chat/ws/abc", nil)
+		r.Host = tt.host
+		if tt.origin != "" {
+			r.Header.Set("Origin", tt.origin)
+		}
+		if got := isLocalhostOrigin(r); got != tt.want {
+			t.Errorf("isLocalhostOrigin(Host %s, Origin %q) = %v, want %v", tt.host, tt.origin, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	maxStreamEvents     = 2000             // Events kept per session for clients catching up
+	streamRetention     = 10 * time.Minute // How long an idle session's events are kept
+	streamSubscriberBuf = 256
+	streamKeepAlive     = 15 * time.Second
+)
+
+// sessionStream is the recent response events of one session and the
+// clients following them
+type sessionStream struct {
+	events      []SSEEvent
+	next        uint64 // ID of the next event
+	subscribers map[chan SSEEvent]struct{}
+	updated     time.Time
+}
+
+// streamHub numbers the response events of each session and fans them out
+// to Server-Sent Events clients, keeping recent ones so a client that
+// reconnects can resume where it left off
+type streamHub struct {
+	mu       sync.Mutex
+	sessions map[string]*sessionStream
+}
+
+func newStreamHub() *streamHub {
+	return &streamHub{sessions: make(map[string]*sessionStream)}
+}
+
+// publish records an event of a session and sends it to its subscribers. A
+// subscriber too slow to keep up is dropped; it catches up on reconnecting.
+func (h *streamHub) publish(sessionID, event string, data interface{}) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	for id, st := range h.sessions {
+		if len(st.subscribers) == 0 && now.Sub(st.updated) > streamRetention {
+			delete(h.sessions, id)
+		}
+	}
+
+	st := h.session(sessionID)
+	st.next++
+	ev := SSEEvent{ID: strconv.FormatUint(st.next, 10), Event: event, Data: data}
+	st.events = append(st.events, ev)
+	if len(st.events) > maxStreamEvents {
+		st.events = st.events[len(st.events)-maxStreamEvents:]
+	}
+	st.updated = now
+
+	for ch := range st.subscribers {
+		select {
+		case ch <- ev:
+		default:
+			delete(st.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// subscribe follows a session's events, returning those after lastEventID
+// that are still kept, the channel of new ones, and a func to stop
+// following. Without a usable lastEventID nothing is replayed.
+func (h *streamHub) subscribe(sessionID, lastEventID string) ([]SSEEvent, <-chan SSEEvent, func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	st := h.session(sessionID)
+	var missed []SSEEvent
+	if last, err := strconv.ParseUint(lastEventID, 10, 64); err == nil && last <= st.next {
+		for _, ev := range st.events {
+			if id, _ := strconv.ParseUint(ev.ID, 10, 64); id > last {
+				missed = append(missed, ev)
+			}
+		}
+	}
+
+	ch := make(chan SSEEvent, streamSubscriberBuf)
+	st.subscribers[ch] = struct{}{}
+	unsubscribe := func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := st.subscribers[ch]; ok {
+			delete(st.subscribers, ch)
+			close(ch)
+		}
+		st.updated = time.Now()
+	}
+	return missed, ch, unsubscribe
+}
+
+// session returns the stream of a session, creating it. Callers hold mu.
+func (h *streamHub) session(sessionID string) *sessionStream {
+	st, ok := h.sessions[sessionID]
+	if !ok {
+		st = &sessionStream{subscribers: make(map[chan SSEEvent]struct{}), updated: time.Now()}
+		h.sessions[sessionID] = st
+	}
+	return st
+}
+
+// publishStream sends a response event of a session to clients following
+// it over Server-Sent Events
+func (s *Server) publishStream(sessionID, event string, data interface{}) {
+	if s.streams != nil {
+		s.streams.publish(sessionID, event, data)
+	}
+}
+
+// latestAssistantMessage returns the newest message of a session when the
+// assistant wrote it
+func (s *Server) latestAssistantMessage(sessionID string) (ChatMessage, bool) {
+	messages, _ := s.chatStorage.GetMessages(sessionID)
+	if len(messages) == 0 || messages[len(messages)-1].Role != "assistant" {
+		return ChatMessage{}, false
+	}
+	return messages[len(messages)-1], true
+}
+
+// handleSessionStream handles GET /chat/sessions/{id}/stream, following a
+// session's responses as Server-Sent Events for clients that can't use the
+// WebSocket. It sends the same stream, chat_response and error events, for
+// messages sent by any client. A client reconnecting with Last-Event-ID, or
+// the last_event_id query parameter, first gets the events it missed.
+func (s *Server) handleSessionStream(w http.ResponseWriter, r *http.Request) {
+	sessionID := mux.Vars(r)["id"]
+
+	if _, exists := s.loadPersistedSession(r.Context(), sessionID); !exists {
+		s.writeError(w, "Session not found", http.StatusNotFound)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok || s.streams == nil {
+		s.writeError(w, "Streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+	missed, events, unsubscribe := s.streams.subscribe(sessionID, lastEventID)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	fmt.Fprintf(w, "retry: 3000\n\n")
+	flusher.Flush()
+	for _, event := range missed {
+		if err := s.writeSSEEvent(w, event); err != nil {
+			return
+		}
+	}
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				// Dropped for falling behind; the client resumes from its last event
+				return
+			}
+			if err := s.writeSSEEvent(w, event); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprintf(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+	"github.com/gorilla/mux"
+)
+
+func TestStreamHub(t *testing.T) {
+	h := newStreamHub()
+	h.publish("s1", "stream", "a")
+	h.publish("s1", "stream", "b")
+	h.publish("s2", "stream", "other")
+
+	missed, events, unsubscribe := h.subscribe("s1", "1")
+	if len(missed) != 1 || missed[0].ID != "2" || missed[0].Data != "b" {
+		t.Fatalf("missed = %+v", missed)
+	}
+	h.publish("s1", "chat_response", "c")
+	if ev := <-events; ev.ID != "3" || ev.Event != "chat_response" {
+		t.Errorf("live event = %+v", ev)
+	}
+	unsubscribe()
+	if _, ok := <-events; ok {
+		t.Error("channel open after unsubscribing")
+	}
+
+	// Without a last event, or one from before a restart, only new events come
+	for _, last := range []string{"", "x", "99"} {
+		if missed, _, unsubscribe := h.subscribe("s1", last); len(missed) != 0 {
+			t.Errorf("subscribe(%q) replayed %d events", last, len(missed))
+		} else {
+			unsubscribe()
+		}
+	}
+
+	// A subscriber that falls behind is dropped, to resume on reconnecting
+	_, slow, unsubscribe := h.subscribe("s1", "")
+	defer unsubscribe()
+	for i := 0; i <= streamSubscriberBuf; i++ {
+		h.publish("s1", "stream", i)
+	}
+	n := 0
+	for range slow {
+		n++
+	}
+	if n != streamSubscriberBuf {
+		t.Errorf("slow subscriber got %d events before being dropped", n)
+	}
+}
+
+func TestHandleSessionStream(t *testing.T) {
+	s := NewServer(nil)
+	session := s.chatStorage.CreateSession("Streaming")
+	s.publishStream(session.ID, "stream", llm.NewStreamEvent(llm.ApiStreamTextChunk{Text: "Hel"}))
+	s.publishStream(session.ID, "stream", llm.NewStreamEvent(llm.ApiStreamTextChunk{Text: "lo"}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/chat/sessions/"+session.ID+"/stream", nil).WithContext(ctx)
+	req = mux.SetURLVars(req, map[string]string{"id": session.ID})
+	req.Header.Set("Last-Event-ID", "1")
+	rec := httptest.NewRecorder()
+	s.handleSessionStream(rec, req)
+
+	body := rec.Body.String()
+	if !strings.Contains(body, "id: 2\nevent: stream\ndata: {\"type\":\"text\",\"text\":\"lo\"}") || strings.Contains(body, "Hel") {
+		t.Errorf("stream =\n%s", body)
+	}
+
+	req = mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/api/v1/chat/sessions/missing/stream", nil), map[string]string{"id": "missing"})
+	rec = httptest.NewRecorder()
+	s.handleSessionStream(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("missing session status = %d", rec.Code)
+	}
+}
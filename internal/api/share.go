@@ -0,0 +1,225 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/storage"
+	"github.com/entrepeneur4lyf/codeforge/internal/transcript"
+	"github.com/gorilla/mux"
+)
+
+const (
+	defaultShareTTL = 24 * time.Hour      // How long a share link works unless the request says
+	maxShareTTL     = 30 * 24 * time.Hour // The longest a share link may work
+)
+
+// SessionShare is a read-only link to a session
+type SessionShare struct {
+	Token     string    `json:"token"`
+	SessionID string    `json:"session_id"`
+	URL       string    `json:"url"` // Web UI page showing the session, relative to the server
+	CreatedBy string    `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// SharedMessage is a message as shown through a share link, without the
+// metadata only the owner's clients need
+type SharedMessage struct {
+	Role      string                `json:"role"`
+	Content   string                `json:"content"`
+	Timestamp time.Time             `json:"timestamp"`
+	Model     string                `json:"model,omitempty"`
+	Diffs     []transcript.FileDiff `json:"diffs,omitempty"` // Changes the message's tool calls made
+}
+
+// shareStore keeps share links by token until they expire
+type shareStore struct {
+	mu     sync.Mutex
+	shares map[string]*SessionShare
+}
+
+func newShareStore() *shareStore {
+	return &shareStore{shares: make(map[string]*SessionShare)}
+}
+
+// add creates a link to the session that works for ttl
+func (st *shareStore) add(sessionID, user string, ttl time.Duration) (*SessionShare, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	token := hex.EncodeToString(b)
+	now := time.Now()
+	share := &SessionShare{
+		Token:     token,
+		SessionID: sessionID,
+		URL:       "/share/" + token,
+		CreatedBy: user,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.prune(now)
+	st.shares[token] = share
+	return share, nil
+}
+
+// get returns the share with the token, if it hasn't expired
+func (st *shareStore) get(token string) (*SessionShare, bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.prune(time.Now())
+	share, ok := st.shares[token]
+	return share, ok
+}
+
+// list returns the session's unexpired shares
+func (st *shareStore) list(sessionID string) []*SessionShare {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.prune(time.Now())
+	shares := []*SessionShare{}
+	for _, share := range st.shares {
+		if share.SessionID == sessionID {
+			shares = append(shares, share)
+		}
+	}
+	return shares
+}
+
+// revoke removes a share of the session, reporting whether it existed
+func (st *shareStore) revoke(sessionID, token string) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	share, ok := st.shares[token]
+	if !ok || share.SessionID != sessionID {
+		return false
+	}
+	delete(st.shares, token)
+	return true
+}
+
+// prune drops expired shares; the caller holds the lock
+func (st *shareStore) prune(now time.Time) {
+	for token, share := range st.shares {
+		if now.After(share.ExpiresAt) {
+			delete(st.shares, token)
+		}
+	}
+}
+
+// handleSessionShares handles GET and POST /chat/sessions/{id}/share, listing
+// a session's share links or creating one. The body may set expires_in, a
+// duration such as "72h"; links last a day by default.
+func (s *Server) handleSessionShares(w http.ResponseWriter, r *http.Request) {
+	sessionID := mux.Vars(r)["id"]
+	if _, exists := s.loadPersistedSession(r.Context(), sessionID); !exists {
+		s.writeError(w, "Session not found", http.StatusNotFound)
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		shares := s.shares.list(sessionID)
+		s.writeJSON(w, map[string]interface{}{
+			"shares": shares,
+			"total":  len(shares),
+		})
+		return
+	}
+
+	var req struct {
+		ExpiresIn string `json:"expires_in,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		s.writeError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	ttl := defaultShareTTL
+	if req.ExpiresIn != "" {
+		d, err := time.ParseDuration(req.ExpiresIn)
+		if err != nil || d <= 0 {
+			s.writeError(w, "expires_in must be a positive duration such as \"72h\"", http.StatusBadRequest)
+			return
+		}
+		if d > maxShareTTL {
+			s.writeError(w, "Share links can last at most 30 days", http.StatusBadRequest)
+			return
+		}
+		ttl = d
+	}
+
+	user := ""
+	if session, ok := GetSession(r.Context()); ok {
+		user = session.User
+	}
+	share, err := s.shares.add(sessionID, user, ttl)
+	if err != nil {
+		s.writeError(w, "Failed to create share link", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	s.writeJSON(w, share)
+}
+
+// handleRevokeSessionShare handles DELETE /chat/sessions/{id}/share/{token}
+func (s *Server) handleRevokeSessionShare(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	if !s.shares.revoke(vars["id"], vars["token"]) {
+		s.writeError(w, "Share not found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleSharedSession handles GET /shared/{token}, which needs no sign-in:
+// the token grants read-only access to one session until it expires
+func (s *Server) handleSharedSession(w http.ResponseWriter, r *http.Request) {
+	share, ok := s.shares.get(mux.Vars(r)["token"])
+	if !ok {
+		s.writeError(w, "Share link not found or expired", http.StatusNotFound)
+		return
+	}
+
+	session, exists := s.loadPersistedSession(r.Context(), share.SessionID)
+	if !exists {
+		s.writeError(w, "Session not found", http.StatusNotFound)
+		return
+	}
+	messages, err := s.chatStorage.GetMessages(share.SessionID)
+	if err != nil {
+		s.writeError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	shared := make([]SharedMessage, 0, len(messages))
+	for _, msg := range messages {
+		shared = append(shared, SharedMessage{
+			Role:      msg.Role,
+			Content:   msg.Content,
+			Timestamp: msg.Timestamp,
+			Model:     msg.Model,
+			Diffs:     transcript.MessageDiffs(storage.Message{Metadata: msg.Metadata}),
+		})
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	s.writeJSON(w, map[string]interface{}{
+		"session": map[string]interface{}{
+			"title":      session.Title,
+			"model":      session.Model,
+			"created_at": session.CreatedAt,
+		},
+		"messages":   shared,
+		"expires_at": share.ExpiresAt,
+	})
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestSessionShare(t *testing.T) {
+	s := NewServer(nil)
+	session := s.chatStorage.CreateSession("Debugging")
+	s.chatStorage.AddMessage(session.ID, ChatMessage{ID: "m1", Role: "user", Content: "fix it"})
+	s.chatStorage.AddMessage(session.ID, ChatMessage{
+		ID:      "m2",
+		Role:    "assistant",
+		Content: "done",
+		Metadata: map[string]interface{}{
+			"client": "cli",
+			"tool_calls": []interface{}{
+				map[string]interface{}{"name": "edit", "arguments": `{"file_path":"main.go","old_string":"a\n","new_string":"b\n"}`},
+			},
+		},
+	})
+	router := s.setupRoutes()
+
+	share := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/chat/sessions/"+session.ID+"/share", strings.NewReader(body))
+		req = mux.SetURLVars(req, map[string]string{"id": session.ID})
+		rec := httptest.NewRecorder()
+		s.handleSessionShares(rec, req)
+		return rec
+	}
+	view := func(token string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/shared/"+token, nil))
+		return rec
+	}
+
+	rec := share(`{"expires_in":"1h"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("share: status %d: %s", rec.Code, rec.Body)
+	}
+	var created SessionShare
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	if created.URL != "/share/"+created.Token || time.Until(created.ExpiresAt) > time.Hour {
+		t.Errorf("unexpected share %+v", created)
+	}
+
+	// The link works without signing in
+	rec = view(created.Token)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("view: status %d: %s", rec.Code, rec.Body)
+	}
+	var shared struct {
+		Messages []SharedMessage `json:"messages"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &shared); err != nil {
+		t.Fatal(err)
+	}
+	if len(shared.Messages) != 2 || len(shared.Messages[1].Diffs) != 1 {
+		t.Fatalf("unexpected messages %+v", shared.Messages)
+	}
+	if diff := shared.Messages[1].Diffs[0].Diff; !strings.Contains(diff, "-a") || !strings.Contains(diff, "+b") {
+		t.Errorf("unexpected diff %q", diff)
+	}
+	if strings.Contains(rec.Body.String(), `"client"`) {
+		t.Error("shared messages include metadata")
+	}
+
+	if rec := view("unknown"); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown token: status %d", rec.Code)
+	}
+
+	// Expired links stop working
+	s.shares.shares[created.Token].ExpiresAt = time.Now().Add(-time.Second)
+	if rec := view(created.Token); rec.Code != http.StatusNotFound {
+		t.Errorf("expired token: status %d", rec.Code)
+	}
+
+	if rec := share(`{"expires_in":"900h"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("too long: status %d", rec.Code)
+	}
+	if rec := share(`{"expires_in":"soon"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid duration: status %d", rec.Code)
+	}
+
+	// Revoked links stop working
+	rec = share("")
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	if !s.shares.revoke(session.ID, created.Token) {
+		t.Fatal("revoke failed")
+	}
+	if rec := view(created.Token); rec.Code != http.StatusNotFound {
+		t.Errorf("revoked token: status %d", rec.Code)
+	}
+}
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+	"github.com/entrepeneur4lyf/codeforge/internal/storage"
+	"github.com/entrepeneur4lyf/codeforge/internal/transcript"
+	"github.com/gorilla/mux"
+)
+
+// usageKeys are the message metadata a response's usage is kept in, as the
+// CLI and the app's streaming path save it
+var usageKeys = []string{"input_tokens", "output_tokens", "cost"}
+
+// usageMetadata returns the message metadata recording a response's usage,
+// or nil when it isn't known
+func usageMetadata(usage *llm.Usage) map[string]interface{} {
+	if usage == nil {
+		return nil
+	}
+	metadata := map[string]interface{}{
+		"input_tokens":  usage.PromptTokens,
+		"output_tokens": usage.CompletionTokens,
+	}
+	if usage.TotalCost > 0 {
+		metadata["cost"] = usage.TotalCost
+	}
+	return metadata
+}
+
+// UsageTotals is what a set of responses used: tokens in and out, and the
+// cost in US dollars
+type UsageTotals struct {
+	Responses    int     `json:"responses"`
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	TotalTokens  int     `json:"total_tokens"`
+	Cost         float64 `json:"cost"`
+}
+
+func (t *UsageTotals) add(u transcript.Usage) {
+	t.Responses++
+	t.InputTokens += u.InputTokens
+	t.OutputTokens += u.OutputTokens
+	t.TotalTokens += u.InputTokens + u.OutputTokens
+	t.Cost += u.Cost
+}
+
+// ModelUsage is what one model's responses in a session used
+type ModelUsage struct {
+	Model string `json:"model"`
+	UsageTotals
+}
+
+// MessageUsage is what one response used
+type MessageUsage struct {
+	MessageID    string    `json:"message_id"`
+	Model        string    `json:"model,omitempty"`
+	InputTokens  int       `json:"input_tokens"`
+	OutputTokens int       `json:"output_tokens"`
+	Cost         float64   `json:"cost"`
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+// SessionUsage is what a session's responses used, in total, by model and
+// by message. Responses whose usage wasn't recorded are counted in
+// Unmetered.
+type SessionUsage struct {
+	SessionID string         `json:"session_id"`
+	Total     UsageTotals    `json:"total"`
+	Models    []ModelUsage   `json:"models"`
+	Messages  []MessageUsage `json:"messages"`
+	Unmetered int            `json:"unmetered"`
+}
+
+// sessionUsage adds up the usage recorded with a session's messages
+func sessionUsage(sessionID string, messages []ChatMessage) SessionUsage {
+	usage := SessionUsage{SessionID: sessionID, Models: []ModelUsage{}, Messages: []MessageUsage{}}
+	models := make(map[string]*ModelUsage)
+	for _, msg := range messages {
+		if msg.Role != "assistant" {
+			continue
+		}
+		u, ok := transcript.MessageUsage(storage.Message{Metadata: msg.Metadata})
+		if !ok {
+			usage.Unmetered++
+			continue
+		}
+
+		usage.Total.add(u)
+		m, ok := models[msg.Model]
+		if !ok {
+			m = &ModelUsage{Model: msg.Model}
+			models[msg.Model] = m
+		}
+		m.add(u)
+		usage.Messages = append(usage.Messages, MessageUsage{
+			MessageID:    msg.ID,
+			Model:        msg.Model,
+			InputTokens:  u.InputTokens,
+			OutputTokens: u.OutputTokens,
+			Cost:         u.Cost,
+			Timestamp:    msg.Timestamp,
+		})
+	}
+
+	for _, m := range models {
+		usage.Models = append(usage.Models, *m)
+	}
+	sort.Slice(usage.Models, func(i, j int) bool {
+		if usage.Models[i].Cost != usage.Models[j].Cost {
+			return usage.Models[i].Cost > usage.Models[j].Cost
+		}
+		return usage.Models[i].Model < usage.Models[j].Model
+	})
+	return usage
+}
+
+// handleSessionUsage handles GET /chat/sessions/{id}/usage, reporting the
+// tokens a session's responses used and what they cost
+func (s *Server) handleSessionUsage(w http.ResponseWriter, r *http.Request) {
+	sessionID := mux.Vars(r)["id"]
+	if _, exists := s.loadPersistedSession(r.Context(), sessionID); !exists {
+		s.writeError(w, "Session not found", http.StatusNotFound)
+		return
+	}
+	messages, err := s.chatStorage.GetMessages(sessionID)
+	if err != nil {
+		s.writeError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	s.writeJSON(w, sessionUsage(sessionID, messages))
+}
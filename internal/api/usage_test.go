@@ -0,0 +1,67 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestSessionUsage(t *testing.T) {
+	s := NewServer(nil)
+	session := s.chatStorage.CreateSession("Usage")
+	session.Model = "mock/echo"
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/chat/sessions/"+session.ID+"/messages", strings.NewReader(`{"message": "count my tokens"}`))
+	rec := httptest.NewRecorder()
+	s.handleChatMessages(rec, mux.SetURLVars(req, map[string]string{"id": session.ID}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("send status %d: %s", rec.Code, rec.Body)
+	}
+
+	// A priced response from another model, and one whose usage wasn't recorded
+	s.chatStorage.AddMessage(session.ID, ChatMessage{
+		SessionID: session.ID,
+		Role:      "assistant",
+		Model:     "claude-sonnet-4",
+		Metadata:  map[string]interface{}{"input_tokens": 1000.0, "output_tokens": 200.0, "cost": 0.006},
+	})
+	s.chatStorage.AddMessage(session.ID, ChatMessage{SessionID: session.ID, Role: "assistant", Content: "imported"})
+
+	usage := func(sessionID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/chat/sessions/"+sessionID+"/usage", nil)
+		rec := httptest.NewRecorder()
+		s.handleSessionUsage(rec, mux.SetURLVars(req, map[string]string{"id": sessionID}))
+		return rec
+	}
+
+	rec = usage(session.ID)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("usage status %d: %s", rec.Code, rec.Body)
+	}
+	var got SessionUsage
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Total.Responses != 2 || got.Unmetered != 1 || len(got.Messages) != 2 {
+		t.Fatalf("usage = %+v", got)
+	}
+	echo := got.Messages[0]
+	if echo.Model != "mock/echo" || echo.InputTokens == 0 || echo.OutputTokens == 0 {
+		t.Errorf("echo response usage = %+v", echo)
+	}
+	if got.Total.InputTokens != echo.InputTokens+1000 || got.Total.TotalTokens != got.Total.InputTokens+got.Total.OutputTokens {
+		t.Errorf("total = %+v", got.Total)
+	}
+	if len(got.Models) != 2 || got.Models[0].Model != "claude-sonnet-4" || got.Models[0].Cost != 0.006 {
+		t.Errorf("models = %+v", got.Models)
+	}
+
+	if rec := usage("missing"); rec.Code != http.StatusNotFound {
+		t.Errorf("missing session status %d", rec.Code)
+	}
+}
@@ -4,10 +4,14 @@ import (
 	"context"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/entrepeneur4lyf/codeforge/internal/chat"
 	"github.com/entrepeneur4lyf/codeforge/internal/events"
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
 	"github.com/entrepeneur4lyf/codeforge/internal/markdown"
+	"github.com/entrepeneur4lyf/codeforge/internal/storage"
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
 )
@@ -18,6 +22,7 @@ type ChatWebSocketClient struct {
 	sessionID string
 	send      chan WebSocketMessage
 	server    *Server
+	ctx       context.Context
 	cancel    context.CancelFunc
 }
 
@@ -42,6 +47,7 @@ func (s *Server) handleChatWebSocket(w http.ResponseWriter, r *http.Request) {
 		sessionID: sessionID,
 		send:      make(chan WebSocketMessage, 256),
 		server:    s,
+		ctx:       ctx,
 		cancel:    cancel,
 	}
 
@@ -152,6 +158,25 @@ func (c *ChatWebSocketClient) handleChatMessage(msg WebSocketMessage) {
 		return
 	}
 
+	// One generation at a time per session keeps messages in order
+	lock, err := storage.LockSession(c.sessionID, webClient)
+	if err != nil {
+		c.sendError(err.Error(), msg.EventID)
+		return
+	}
+
+	// Store the user message so other clients of the session see it; a
+	// streamed exchange is saved by the app instead
+	c.server.loadPersistedSession(context.Background(), c.sessionID)
+	if c.server.app == nil {
+		c.server.chatStorage.AddMessage(c.sessionID, ChatMessage{
+			SessionID: c.sessionID,
+			Role:      "user",
+			Content:   message,
+		})
+	}
+	model, _ := data["model"].(string)
+
 	// Send acknowledgment
 	c.sendMessage(WebSocketMessage{
 		Type:    "message_received",
@@ -163,11 +188,15 @@ func (c *ChatWebSocketClient) handleChatMessage(msg WebSocketMessage) {
 	})
 
 	// Process message asynchronously
-	go c.processMessage(message, msg.EventID)
+	go func() {
+		defer lock.Release()
+		c.processMessage(message, model, msg.EventID)
+	}()
 }
 
-// processMessage handles AI response generation with markdown support
-func (c *ChatWebSocketClient) processMessage(message string, eventID string) {
+// processMessage handles AI response generation with markdown support,
+// streaming the response as it's generated
+func (c *ChatWebSocketClient) processMessage(message, model, eventID string) {
 	// Send typing indicator
 	c.sendMessage(WebSocketMessage{
 		Type: "assistant_typing",
@@ -175,10 +204,15 @@ func (c *ChatWebSocketClient) processMessage(message string, eventID string) {
 			"typing": true,
 		},
 	})
+	defer c.sendMessage(WebSocketMessage{
+		Type: "assistant_typing",
+		Data: map[string]interface{}{
+			"typing": false,
+		},
+	})
 
-	// Integrate with actual chat engine
 	// Get session to determine model and provider
-	session, exists := c.server.chatStorage.GetSession(c.sessionID)
+	session, exists := c.server.loadPersistedSession(context.Background(), c.sessionID)
 	if !exists {
 		c.sendMessage(WebSocketMessage{
 			Type:  "error",
@@ -187,59 +221,106 @@ func (c *ChatWebSocketClient) processMessage(message string, eventID string) {
 		return
 	}
 
-	// Use the integrated CodeForge app for real chat processing
-	var responseContent string
-	if c.server.app != nil {
-		ctx := context.Background()
-		modelID := session.Model
-		if modelID == "" {
-			modelID = "default"
-		}
-
-		response, err := c.server.app.ProcessChatMessage(ctx, c.sessionID, message, modelID)
-		if err != nil {
-			log.Printf("Chat processing error: %v", err)
-			responseContent = "I apologize, but I encountered an error processing your message. Please try again."
-		} else {
-			responseContent = response
-		}
-	} else {
-		responseContent = "Chat processing is not available - app not initialized"
+	if c.server.app == nil {
+		response := c.markdownResponse("Chat processing is not available - app not initialized", session)
+		c.server.chatStorage.AddMessage(c.sessionID, *response)
+		c.sendMessage(WebSocketMessage{
+			Type:    "chat_response",
+			EventID: eventID,
+			Data:    response,
+		})
+		return
 	}
 
-	// Process response with markdown support
-	processedResponse, err := c.processResponseWithMarkdown(responseContent, session)
-	if err != nil {
-		log.Printf("Markdown processing error: %v", err)
-		// Fallback to basic response
-		processedResponse = &ChatMessage{
-			ID:        generateMessageID(),
-			SessionID: c.sessionID,
-			Role:      "assistant",
-			Content:   responseContent,
-			Timestamp: time.Now(),
-			Metadata: map[string]interface{}{
-				"model":    session.Model,
-				"provider": session.Provider,
-				"via":      "websocket",
-				"markdown": false,
-			},
-		}
+	if model == "" {
+		model = session.Model
+	}
+	if model == "" {
+		model = chat.GetDefaultModel()
+	}
+	content, ok := c.streamResponse(message, model, eventID)
+	if !ok {
+		return
 	}
 
+	// The app saved the exchange to the store; show it in the session too and
+	// send the complete message, under its stored ID, to end the stream
+	c.server.importLatestMessages(c.sessionID, 2)
+	response := c.markdownResponse(content, session)
+	if stored, ok := c.server.latestAssistantMessage(c.sessionID); ok {
+		response.ID = stored.ID
+	}
+	c.server.publishStream(c.sessionID, "chat_response", response)
 	c.sendMessage(WebSocketMessage{
 		Type:    "chat_response",
 		EventID: eventID,
-		Data:    processedResponse,
+		Data:    response,
 	})
+}
 
-	// Stop typing indicator
-	c.sendMessage(WebSocketMessage{
-		Type: "assistant_typing",
-		Data: map[string]interface{}{
-			"typing": false,
+// streamResponse generates the response to message, sending each chunk as a
+// "stream" message holding a unified stream event (text, reasoning,
+// tool_call_delta, usage or finish_reason). It returns the response text,
+// reporting false when generation couldn't start or the client left
+func (c *ChatWebSocketClient) streamResponse(message, model, eventID string) (string, bool) {
+	_, stream, err := c.server.app.ProcessChatMessageWithStream(c.ctx, c.sessionID, message, model)
+	if err != nil {
+		log.Printf("Chat processing error: %v", err)
+		const failed = "I apologize, but I encountered an error processing your message. Please try again."
+		c.server.publishStream(c.sessionID, "error", map[string]string{"error": failed})
+		c.sendError(failed, eventID)
+		return "", false
+	}
+
+	var content strings.Builder
+	for chunk := range stream {
+		if text, ok := chunk.(llm.ApiStreamTextChunk); ok {
+			content.WriteString(text.Text)
+		}
+		event := llm.NewStreamEvent(chunk)
+		c.server.publishStream(c.sessionID, "stream", event)
+		msg := WebSocketMessage{Type: "stream", EventID: eventID, Data: event}
+		// Deltas must not be dropped, so wait for room rather than give up
+		select {
+		case c.send <- msg:
+		case <-c.ctx.Done():
+			// The client left; let the generation wind down for followers
+			go func() {
+				for chunk := range stream {
+					c.server.publishStream(c.sessionID, "stream", llm.NewStreamEvent(chunk))
+				}
+				c.server.importLatestMessages(c.sessionID, 2)
+				if message, ok := c.server.latestAssistantMessage(c.sessionID); ok {
+					c.server.publishStream(c.sessionID, "chat_response", message)
+				}
+			}()
+			return "", false
+		}
+	}
+	return content.String(), true
+}
+
+// markdownResponse builds the assistant message for content, falling back
+// to plain text when markdown processing fails
+func (c *ChatWebSocketClient) markdownResponse(content string, session *ChatSession) *ChatMessage {
+	response, err := c.processResponseWithMarkdown(content, session)
+	if err == nil {
+		return response
+	}
+	log.Printf("Markdown processing error: %v", err)
+	return &ChatMessage{
+		ID:        generateMessageID(),
+		SessionID: c.sessionID,
+		Role:      "assistant",
+		Content:   content,
+		Timestamp: time.Now(),
+		Metadata: map[string]interface{}{
+			"model":    session.Model,
+			"provider": session.Provider,
+			"via":      "websocket",
+			"markdown": false,
 		},
-	})
+	}
 }
 
 // processResponseWithMarkdown processes the AI response with markdown support
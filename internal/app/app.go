@@ -13,7 +13,9 @@ import (
 	"github.com/entrepeneur4lyf/codeforge/internal/config"
 	contextmgmt "github.com/entrepeneur4lyf/codeforge/internal/context"
 	"github.com/entrepeneur4lyf/codeforge/internal/events"
+	"github.com/entrepeneur4lyf/codeforge/internal/fileutil"
 	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+	"github.com/entrepeneur4lyf/codeforge/internal/llm/canary"
 	"github.com/entrepeneur4lyf/codeforge/internal/llm/providers"
 	"github.com/entrepeneur4lyf/codeforge/internal/llm/tools"
 	"github.com/entrepeneur4lyf/codeforge/internal/lsp"
@@ -21,8 +23,11 @@ import (
 	"github.com/entrepeneur4lyf/codeforge/internal/models"
 	"github.com/entrepeneur4lyf/codeforge/internal/notifications"
 	"github.com/entrepeneur4lyf/codeforge/internal/permissions"
+	"github.com/entrepeneur4lyf/codeforge/internal/shellcmd"
 	"github.com/entrepeneur4lyf/codeforge/internal/storage"
+	"github.com/entrepeneur4lyf/codeforge/internal/tokens"
 	"github.com/entrepeneur4lyf/codeforge/internal/vectordb"
+	"github.com/entrepeneur4lyf/codeforge/internal/webhooks"
 	"github.com/google/uuid"
 )
 
@@ -62,6 +67,7 @@ type AppConfig struct {
 	EnablePermissions bool
 	EnableContextMgmt bool
 	Debug             bool
+	Ephemeral         bool // Keep conversations off disk
 }
 
 // NewApp creates a new CodeForge application with all systems initialized
@@ -74,6 +80,12 @@ func NewApp(ctx context.Context, appConfig *AppConfig) (*App, error) {
 		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
 
+	// Ephemeral sessions leave no conversation data on disk
+	if appConfig.Ephemeral {
+		cfg.WireLog.Enabled = false
+		cfg.Canary.Enabled = false
+	}
+
 	// Resolve workspace root
 	workspaceRoot := appConfig.WorkspaceRoot
 	if workspaceRoot == "" {
@@ -98,6 +110,11 @@ func NewApp(ctx context.Context, appConfig *AppConfig) (*App, error) {
 		return nil, fmt.Errorf("failed to initialize event system: %w", err)
 	}
 
+	// Send agent runs, budget alerts and server errors to webhooks
+	if err := webhooks.Configure(cfg); err != nil {
+		return nil, fmt.Errorf("invalid webhook config: %w", err)
+	}
+
 	// Initialize vector database
 	if err := app.initializeVectorDB(appConfig.DatabasePath); err != nil {
 		return nil, fmt.Errorf("failed to initialize vector database: %w", err)
@@ -137,9 +154,11 @@ func NewApp(ctx context.Context, appConfig *AppConfig) (*App, error) {
 		return nil, fmt.Errorf("failed to initialize models system: %w", err)
 	}
 
-	// Initialize chat store
-	if err := app.initializeChatStore(); err != nil {
-		return nil, fmt.Errorf("failed to initialize chat store: %w", err)
+	// Initialize chat store; without one, conversations aren't saved
+	if !appConfig.Ephemeral {
+		if err := app.initializeChatStore(); err != nil {
+			return nil, fmt.Errorf("failed to initialize chat store: %w", err)
+		}
 	}
 
 	// Link managers to context manager for tool context integration
@@ -307,6 +326,23 @@ func (app *App) initializeToolRegistry() error {
 	return nil
 }
 
+// RestrictTools limits the built-in tools offered to models to names, as a
+// session template asks. Nothing changes when names is empty.
+func (app *App) RestrictTools(names []string) error {
+	if len(names) == 0 || app.ToolRegistry == nil {
+		return nil
+	}
+	restricted, err := app.ToolRegistry.Only(names)
+	if err != nil {
+		return err
+	}
+	app.ToolRegistry = restricted
+	if app.ContextManager != nil {
+		app.ContextManager.SetToolRegistry(restricted)
+	}
+	return nil
+}
+
 // initializeChatStore initializes the chat storage system
 func (app *App) initializeChatStore() error {
 	log.Printf("Initializing chat store...")
@@ -364,6 +400,16 @@ func (app *App) SetServer(server interface {
 
 // ProcessChatMessage processes a chat message with full context management and permissions
 func (app *App) ProcessChatMessage(ctx context.Context, sessionID, message, modelID string) (string, error) {
+	response, _, err := app.ProcessChatMessageWithUsage(ctx, sessionID, message, modelID)
+	return response, err
+}
+
+// ProcessChatMessageWithUsage processes a chat message as ProcessChatMessage
+// does, also returning the tokens the response used and what it cost, or
+// nil when that isn't known
+func (app *App) ProcessChatMessageWithUsage(ctx context.Context, sessionID, message, modelID string) (string, *llm.Usage, error) {
+	start := time.Now()
+
 	// Publish chat message received event
 	if app.EventManager != nil {
 		app.EventManager.PublishChat(events.ChatMessageReceived, events.ChatEventPayload{
@@ -396,11 +442,11 @@ func (app *App) ProcessChatMessage(ctx context.Context, sessionID, message, mode
 
 		result, err := app.PermissionService.CheckPermission(ctx, check)
 		if err != nil {
-			return "", fmt.Errorf("permission check failed: %w", err)
+			return "", nil, fmt.Errorf("permission check failed: %w", err)
 		}
 
 		if !result.Allowed {
-			return "", fmt.Errorf("permission denied: %s", result.Reason)
+			return "", nil, fmt.Errorf("permission denied: %s", result.Reason)
 		}
 	}
 
@@ -441,9 +487,10 @@ func (app *App) ProcessChatMessage(ctx context.Context, sessionID, message, mode
 	}
 
 	// Integrate with actual LLM processing using chat module
-	response, err := app.processWithLLM(ctx, contextualMessage, modelID, sessionID)
+	response, usage, err := app.processWithLLM(ctx, contextualMessage, modelID, sessionID)
+	notifyRunFinished(sessionID, modelID, start, usageChunk(usage), err)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
 	// Publish chat message sent event
@@ -463,11 +510,63 @@ func (app *App) ProcessChatMessage(ctx context.Context, sessionID, message, mode
 			notifications.WithSessionID(sessionID))
 	}
 
-	return response, nil
+	return response, usage, nil
+}
+
+// directoryContext returns the context profiles of the directories holding
+// files the conversation's user messages mention
+func (app *App) directoryContext(conversation []contextmgmt.ConversationMessage) string {
+	if app.Config == nil {
+		return ""
+	}
+	root := app.Config.WorkingDir
+	var paths []string
+	for _, msg := range conversation {
+		if msg.Role == "user" {
+			paths = append(paths, contextmgmt.ReferencedPaths(root, msg.Content)...)
+		}
+	}
+	profiles := contextmgmt.DirectoryProfiles(root, contextmgmt.ProfileNames(app.Config.ContextPaths), paths)
+	return contextmgmt.FormatProfiles(profiles)
+}
+
+// usageChunk converts a response's usage for notifyRunFinished
+func usageChunk(usage *llm.Usage) *llm.ApiStreamUsageChunk {
+	if usage == nil {
+		return nil
+	}
+	return &llm.ApiStreamUsageChunk{
+		InputTokens:  usage.PromptTokens,
+		OutputTokens: usage.CompletionTokens,
+		TotalCost:    &usage.TotalCost,
+	}
+}
+
+// notifyRunFinished sends the agent.finished webhook for a chat response
+func notifyRunFinished(sessionID, modelID string, start time.Time, usage *llm.ApiStreamUsageChunk, err error) {
+	duration := time.Since(start)
+	data := map[string]interface{}{
+		"session_id":  sessionID,
+		"model":       modelID,
+		"duration_ms": duration.Milliseconds(),
+	}
+	text := fmt.Sprintf("Chat response in session %s finished in %s with %s", sessionID, duration.Round(100*time.Millisecond), modelID)
+	if err != nil {
+		data["error"] = err.Error()
+		text = fmt.Sprintf("Chat response in session %s failed with %s: %v", sessionID, modelID, err)
+	}
+	if usage != nil {
+		data["input_tokens"] = usage.InputTokens
+		data["output_tokens"] = usage.OutputTokens
+		if usage.TotalCost != nil {
+			data["cost"] = *usage.TotalCost
+		}
+	}
+	webhooks.Notify(webhooks.AgentFinished, text, data)
 }
 
 // processWithLLM processes a message using the LLM chat system
-func (app *App) processWithLLM(ctx context.Context, message, modelID, sessionID string) (string, error) {
+func (app *App) processWithLLM(ctx context.Context, message, modelID, sessionID string) (string, *llm.Usage, error) {
 	// Generate operation ID for progress tracking
 	operationID := fmt.Sprintf("llm_%s_%d", sessionID, time.Now().Unix())
 
@@ -501,30 +600,46 @@ func (app *App) processWithLLM(ctx context.Context, message, modelID, sessionID
 		modelID = chatModule.GetDefaultModel()
 	}
 
+	// Send a share of requests to the candidate of a canary experiment
+	assignment := canary.Default().Route(sessionID, modelID, message)
+	modelID = assignment.Model
+
+	if err := app.ValidateModelRequest(modelID, models.Requirements{InputTokens: tokens.Count(message, modelID).Count}); err != nil {
+		assignment.Done(err)
+		return "", nil, err
+	}
+
 	// Get API key for the model
 	apiKey := chatModule.GetAPIKeyForModel(modelID)
 	if apiKey == "" {
-		return "", fmt.Errorf("no API key found for model: %s", modelID)
+		err := fmt.Errorf("no API key found for model: %s", modelID)
+		assignment.Done(err)
+		return "", nil, err
 	}
 
 	// Create chat session using the actual chat module
 	session, err := chatModule.NewChatSession(modelID, apiKey, "", true, "text")
 	if err != nil {
-		return "", fmt.Errorf("failed to create chat session: %w", err)
+		assignment.Done(err)
+		return "", nil, fmt.Errorf("failed to create chat session: %w", err)
 	}
 
+	// Continue the conversation saved by other clients (CLI, web UI, TUI)
+	session.LoadHistory(app.storedHistory(ctx, sessionID, message))
+
 	// Process the message using the actual LLM
 	response, err := session.ProcessMessage(message)
+	assignment.Done(err)
 	if err != nil {
 		// Fallback to direct LLM completion if chat session fails
 		return app.processWithDirectLLM(ctx, message, modelID, llmModule)
 	}
 
-	return response, nil
+	return response, session.LastUsage(), nil
 }
 
 // processWithDirectLLM processes a message using direct LLM completion
-func (app *App) processWithDirectLLM(ctx context.Context, message, modelID string, llmModule *realLLMModule) (string, error) {
+func (app *App) processWithDirectLLM(ctx context.Context, message, modelID string, llmModule *realLLMModule) (string, *llm.Usage, error) {
 	// Get the default model info
 	defaultModel := llmModule.GetDefaultModel()
 	if modelID == "" {
@@ -538,10 +653,10 @@ func (app *App) processWithDirectLLM(ctx context.Context, message, modelID strin
 	// Get completion
 	resp, err := llmModule.GetCompletion(ctx, completionReq)
 	if err != nil {
-		return "", fmt.Errorf("LLM completion failed: %w", err)
+		return "", nil, fmt.Errorf("LLM completion failed: %w", err)
 	}
 
-	return resp.Content, nil
+	return resp.Content, resp.Usage, nil
 }
 
 // realChatModule implements the actual chat module integration
@@ -573,6 +688,34 @@ func (cs *realChatSession) ProcessMessage(message string) (string, error) {
 	return cs.session.ProcessMessage(message)
 }
 
+func (cs *realChatSession) LoadHistory(messages []storage.Message) {
+	cs.session.LoadHistory(messages)
+}
+
+func (cs *realChatSession) LastUsage() *llm.Usage {
+	return cs.session.LastUsage()
+}
+
+// storedHistoryLimit caps how many saved messages are loaded as history
+const storedHistoryLimit = 50
+
+// storedHistory returns the latest saved messages of a session, leaving out
+// the message being processed when the caller already saved it
+func (app *App) storedHistory(ctx context.Context, sessionID, message string) []storage.Message {
+	if app.ChatStore == nil {
+		return nil
+	}
+
+	messages, err := app.ChatStore.GetLatestMessages(ctx, sessionID, storedHistoryLimit)
+	if err != nil || len(messages) == 0 {
+		return nil
+	}
+	if last := messages[len(messages)-1]; last.Role == "user" && last.Content == message {
+		messages = messages[:len(messages)-1]
+	}
+	return messages
+}
+
 // realLLMModule implements the actual LLM module integration
 type realLLMModule struct{}
 
@@ -616,6 +759,24 @@ func (app *App) initializeEventSystem() error {
 		return fmt.Errorf("failed to start event manager: %w", err)
 	}
 
+	// Audit file writes made by tools
+	eventManager := app.EventManager
+	fileutil.SetWriteObserver(func(e fileutil.WriteEvent) {
+		eventType := events.FileUpdated
+		switch e.Operation {
+		case "create":
+			eventType = events.FileCreated
+		case "delete":
+			eventType = events.FileDeleted
+		}
+		eventManager.PublishFile(eventType, events.FileEventPayload{
+			Path:      e.Path,
+			Operation: e.Operation,
+			Size:      e.Size,
+			Metadata:  map[string]interface{}{"mode": e.Mode.String()},
+		})
+	})
+
 	// Create notification manager
 	app.NotificationManager = notifications.NewManager(app.EventManager)
 
@@ -641,6 +802,7 @@ func (app *App) Close() error {
 
 	// Close event manager
 	if app.EventManager != nil {
+		fileutil.SetWriteObserver(nil)
 		app.EventManager.Shutdown()
 	}
 
@@ -808,13 +970,21 @@ func (app *App) SetCurrentModel(provider, model string) error {
 
 // GetCurrentModel returns the currently selected model
 func (app *App) GetCurrentModel() (provider, model string) {
-	// Return default model for now
+	// A default set in config (usually per project) wins
+	if cfg := config.Get(); cfg != nil && cfg.Model != "" {
+		if cfg.Provider != "" {
+			return cfg.Provider, cfg.Model
+		}
+		return splitModelID(cfg.Model)
+	}
+
 	defaultModel := llm.GetDefaultModel()
 	return defaultModel.Provider, defaultModel.Name
 }
 
-// ProcessChatMessageWithStream processes a chat message with context management and returns a stream channel
-func (app *App) ProcessChatMessageWithStream(ctx context.Context, sessionID, message, modelID string) (*contextmgmt.ProcessedContext, <-chan string, error) {
+// ProcessChatMessageWithStream processes a chat message with context management and returns the
+// provider's stream of chunks; errors arrive as text chunks
+func (app *App) ProcessChatMessageWithStream(ctx context.Context, sessionID, message, modelID string) (*contextmgmt.ProcessedContext, <-chan llm.ApiStreamChunk, error) {
 	// Publish chat message received event
 	if app.EventManager != nil {
 		app.EventManager.PublishChat(events.ChatMessageReceived, events.ChatEventPayload{
@@ -855,6 +1025,10 @@ func (app *App) ProcessChatMessageWithStream(ctx context.Context, sessionID, mes
 		}
 	}
 
+	// Send a share of requests to the candidate of a canary experiment
+	assignment := canary.Default().Route(sessionID, modelID, message)
+	modelID = assignment.Model
+
 	// Ensure session exists and save user message to database
 	if app.ChatStore != nil {
 		// Check if session exists, create if not
@@ -954,8 +1128,18 @@ func (app *App) ProcessChatMessageWithStream(ctx context.Context, sessionID, mes
 		}
 	}
 
+	// Reject requests the model can't serve before anything is sent
+	req := models.Requirements{Streaming: true, InputTokens: tokens.Count(message, modelID).Count}
+	if processedCtx != nil {
+		req.InputTokens = processedCtx.FinalTokens
+	}
+	if err := app.ValidateModelRequest(modelID, req); err != nil {
+		assignment.Done(err)
+		return processedCtx, nil, err
+	}
+
 	// Create stream channel for response
-	streamChan := make(chan string, 100)
+	streamChan := make(chan llm.ApiStreamChunk, 100)
 	
 	// Process with LLM in background
 	go func() {
@@ -964,7 +1148,8 @@ func (app *App) ProcessChatMessageWithStream(ctx context.Context, sessionID, mes
 		// Get LLM handler
 		handler := app.GetLLMHandler(modelID)
 		if handler == nil {
-			streamChan <- fmt.Sprintf("Error: Failed to get handler for model %s", modelID)
+			assignment.Done(fmt.Errorf("no handler for model %s", modelID))
+			streamChan <- llm.ApiStreamTextChunk{Text: fmt.Sprintf("Error: Failed to get handler for model %s", modelID)}
 			return
 		}
 
@@ -992,26 +1177,42 @@ func (app *App) ProcessChatMessageWithStream(ctx context.Context, sessionID, mes
 		
 		// If no system prompt found, use default
 		if systemPrompt == "" {
-			systemPrompt = "You are CodeForge, an AI coding assistant."
+			systemPrompt = "You are CodeForge, an AI coding assistant.\n\n" + shellcmd.Instructions
+		}
+		if profiles := app.directoryContext(fullContext); profiles != "" {
+			systemPrompt += "\n\n" + profiles
 		}
 
 		// Stream response from LLM
+		start := time.Now()
 		stream, err := handler.CreateMessage(ctx, systemPrompt, messages)
 		if err != nil {
-			streamChan <- fmt.Sprintf("Error: %v", err)
+			assignment.Done(err)
+			notifyRunFinished(sessionID, modelID, start, nil, err)
+			streamChan <- llm.ApiStreamTextChunk{Text: fmt.Sprintf("Error: %v", err)}
 			return
 		}
 
 		// Forward chunks to stream channel
 		fullResponse := ""
+		var usage *llm.ApiStreamUsageChunk
+		var toolCalls llm.ToolCallParser
 		for chunk := range stream {
-			if textChunk, ok := chunk.(llm.ApiStreamTextChunk); ok {
-				if textChunk.Text != "" {
-					streamChan <- textChunk.Text
-					fullResponse += textChunk.Text
+			switch c := chunk.(type) {
+			case llm.ApiStreamTextChunk:
+				if c.Text == "" {
+					continue
 				}
+				fullResponse += c.Text
+			case llm.ApiStreamUsageChunk:
+				usage = &c
+			case llm.ApiStreamToolCallDeltaChunk:
+				toolCalls.Add(c)
 			}
+			streamChan <- chunk
 		}
+		assignment.Done(ctx.Err())
+		notifyRunFinished(sessionID, modelID, start, usage, ctx.Err())
 
 		// Save assistant message to database
 		if app.ChatStore != nil && fullResponse != "" {
@@ -1025,6 +1226,21 @@ func (app *App) ProcessChatMessageWithStream(ctx context.Context, sessionID, mes
 				CreatedAt: time.Now(),
 				Metadata:  map[string]interface{}{"model": modelID},
 			}
+			if usage != nil {
+				assistantMsg.Tokens = usage.InputTokens + usage.OutputTokens
+				assistantMsg.Metadata["input_tokens"] = usage.InputTokens
+				assistantMsg.Metadata["output_tokens"] = usage.OutputTokens
+				if cost := usage.Cost(handler.GetModel().Info); cost > 0 {
+					assistantMsg.Metadata["cost"] = cost
+				}
+			}
+			var calls []llm.StreamToolCall
+			for _, call := range toolCalls.Calls() {
+				calls = append(calls, call.ToolCall())
+			}
+			if len(calls) > 0 {
+				assistantMsg.Metadata["tool_calls"] = calls
+			}
 
 			if err := app.ChatStore.SaveMessage(ctx, assistantMsg); err != nil {
 				log.Printf("Warning: Failed to save assistant message: %v", err)
@@ -1086,28 +1302,20 @@ func (app *App) ProcessChatMessageWithStream(ctx context.Context, sessionID, mes
 
 // GetLLMHandler returns an LLM handler for the specified model
 func (app *App) GetLLMHandler(modelID string) llm.ApiHandler {
-	// Parse provider from model ID
-	provider := ""
-	if strings.Contains(modelID, "/") {
-		parts := strings.Split(modelID, "/")
-		if len(parts) >= 2 {
-			provider = parts[0]
-			modelID = strings.Join(parts[1:], "/")
-		}
-	} else {
-		// Detect provider from model ID
-		if strings.HasPrefix(modelID, "claude-") {
-			provider = "anthropic"
-		} else if strings.HasPrefix(modelID, "gpt-") || strings.HasPrefix(modelID, "o1-") {
-			provider = "openai"
-		} else if strings.HasPrefix(modelID, "gemini-") {
-			provider = "gemini"
-		}
-	}
-	
+	provider, modelID := splitModelID(config.ResolveModel(modelID))
+
 	// Get API key for provider
 	apiKey := ""
 	switch provider {
+	case "ollama", "lmstudio", "jan":
+		// Local providers don't need a key
+		apiKey = "local"
+		modelID = provider + "/" + modelID
+	case "mock":
+		apiKey = "local"
+		if modelID != "mock" {
+			modelID = "mock/" + modelID
+		}
 	case "anthropic":
 		apiKey = os.Getenv("ANTHROPIC_API_KEY")
 	case "openai":
@@ -1117,6 +1325,13 @@ func (app *App) GetLLMHandler(modelID string) llm.ApiHandler {
 	case "openrouter":
 		apiKey = os.Getenv("OPENROUTER_API_KEY")
 	default:
+		// Other local servers, as llama.cpp or those added in the config,
+		// need no key here; the handler sends one if the config sets it
+		if spec, ok := config.LookupProvider(provider); ok && spec.Local {
+			apiKey = "local"
+			modelID = provider + "/" + modelID
+			break
+		}
 		// Try to find any available key
 		if key := os.Getenv("OPENROUTER_API_KEY"); key != "" {
 			provider = "openrouter"
@@ -1144,6 +1359,37 @@ func (app *App) GetLLMHandler(modelID string) llm.ApiHandler {
 	return handler
 }
 
+// splitModelID separates a "provider/model" ID, detecting the provider from
+// well-known model prefixes when it isn't given
+func splitModelID(modelID string) (provider, model string) {
+	if before, after, found := strings.Cut(modelID, "/"); found {
+		return before, after
+	}
+
+	switch {
+	case strings.HasPrefix(modelID, "claude-"):
+		provider = "anthropic"
+	case strings.HasPrefix(modelID, "gpt-") || strings.HasPrefix(modelID, "o1-"):
+		provider = "openai"
+	case strings.HasPrefix(modelID, "gemini-"):
+		provider = "gemini"
+	case modelID == "mock":
+		provider = "mock"
+	}
+	return provider, modelID
+}
+
+// ValidateModelRequest checks a request against the capability registry
+// before it is sent, so unsupported features fail with a clear
+// *models.CapabilityError instead of a provider error
+func (app *App) ValidateModelRequest(modelID string, req models.Requirements) error {
+	if app.ModelRegistry == nil {
+		return nil
+	}
+	provider, model := splitModelID(config.ResolveModel(modelID))
+	return app.ModelRegistry.ValidateRequest(models.ProviderID(provider), model, req)
+}
+
 // Session Management Methods
 
 // GetChatSessions returns a list of chat sessions for the user
@@ -0,0 +1,256 @@
+// Package atrest encrypts values stored in CodeForge's databases with
+// envelope encryption: each database has a random data key, kept next to it
+// wrapped by a master key from the environment or the OS keychain.
+package atrest
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+	"github.com/entrepeneur4lyf/codeforge/internal/platform"
+	"golang.org/x/crypto/scrypt"
+)
+
+// EnvKey is the environment variable holding the master key
+const EnvKey = "CODEFORGE_ENCRYPTION_KEY"
+
+// Keychain entry holding the master key
+const (
+	KeychainService = "codeforge"
+	KeychainAccount = "encryption-key"
+)
+
+// prefix marks encrypted values, so values written before encryption was
+// enabled are still read as plain text
+const prefix = "enc:v1:"
+
+var (
+	// ErrNoKey is returned when encryption is enabled but no master key is set
+	ErrNoKey = fmt.Errorf("encryption is enabled but no key was found; set %s or run codeforge encryption init", EnvKey)
+	// ErrWrongKey is returned when the master key doesn't unwrap a data key
+	ErrWrongKey = errors.New("the encryption key doesn't match the one the database was encrypted with")
+	// ErrLocked is returned when reading an encrypted value without a key
+	ErrLocked = errors.New("value is encrypted and encryption isn't enabled")
+)
+
+// Cipher encrypts and decrypts the values of one database. A nil Cipher
+// leaves values unencrypted.
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// keyFile is the wrapped data key stored next to a database
+type keyFile struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Salt       string `json:"salt"`
+	WrappedKey string `json:"wrappedKey"`
+}
+
+// KeyPath returns the file holding the wrapped data key of a database
+func KeyPath(dbPath string) string {
+	return dbPath + ".key"
+}
+
+// ForDatabase returns the cipher for the database at dbPath, or nil when
+// encryption isn't enabled
+func ForDatabase(dbPath string) (*Cipher, error) {
+	cfg := config.Get()
+	if cfg == nil || !cfg.Encryption.Enabled {
+		return nil, nil
+	}
+
+	secret, err := MasterKey(context.Background(), cfg.Encryption.KeySource)
+	if err != nil {
+		return nil, err
+	}
+	return Open(KeyPath(dbPath), secret)
+}
+
+// MasterKey reads the master key from source: "env", "keychain", or "auto"
+// for the environment and then the keychain
+func MasterKey(ctx context.Context, source string) (string, error) {
+	if source != "keychain" {
+		if key := strings.TrimSpace(os.Getenv(EnvKey)); key != "" {
+			return key, nil
+		}
+		if source == "env" {
+			return "", ErrNoKey
+		}
+	}
+
+	key, err := platform.KeychainGet(ctx, KeychainService, KeychainAccount)
+	if err != nil {
+		return "", ErrNoKey
+	}
+	return key, nil
+}
+
+// GenerateKey returns a random master key
+func GenerateKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("failed to generate key: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// Open unwraps the data key in keyPath with the master key, creating the
+// data key when the file doesn't exist yet
+func Open(keyPath, secret string) (*Cipher, error) {
+	data, err := os.ReadFile(keyPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return create(keyPath, secret)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read encryption key file: %w", err)
+	}
+
+	var kf keyFile
+	if err := json.Unmarshal(data, &kf); err != nil {
+		return nil, fmt.Errorf("invalid encryption key file %s: %w", keyPath, err)
+	}
+	salt, err := base64.StdEncoding.DecodeString(kf.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key file %s: %w", keyPath, err)
+	}
+	wrapped, err := base64.StdEncoding.DecodeString(kf.WrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key file %s: %w", keyPath, err)
+	}
+
+	kek, err := deriveKey(secret, salt)
+	if err != nil {
+		return nil, err
+	}
+	dataKey, err := kek.open(wrapped)
+	if err != nil {
+		return nil, ErrWrongKey
+	}
+	return newCipher(dataKey)
+}
+
+// create generates a data key and writes it wrapped to keyPath
+func create(keyPath, secret string) (*Cipher, error) {
+	salt := make([]byte, 16)
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	kek, err := deriveKey(secret, salt)
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := kek.seal(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.MarshalIndent(keyFile{
+		Version:    1,
+		KDF:        "scrypt",
+		Salt:       base64.StdEncoding.EncodeToString(salt),
+		WrappedKey: base64.StdEncoding.EncodeToString(wrapped),
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(keyPath), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create key directory: %w", err)
+	}
+	if err := os.WriteFile(keyPath, data, 0o600); err != nil {
+		return nil, fmt.Errorf("failed to write encryption key file: %w", err)
+	}
+	return newCipher(dataKey)
+}
+
+// deriveKey stretches the master key, which may be a passphrase, into the
+// key that wraps data keys
+func deriveKey(secret string, salt []byte) (*Cipher, error) {
+	key, err := scrypt.Key([]byte(secret), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	return newCipher(key)
+}
+
+func newCipher(key []byte) (*Cipher, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Cipher{aead: aead}, nil
+}
+
+// seal encrypts with a random nonce prepended to the ciphertext
+func (c *Cipher) seal(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return c.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func (c *Cipher) open(sealed []byte) ([]byte, error) {
+	size := c.aead.NonceSize()
+	if len(sealed) < size {
+		return nil, errors.New("ciphertext too short")
+	}
+	return c.aead.Open(nil, sealed[:size], sealed[size:], nil)
+}
+
+// Encrypt returns value encrypted for storage, or value itself when c is nil
+func (c *Cipher) Encrypt(value string) (string, error) {
+	if c == nil {
+		return value, nil
+	}
+	sealed, err := c.seal([]byte(value))
+	if err != nil {
+		return "", err
+	}
+	return prefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt returns the plain text of a stored value. Values that weren't
+// encrypted are returned unchanged.
+func (c *Cipher) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	if c == nil {
+		return "", ErrLocked
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, prefix))
+	if err != nil {
+		return "", fmt.Errorf("invalid encrypted value: %w", err)
+	}
+	plaintext, err := c.open(sealed)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// IsEncrypted reports whether a stored value was encrypted
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
@@ -0,0 +1,58 @@
+package atrest
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestCipherRoundTrip(t *testing.T) {
+	keyPath := filepath.Join(t.TempDir(), "chat.db.key")
+	c, err := Open(keyPath, "correct horse")
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	sealed, err := c.Encrypt("func main() {}")
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	if !IsEncrypted(sealed) {
+		t.Fatalf("Encrypt() = %q, want an encrypted value", sealed)
+	}
+
+	// Reopening with the same master key unwraps the same data key
+	reopened, err := Open(keyPath, "correct horse")
+	if err != nil {
+		t.Fatalf("Open() again error = %v", err)
+	}
+	if got, err := reopened.Decrypt(sealed); err != nil || got != "func main() {}" {
+		t.Errorf("Decrypt() = %q, %v; want the original value", got, err)
+	}
+
+	if _, err := Open(keyPath, "wrong"); !errors.Is(err, ErrWrongKey) {
+		t.Errorf("Open() with the wrong key error = %v, want ErrWrongKey", err)
+	}
+}
+
+func TestPlaintextValues(t *testing.T) {
+	c, err := Open(filepath.Join(t.TempDir(), "vectors.db.key"), "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Values written before encryption was enabled read back unchanged
+	if got, err := c.Decrypt("hello"); err != nil || got != "hello" {
+		t.Errorf("Decrypt(plaintext) = %q, %v", got, err)
+	}
+
+	// A nil cipher writes plaintext and can't read encrypted values
+	var none *Cipher
+	if got, _ := none.Encrypt("hello"); got != "hello" {
+		t.Errorf("nil Encrypt() = %q, want plaintext", got)
+	}
+	sealed, _ := c.Encrypt("hello")
+	if _, err := none.Decrypt(sealed); !errors.Is(err, ErrLocked) {
+		t.Errorf("nil Decrypt() error = %v, want ErrLocked", err)
+	}
+}
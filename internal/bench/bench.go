@@ -0,0 +1,281 @@
+// Package bench measures indexing and search performance on a repository
+package bench
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/chunking"
+	"github.com/entrepeneur4lyf/codeforge/internal/embeddings"
+	"github.com/entrepeneur4lyf/codeforge/internal/fileutil"
+	"github.com/entrepeneur4lyf/codeforge/internal/ignore"
+	"github.com/entrepeneur4lyf/codeforge/internal/indexer"
+	"github.com/entrepeneur4lyf/codeforge/internal/vectordb"
+)
+
+// Latency summarises a set of timings
+type Latency struct {
+	Count int           `json:"count"`
+	Mean  time.Duration `json:"mean"`
+	P50   time.Duration `json:"p50"`
+	P95   time.Duration `json:"p95"`
+	P99   time.Duration `json:"p99"`
+	Max   time.Duration `json:"max"`
+}
+
+// NewLatency summarises timings
+func NewLatency(timings []time.Duration) Latency {
+	if len(timings) == 0 {
+		return Latency{}
+	}
+	sorted := append([]time.Duration(nil), timings...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, t := range sorted {
+		total += t
+	}
+	return Latency{
+		Count: len(sorted),
+		Mean:  total / time.Duration(len(sorted)),
+		P50:   percentile(sorted, 50),
+		P95:   percentile(sorted, 95),
+		P99:   percentile(sorted, 99),
+		Max:   sorted[len(sorted)-1],
+	}
+}
+
+// percentile returns the nearest-rank percentile of sorted timings
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func (l Latency) String() string {
+	return fmt.Sprintf("p50 %s  p95 %s  p99 %s  max %s  (n=%d)",
+		round(l.P50), round(l.P95), round(l.P99), round(l.Max), l.Count)
+}
+
+// IndexReport measures chunking and embedding a repository
+type IndexReport struct {
+	Root          string        `json:"root"`
+	Files         int           `json:"files"`
+	Bytes         int64         `json:"bytes"`
+	Chunks        int           `json:"chunks"`
+	ChunkTime     time.Duration `json:"chunk_time"`
+	FilesPerSec   float64       `json:"files_per_sec"`
+	ChunksPerSec  float64       `json:"chunks_per_sec"`
+	MBPerSec      float64       `json:"mb_per_sec"`
+	Embedded      int           `json:"embedded"` // Chunks embedded, a sample when there are many
+	EmbedTime     time.Duration `json:"embed_time"`
+	EmbedsPerSec  float64       `json:"embeds_per_sec"`
+	EmbedLatency  Latency       `json:"embed_latency"`
+	EmbedFailures int           `json:"embed_failures"`
+	Provider      string        `json:"provider"`
+	Dimensions    int           `json:"dimensions"`
+}
+
+// Index chunks every source file under root and embeds up to maxEmbeds of
+// the chunks, without storing anything
+func Index(ctx context.Context, root string, maxEmbeds int) (*IndexReport, error) {
+	report := &IndexReport{Root: root}
+	chunker := chunking.NewCodeChunker(chunking.DefaultConfig())
+	matcher := ignore.Default()
+
+	var chunks []*vectordb.CodeChunk
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, path)
+		if rel != "." && matcher.Match(rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		language, ok := indexer.Language(path)
+		if d.IsDir() || !ok {
+			return nil
+		}
+
+		file, err := fileutil.ReadText(path, fileutil.MaxFileSize())
+		if err != nil {
+			return nil
+		}
+
+		start := time.Now()
+		fileChunks, err := chunker.ChunkFile(ctx, filepath.ToSlash(rel), file.Content, language)
+		report.ChunkTime += time.Since(start)
+		if err != nil {
+			return nil
+		}
+
+		report.Files++
+		report.Bytes += int64(len(file.Content))
+		chunks = append(chunks, fileChunks...)
+		return ctx.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	report.Chunks = len(chunks)
+	if secs := report.ChunkTime.Seconds(); secs > 0 {
+		report.FilesPerSec = float64(report.Files) / secs
+		report.ChunksPerSec = float64(report.Chunks) / secs
+		report.MBPerSec = float64(report.Bytes) / (1 << 20) / secs
+	}
+
+	report.Provider, report.Dimensions, _ = embeddings.GetCurrentProvider()
+	var timings []time.Duration
+	for _, chunk := range sample(chunks, maxEmbeds) {
+		start := time.Now()
+		_, err := embeddings.GetCodeEmbedding(ctx, chunk.Content, chunk.Language)
+		elapsed := time.Since(start)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err != nil {
+			report.EmbedFailures++
+			continue
+		}
+		report.EmbedTime += elapsed
+		timings = append(timings, elapsed)
+	}
+	report.Embedded = len(timings)
+	report.EmbedLatency = NewLatency(timings)
+	if secs := report.EmbedTime.Seconds(); secs > 0 {
+		report.EmbedsPerSec = float64(report.Embedded) / secs
+	}
+	return report, nil
+}
+
+// sample returns up to n chunks spread evenly over chunks
+func sample(chunks []*vectordb.CodeChunk, n int) []*vectordb.CodeChunk {
+	if n <= 0 || len(chunks) <= n {
+		return chunks
+	}
+	sampled := make([]*vectordb.CodeChunk, n)
+	for i := range sampled {
+		sampled[i] = chunks[i*len(chunks)/n]
+	}
+	return sampled
+}
+
+// Write prints the report
+func (r *IndexReport) Write(w io.Writer) {
+	fmt.Fprintf(w, "Index benchmark: %s\n\n", r.Root)
+	fmt.Fprintf(w, "Chunking\n")
+	fmt.Fprintf(w, "  files        %d (%.1f MB)\n", r.Files, float64(r.Bytes)/(1<<20))
+	fmt.Fprintf(w, "  chunks       %d\n", r.Chunks)
+	fmt.Fprintf(w, "  time         %s\n", round(r.ChunkTime))
+	fmt.Fprintf(w, "  throughput   %.0f files/s, %.0f chunks/s, %.2f MB/s\n\n", r.FilesPerSec, r.ChunksPerSec, r.MBPerSec)
+	fmt.Fprintf(w, "Embedding (%s, %d dimensions)\n", r.Provider, r.Dimensions)
+	fmt.Fprintf(w, "  embedded     %d of %d chunks", r.Embedded, r.Chunks)
+	if r.EmbedFailures > 0 {
+		fmt.Fprintf(w, ", %d failed", r.EmbedFailures)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "  rate         %.1f embeddings/s\n", r.EmbedsPerSec)
+	fmt.Fprintf(w, "  latency      %s\n", r.EmbedLatency)
+}
+
+// SearchReport measures query embedding and vector search latency
+type SearchReport struct {
+	Chunks         int     `json:"chunks"` // Chunks in the index
+	Queries        int     `json:"queries"`
+	Results        int     `json:"results"` // Results requested per search
+	EmbedLatency   Latency `json:"embed_latency"`
+	SearchLatency  Latency `json:"search_latency"`
+	TotalLatency   Latency `json:"total_latency"`
+	SearchFailures int     `json:"search_failures"`
+}
+
+// Search runs each query iterations times against the index, timing query
+// embedding and the vector search separately
+func Search(ctx context.Context, vdb *vectordb.VectorDB, queries []string, iterations, results int) (*SearchReport, error) {
+	stats, err := vdb.GetStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if stats.TotalChunks == 0 {
+		return nil, fmt.Errorf("the index is empty; index the repository before benchmarking search")
+	}
+	if iterations <= 0 {
+		iterations = 1
+	}
+
+	report := &SearchReport{Chunks: stats.TotalChunks, Results: results}
+	var embedTimes, searchTimes, totalTimes []time.Duration
+	for i := 0; i < iterations; i++ {
+		for _, query := range queries {
+			start := time.Now()
+			embedding, err := embeddings.GetQueryEmbedding(ctx, query)
+			embedded := time.Now()
+			if err == nil {
+				_, err = vdb.SearchSimilarChunks(ctx, embedding, results, nil)
+			}
+			done := time.Now()
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			if err != nil {
+				report.SearchFailures++
+				continue
+			}
+
+			report.Queries++
+			embedTimes = append(embedTimes, embedded.Sub(start))
+			searchTimes = append(searchTimes, done.Sub(embedded))
+			totalTimes = append(totalTimes, done.Sub(start))
+		}
+	}
+
+	report.EmbedLatency = NewLatency(embedTimes)
+	report.SearchLatency = NewLatency(searchTimes)
+	report.TotalLatency = NewLatency(totalTimes)
+	return report, nil
+}
+
+// Write prints the report
+func (r *SearchReport) Write(w io.Writer) {
+	fmt.Fprintf(w, "Search benchmark: %d queries over %d chunks, top %d\n\n", r.Queries, r.Chunks, r.Results)
+	fmt.Fprintf(w, "  query embedding  %s\n", r.EmbedLatency)
+	fmt.Fprintf(w, "  vector search    %s\n", r.SearchLatency)
+	fmt.Fprintf(w, "  total            %s\n", r.TotalLatency)
+	if r.SearchFailures > 0 {
+		fmt.Fprintf(w, "  failures         %d\n", r.SearchFailures)
+	}
+}
+
+// DefaultQueries are searched when none are given
+var DefaultQueries = []string{
+	"error handling",
+	"parse configuration file",
+	"http request handler",
+	"database connection",
+	"read file contents",
+	"unit test setup",
+	"authentication token",
+	"cache invalidation",
+}
+
+// round shortens durations for display
+func round(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Second:
+		return d.Round(10 * time.Millisecond)
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond)
+	default:
+		return d.Round(time.Microsecond / 10)
+	}
+}
@@ -0,0 +1,56 @@
+package bench
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewLatency(t *testing.T) {
+	var timings []time.Duration
+	for i := 100; i >= 1; i-- {
+		timings = append(timings, time.Duration(i)*time.Millisecond)
+	}
+	l := NewLatency(timings)
+	if l.Count != 100 || l.P50 != 50*time.Millisecond || l.P95 != 95*time.Millisecond ||
+		l.P99 != 99*time.Millisecond || l.Max != 100*time.Millisecond {
+		t.Errorf("NewLatency() = %+v", l)
+	}
+	if timings[0] != 100*time.Millisecond {
+		t.Error("NewLatency() reordered its input")
+	}
+	if l := NewLatency(nil); l.Count != 0 {
+		t.Errorf("NewLatency(nil) = %+v, want zero", l)
+	}
+}
+
+func TestIndex_Chunking(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"main.go":             "package main\n\nfunc main() {\n\tprintln(\"hi\")\n}\n\nfunc helper() int {\n\treturn 1\n}\n",
+		"README.md":           "# Not code\n",
+		"node_modules/dep.js": "function skipped() {}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report, err := Index(context.Background(), root, 1)
+	if err != nil {
+		t.Fatalf("Index() error: %v", err)
+	}
+	if report.Files != 1 || report.Chunks == 0 {
+		t.Errorf("Index() chunked %d files into %d chunks, want only main.go", report.Files, report.Chunks)
+	}
+	if report.Embedded+report.EmbedFailures != 1 {
+		t.Errorf("Index() embedded %d and failed %d, want one chunk sampled", report.Embedded, report.EmbedFailures)
+	}
+}
@@ -1,16 +1,30 @@
 package builder
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"regexp"
 	"strings"
 
+	"github.com/entrepeneur4lyf/codeforge/internal/platform"
 	"github.com/sergi/go-diff/diffmatchpatch"
 )
 
+// runner executes build tools. Tests swap in a fake to check the commands
+// issued without the toolchains installed.
+var runner platform.Runner = platform.ExecRunner{}
+
+// run executes argv in projectPath. Program paths such as "./main" are
+// resolved for the host platform by the runner.
+func run(projectPath string, argv ...string) ([]byte, error) {
+	if len(argv) == 0 {
+		return nil, fmt.Errorf("no command configured")
+	}
+	return runner.Run(context.Background(), projectPath, argv[0], argv[1:]...)
+}
+
 // Language represents a supported programming language
 type Language struct {
 	Name           string
@@ -172,11 +186,42 @@ func Build(projectPath string) ([]byte, error) {
 	return BuildWithLanguage(projectPath, lang)
 }
 
+// BuildIn builds a project with command, a shell command line, or the
+// detected language's build command when it's empty. env is added to the
+// environment of the build, as a session's own variables are.
+func BuildIn(projectPath, command string, env []string) ([]byte, error) {
+	r := runner
+	if exec, ok := r.(platform.ExecRunner); ok && len(env) > 0 {
+		exec.Env = env
+		r = exec
+	}
+	if command != "" {
+		return platform.RunShell(context.Background(), r, projectPath, command)
+	}
+
+	lang, err := detectProjectLanguage(projectPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect project language: %w", err)
+	}
+	if len(lang.BuildCommand) == 0 {
+		return nil, fmt.Errorf("no command configured")
+	}
+	return r.Run(context.Background(), projectPath, lang.BuildCommand[0], lang.BuildCommand[1:]...)
+}
+
+// Commands returns the build and test command lines of the project's
+// detected language; either is empty when the language has none
+func Commands(projectPath string) (build, test string, err error) {
+	lang, err := detectProjectLanguage(projectPath)
+	if err != nil {
+		return "", "", err
+	}
+	return strings.Join(lang.BuildCommand, " "), strings.Join(lang.TestCommand, " "), nil
+}
+
 // BuildWithLanguage builds a project with a specific language
 func BuildWithLanguage(projectPath string, lang Language) ([]byte, error) {
-	cmd := exec.Command(lang.BuildCommand[0], lang.BuildCommand[1:]...)
-	cmd.Dir = projectPath
-	return cmd.CombinedOutput()
+	return run(projectPath, lang.BuildCommand...)
 }
 
 // BuildGo maintains backward compatibility
@@ -199,32 +244,24 @@ func BuildC(projectPath string) ([]byte, error) {
 // TestPHP runs PHP tests
 func TestPHP(projectPath string) ([]byte, error) {
 	lang := SupportedLanguages["php"]
-	cmd := exec.Command(lang.TestCommand[0], lang.TestCommand[1:]...)
-	cmd.Dir = projectPath
-	return cmd.CombinedOutput()
+	return run(projectPath, lang.TestCommand...)
 }
 
 // TestC runs C tests
 func TestC(projectPath string) ([]byte, error) {
 	lang := SupportedLanguages["c"]
-	cmd := exec.Command(lang.TestCommand[0], lang.TestCommand[1:]...)
-	cmd.Dir = projectPath
-	return cmd.CombinedOutput()
+	return run(projectPath, lang.TestCommand...)
 }
 
 // RunPHP executes a PHP file
 func RunPHP(projectPath string, fileName string) ([]byte, error) {
 	lang := SupportedLanguages["php"]
-	cmd := exec.Command(lang.RunCommand[0], fileName)
-	cmd.Dir = projectPath
-	return cmd.CombinedOutput()
+	return run(projectPath, lang.RunCommand[0], fileName)
 }
 
 // RunC executes a compiled C program
 func RunC(projectPath string, executableName string) ([]byte, error) {
-	cmd := exec.Command("./" + executableName)
-	cmd.Dir = projectPath
-	return cmd.CombinedOutput()
+	return run(projectPath, "./"+executableName)
 }
 
 func ApplyFix(filePath string, content string) error {
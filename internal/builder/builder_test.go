@@ -0,0 +1,81 @@
+package builder
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+type fakeRunner struct {
+	dir  string
+	argv []string
+}
+
+func (f *fakeRunner) Run(_ context.Context, dir, name string, args ...string) ([]byte, error) {
+	f.dir = dir
+	f.argv = append([]string{name}, args...)
+	return []byte("ok"), nil
+}
+
+func useFakeRunner(t *testing.T) *fakeRunner {
+	fake := &fakeRunner{}
+	previous := runner
+	runner = fake
+	t.Cleanup(func() { runner = previous })
+	return fake
+}
+
+func TestBuildUsesDetectedLanguage(t *testing.T) {
+	fake := useFakeRunner(t)
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "Cargo.toml"), []byte("[package]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Build(dir); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if fake.dir != dir {
+		t.Errorf("Expected build in %s, got %s", dir, fake.dir)
+	}
+	if want := []string{"cargo", "build"}; !reflect.DeepEqual(fake.argv, want) {
+		t.Errorf("Expected %v, got %v", want, fake.argv)
+	}
+}
+
+func TestBuildInUsesCommand(t *testing.T) {
+	fake := useFakeRunner(t)
+	dir := t.TempDir()
+
+	if _, err := BuildIn(dir, "make release", nil); err != nil {
+		t.Fatalf("BuildIn failed: %v", err)
+	}
+	if fake.dir != dir || fake.argv[len(fake.argv)-1] != "make release" {
+		t.Errorf("ran %v in %s, want the command through the shell in %s", fake.argv, fake.dir, dir)
+	}
+
+	// Without a command the language is detected
+	if _, err := BuildIn(dir, "", nil); err == nil {
+		t.Error("BuildIn built a directory with no project in it")
+	}
+}
+
+func TestRunCUsesRelativeExecutable(t *testing.T) {
+	fake := useFakeRunner(t)
+
+	if _, err := RunC("project", "main"); err != nil {
+		t.Fatalf("RunC failed: %v", err)
+	}
+	if want := []string{"./main"}; !reflect.DeepEqual(fake.argv, want) {
+		t.Errorf("Expected %v, got %v", want, fake.argv)
+	}
+}
+
+func TestParseErrorWindowsPath(t *testing.T) {
+	file, line := ParseError(`C:\src\app\main.go:12:3: undefined: foo`)
+	if file != `C:\src\app\main.go` || line != "12" {
+		t.Errorf("Expected C:\\src\\app\\main.go:12, got %s:%s", file, line)
+	}
+}
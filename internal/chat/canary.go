@@ -0,0 +1,69 @@
+package chat
+
+import (
+	"fmt"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+	"github.com/entrepeneur4lyf/codeforge/internal/llm/canary"
+	"github.com/google/uuid"
+)
+
+// routeCanary switches the session to the model a running canary experiment
+// picks for the next message. finishCanary switches it back.
+func (cs *ChatSession) routeCanary(input string) *canary.Assignment {
+	router := canary.Default()
+	if router == nil {
+		return nil
+	}
+	if cs.canaryID == "" {
+		cs.canaryID = cs.StoreSessionID()
+		if cs.canaryID == "" {
+			cs.canaryID = uuid.New().String()
+		}
+		cs.canaryHandlers = make(map[string]llm.ApiHandler)
+	}
+
+	a := router.Route(cs.canaryID, cs.model, input)
+	if a.Model == cs.model {
+		return a
+	}
+
+	handler, ok := cs.canaryHandlers[a.Model]
+	if !ok {
+		var err error
+		handler, err = NewHandlerForModel(a.Model, GetAPIKeyForModel(a.Model), "")
+		if err != nil {
+			// Count the failure once, then keep the session on its model
+			a.Done(err)
+			if !cs.quiet {
+				fmt.Printf("Canary model %s unavailable: %v\n", a.Model, err)
+			}
+		}
+		cs.canaryHandlers[a.Model] = handler
+	}
+	if handler == nil {
+		return a.Baseline(cs.model)
+	}
+
+	cs.baseline, cs.baselineModel = cs.handler, cs.model
+	cs.handler, cs.model = handler, a.Model
+	return a
+}
+
+// finishCanary logs the outcome of a routed message and restores the
+// session's own model
+func (cs *ChatSession) finishCanary(a *canary.Assignment, err error) {
+	a.Done(err)
+	if cs.baseline != nil {
+		cs.handler, cs.model = cs.baseline, cs.baselineModel
+		cs.baseline, cs.baselineModel = nil, ""
+	}
+}
+
+// RecordCanaryFeedback attributes a thumbs up or down for the last response
+// to the model that wrote it, when a canary experiment is running
+func (cs *ChatSession) RecordCanaryFeedback(positive bool) {
+	if cs.canaryID != "" {
+		canary.Default().RecordFeedback(cs.canaryID, positive)
+	}
+}
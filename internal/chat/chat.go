@@ -8,116 +8,86 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/entrepeneur4lyf/codeforge/internal/config"
+	contextmgmt "github.com/entrepeneur4lyf/codeforge/internal/context"
+	"github.com/entrepeneur4lyf/codeforge/internal/diagram"
 	"github.com/entrepeneur4lyf/codeforge/internal/events"
+	"github.com/entrepeneur4lyf/codeforge/internal/fileedit"
+	"github.com/entrepeneur4lyf/codeforge/internal/issues"
 	"github.com/entrepeneur4lyf/codeforge/internal/llm"
 	"github.com/entrepeneur4lyf/codeforge/internal/llm/agent"
 	"github.com/entrepeneur4lyf/codeforge/internal/llm/providers"
+	"github.com/entrepeneur4lyf/codeforge/internal/llm/tools"
+	"github.com/entrepeneur4lyf/codeforge/internal/ml"
+	"github.com/entrepeneur4lyf/codeforge/internal/notes"
+	"github.com/entrepeneur4lyf/codeforge/internal/shellcmd"
+	"github.com/entrepeneur4lyf/codeforge/internal/storage"
+	"github.com/entrepeneur4lyf/codeforge/internal/tokens"
 )
 
 // GetAPIKeyForModel returns the appropriate API key for the given model
 func GetAPIKeyForModel(model string) string {
-	// Determine provider from model and get corresponding API key
+	model = config.ResolveModel(model)
 
-	// Check if this is a provider-specific model (provider/model format)
-	if strings.Contains(model, "/") {
-		parts := strings.Split(model, "/")
-		if len(parts) == 2 {
-			provider := parts[0]
-			// Use provider-specific API key directly for known providers
-			switch provider {
-			case "anthropic":
-				return os.Getenv("ANTHROPIC_API_KEY")
-			case "openai":
-				return os.Getenv("OPENAI_API_KEY")
-			case "google":
-				return os.Getenv("GEMINI_API_KEY")
-			case "groq":
-				return os.Getenv("GROQ_API_KEY")
-			case "mistralai", "mistral":
-				return os.Getenv("MISTRAL_API_KEY")
-			case "deepseek":
-				return os.Getenv("DEEPSEEK_API_KEY")
-			case "cohere":
-				return os.Getenv("COHERE_API_KEY")
-			default:
-				// For unknown providers, try OpenRouter as fallback
-				if key := os.Getenv("OPENROUTER_API_KEY"); key != "" {
-					return key
-				}
-			}
-		}
-	}
-
-	// Direct provider models (without provider/ prefix)
-	// Anthropic models
-	if strings.HasPrefix(model, "claude-") || strings.HasPrefix(model, "anthropic.") {
-		return os.Getenv("ANTHROPIC_API_KEY")
-	}
-
-	// OpenAI models
-	if strings.HasPrefix(model, "gpt-") || strings.HasPrefix(model, "o1-") || strings.HasPrefix(model, "chatgpt-") {
-		return os.Getenv("OPENAI_API_KEY")
+	// Local providers don't need a key; return a placeholder so callers
+	// don't treat it as missing
+	if isLocalModel(model) {
+		return "local"
 	}
 
-	// Google models
-	if strings.HasPrefix(model, "gemini-") {
-		return os.Getenv("GEMINI_API_KEY")
+	// Models named with a provider prefix, as "deepseek/deepseek-chat", use
+	// that provider's key. OpenRouter serves most providers' models, so its
+	// key is the fallback.
+	if provider, _, found := strings.Cut(model, "/"); found {
+		if provider == "copilot" {
+			return providers.CopilotToken()
+		}
+		if spec, ok := config.LookupProvider(provider); ok {
+			if key := spec.APIKey(); key != "" {
+				return key
+			}
+		}
+		if key := config.ProviderKey("openrouter"); key != "" {
+			return key
+		}
+	} else if spec, ok := config.LookupProvider(detectProviderFromModel(model)); ok {
+		return spec.APIKey()
 	}
 
-	// Groq models
-	if strings.HasPrefix(model, "llama") && strings.Contains(model, "versatile") {
-		return os.Getenv("GROQ_API_KEY")
+	// Otherwise the first provider with a key, in registry order
+	for _, spec := range config.ListProviders() {
+		if spec.Local || spec.ID == "copilot" {
+			continue
+		}
+		if key := spec.APIKey(); key != "" {
+			return key
+		}
 	}
+	return ""
+}
 
-	// Additional provider-specific keys
-	if strings.Contains(model, "together/") || strings.Contains(model, "togetherai/") {
-		return os.Getenv("TOGETHER_API_KEY")
-	}
-	if strings.Contains(model, "fireworks/") {
-		return os.Getenv("FIREWORKS_API_KEY")
-	}
-	if strings.Contains(model, "deepseek/") {
-		return os.Getenv("DEEPSEEK_API_KEY")
-	}
-	if strings.Contains(model, "cohere/") {
-		return os.Getenv("COHERE_API_KEY")
-	}
-	if strings.Contains(model, "mistral/") {
-		return os.Getenv("MISTRAL_API_KEY")
-	}
-	if strings.Contains(model, "perplexity/") {
-		return os.Getenv("PERPLEXITY_API_KEY")
-	}
-	if strings.Contains(model, "cerebras/") {
-		return os.Getenv("CEREBRAS_API_KEY")
-	}
-	if strings.Contains(model, "sambanova/") {
-		return os.Getenv("SAMBANOVA_API_KEY")
+// detectProviderFromModel detects the provider from the model ID
+func detectProviderFromModel(model string) string {
+	if strings.HasPrefix(model, "ollama/") {
+		return "ollama"
 	}
-
-	// Priority fallback order (most versatile first)
-	if key := os.Getenv("OPENROUTER_API_KEY"); key != "" {
-		return key
+	if strings.HasPrefix(model, "lmstudio/") {
+		return "lmstudio"
 	}
-	if key := os.Getenv("ANTHROPIC_API_KEY"); key != "" {
-		return key
+	if strings.HasPrefix(model, "jan/") {
+		return "jan"
 	}
-	if key := os.Getenv("OPENAI_API_KEY"); key != "" {
-		return key
+	if model == "mock" || strings.HasPrefix(model, "mock/") {
+		return "mock"
 	}
-	if key := os.Getenv("GEMINI_API_KEY"); key != "" {
-		return key
+	if strings.HasPrefix(model, "copilot/") {
+		return "copilot"
 	}
 
-	return ""
-}
-
-// detectProviderFromModel detects the provider from the model ID
-func detectProviderFromModel(model string) string {
 	// OpenRouter models have provider/model format
 	if strings.Contains(model, "/") {
 		return "openrouter"
@@ -151,43 +121,96 @@ func detectProviderFromModel(model string) string {
 
 // GetDefaultModel returns a default model if none specified
 func GetDefaultModel() string {
-	// Try to find a model based on available API keys (priority order)
-
-	// OpenRouter is most versatile (300+ models)
-	if os.Getenv("OPENROUTER_API_KEY") != "" {
-		return "anthropic/claude-3.5-sonnet"
+	// Local-only mode always uses the configured local model
+	if config.IsLocalOnly() {
+		localModel := "llama3.2"
+		if cfg := config.Get(); cfg != nil && cfg.LocalModel != "" {
+			localModel = cfg.LocalRouteModel()
+		}
+		return "ollama/" + localModel
 	}
 
-	// Direct provider keys
-	if os.Getenv("ANTHROPIC_API_KEY") != "" {
-		return "claude-3-5-sonnet-20241022"
-	}
-	if os.Getenv("OPENAI_API_KEY") != "" {
-		return "gpt-4o"
+	// A default set in config (usually per project) wins over detection
+	// from API keys
+	model := defaultCloudModel()
+	if cfg := config.Get(); cfg != nil && cfg.DefaultModelID() != "" {
+		model = cfg.DefaultModelID()
 	}
-	if os.Getenv("GEMINI_API_KEY") != "" {
-		return "gemini-1.5-pro"
+
+	// Route to a local model automatically when offline
+	model, _ = RouteOffline(model)
+	return model
+}
+
+// defaultCloudModel picks the default model of the first provider in the
+// registry that has an API key
+func defaultCloudModel() string {
+	for _, spec := range config.ListProviders() {
+		if spec.DefaultModel == "" || spec.Local {
+			continue
+		}
+		// A GitHub Copilot login works without any provider key
+		if spec.ID == "copilot" && providers.CopilotToken() != "" {
+			return spec.DefaultModel
+		}
+		if spec.APIKey() != "" {
+			return spec.DefaultModel
+		}
 	}
-	if os.Getenv("GROQ_API_KEY") != "" {
-		return "groq/llama-3.1-70b-versatile"
+
+	// Default to Claude (user will get error if no API key)
+	return "claude-3-5-sonnet-20241022"
+}
+
+// RouteOffline switches a cloud model to an installed Ollama model when cloud
+// providers are unreachable. It returns the model to use and whether it changed.
+func RouteOffline(model string) (string, bool) {
+	if isLocalModel(model) || providers.IsOnline() {
+		return model, false
 	}
 
-	// Additional providers
-	if os.Getenv("TOGETHER_API_KEY") != "" {
-		return "together/meta-llama/Llama-3-70b-chat-hf"
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	installed, err := providers.ListOllamaModels(ctx)
+	if err != nil || len(installed) == 0 {
+		return model, false
 	}
-	if os.Getenv("FIREWORKS_API_KEY") != "" {
-		return "fireworks/llama-v3p1-70b-instruct"
+
+	// Prefer the configured local model, otherwise the first chat model
+	preferred := "llama3.2"
+	if cfg := config.Get(); cfg != nil && cfg.LocalModel != "" {
+		preferred = cfg.LocalRouteModel()
 	}
-	if os.Getenv("DEEPSEEK_API_KEY") != "" {
-		return "deepseek/deepseek-chat"
+	chosen := ""
+	for _, name := range installed {
+		if strings.Contains(name, "embed") {
+			continue
+		}
+		if name == preferred || strings.HasPrefix(name, preferred+":") {
+			chosen = name
+			break
+		}
+		if chosen == "" {
+			chosen = name
+		}
 	}
-	if os.Getenv("COHERE_API_KEY") != "" {
-		return "cohere/command-r-plus"
+	if chosen == "" {
+		return model, false
 	}
 
-	// Default to Claude (user will get error if no API key)
-	return "claude-3-5-sonnet-20241022"
+	return "ollama/" + chosen, true
+}
+
+// isLocalModel reports whether the model is served by a local provider,
+// including OpenAI-compatible servers added in the config
+func isLocalModel(model string) bool {
+	if model == "mock" || strings.HasPrefix(model, "mock/") {
+		return true
+	}
+	provider, _, found := strings.Cut(model, "/")
+	spec, ok := config.LookupProvider(provider)
+	return found && ok && spec.Local
 }
 
 // ChatSession represents an interactive chat session
@@ -197,21 +220,45 @@ type ChatSession struct {
 	systemPrompt    string
 	quiet           bool
 	model           string
+	provider        string // Provider given for the model, detected when empty
+	keyVersion      uint64 // config.KeyVersion when the handler was built
 	format          string
 	commandRouter   *CommandRouter
 	favorites       *Favorites
-	contextGathered bool   // Track if context has been gathered for this session
-	sessionContext  string // Store the gathered context for the session
+	contextGathered bool               // Track if context has been gathered for this session
+	sessionContext  string             // Store the gathered context for the session
+	sessionSources  []ml.ContextSource // Code the gathered context was drawn from
+	pins            *Pins              // Files included in every prompt
+	issues          []*issues.Issue    // Issues added to the context with /issue
+	lastDiagrams    []diagram.Diagram  // Diagrams in the last response, for /diagram
+	lastEdits       []fileedit.Change  // Changes the last response's edits make, for /apply
+	mentioned       []string           // Paths mentioned so far, whose directory profiles apply
+	workspace       *tools.Workspace   // Working directory and environment set for this session
+
+	// Persistence for continuing the session from other clients
+	store     storage.ChatStore
+	follower  *storage.Follower
+	lastUsage *llm.Usage // Usage of the last response, saved with it
+	usage     usageTotals
 
 	// Agent integration
 	agentService agent.Service
 	eventManager *events.Manager
 	sessionID    string
 	currentAgent config.AgentName
+
+	// Canary experiment state
+	canaryID       string                    // Identifies the session in the canary log
+	canaryHandlers map[string]llm.ApiHandler // Candidate handlers, nil when unavailable
+	baseline       llm.ApiHandler            // Handler to restore after a candidate request
+	baselineModel  string
 }
 
-// NewChatSession creates a new chat session with the specified configuration
-func NewChatSession(model, apiKey, provider string, quiet bool, format string) (*ChatSession, error) {
+// NewHandlerForModel builds an LLM handler for the given model, detecting the
+// provider from the model ID when none is specified
+func NewHandlerForModel(model, apiKey, provider string) (llm.ApiHandler, error) {
+	model = config.ResolveModel(model)
+
 	// Create handler options
 	options := llm.ApiHandlerOptions{
 		APIKey:  apiKey,
@@ -234,6 +281,17 @@ func NewChatSession(model, apiKey, provider string, quiet bool, format string) (
 		return nil, fmt.Errorf("failed to create LLM handler: %w", err)
 	}
 
+	return handler, nil
+}
+
+// NewChatSession creates a new chat session with the specified configuration
+func NewChatSession(model, apiKey, provider string, quiet bool, format string) (*ChatSession, error) {
+	model = config.ResolveModel(model)
+	handler, err := NewHandlerForModel(model, apiKey, provider)
+	if err != nil {
+		return nil, err
+	}
+
 	// Set up system prompt for CodeForge
 	systemPrompt := `You are CodeForge, an AI coding assistant. You help developers with:
 
@@ -249,7 +307,9 @@ When users ask you to:
 - "fix" or "debug" - analyze and fix code issues
 - "explain" or "document" - provide clear explanations
 
-Be concise, practical, and focus on actionable solutions. Provide code examples when helpful.`
+Be concise, practical, and focus on actionable solutions. Provide code examples when helpful.
+
+` + shellcmd.Instructions
 
 	// Get current working directory for command router
 	workingDir, err := os.Getwd()
@@ -263,39 +323,90 @@ Be concise, practical, and focus on actionable solutions. Provide code examples
 		return nil, fmt.Errorf("failed to initialize favorites: %w", err)
 	}
 
+	// Start with the configured pinned files
+	var pinnedFiles []string
+	pinnedBudget := DefaultPinnedBudget
+	if cfg := config.Get(); cfg != nil {
+		pinnedFiles = cfg.Context.PinnedFiles
+		pinnedBudget = cfg.Context.PinnedBudget
+	}
+
 	return &ChatSession{
 		handler:       handler,
 		messages:      []llm.Message{},
 		systemPrompt:  systemPrompt,
 		quiet:         quiet,
 		model:         model,
+		provider:      provider,
+		keyVersion:    config.KeyVersion(),
 		format:        format,
 		commandRouter: NewCommandRouter(workingDir),
 		favorites:     favorites,
+		pins:          NewPins(workingDir, pinnedFiles, pinnedBudget),
 	}, nil
 }
 
+// SetWorkspace runs the session in its own working directory, with its own
+// environment variables and build command, instead of the process's. Tool
+// calls, builds and pinned files all use it. Call it before the first
+// message: files pinned so far are replaced by the configured pins.
+func (cs *ChatSession) SetWorkspace(ws tools.Workspace) error {
+	if ws.Dir == "" {
+		ws.Dir = cs.commandRouter.workingDir
+	}
+	dir, err := filepath.Abs(ws.Dir)
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return fmt.Errorf("working directory %s is not a directory", ws.Dir)
+	}
+	ws.Dir = dir
+
+	cs.workspace = &ws
+	cs.commandRouter.workingDir = dir
+	cs.commandRouter.buildCommand = ws.BuildCommand
+	cs.commandRouter.env = ws.Environ()
+
+	var pinnedFiles []string
+	if cfg := config.Get(); cfg != nil {
+		pinnedFiles = cfg.Context.PinnedFiles
+	}
+	cs.pins = NewPins(dir, pinnedFiles, cs.pins.budget)
+	return nil
+}
+
 // StartInteractive starts an interactive chat session
 func (cs *ChatSession) StartInteractive() error {
 	if !cs.quiet {
 		// Show session info first
 		fmt.Printf("Model: %s\n", cs.model)
+		if id := cs.StoreSessionID(); id != "" {
+			fmt.Printf("Session: %s (continue with --session %s or in the web UI)\n", id, id)
+		}
 		fmt.Println("Type 'exit', 'quit', or press Ctrl+C to end the session")
 		fmt.Println("Type '/help' for available commands")
 		fmt.Println()
 
-		// Send initial greeting to model to get "What can I do for you today?" response
-		_, err := cs.ProcessMessage("Say 'What can I do for you today?' and nothing else.")
-		if err != nil {
-			fmt.Printf("Error getting initial response: %v\n", err)
-			// Continue anyway
+		if len(cs.messages) > 0 {
+			fmt.Printf("Resumed conversation with %d messages. Use /history to review it.\n\n", len(cs.messages))
+		} else {
+			// Send initial greeting to model to get "What can I do for you today?" response
+			_, err := cs.ProcessMessage("Say 'What can I do for you today?' and nothing else.")
+			if err != nil {
+				fmt.Printf("Error getting initial response: %v\n", err)
+				// Continue anyway
+			}
+			fmt.Println() // Add spacing after the streamed response
 		}
-		fmt.Println() // Add spacing after the streamed response
 	}
 
 	scanner := bufio.NewScanner(os.Stdin)
 
 	for {
+		// Pick up messages sent from the web UI or other terminals
+		cs.syncFromStore()
+
 		// Show prompt
 		if !cs.quiet {
 			fmt.Print("> ")
@@ -327,15 +438,36 @@ func (cs *ChatSession) StartInteractive() error {
 			break
 		}
 
-		// Process the message
+		// Stale retrieval is a common source of wrong answers
+		if !cs.quiet {
+			input = cs.offerStaleDiffs(scanner, input)
+		}
+
+		// Process the message, one client at a time in a shared session
+		unlock := cs.lockStoreSession()
+		cs.refreshKey()
+		assignment := cs.routeCanary(input)
 		response, err := cs.ProcessMessage(input)
 		if err != nil {
+			unlock()
+			cs.finishCanary(assignment, err)
 			fmt.Printf("Error: %v\n", err)
 			continue
 		}
 
+		cs.persistExchange(input, response)
+		unlock()
+		cs.finishCanary(assignment, nil)
+
 		// Display response
 		cs.displayResponse(response)
+		cs.offerEdits(scanner, response)
+		response = cs.offerCommands(scanner, response)
+		cs.offerDiagrams(scanner, response)
+	}
+
+	if summary := cs.UsageSummary(); summary != "" && !cs.quiet {
+		fmt.Println(summary)
 	}
 
 	if err := scanner.Err(); err != nil {
@@ -358,6 +490,9 @@ func (cs *ChatSession) ProcessMessageWithAgent(userInput string) (string, error)
 	// First, check if this is a direct command (build, file operations)
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
+	if cs.workspace != nil {
+		ctx = tools.WithWorkspace(ctx, *cs.workspace)
+	}
 
 	if commandResponse, handled := cs.commandRouter.RouteDirectCommand(ctx, userInput); handled {
 		// Direct command was handled, return the response
@@ -377,18 +512,30 @@ func (cs *ChatSession) ProcessMessageWithAgent(userInput string) (string, error)
 func (cs *ChatSession) processWithAgent(ctx context.Context, userInput string) (string, error) {
 	// Gather context only once per session
 	if !cs.contextGathered {
-		cs.sessionContext = cs.commandRouter.GatherContext(ctx, userInput)
+		cs.sessionContext, cs.sessionSources = cs.commandRouter.GatherContext(ctx, userInput)
 		cs.contextGathered = true
 	}
 
 	// Prepare content blocks
+	cs.notePaths(userInput)
 	var attachments []llm.ContentBlock
+	if profiles := contextmgmt.FormatProfiles(cs.directoryProfiles()); profiles != "" {
+		attachments = append(attachments, llm.TextBlock{Text: "\n\n" + profiles})
+	}
+	if pinned := cs.pins.Context(cs.model); pinned != "" {
+		attachments = append(attachments, llm.TextBlock{Text: "\n\n" + pinned})
+	}
+	if issueContext := cs.issueContext(); issueContext != "" {
+		attachments = append(attachments, llm.TextBlock{Text: "\n\n" + issueContext})
+	}
 	if cs.sessionContext != "" {
 		attachments = append(attachments, llm.TextBlock{
 			Text: "\n\n**Relevant Context:**\n" + cs.sessionContext,
 		})
 	}
 
+	cs.traceContext(userInput, cs.messages)
+
 	// Run agent
 	eventChan, err := cs.agentService.Run(ctx, cs.sessionID, cs.currentAgent, userInput, attachments...)
 	if err != nil {
@@ -405,7 +552,7 @@ func (cs *ChatSession) processWithAgent(ctx context.Context, userInput string) (
 			if text, ok := event.Data["text"].(string); ok {
 				fullResponse.WriteString(text)
 				if !cs.quiet {
-					fmt.Print(text)
+					fmt.Print(Printable(text))
 				}
 			}
 
@@ -451,6 +598,7 @@ func (cs *ChatSession) processWithAgent(ctx context.Context, userInput string) (
 		},
 	}
 	cs.messages = append(cs.messages, assistantMessage)
+	cs.recordUsage(usage)
 
 	// Show usage info in non-quiet mode
 	if !cs.quiet && usage != nil {
@@ -482,7 +630,7 @@ func (cs *ChatSession) ProcessMessage(userInput string) (string, error) {
 
 	// Gather context only once per session
 	if !cs.contextGathered {
-		cs.sessionContext = cs.commandRouter.GatherContext(ctx, userInput)
+		cs.sessionContext, cs.sessionSources = cs.commandRouter.GatherContext(ctx, userInput)
 		cs.contextGathered = true
 	}
 
@@ -492,6 +640,9 @@ func (cs *ChatSession) ProcessMessage(userInput string) (string, error) {
 		enhancedPrompt = userInput + "\n\n**Relevant Context:**\n" + cs.sessionContext
 	}
 
+	cs.notePaths(userInput)
+	cs.traceContext(userInput, cs.messages)
+
 	// Add user message to conversation
 	userMessage := llm.Message{
 		Role: "user",
@@ -506,7 +657,8 @@ func (cs *ChatSession) ProcessMessage(userInput string) (string, error) {
 	defer cancel()
 
 	// Send message to LLM
-	stream, err := cs.handler.CreateMessage(ctx, cs.systemPrompt, cs.messages)
+	cs.refreshKey()
+	stream, err := cs.handler.CreateMessage(ctx, cs.sessionSystemPrompt(), cs.messages)
 	if err != nil {
 		return "", fmt.Errorf("failed to send message: %w", err)
 	}
@@ -518,6 +670,7 @@ func (cs *ChatSession) ProcessMessage(userInput string) (string, error) {
 	// Collect response
 	var responseText strings.Builder
 	var usage *llm.Usage
+	var finishReason string
 
 	chunkCount := 0
 	for chunk := range stream {
@@ -527,27 +680,48 @@ func (cs *ChatSession) ProcessMessage(userInput string) (string, error) {
 			responseText.WriteString(c.Text)
 			// Show streaming response in real-time for interactive mode
 			if !cs.quiet {
-				fmt.Print(c.Text)
+				fmt.Print(Printable(c.Text))
 			}
 		case llm.ApiStreamUsageChunk:
-			usage = &llm.Usage{
-				PromptTokens:     c.InputTokens,
-				CompletionTokens: c.OutputTokens,
-				TotalTokens:      c.InputTokens + c.OutputTokens,
-			}
-			if c.TotalCost != nil {
-				usage.TotalCost = *c.TotalCost
-			}
+			usage = cs.sessionUsage(c)
 		case llm.ApiStreamReasoningChunk:
 			// Handle reasoning/thinking chunks (for models that support it)
 			if !cs.quiet {
 				fmt.Printf("\n[Thinking: %s]\n", c.Reasoning)
 			}
+		case llm.ApiStreamFinishChunk:
+			finishReason = c.Reason
+		}
+	}
+
+	// Say when the response was cut short
+	if !cs.quiet {
+		switch finishReason {
+		case llm.FinishLength:
+			fmt.Print("\n[Response truncated: the output token limit was reached]")
+		case llm.FinishContentFilter:
+			fmt.Print("\n[Response stopped by the provider's content filter]")
 		}
 	}
 
 	response := responseText.String()
 
+	// Estimate usage locally when the provider doesn't report it
+	estimated := false
+	if usage == nil {
+		input := tokens.Count(cs.systemPrompt, cs.model).Count
+		for _, msg := range cs.messages {
+			for _, block := range msg.Content {
+				if text, ok := block.(llm.TextBlock); ok {
+					input += tokens.Count(text.Text, cs.model).Count
+				}
+			}
+		}
+		output := tokens.Count(response, cs.model).Count
+		usage = cs.sessionUsage(llm.ApiStreamUsageChunk{InputTokens: input, OutputTokens: output})
+		estimated = true
+	}
+
 	// Add assistant response to conversation
 	assistantMessage := llm.Message{
 		Role: "assistant",
@@ -556,10 +730,14 @@ func (cs *ChatSession) ProcessMessage(userInput string) (string, error) {
 		},
 	}
 	cs.messages = append(cs.messages, assistantMessage)
+	cs.recordUsage(usage)
 
 	// Show usage info in non-quiet mode
 	if !cs.quiet && usage != nil {
 		fmt.Printf("\n\nTokens: %d input, %d output", usage.PromptTokens, usage.CompletionTokens)
+		if estimated {
+			fmt.Print(" (estimated)")
+		}
 		if usage.TotalCost > 0 {
 			fmt.Printf(" | Cost: $%.4f", usage.TotalCost)
 		}
@@ -571,7 +749,8 @@ func (cs *ChatSession) ProcessMessage(userInput string) (string, error) {
 
 // handleCommand processes special chat commands
 func (cs *ChatSession) handleCommand(command string) bool {
-	switch command {
+	fields := strings.Fields(command)
+	switch fields[0] {
 	case "/help":
 		cs.showHelp()
 	case "/clear":
@@ -584,8 +763,32 @@ func (cs *ChatSession) handleCommand(command string) bool {
 		cs.selectEmbedding()
 	case "/history":
 		cs.showHistory()
+	case "/sessions":
+		cs.showSessions(fields[1:])
 	case "/info":
 		cs.showModelInfo()
+	case "/pin":
+		cs.pinFiles(fields[1:])
+	case "/pins":
+		cs.showPins()
+	case "/unpin":
+		cs.unpinFiles(fields[1:])
+	case "/issue":
+		cs.attachIssue(fields[1:])
+	case "/diagram":
+		cs.openDiagrams()
+	case "/apply":
+		cs.applyEdits()
+	case "/git":
+		cs.handleGit(fields[1:])
+	case "/tasks":
+		cs.showTasks(fields[1:])
+	case "/notes":
+		cs.handleNotes(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(command), "/notes")))
+	case "/good":
+		cs.rateLastResponse(storage.FeedbackGood, "")
+	case "/bad":
+		cs.rateLastResponse(storage.FeedbackBad, strings.Join(fields[1:], " "))
 	case "/exit", "/quit":
 		if !cs.quiet {
 			fmt.Println("Goodbye!")
@@ -613,6 +816,18 @@ func (cs *ChatSession) showHelp() {
 	fmt.Println("  /embedding - Select embedding provider")
 	fmt.Println("  /favorites - Show favorite providers and models")
 	fmt.Println("  /history   - Show conversation history")
+	fmt.Println("  /sessions  - List saved sessions; /sessions N continues one")
+	fmt.Println("  /pin PATH  - Keep a file in context for every prompt")
+	fmt.Println("  /pins      - Show pinned files and their token usage")
+	fmt.Println("  /unpin X   - Unpin a file by path or /pins number")
+	fmt.Println("  /issue N   - Add GitHub or GitLab issue N to the context")
+	fmt.Println("  /notes     - Show project notes; /notes TEXT adds a note")
+	fmt.Println("  /diagram   - Open the last response's diagrams in the browser")
+	fmt.Println("  /apply     - Apply the last response's diffs and SEARCH/REPLACE edits")
+	fmt.Println("  /git       - Show status or diffs, stage files, or commit with a generated message")
+	fmt.Println("  /tasks     - List the conversation's open tasks; /tasks issues drafts issues")
+	fmt.Println("  /good      - Rate the last response as good")
+	fmt.Println("  /bad [WHY] - Rate the last response as bad, optionally saying why")
 	fmt.Println("  /exit      - Exit the chat session")
 	fmt.Println("  exit       - Exit the chat session")
 	fmt.Println("  quit       - Exit the chat session")
@@ -780,7 +995,7 @@ func setProviderSpecificOptions(options *llm.ApiHandlerOptions, provider, apiKey
 	case "vertex":
 		// Set Vertex-specific options if needed
 		// options.VertexProjectID would need to be set by user
-	case "xai", "mistral", "deepseek", "groq", "ollama":
+	case "xai", "mistral", "deepseek", "groq", "ollama", "copilot":
 		// These providers use the generic APIKey field
 		// No additional provider-specific fields needed
 	}
@@ -837,6 +1052,148 @@ func (cs *ChatSession) showHistory() {
 	}
 }
 
+// sessionSystemPrompt returns the system prompt with project notes,
+// directory profiles, pinned files and issues appended
+func (cs *ChatSession) sessionSystemPrompt() string {
+	prompt := cs.systemPrompt
+	if projectNotes := notes.PromptContext(); projectNotes != "" {
+		prompt += "\n\n" + projectNotes
+	}
+	if profiles := contextmgmt.FormatProfiles(cs.directoryProfiles()); profiles != "" {
+		prompt += "\n\n" + profiles
+	}
+	if pinned := cs.pins.Context(cs.model); pinned != "" {
+		prompt += "\n\n" + pinned
+	}
+	if issueContext := cs.issueContext(); issueContext != "" {
+		prompt += "\n\n" + issueContext
+	}
+	return prompt
+}
+
+// pinFiles pins files for the rest of the session
+func (cs *ChatSession) pinFiles(paths []string) {
+	if len(paths) == 0 {
+		fmt.Println("Usage: /pin <path>...")
+		return
+	}
+	for _, path := range paths {
+		rel, err := cs.pins.Add(path)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			continue
+		}
+		if !cs.quiet {
+			fmt.Printf("Pinned %s\n", rel)
+		}
+	}
+	cs.warnPinsOverBudget()
+}
+
+// unpinFiles removes pins by path or /pins number
+func (cs *ChatSession) unpinFiles(refs []string) {
+	if len(refs) == 0 {
+		fmt.Println("Usage: /unpin <path|number>...")
+		return
+	}
+	// Resolve numbers up front so earlier removals don't shift later ones
+	paths := cs.pins.Paths()
+	for i, ref := range refs {
+		if n, err := strconv.Atoi(ref); err == nil && n >= 1 && n <= len(paths) {
+			refs[i] = paths[n-1]
+		}
+	}
+	for _, ref := range refs {
+		removed, err := cs.pins.Remove(ref)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			continue
+		}
+		if !cs.quiet {
+			fmt.Printf("Unpinned %s\n", removed)
+		}
+	}
+}
+
+// showPins lists pinned files and how they fit in the token budget
+func (cs *ChatSession) showPins() {
+	if cs.quiet {
+		return
+	}
+
+	statuses := cs.pins.List(cs.model)
+	if len(statuses) == 0 {
+		fmt.Println("No pinned files. Use /pin <path> or context.pinnedFiles in config.")
+		return
+	}
+
+	used := 0
+	fmt.Println("Pinned files:")
+	for i, st := range statuses {
+		source := ""
+		if st.FromConfig {
+			source = " [config]"
+		}
+		switch {
+		case st.Err != nil:
+			fmt.Printf("%d. %s%s - unreadable: %v\n", i+1, st.Path, source, st.Err)
+		case st.Included:
+			used += st.Tokens
+			fmt.Printf("%d. %s%s - %d tokens\n", i+1, st.Path, source, st.Tokens)
+		default:
+			fmt.Printf("%d. %s%s - %d tokens (skipped: over budget)\n", i+1, st.Path, source, st.Tokens)
+		}
+	}
+	fmt.Printf("\nUsing %d of %d pinned tokens. Unpin with /unpin <number|path>.\n", used, cs.pins.Budget())
+}
+
+// handleNotes shows the project notes, or appends a note when text is given
+func (cs *ChatSession) handleNotes(text string) {
+	store := notes.ForProject()
+	if store == nil {
+		fmt.Println("Error: configuration not loaded")
+		return
+	}
+
+	if text != "" {
+		if err := store.Append(text, time.Now()); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		if !cs.quiet {
+			fmt.Printf("Noted in %s\n", store.Path())
+		}
+		return
+	}
+
+	if cs.quiet {
+		return
+	}
+	saved, err := store.Load()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	if strings.TrimSpace(saved) == "" {
+		fmt.Println("No project notes. Add one with /notes <text>.")
+		return
+	}
+	fmt.Printf("Project notes (%s):\n%s\n", store.Path(), strings.TrimSpace(saved))
+	if cfg := config.Get(); cfg != nil && !cfg.Notes.InjectContext {
+		fmt.Println("\nNotes are not added to context; set notes.injectContext to include them.")
+	}
+}
+
+// warnPinsOverBudget reports pins that no longer fit in the budget
+func (cs *ChatSession) warnPinsOverBudget() {
+	for _, st := range cs.pins.List(cs.model) {
+		if st.Err == nil && !st.Included {
+			fmt.Printf("Warning: %s (%d tokens) exceeds the remaining pinned budget of %d tokens and will be skipped\n",
+				st.Path, st.Tokens, cs.pins.Budget())
+		}
+	}
+}
+
 // displayResponse formats and displays the AI response
 func (cs *ChatSession) displayResponse(response string) {
 	if cs.quiet {
@@ -867,6 +1224,7 @@ func (cs *ChatSession) selectModel() {
 
 	// Update the current model
 	cs.model = model
+	cs.provider = ""
 
 	// Create new handler with the selected model
 	apiKey := GetAPIKeyForModel(model)
@@ -890,6 +1248,7 @@ func (cs *ChatSession) selectModel() {
 
 	cs.handler = handler
 	fmt.Printf("Switched to model: %s\n", model)
+	cs.fitHistory()
 }
 
 // showFavorites displays favorite providers and models
@@ -900,7 +1259,7 @@ func (cs *ChatSession) showFavorites() {
 
 	favorites := cs.favorites.GetAllFavorites()
 	if len(favorites) == 0 {
-		fmt.Println("📝 No favorites yet!")
+		fmt.Println(Icon("📝") + "No favorites yet!")
 		fmt.Println("Use the /model command and press spacebar to add favorites")
 		return
 	}
@@ -922,7 +1281,7 @@ func (cs *ChatSession) showFavorites() {
 
 	// Show favorite providers
 	if len(providers) > 0 {
-		fmt.Println("🔌 Favorite Providers:")
+		fmt.Println(Icon("🔌") + "Favorite Providers:")
 		for _, provider := range providers {
 			fmt.Printf("  • %s\n", provider.Name)
 		}
@@ -939,5 +1298,5 @@ func (cs *ChatSession) showFavorites() {
 	}
 
 	providerCount, modelCount := cs.favorites.GetStats()
-	fmt.Printf("📊 Total: %d providers, %d models\n", providerCount, modelCount)
+	fmt.Printf("%sTotal: %d providers, %d models\n", Icon("📊"), providerCount, modelCount)
 }
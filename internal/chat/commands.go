@@ -10,6 +10,7 @@ import (
 	"github.com/entrepeneur4lyf/codeforge/internal/builder"
 	"github.com/entrepeneur4lyf/codeforge/internal/embeddings"
 	"github.com/entrepeneur4lyf/codeforge/internal/git"
+	"github.com/entrepeneur4lyf/codeforge/internal/ignore"
 	"github.com/entrepeneur4lyf/codeforge/internal/lsp"
 	"github.com/entrepeneur4lyf/codeforge/internal/ml"
 	"github.com/entrepeneur4lyf/codeforge/internal/vectordb"
@@ -17,7 +18,9 @@ import (
 
 // CommandRouter handles natural language commands and routes them to appropriate functionality
 type CommandRouter struct {
-	workingDir string
+	workingDir   string
+	buildCommand string   // Replaces the detected language's build command when set
+	env          []string // Added to the environment of builds
 }
 
 // NewCommandRouter creates a new command router
@@ -238,18 +241,19 @@ func (cr *CommandRouter) handleGitConflictCommand(ctx context.Context, userInput
 	return response.String(), true
 }
 
-// GatherContext collects relevant context using ML-powered code intelligence
-func (cr *CommandRouter) GatherContext(ctx context.Context, userInput string) string {
+// GatherContext collects relevant context using ML-powered code intelligence,
+// along with the code it was drawn from when known
+func (cr *CommandRouter) GatherContext(ctx context.Context, userInput string) (string, []ml.ContextSource) {
 	// Only gather context for code-related queries to avoid unnecessary ML searches
 	if !cr.isCodeRelatedQuery(userInput) {
-		return ""
+		return "", nil
 	}
 
 	// Try to get ML service for intelligent context
 	mlService := ml.GetService()
 	if mlService != nil && mlService.IsEnabled() {
 		// Use ML-powered intelligent context gathering
-		context := mlService.GetIntelligentContext(ctx, userInput, 10)
+		context, sources := mlService.RetrieveContext(ctx, userInput, 10)
 
 		// If ML context is empty, try smart search as fallback
 		if context == "" {
@@ -257,13 +261,13 @@ func (cr *CommandRouter) GatherContext(ctx context.Context, userInput string) st
 		}
 
 		if context != "" {
-			return context
+			return context, sources
 		}
 	}
 
 	// Graceful degradation - return empty context if ML is not available
 	// This allows the existing chat system to work normally
-	return ""
+	return "", nil
 }
 
 // isCodeRelatedQuery determines if a query is code-related and needs context
@@ -330,12 +334,12 @@ func (cr *CommandRouter) isBuildCommand(input string) bool {
 
 func (cr *CommandRouter) handleBuildCommand(ctx context.Context, userInput string) (string, bool) {
 	// Execute build
-	output, err := builder.Build(cr.workingDir)
+	output, err := builder.BuildIn(cr.workingDir, cr.buildCommand, cr.env)
 
 	if err != nil {
 		// Build failed - provide detailed error analysis
 		errorOutput := string(output)
-		result := fmt.Sprintf("🔨 Build failed in %s\n\n", cr.workingDir)
+		result := fmt.Sprintf("%sBuild failed in %s\n\n", Icon("🔨"), cr.workingDir)
 		result += "**Error Output:**\n```\n" + errorOutput + "\n```\n\n"
 
 		// Try to parse and explain the error
@@ -406,7 +410,7 @@ func (cr *CommandRouter) handleSearchCommand(ctx context.Context, userInput stri
 	}
 
 	// Get embedding for the search query using the package-level function
-	embedding, err := embeddings.GetEmbedding(ctx, query)
+	embedding, err := embeddings.GetQueryEmbedding(ctx, query)
 	if err != nil {
 		return fmt.Sprintf("Failed to generate embedding: %v", err), true
 	}
@@ -429,7 +433,11 @@ func (cr *CommandRouter) handleSearchCommand(ctx context.Context, userInput stri
 	// Format results
 	response := fmt.Sprintf("Search results for: **%s**\n\n", query)
 	for i, result := range results {
-		response += fmt.Sprintf("**%d. %s** (Score: %.3f)\n", i+1, result.Chunk.FilePath, result.Score)
+		location := fmt.Sprintf("Score: %.3f", result.Score)
+		if lines := result.Chunk.Lines(); lines != "" {
+			location = "lines " + lines + ", " + location
+		}
+		response += fmt.Sprintf("**%d. %s** (%s)\n", i+1, result.Chunk.Describe(), location)
 		response += fmt.Sprintf("```%s\n%s\n```\n\n", result.Chunk.Language, result.Chunk.Content)
 	}
 
@@ -479,7 +487,7 @@ func (cr *CommandRouter) handleLSPCommand(_ context.Context, _ string) (string,
 
 	// For now, provide general LSP information
 	// In a full implementation, this would parse the command and execute specific LSP operations
-	response := "🔧 **LSP Features Available:**\n\n"
+	response := Icon("🔧") + "**LSP Features Available:**\n\n"
 	response += "- **Find Definition**: Locate where symbols are defined\n"
 	response += "- **Find References**: Find all usages of a symbol\n"
 	response += "- **Hover Information**: Get type and documentation info\n"
@@ -522,6 +530,7 @@ func (cr *CommandRouter) handleFileCommand(_ context.Context, _ string) (string,
 
 func (cr *CommandRouter) listProjectFiles(dir string) ([]string, error) {
 	var files []string
+	excludes := ignore.Default()
 
 	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -536,20 +545,21 @@ func (cr *CommandRouter) listProjectFiles(dir string) ([]string, error) {
 			return nil
 		}
 
-		// Skip common build/cache directories
-		skipDirs := []string{"node_modules", "target", "build", "dist", ".git", "__pycache__"}
-		for _, skipDir := range skipDirs {
-			if info.IsDir() && info.Name() == skipDir {
+		// Get relative path
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			relPath = path
+		}
+
+		// Skip build/cache directories and other shared excludes
+		if relPath != "." && excludes.Match(relPath) {
+			if info.IsDir() {
 				return filepath.SkipDir
 			}
+			return nil
 		}
 
 		if !info.IsDir() {
-			// Get relative path
-			relPath, err := filepath.Rel(dir, path)
-			if err != nil {
-				relPath = path
-			}
 			files = append(files, relPath)
 		}
 
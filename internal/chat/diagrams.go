@@ -0,0 +1,64 @@
+package chat
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+	"github.com/entrepeneur4lyf/codeforge/internal/diagram"
+)
+
+// offerDiagrams offers to open the Mermaid and Graphviz diagrams of a
+// response in the browser, where they can actually be seen
+func (cs *ChatSession) offerDiagrams(scanner *bufio.Scanner, response string) {
+	cs.lastDiagrams = diagram.Extract(response)
+	if len(cs.lastDiagrams) == 0 || cs.quiet {
+		return
+	}
+
+	noun := "diagrams"
+	if len(cs.lastDiagrams) == 1 {
+		noun = "diagram"
+	}
+	fmt.Printf("\nOpen the %d %s in your browser? [y/N] ", len(cs.lastDiagrams), noun)
+	if !scanner.Scan() {
+		return
+	}
+	if answer := strings.ToLower(strings.TrimSpace(scanner.Text())); answer != "y" && answer != "yes" {
+		fmt.Println("Use /diagram to open them later.")
+		return
+	}
+	cs.openDiagrams()
+}
+
+// openDiagrams renders the diagrams of the last response to a page and
+// opens it, for /diagram
+func (cs *ChatSession) openDiagrams() {
+	if len(cs.lastDiagrams) == 0 {
+		fmt.Println("The last response has no Mermaid or Graphviz diagrams.")
+		return
+	}
+	cfg := config.Get()
+	if cfg == nil {
+		fmt.Println("Error: configuration not loaded")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	path, err := diagram.Open(ctx, cfg.DiagramDir(), cs.lastDiagrams)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		if path != "" {
+			fmt.Printf("The diagrams are in %s\n", path)
+		}
+		return
+	}
+	if !cs.quiet {
+		fmt.Printf("Opened %s\n", path)
+	}
+}
@@ -0,0 +1,77 @@
+package chat
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+	"github.com/entrepeneur4lyf/codeforge/internal/fileedit"
+)
+
+// offerEdits shows the changes the diffs and SEARCH/REPLACE blocks of a
+// response would make to the working tree, and applies them if asked to
+func (cs *ChatSession) offerEdits(scanner *bufio.Scanner, response string) {
+	cs.lastEdits = nil
+	edits, err := fileedit.Extract(response)
+	if (len(edits) == 0 && err == nil) || cs.quiet {
+		return
+	}
+	if err == nil {
+		cs.lastEdits, err = fileedit.Plan(cs.commandRouter.workingDir, edits)
+	}
+	if err != nil {
+		fmt.Printf("\nThe response's edits can't be applied: %v\n", err)
+		return
+	}
+
+	fmt.Println()
+	for _, c := range cs.lastEdits {
+		fmt.Print(c.Diff())
+	}
+	fmt.Printf("\nApply the changes to %s? [y/N] ", fileCount(len(cs.lastEdits)))
+	if !scanner.Scan() {
+		return
+	}
+	if answer := strings.ToLower(strings.TrimSpace(scanner.Text())); answer != "y" && answer != "yes" {
+		fmt.Println("Use /apply to apply them later.")
+		return
+	}
+	cs.applyEdits()
+}
+
+// applyEdits applies the edits of the last response, for /apply
+func (cs *ChatSession) applyEdits() {
+	if len(cs.lastEdits) == 0 {
+		fmt.Println("The last response has no edits to apply.")
+		return
+	}
+	cfg := config.Get()
+	if cfg == nil {
+		fmt.Println("Error: configuration not loaded")
+		return
+	}
+
+	backups, err := fileedit.Apply(cs.commandRouter.workingDir, cs.lastEdits, cfg.EditBackupDir())
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	applied := len(cs.lastEdits)
+	cs.lastEdits = nil
+	if cs.quiet {
+		return
+	}
+	fmt.Printf("Changed %s.", fileCount(applied))
+	if backups != "" {
+		fmt.Printf(" The originals are in %s", backups)
+	}
+	fmt.Println()
+}
+
+func fileCount(n int) string {
+	if n == 1 {
+		return "1 file"
+	}
+	return fmt.Sprintf("%d files", n)
+}
@@ -0,0 +1,47 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/storage"
+)
+
+// rateLastResponse stores a rating with the last assistant message of the
+// session, for /good and /bad
+func (cs *ChatSession) rateLastResponse(rating, reason string) {
+	if err := cs.saveFeedback(rating, reason); err != nil {
+		if !cs.quiet {
+			fmt.Printf("Feedback not saved: %v\n", err)
+		}
+		return
+	}
+	if !cs.quiet {
+		fmt.Printf("Rated the last response as %s\n", rating)
+	}
+}
+
+// saveFeedback rates the last assistant message and reports the rating to
+// a running canary experiment
+func (cs *ChatSession) saveFeedback(rating, reason string) error {
+	if cs.store == nil {
+		return fmt.Errorf("this conversation isn't saved")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	msg, err := storage.LastAssistantMessage(ctx, cs.store, cs.follower.SessionID())
+	if err != nil {
+		return err
+	}
+	model, _ := msg.Metadata["model"].(string)
+	feedback := &storage.Feedback{Rating: rating, Reason: reason, Model: model, CreatedAt: time.Now()}
+	if err := cs.store.SetMessageFeedback(ctx, msg.ID, feedback); err != nil {
+		return err
+	}
+
+	cs.RecordCanaryFeedback(rating == storage.FeedbackGood)
+	return nil
+}
@@ -0,0 +1,136 @@
+package chat
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/git"
+)
+
+const gitUsage = "Usage: /git status | diff [--staged] | add [PATH...] | reset [PATH...] | commit [MESSAGE]"
+
+// handleGit runs /git: showing the status and diffs of the project's
+// repository, staging files and committing with a message the current
+// model writes from the staged diff
+func (cs *ChatSession) handleGit(args []string) {
+	if len(args) == 0 {
+		fmt.Println(gitUsage)
+		return
+	}
+	if !git.IsGitInstalled() {
+		fmt.Println("Git is not installed on this system")
+		return
+	}
+	repo := git.NewRepository(cs.commandRouter.workingDir)
+	if !repo.IsGitRepository() {
+		fmt.Println("This directory is not a git repository")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	var err error
+	switch args[0] {
+	case "status":
+		var status *git.GitStatus
+		if status, err = repo.GetStatus(ctx); err == nil {
+			fmt.Print(formatGitStatus(status))
+		}
+	case "diff":
+		staged := len(args) > 1 && (args[1] == "--staged" || args[1] == "--cached")
+		var patch string
+		if patch, err = repo.Patch(ctx, staged); err == nil {
+			if patch == "" {
+				patch = "No changes.\n"
+			}
+			fmt.Print(patch)
+		}
+	case "add":
+		if err = repo.Stage(ctx, args[1:]...); err == nil && !cs.quiet {
+			fmt.Println("Staged.")
+		}
+	case "reset":
+		if err = repo.Unstage(ctx, args[1:]...); err == nil && !cs.quiet {
+			fmt.Println("Unstaged.")
+		}
+	case "commit":
+		err = cs.gitCommit(ctx, repo, strings.Join(args[1:], " "))
+	default:
+		fmt.Println(gitUsage)
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+	}
+}
+
+// gitCommit commits the staged changes with message, or with one the
+// current model writes from the staged diff once the user accepts it
+func (cs *ChatSession) gitCommit(ctx context.Context, repo *git.Repository, message string) error {
+	if message == "" {
+		if !cs.quiet {
+			fmt.Println("Writing a commit message for the staged changes...")
+		}
+		cs.refreshKey()
+		generated, err := git.NewCommitMessageGeneratorFor(cs.handler).GenerateCommitMessage(ctx, repo, true)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("\n%s\n\nCommit with this message? [y/N] ", generated)
+		scanner := bufio.NewScanner(os.Stdin)
+		if !scanner.Scan() {
+			return nil
+		}
+		if answer := strings.ToLower(strings.TrimSpace(scanner.Text())); answer != "y" && answer != "yes" {
+			fmt.Println("Not committed.")
+			return nil
+		}
+		message = generated
+	}
+
+	commit, err := repo.Commit(ctx, message)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Committed %s %s\n", commit.ShortHash, commit.Subject)
+	return nil
+}
+
+// formatGitStatus lists a status's files by state, as git status does
+func formatGitStatus(status *git.GitStatus) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "On branch %s", status.Branch)
+	if status.Ahead > 0 || status.Behind > 0 {
+		fmt.Fprintf(&sb, " (%d ahead, %d behind)", status.Ahead, status.Behind)
+	}
+	sb.WriteString("\n")
+	sections := []struct {
+		title string
+		files []string
+	}{
+		{"Staged", status.Staged},
+		{"Modified", status.Modified},
+		{"Deleted", status.Deleted},
+		{"Renamed", status.Renamed},
+		{"Untracked", status.Untracked},
+	}
+	clean := true
+	for _, section := range sections {
+		if len(section.files) == 0 {
+			continue
+		}
+		clean = false
+		fmt.Fprintf(&sb, "%s:\n", section.title)
+		for _, file := range section.files {
+			fmt.Fprintf(&sb, "  %s\n", file)
+		}
+	}
+	if clean {
+		sb.WriteString("Nothing to commit, working tree clean\n")
+	}
+	return sb.String()
+}
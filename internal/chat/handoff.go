@@ -0,0 +1,171 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+	"github.com/entrepeneur4lyf/codeforge/internal/storage"
+	"github.com/google/uuid"
+)
+
+// handoffClient tags messages this session persists
+const handoffClient = "cli"
+
+// sessionLockPoll is how often a terminal waiting on a busy session retries
+const sessionLockPoll = 500 * time.Millisecond
+
+// AttachStore persists the conversation to store under sessionID, creating
+// the session if needed and loading its earlier messages, so it can be
+// continued from the web UI or another terminal. It returns the number of
+// messages loaded.
+func (cs *ChatSession) AttachStore(store storage.ChatStore, sessionID string) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := store.GetSession(ctx, sessionID); err != nil {
+		now := time.Now()
+		session := &storage.Session{
+			ID:        sessionID,
+			Title:     "CLI Chat",
+			Model:     cs.model,
+			CreatedAt: now,
+			UpdatedAt: now,
+			Metadata:  map[string]interface{}{"created_by": handoffClient},
+		}
+		if err := store.CreateSession(ctx, session); err != nil {
+			return 0, fmt.Errorf("failed to create session %s: %w", sessionID, err)
+		}
+	}
+
+	cs.store = store
+	cs.follower = storage.NewFollower(store, sessionID)
+	history, err := cs.follower.Poll(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load session %s: %w", sessionID, err)
+	}
+	cs.LoadHistory(history)
+	return len(history), nil
+}
+
+// StoreSessionID returns the persisted session ID, or "" when the
+// conversation isn't persisted
+func (cs *ChatSession) StoreSessionID() string {
+	if cs.follower == nil {
+		return ""
+	}
+	return cs.follower.SessionID()
+}
+
+// persistExchange saves a user message and its response to the store
+func (cs *ChatSession) persistExchange(input, response string) {
+	if cs.store == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for _, m := range []struct{ role, content string }{{"user", input}, {"assistant", response}} {
+		msg := &storage.Message{
+			ID:        uuid.New().String(),
+			SessionID: cs.follower.SessionID(),
+			Role:      m.role,
+			Content:   m.content,
+			CreatedAt: time.Now(),
+			Metadata:  map[string]interface{}{"client": handoffClient, "model": cs.model},
+		}
+		if m.role == "assistant" && cs.lastUsage != nil {
+			msg.Tokens = cs.lastUsage.TotalTokens
+			msg.Metadata["input_tokens"] = cs.lastUsage.PromptTokens
+			msg.Metadata["output_tokens"] = cs.lastUsage.CompletionTokens
+			if cs.lastUsage.TotalCost > 0 {
+				msg.Metadata["cost"] = cs.lastUsage.TotalCost
+			}
+		}
+		if err := cs.store.SaveMessage(ctx, msg); err != nil {
+			fmt.Printf("Warning: failed to save message: %v\n", err)
+			return
+		}
+		cs.follower.MarkSeen(msg.ID)
+	}
+}
+
+// lockStoreSession waits until no other client is generating a response in
+// the persisted session and takes its lock, returning the function that
+// releases it. Conversations that aren't persisted aren't shared and need no
+// lock.
+func (cs *ChatSession) lockStoreSession() func() {
+	if cs.follower == nil {
+		return func() {}
+	}
+
+	waited := false
+	for {
+		lock, err := storage.LockSession(cs.follower.SessionID(), handoffClient)
+		if err == nil {
+			if waited {
+				// Answer after the exchange that was running, not alongside it
+				cs.syncFromStore()
+			}
+			return lock.Release
+		}
+		if !errors.Is(err, storage.ErrSessionBusy) {
+			fmt.Printf("Warning: failed to lock session: %v\n", err)
+			return func() {}
+		}
+		if !waited && !cs.quiet {
+			fmt.Printf("Waiting: %v\n", err)
+		}
+		waited = true
+		time.Sleep(sessionLockPoll)
+	}
+}
+
+// syncFromStore adds and shows messages other clients wrote to the session
+func (cs *ChatSession) syncFromStore() {
+	if cs.follower == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	messages, err := cs.follower.Poll(ctx)
+	if err != nil {
+		return
+	}
+	for _, msg := range messages {
+		cs.appendStoredMessage(msg)
+		if cs.quiet {
+			continue
+		}
+		client := storage.MessageClient(msg)
+		if client == "" {
+			client = "other client"
+		}
+		fmt.Printf("[%s] %s: %s\n\n", client, msg.Role, strings.TrimSpace(msg.Content))
+	}
+}
+
+// LoadHistory adds persisted messages to the conversation history, for
+// continuing a session another client started
+func (cs *ChatSession) LoadHistory(messages []storage.Message) {
+	for _, msg := range messages {
+		cs.appendStoredMessage(msg)
+	}
+}
+
+// appendStoredMessage adds a persisted message to the conversation history
+func (cs *ChatSession) appendStoredMessage(msg storage.Message) {
+	if msg.Role != "user" && msg.Role != "assistant" {
+		return
+	}
+	cs.messages = append(cs.messages, llm.Message{
+		Role:    msg.Role,
+		Content: []llm.ContentBlock{llm.TextBlock{Text: msg.Content}},
+	})
+}
@@ -0,0 +1,52 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/embeddings"
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+)
+
+const hydePrompt = `You help a code search engine. Write a short code snippet, at most 20 lines, that would be a good match for the search query: the kind of function, type or call the user is probably looking for. Use the language the query suggests, or Go if it doesn't say. Reply with the code only, without explanations or markdown fences.`
+
+// NewCodeWriter returns an embeddings.CodeWriter that asks model for a
+// hypothetical snippet answering a search query
+func NewCodeWriter(model string) embeddings.CodeWriter {
+	return func(ctx context.Context, query string) (string, error) {
+		handler, err := NewHandlerForModel(model, GetAPIKeyForModel(model), "")
+		if err != nil {
+			return "", err
+		}
+
+		messages := []llm.Message{{
+			Role:    "user",
+			Content: []llm.ContentBlock{llm.TextBlock{Text: query}},
+		}}
+		stream, err := handler.CreateMessage(ctx, hydePrompt, messages)
+		if err != nil {
+			return "", fmt.Errorf("failed to write code for %q: %w", query, err)
+		}
+
+		var snippet strings.Builder
+		for chunk := range stream {
+			if text, ok := chunk.(llm.ApiStreamTextChunk); ok {
+				snippet.WriteString(text.Text)
+			}
+		}
+		return stripFences(snippet.String()), nil
+	}
+}
+
+// stripFences removes a markdown code fence around a reply
+func stripFences(reply string) string {
+	reply = strings.TrimSpace(reply)
+	if !strings.HasPrefix(reply, "```") {
+		return reply
+	}
+	if _, body, ok := strings.Cut(reply, "\n"); ok {
+		reply = body
+	}
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(reply), "```"))
+}
@@ -0,0 +1,64 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/issues"
+)
+
+// attachIssue fetches an issue of the project's repository and keeps it in
+// context for the rest of the session, for /issue
+func (cs *ChatSession) attachIssue(args []string) {
+	if len(args) != 1 {
+		fmt.Println("Usage: /issue NUMBER")
+		return
+	}
+	number, err := strconv.Atoi(strings.TrimPrefix(args[0], "#"))
+	if err != nil || number <= 0 {
+		fmt.Printf("Error: %q is not an issue number\n", args[0])
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	tracker, err := issues.Detect(ctx, cs.commandRouter.workingDir)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	issue, err := tracker.Issue(ctx, number)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	// Fetching an issue again refreshes it
+	for i, attached := range cs.issues {
+		if attached.Number == number {
+			cs.issues = append(cs.issues[:i], cs.issues[i+1:]...)
+			break
+		}
+	}
+	cs.issues = append(cs.issues, issue)
+
+	if !cs.quiet {
+		fmt.Printf("Added issue #%d to the context: %s (%s, %d comments)\n", issue.Number, issue.Title, issue.State, len(issue.Comments))
+	}
+}
+
+// issueContext returns the issues added with /issue, formatted for a prompt
+func (cs *ChatSession) issueContext() string {
+	if len(cs.issues) == 0 {
+		return ""
+	}
+	parts := make([]string, len(cs.issues))
+	for i, issue := range cs.issues {
+		parts[i] = issue.Context()
+	}
+	return "# Issues\n\n" + strings.Join(parts, "\n")
+}
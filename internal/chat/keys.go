@@ -0,0 +1,27 @@
+package chat
+
+import (
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+	"github.com/entrepeneur4lyf/codeforge/internal/logging"
+)
+
+// refreshKey rebuilds the session's handler when a provider key was
+// replaced since it was built, so rotated keys apply from the next message.
+// A canary candidate in use is left alone until the message finishes.
+func (cs *ChatSession) refreshKey() {
+	version := config.KeyVersion()
+	if version == cs.keyVersion || cs.baseline != nil {
+		return
+	}
+	cs.keyVersion = version
+
+	// Candidates are rebuilt with the new keys when next routed to
+	clear(cs.canaryHandlers)
+
+	handler, err := NewHandlerForModel(cs.model, GetAPIKeyForModel(cs.model), cs.provider)
+	if err != nil {
+		logging.Warn("Keeping the previous API key", "model", cs.model, "error", err)
+		return
+	}
+	cs.handler = handler
+}
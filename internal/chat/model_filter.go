@@ -0,0 +1,97 @@
+package chat
+
+import (
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/lithammer/fuzzysearch/fuzzy"
+)
+
+// updateFilter handles a key while the model filter is open, reporting
+// whether it was used up by the filter
+func (ms *ModelSelector) updateFilter(msg tea.KeyMsg) (tea.Cmd, bool) {
+	switch msg.String() {
+	case "ctrl+c", "up", "down", "enter":
+		// Navigation, selection and quitting work as without the filter
+		return nil, false
+
+	case "esc":
+		ms.clearFilter()
+		return nil, true
+
+	case "backspace":
+		// Backspacing past the start closes the filter
+		if ms.filter.Value() == "" {
+			ms.clearFilter()
+			return nil, true
+		}
+	}
+
+	before := ms.filter.Value()
+	var cmd tea.Cmd
+	ms.filter, cmd = ms.filter.Update(msg)
+	if ms.filter.Value() != before {
+		ms.selectedIndex = 0
+	}
+	return cmd, true
+}
+
+// clearFilter closes the model filter and shows every model again
+func (ms *ModelSelector) clearFilter() {
+	ms.filtering = false
+	ms.filter.Reset()
+	ms.filter.Blur()
+	ms.selectedIndex = 0
+}
+
+// visibleModels returns the indexes into ms.models of the models the filter
+// matches, best match first, or of all models when there's no filter
+func (ms *ModelSelector) visibleModels() []int {
+	query := strings.TrimSpace(ms.filter.Value())
+	visible := make([]int, 0, len(ms.models))
+	distances := make(map[int]int, len(ms.models))
+	for i, model := range ms.models {
+		if query == "" {
+			visible = append(visible, i)
+			continue
+		}
+		if distance, ok := matchModel(query, model); ok {
+			visible = append(visible, i)
+			distances[i] = distance
+		}
+	}
+	sort.SliceStable(visible, func(a, b int) bool {
+		return distances[visible[a]] < distances[visible[b]]
+	})
+	return visible
+}
+
+// selectedModel returns the highlighted model among those shown
+func (ms *ModelSelector) selectedModel() (ModelInfo, bool) {
+	visible := ms.visibleModels()
+	if ms.selectedIndex >= len(visible) {
+		return ModelInfo{}, false
+	}
+	return ms.models[visible[ms.selectedIndex]], true
+}
+
+// matchModel fuzzy matches each word of a query against a model's name, ID
+// and provider, returning the summed distance of the closest fields. The
+// words may match different fields, as in "anthropic haiku".
+func matchModel(query string, model ModelInfo) (int, bool) {
+	total := 0
+	for _, word := range strings.Fields(query) {
+		best := -1
+		for _, field := range []string{model.Name, model.ID, model.Provider} {
+			if distance := fuzzy.RankMatchFold(word, field); distance >= 0 && (best < 0 || distance < best) {
+				best = distance
+			}
+		}
+		if best < 0 {
+			return 0, false
+		}
+		total += best
+	}
+	return total, true
+}
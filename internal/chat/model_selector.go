@@ -4,15 +4,21 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
 	"unicode"
 
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
 	"github.com/entrepeneur4lyf/codeforge/internal/llm/providers"
+	"github.com/entrepeneur4lyf/codeforge/internal/models"
+	"github.com/entrepeneur4lyf/codeforge/internal/platform"
 	"github.com/entrepeneur4lyf/codeforge/internal/vectordb"
 )
 
@@ -29,6 +35,8 @@ type ModelSelector struct {
 	result            chan SelectionResult
 	loading           bool
 	loadingMessage    string
+	filtering         bool            // Typing into the model filter
+	filter            textinput.Model // Narrows the model list as it's typed
 }
 
 type SelectionMode int
@@ -67,6 +75,8 @@ type ModelInfo struct {
 	InputPrice    float64
 	OutputPrice   float64
 	Capabilities  []string
+	Modality      string // Inputs and outputs, as "text+image->text"
+	Warning       string // Why a local model may not run well on this machine
 }
 
 type SelectionResult struct {
@@ -101,14 +111,31 @@ var (
 	helpStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("241")).
 			MarginTop(1)
+
+	bannerStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("214")).
+			Bold(true)
+
+	detailStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("241")).
+			Padding(0, 1).
+			Width(64)
 )
 
 // NewModelSelector creates a new model selector
 func NewModelSelector(favorites *Favorites) *ModelSelector {
+	filter := textinput.New()
+	filter.Prompt = "/ "
+	filter.Placeholder = "filter by name, ID or provider"
+	filter.CharLimit = 100
+	filter.Cursor.SetMode(cursor.CursorStatic)
+
 	ms := &ModelSelector{
 		favorites: favorites,
 		mode:      SelectingProvider,
 		result:    make(chan SelectionResult, 1),
+		filter:    filter,
 	}
 	ms.loadOpenRouterFilters()
 	return ms
@@ -119,6 +146,10 @@ func (ms *ModelSelector) SelectModel() (string, string, error) {
 	// Load providers
 	ms.loadProviders()
 
+	if plainOutput {
+		return ms.selectPlain(os.Stdin, os.Stdout)
+	}
+
 	// Start the TUI
 	p := tea.NewProgram(ms, tea.WithAltScreen())
 	if _, err := p.Run(); err != nil {
@@ -136,14 +167,34 @@ func (ms *ModelSelector) SelectModel() (string, string, error) {
 
 // loadProviders loads available providers and checks their availability
 func (ms *ModelSelector) loadProviders() {
-	providerNames := []string{"anthropic", "openai", "gemini", "groq", "github", "openrouter"}
+	var providerNames []string
+	for _, spec := range config.ListProviders() {
+		// Local servers other than Ollama are listed when found, below,
+		// unless the config sets where one listens
+		if spec.Local && spec.ID != "ollama" && spec.BaseURL == "" {
+			continue
+		}
+		providerNames = append(providerNames, spec.ID)
+	}
+
+	// Local servers found at startup are listed too
+	for _, server := range providers.LocalServers() {
+		if !slices.Contains(providerNames, server.ID) {
+			providerNames = append(providerNames, server.ID)
+		}
+	}
 
 	for _, name := range providerNames {
 		available := ms.isProviderAvailable(name)
 		favorite := ms.favorites.IsProviderFavorite(name)
 
+		label := providerLabel(name)
+		if _, ok := providers.LocalServerByID(name); ok {
+			label += " (local)"
+		}
+
 		ms.providers = append(ms.providers, ProviderInfo{
-			Name:      titleCase(name),
+			Name:      label,
 			ID:        name,
 			Available: available,
 			Favorite:  favorite,
@@ -273,24 +324,42 @@ func titleCase(s string) string {
 	return string(runes)
 }
 
-// isProviderAvailable checks if a provider has an API key
+// providerLabel returns the display name of a provider
+func providerLabel(provider string) string {
+	if spec, ok := config.LookupProvider(provider); ok && spec.Name != "" {
+		return spec.Name
+	}
+	return titleCase(provider)
+}
+
+// unavailableReason explains why a provider can't be selected
+func unavailableReason(provider string) string {
+	switch provider {
+	case "ollama":
+		return "not running"
+	case "copilot":
+		return "run codeforge copilot login"
+	}
+	return "no API key"
+}
+
+// isProviderAvailable checks if a provider has an API key, or for local
+// providers whether the server is running
 func (ms *ModelSelector) isProviderAvailable(provider string) bool {
 	switch provider {
-	case "anthropic":
-		return os.Getenv("ANTHROPIC_API_KEY") != ""
-	case "openai":
-		return os.Getenv("OPENAI_API_KEY") != ""
-	case "gemini":
-		return os.Getenv("GEMINI_API_KEY") != ""
-	case "groq":
-		return os.Getenv("GROQ_API_KEY") != ""
-	case "github":
-		return os.Getenv("GITHUB_TOKEN") != ""
-	case "openrouter":
-		return os.Getenv("OPENROUTER_API_KEY") != ""
-	default:
-		return false
+	case "copilot":
+		return providers.CopilotToken() != ""
+	case "ollama":
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		_, err := providers.ListOllamaModels(ctx)
+		return err == nil
 	}
+	if _, ok := providers.LocalServerByID(provider); ok {
+		return true
+	}
+	spec, ok := config.LookupProvider(provider)
+	return ok && !spec.Local && spec.APIKey() != ""
 }
 
 // loadModels loads models for the selected provider asynchronously
@@ -309,8 +378,14 @@ func (ms *ModelSelector) loadModels(providerID string) tea.Cmd {
 			models = ms.loadAnthropicModels(providerID)
 		case "openai":
 			models = ms.loadOpenAIModels(providerID)
+		case "ollama":
+			models = ms.loadOllamaModels(providerID)
 		default:
-			models = ms.loadDefaultModels(providerID)
+			if _, ok := providers.LocalServerByID(providerID); ok {
+				models = ms.loadLocalServerModels(providerID)
+			} else {
+				models = ms.loadDefaultModels(providerID)
+			}
 		}
 
 		// Sort models: favorites first, then alphabetically
@@ -337,6 +412,7 @@ func (ms *ModelSelector) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		ms.loading = false
 		ms.models = []ModelInfo(msg)
 		ms.selectedIndex = 0
+		ms.clearFilter()
 		return ms, nil
 
 	case loadingMsg:
@@ -344,7 +420,20 @@ func (ms *ModelSelector) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return ms, nil
 
 	case tea.KeyMsg:
+		if ms.filtering {
+			if cmd, handled := ms.updateFilter(msg); handled {
+				return ms, cmd
+			}
+		}
+
 		switch msg.String() {
+		case "/":
+			// Type to filter the model list
+			if ms.mode == SelectingModel && !ms.loading {
+				ms.filtering = true
+				return ms, ms.filter.Focus()
+			}
+
 		case "ctrl+c", "q", "esc":
 			ms.result <- SelectionResult{Canceled: true}
 			return ms, tea.Quit
@@ -362,7 +451,7 @@ func (ms *ModelSelector) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case SelectingOpenRouterFilter:
 				maxIndex = len(ms.openRouterFilters) - 1
 			default:
-				maxIndex = len(ms.models) - 1
+				maxIndex = len(ms.visibleModels()) - 1
 			}
 			if ms.selectedIndex < maxIndex {
 				ms.selectedIndex++
@@ -400,8 +489,7 @@ func (ms *ModelSelector) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			default:
 				// Select model and finish (SelectingModel mode)
-				if !ms.loading && ms.selectedIndex < len(ms.models) {
-					model := ms.models[ms.selectedIndex]
+				if model, ok := ms.selectedModel(); ok && !ms.loading {
 					ms.result <- SelectionResult{
 						Provider: model.Provider,
 						Model:    model.ID,
@@ -424,8 +512,8 @@ func (ms *ModelSelector) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					}
 				}
 			} else {
-				if ms.selectedIndex < len(ms.models) {
-					model := &ms.models[ms.selectedIndex]
+				if visible := ms.visibleModels(); ms.selectedIndex < len(visible) {
+					model := &ms.models[visible[ms.selectedIndex]]
 					model.Favorite = !model.Favorite
 					if model.Favorite {
 						ms.favorites.AddModelFavorite(model.ID)
@@ -445,6 +533,7 @@ func (ms *ModelSelector) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					ms.mode = SelectingProvider
 				}
 				ms.selectedIndex = 0
+				ms.clearFilter()
 			case SelectingOpenRouterFilter:
 				ms.mode = SelectingProvider
 				ms.selectedIndex = 0
@@ -468,7 +557,7 @@ func (ms *ModelSelector) View() string {
 
 			// Add favorite indicator
 			if provider.Favorite {
-				line += favoriteStyle.Render("★ ")
+				line += favoriteStyle.Render(Icon("★"))
 			} else {
 				line += "  "
 			}
@@ -477,7 +566,7 @@ func (ms *ModelSelector) View() string {
 			if provider.Available {
 				line += availableStyle.Render(provider.Name)
 			} else {
-				line += unavailableStyle.Render(provider.Name + " (no API key)")
+				line += unavailableStyle.Render(provider.Name + " (" + unavailableReason(provider.ID) + ")")
 			}
 
 			// Highlight selected item
@@ -492,7 +581,7 @@ func (ms *ModelSelector) View() string {
 		b.WriteString(helpStyle.Render("↑/↓: navigate • enter: select • space: favorite • q: quit"))
 
 	case SelectingOpenRouterFilter:
-		b.WriteString(titleStyle.Render("🌐 OpenRouter - Select Provider Filter"))
+		b.WriteString(titleStyle.Render(Icon("🌐") + "OpenRouter - Select Provider Filter"))
 		b.WriteString("\n\n")
 
 		for i, filter := range ms.openRouterFilters {
@@ -513,6 +602,16 @@ func (ms *ModelSelector) View() string {
 		b.WriteString(titleStyle.Render("Select Model"))
 		b.WriteString("\n\n")
 
+		// Warn when the catalog was served from an expired cache
+		if status, ok := providers.GetCatalogStatus(ms.selectedProvider); ok && status.Stale && !ms.loading {
+			b.WriteString(bannerStyle.Render(Icon("⚠")+status.Banner()) + "\n\n")
+		}
+
+		// Local model fit depends on this machine's memory
+		if isLocalProvider(ms.selectedProvider) {
+			b.WriteString(helpStyle.Render("This machine: "+platform.DetectHardware().Summary()) + "\n\n")
+		}
+
 		if ms.loading {
 			// Show loading state
 			b.WriteString("  " + ms.loadingMessage + "\n")
@@ -523,18 +622,30 @@ func (ms *ModelSelector) View() string {
 			b.WriteString("  No models available\n\n")
 			b.WriteString(helpStyle.Render("backspace: back • q: quit"))
 		} else {
+			visible := ms.visibleModels()
+			if ms.filtering || ms.filter.Value() != "" {
+				b.WriteString(ms.filter.View() + "\n\n")
+			}
+			if len(visible) == 0 {
+				b.WriteString("  No models match\n")
+			}
+
 			// Show models
-			for i, model := range ms.models {
+			for i, index := range visible {
+				model := ms.models[index]
 				line := ""
 
 				// Add favorite indicator
 				if model.Favorite {
-					line += favoriteStyle.Render("★ ")
+					line += favoriteStyle.Render(Icon("★"))
 				} else {
 					line += "  "
 				}
 
 				line += model.Name
+				if model.Warning != "" {
+					line += unavailableStyle.Render(" (" + model.Warning + ")")
+				}
 
 				// Highlight selected item
 				if i == ms.selectedIndex {
@@ -544,14 +655,60 @@ func (ms *ModelSelector) View() string {
 				b.WriteString(line + "\n")
 			}
 
+			// Details of the highlighted model, to compare before choosing
+			if selected, ok := ms.selectedModel(); ok {
+				b.WriteString("\n" + detailStyle.Render(modelDetails(selected)) + "\n")
+			}
+
 			b.WriteString("\n")
-			b.WriteString(helpStyle.Render("↑/↓: navigate • enter: select • space: favorite • backspace: back • q: quit"))
+			if ms.filtering {
+				b.WriteString(helpStyle.Render("↑/↓: navigate • enter: select • esc: clear filter"))
+			} else {
+				b.WriteString(helpStyle.Render("↑/↓: navigate • /: filter • enter: select • space: favorite • backspace: back • q: quit"))
+			}
 		}
 	}
 
 	return b.String()
 }
 
+// modelDetails describes a model for the detail pane: its ID, context size,
+// pricing, modality and capabilities, leaving out what isn't known
+func modelDetails(model ModelInfo) string {
+	lines := []string{titleStyle.UnsetMarginBottom().Render(model.ID)}
+
+	if model.ContextLength > 0 {
+		lines = append(lines, "Context:  "+formatContextLength(model.ContextLength)+" tokens")
+	}
+	switch {
+	case model.InputPrice > 0 || model.OutputPrice > 0:
+		lines = append(lines, fmt.Sprintf("Price:    $%.2f in • $%.2f out per 1M tokens", model.InputPrice, model.OutputPrice))
+	case isLocalProvider(model.Provider):
+		lines = append(lines, "Price:    free, runs on this machine")
+	}
+	if model.Modality != "" {
+		lines = append(lines, "Modality: "+model.Modality)
+	}
+	if len(model.Capabilities) > 0 {
+		lines = append(lines, "Good at:  "+strings.Join(model.Capabilities, ", "))
+	}
+	if model.Description != "" {
+		lines = append(lines, "", helpStyle.UnsetMarginTop().Render(model.Description))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatContextLength shortens a token count, as 200K or 1M
+func formatContextLength(tokens int) string {
+	switch {
+	case tokens >= 1000000:
+		return strconv.FormatFloat(float64((tokens+50000)/100000)/10, 'f', -1, 64) + "M"
+	case tokens >= 1000:
+		return strconv.Itoa((tokens+500)/1000) + "K"
+	}
+	return strconv.Itoa(tokens)
+}
+
 // addOpenRouterModels fetches and adds OpenRouter models dynamically
 func (ms *ModelSelector) addOpenRouterModels() {
 	// Try to get OpenRouter API key
@@ -602,29 +759,9 @@ func (ms *ModelSelector) addOpenRouterModels() {
 				continue
 			}
 
-			// Extract capabilities from architecture
-			capabilities := []string{"text"}
-			if model.Architecture.Modality != "" {
-				if strings.Contains(model.Architecture.Modality, "image") {
-					capabilities = append(capabilities, "vision")
-				}
-				if strings.Contains(model.Architecture.Modality, "audio") {
-					capabilities = append(capabilities, "audio")
-				}
-			}
-
 			// Use actual OpenRouter metadata
-			modelInfo := ModelInfo{
-				Name:          fmt.Sprintf("[%s] %s", providerName, model.Name),
-				ID:            model.ID,
-				Provider:      "openrouter",
-				Favorite:      ms.favorites.IsModelFavorite(model.ID),
-				Description:   model.Description,
-				ContextLength: model.ContextLength,
-				InputPrice:    parsePrice(model.Pricing.Prompt),
-				OutputPrice:   parsePrice(model.Pricing.Completion),
-				Capabilities:  capabilities,
-			}
+			modelInfo := ms.openRouterModelInfo(model)
+			modelInfo.Name = fmt.Sprintf("[%s] %s", providerName, model.Name)
 			ms.models = append(ms.models, modelInfo)
 			totalModelsAdded++
 		}
@@ -655,6 +792,32 @@ func (ms *ModelSelector) addOpenRouterFallbackModels() {
 	}
 }
 
+// openRouterModelInfo converts an OpenRouter catalog entry, keeping its
+// context size, pricing and modality for the detail pane
+func (ms *ModelSelector) openRouterModelInfo(model providers.OpenRouterModel) ModelInfo {
+	// Extract capabilities from architecture
+	capabilities := []string{"text"}
+	if strings.Contains(model.Architecture.Modality, "image") {
+		capabilities = append(capabilities, "vision")
+	}
+	if strings.Contains(model.Architecture.Modality, "audio") {
+		capabilities = append(capabilities, "audio")
+	}
+
+	return ModelInfo{
+		Name:          model.Name,
+		ID:            model.ID,
+		Provider:      "openrouter",
+		Favorite:      ms.favorites.IsModelFavorite(model.ID),
+		Description:   model.Description,
+		ContextLength: model.ContextLength,
+		InputPrice:    parsePrice(model.Pricing.Prompt),
+		OutputPrice:   parsePrice(model.Pricing.Completion),
+		Capabilities:  capabilities,
+		Modality:      model.Architecture.Modality,
+	}
+}
+
 // parsePrice converts OpenRouter price string to float64
 func parsePrice(priceStr string) float64 {
 	if priceStr == "" {
@@ -704,17 +867,7 @@ func (ms *ModelSelector) loadOpenRouterModels() []ModelInfo {
 				continue
 			}
 
-			// Use basic model information from database
-			modelInfo := ModelInfo{
-				Name:          model.Name,
-				ID:            model.ID,
-				Provider:      "openrouter",
-				Favorite:      ms.favorites.IsModelFavorite(model.ID),
-				Description:   model.Description,
-				ContextLength: model.ContextLength,
-				Capabilities:  []string{"text"}, // Default capability
-			}
-			modelInfos = append(modelInfos, modelInfo)
+			modelInfos = append(modelInfos, ms.openRouterModelInfo(model))
 		}
 
 		return modelInfos
@@ -741,17 +894,7 @@ func (ms *ModelSelector) loadOpenRouterModels() []ModelInfo {
 			continue
 		}
 
-		// Use basic model information from database
-		modelInfo := ModelInfo{
-			Name:          model.Name,
-			ID:            model.ID,
-			Provider:      "openrouter",
-			Favorite:      ms.favorites.IsModelFavorite(model.ID),
-			Description:   model.Description,
-			ContextLength: model.ContextLength,
-			Capabilities:  []string{"text"}, // Default capability
-		}
-		modelInfos = append(modelInfos, modelInfo)
+		modelInfos = append(modelInfos, ms.openRouterModelInfo(model))
 	}
 
 	return modelInfos
@@ -777,6 +920,83 @@ func (ms *ModelSelector) loadDefaultModels(providerID string) []ModelInfo {
 				InputPrice: 5.0, OutputPrice: 15.0, Capabilities: []string{"text", "code", "vision"},
 			},
 		},
+		"groq": {
+			{
+				Name: "Llama 3.3 70B Versatile", ID: "groq/llama-3.3-70b-versatile", Provider: providerID,
+				Description: "Llama 3.3 70B on Groq", ContextLength: 128000,
+				InputPrice: 0.59, OutputPrice: 0.79, Capabilities: []string{"text", "code"},
+			},
+		},
+		"xai": {
+			{
+				Name: "Grok 3", ID: "xai/grok-3", Provider: providerID,
+				Description: "xAI's flagship model", ContextLength: 131072,
+				InputPrice: 3.0, OutputPrice: 15.0, Capabilities: []string{"text", "code", "reasoning"},
+			},
+			{
+				Name: "Grok 3 Mini", ID: "xai/grok-3-mini", Provider: providerID,
+				Description: "Small, fast Grok reasoning model", ContextLength: 131072,
+				InputPrice: 0.3, OutputPrice: 0.5, Capabilities: []string{"text", "code", "reasoning"},
+			},
+		},
+		"mistral": {
+			{
+				Name: "Mistral Large", ID: "mistral/mistral-large-latest", Provider: providerID,
+				Description: "Mistral's flagship model", ContextLength: 128000,
+				InputPrice: 2.0, OutputPrice: 6.0, Capabilities: []string{"text", "code"},
+			},
+			{
+				Name: "Codestral", ID: "mistral/codestral-latest", Provider: providerID,
+				Description: "Mistral's code model", ContextLength: 256000,
+				InputPrice: 0.3, OutputPrice: 0.9, Capabilities: []string{"code"},
+			},
+		},
+		"deepseek": {
+			{
+				Name: "DeepSeek V3", ID: "deepseek/deepseek-chat", Provider: providerID,
+				Description: "DeepSeek's chat model", ContextLength: 64000,
+				InputPrice: 0.27, OutputPrice: 1.1, Capabilities: []string{"text", "code"},
+			},
+			{
+				Name: "DeepSeek R1", ID: "deepseek/deepseek-reasoner", Provider: providerID,
+				Description: "DeepSeek's reasoning model", ContextLength: 64000,
+				InputPrice: 0.55, OutputPrice: 2.19, Capabilities: []string{"text", "code", "reasoning"},
+			},
+		},
+		"together": {
+			{
+				Name: "Llama 3.3 70B Instruct Turbo", ID: "together/meta-llama/Llama-3.3-70B-Instruct-Turbo", Provider: providerID,
+				Description: "Llama 3.3 70B on Together AI", ContextLength: 131072,
+				InputPrice: 0.88, OutputPrice: 0.88, Capabilities: []string{"text", "code"},
+			},
+			{
+				Name: "Qwen 2.5 Coder 32B", ID: "together/Qwen/Qwen2.5-Coder-32B-Instruct", Provider: providerID,
+				Description: "Qwen 2.5 Coder on Together AI", ContextLength: 32768,
+				InputPrice: 0.8, OutputPrice: 0.8, Capabilities: []string{"code"},
+			},
+		},
+		"copilot": {
+			{
+				Name: "GPT-4o", ID: "copilot/gpt-4o", Provider: providerID,
+				Description: "GPT-4o through GitHub Copilot", ContextLength: 128000,
+				Capabilities: []string{"text", "code", "vision"},
+			},
+			{
+				Name: "GPT-4.1", ID: "copilot/gpt-4.1", Provider: providerID,
+				Description: "GPT-4.1 through GitHub Copilot", ContextLength: 128000,
+				Capabilities: []string{"text", "code", "vision"},
+			},
+			{
+				Name: "Claude Sonnet 4", ID: "copilot/claude-sonnet-4", Provider: providerID,
+				Description: "Claude Sonnet 4 through GitHub Copilot", ContextLength: 128000,
+				Capabilities: []string{"text", "code", "vision"},
+			},
+			{
+				Name: "Gemini 2.5 Pro", ID: "copilot/gemini-2.5-pro", Provider: providerID,
+				Description: "Gemini 2.5 Pro through GitHub Copilot", ContextLength: 128000,
+				Capabilities: []string{"text", "code", "reasoning"},
+			},
+		},
 	}
 
 	if providerModels, exists := defaults[providerID]; exists {
@@ -789,6 +1009,85 @@ func (ms *ModelSelector) loadDefaultModels(providerID string) []ModelInfo {
 	return models
 }
 
+// loadOllamaModels lists the chat models installed in the local Ollama
+func (ms *ModelSelector) loadOllamaModels(providerID string) []ModelInfo {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	installed, err := providers.ListOllamaModelDetails(ctx)
+	if err != nil {
+		return nil
+	}
+
+	var models []ModelInfo
+	for _, m := range installed {
+		if strings.Contains(m.Name, "embed") {
+			continue
+		}
+		id := "ollama/" + m.Name
+		warning, detail := localModelFit(m.Name, m.Size, m.Details.ParameterSize, m.Details.QuantizationLevel)
+		models = append(models, ModelInfo{
+			Name:         m.Name,
+			ID:           id,
+			Provider:     providerID,
+			Favorite:     ms.favorites.IsModelFavorite(id),
+			Description:  "Local model via Ollama" + detail,
+			Capabilities: []string{"text", "code"},
+			Warning:      warning,
+		})
+	}
+	return models
+}
+
+// isLocalProvider reports whether a provider runs models on this machine
+func isLocalProvider(provider string) bool {
+	spec, ok := config.LookupProvider(provider)
+	return ok && spec.Local
+}
+
+// localModelFit checks a local model against this machine's memory. It
+// returns a short warning for model lists and a detail with a suggested
+// quantization to append to the description; both are empty when the model
+// runs well or can't be sized.
+func localModelFit(name string, size int64, paramSize, quant string) (string, string) {
+	est := models.EstimateLocalModel(name, size, models.ParseParameterSize(paramSize), quant, platform.DetectHardware())
+	if est.Label() == "" {
+		return "", ""
+	}
+	detail := "; " + est.Note
+	if est.Suggestion != "" {
+		detail += "; " + est.Suggestion
+	}
+	return est.Label(), detail
+}
+
+// loadLocalServerModels lists the models of a local server found at startup
+func (ms *ModelSelector) loadLocalServerModels(providerID string) []ModelInfo {
+	server, ok := providers.LocalServerByID(providerID)
+	if !ok {
+		return nil
+	}
+
+	var models []ModelInfo
+	for _, name := range server.Models {
+		if strings.Contains(name, "embed") {
+			continue
+		}
+		id := providerID + "/" + name
+		warning, detail := localModelFit(name, 0, "", "")
+		models = append(models, ModelInfo{
+			Name:         name,
+			ID:           id,
+			Provider:     providerID,
+			Favorite:     ms.favorites.IsModelFavorite(id),
+			Description:  "Local model via " + server.Name + detail,
+			Capabilities: []string{"text", "code"},
+			Warning:      warning,
+		})
+	}
+	return models
+}
+
 // loadOpenAIModels loads OpenAI models from cache (populated by background fetcher)
 func (ms *ModelSelector) loadOpenAIModels(providerID string) []ModelInfo {
 	// Try to get OpenAI API key
@@ -0,0 +1,104 @@
+package chat
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/tui/headless"
+)
+
+func newTestSelector(t *testing.T) *ModelSelector {
+	t.Helper()
+	ms := NewModelSelector(&Favorites{filePath: filepath.Join(t.TempDir(), "favorites.json")})
+	ms.providers = []ProviderInfo{
+		{Name: "Anthropic", ID: "anthropic", Available: true, Favorite: true},
+		{Name: "OpenAI", ID: "openai", Available: true},
+		{Name: "Gemini", ID: "gemini"},
+	}
+	return ms
+}
+
+func TestModelSelector_Snapshot(t *testing.T) {
+	ms := newTestSelector(t)
+	d := headless.New(ms, 80, 20)
+	if err := d.Press("down", "space"); err != nil {
+		t.Fatal(err)
+	}
+	headless.AssertGolden(t, "model_selector_providers", d.View())
+
+	if !ms.favorites.IsProviderFavorite("openai") {
+		t.Error("space should favorite the selected provider")
+	}
+
+	d.Send(modelsLoadedMsg{
+		{Name: "Claude Sonnet", ID: "claude-sonnet", Provider: "anthropic", Favorite: true},
+		{Name: "Claude Haiku", ID: "claude-haiku", Provider: "anthropic", ContextLength: 200000,
+			InputPrice: 0.8, OutputPrice: 4, Modality: "text+image->text", Capabilities: []string{"text", "vision"}},
+	})
+	ms.mode = SelectingModel
+	ms.selectedProvider = "anthropic"
+	if err := d.Press("down"); err != nil {
+		t.Fatal(err)
+	}
+	headless.AssertGolden(t, "model_selector_models", d.View())
+
+	if err := d.Press("enter"); err != nil {
+		t.Fatal(err)
+	}
+	if !d.Quit() {
+		t.Fatal("selecting a model should quit")
+	}
+	if got := <-ms.result; got.Model != "claude-haiku" || got.Provider != "anthropic" {
+		t.Errorf("result = %+v, want anthropic/claude-haiku", got)
+	}
+}
+
+func TestModelSelector_Filter(t *testing.T) {
+	ms := newTestSelector(t)
+	ms.mode = SelectingModel
+	d := headless.New(ms, 80, 20)
+	d.Send(modelsLoadedMsg{
+		{Name: "GPT-4o", ID: "openai/gpt-4o", Provider: "openrouter"},
+		{Name: "Claude Sonnet", ID: "anthropic/claude-sonnet", Provider: "openrouter"},
+		{Name: "Claude Haiku", ID: "anthropic/claude-haiku", Provider: "openrouter"},
+	})
+
+	if err := d.Press("/"); err != nil {
+		t.Fatal(err)
+	}
+	d.Type("clhku")
+	if visible := ms.visibleModels(); len(visible) != 1 || ms.models[visible[0]].ID != "anthropic/claude-haiku" {
+		t.Errorf("visible = %v, want only Claude Haiku", visible)
+	}
+
+	// Esc clears the filter rather than quitting
+	if err := d.Press("esc"); err != nil {
+		t.Fatal(err)
+	}
+	if d.Quit() || ms.filtering || len(ms.visibleModels()) != 3 {
+		t.Fatalf("esc should only clear the filter")
+	}
+
+	// The query can span fields
+	if err := d.Press("/"); err != nil {
+		t.Fatal(err)
+	}
+	d.Type("openai gpt")
+	if err := d.Press("enter"); err != nil {
+		t.Fatal(err)
+	}
+	if !d.Quit() {
+		t.Fatal("selecting a filtered model should quit")
+	}
+	if got := <-ms.result; got.Model != "openai/gpt-4o" {
+		t.Errorf("result = %+v, want openai/gpt-4o", got)
+	}
+}
+
+func TestFormatContextLength(t *testing.T) {
+	for tokens, want := range map[int]string{512: "512", 131072: "131K", 200000: "200K", 1000000: "1M", 1048576: "1M", 1500000: "1.5M", 2000000: "2M"} {
+		if got := formatContextLength(tokens); got != want {
+			t.Errorf("formatContextLength(%d) = %q, want %q", tokens, got, want)
+		}
+	}
+}
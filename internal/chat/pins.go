@@ -0,0 +1,193 @@
+package chat
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/fileutil"
+	"github.com/entrepeneur4lyf/codeforge/internal/tokens"
+)
+
+// DefaultPinnedBudget is the token budget for pinned files when none is configured
+const DefaultPinnedBudget = 8000
+
+// Pin is a file kept in context for every prompt of a session
+type Pin struct {
+	Path       string // Relative to the working directory
+	FromConfig bool   // Pinned by context.pinnedFiles or a session template rather than /pin
+}
+
+// PinStatus describes how a pin fits in the token budget
+type PinStatus struct {
+	Pin
+	Tokens   int
+	Included bool
+	Err      error
+}
+
+// Pins tracks the pinned files of a chat session
+type Pins struct {
+	root   string
+	budget int
+	pins   []Pin
+}
+
+// NewPins creates the pin set for a session, starting with configured pins.
+// Configured pins that don't exist are ignored.
+func NewPins(root string, configured []string, budget int) *Pins {
+	if budget <= 0 {
+		budget = DefaultPinnedBudget
+	}
+	p := &Pins{root: root, budget: budget}
+	p.addConfigured(configured)
+	return p
+}
+
+// addConfigured pins files named by configuration, skipping ones that don't
+// exist or are already pinned
+func (p *Pins) addConfigured(paths []string) {
+	for _, path := range paths {
+		if rel, err := p.resolve(path); err == nil && !p.has(rel) {
+			p.pins = append(p.pins, Pin{Path: rel, FromConfig: true})
+		}
+	}
+}
+
+// Add pins a file
+func (p *Pins) Add(path string) (string, error) {
+	rel, err := p.resolve(path)
+	if err != nil {
+		return "", err
+	}
+	if p.has(rel) {
+		return rel, fmt.Errorf("%s is already pinned", rel)
+	}
+	p.pins = append(p.pins, Pin{Path: rel})
+	return rel, nil
+}
+
+// Remove unpins a file by path or by its 1-based position in List
+func (p *Pins) Remove(ref string) (string, error) {
+	index := -1
+	if n, err := strconv.Atoi(ref); err == nil {
+		index = n - 1
+	} else if rel, err := p.relative(ref); err == nil {
+		for i, pin := range p.pins {
+			if pin.Path == rel {
+				index = i
+				break
+			}
+		}
+	}
+
+	if index < 0 || index >= len(p.pins) {
+		return "", fmt.Errorf("%s is not pinned", ref)
+	}
+	removed := p.pins[index].Path
+	p.pins = append(p.pins[:index], p.pins[index+1:]...)
+	return removed, nil
+}
+
+// Paths returns the pinned paths in order
+func (p *Pins) Paths() []string {
+	paths := make([]string, len(p.pins))
+	for i, pin := range p.pins {
+		paths[i] = pin.Path
+	}
+	return paths
+}
+
+// Budget returns the token budget for pinned files
+func (p *Pins) Budget() int {
+	return p.budget
+}
+
+// List returns the pins in order with their token counts. Pins are included
+// in order until the budget is exhausted; a pin that doesn't fit is skipped
+// so smaller pins after it can still be included.
+func (p *Pins) List(model string) []PinStatus {
+	statuses, _ := p.build(model)
+	return statuses
+}
+
+// Context returns the pinned file contents to add to a prompt, or "" when
+// nothing is pinned
+func (p *Pins) Context(model string) string {
+	_, pinned := p.build(model)
+	return pinned
+}
+
+func (p *Pins) build(model string) ([]PinStatus, string) {
+	var statuses []PinStatus
+	var sb strings.Builder
+	remaining := p.budget
+
+	for _, pin := range p.pins {
+		status := PinStatus{Pin: pin}
+		file, err := fileutil.ReadText(filepath.Join(p.root, pin.Path), fileutil.MaxFileSize())
+		if err != nil {
+			status.Err = err
+			statuses = append(statuses, status)
+			continue
+		}
+
+		section := fmt.Sprintf("### %s\n```\n%s\n```\n", pin.Path, strings.TrimRight(file.Content, "\n"))
+		if note := file.TruncationNote(); note != "" {
+			section += note + "\n"
+		}
+		section += "\n"
+		status.Tokens = tokens.Count(section, model).Count
+		if status.Tokens <= remaining {
+			status.Included = true
+			remaining -= status.Tokens
+			sb.WriteString(section)
+		}
+		statuses = append(statuses, status)
+	}
+
+	if sb.Len() == 0 {
+		return statuses, ""
+	}
+	return statuses, "**Pinned Files** (always kept in context):\n\n" + sb.String()
+}
+
+// resolve validates a path to pin and returns it relative to the root
+func (p *Pins) resolve(path string) (string, error) {
+	rel, err := p.relative(path)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(filepath.Join(p.root, rel))
+	if err != nil {
+		return "", fmt.Errorf("cannot pin %s: %w", path, err)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("cannot pin %s: is a directory", path)
+	}
+	return rel, nil
+}
+
+// relative returns a path relative to the root, rejecting paths outside it
+func (p *Pins) relative(path string) (string, error) {
+	abs := path
+	if !filepath.IsAbs(abs) {
+		abs = filepath.Join(p.root, path)
+	}
+	rel, err := filepath.Rel(p.root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("cannot pin %s: outside the working directory", path)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+func (p *Pins) has(rel string) bool {
+	for _, pin := range p.pins {
+		if pin.Path == rel {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package chat
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPinsBudgetAndUnpin(t *testing.T) {
+	root := t.TempDir()
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("ARCHITECTURE.md", "Layered design.")
+	write("big.txt", strings.Repeat("word ", 500))
+	write("notes.txt", "Remember the cache.")
+
+	pins := NewPins(root, []string{"ARCHITECTURE.md", "missing.md"}, 100)
+	if got := pins.Paths(); len(got) != 1 || got[0] != "ARCHITECTURE.md" {
+		t.Fatalf("Expected only existing configured pin, got %v", got)
+	}
+
+	if _, err := pins.Add("big.txt"); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if _, err := pins.Add(filepath.Join(root, "notes.txt")); err != nil {
+		t.Fatalf("Add with absolute path failed: %v", err)
+	}
+	if _, err := pins.Add("notes.txt"); err == nil {
+		t.Error("Expected error pinning a file twice")
+	}
+	if _, err := pins.Add("../outside.txt"); err == nil {
+		t.Error("Expected error pinning a file outside the root")
+	}
+
+	statuses := pins.List("ollama/llama3.2")
+	if len(statuses) != 3 || !statuses[0].Included || statuses[1].Included || !statuses[2].Included {
+		t.Fatalf("Expected big.txt to be skipped for budget, got %+v", statuses)
+	}
+
+	context := pins.Context("ollama/llama3.2")
+	if !strings.Contains(context, "Layered design.") || strings.Contains(context, "word word") {
+		t.Errorf("Unexpected pinned context: %s", context)
+	}
+
+	if removed, err := pins.Remove("2"); err != nil || removed != "big.txt" {
+		t.Errorf("Expected to unpin big.txt, got %q (%v)", removed, err)
+	}
+	if removed, err := pins.Remove("notes.txt"); err != nil || removed != "notes.txt" {
+		t.Errorf("Expected to unpin notes.txt, got %q (%v)", removed, err)
+	}
+	if _, err := pins.Remove("notes.txt"); err == nil {
+		t.Error("Expected error unpinning a file that isn't pinned")
+	}
+}
@@ -0,0 +1,169 @@
+package chat
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Plain output mode is for screen readers and dumb terminals: output is
+// written sequentially, without the alt-screen or cursor positioning, and
+// optionally without emoji.
+var (
+	plainOutput bool
+	noEmoji     bool
+)
+
+// SetPlainOutput turns plain output mode on or off; withoutEmoji also drops
+// emoji from everything the chat prints
+func SetPlainOutput(plain, withoutEmoji bool) {
+	plainOutput = plain
+	noEmoji = withoutEmoji
+}
+
+// PlainOutput reports whether plain output mode is on
+func PlainOutput() bool {
+	return plainOutput
+}
+
+// PlainTerminal reports whether the terminal can't drive a full-screen UI
+func PlainTerminal() bool {
+	return os.Getenv("TERM") == "dumb"
+}
+
+// Icon returns emoji followed by a space, or nothing when emoji are off
+func Icon(emoji string) string {
+	if noEmoji {
+		return ""
+	}
+	return emoji + " "
+}
+
+// Printable returns s as it should be shown, without emoji when they are off
+func Printable(s string) string {
+	if !noEmoji {
+		return s
+	}
+	return StripEmoji(s)
+}
+
+// StripEmoji removes emoji and other pictographs from s, along with the
+// space that separates a leading icon from its text
+func StripEmoji(s string) string {
+	var b strings.Builder
+	dropped := false
+	for _, r := range s {
+		if isEmoji(r) {
+			dropped = true
+			continue
+		}
+		if dropped && r == ' ' && (b.Len() == 0 || strings.HasSuffix(b.String(), "\n") || strings.HasSuffix(b.String(), " ")) {
+			dropped = false
+			continue
+		}
+		dropped = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func isEmoji(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF: // pictographs, emoticons, transport, symbols
+		return true
+	case r >= 0x2600 && r <= 0x27BF: // miscellaneous symbols and dingbats
+		return true
+	case r == 0x2B50 || r == 0x2B55 || r == 0x2B1B || r == 0x2B1C:
+		return true
+	case r == 0x200D || (r >= 0xFE00 && r <= 0xFE0F): // joiners and variation selectors
+		return true
+	}
+	return false
+}
+
+// selectPlain is the model selector for plain output mode: numbered lists
+// answered one line at a time
+func (ms *ModelSelector) selectPlain(in io.Reader, out io.Writer) (string, string, error) {
+	scanner := bufio.NewScanner(in)
+
+	labels := make([]string, len(ms.providers))
+	for i, p := range ms.providers {
+		labels[i] = p.Name
+		if p.Favorite {
+			labels[i] += " (favorite)"
+		}
+		if !p.Available {
+			labels[i] += " (" + unavailableReason(p.ID) + ")"
+		}
+	}
+	choice, err := choosePlain(scanner, out, "Providers", labels)
+	if err != nil {
+		return "", "", err
+	}
+	provider := ms.providers[choice]
+	if !provider.Available {
+		return "", "", fmt.Errorf("provider %s is unavailable: %s", provider.ID, unavailableReason(provider.ID))
+	}
+
+	if provider.ID == "openrouter" {
+		ms.selectedProvider = provider.ID
+		labels = make([]string, len(ms.openRouterFilters))
+		for i, f := range ms.openRouterFilters {
+			labels[i] = fmt.Sprintf("%s - %s", f.Name, f.Description)
+		}
+		choice, err := choosePlain(scanner, out, "OpenRouter provider filters", labels)
+		if err != nil {
+			return "", "", err
+		}
+		ms.selectedFilter = ms.openRouterFilters[choice].ProviderKey
+	}
+
+	fmt.Fprintln(out, "Loading models...")
+	models := []ModelInfo(ms.loadModels(provider.ID)().(modelsLoadedMsg))
+	if len(models) == 0 {
+		return "", "", fmt.Errorf("no models found for provider: %s", provider.ID)
+	}
+
+	labels = make([]string, len(models))
+	for i, m := range models {
+		labels[i] = fmt.Sprintf("%s (%s)", m.Name, m.ID)
+		if m.Warning != "" {
+			labels[i] += " (" + m.Warning + ")"
+		}
+		if m.Favorite {
+			labels[i] += " (favorite)"
+		}
+	}
+	choice, err = choosePlain(scanner, out, "Models", labels)
+	if err != nil {
+		return "", "", err
+	}
+	return models[choice].Provider, models[choice].ID, nil
+}
+
+// choosePlain lists items by number and reads a choice, asking again until
+// it gets a valid number. An empty answer or end of input cancels.
+func choosePlain(scanner *bufio.Scanner, out io.Writer, title string, items []string) (int, error) {
+	fmt.Fprintf(out, "%s:\n", title)
+	for i, item := range items {
+		fmt.Fprintf(out, "  %d. %s\n", i+1, item)
+	}
+
+	for {
+		fmt.Fprintf(out, "Choose 1-%d, or press Enter to cancel: ", len(items))
+		if !scanner.Scan() {
+			return 0, fmt.Errorf("selection canceled")
+		}
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			return 0, fmt.Errorf("selection canceled")
+		}
+		if n, err := strconv.Atoi(text); err == nil && n >= 1 && n <= len(items) {
+			return n - 1, nil
+		}
+		fmt.Fprintf(out, "%q is not a number from 1 to %d\n", text, len(items))
+	}
+}
@@ -0,0 +1,50 @@
+package chat
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestStripEmoji(t *testing.T) {
+	tests := map[string]string{
+		"📝 No favorites yet!":         "No favorites yet!",
+		"Total: 3 📊 models":           "Total: 3 models",
+		"line one\n🔧 line two":        "line one\nline two",
+		"⚠️ careful":                  "careful",
+		"plain text, no icons":        "plain text, no icons",
+		"👍🏽 thumbs":                   "thumbs",
+		"done ✅":                      "done ",
+		"**LSP Features Available:**": "**LSP Features Available:**",
+	}
+	for in, want := range tests {
+		if got := StripEmoji(in); got != want {
+			t.Errorf("StripEmoji(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestChoosePlain(t *testing.T) {
+	var out bytes.Buffer
+	scanner := bufio.NewScanner(strings.NewReader("x\n5\n2\n"))
+
+	choice, err := choosePlain(scanner, &out, "Models", []string{"a", "b", "c"})
+	if err != nil {
+		t.Fatalf("choosePlain: %v", err)
+	}
+	if choice != 1 {
+		t.Errorf("choice = %d, want 1", choice)
+	}
+	if !strings.Contains(out.String(), "  3. c\n") {
+		t.Errorf("items not listed:\n%s", out.String())
+	}
+	if strings.Count(out.String(), "is not a number from 1 to 3") != 2 {
+		t.Errorf("invalid answers not rejected:\n%s", out.String())
+	}
+
+	scanner = bufio.NewScanner(strings.NewReader("\n"))
+	if _, err := choosePlain(scanner, &out, "Models", []string{"a"}); err == nil {
+		t.Error("empty answer should cancel")
+	}
+}
@@ -0,0 +1,32 @@
+package chat
+
+import (
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+	contextmgmt "github.com/entrepeneur4lyf/codeforge/internal/context"
+)
+
+// notePaths remembers the files and directories input mentions, so the
+// profiles of their directories apply for the rest of the session
+func (cs *ChatSession) notePaths(input string) {
+	seen := map[string]bool{}
+	for _, p := range cs.mentioned {
+		seen[p] = true
+	}
+	for _, p := range contextmgmt.ReferencedPaths(cs.pins.root, input) {
+		if !seen[p] {
+			seen[p] = true
+			cs.mentioned = append(cs.mentioned, p)
+		}
+	}
+}
+
+// directoryProfiles returns the context profiles of the directories holding
+// the files mentioned in the session or pinned
+func (cs *ChatSession) directoryProfiles() []contextmgmt.DirectoryProfile {
+	cfg := config.Get()
+	if cfg == nil {
+		return nil
+	}
+	paths := append(append([]string{}, cs.mentioned...), cs.pins.Paths()...)
+	return contextmgmt.DirectoryProfiles(cs.pins.root, contextmgmt.ProfileNames(cfg.ContextPaths), paths)
+}
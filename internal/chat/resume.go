@@ -0,0 +1,153 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+	"github.com/entrepeneur4lyf/codeforge/internal/storage"
+)
+
+// sessionListLimit is how many of the latest sessions /sessions shows
+const sessionListLimit = 20
+
+// sessionSearchLimit is how many of the latest sessions an ID prefix is
+// looked up in
+const sessionSearchLimit = 500
+
+// FindStoredSession returns the ID of the saved session ref names, by its
+// full ID or a prefix of it, or the most recently active session when ref is
+// empty
+func FindStoredSession(ctx context.Context, store storage.ChatStore, ref string) (string, error) {
+	if ref != "" {
+		if _, err := store.GetSession(ctx, ref); err == nil {
+			return ref, nil
+		}
+	}
+
+	sessions, err := store.ListSessions(ctx, "", sessionSearchLimit, 0)
+	if err != nil {
+		return "", err
+	}
+	if ref == "" {
+		if len(sessions) == 0 {
+			return "", fmt.Errorf("no saved sessions to resume")
+		}
+		return sessions[0].ID, nil
+	}
+
+	match := ""
+	for _, s := range sessions {
+		if !strings.HasPrefix(s.ID, ref) {
+			continue
+		}
+		if match != "" {
+			return "", fmt.Errorf("%s matches more than one session; give more of its ID", ref)
+		}
+		match = s.ID
+	}
+	if match == "" {
+		return "", fmt.Errorf("no saved session %s", ref)
+	}
+	return match, nil
+}
+
+// ResumeSession continues the saved session with the given ID in place of
+// the current conversation, restoring its history into the model's context.
+// It returns the number of messages restored.
+func (cs *ChatSession) ResumeSession(sessionID string) (int, error) {
+	if cs.store == nil {
+		return 0, fmt.Errorf("this chat isn't saved, so it can't switch sessions")
+	}
+
+	messages, follower := cs.messages, cs.follower
+	cs.messages = []llm.Message{}
+	restored, err := cs.AttachStore(cs.store, sessionID)
+	if err != nil {
+		cs.messages, cs.follower = messages, follower
+		return 0, err
+	}
+
+	// Context gathered for the previous conversation doesn't carry over
+	cs.contextGathered = false
+	cs.sessionContext = ""
+	cs.sessionSources = nil
+	cs.lastDiagrams = nil
+	cs.lastEdits = nil
+	return restored, nil
+}
+
+// showSessions lists the saved sessions or, given a /sessions number or a
+// session ID, switches the conversation to that session
+func (cs *ChatSession) showSessions(args []string) {
+	if cs.quiet {
+		return
+	}
+	if cs.store == nil {
+		fmt.Println("This chat isn't saved, so there are no sessions to list")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	sessions, err := cs.store.ListSessions(ctx, "", sessionListLimit, 0)
+	if err != nil {
+		fmt.Printf("Failed to list sessions: %v\n", err)
+		return
+	}
+
+	if len(args) == 0 {
+		if len(sessions) == 0 {
+			fmt.Println("No saved sessions")
+			return
+		}
+		fmt.Println("Saved sessions, latest first:")
+		for i, s := range sessions {
+			current := ""
+			if s.ID == cs.StoreSessionID() {
+				current = " (current)"
+			}
+			fmt.Printf("%2d. %s  %s, %d messages, %s%s\n", i+1, shortSessionID(s.ID), s.Title, s.MessageCount, s.UpdatedAt.Local().Format("Jan 2 15:04"), current)
+			if last := strings.Join(strings.Fields(s.LastMessage), " "); last != "" {
+				if len(last) > 70 {
+					last = last[:70] + "..."
+				}
+				fmt.Printf("    %s\n", last)
+			}
+		}
+		fmt.Println("Use /sessions N or /sessions ID to continue one")
+		return
+	}
+
+	ref := args[0]
+	if n, err := strconv.Atoi(ref); err == nil && n >= 1 && n <= len(sessions) {
+		ref = sessions[n-1].ID
+	}
+	id, err := FindStoredSession(ctx, cs.store, ref)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	if id == cs.StoreSessionID() {
+		fmt.Println("Already in that session")
+		return
+	}
+	restored, err := cs.ResumeSession(id)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	fmt.Printf("Continuing session %s: %d earlier messages restored\n", shortSessionID(id), restored)
+}
+
+// shortSessionID is enough of a session ID to recognize and resume it by
+func shortSessionID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
@@ -0,0 +1,95 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+	"github.com/entrepeneur4lyf/codeforge/internal/storage"
+)
+
+// sessionStore is a ChatStore holding a few sessions' messages, latest
+// session first
+type sessionStore struct {
+	storage.ChatStore
+	ids      []string
+	messages map[string][]storage.Message
+}
+
+func (s *sessionStore) GetSession(ctx context.Context, id string) (*storage.Session, error) {
+	if _, ok := s.messages[id]; !ok {
+		return nil, fmt.Errorf("session not found: %s", id)
+	}
+	return &storage.Session{ID: id}, nil
+}
+
+func (s *sessionStore) ListSessions(ctx context.Context, userID string, limit, offset int) ([]*storage.SessionSummary, error) {
+	var sessions []*storage.SessionSummary
+	for _, id := range s.ids {
+		sessions = append(sessions, &storage.SessionSummary{ID: id, MessageCount: len(s.messages[id])})
+	}
+	return sessions, nil
+}
+
+func (s *sessionStore) GetMessages(ctx context.Context, sessionID string, limit, offset int) (*storage.MessageBatch, error) {
+	messages := s.messages[sessionID]
+	if offset > len(messages) {
+		offset = len(messages)
+	}
+	return &storage.MessageBatch{Messages: messages[offset:], TotalCount: len(messages)}, nil
+}
+
+func TestResumeSession(t *testing.T) {
+	store := &sessionStore{
+		ids: []string{"7f3c21aa-latest", "7f9b0e4c-older", "0a1b2c3d-oldest"},
+		messages: map[string][]storage.Message{
+			"7f3c21aa-latest": {{ID: "m1", Role: "user", Content: "hi"}},
+			"7f9b0e4c-older": {
+				{ID: "m2", Role: "user", Content: "Why does the build fail?"},
+				{ID: "m3", Role: "assistant", Content: "A missing import in main.go"},
+			},
+			"0a1b2c3d-oldest": {},
+		},
+	}
+	ctx := context.Background()
+
+	for ref, want := range map[string]string{
+		"":                "7f3c21aa-latest",
+		"7f9b":            "7f9b0e4c-older",
+		"0a1b2c3d-oldest": "0a1b2c3d-oldest",
+	} {
+		if got, err := FindStoredSession(ctx, store, ref); err != nil || got != want {
+			t.Errorf("FindStoredSession(%q) = %q, %v; want %q", ref, got, err, want)
+		}
+	}
+	if _, err := FindStoredSession(ctx, store, "7f"); err == nil {
+		t.Error("ambiguous prefix matched a session")
+	}
+	if _, err := FindStoredSession(ctx, store, "ffff"); err == nil {
+		t.Error("unknown ID matched a session")
+	}
+
+	cs := &ChatSession{quiet: true}
+	if _, err := cs.ResumeSession("7f9b0e4c-older"); err == nil {
+		t.Error("switched sessions in a chat that isn't saved")
+	}
+
+	if _, err := cs.AttachStore(store, "7f3c21aa-latest"); err != nil {
+		t.Fatal(err)
+	}
+	cs.contextGathered = true
+	restored, err := cs.ResumeSession("7f9b0e4c-older")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored != 2 || len(cs.messages) != 2 || cs.StoreSessionID() != "7f9b0e4c-older" {
+		t.Fatalf("restored %d messages, history %d, session %s", restored, len(cs.messages), cs.StoreSessionID())
+	}
+	if text := cs.messages[1].Content[0].(llm.TextBlock).Text; cs.messages[1].Role != "assistant" || text != "A missing import in main.go" {
+		t.Errorf("last restored message = %s: %q", cs.messages[1].Role, text)
+	}
+	if cs.contextGathered {
+		t.Error("context of the previous conversation kept")
+	}
+}
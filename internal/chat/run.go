@@ -0,0 +1,77 @@
+package chat
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+	"github.com/entrepeneur4lyf/codeforge/internal/shellcmd"
+)
+
+// offerCommands asks whether to run each command the response proposes,
+// runs those approved and sends their output back to the model. It repeats
+// for the commands the reply proposes, and returns the last response.
+func (cs *ChatSession) offerCommands(scanner *bufio.Scanner, response string) string {
+	policy := shellcmd.NewPolicy(config.Get())
+	for !cs.quiet {
+		commands := shellcmd.Extract(response)
+		if len(commands) == 0 {
+			return response
+		}
+
+		var notes []string
+		ran := false
+		for _, command := range commands {
+			if err := policy.Check(command); err != nil {
+				fmt.Printf("\nNot running `%s`: %v\n", command, err)
+				notes = append(notes, fmt.Sprintf("`%s` wasn't run: %v.", command, err))
+				continue
+			}
+			fmt.Printf("\nRun `%s`? [y/N] ", command)
+			if !scanner.Scan() {
+				return response
+			}
+			if answer := strings.ToLower(strings.TrimSpace(scanner.Text())); answer != "y" && answer != "yes" {
+				notes = append(notes, fmt.Sprintf("I chose not to run `%s`.", command))
+				continue
+			}
+
+			result, err := shellcmd.Run(context.Background(), cs.commandRouter.workingDir, cs.commandRouter.env, command, policy.Timeout)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				notes = append(notes, fmt.Sprintf("`%s` couldn't be run: %v.", command, err))
+				continue
+			}
+			ran = true
+			fmt.Print(result.Output)
+			if result.TimedOut {
+				fmt.Printf("Timed out after %s\n", policy.Timeout)
+			} else if result.ExitCode != 0 {
+				fmt.Printf("Exited with status %d\n", result.ExitCode)
+			}
+			notes = append(notes, result.Message())
+		}
+		// Nothing to report when no command ran
+		if !ran {
+			return response
+		}
+
+		message := strings.Join(notes, "\n\n")
+		unlock := cs.lockStoreSession()
+		reply, err := cs.ProcessMessage(message)
+		if err != nil {
+			unlock()
+			fmt.Printf("Error: %v\n", err)
+			return response
+		}
+		cs.persistExchange(message, reply)
+		unlock()
+
+		cs.displayResponse(reply)
+		cs.offerEdits(scanner, reply)
+		response = reply
+	}
+	return response
+}
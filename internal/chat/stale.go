@@ -0,0 +1,94 @@
+package chat
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	contextmgmt "github.com/entrepeneur4lyf/codeforge/internal/context"
+	"github.com/entrepeneur4lyf/codeforge/internal/git"
+	"github.com/entrepeneur4lyf/codeforge/internal/vectordb"
+)
+
+// referencedFiles returns the files in the working directory that input
+// mentions by path, relative to the working directory
+func (cs *ChatSession) referencedFiles(input string) []string {
+	var files []string
+	for _, rel := range contextmgmt.ReferencedPaths(cs.pins.root, input) {
+		if info, err := os.Stat(filepath.Join(cs.pins.root, rel)); err == nil && info.Mode().IsRegular() {
+			files = append(files, rel)
+		}
+	}
+	return files
+}
+
+// staleFiles returns the files input mentions that changed since they were
+// last indexed, as search results for them may no longer match the code
+func (cs *ChatSession) staleFiles(ctx context.Context, input string) []string {
+	vdb := vectordb.Get()
+	if vdb == nil {
+		return nil
+	}
+
+	var stale []string
+	for _, rel := range cs.referencedFiles(input) {
+		indexedAt, err := vdb.IndexedAt(ctx, rel)
+		if err != nil || indexedAt.IsZero() {
+			continue // Files that were never indexed can't be stale
+		}
+		info, err := os.Stat(filepath.Join(cs.pins.root, rel))
+		if err == nil && info.ModTime().Truncate(time.Second).After(indexedAt) {
+			stale = append(stale, rel)
+		}
+	}
+	return stale
+}
+
+// offerStaleDiffs warns when input mentions files changed since they were
+// last indexed and, if the user agrees, returns input with the uncommitted
+// diffs of those files attached
+func (cs *ChatSession) offerStaleDiffs(scanner *bufio.Scanner, input string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	stale := cs.staleFiles(ctx, input)
+	if len(stale) == 0 {
+		return input
+	}
+
+	noun := "files"
+	if len(stale) == 1 {
+		noun = "file"
+	}
+	fmt.Printf("%s%d %s changed since last index: %s\n", Icon("⚠"), len(stale), noun, strings.Join(stale, ", "))
+	fmt.Print("Attach the diffs to your message? [y/N] ")
+	if !scanner.Scan() {
+		return input
+	}
+	if answer := strings.ToLower(strings.TrimSpace(scanner.Text())); answer != "y" && answer != "yes" {
+		return input
+	}
+
+	diffs := staleDiffs(ctx, git.NewRepository(cs.pins.root), stale)
+	if diffs == "" {
+		fmt.Println("No uncommitted changes to attach.")
+		return input
+	}
+	return input + "\n\n**Changes since last index:**\n```diff\n" + diffs + "```"
+}
+
+// staleDiffs returns the uncommitted diffs of files, skipping files
+// without any
+func staleDiffs(ctx context.Context, repo *git.Repository, files []string) string {
+	var sb strings.Builder
+	for _, file := range files {
+		if diff, err := repo.FileDiff(ctx, file); err == nil {
+			sb.WriteString(diff)
+		}
+	}
+	return sb.String()
+}
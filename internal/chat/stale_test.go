@@ -0,0 +1,27 @@
+package chat
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReferencedFiles(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "internal", "app"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"main.go", "internal/app/app.go"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte("package main"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cs := &ChatSession{pins: NewPins(root, nil, 0)}
+	got := cs.referencedFiles("Why does `internal/app/app.go` call main.go? Also see missing.go and main.go.")
+	want := []string{"internal/app/app.go", "main.go"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("referencedFiles() = %v, want %v", got, want)
+	}
+}
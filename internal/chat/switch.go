@@ -0,0 +1,110 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+	"github.com/entrepeneur4lyf/codeforge/internal/llm/prompt"
+	"github.com/entrepeneur4lyf/codeforge/internal/tokens"
+)
+
+const (
+	switchBudget = 0.5 // Share of a newly chosen model's context window the history may fill
+	keptOnSwitch = 4   // Latest messages always carried over as they are
+)
+
+// fitHistory shrinks the conversation to fit the model just switched to.
+// The older turns are summarized by that model when context.summarizeOnSwitch
+// is set, and dropped otherwise.
+func (cs *ChatSession) fitHistory() {
+	window := cs.handler.GetModel().Info.ContextWindow
+	if window <= 0 || len(cs.messages) <= keptOnSwitch {
+		return
+	}
+	budget := int(float64(window) * switchBudget)
+	if historyTokens(cs.messages, cs.model) <= budget {
+		return
+	}
+
+	older := cs.messages[:len(cs.messages)-keptOnSwitch]
+	recent := cs.messages[len(cs.messages)-keptOnSwitch:]
+	if cfg := config.Get(); cfg != nil && cfg.Context.SummarizeOnSwitch {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		summary, err := summarizeHistory(ctx, cs.handler, older, budget/2)
+		cancel()
+		if err == nil {
+			cs.messages = append([]llm.Message{{
+				Role:    "user",
+				Content: []llm.ContentBlock{llm.TextBlock{Text: "Summary of the earlier conversation:\n\n" + summary}},
+			}}, recent...)
+			fmt.Printf("Summarized %d earlier messages to fit %s\n", len(older), cs.model)
+			return
+		}
+		fmt.Printf("Couldn't summarize the earlier messages, dropping them instead: %v\n", err)
+	}
+
+	dropped, total := 0, historyTokens(cs.messages, cs.model)
+	for len(cs.messages) > keptOnSwitch && total > budget {
+		total -= historyTokens(cs.messages[:1], cs.model)
+		cs.messages = cs.messages[1:]
+		dropped++
+	}
+	fmt.Printf("Dropped %d earlier messages to fit %s\n", dropped, cs.model)
+}
+
+// historyTokens counts the tokens of the conversation's text
+func historyTokens(messages []llm.Message, model string) int {
+	total := 0
+	for _, msg := range messages {
+		total += tokens.Count(messageText(msg), model).Count
+	}
+	return total
+}
+
+// messageText joins the text blocks of a message
+func messageText(msg llm.Message) string {
+	var texts []string
+	for _, block := range msg.Content {
+		if text, ok := block.(llm.TextBlock); ok {
+			texts = append(texts, text.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// summarizeHistory has the model behind handler summarize a conversation,
+// sending at most its latest maxTokens worth of text
+func summarizeHistory(ctx context.Context, handler llm.ApiHandler, messages []llm.Message, maxTokens int) (string, error) {
+	var transcript strings.Builder
+	for _, msg := range messages {
+		fmt.Fprintf(&transcript, "## %s\n%s\n\n", msg.Role, messageText(msg))
+	}
+	text := transcript.String()
+	if maxChars := maxTokens * 4; len(text) > maxChars {
+		text = "[earlier conversation omitted]\n\n" + text[len(text)-maxChars:]
+	}
+
+	request := []llm.Message{{
+		Role:    "user",
+		Content: []llm.ContentBlock{llm.TextBlock{Text: "Summarize this conversation:\n\n" + text}},
+	}}
+	stream, err := handler.CreateMessage(ctx, prompt.SummarizerPrompt(""), request)
+	if err != nil {
+		return "", err
+	}
+
+	var summary strings.Builder
+	for chunk := range stream {
+		if text, ok := chunk.(llm.ApiStreamTextChunk); ok {
+			summary.WriteString(text.Text)
+		}
+	}
+	if strings.TrimSpace(summary.String()) == "" {
+		return "", fmt.Errorf("the model returned no summary")
+	}
+	return strings.TrimSpace(summary.String()), nil
+}
@@ -0,0 +1,46 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/tasks"
+)
+
+// showTasks extracts the remaining work from the conversation and prints
+// it as a checklist, or as issue drafts with /tasks issues
+func (cs *ChatSession) showTasks(args []string) {
+	asIssues := len(args) > 0 && args[0] == "issues"
+	if len(args) > 0 && !asIssues {
+		fmt.Println("Usage: /tasks [issues]")
+		return
+	}
+	if len(cs.messages) == 0 {
+		fmt.Println("Nothing to extract tasks from yet.")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	if !cs.quiet {
+		fmt.Println("Extracting tasks from the conversation...")
+	}
+	cs.refreshKey()
+	found, err := tasks.Extract(ctx, cs.handler, tasks.Transcript(cs.messages))
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	if len(found) == 0 {
+		fmt.Println("No open tasks found in the conversation.")
+		return
+	}
+
+	if asIssues {
+		fmt.Println(tasks.IssueDrafts(found))
+	} else {
+		fmt.Println(tasks.Checklist(found))
+	}
+}
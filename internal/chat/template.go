@@ -0,0 +1,13 @@
+package chat
+
+import "github.com/entrepeneur4lyf/codeforge/internal/config"
+
+// ApplyTemplate pins a session template's files and adds its instructions
+// to the system prompt. The template's model is chosen when the session is
+// created and its tools are applied to the app.
+func (cs *ChatSession) ApplyTemplate(t config.SessionTemplate) {
+	cs.pins.addConfigured(t.PinnedFiles)
+	if t.Prompt != "" {
+		cs.systemPrompt += "\n\n" + t.Prompt
+	}
+}
@@ -0,0 +1,137 @@
+package chat
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+	contextmgmt "github.com/entrepeneur4lyf/codeforge/internal/context"
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+	"github.com/entrepeneur4lyf/codeforge/internal/ml"
+	"github.com/entrepeneur4lyf/codeforge/internal/notes"
+	"github.com/entrepeneur4lyf/codeforge/internal/tokens"
+)
+
+// contextTrace describes how a prompt was put together, printed with
+// --debug so prompt engineering doesn't mean guessing what the model saw
+type contextTrace struct {
+	Model         string
+	ContextWindow int
+
+	SystemPrompt int // Tokens of the base system prompt
+	Notes        int // Tokens of the project notes
+
+	Profiles      []string // Directories whose context profiles apply
+	ProfileTokens int
+
+	Pins      []PinStatus
+	PinBudget int
+
+	Sources   []ml.ContextSource // Code the retrieved context was drawn from
+	Retrieved int                // Tokens of the retrieved context
+
+	History       int // Earlier messages sent with the prompt, never truncated
+	HistoryTokens int
+
+	Message int // Tokens of the user message
+}
+
+// Total returns the tokens of the whole prompt
+func (t contextTrace) Total() int {
+	total := t.SystemPrompt + t.Notes + t.ProfileTokens + t.Retrieved + t.HistoryTokens + t.Message
+	for _, pin := range t.Pins {
+		if pin.Included {
+			total += pin.Tokens
+		}
+	}
+	return total
+}
+
+// Write prints the trace
+func (t contextTrace) Write(w io.Writer) {
+	fmt.Fprintf(w, "[context] model %s\n", t.Model)
+	fmt.Fprintf(w, "[context]   system prompt  %6d tokens\n", t.SystemPrompt)
+	fmt.Fprintf(w, "[context]   project notes  %6d tokens\n", t.Notes)
+	fmt.Fprintf(w, "[context]   dir profiles   %6d tokens from %d directories\n", t.ProfileTokens, len(t.Profiles))
+	for _, dir := range t.Profiles {
+		fmt.Fprintf(w, "[context]     + %s/\n", dir)
+	}
+
+	pinned, included := 0, 0
+	for _, pin := range t.Pins {
+		if pin.Included {
+			pinned += pin.Tokens
+			included++
+		}
+	}
+	fmt.Fprintf(w, "[context]   pinned files   %6d tokens, %d of %d files within the %d token budget\n",
+		pinned, included, len(t.Pins), t.PinBudget)
+	for _, pin := range t.Pins {
+		switch {
+		case pin.Err != nil:
+			fmt.Fprintf(w, "[context]     - %s: %v\n", pin.Path, pin.Err)
+		case pin.Included:
+			fmt.Fprintf(w, "[context]     + %s (%d tokens)\n", pin.Path, pin.Tokens)
+		default:
+			fmt.Fprintf(w, "[context]     - %s (%d tokens, over budget)\n", pin.Path, pin.Tokens)
+		}
+	}
+
+	fmt.Fprintf(w, "[context]   retrieved      %6d tokens from %d sources\n", t.Retrieved, len(t.Sources))
+	for _, source := range t.Sources {
+		fmt.Fprintf(w, "[context]     %.2f %s\n", source.Confidence, source.Path)
+	}
+
+	fmt.Fprintf(w, "[context]   history        %6d tokens, all %d messages kept\n", t.HistoryTokens, t.History)
+	fmt.Fprintf(w, "[context]   user message   %6d tokens\n", t.Message)
+
+	if t.ContextWindow > 0 {
+		fmt.Fprintf(w, "[context]   total          %6d of %d tokens (%.0f%%)\n",
+			t.Total(), t.ContextWindow, 100*float64(t.Total())/float64(t.ContextWindow))
+	} else {
+		fmt.Fprintf(w, "[context]   total          %6d tokens\n", t.Total())
+	}
+}
+
+// traceContext prints how the prompt for userInput is assembled, when
+// running with --debug. history is what is sent before the user message.
+func (cs *ChatSession) traceContext(userInput string, history []llm.Message) {
+	if cfg := config.Get(); cfg == nil || !cfg.Debug {
+		return
+	}
+
+	trace := contextTrace{
+		Model:        cs.model,
+		SystemPrompt: tokens.Count(cs.systemPrompt, cs.model).Count,
+		Pins:         cs.pins.List(cs.model),
+		PinBudget:    cs.pins.Budget(),
+		Sources:      cs.sessionSources,
+		History:      len(history),
+		Message:      tokens.Count(userInput, cs.model).Count,
+	}
+	if cs.handler != nil {
+		trace.ContextWindow = cs.handler.GetModel().Info.ContextWindow
+	}
+	if projectNotes := notes.PromptContext(); projectNotes != "" {
+		trace.Notes = tokens.Count(projectNotes, cs.model).Count
+	}
+	if profiles := cs.directoryProfiles(); len(profiles) > 0 {
+		for _, profile := range profiles {
+			trace.Profiles = append(trace.Profiles, profile.Dir)
+		}
+		trace.ProfileTokens = tokens.Count(contextmgmt.FormatProfiles(profiles), cs.model).Count
+	}
+	if cs.sessionContext != "" {
+		trace.Retrieved = tokens.Count(cs.sessionContext, cs.model).Count
+	}
+	for _, msg := range history {
+		for _, block := range msg.Content {
+			if text, ok := block.(llm.TextBlock); ok {
+				trace.HistoryTokens += tokens.Count(text.Text, cs.model).Count
+			}
+		}
+	}
+
+	trace.Write(os.Stderr)
+}
@@ -0,0 +1,45 @@
+package chat
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/ml"
+)
+
+func TestContextTrace_Write(t *testing.T) {
+	trace := contextTrace{
+		Model:         "test-model",
+		ContextWindow: 1000,
+		SystemPrompt:  100,
+		Pins: []PinStatus{
+			{Pin: Pin{Path: "README.md"}, Tokens: 50, Included: true},
+			{Pin: Pin{Path: "big.go"}, Tokens: 900},
+		},
+		PinBudget:     200,
+		Sources:       []ml.ContextSource{{Path: "internal/app.go", Confidence: 0.75}},
+		Retrieved:     30,
+		History:       2,
+		HistoryTokens: 20,
+		Message:       10,
+	}
+	if total := trace.Total(); total != 210 {
+		t.Errorf("Total() = %d, want 210", total)
+	}
+
+	var out bytes.Buffer
+	trace.Write(&out)
+	for _, want := range []string{
+		"1 of 2 files within the 200 token budget",
+		"+ README.md (50 tokens)",
+		"- big.go (900 tokens, over budget)",
+		"0.75 internal/app.go",
+		"all 2 messages kept",
+		"210 of 1000 tokens (21%)",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("trace missing %q:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package chat
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+	"github.com/entrepeneur4lyf/codeforge/internal/transcript"
+)
+
+// usageTotals adds up the usage of a session's responses. It's read when
+// the CLI is interrupted, so it has a lock of its own.
+type usageTotals struct {
+	mu        sync.Mutex
+	total     transcript.Usage
+	responses int
+}
+
+// recordUsage keeps the usage of a response, to be saved with it, and adds
+// it to the session's totals
+func (cs *ChatSession) recordUsage(usage *llm.Usage) {
+	cs.lastUsage = usage
+	if usage == nil {
+		return
+	}
+	cs.usage.mu.Lock()
+	defer cs.usage.mu.Unlock()
+	cs.usage.total.InputTokens += usage.PromptTokens
+	cs.usage.total.OutputTokens += usage.CompletionTokens
+	cs.usage.total.Cost += usage.TotalCost
+	cs.usage.responses++
+}
+
+// sessionUsage converts the usage reported for a response, priced the way
+// the budget tracker prices it: the provider's cost, or else the model's
+// list prices, cache reads and writes included
+func (cs *ChatSession) sessionUsage(chunk llm.ApiStreamUsageChunk) *llm.Usage {
+	usage := &llm.Usage{
+		PromptTokens:     chunk.InputTokens,
+		CompletionTokens: chunk.OutputTokens,
+		TotalTokens:      chunk.InputTokens + chunk.OutputTokens,
+	}
+	if cs.handler != nil {
+		usage.TotalCost = chunk.Cost(cs.handler.GetModel().Info)
+	}
+	return usage
+}
+
+// LastUsage returns the usage of the last response, or nil before the
+// first
+func (cs *ChatSession) LastUsage() *llm.Usage {
+	return cs.lastUsage
+}
+
+// Usage returns what the session's responses used so far, and how many
+// responses there were
+func (cs *ChatSession) Usage() (transcript.Usage, int) {
+	cs.usage.mu.Lock()
+	defer cs.usage.mu.Unlock()
+	return cs.usage.total, cs.usage.responses
+}
+
+// UsageSummary describes what the session's responses used, such as
+// "Session usage: 3 responses, 1,200 in / 350 out tokens, $0.0042", or is
+// empty before the first response
+func (cs *ChatSession) UsageSummary() string {
+	total, responses := cs.Usage()
+	if responses == 0 {
+		return ""
+	}
+	noun := "responses"
+	if responses == 1 {
+		noun = "response"
+	}
+	return fmt.Sprintf("Session usage: %d %s, %s", responses, noun, total)
+}
@@ -0,0 +1,47 @@
+package chat
+
+import (
+	"testing"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+)
+
+// pricedHandler is a handler for a model with list prices
+type pricedHandler struct {
+	llm.ApiHandler
+}
+
+func (pricedHandler) GetModel() llm.ModelResponse {
+	return llm.ModelResponse{ID: "priced", Info: llm.ModelInfo{InputPrice: 3, OutputPrice: 15, CacheReadsPrice: 0.3, CacheWritesPrice: 3.75}}
+}
+
+func TestSessionUsage(t *testing.T) {
+	cs := &ChatSession{quiet: true, handler: pricedHandler{}}
+	if summary := cs.UsageSummary(); summary != "" {
+		t.Errorf("summary before any response = %q", summary)
+	}
+
+	// Unpriced usage is priced from the model, cache tokens included;
+	// reported costs are kept
+	cacheReads, cacheWrites := 10000, 2000
+	unpriced := cs.sessionUsage(llm.ApiStreamUsageChunk{InputTokens: 1000, OutputTokens: 200, CacheReadTokens: &cacheReads, CacheWriteTokens: &cacheWrites})
+	if want := 0.0165; unpriced.TotalCost < want-1e-9 || unpriced.TotalCost > want+1e-9 {
+		t.Errorf("priced at $%f, want $%f", unpriced.TotalCost, want)
+	}
+	cs.recordUsage(unpriced)
+
+	cost := 0.01
+	reported := cs.sessionUsage(llm.ApiStreamUsageChunk{InputTokens: 500, OutputTokens: 100, TotalCost: &cost})
+	cs.recordUsage(reported)
+
+	total, responses := cs.Usage()
+	if responses != 2 || total.InputTokens != 1500 || total.OutputTokens != 300 {
+		t.Errorf("usage = %+v over %d responses", total, responses)
+	}
+	if cs.LastUsage() != reported {
+		t.Error("last usage isn't the last response's")
+	}
+	if summary, want := cs.UsageSummary(), "Session usage: 2 responses, 1,500 in / 300 out tokens, $0.0265"; summary != want {
+		t.Errorf("summary = %q, want %q", summary, want)
+	}
+}
@@ -633,7 +633,7 @@ func (c *CodeChunker) chunkPHPFunctions(filePath, content string, lines []string
 
 // createFunctionChunk creates a function chunk
 func (c *CodeChunker) createFunctionChunk(filePath, funcName, content string, startLine, endLine int) *vectordb.CodeChunk {
-	return &vectordb.CodeChunk{
+	chunk := &vectordb.CodeChunk{
 		ID:       fmt.Sprintf("%s_func_%s", filepath.Base(filePath), funcName),
 		FilePath: filePath,
 		Content:  strings.TrimSpace(content),
@@ -651,6 +651,44 @@ func (c *CodeChunker) createFunctionChunk(filePath, funcName, content string, st
 			EndColumn:   1,
 		},
 	}
+
+	if c.config.ExtractSymbols && funcName != "unknown" {
+		signature := firstLine(chunk.Content)
+		symbol := vectordb.Symbol{
+			Name:      funcName,
+			Kind:      "function",
+			Signature: signature,
+			Location:  chunk.Location,
+		}
+		if chunk.Language == "go" {
+			if symbol.Parent = goReceiver(signature); symbol.Parent != "" {
+				symbol.Kind = "method"
+			}
+		}
+		chunk.Symbols = []vectordb.Symbol{symbol}
+	}
+	return chunk
+}
+
+// firstLine returns the first line of a declaration without its opening
+// brace, for use as a signature
+func firstLine(content string) string {
+	line, _, _ := strings.Cut(content, "\n")
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(line), "{"))
+}
+
+// goReceiver returns the receiver of a Go method declaration, such as
+// "(cs *ChatStorage)", or "" for a plain function
+func goReceiver(line string) string {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(line), "func ")
+	if !ok || !strings.HasPrefix(rest, "(") {
+		return ""
+	}
+	end := strings.Index(rest, ")")
+	if end < 0 {
+		return ""
+	}
+	return rest[:end+1]
 }
 
 // extractGoFunctionName extracts function name from Go function declaration
@@ -1182,7 +1220,7 @@ func (c *CodeChunker) createChunkFromNode(node *sitter.Node, filePath, content,
 	// Extract symbol name from the node
 	symbolName := c.extractSymbolName(node, content)
 
-	return &vectordb.CodeChunk{
+	chunk := &vectordb.CodeChunk{
 		ID:       fmt.Sprintf("%s_%s_%s_%d", filepath.Base(filePath), chunkType, symbolName, startPoint.Row),
 		FilePath: filePath,
 		Content:  chunkContent,
@@ -1201,10 +1239,60 @@ func (c *CodeChunker) createChunkFromNode(node *sitter.Node, filePath, content,
 			EndColumn:   int(endPoint.Column) + 1,
 		},
 	}
+
+	if c.config.ExtractSymbols && symbolName != "unknown" {
+		symbol := vectordb.Symbol{
+			Name:      symbolName,
+			Kind:      chunkType,
+			Signature: firstLine(chunkContent),
+			Location:  chunk.Location,
+			Parent:    c.extractSymbolParent(node, content),
+		}
+		if chunkType == "function" && symbol.Parent != "" {
+			symbol.Kind = "method"
+		}
+		chunk.Symbols = []vectordb.Symbol{symbol}
+	}
+	return chunk
+}
+
+// extractSymbolParent returns the receiver of a Go method or the name of the
+// class enclosing a method, or "" for top-level symbols
+func (c *CodeChunker) extractSymbolParent(node *sitter.Node, content string) string {
+	if receiver := node.ChildByFieldName("receiver"); receiver != nil {
+		return receiver.Utf8Text([]byte(content))
+	}
+	classTypes := []string{
+		"class_definition", "class_declaration", "class_specifier",
+		"interface_declaration", "enum_declaration", "trait_item", "impl_item",
+	}
+	for parent := node.Parent(); parent != nil; parent = parent.Parent() {
+		if slices.Contains(classTypes, parent.Kind()) {
+			if name := c.extractSymbolName(parent, content); name != "unknown" {
+				return name
+			}
+		}
+	}
+	return ""
 }
 
 // extractSymbolName extracts the symbol name from an AST node
 func (c *CodeChunker) extractSymbolName(node *sitter.Node, content string) string {
+	// Most grammars name declarations in a "name" field; Rust impls use "type"
+	// and Go type declarations hold theirs in a type_spec
+	for _, field := range []string{"name", "type"} {
+		if name := node.ChildByFieldName(field); name != nil {
+			return name.Utf8Text([]byte(content))
+		}
+	}
+	if node.Kind() == "type_declaration" && node.NamedChildCount() > 0 {
+		if spec := node.NamedChild(0); spec != nil {
+			if name := spec.ChildByFieldName("name"); name != nil {
+				return name.Utf8Text([]byte(content))
+			}
+		}
+	}
+
 	// Look for identifier nodes in the children
 	childCount := int(node.ChildCount())
 	for i := range childCount {
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// modelAliasesKey is the config section holding model aliases
+const modelAliasesKey = "modelAliases"
+
+// ResolveModel returns the model an alias in the loaded config names, or
+// modelID unchanged when it isn't an alias or no config is loaded
+func ResolveModel(modelID string) string {
+	if cfg == nil {
+		return modelID
+	}
+	return cfg.ResolveModel(modelID)
+}
+
+// ResolveModel returns the model an alias names, or modelID unchanged when
+// it isn't an alias. Alias targets are "provider/model" pairs and are turned
+// into model IDs the same way as the default model.
+func (c *Config) ResolveModel(modelID string) string {
+	// Viper lowercases map keys, so aliases are case-insensitive
+	target, ok := c.ModelAliases[strings.ToLower(modelID)]
+	if !ok || target == "" {
+		return modelID
+	}
+	provider, model, found := strings.Cut(target, "/")
+	if !found {
+		return target
+	}
+	return modelIDFor(provider, model)
+}
+
+// LocalRouteModel returns the Ollama model that requests are routed to when
+// they must stay local: in local-only mode and when offline. It may be set to
+// an alias of an ollama/ model.
+func (c *Config) LocalRouteModel() string {
+	return strings.TrimPrefix(c.ResolveModel(c.LocalModel), "ollama/")
+}
+
+// OutboundLocalModel returns the Ollama model the outbound filter routes
+// sensitive requests to: its own when set, otherwise LocalRouteModel
+func (c *Config) OutboundLocalModel() string {
+	if c.OutboundFilter.LocalModel == "" {
+		return c.LocalRouteModel()
+	}
+	return strings.TrimPrefix(c.ResolveModel(c.OutboundFilter.LocalModel), "ollama/")
+}
+
+// CheckModelApproved returns an error when approvedModels is set in the
+// loaded config and doesn't allow the model
+func CheckModelApproved(provider, modelID string) error {
+	if cfg == nil || cfg.ModelApproved(provider, modelID) {
+		return nil
+	}
+	return fmt.Errorf("model %s (provider %s) isn't in approvedModels; approved: %s",
+		modelID, provider, strings.Join(cfg.ApprovedModels, ", "))
+}
+
+// ModelApproved reports whether approvedModels allows a model. Patterns are
+// matched against the model ID and against provider/model, with * matching
+// anything, slashes included, so "openrouter/*" covers every OpenRouter model.
+func (c *Config) ModelApproved(provider, modelID string) bool {
+	if len(c.ApprovedModels) == 0 {
+		return true
+	}
+	qualified := provider + "/" + modelID
+	for _, pattern := range c.ApprovedModels {
+		re, err := regexp.Compile("^" + strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*") + "$")
+		if err == nil && (re.MatchString(modelID) || re.MatchString(qualified)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package config
+
+import "testing"
+
+func TestResolveModel(t *testing.T) {
+	dir := useProjectDir(t, `model: smart
+modelAliases:
+  fast: groq/llama-3.3-70b-versatile
+  Smart: anthropic/claude-sonnet-4-20250514
+  cheap: openrouter/meta-llama/llama-3.3-70b-instruct
+  local: ollama/qwen2.5-coder:7b
+localModel: local
+outboundFilter:
+  localModel: ollama/phi3
+`)
+	if err := mergeProjectConfig(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		model, want string
+	}{
+		{"fast", "groq/llama-3.3-70b-versatile"},
+		{"SMART", "claude-sonnet-4-20250514"},
+		{"cheap", "meta-llama/llama-3.3-70b-instruct"},
+		{"local", "ollama/qwen2.5-coder:7b"},
+		{"gpt-4o", "gpt-4o"},
+	}
+	for _, tt := range tests {
+		if got := ResolveModel(tt.model); got != tt.want {
+			t.Errorf("ResolveModel(%q) = %q, want %q", tt.model, got, tt.want)
+		}
+	}
+
+	if got := cfg.DefaultModelID(); got != "claude-sonnet-4-20250514" {
+		t.Errorf("DefaultModelID() = %q, want the smart alias target", got)
+	}
+	if got := cfg.LocalRouteModel(); got != "qwen2.5-coder:7b" {
+		t.Errorf("LocalRouteModel() = %q", got)
+	}
+	if got := cfg.OutboundLocalModel(); got != "phi3" {
+		t.Errorf("OutboundLocalModel() = %q, want the outbound filter's own", got)
+	}
+	cfg.OutboundFilter.LocalModel = ""
+	if got := cfg.OutboundLocalModel(); got != "qwen2.5-coder:7b" {
+		t.Errorf("OutboundLocalModel() = %q, want localModel when the filter names none", got)
+	}
+
+	if err := SetProjectValue("modelAliases.review", "openai/gpt-4.1"); err != nil {
+		t.Fatalf("SetProjectValue() error = %v", err)
+	}
+	if got := ResolveModel("review"); got != "gpt-4.1" {
+		t.Errorf("ResolveModel(review) = %q after setting it", got)
+	}
+}
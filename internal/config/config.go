@@ -9,6 +9,8 @@ import (
 	"time"
 
 	"github.com/entrepeneur4lyf/codeforge/internal/models"
+	"github.com/entrepeneur4lyf/codeforge/internal/platform"
+	"github.com/entrepeneur4lyf/codeforge/internal/policy"
 	"github.com/spf13/viper"
 )
 
@@ -42,20 +44,30 @@ type ModelConfig struct {
 
 // ContextConfig defines context management configuration
 type ContextConfig struct {
-	AutoSummarize      bool    `json:"autoSummarize"`      // Enable automatic summarization
-	SlidingWindow      bool    `json:"slidingWindow"`      // Enable sliding window
-	WindowOverlap      int     `json:"windowOverlap"`      // Overlap size for sliding window
-	CacheEnabled       bool    `json:"cacheEnabled"`       // Enable context caching
-	CacheTTL           int     `json:"cacheTTL"`           // Cache TTL in seconds
-	MaxCacheSize       int     `json:"maxCacheSize"`       // Maximum cache entries
-	CompressionLevel   int     `json:"compressionLevel"`   // Context compression level (0-9)
-	RelevanceThreshold float64 `json:"relevanceThreshold"` // Minimum relevance score for inclusion
+	AutoSummarize      bool     `json:"autoSummarize"`      // Enable automatic summarization
+	SlidingWindow      bool     `json:"slidingWindow"`      // Enable sliding window
+	WindowOverlap      int      `json:"windowOverlap"`      // Overlap size for sliding window
+	CacheEnabled       bool     `json:"cacheEnabled"`       // Enable context caching
+	CacheTTL           int      `json:"cacheTTL"`           // Cache TTL in seconds
+	MaxCacheSize       int      `json:"maxCacheSize"`       // Maximum cache entries
+	CompressionLevel   int      `json:"compressionLevel"`   // Context compression level (0-9)
+	RelevanceThreshold float64  `json:"relevanceThreshold"` // Minimum relevance score for inclusion
+	PinnedFiles        []string `json:"pinnedFiles"`        // Files always included in every prompt
+	PinnedBudget       int      `json:"pinnedBudget"`       // Token budget for pinned files
+	SummarizeOnSwitch  bool     `json:"summarizeOnSwitch"`  // Summarize, rather than drop, older turns too long for a newly chosen model
 }
 
 // Provider defines configuration for an LLM provider
 type Provider struct {
 	APIKey   string `json:"apiKey"`
 	Disabled bool   `json:"disabled"`
+
+	// Set to add a provider to the registry or change a built-in one
+	Name         string `json:"name,omitempty"`
+	KeyEnv       string `json:"keyEnv,omitempty"`
+	DefaultModel string `json:"defaultModel,omitempty"`
+
+	BaseURL string `json:"baseURL,omitempty"` // Where a local server such as Ollama listens
 }
 
 // Data defines storage configuration
@@ -65,13 +77,39 @@ type Data struct {
 
 // TUIConfig defines terminal UI configuration
 type TUIConfig struct {
-	Theme string `json:"theme"`
+	Theme  string `json:"theme"`
+	Keymap string `json:"keymap,omitempty"`
+	// Mouse enables wheel scrolling and clicking; turn it off for terminals
+	// that misreport mouse events
+	Mouse bool `json:"mouse"`
+	// FollowUps shows suggested follow-up prompts under each answer
+	FollowUps bool `json:"followUps"`
+	// FollowUpModel writes the suggestions: an alias or a provider/model ID.
+	// The default, the "fast" alias, falls back to the chat's model when it
+	// isn't configured.
+	FollowUpModel string `json:"followUpModel,omitempty"`
+}
+
+// DisplayConfig defines how output is rendered
+type DisplayConfig struct {
+	// ASCIIOnly replaces emoji icons with ASCII, for terminals and fonts
+	// that render them as boxes or break column alignment
+	ASCIIOnly bool `json:"ascii_only" mapstructure:"ascii_only"`
 }
 
 // ShellConfig defines shell configuration
 type ShellConfig struct {
 	Path string   `json:"path"`
 	Args []string `json:"args"`
+
+	// Allow and Deny are globs of commands the assistant may propose running,
+	// such as "go test *" or "git status". Deny is checked first and adds to
+	// the built-in deny list; an empty Allow uses the built-in allowlist of
+	// builds, tests, formatters and local git commands.
+	Allow []string `json:"allow,omitempty"`
+	Deny  []string `json:"deny,omitempty"`
+	// TimeoutSeconds limits how long an approved command runs (default 120)
+	TimeoutSeconds int `json:"timeout_seconds,omitempty" mapstructure:"timeout_seconds"`
 }
 
 // EmbeddingConfig defines embedding service configuration
@@ -79,6 +117,11 @@ type EmbeddingConfig struct {
 	Provider string `json:"provider"` // "ollama", "openai", "auto"
 	Model    string `json:"model"`    // e.g., "nomic-embed-text"
 	BaseURL  string `json:"baseURL"`  // for custom Ollama instances
+
+	Secondary string `json:"secondary"` // Provider to fail over to when the primary errors, e.g. "fallback"
+
+	ExpandQueries bool `json:"expandQueries"` // Add identifier parts and code synonyms to search queries
+	HyDE          bool `json:"hyde"`          // Also embed an LLM-written snippet answering the query
 }
 
 // MCPServer defines MCP server configuration
@@ -116,6 +159,119 @@ type PermissionConfig struct {
 	DatabasePath         string `json:"databasePath"`         // Path to permission database
 }
 
+// OutboundFilterConfig defines classification of context sent to cloud providers
+type OutboundFilterConfig struct {
+	Enabled        bool     `json:"enabled"`        // Enable the outbound filter
+	Action         string   `json:"action"`         // "block", "redact" or "local"
+	SensitivePaths []string `json:"sensitivePaths"` // Globs for sensitive files, e.g. "**/customers/**"
+	SensitiveTag   string   `json:"sensitiveTag"`   // Marker that tags a file as sensitive
+	DetectPII      bool     `json:"detectPII"`      // Detect emails, SSNs, card numbers and credentials
+	Patterns       []string `json:"patterns"`       // Additional regular expressions to treat as sensitive
+	LocalModel     string   `json:"localModel"`     // Ollama model sensitive requests are routed to; the top-level localModel when empty
+}
+
+// ProxyConfig defines proxy and TLS settings for provider HTTP clients
+type ProxyConfig struct {
+	URL       string            `json:"url"`       // http://, https:// or socks5:// proxy; defaults to HTTPS_PROXY
+	NoProxy   string            `json:"noProxy"`   // Comma-separated hosts that bypass the proxy; defaults to NO_PROXY
+	CABundle  string            `json:"caBundle"`  // PEM file with extra CA certificates to trust
+	Providers map[string]string `json:"providers"` // Per-provider proxy overrides; "direct" bypasses the proxy
+}
+
+// WireLogConfig defines on-disk logging of provider requests and responses
+type WireLogConfig struct {
+	Enabled bool   `json:"enabled"` // Log every generation for later replay
+	Dir     string `json:"dir"`     // Log directory; defaults to <data dir>/wirelog
+}
+
+// CanaryConfig defines A/B testing of a candidate model against the default
+type CanaryConfig struct {
+	Enabled   bool   `json:"enabled"`   // Route a share of requests to the candidate
+	Candidate string `json:"candidate"` // Candidate model ID or alias
+	Percent   int    `json:"percent"`   // Share of requests sent to the candidate, 0-100
+	Log       string `json:"log"`       // Event log; defaults to <data dir>/canary.jsonl
+}
+
+// EncryptionConfig defines at-rest encryption of the chat and vector databases
+type EncryptionConfig struct {
+	Enabled   bool   `json:"enabled"`   // Encrypt stored messages, session titles and embeddings
+	KeySource string `json:"keySource"` // "auto" (env, then keychain), "env" or "keychain"
+}
+
+// NotesConfig defines the per-project scratch notes
+type NotesConfig struct {
+	InjectContext bool   `json:"injectContext"` // Add saved notes to the context of every session
+	Path          string `json:"path"`          // Notes file; defaults to <data dir>/notes.md
+}
+
+// FilesConfig defines which project files CodeForge reads
+type FilesConfig struct {
+	Exclude     []string `json:"exclude"`     // Globs skipped by indexing, search and file listings, on top of the defaults
+	MaxFileSize int64    `json:"maxFileSize"` // Larger files are skipped when indexing and searching and truncated when attached
+
+	// LargeAttachments is how attachments over MaxFileSize reach the model:
+	// "truncate" inlines the first MaxFileSize bytes, "outline" sends the
+	// file's symbols and line count and lets the model read_file the rest
+	LargeAttachments string `json:"largeAttachments"`
+
+	WorkspaceRoots []string `json:"workspaceRoots"` // Directories file tools may write in besides the working directory
+	AllowWrite     []string `json:"allowWrite"`     // Files or directories outside the workspace file tools may write, such as ~/.config/nvim
+}
+
+// IndexConfig defines how the code index is kept
+type IndexConfig struct {
+	BranchDeltas bool    `json:"branchDeltas"` // Index changes on other branches separately from the base branch
+	BaseBranch   string  `json:"baseBranch"`   // Branch holding the shared index; defaults to main or master
+	Diversity    float64 `json:"diversity"`    // 0-1, how much search results trade relevance for covering different code
+	Vectors      string  `json:"vectors"`      // "mmap" to read embeddings from the mapped index file, "memory" to keep them decoded in RAM
+	MmapSizeMB   int     `json:"mmapSizeMB"`   // How much of the index file is memory-mapped in mmap mode; -1 for none
+	CacheChunks  int     `json:"cacheChunks"`  // Most recently used chunks kept decoded in memory; -1 for none
+	MaxMemoryMB  int     `json:"maxMemoryMB"`  // RSS budget, beyond which caches are dropped and embeddings read from disk; 0 for none
+}
+
+// OIDCConfig defines single sign-on for the API server through an OpenID
+// Connect identity provider, alongside localhost token auth
+type OIDCConfig struct {
+	Issuer       string            `json:"issuer"`       // Issuer URL; OIDC login is off when empty
+	ClientID     string            `json:"clientID"`     // Client registered with the identity provider
+	ClientSecret string            `json:"clientSecret"` // Client secret; defaults to CODEFORGE_OIDC_CLIENT_SECRET
+	RedirectURL  string            `json:"redirectURL"`  // Callback URL, ending in /api/v1/auth/oidc/callback
+	Scopes       []string          `json:"scopes"`       // Scopes requested besides openid; defaults to email and profile
+	UserClaim    string            `json:"userClaim"`    // ID token claim naming the user; defaults to email
+	GroupsClaim  string            `json:"groupsClaim"`  // ID token claim listing the user's groups; defaults to groups
+	Users        map[string]string `json:"users"`        // Role for each user, by UserClaim value: admin, developer or viewer
+	Groups       map[string]string `json:"groups"`       // Role for members of each group
+	DefaultRole  string            `json:"defaultRole"`  // Role for other users; they are refused when empty
+}
+
+// DocsConfig defines the documentation knowledge base used by ask-docs
+type DocsConfig struct {
+	Paths []string `json:"paths"` // Files and directories ingested, relative to the project
+}
+
+// BudgetConfig defines the spending budgets of the cost tracker, in dollars
+type BudgetConfig struct {
+	Hourly  float64 `json:"hourly"`
+	Daily   float64 `json:"daily"`
+	Monthly float64 `json:"monthly"`
+}
+
+// WebhooksConfig defines outbound webhooks, such as Slack incoming webhooks,
+// that are told about agent runs, budget alerts and server errors
+type WebhooksConfig struct {
+	Endpoints        []WebhookConfig `json:"endpoints"`
+	BudgetThresholds []float64       `json:"budgetThresholds"` // Percentages of the daily budget that send budget.threshold
+}
+
+// WebhookConfig defines one webhook endpoint
+type WebhookConfig struct {
+	Name     string            `json:"name"`
+	URL      string            `json:"url"`
+	Events   []string          `json:"events"`   // agent.finished, budget.threshold or server.error; all when empty
+	Template string            `json:"template"` // Go template of the request body; a Slack message by default
+	Headers  map[string]string `json:"headers"`  // Extra request headers, such as Authorization
+}
+
 // Config is the main configuration structure for the application
 type Config struct {
 	Data         Data                              `json:"data"`
@@ -129,6 +285,7 @@ type Config struct {
 	DebugLSP     bool                              `json:"debugLSP,omitempty"`
 	ContextPaths []string                          `json:"contextPaths,omitempty"`
 	TUI          TUIConfig                         `json:"tui"`
+	Display      DisplayConfig                     `json:"display"`
 	Shell        ShellConfig                       `json:"shell,omitempty"`
 	Embedding    EmbeddingConfig                   `json:"embedding,omitempty"`
 	AutoCompact  bool                              `json:"autoCompact,omitempty"`
@@ -136,6 +293,28 @@ type Config struct {
 	Context      ContextConfig                     `json:"context"`          // Context management configuration
 	Permissions  PermissionConfig                  `json:"permissions"`      // Permission system configuration
 
+	OutboundFilter OutboundFilterConfig       `json:"outboundFilter"`           // Classification of context sent to cloud providers
+	LocalOnly      bool                       `json:"localOnly,omitempty"`      // Restrict LLM and embedding traffic to local endpoints
+	LocalModel     string                     `json:"localModel,omitempty"`     // Ollama model used in local-only mode and when offline
+	Proxy          ProxyConfig                `json:"proxy"`                    // Proxy and CA settings for provider clients
+	WireLog        WireLogConfig              `json:"wireLog"`                  // Request/response logging for replay
+	Canary         CanaryConfig               `json:"canary"`                   // A/B testing of a candidate model
+	Encryption     EncryptionConfig           `json:"encryption"`               // At-rest encryption of stored conversations and embeddings
+	Notes          NotesConfig                `json:"notes"`                    // Scratch notes kept per project
+	Files          FilesConfig                `json:"files"`                    // Exclude globs shared by indexing and search
+	Index          IndexConfig                `json:"index"`                    // Per-branch code index
+	Model          string                     `json:"model,omitempty"`          // Default model, usually set per project in .codeforge.yaml
+	Provider       string                     `json:"provider,omitempty"`       // Provider of the default model
+	ModelAliases   map[string]string          `json:"modelAliases,omitempty"`   // Names such as "fast" or "smart" for provider/model pairs
+	Templates      map[string]SessionTemplate `json:"templates,omitempty"`      // Named session setups chosen with --template
+	ApprovedModels []string                   `json:"approvedModels,omitempty"` // Models that may be used, as globs over model IDs or provider/model; all when empty
+	Policies       policy.Policies            `json:"policies"`                 // Code policies for every project, on top of .codeforge/policies.json
+	Org            OrgConfig                  `json:"org"`                      // Shared base config published by an organization
+	OIDC           OIDCConfig                 `json:"oidc"`                     // Single sign-on for the API server
+	Budget         BudgetConfig               `json:"budget"`                   // Spending budgets for cost tracking
+	Webhooks       WebhooksConfig             `json:"webhooks"`                 // Outbound event notifications
+	Docs           DocsConfig                 `json:"docs"`                     // Documentation knowledge base
+
 	// Enhanced configuration managers (Phase 4)
 	ModelConfigManager *ModelConfigManager `json:"-"` // Enhanced model configuration manager
 	ProviderManager    *ProviderManager    `json:"-"` // Provider health and load balancing manager
@@ -171,6 +350,9 @@ var defaultContextPaths = []string{
 // Global configuration instance
 var cfg *Config
 
+// loadIssues are the problems found in the config files by Load
+var loadIssues []Issue
+
 // Load initializes the configuration from environment variables and config files
 func Load(workingDir string, debug bool) (*Config, error) {
 	if cfg != nil {
@@ -185,14 +367,32 @@ func Load(workingDir string, debug bool) (*Config, error) {
 		Agents:     make(map[AgentName]Agent),
 	}
 
-	configureViper()
+	configureViper(viper.GetViper())
 	setDefaults(debug)
 
+	// Check the config files against the schema first, so values that
+	// fail to decode are reported with their line
+	loadIssues = ValidateFiles(ConfigFiles(workingDir)...)
+
 	// Read global config
 	if err := readConfig(viper.ReadInConfig()); err != nil {
 		return cfg, err
 	}
 
+	// The org config, if the global config names one, sits below it
+	loadIssues = append(loadIssues, loadOrgConfig()...)
+
+	// Project settings override global ones
+	if err := mergeProjectConfig(workingDir); err != nil {
+		return cfg, err
+	}
+
+	// Keys the org config locks keep its values
+	loadIssues = append(loadIssues, enforceOrgLocks()...)
+	if err := viper.Unmarshal(cfg); err != nil {
+		return cfg, fmt.Errorf("unable to decode config: %w", err)
+	}
+
 	// Load providers from environment variables
 	loadProvidersFromEnv()
 
@@ -211,14 +411,14 @@ func Load(workingDir string, debug bool) (*Config, error) {
 }
 
 // configureViper sets up viper's configuration paths and environment variables
-func configureViper() {
-	viper.SetConfigName(fmt.Sprintf(".%s", appName))
-	viper.SetConfigType("json")
-	viper.AddConfigPath("$HOME")
-	viper.AddConfigPath(fmt.Sprintf("$XDG_CONFIG_HOME/%s", appName))
-	viper.AddConfigPath(fmt.Sprintf("$HOME/.config/%s", appName))
-	viper.SetEnvPrefix(strings.ToUpper(appName))
-	viper.AutomaticEnv()
+func configureViper(v *viper.Viper) {
+	v.SetConfigName(fmt.Sprintf(".%s", appName))
+	v.SetConfigType("json")
+	v.AddConfigPath("$HOME")
+	v.AddConfigPath(fmt.Sprintf("$XDG_CONFIG_HOME/%s", appName))
+	v.AddConfigPath(fmt.Sprintf("$HOME/.config/%s", appName))
+	v.SetEnvPrefix(strings.ToUpper(appName))
+	v.AutomaticEnv()
 }
 
 // setDefaults configures default values for configuration options
@@ -226,7 +426,13 @@ func setDefaults(debug bool) {
 	viper.SetDefault("data.directory", defaultDataDirectory)
 	viper.SetDefault("contextPaths", defaultContextPaths)
 	viper.SetDefault("tui.theme", "codeforge")
+	viper.SetDefault("tui.mouse", true)
+	viper.SetDefault("tui.followUpModel", "fast")
+	viper.SetDefault("display.ascii_only", false)
 	viper.SetDefault("autoCompact", true)
+	viper.SetDefault("model", "")
+	viper.SetDefault("provider", "")
+	viper.SetDefault("modelAliases", map[string]string{})
 
 	// Context management defaults
 	viper.SetDefault("context.autoSummarize", true)
@@ -247,14 +453,68 @@ func setDefaults(debug bool) {
 	viper.SetDefault("context.maxCacheSize", 1000)
 	viper.SetDefault("context.compressionLevel", 3)
 	viper.SetDefault("context.relevanceThreshold", 0.1)
-
-	// Set default shell from environment or fallback to /bin/bash
-	shellPath := os.Getenv("SHELL")
-	if shellPath == "" {
-		shellPath = "/bin/bash"
-	}
+	viper.SetDefault("context.pinnedBudget", 8000)
+
+	// Outbound filter defaults
+	viper.SetDefault("outboundFilter.enabled", false)
+	viper.SetDefault("outboundFilter.action", "redact")
+	viper.SetDefault("outboundFilter.sensitiveTag", "codeforge:sensitive")
+	viper.SetDefault("outboundFilter.detectPII", true)
+
+	// Local model used in local-only mode, when offline and by the outbound
+	// filter unless it names its own
+	viper.SetDefault("localModel", "llama3.2")
+
+	// Wire log defaults
+	viper.SetDefault("wireLog.enabled", false)
+
+	// Budget defaults
+	viper.SetDefault("budget.hourly", 10.0)
+	viper.SetDefault("budget.daily", 100.0)
+	viper.SetDefault("budget.monthly", 1000.0)
+
+	// Webhook defaults
+	viper.SetDefault("webhooks.budgetThresholds", []float64{80, 100})
+
+	// Docs defaults
+	viper.SetDefault("docs.paths", []string{"docs", "README.md"})
+
+	// Canary defaults
+	viper.SetDefault("canary.enabled", false)
+	viper.SetDefault("canary.candidate", "")
+	viper.SetDefault("canary.percent", 10)
+	viper.SetDefault("canary.log", "")
+
+	// Encryption defaults
+	viper.SetDefault("encryption.enabled", false)
+	viper.SetDefault("encryption.keySource", "auto")
+
+	// Notes defaults
+	viper.SetDefault("notes.injectContext", false)
+
+	// File guardrail defaults
+	viper.SetDefault("files.maxFileSize", 1024*1024)
+	viper.SetDefault("files.largeAttachments", "truncate")
+
+	// Search query defaults
+	viper.SetDefault("embedding.expandQueries", true)
+	viper.SetDefault("embedding.hyde", false)
+
+	// Index defaults
+	viper.SetDefault("index.branchDeltas", false)
+	viper.SetDefault("index.baseBranch", "")
+	viper.SetDefault("index.diversity", 0.3)
+	viper.SetDefault("index.vectors", "mmap")
+	viper.SetDefault("index.mmapSizeMB", 256)
+	viper.SetDefault("index.cacheChunks", 1000)
+	viper.SetDefault("index.maxMemoryMB", 0)
+
+	// Default to $SHELL, or on Windows the bash from Git for Windows; empty
+	// when none is installed
+	shellPath, _ := platform.PosixShell()
 	viper.SetDefault("shell.path", shellPath)
 	viper.SetDefault("shell.args", []string{"-l"})
+	viper.SetDefault("shell.timeout_seconds", 120)
 
 	if debug {
 		viper.SetDefault("debug", true)
@@ -288,29 +548,17 @@ func readConfig(err error) error {
 
 // loadProvidersFromEnv loads provider configurations from environment variables
 func loadProvidersFromEnv() {
-	providers := map[models.ModelProvider]string{
-		models.ProviderCopilot:    "COPILOT_API_KEY",
-		models.ProviderAnthropic:  "ANTHROPIC_API_KEY",
-		models.ProviderOpenAI:     "OPENAI_API_KEY",
-		models.ProviderGemini:     "GEMINI_API_KEY",
-		models.ProviderGROQ:       "GROQ_API_KEY",
-		models.ProviderOpenRouter: "OPENROUTER_API_KEY",
-		models.ProviderBedrock:    "AWS_ACCESS_KEY_ID", // Special case for AWS
-		models.ProviderAzure:      "AZURE_OPENAI_API_KEY",
-		models.ProviderVertexAI:   "GOOGLE_APPLICATION_CREDENTIALS", // Special case for GCP
-		models.ProviderXAI:        "XAI_API_KEY",
-		models.ProviderLocal:      "LOCAL_ENDPOINT", // Special case for local
-	}
-
-	for provider, envVar := range providers {
-		if apiKey := os.Getenv(envVar); apiKey != "" {
+	for _, spec := range allProviders() {
+		if spec.KeyEnv == "" {
+			continue
+		}
+		if apiKey := os.Getenv(spec.KeyEnv); apiKey != "" {
 			if cfg.Providers == nil {
 				cfg.Providers = make(map[models.ModelProvider]Provider)
 			}
-			cfg.Providers[provider] = Provider{
-				APIKey:   apiKey,
-				Disabled: false,
-			}
+			providerCfg := cfg.Providers[models.ModelProvider(spec.ID)]
+			providerCfg.APIKey = apiKey
+			cfg.Providers[models.ModelProvider(spec.ID)] = providerCfg
 		}
 	}
 }
@@ -371,6 +619,11 @@ func Get() *Config {
 	return cfg
 }
 
+// Issues returns the problems found in the config files when they were loaded
+func Issues() []Issue {
+	return loadIssues
+}
+
 // WorkingDirectory returns the current working directory from the configuration
 func WorkingDirectory() string {
 	if cfg == nil {
@@ -379,6 +632,76 @@ func WorkingDirectory() string {
 	return cfg.WorkingDir
 }
 
+// WireLogDir returns the directory wire logs are written to
+func (c *Config) WireLogDir() string {
+	if c.WireLog.Dir != "" {
+		return c.WireLog.Dir
+	}
+	return filepath.Join(c.Data.Directory, "wirelog")
+}
+
+// CanaryLogPath returns the file canary events are written to
+func (c *Config) CanaryLogPath() string {
+	if c.Canary.Log != "" {
+		return c.Canary.Log
+	}
+	return filepath.Join(c.Data.Directory, "canary.jsonl")
+}
+
+// NotesPath returns the file holding the project's scratch notes
+func (c *Config) NotesPath() string {
+	if c.Notes.Path != "" {
+		return c.Notes.Path
+	}
+	return filepath.Join(c.Data.Directory, "notes.md")
+}
+
+// DiagramDir returns the directory rendered diagram pages are written to
+func (c *Config) DiagramDir() string {
+	return filepath.Join(c.Data.Directory, "diagrams")
+}
+
+// AgentRunDir returns the directory agent runs are saved to, for resuming
+func (c *Config) AgentRunDir() string {
+	return filepath.Join(c.Data.Directory, "agent-runs")
+}
+
+// EditBackupDir returns the directory files are backed up to before chat
+// edits replace them
+func (c *Config) EditBackupDir() string {
+	return filepath.Join(c.Data.Directory, "backups")
+}
+
+// KeymapPath returns the TUI keymap file, by default keymap.json in the
+// user config directory
+func (c *Config) KeymapPath() string {
+	if c.TUI.Keymap != "" {
+		return c.TUI.Keymap
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(c.Data.Directory, "keymap.json")
+	}
+	return filepath.Join(homeDir, ".config", appName, "keymap.json")
+}
+
+// IsLocalOnly reports whether LLM and embedding traffic is restricted to local endpoints
+func IsLocalOnly() bool {
+	return cfg != nil && cfg.LocalOnly
+}
+
+// SetLocalOnly enables or disables local-only mode for this process
+func SetLocalOnly(enabled bool) {
+	if cfg != nil {
+		cfg.LocalOnly = enabled
+	}
+}
+
+// LocalOnlyError returns the error reported when a feature would call a cloud API in local-only mode
+func LocalOnlyError(feature string) error {
+	return fmt.Errorf("local-only mode: %s would call a cloud API; use an Ollama or LM Studio model (e.g. --model ollama/llama3.2) or disable localOnly", feature)
+}
+
 // GetModelConfig returns configuration for a specific model
 func (c *Config) GetModelConfig(modelID string) ModelConfig {
 	if config, exists := c.Models[modelID]; exists {
@@ -495,6 +818,8 @@ func GetProvider(provider models.ModelProvider) (Provider, bool) {
 	if cfg == nil {
 		return Provider{}, false
 	}
+	providersMu.RLock()
+	defer providersMu.RUnlock()
 	providerCfg, exists := cfg.Providers[provider]
 	return providerCfg, exists
 }
@@ -586,10 +911,10 @@ func initializeEnhancedManagers() {
 		cfg.ProviderManager.SetProviderConfig(providerID, providerConfig)
 	}
 
-	// Set up default budgets for cost tracking
-	cfg.CostTracker.SetBudget(PeriodHourly, 10.0)    // $10/hour default
-	cfg.CostTracker.SetBudget(PeriodDaily, 100.0)    // $100/day default
-	cfg.CostTracker.SetBudget(PeriodMonthly, 1000.0) // $1000/month default
+	// Set up budgets for cost tracking
+	cfg.CostTracker.SetBudget(PeriodHourly, cfg.Budget.Hourly)
+	cfg.CostTracker.SetBudget(PeriodDaily, cfg.Budget.Daily)
+	cfg.CostTracker.SetBudget(PeriodMonthly, cfg.Budget.Monthly)
 
 	// Create default tool configurations
 	defaultTools := []string{
@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"log"
 	"sync"
 	"time"
 
@@ -176,6 +177,10 @@ type CostTracker struct {
 	// Budget tracking
 	budgets         map[CostPeriod]float64
 	currentSpending map[CostPeriod]float64
+	spendingSince   map[CostPeriod]time.Time // Start of the period currentSpending covers
+
+	// Called when a budget alert triggers
+	alertHandler func(alert BudgetAlert, spending, budget float64)
 
 	// Optimization
 	optimizationEnabled bool
@@ -192,6 +197,7 @@ func NewCostTracker() *CostTracker {
 		retentionPeriod:     30 * 24 * time.Hour, // 30 days
 		budgets:             make(map[CostPeriod]float64),
 		currentSpending:     make(map[CostPeriod]float64),
+		spendingSince:       make(map[CostPeriod]time.Time),
 		optimizationEnabled: true,
 	}
 }
@@ -367,6 +373,15 @@ func (ct *CostTracker) AddBudgetAlert(alert *BudgetAlert) {
 	ct.alerts[alert.ID] = alert
 }
 
+// SetAlertHandler sets the function called when a budget alert triggers.
+// It runs with the tracker locked and must not call back into it.
+func (ct *CostTracker) SetAlertHandler(handler func(alert BudgetAlert, spending, budget float64)) {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+
+	ct.alertHandler = handler
+}
+
 // GetOptimizationRecommendations returns cost optimization recommendations
 func (ct *CostTracker) GetOptimizationRecommendations() []CostOptimizationRecommendation {
 	if !ct.optimizationEnabled {
@@ -412,22 +427,33 @@ func (ct *CostTracker) EstimateCost(modelID models.ModelID, inputTokens, outputT
 func (ct *CostTracker) updateCurrentSpending(record TokenUsageRecord) {
 	now := time.Now()
 
-	// Update hourly spending
-	hourStart := time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), 0, 0, 0, now.Location())
-	if record.Timestamp.After(hourStart) {
-		ct.currentSpending[PeriodHourly] += record.TotalCost
-	}
+	for _, period := range []CostPeriod{PeriodHourly, PeriodDaily, PeriodMonthly} {
+		start := periodStart(period, now)
+
+		// Spending starts over with each period
+		if !ct.spendingSince[period].Equal(start) {
+			ct.currentSpending[period] = 0
+			ct.spendingSince[period] = start
+		}
 
-	// Update daily spending
-	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-	if record.Timestamp.After(dayStart) {
-		ct.currentSpending[PeriodDaily] += record.TotalCost
+		if !record.Timestamp.Before(start) {
+			ct.currentSpending[period] += record.TotalCost
+		}
 	}
+}
 
-	// Update monthly spending
-	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
-	if record.Timestamp.After(monthStart) {
-		ct.currentSpending[PeriodMonthly] += record.TotalCost
+// periodStart returns the start of the period containing t
+func periodStart(period CostPeriod, t time.Time) time.Time {
+	switch period {
+	case PeriodHourly:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+	case PeriodWeekly:
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+		return day.AddDate(0, 0, -int(day.Weekday()))
+	case PeriodMonthly:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
 	}
 }
 
@@ -444,11 +470,17 @@ func (ct *CostTracker) trimRecords() {
 
 // checkBudgetAlerts checks if any budget alerts should be triggered
 func (ct *CostTracker) checkBudgetAlerts(_ TokenUsageRecord) {
+	now := time.Now()
 	for _, alert := range ct.alerts {
 		if !alert.Enabled {
 			continue
 		}
 
+		// An alert triggers once per period
+		if !alert.LastTriggered.Before(periodStart(alert.Period, now)) {
+			continue
+		}
+
 		currentSpending := ct.currentSpending[alert.Period]
 		budget := ct.budgets[alert.Period]
 
@@ -458,9 +490,12 @@ func (ct *CostTracker) checkBudgetAlerts(_ TokenUsageRecord) {
 			if (alert.Threshold > 0 && currentSpending >= alert.Threshold) ||
 				(alert.ThresholdPercent > 0 && percentage >= alert.ThresholdPercent) {
 
-				// Trigger alert - log and update timestamp
+				// Trigger alert - log, notify and update timestamp
 				ct.logBudgetAlert(alert, currentSpending, percentage)
-				alert.LastTriggered = time.Now()
+				alert.LastTriggered = now
+				if ct.alertHandler != nil {
+					ct.alertHandler(*alert, currentSpending, budget)
+				}
 			}
 		}
 	}
@@ -550,8 +585,8 @@ func (ct *CostTracker) analyzeProviderUsage(summary *CostSummary) []CostOptimiza
 
 // logBudgetAlert logs a budget alert when triggered
 func (ct *CostTracker) logBudgetAlert(alert *BudgetAlert, currentSpending, percentage float64) {
-	// In a production system, this would send notifications via email, Slack, etc.
-	// For now, we'll use structured logging
-	fmt.Printf("BUDGET ALERT: %s - Current spending: $%.4f (%.1f%% of budget)\n",
+	// Webhooks are sent through the alert handler; the log keeps a record
+	// without writing over the TUI
+	log.Printf("BUDGET ALERT: %s - Current spending: $%.4f (%.1f%% of budget)",
 		alert.Name, currentSpending, percentage)
 }
@@ -0,0 +1,27 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// newHTTPClient builds the clients for the config's own requests. The
+// httpclient package sets it, since it builds on this one.
+var newHTTPClient func(provider string, timeout time.Duration) *http.Client
+
+// SetHTTPClient sets how clients for the config's own requests, such as
+// fetching the org config, are built, so they honour the proxy and CA
+// settings
+func SetHTTPClient(build func(provider string, timeout time.Duration) *http.Client) {
+	newHTTPClient = build
+}
+
+// httpClient returns a client for provider's requests. Without one set,
+// requests fail rather than going around the proxy settings.
+func httpClient(provider string, timeout time.Duration) (*http.Client, error) {
+	if newHTTPClient == nil {
+		return nil, fmt.Errorf("no HTTP client that honours the proxy settings is set up")
+	}
+	return newHTTPClient(provider, timeout), nil
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/models"
+)
+
+// keyVersion counts the provider keys replaced since startup
+var keyVersion atomic.Uint64
+
+// providersMu guards cfg.Providers, which SetProviderKey changes while
+// handlers read it
+var providersMu sync.RWMutex
+
+// KeyProviders returns the providers whose API keys can be replaced at
+// runtime. Providers with other credentials, such as AWS or GCP, aren't
+// included.
+func KeyProviders() []string {
+	var names []string
+	for _, spec := range allProviders() {
+		if strings.HasSuffix(spec.KeyEnv, "_API_KEY") {
+			names = append(names, spec.ID)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ProviderKey returns the API key currently used for the provider: its
+// environment variable, or else the key in the config file
+func ProviderKey(provider string) string {
+	spec, ok := LookupProvider(provider)
+	if !ok {
+		return ""
+	}
+	return spec.APIKey()
+}
+
+// SetProviderKey replaces a provider's API key without a restart, for key
+// rotation. Handlers built afterwards use the new key and chat sessions
+// rebuild theirs before their next message. The key isn't saved; update it
+// wherever it's stored too.
+func SetProviderKey(provider, key string) error {
+	spec, ok := LookupProvider(provider)
+	if !ok || !strings.HasSuffix(spec.KeyEnv, "_API_KEY") {
+		return fmt.Errorf("unknown provider %q (keys can be set for %s)", provider, strings.Join(KeyProviders(), ", "))
+	}
+	provider, envVar := spec.ID, spec.KeyEnv
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return fmt.Errorf("no API key given for %s", provider)
+	}
+
+	if err := os.Setenv(envVar, key); err != nil {
+		return fmt.Errorf("failed to set %s: %w", envVar, err)
+	}
+	if cfg != nil {
+		providersMu.Lock()
+		if cfg.Providers == nil {
+			cfg.Providers = make(map[models.ModelProvider]Provider)
+		}
+		providerCfg := cfg.Providers[models.ModelProvider(provider)]
+		providerCfg.APIKey = key
+		cfg.Providers[models.ModelProvider(provider)] = providerCfg
+		providersMu.Unlock()
+	}
+	keyVersion.Add(1)
+	return nil
+}
+
+// KeyVersion changes whenever a provider key is replaced, so holders of
+// long-lived handlers can tell when to rebuild them
+func KeyVersion() uint64 {
+	return keyVersion.Load()
+}
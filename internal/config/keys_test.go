@@ -0,0 +1,56 @@
+package config
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/models"
+)
+
+func TestSetProviderKey(t *testing.T) {
+	useProjectDir(t, "")
+	t.Setenv("ANTHROPIC_API_KEY", "old")
+
+	version := KeyVersion()
+	if err := SetProviderKey("anthropic", " new \n"); err != nil {
+		t.Fatalf("SetProviderKey() error = %v", err)
+	}
+	if got := ProviderKey("anthropic"); got != "new" {
+		t.Errorf("ProviderKey() = %q, want the trimmed new key", got)
+	}
+	if got := cfg.Providers[models.ProviderAnthropic].APIKey; got != "new" {
+		t.Errorf("config key = %q, want new", got)
+	}
+	if KeyVersion() == version {
+		t.Error("KeyVersion() didn't change")
+	}
+
+	for _, provider := range []string{"bedrock", "nope"} {
+		err := SetProviderKey(provider, "k")
+		if err == nil || !strings.Contains(err.Error(), `"`+provider+`"`) {
+			t.Errorf("SetProviderKey(%s) error = %v, want one naming the provider", provider, err)
+		}
+	}
+	if err := SetProviderKey("openai", "  "); err == nil {
+		t.Error("SetProviderKey() accepted an empty key")
+	}
+
+	// Keys can be replaced while handlers read the config
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			SetProviderKey("openai", "k")
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		ProviderKey("openai")
+		ListProviders()
+	}
+	<-done
+
+	if slices.Contains(KeyProviders(), "bedrock") || !slices.Contains(KeyProviders(), "openai") {
+		t.Errorf("KeyProviders() = %v", KeyProviders())
+	}
+}
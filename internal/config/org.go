@@ -0,0 +1,427 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// OrgConfig points at a base config an organization publishes for all its
+// developers. The org config sits below the user and project configs,
+// except for the keys it locks.
+type OrgConfig struct {
+	URL       string   `json:"url,omitempty"`       // https:// URL of the config, or git+<repository>#<file> for a file in a git repository
+	PublicKey string   `json:"publicKey,omitempty"` // Base64 Ed25519 public key the config's signature must verify with
+	Refresh   string   `json:"refresh,omitempty"`   // How long a fetched config is used before fetching it again
+	Locked    []string `json:"locked,omitempty"`    // Read from the org config only: keys user and project configs can't change
+	Version   int64    `json:"version,omitempty"`   // Read from the org config only: raised with each change, so an older signed copy can't replace it
+}
+
+// orgFetchTimeout bounds fetching the org config, so startup isn't held up
+// by an unreachable server
+const orgFetchTimeout = 10 * time.Second
+
+// defaultOrgFile is the file read from a git repository when the URL
+// doesn't name one
+const defaultOrgFile = "codeforge.yaml"
+
+// orgLocked holds the locked keys of the applied org config and their values
+var orgLocked map[string]any
+
+// orgFetchedAt is when the applied org config was fetched, zero when none
+// was applied
+var orgFetchedAt time.Time
+
+// OrgInfo describes the org config applied by Load
+type OrgInfo struct {
+	URL       string    `json:"url"`
+	Applied   bool      `json:"applied"`
+	FetchedAt time.Time `json:"fetched_at,omitempty"`
+	Locked    []string  `json:"locked,omitempty"` // Locked keys, one per setting
+}
+
+// OrgStatus returns the org config applied by Load
+func OrgStatus() OrgInfo {
+	info := OrgInfo{URL: viper.GetString("org.url"), Applied: !orgFetchedAt.IsZero(), FetchedAt: orgFetchedAt}
+	for key := range orgLocked {
+		info.Locked = append(info.Locked, key)
+	}
+	sort.Strings(info.Locked)
+	return info
+}
+
+// RefreshOrgConfig fetches the org config now, replacing the cached copy
+// once it's verified. It applies from the next start.
+func RefreshOrgConfig(ctx context.Context) error {
+	url := viper.GetString("org.url")
+	if url == "" {
+		return fmt.Errorf("no org config: set org.url and org.publicKey in the global config")
+	}
+	publicKey, err := parsePublicKey(viper.GetString("org.publicKey"))
+	if err != nil {
+		return err
+	}
+	doc, sig, err := FetchOrgConfig(ctx, url)
+	if err != nil {
+		return err
+	}
+	if err := VerifyOrgConfig(doc, sig, publicKey); err != nil {
+		return fmt.Errorf("org config rejected: it %w", err)
+	}
+	_, _, version, err := parseOrgConfig(doc)
+	if err != nil {
+		return err
+	}
+	cachePath := orgCachePath(url)
+	if err := checkOrgVersion(cachePath, version); err != nil {
+		return fmt.Errorf("org config rejected: it %w", err)
+	}
+	return writeOrgCache(cachePath, doc, sig, version)
+}
+
+// SignOrgConfig returns the detached signature to publish next to an org
+// config, as <file>.sig
+func SignOrgConfig(doc []byte, key ed25519.PrivateKey) []byte {
+	return []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(key, doc)) + "\n")
+}
+
+// loadOrgConfig fetches the org config named by the global config, verifies
+// its signature and applies its settings as defaults, below the user and
+// project configs. Problems are reported as issues and leave the org config
+// out, falling back to the last verified copy when there is one.
+func loadOrgConfig() []Issue {
+	orgLocked, orgFetchedAt = nil, time.Time{}
+	url := viper.GetString("org.url")
+	if url == "" {
+		return nil
+	}
+	issue := func(severity, format string, args ...any) Issue {
+		return Issue{File: url, Severity: severity, Key: "org", Message: fmt.Sprintf(format, args...)}
+	}
+
+	publicKey, err := parsePublicKey(viper.GetString("org.publicKey"))
+	if err != nil {
+		return []Issue{issue(SeverityError, "org config not applied: %v", err)}
+	}
+
+	refresh := 24 * time.Hour
+	if r := viper.GetString("org.refresh"); r != "" {
+		if refresh, err = time.ParseDuration(r); err != nil {
+			return []Issue{issue(SeverityError, "org config not applied: invalid org.refresh: %v", err)}
+		}
+	}
+
+	// The fetch goes through the proxy, which the rest of the config isn't
+	// decoded far enough yet to give
+	if cfg != nil {
+		if err := viper.UnmarshalKey("proxy", &cfg.Proxy); err != nil {
+			return []Issue{issue(SeverityError, "org config not applied: invalid proxy settings: %v", err)}
+		}
+	}
+
+	var issues []Issue
+	fetched := false
+	cachePath := orgCachePath(url)
+	doc, sig, fetchedAt, cacheErr := readOrgCache(cachePath)
+	if cacheErr != nil || time.Since(fetchedAt) > refresh {
+		ctx, cancel := context.WithTimeout(context.Background(), orgFetchTimeout)
+		fetchedDoc, fetchedSig, err := FetchOrgConfig(ctx, url)
+		cancel()
+
+		var version int64
+		if err == nil {
+			if err = VerifyOrgConfig(fetchedDoc, fetchedSig, publicKey); err == nil {
+				if _, _, version, err = parseOrgConfig(fetchedDoc); err == nil {
+					err = checkOrgVersion(cachePath, version)
+				}
+			}
+			if err != nil {
+				err = fmt.Errorf("fetched org config rejected: it %w", err)
+			}
+		} else {
+			err = fmt.Errorf("failed to fetch org config: %w", err)
+		}
+
+		if err != nil {
+			severity := SeverityError
+			if !strings.HasPrefix(err.Error(), "fetched") {
+				severity = SeverityWarning
+			}
+			issues = append(issues, issue(severity, "%v", err))
+		} else {
+			doc, sig, cacheErr, fetched = fetchedDoc, fetchedSig, nil, true
+			if err := writeOrgCache(cachePath, doc, sig, version); err != nil {
+				issues = append(issues, issue(SeverityWarning, "failed to cache org config: %v", err))
+			}
+		}
+		if cacheErr == nil && !fetched {
+			issues = append(issues, issue(SeverityWarning, "using the copy fetched %s", fetchedAt.Format(time.RFC3339)))
+		}
+	}
+	if cacheErr != nil {
+		return append(issues, issue(SeverityError, "org config not applied: no verified copy available"))
+	}
+
+	// The cache could have been edited since it was written
+	if err := VerifyOrgConfig(doc, sig, publicKey); err != nil {
+		return append(issues, issue(SeverityError, "org config not applied: cached copy %v", err))
+	}
+
+	settings, locked, version, err := parseOrgConfig(doc)
+	if err != nil {
+		return append(issues, issue(SeverityError, "org config not applied: %v", err))
+	}
+	if err := checkOrgVersion(cachePath, version); err != nil {
+		return append(issues, issue(SeverityError, "org config not applied: cached copy %v", err))
+	}
+	orgFetchedAt = fetchedAt
+	if fetched {
+		orgFetchedAt = time.Now()
+	}
+	flat := flattenSettings("", settings)
+	for key, value := range flat {
+		viper.SetDefault(key, value)
+	}
+
+	// Locking a section locks every key in it
+	orgLocked = map[string]any{}
+	for _, lock := range locked {
+		lock = strings.ToLower(lock)
+		for key, value := range flat {
+			if key == lock || strings.HasPrefix(key, lock+".") {
+				orgLocked[key] = value
+			}
+		}
+	}
+	return issues
+}
+
+// enforceOrgLocks puts back the org values of locked keys, reporting local
+// settings that tried to change them
+func enforceOrgLocks() []Issue {
+	var issues []Issue
+	for key, value := range orgLocked {
+		if current := viper.Get(key); fmt.Sprint(current) != fmt.Sprint(value) {
+			issues = append(issues, Issue{
+				File:     viper.GetString("org.url"),
+				Key:      key,
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("%s is locked by the org config; the local setting is ignored", key),
+			})
+		}
+		viper.Set(key, value)
+	}
+	return issues
+}
+
+// FetchOrgConfig downloads an org config and its detached signature. The
+// signature is the file's URL with .sig appended.
+func FetchOrgConfig(ctx context.Context, url string) (doc, sig []byte, err error) {
+	if repo, ok := strings.CutPrefix(url, "git+"); ok {
+		return fetchOrgConfigGit(ctx, repo)
+	}
+	if !strings.HasPrefix(url, "https://") {
+		return nil, nil, fmt.Errorf("org config URL must use https:// or git+")
+	}
+	if doc, err = httpGet(ctx, url); err != nil {
+		return nil, nil, err
+	}
+	if sig, err = httpGet(ctx, url+".sig"); err != nil {
+		return nil, nil, fmt.Errorf("signature: %w", err)
+	}
+	return doc, sig, nil
+}
+
+// fetchOrgConfigGit reads the config and signature from a shallow clone
+// of repo, given as <repository>#<file>
+func fetchOrgConfigGit(ctx context.Context, repo string) ([]byte, []byte, error) {
+	repo, file, _ := strings.Cut(repo, "#")
+	if file == "" {
+		file = defaultOrgFile
+	}
+	if strings.HasPrefix(repo, "-") {
+		return nil, nil, fmt.Errorf("invalid repository %q", repo)
+	}
+
+	dir, err := os.MkdirTemp("", "codeforge-org-")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	cmd := exec.CommandContext(ctx, "git", "clone", "--depth", "1", "--quiet", "--", repo, dir)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return nil, nil, fmt.Errorf("git clone failed: %s", strings.TrimSpace(string(out)))
+	}
+
+	// Keep the file inside the clone
+	file = path.Clean("/" + filepath.ToSlash(file))
+	doc, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(file)))
+	if err != nil {
+		return nil, nil, err
+	}
+	sig, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(file)+".sig"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("signature: %w", err)
+	}
+	return doc, sig, nil
+}
+
+func httpGet(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	client, err := httpClient("org", orgFetchTimeout)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	// Configs are small; anything bigger isn't one
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+// VerifyOrgConfig checks doc against its base64 Ed25519 signature
+func VerifyOrgConfig(doc, sig []byte, publicKey ed25519.PublicKey) error {
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+	if err != nil {
+		return fmt.Errorf("has a malformed signature: %w", err)
+	}
+	if !ed25519.Verify(publicKey, doc, decoded) {
+		return fmt.Errorf("doesn't match its signature")
+	}
+	return nil
+}
+
+func parsePublicKey(encoded string) (ed25519.PublicKey, error) {
+	if encoded == "" {
+		return nil, fmt.Errorf("org.publicKey is required to verify the org config")
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("org.publicKey must be a base64 Ed25519 public key")
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// parseOrgConfig returns the settings of an org config, the keys it locks
+// and its version. Its own org section is dropped, so it can't redirect the
+// source.
+func parseOrgConfig(doc []byte) (map[string]any, []string, int64, error) {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	if err := v.ReadConfig(bytes.NewReader(doc)); err != nil {
+		return nil, nil, 0, fmt.Errorf("failed to parse: %w", err)
+	}
+	locked := v.GetStringSlice("org.locked")
+	version := v.GetInt64("org.version")
+	settings := v.AllSettings()
+	delete(settings, "org")
+	return settings, locked, version, nil
+}
+
+// checkOrgVersion refuses an org config older than the last one accepted
+// from the same place: replaying an old signed copy would otherwise undo
+// the changes made since, such as a new lock
+func checkOrgVersion(cachePath string, version int64) error {
+	if accepted := acceptedOrgVersion(cachePath); version < accepted {
+		return fmt.Errorf("is version %d, older than version %d already accepted", version, accepted)
+	}
+	return nil
+}
+
+// acceptedOrgVersion returns the highest version of the org config cached
+// at cachePath accepted so far, 0 before any
+func acceptedOrgVersion(cachePath string) int64 {
+	data, err := os.ReadFile(cachePath + ".version")
+	if err != nil {
+		return 0
+	}
+	version, _ := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	return version
+}
+
+// flattenSettings turns nested settings into dotted keys, so defaults merge
+// with config files key by key rather than section by section
+func flattenSettings(prefix string, settings map[string]any) map[string]any {
+	flat := map[string]any{}
+	for key, value := range settings {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if nested, ok := value.(map[string]any); ok && len(nested) > 0 {
+			for k, v := range flattenSettings(key, nested) {
+				flat[k] = v
+			}
+			continue
+		}
+		flat[key] = value
+	}
+	return flat
+}
+
+// orgCachePath is where the last verified copy of the org config at url is
+// kept; its signature sits next to it
+func orgCachePath(url string) string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(dir, appName, "org", hex.EncodeToString(sum[:8])+".yaml")
+}
+
+func readOrgCache(path string) (doc, sig []byte, fetchedAt time.Time, err error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, time.Time{}, err
+	}
+	if doc, err = os.ReadFile(path); err != nil {
+		return nil, nil, time.Time{}, err
+	}
+	if sig, err = os.ReadFile(path + ".sig"); err != nil {
+		return nil, nil, time.Time{}, err
+	}
+	return doc, sig, info.ModTime(), nil
+}
+
+// writeOrgCache keeps doc as the last verified copy, raising the version
+// later copies must have to at least version
+func writeOrgCache(path string, doc, sig []byte, version int64) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path+".sig", sig, 0o644); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, doc, 0o644); err != nil {
+		return err
+	}
+	if version > acceptedOrgVersion(path) {
+		return os.WriteFile(path+".version", []byte(strconv.FormatInt(version, 10)+"\n"), 0o644)
+	}
+	return nil
+}
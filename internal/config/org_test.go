@@ -0,0 +1,128 @@
+package config
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestLoadOrgConfig(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	dir := useProjectDir(t, `approvedModels: ["openai/*"]
+model: gpt-4.1
+`)
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const url = "https://config.example.com/codeforge.yaml"
+	doc := []byte(`approvedModels: ["anthropic/*", "ollama/*"]
+model: claude-sonnet-4-20250514
+org:
+  url: https://elsewhere.example.com/codeforge.yaml
+  locked: [approvedModels]
+  version: 3
+`)
+	// A fresh cached copy is used without fetching
+	cache := orgCachePath(url)
+	if err := writeOrgCache(cache, doc, SignOrgConfig(doc, private), 3); err != nil {
+		t.Fatal(err)
+	}
+	viper.Set("org.url", url)
+	viper.Set("org.publicKey", base64.StdEncoding.EncodeToString(public))
+
+	if issues := loadOrgConfig(); len(issues) > 0 {
+		t.Fatalf("loadOrgConfig() issues = %v", issues)
+	}
+	if err := mergeProjectConfig(dir); err != nil {
+		t.Fatal(err)
+	}
+	issues := enforceOrgLocks()
+	if err := viper.Unmarshal(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(issues) != 1 || !strings.Contains(issues[0].Message, "approvedmodels is locked") {
+		t.Errorf("enforceOrgLocks() = %v, want the overridden lock reported", issues)
+	}
+	if got := strings.Join(cfg.ApprovedModels, ","); got != "anthropic/*,ollama/*" {
+		t.Errorf("approvedModels = %s, want the locked org value", got)
+	}
+	if cfg.Model != "gpt-4.1" {
+		t.Errorf("model = %s, want the project value over the org one", cfg.Model)
+	}
+	if viper.GetString("org.url") != url {
+		t.Error("the org config redirected org.url")
+	}
+	if info := OrgStatus(); !info.Applied || strings.Join(info.Locked, ",") != "approvedmodels" {
+		t.Errorf("OrgStatus() = %+v", info)
+	}
+
+	// A tampered copy isn't applied
+	if err := os.WriteFile(cache, append(doc, "localOnly: false\n"...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	issues = loadOrgConfig()
+	if len(issues) != 1 || !strings.Contains(issues[0].Message, "doesn't match its signature") {
+		t.Errorf("loadOrgConfig() with a tampered cache = %v", issues)
+	}
+	if OrgStatus().Applied {
+		t.Error("tampered org config was applied")
+	}
+
+	// An older signed copy can't be replayed to undo the lock
+	old := []byte("approvedModels: [\"*\"]\norg:\n  version: 2\n")
+	if err := writeOrgCache(cache, old, SignOrgConfig(old, private), 2); err != nil {
+		t.Fatal(err)
+	}
+	issues = loadOrgConfig()
+	if len(issues) != 1 || !strings.Contains(issues[0].Message, "older than version 3") {
+		t.Errorf("loadOrgConfig() with a replayed cache = %v", issues)
+	}
+	if OrgStatus().Applied {
+		t.Error("replayed org config was applied")
+	}
+}
+
+func TestLoadOrgConfig_RequiresKey(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	useProjectDir(t, "")
+	viper.Set("org.url", "git+https://example.com/config.git")
+
+	issues := loadOrgConfig()
+	if len(issues) != 1 || !strings.Contains(issues[0].Message, "org.publicKey is required") {
+		t.Errorf("loadOrgConfig() = %v, want a missing key error", issues)
+	}
+	if _, err := os.Stat(filepath.Dir(orgCachePath("git+https://example.com/config.git"))); err == nil {
+		t.Error("an unverifiable org config was fetched")
+	}
+}
+
+func TestModelApproved(t *testing.T) {
+	c := &Config{ApprovedModels: []string{"anthropic/*", "gpt-4.1", "openrouter/meta-llama/*"}}
+	tests := []struct {
+		provider, model string
+		want            bool
+	}{
+		{"anthropic", "claude-sonnet-4-20250514", true},
+		{"openai", "gpt-4.1", true},
+		{"openai", "gpt-4o", false},
+		{"openrouter", "meta-llama/llama-3.3-70b-instruct", true},
+		{"openrouter", "openai/gpt-4o", false},
+	}
+	for _, tt := range tests {
+		if got := c.ModelApproved(tt.provider, tt.model); got != tt.want {
+			t.Errorf("ModelApproved(%s, %s) = %v, want %v", tt.provider, tt.model, got, tt.want)
+		}
+	}
+	if !(&Config{}).ModelApproved("openai", "gpt-4o") {
+		t.Error("no approvedModels should allow every model")
+	}
+}
@@ -0,0 +1,210 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectConfigFile is the per-project config file. It lives in the project
+// root and its settings override the global config.
+const ProjectConfigFile = ".codeforge.yaml"
+
+// ProjectConfigPath returns the path of the project config file for the
+// working directory
+func ProjectConfigPath() string {
+	return filepath.Join(WorkingDirectory(), ProjectConfigFile)
+}
+
+// mergeProjectConfig merges the project config file in workingDir, if any,
+// over the global config
+func mergeProjectConfig(workingDir string) error {
+	path := filepath.Join(workingDir, ProjectConfigFile)
+	if _, err := os.Stat(path); err != nil {
+		return nil
+	}
+
+	project := viper.New()
+	project.SetConfigFile(path)
+	if err := project.ReadInConfig(); err != nil {
+		return fmt.Errorf("error reading %s: %w", path, err)
+	}
+	if err := viper.MergeConfigMap(project.AllSettings()); err != nil {
+		return fmt.Errorf("error merging %s: %w", path, err)
+	}
+
+	if err := viper.Unmarshal(cfg); err != nil {
+		return fmt.Errorf("unable to decode config: %w", err)
+	}
+	return nil
+}
+
+// ForProject returns the config as it applies in another project directory:
+// the loaded settings with that directory's project config file merged over
+// them, and the keys the org config locks put back. The loaded config is
+// left as it is.
+func ForProject(dir string) (*Config, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("config not loaded")
+	}
+
+	v := viper.New()
+	if err := v.MergeConfigMap(viper.AllSettings()); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, ProjectConfigFile)
+	if _, err := os.Stat(path); err == nil {
+		project := viper.New()
+		project.SetConfigFile(path)
+		if err := project.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("error reading %s: %w", path, err)
+		}
+		if err := v.MergeConfigMap(project.AllSettings()); err != nil {
+			return nil, fmt.Errorf("error merging %s: %w", path, err)
+		}
+	}
+	for key, value := range orgLocked {
+		v.Set(key, value)
+	}
+
+	projectCfg := &Config{}
+	if err := v.Unmarshal(projectCfg); err != nil {
+		return nil, fmt.Errorf("unable to decode config: %w", err)
+	}
+	projectCfg.WorkingDir = dir
+	return projectCfg, nil
+}
+
+// SetProjectValue writes key (a dotted config key such as "model" or
+// "files.maxFileSize") to the project config file and applies it to the
+// loaded config
+func SetProjectValue(key, value string) error {
+	return SetProjectValues(map[string]any{key: value})
+}
+
+// SetProjectValues writes several settings to the project config file at
+// once and applies them to the loaded config. Nothing is written unless
+// every key is known and every value has the right type.
+func SetProjectValues(values map[string]any) error {
+	if cfg == nil {
+		return fmt.Errorf("config not loaded")
+	}
+	for key, value := range values {
+		if err := checkValue(key, value); err != nil {
+			return err
+		}
+	}
+
+	path := ProjectConfigPath()
+	settings := map[string]any{}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := yaml.Unmarshal(data, &settings); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	for key, value := range values {
+		// Walk down to the map holding the last part of the key
+		parts := strings.Split(key, ".")
+		section := settings
+		for _, part := range parts[:len(parts)-1] {
+			next, ok := section[part].(map[string]any)
+			if !ok {
+				next = map[string]any{}
+				section[part] = next
+			}
+			section = next
+		}
+		section[parts[len(parts)-1]] = value
+	}
+
+	data, err = yaml.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	for key, value := range values {
+		viper.Set(key, value)
+	}
+	if err := viper.Unmarshal(cfg); err != nil {
+		return fmt.Errorf("unable to decode config: %w", err)
+	}
+	return nil
+}
+
+// checkValue reports whether value can be set at key. Keys inside maps,
+// such as "modelAliases.fast", are user-defined so they needn't exist yet.
+func checkValue(key string, value any) error {
+	schema := schemaAt(key)
+	if schema == nil {
+		if viper.IsSet(key) {
+			return nil
+		}
+		return fmt.Errorf("unknown config key %q", key)
+	}
+
+	var n yaml.Node
+	if err := n.Encode(value); err != nil {
+		return fmt.Errorf("invalid value for %s: %w", key, err)
+	}
+	v := validator{}
+	v.check(&n, schema, key)
+	for _, issue := range v.issues {
+		if issue.Severity == SeverityError {
+			return errors.New(issue.Message)
+		}
+	}
+	return nil
+}
+
+// restartKeys are the config sections only read at startup
+var restartKeys = []string{
+	"data", "debug", "debugLSP", "embedding", "encryption", "index", "log", "lsp", "mcp",
+	"mcpServers", "permissions", "providers", "proxy", "shell", "tui",
+}
+
+// RequiresRestart reports whether a change to key only takes effect after
+// CodeForge is restarted
+func RequiresRestart(key string) bool {
+	section, _, _ := strings.Cut(key, ".")
+	for _, k := range restartKeys {
+		if strings.EqualFold(k, section) {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultModelID returns the configured default model in the
+// "provider/model" form used for model IDs, or "" when none is set. The
+// model may be an alias.
+func (c *Config) DefaultModelID() string {
+	if c.Model == "" {
+		return ""
+	}
+	return c.ResolveModel(modelIDFor(c.Provider, c.Model))
+}
+
+// modelIDFor returns the model ID for a provider and model. Anthropic,
+// OpenAI and Gemini models and OpenRouter's vendor/model IDs are used as
+// they are; other providers' models are prefixed with the provider.
+func modelIDFor(provider, model string) string {
+	switch provider {
+	case "", "anthropic", "openai", "gemini", "openrouter":
+		return model
+	}
+	if strings.HasPrefix(model, provider+"/") {
+		return model
+	}
+	return provider + "/" + model
+}
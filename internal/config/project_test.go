@@ -0,0 +1,153 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// useProjectDir points the global config at a fresh project in a temp dir
+func useProjectDir(t *testing.T, projectYAML string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if projectYAML != "" {
+		if err := os.WriteFile(filepath.Join(dir, ProjectConfigFile), []byte(projectYAML), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	saved := cfg
+	t.Cleanup(func() {
+		cfg = saved
+		viper.Reset()
+	})
+	viper.Reset()
+	setDefaults(false)
+	cfg = &Config{WorkingDir: dir}
+	return dir
+}
+
+func TestMergeProjectConfig(t *testing.T) {
+	dir := useProjectDir(t, "model: qwen2.5-coder\nprovider: ollama\nfiles:\n  maxFileSize: 2048\n")
+
+	if err := mergeProjectConfig(dir); err != nil {
+		t.Fatalf("mergeProjectConfig() error = %v", err)
+	}
+	if cfg.Model != "qwen2.5-coder" || cfg.Provider != "ollama" {
+		t.Errorf("model = %q, provider = %q", cfg.Model, cfg.Provider)
+	}
+	if cfg.Files.MaxFileSize != 2048 {
+		t.Errorf("files.maxFileSize = %d, want 2048", cfg.Files.MaxFileSize)
+	}
+	if got := cfg.DefaultModelID(); got != "ollama/qwen2.5-coder" {
+		t.Errorf("DefaultModelID() = %q", got)
+	}
+}
+
+func TestForProject(t *testing.T) {
+	useProjectDir(t, "")
+	viper.Set("model", "gpt-4o")
+	viper.Set("files.maxFileSize", 4096)
+
+	other := t.TempDir()
+	if err := os.WriteFile(filepath.Join(other, ProjectConfigFile), []byte("model: qwen2.5-coder\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	projectCfg, err := ForProject(other)
+	if err != nil {
+		t.Fatalf("ForProject() error = %v", err)
+	}
+	if projectCfg.Model != "qwen2.5-coder" || projectCfg.Files.MaxFileSize != 4096 || projectCfg.WorkingDir != other {
+		t.Errorf("project config = model %q, maxFileSize %d, dir %q", projectCfg.Model, projectCfg.Files.MaxFileSize, projectCfg.WorkingDir)
+	}
+	if viper.GetString("model") != "gpt-4o" {
+		t.Error("ForProject() changed the loaded settings")
+	}
+	// Keys the org config locks can't be changed by the project
+	orgLocked = map[string]any{"model": "gpt-4o"}
+	t.Cleanup(func() { orgLocked = nil })
+	if projectCfg, err = ForProject(other); err != nil {
+		t.Fatal(err)
+	}
+	if projectCfg.Model != "gpt-4o" {
+		t.Errorf("ForProject() with model locked = model %q", projectCfg.Model)
+	}
+}
+
+func TestSetProjectValue(t *testing.T) {
+	dir := useProjectDir(t, "provider: groq\n")
+	if err := mergeProjectConfig(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SetProjectValue("model", "gpt-4o-mini"); err != nil {
+		t.Fatalf("SetProjectValue() error = %v", err)
+	}
+	if cfg.Model != "gpt-4o-mini" {
+		t.Errorf("cfg.Model = %q", cfg.Model)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, ProjectConfigFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"model: gpt-4o-mini", "provider: groq"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("project config missing %q:\n%s", want, data)
+		}
+	}
+
+	if err := SetProjectValue("no.such.key", "x"); err == nil {
+		t.Error("SetProjectValue() accepted an unknown key")
+	}
+}
+
+func TestDefaultModelID(t *testing.T) {
+	tests := []struct {
+		provider, model, want string
+	}{
+		{"", "", ""},
+		{"", "claude-sonnet-4-20250514", "claude-sonnet-4-20250514"},
+		{"anthropic", "claude-sonnet-4-20250514", "claude-sonnet-4-20250514"},
+		{"openrouter", "anthropic/claude-3.5-sonnet", "anthropic/claude-3.5-sonnet"},
+		{"ollama", "llama3.2", "ollama/llama3.2"},
+		{"ollama", "ollama/llama3.2", "ollama/llama3.2"},
+	}
+	for _, tt := range tests {
+		c := &Config{Provider: tt.provider, Model: tt.model}
+		if got := c.DefaultModelID(); got != tt.want {
+			t.Errorf("DefaultModelID(%q, %q) = %q, want %q", tt.provider, tt.model, got, tt.want)
+		}
+	}
+}
+
+func TestSetProjectValues(t *testing.T) {
+	dir := useProjectDir(t, "")
+	if err := mergeProjectConfig(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	// A bad value leaves the file untouched
+	if err := SetProjectValues(map[string]any{"model": "gpt-4.1", "files.maxFileSize": "big"}); err == nil {
+		t.Fatal("SetProjectValues() accepted a string for an integer setting")
+	}
+	if _, err := os.Stat(filepath.Join(dir, ProjectConfigFile)); !os.IsNotExist(err) {
+		t.Errorf("project config written despite an invalid value")
+	}
+
+	err := SetProjectValues(map[string]any{"model": "gpt-4.1", "providers.openai.disabled": true})
+	if err != nil {
+		t.Fatalf("SetProjectValues() error = %v", err)
+	}
+	if cfg.Model != "gpt-4.1" || !cfg.Providers["openai"].Disabled {
+		t.Errorf("model = %q, providers = %+v", cfg.Model, cfg.Providers)
+	}
+
+	if !RequiresRestart("providers.openai.disabled") || RequiresRestart("model") {
+		t.Error("RequiresRestart() doesn't tell startup settings from live ones")
+	}
+}
@@ -656,8 +656,11 @@ func (pm *ProviderManager) checkProviderHealth(config *EnhancedProviderConfig) {
 	start := time.Now()
 
 	// Simple HTTP health check (this would be provider-specific in reality)
-	client := &http.Client{Timeout: pm.healthCheckTimeout}
-	resp, err := client.Get(config.BaseURL)
+	client, err := httpClient(string(config.ID), pm.healthCheckTimeout)
+	var resp *http.Response
+	if err == nil {
+		resp, err = client.Get(config.BaseURL)
+	}
 
 	latency := time.Since(start)
 	success := err == nil && resp != nil && resp.StatusCode < 400
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/models"
+)
+
+// ProviderSpec describes a model provider: how it's named, where its API key
+// comes from and which model to default to when it's the only one set up
+type ProviderSpec struct {
+	ID           string
+	Name         string
+	Description  string
+	KeyEnv       string   // Environment variable holding the API key
+	Aliases      []string // Other IDs models are prefixed with, as "google/"
+	DefaultModel string
+	BaseURL      string // OpenAI-compatible endpoint of a local server, set in the config
+	Local        bool   // Runs on this machine and needs no key
+	Unlisted     bool   // Configured through the environment, not chosen in the selector
+}
+
+// builtinProviders are the providers CodeForge knows, in the order a default
+// model is picked from the keys that are set
+var builtinProviders = []ProviderSpec{
+	{ID: "openrouter", Name: "OpenRouter", Description: "Access to 300+ models from multiple providers", KeyEnv: "OPENROUTER_API_KEY", DefaultModel: "anthropic/claude-3.5-sonnet"},
+	{ID: "anthropic", Name: "Anthropic", Description: "Claude models for advanced reasoning", KeyEnv: "ANTHROPIC_API_KEY", DefaultModel: "claude-3-5-sonnet-20241022"},
+	{ID: "openai", Name: "OpenAI", Description: "GPT models for general AI tasks", KeyEnv: "OPENAI_API_KEY", DefaultModel: "gpt-4o"},
+	{ID: "gemini", Name: "Google Gemini", Description: "Google's multimodal AI models", KeyEnv: "GEMINI_API_KEY", Aliases: []string{"google"}, DefaultModel: "gemini-1.5-pro"},
+	{ID: "groq", Name: "Groq", Description: "Ultra-fast inference for open models", KeyEnv: "GROQ_API_KEY", DefaultModel: "groq/llama-3.1-70b-versatile"},
+	{ID: "together", Name: "Together AI", Description: "Open source models with fast inference", KeyEnv: "TOGETHER_API_KEY", Aliases: []string{"togetherai"}, DefaultModel: "together/meta-llama/Llama-3-70b-chat-hf"},
+	{ID: "fireworks", Name: "Fireworks AI", Description: "Fast inference for open models", KeyEnv: "FIREWORKS_API_KEY", DefaultModel: "fireworks/llama-v3p1-70b-instruct"},
+	{ID: "deepseek", Name: "DeepSeek", Description: "Advanced reasoning and coding models", KeyEnv: "DEEPSEEK_API_KEY", DefaultModel: "deepseek/deepseek-chat"},
+	{ID: "cohere", Name: "Cohere", Description: "Enterprise-grade language models", KeyEnv: "COHERE_API_KEY", DefaultModel: "cohere/command-r-plus"},
+	{ID: "xai", Name: "xAI", Description: "Grok models", KeyEnv: "XAI_API_KEY", DefaultModel: "xai/grok-2"},
+	{ID: "mistral", Name: "Mistral AI", Description: "Mistral's efficient and powerful models", KeyEnv: "MISTRAL_API_KEY", Aliases: []string{"mistralai"}, DefaultModel: "mistral/mistral-large-latest"},
+	{ID: "cerebras", Name: "Cerebras", Description: "Wafer-scale inference for open models", KeyEnv: "CEREBRAS_API_KEY"},
+	{ID: "sambanova", Name: "SambaNova", Description: "Fast inference for open models", KeyEnv: "SAMBANOVA_API_KEY"},
+	{ID: "perplexity", Name: "Perplexity", Description: "Search-augmented language models", KeyEnv: "PERPLEXITY_API_KEY"},
+	{ID: "github", Name: "GitHub", Description: "GitHub Models", KeyEnv: "GITHUB_TOKEN"},
+	{ID: "copilot", Name: "GitHub Copilot", Description: "Models included with a Copilot subscription", KeyEnv: "COPILOT_API_KEY", DefaultModel: "copilot/gpt-4o"},
+	{ID: "ollama", Name: "Ollama", Description: "Local models for privacy and speed", Local: true},
+	{ID: "lmstudio", Name: "LM Studio", Description: "Local models served by LM Studio", Local: true},
+	{ID: "jan", Name: "Jan", Description: "Local models served by Jan", Local: true},
+	{ID: "llamacpp", Name: "llama.cpp", Description: "Local models served by llama.cpp's server", Local: true},
+
+	{ID: "bedrock", Name: "AWS Bedrock", KeyEnv: "AWS_ACCESS_KEY_ID", Unlisted: true},
+	{ID: "azure", Name: "Azure OpenAI", KeyEnv: "AZURE_OPENAI_API_KEY", Unlisted: true},
+	{ID: "vertexai", Name: "Vertex AI", KeyEnv: "GOOGLE_APPLICATION_CREDENTIALS", Unlisted: true},
+	{ID: "local", Name: "Local endpoint", KeyEnv: "LOCAL_ENDPOINT", Unlisted: true, Local: true},
+}
+
+// ListProviders returns the providers that can be picked from: the built-in
+// ones and any added in the config's providers section, less those disabled
+// there
+func ListProviders() []ProviderSpec {
+	var listed []ProviderSpec
+	for _, spec := range allProviders() {
+		if spec.Unlisted {
+			continue
+		}
+		if providerCfg, _ := GetProvider(models.ModelProvider(spec.ID)); providerCfg.Disabled {
+			continue
+		}
+		listed = append(listed, spec)
+	}
+	return listed
+}
+
+// LookupProvider returns the provider with the given ID or alias
+func LookupProvider(id string) (ProviderSpec, bool) {
+	id = strings.ToLower(id)
+	for _, spec := range allProviders() {
+		if spec.ID == id || slices.Contains(spec.Aliases, id) {
+			return spec, true
+		}
+	}
+	return ProviderSpec{}, false
+}
+
+// APIKey returns the provider's key from its environment variable, or else
+// from the config file
+func (p ProviderSpec) APIKey() string {
+	if p.KeyEnv != "" {
+		if key := os.Getenv(p.KeyEnv); key != "" {
+			return key
+		}
+	}
+	providerCfg, _ := GetProvider(models.ModelProvider(p.ID))
+	return providerCfg.APIKey
+}
+
+// allProviders merges the providers section of the config into the built-in
+// providers. Entries for providers CodeForge doesn't know add them, after the
+// built-in ones and in name order; those with a base URL are local
+// OpenAI-compatible servers.
+func allProviders() []ProviderSpec {
+	specs := slices.Clone(builtinProviders)
+	if cfg == nil {
+		return specs
+	}
+
+	known := make(map[string]int, len(specs))
+	for i, spec := range specs {
+		known[spec.ID] = i
+	}
+	var added []ProviderSpec
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	for id, providerCfg := range cfg.Providers {
+		i, ok := known[string(id)]
+		if ok {
+			specs[i] = providerCfg.override(specs[i])
+			continue
+		}
+		// Only entries naming a key variable, a model or a server describe a provider
+		if providerCfg.KeyEnv != "" || providerCfg.DefaultModel != "" || providerCfg.BaseURL != "" {
+			spec := ProviderSpec{ID: string(id), Name: string(id), Local: providerCfg.BaseURL != ""}
+			if spec.Local {
+				spec.Description = "OpenAI-compatible server at " + providerCfg.BaseURL
+			}
+			added = append(added, providerCfg.override(spec))
+		}
+	}
+
+	slices.SortFunc(added, func(a, b ProviderSpec) int { return strings.Compare(a.ID, b.ID) })
+	return append(specs, added...)
+}
+
+// override applies what a providers entry in the config sets to spec
+func (p Provider) override(spec ProviderSpec) ProviderSpec {
+	if p.Name != "" {
+		spec.Name = p.Name
+	}
+	if p.KeyEnv != "" {
+		spec.KeyEnv = p.KeyEnv
+	}
+	if p.DefaultModel != "" {
+		spec.DefaultModel = p.DefaultModel
+	}
+	if p.BaseURL != "" {
+		spec.BaseURL = p.BaseURL
+	}
+	return spec
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/models"
+)
+
+func TestProviderRegistry(t *testing.T) {
+	useProjectDir(t, "")
+	cfg.Providers = map[models.ModelProvider]Provider{
+		"hyperbolic": {Name: "Hyperbolic", KeyEnv: "HYPERBOLIC_API_KEY"},
+		"deepseek":   {DefaultModel: "deepseek/deepseek-reasoner"},
+		"perplexity": {Disabled: true},
+		"unrelated":  {APIKey: "k"},
+		"vllm":       {BaseURL: "http://gpu-box:8000/v1", APIKey: "vk"},
+	}
+	t.Setenv("HYPERBOLIC_API_KEY", "hk")
+
+	listed := map[string]ProviderSpec{}
+	for _, spec := range ListProviders() {
+		listed[spec.ID] = spec
+	}
+	if spec, ok := listed["hyperbolic"]; !ok || spec.Name != "Hyperbolic" || spec.APIKey() != "hk" {
+		t.Errorf("added provider = %+v", spec)
+	}
+	if listed["deepseek"].DefaultModel != "deepseek/deepseek-reasoner" || listed["deepseek"].KeyEnv != "DEEPSEEK_API_KEY" {
+		t.Errorf("overridden provider = %+v", listed["deepseek"])
+	}
+	if spec := listed["vllm"]; !spec.Local || spec.BaseURL != "http://gpu-box:8000/v1" || spec.APIKey() != "vk" {
+		t.Errorf("local server = %+v", spec)
+	}
+	for _, id := range []string{"perplexity", "unrelated", "bedrock"} {
+		if _, ok := listed[id]; ok {
+			t.Errorf("%s is listed", id)
+		}
+	}
+
+	if spec, ok := LookupProvider("Google"); !ok || spec.ID != "gemini" {
+		t.Errorf("LookupProvider(Google) = %+v, %v", spec, ok)
+	}
+	if ProviderKey("hyperbolic") != "hk" {
+		t.Errorf("ProviderKey(hyperbolic) = %q", ProviderKey("hyperbolic"))
+	}
+}
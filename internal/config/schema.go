@@ -0,0 +1,400 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// Issue severities. Errors stop a value from being applied; warnings are
+// settings that are ignored.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Issue is a problem found in a config file
+type Issue struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Key      string `json:"key,omitempty"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+func (i Issue) String() string {
+	// Issues with fetched configs have no position
+	if i.Line == 0 {
+		return fmt.Sprintf("%s: %s: %s", i.File, i.Severity, i.Message)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s: %s", i.File, i.Line, i.Column, i.Severity, i.Message)
+}
+
+// deprecatedKeys are settings still accepted in config files but no longer
+// used, with what to do instead
+var deprecatedKeys = map[string]string{
+	"wd":         "the working directory comes from the --wd flag",
+	"mcp":        "MCP servers are configured with codeforge mcp manage add",
+	"mcpServers": "MCP servers are configured with codeforge mcp manage add",
+}
+
+// schemaNode describes the shape a config value must have
+type schemaNode struct {
+	Type       string                 // JSON schema type; "" accepts anything
+	Properties map[string]*schemaNode // struct fields by key
+	Items      *schemaNode            // array elements
+	Values     *schemaNode            // map values
+	Deprecated string
+}
+
+// configSchema is the schema of Config, built once from its struct tags
+var configSchema = schemaFor(reflect.TypeOf(Config{}), "")
+
+// schemaFor builds the schema of a Go type as viper decodes it. path is the
+// dotted key of the value, used to mark deprecated settings.
+func schemaFor(t reflect.Type, path string) *schemaNode {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &schemaNode{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &schemaNode{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &schemaNode{Type: "number"}
+	case reflect.String:
+		return &schemaNode{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &schemaNode{Type: "array", Items: schemaFor(t.Elem(), path)}
+	case reflect.Map:
+		return &schemaNode{Type: "object", Values: schemaFor(t.Elem(), path)}
+	case reflect.Struct:
+		node := &schemaNode{Type: "object", Properties: map[string]*schemaNode{}}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			key := fieldKey(field)
+			if key == "" {
+				continue
+			}
+			child := key
+			if path != "" {
+				child = path + "." + key
+			}
+			prop := schemaFor(field.Type, child)
+			prop.Deprecated = deprecatedKeys[child]
+			node.Properties[key] = prop
+		}
+		return node
+	}
+	return &schemaNode{}
+}
+
+// fieldKey returns the config key of a struct field, or "" for fields that
+// aren't configuration
+func fieldKey(field reflect.StructField) string {
+	if !field.IsExported() {
+		return ""
+	}
+	if name, _, _ := strings.Cut(field.Tag.Get("mapstructure"), ","); name != "" {
+		return name
+	}
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// property finds a struct field by key. Viper lowercases keys, so they
+// match regardless of case.
+func (n *schemaNode) property(key string) (string, *schemaNode) {
+	for name, prop := range n.Properties {
+		if strings.EqualFold(name, key) {
+			return name, prop
+		}
+	}
+	return "", nil
+}
+
+// schemaAt returns the schema of the value at a dotted key, or nil when the
+// key isn't part of the config
+func schemaAt(key string) *schemaNode {
+	node := configSchema
+	for _, part := range strings.Split(key, ".") {
+		switch {
+		case node.Properties != nil:
+			_, node = node.property(part)
+		case node.Values != nil:
+			node = node.Values
+		default:
+			node = nil
+		}
+		if node == nil {
+			return nil
+		}
+	}
+	return node
+}
+
+// Schema returns the JSON schema of the config files
+func Schema() map[string]any {
+	schema := configSchema.jsonSchema()
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = "CodeForge configuration"
+	return schema
+}
+
+func (n *schemaNode) jsonSchema() map[string]any {
+	schema := map[string]any{}
+	if n.Type != "" {
+		schema["type"] = n.Type
+	}
+	if n.Deprecated != "" {
+		schema["deprecated"] = true
+		schema["description"] = "Deprecated: " + n.Deprecated
+	}
+	if n.Items != nil {
+		schema["items"] = n.Items.jsonSchema()
+	}
+	if n.Values != nil {
+		schema["additionalProperties"] = n.Values.jsonSchema()
+	}
+	if n.Properties != nil {
+		props := map[string]any{}
+		for name, prop := range n.Properties {
+			props[name] = prop.jsonSchema()
+		}
+		schema["properties"] = props
+		schema["additionalProperties"] = false
+	}
+	return schema
+}
+
+// ConfigFiles returns the global and project config files in use for
+// workingDir
+func ConfigFiles(workingDir string) []string {
+	var files []string
+
+	v := viper.New()
+	configureViper(v)
+	var notFound viper.ConfigFileNotFoundError
+	if err := v.ReadInConfig(); !errors.As(err, &notFound) && v.ConfigFileUsed() != "" {
+		files = append(files, v.ConfigFileUsed())
+	}
+
+	project := filepath.Join(workingDir, ProjectConfigFile)
+	if _, err := os.Stat(project); err == nil {
+		files = append(files, project)
+	}
+	return files
+}
+
+// ValidateFiles checks config files against the schema
+func ValidateFiles(paths ...string) []Issue {
+	var issues []Issue
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			issues = append(issues, Issue{File: path, Severity: SeverityError, Message: err.Error()})
+			continue
+		}
+		issues = append(issues, Validate(path, data)...)
+	}
+	return issues
+}
+
+// yamlLine splits the line number off a YAML parse error
+var yamlLine = regexp.MustCompile(`^yaml: line (\d+): `)
+
+// Validate checks the contents of a config file, JSON or YAML, against the
+// schema. file is only used to label the issues.
+func Validate(file string, data []byte) []Issue {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		issue := Issue{File: file, Severity: SeverityError, Message: err.Error()}
+		if m := yamlLine.FindStringSubmatch(issue.Message); m != nil {
+			issue.Line, _ = strconv.Atoi(m[1])
+			issue.Message = strings.TrimPrefix(issue.Message, m[0])
+		}
+		return []Issue{issue}
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+
+	v := validator{file: file}
+	v.check(doc.Content[0], configSchema, "")
+	sort.SliceStable(v.issues, func(i, j int) bool { return v.issues[i].Line < v.issues[j].Line })
+	return v.issues
+}
+
+type validator struct {
+	file   string
+	issues []Issue
+}
+
+func (v *validator) report(n *yaml.Node, key, severity, format string, args ...any) {
+	v.issues = append(v.issues, Issue{
+		File:     v.file,
+		Line:     n.Line,
+		Column:   n.Column,
+		Key:      key,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// check validates the value n at the dotted key path
+func (v *validator) check(n *yaml.Node, schema *schemaNode, path string) {
+	if n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	if n.Tag == "!!null" || schema.Type == "" {
+		return
+	}
+
+	label := path
+	if label == "" {
+		label = "the config"
+	}
+	if !matchesType(n, schema.Type) {
+		v.report(n, path, SeverityError, "%s must be %s, not %s", label, article(schema.Type), describe(n))
+		return
+	}
+
+	switch {
+	case schema.Properties != nil:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			keyNode, value := n.Content[i], n.Content[i+1]
+			name, prop := schema.property(keyNode.Value)
+			if prop == nil {
+				key := joinKey(path, keyNode.Value)
+				msg := fmt.Sprintf("unknown key %q", key)
+				if suggestion := closestKey(keyNode.Value, schema.Properties); suggestion != "" {
+					msg += fmt.Sprintf("; did you mean %q?", joinKey(path, suggestion))
+				}
+				v.report(keyNode, key, SeverityWarning, "%s", msg)
+				continue
+			}
+			key := joinKey(path, name)
+			if prop.Deprecated != "" {
+				v.report(keyNode, key, SeverityWarning, "%s is deprecated and ignored; %s", key, prop.Deprecated)
+			}
+			v.check(value, prop, key)
+		}
+	case schema.Values != nil:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			v.check(n.Content[i+1], schema.Values, joinKey(path, n.Content[i].Value))
+		}
+	case schema.Items != nil:
+		for i, item := range n.Content {
+			v.check(item, schema.Items, fmt.Sprintf("%s[%d]", path, i))
+		}
+	}
+}
+
+// matchesType reports whether viper can decode n as typ. Viper decodes
+// weakly, so quoted scalars such as "true" or "42" are accepted too; that is
+// also how codeforge config set writes values.
+func matchesType(n *yaml.Node, typ string) bool {
+	switch typ {
+	case "object":
+		return n.Kind == yaml.MappingNode
+	case "array":
+		return n.Kind == yaml.SequenceNode
+	}
+	if n.Kind != yaml.ScalarNode {
+		return false
+	}
+
+	switch typ {
+	case "boolean":
+		_, err := strconv.ParseBool(n.Value)
+		return err == nil
+	case "integer":
+		_, err := strconv.ParseInt(n.Value, 0, 64)
+		return err == nil
+	case "number":
+		_, err := strconv.ParseFloat(n.Value, 64)
+		return err == nil
+	}
+	return true
+}
+
+// describe names the kind of a value for error messages
+func describe(n *yaml.Node) string {
+	switch n.Kind {
+	case yaml.MappingNode:
+		return "an object"
+	case yaml.SequenceNode:
+		return "an array"
+	}
+	return strconv.Quote(n.Value)
+}
+
+func article(typ string) string {
+	switch typ {
+	case "object", "array", "integer":
+		return "an " + typ
+	}
+	return "a " + typ
+}
+
+func joinKey(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// closestKey suggests the known key nearest to a misspelled one, or ""
+// when none is close
+func closestKey(key string, properties map[string]*schemaNode) string {
+	best, bestDist := "", 3
+	for name := range properties {
+		if d := editDistance(strings.ToLower(key), strings.ToLower(name)); d < bestDist || (d == bestDist && name < best) {
+			best, bestDist = name, d
+		}
+	}
+	if bestDist > 2 {
+		return ""
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		data string
+		want []string // "line:severity:message substring"
+	}{
+		{
+			name: "valid yaml",
+			file: ".codeforge.yaml",
+			data: "model: gpt-4.1\nfiles:\n  maxFileSize: 2048\n  exclude: [\"vendor/**\"]\nencryption:\n  enabled: \"true\"\n",
+		},
+		{
+			name: "unknown key with suggestion",
+			file: ".codeforge.yaml",
+			data: "model: gpt-4.1\ntui:\n  theem: dark\n",
+			want: []string{`3:warning:unknown key "tui.theem"; did you mean "tui.theme"?`},
+		},
+		{
+			name: "type mismatches",
+			file: ".codeforge.yaml",
+			data: "files:\n  maxFileSize: big\n  exclude: vendor\ncanary:\n  enabled: maybe\n",
+			want: []string{
+				`2:error:files.maxFileSize must be an integer, not "big"`,
+				`3:error:files.exclude must be an array, not "vendor"`,
+				`5:error:canary.enabled must be a boolean, not "maybe"`,
+			},
+		},
+		{
+			name: "deprecated json",
+			file: ".codeforge.json",
+			data: "{\n\t\"tui\": {\"mouse\": false},\n\t\"mcpServers\": {}\n}\n",
+			want: []string{`3:warning:mcpServers is deprecated`},
+		},
+		{
+			name: "map values",
+			file: ".codeforge.json",
+			data: "{\n  \"providers\": {\n    \"openai\": {\"disabled\": \"no way\"}\n  }\n}\n",
+			want: []string{`3:error:providers.openai.disabled must be a boolean`},
+		},
+		{
+			name: "syntax error",
+			file: ".codeforge.yaml",
+			data: "model: [gpt\n",
+			want: []string{"1:error:did not find expected"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := Validate(tt.file, []byte(tt.data))
+			if len(issues) != len(tt.want) {
+				t.Fatalf("Validate() = %v, want %d issues", issues, len(tt.want))
+			}
+			for i, want := range tt.want {
+				parts := strings.SplitN(want, ":", 3)
+				got := issues[i]
+				if got.File != tt.file || parts[0] != strconv.Itoa(got.Line) || got.Severity != parts[1] || !strings.Contains(got.Message, parts[2]) {
+					t.Errorf("issue %d = %s, want %s", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSchema(t *testing.T) {
+	props := Schema()["properties"].(map[string]any)
+	if _, ok := props["ModelConfigManager"]; ok {
+		t.Error("Schema() includes fields that aren't configuration")
+	}
+	display := props["display"].(map[string]any)["properties"].(map[string]any)
+	if _, ok := display["ascii_only"]; !ok {
+		t.Errorf("display properties = %v, want ascii_only", display)
+	}
+	if props["mcp"].(map[string]any)["deprecated"] != true {
+		t.Error("mcp isn't marked deprecated")
+	}
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SessionTemplate sets up a session for a kind of task: the model, the files
+// pinned to every prompt, the built-in tools on offer and extra instructions
+type SessionTemplate struct {
+	Description string   `json:"description,omitempty"` // Shown when listing templates
+	Model       string   `json:"model,omitempty"`       // Model or alias, the default model when empty
+	Provider    string   `json:"provider,omitempty"`    // Provider of the model, detected when empty
+	PinnedFiles []string `json:"pinnedFiles,omitempty"` // Files pinned to every prompt; missing files are skipped
+	Tools       []string `json:"tools,omitempty"`       // Built-in tools offered, all when empty
+	Prompt      string   `json:"prompt,omitempty"`      // Added to the system prompt
+}
+
+// builtinTemplates are available without any configuration. Templates in
+// the config with the same name replace them.
+var builtinTemplates = map[string]SessionTemplate{
+	"bugfix": {
+		Description: "Find and fix a bug, with shell and edit tools and the strongest model",
+		Model:       "smart",
+		PinnedFiles: []string{"ARCHITECTURE.md", "AGENTS.md"},
+		Tools:       []string{"view", "read_file", "grep", "glob", "ls", "diagnostics", "bash", "edit", "patch", "write"},
+		Prompt: "The user is fixing a bug. Reproduce it first, find the root cause before changing code, " +
+			"make the smallest fix and say how to verify it, ideally with a test.",
+	},
+	"review": {
+		Description: "Review changes without modifying files",
+		Model:       "smart",
+		PinnedFiles: []string{"ARCHITECTURE.md", "CONTRIBUTING.md"},
+		Tools:       []string{"view", "read_file", "grep", "glob", "ls", "diagnostics"},
+		Prompt: "The user wants a code review. Point out bugs, risky changes and missing tests, " +
+			"most important first. Don't rewrite code that is fine.",
+	},
+	"explore": {
+		Description: "Learn how an unfamiliar codebase fits together, read-only and fast",
+		Model:       "fast",
+		PinnedFiles: []string{"README.md", "ARCHITECTURE.md"},
+		Tools:       []string{"view", "read_file", "grep", "glob", "ls"},
+		Prompt:      "The user is new to this codebase. Explain with references to files and functions.",
+	},
+}
+
+// Template returns the named session template from the loaded config
+func Template(name string) (SessionTemplate, error) {
+	if cfg == nil {
+		return (&Config{}).Template(name)
+	}
+	return cfg.Template(name)
+}
+
+// Template returns the named session template, from the config or the
+// built-in ones. Built-in templates name model aliases; when an alias isn't
+// configured the template uses the default model instead.
+func (c *Config) Template(name string) (SessionTemplate, error) {
+	// Viper lowercases map keys, so template names are case-insensitive
+	name = strings.ToLower(name)
+	if t, ok := c.Templates[name]; ok {
+		return t, nil
+	}
+
+	t, ok := builtinTemplates[name]
+	if !ok {
+		return SessionTemplate{}, fmt.Errorf("unknown template %q (available: %s)", name, strings.Join(c.TemplateNames(), ", "))
+	}
+	if _, ok := c.ModelAliases[t.Model]; !ok {
+		t.Model = ""
+	}
+	return t, nil
+}
+
+// TemplateNames returns the names of the templates in the loaded config and
+// the built-in ones
+func TemplateNames() []string {
+	if cfg == nil {
+		return (&Config{}).TemplateNames()
+	}
+	return cfg.TemplateNames()
+}
+
+// TemplateNames returns the names of the configured and built-in templates
+func (c *Config) TemplateNames() []string {
+	names := make([]string, 0, len(builtinTemplates)+len(c.Templates))
+	for name := range builtinTemplates {
+		names = append(names, name)
+	}
+	for name := range c.Templates {
+		if _, builtin := builtinTemplates[name]; !builtin {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
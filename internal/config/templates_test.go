@@ -0,0 +1,54 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTemplate(t *testing.T) {
+	dir := useProjectDir(t, `modelAliases:
+  smart: anthropic/claude-sonnet-4-20250514
+templates:
+  Migration:
+    model: openai/gpt-4.1
+    pinnedFiles: [db/schema.sql]
+    tools: [view, edit]
+    prompt: Every schema change needs a reversible migration.
+  explore:
+    prompt: Keep it short.
+`)
+	if err := mergeProjectConfig(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	migration, err := Template("migration")
+	if err != nil {
+		t.Fatalf("Template(migration) error = %v", err)
+	}
+	if migration.Model != "openai/gpt-4.1" || !reflect.DeepEqual(migration.Tools, []string{"view", "edit"}) ||
+		!reflect.DeepEqual(migration.PinnedFiles, []string{"db/schema.sql"}) {
+		t.Errorf("Template(migration) = %+v", migration)
+	}
+
+	// Built-in templates keep their model only when the alias is configured
+	if bugfix, _ := Template("bugfix"); bugfix.Model != "smart" || len(bugfix.Tools) == 0 {
+		t.Errorf("Template(bugfix) = %+v, want the smart alias and tools", bugfix)
+	}
+	if review, _ := (&Config{}).Template("review"); review.Model != "" {
+		t.Errorf("review model = %q without a smart alias, want the default model", review.Model)
+	}
+
+	if explore, _ := Template("explore"); explore.Prompt != "Keep it short." || explore.Model != "" {
+		t.Errorf("Template(explore) = %+v, want the configured replacement", explore)
+	}
+
+	want := []string{"bugfix", "explore", "migration", "review"}
+	if got := TemplateNames(); !reflect.DeepEqual(got, want) {
+		t.Errorf("TemplateNames() = %v, want %v", got, want)
+	}
+
+	if _, err := Template("deploy"); err == nil || !strings.Contains(err.Error(), "available: bugfix") {
+		t.Errorf("Template(deploy) error = %v, want the available templates", err)
+	}
+}
@@ -0,0 +1,152 @@
+package context
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// maxProfileFileSize caps the content taken from one profile file
+const maxProfileFileSize = 10000
+
+// DirectoryProfile is the context of a subdirectory, such as React
+// conventions in frontend/AGENTS.md, which applies to prompts about the
+// files under it
+type DirectoryProfile struct {
+	Dir     string // Directory relative to the root, with forward slashes
+	Files   []string
+	Content string
+}
+
+// ProfileNames returns the context file names a subdirectory can define a
+// profile with: the contextPaths entries naming a single file
+func ProfileNames(contextPaths []string) []string {
+	var names []string
+	for _, p := range contextPaths {
+		if p != "" && !strings.ContainsAny(p, `/\`) {
+			names = append(names, p)
+		}
+	}
+	return names
+}
+
+// ReferencedPaths returns the files and directories under root that text
+// mentions by path, relative to root with forward slashes
+func ReferencedPaths(root, text string) []string {
+	var paths []string
+	seen := map[string]bool{}
+	for _, word := range strings.Fields(text) {
+		word = strings.Trim(word, "\"'`,;:()[]{}<>?!")
+		word = strings.TrimRight(word, ".")
+		if !strings.ContainsAny(word, "./") {
+			continue
+		}
+		abs := word
+		if !filepath.IsAbs(abs) {
+			abs = filepath.Join(root, word)
+		}
+		rel, err := filepath.Rel(root, abs)
+		if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		if seen[rel] {
+			continue
+		}
+		if _, err := os.Stat(abs); err == nil {
+			seen[rel] = true
+			paths = append(paths, rel)
+		}
+	}
+	return paths
+}
+
+// DirectoryProfiles returns the profiles of the subdirectories containing
+// paths, outermost first, so backend/ comes before backend/api/. The root
+// is left out as its context files are already in the system prompt.
+func DirectoryProfiles(root string, names []string, paths []string) []DirectoryProfile {
+	var profiles []DirectoryProfile
+	seen := map[string]bool{}
+	for _, p := range paths {
+		for _, dir := range ancestors(root, p) {
+			if seen[dir] {
+				continue
+			}
+			seen[dir] = true
+			if profile, ok := loadProfile(root, dir, names); ok {
+				profiles = append(profiles, profile)
+			}
+		}
+	}
+
+	// Order by depth, keeping the order paths were given in otherwise
+	ordered := profiles[:0:0]
+	for depth := 1; len(ordered) < len(profiles); depth++ {
+		for _, profile := range profiles {
+			if strings.Count(profile.Dir, "/")+1 == depth {
+				ordered = append(ordered, profile)
+			}
+		}
+	}
+	return ordered
+}
+
+// ancestors returns the subdirectories of root that contain p, outermost
+// first, including p itself when it is a directory
+func ancestors(root, p string) []string {
+	dir := path.Dir(p)
+	if info, err := os.Stat(filepath.Join(root, filepath.FromSlash(p))); err == nil && info.IsDir() {
+		dir = p
+	}
+
+	var dirs []string
+	for dir != "." && dir != "/" && dir != "" {
+		dirs = append([]string{dir}, dirs...)
+		dir = path.Dir(dir)
+	}
+	return dirs
+}
+
+// loadProfile reads the profile files of dir, reporting false when it has
+// none
+func loadProfile(root, dir string, names []string) (DirectoryProfile, bool) {
+	profile := DirectoryProfile{Dir: dir}
+	var parts []string
+	read := map[string]bool{} // Names differing only in case may be one file
+	for _, name := range names {
+		if read[strings.ToLower(name)] {
+			continue
+		}
+		file := path.Join(dir, name)
+		content, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(file)))
+		if err != nil {
+			continue
+		}
+		read[strings.ToLower(name)] = true
+		if len(content) > maxProfileFileSize {
+			content = append(content[:maxProfileFileSize:maxProfileFileSize], "\n... [file truncated]"...)
+		}
+		profile.Files = append(profile.Files, file)
+		parts = append(parts, strings.TrimSpace(string(content)))
+	}
+	if len(parts) == 0 {
+		return profile, false
+	}
+	profile.Content = strings.Join(parts, "\n\n")
+	return profile, true
+}
+
+// FormatProfiles formats profiles for a prompt
+func FormatProfiles(profiles []DirectoryProfile) string {
+	if len(profiles) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("# Directory Context\nFollow these instructions for files in the directories they name.\n")
+	for _, profile := range profiles {
+		fmt.Fprintf(&sb, "\n## %s/ (from %s)\n%s\n", profile.Dir, strings.Join(profile.Files, ", "), profile.Content)
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
@@ -0,0 +1,61 @@
+package context
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDirectoryProfiles(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"AGENTS.md":                      "Root context, already in the system prompt",
+		"frontend/AGENTS.md":             "Use React function components.",
+		"frontend/src/App.tsx":           "export {}",
+		"backend/CODEFORGE.md":           "Follow the Go style guide.",
+		"backend/api/AGENTS.md":          "Handlers return JSON errors.",
+		"backend/api/handlers/health.go": "package handlers",
+		"docs/guide.md":                  "# Guide",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	names := ProfileNames([]string{".cursor/rules/", "CODEFORGE.md", "AGENTS.md"})
+	if want := []string{"CODEFORGE.md", "AGENTS.md"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("ProfileNames = %v, want %v", names, want)
+	}
+
+	paths := ReferencedPaths(root, "Why does backend/api/handlers/health.go differ from `frontend/`? See docs/guide.md and missing.go.")
+	if want := []string{"backend/api/handlers/health.go", "frontend", "docs/guide.md"}; !reflect.DeepEqual(paths, want) {
+		t.Fatalf("ReferencedPaths = %v, want %v", paths, want)
+	}
+
+	var dirs []string
+	for _, profile := range DirectoryProfiles(root, names, paths) {
+		dirs = append(dirs, profile.Dir)
+	}
+	// Outermost first, and nothing for the root or directories without one
+	if want := []string{"backend", "frontend", "backend/api"}; !reflect.DeepEqual(dirs, want) {
+		t.Errorf("profile dirs = %v, want %v", dirs, want)
+	}
+
+	formatted := FormatProfiles(DirectoryProfiles(root, names, []string{"frontend/src/App.tsx"}))
+	if !strings.Contains(formatted, "## frontend/ (from frontend/AGENTS.md)\nUse React function components.") {
+		t.Errorf("FormatProfiles =\n%s", formatted)
+	}
+	if strings.Contains(formatted, "Root context") {
+		t.Error("root context repeated in the profiles")
+	}
+	if FormatProfiles(nil) != "" {
+		t.Error("FormatProfiles(nil) is not empty")
+	}
+}
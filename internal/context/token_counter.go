@@ -2,10 +2,9 @@ package context
 
 import (
 	"fmt"
-	"regexp"
-	"strings"
 	"time"
-	"unicode/utf8"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/tokens"
 )
 
 // TokenCounter provides token counting functionality for different models
@@ -39,7 +38,8 @@ func (tu *TokenUsage) Add(other TokenUsage) {
 	tu.TotalTokens += other.TotalTokens
 }
 
-// CountTokens estimates token count for text using multiple methods
+// CountTokens returns the token count for text using the model's tokenizer,
+// falling back to per-family estimates
 func (tc *TokenCounter) CountTokens(text string, model string) int {
 	// Create cache key
 	cacheKey := fmt.Sprintf("%s:%s", model, hashString(text))
@@ -49,108 +49,13 @@ func (tc *TokenCounter) CountTokens(text string, model string) int {
 		return count
 	}
 
-	var count int
-
-	// Use model-specific counting if available
-	switch {
-	case strings.Contains(strings.ToLower(model), "gpt"):
-		count = tc.countGPTTokens(text)
-	case strings.Contains(strings.ToLower(model), "claude"):
-		count = tc.countClaudeTokens(text)
-	case strings.Contains(strings.ToLower(model), "gemini"):
-		count = tc.countGeminiTokens(text)
-	default:
-		count = tc.countGenericTokens(text)
-	}
+	count := tokens.Count(text, model).Count
 
 	// Cache the result
 	tc.cache[cacheKey] = count
 	return count
 }
 
-// countGPTTokens estimates tokens for GPT models
-func (tc *TokenCounter) countGPTTokens(text string) int {
-	// GPT tokenization approximation: ~4 characters per token
-	// More accurate for English text
-
-	// Remove extra whitespace
-	text = strings.TrimSpace(regexp.MustCompile(`\s+`).ReplaceAllString(text, " "))
-
-	// Count characters
-	charCount := utf8.RuneCountInString(text)
-
-	// Estimate tokens (GPT models average ~4 chars per token)
-	tokenCount := charCount / 4
-
-	// Add some tokens for special characters and formatting
-	specialChars := len(regexp.MustCompile(`[{}[\]().,;:!?'"<>]`).FindAllString(text, -1))
-	tokenCount += specialChars / 2
-
-	// Minimum 1 token for non-empty text
-	if charCount > 0 && tokenCount == 0 {
-		tokenCount = 1
-	}
-
-	return tokenCount
-}
-
-// countClaudeTokens estimates tokens for Claude models
-func (tc *TokenCounter) countClaudeTokens(text string) int {
-	// Claude tokenization is similar to GPT but slightly different
-	// Approximately 3.5-4 characters per token
-
-	text = strings.TrimSpace(regexp.MustCompile(`\s+`).ReplaceAllString(text, " "))
-	charCount := utf8.RuneCountInString(text)
-
-	// Claude tends to be slightly more efficient
-	tokenCount := charCount * 10 / 35 // ~3.5 chars per token
-
-	// Add tokens for code blocks and special formatting
-	codeBlocks := len(regexp.MustCompile("```").FindAllString(text, -1))
-	tokenCount += codeBlocks * 2
-
-	if charCount > 0 && tokenCount == 0 {
-		tokenCount = 1
-	}
-
-	return tokenCount
-}
-
-// countGeminiTokens estimates tokens for Gemini models
-func (tc *TokenCounter) countGeminiTokens(text string) int {
-	// Gemini tokenization approximation
-	text = strings.TrimSpace(regexp.MustCompile(`\s+`).ReplaceAllString(text, " "))
-	charCount := utf8.RuneCountInString(text)
-
-	// Gemini averages around 4 characters per token
-	tokenCount := charCount / 4
-
-	if charCount > 0 && tokenCount == 0 {
-		tokenCount = 1
-	}
-
-	return tokenCount
-}
-
-// countGenericTokens provides a generic token counting method
-func (tc *TokenCounter) countGenericTokens(text string) int {
-	// Generic approximation: split by whitespace and punctuation
-	text = strings.TrimSpace(text)
-	if text == "" {
-		return 0
-	}
-
-	// Split by whitespace
-	words := strings.Fields(text)
-	tokenCount := len(words)
-
-	// Add tokens for punctuation and special characters
-	punctuation := len(regexp.MustCompile(`[.,;:!?(){}[\]"']`).FindAllString(text, -1))
-	tokenCount += punctuation / 3
-
-	return tokenCount
-}
-
 // CountConversationTokens counts tokens for an entire conversation
 func (tc *TokenCounter) CountConversationTokens(messages []ConversationMessage, model string) TokenUsage {
 	usage := TokenUsage{}
@@ -248,15 +153,7 @@ func (tc *TokenCounter) CountWithMetadata(text string, model string) TokenCountR
 
 	count := tc.CountTokens(text, model)
 
-	method := "generic"
-	switch {
-	case strings.Contains(strings.ToLower(model), "gpt"):
-		method = "gpt"
-	case strings.Contains(strings.ToLower(model), "claude"):
-		method = "claude"
-	case strings.Contains(strings.ToLower(model), "gemini"):
-		method = "gemini"
-	}
+	method := string(tokens.FamilyOf(model))
 
 	return TokenCountResult{
 		Count:     count,
@@ -0,0 +1,244 @@
+// Package daemon keeps CodeForge running for a workspace and lets the CLI
+// hand it one-shot prompts over a unix socket, so they skip the cold start
+// of indexes, language servers and provider clients
+package daemon
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/storage"
+)
+
+// dialTimeout bounds how long the CLI waits to learn whether a daemon is
+// running; a live socket answers at once
+const dialTimeout = 200 * time.Millisecond
+
+// Requests the daemon answers
+const (
+	OpPrompt = "prompt"
+	OpStatus = "status"
+	OpStop   = "stop"
+)
+
+// Request is one call from the CLI to the daemon
+type Request struct {
+	Op     string `json:"op"`
+	Prompt string `json:"prompt,omitempty"`
+	Model  string `json:"model,omitempty"` // Empty for the default model
+}
+
+// Response answers a Request
+type Response struct {
+	Text   string  `json:"text,omitempty"`
+	Error  string  `json:"error,omitempty"`
+	Status *Status `json:"status,omitempty"`
+}
+
+// Status describes a running daemon
+type Status struct {
+	Workspace string    `json:"workspace"`
+	PID       int       `json:"pid"`
+	Started   time.Time `json:"started"`
+	Prompts   int64     `json:"prompts"` // Prompts answered so far
+}
+
+// Handler answers a prompt
+type Handler func(ctx context.Context, req Request) (string, error)
+
+// SocketPath returns where the daemon for the workspace at dir listens. Each
+// workspace has its own daemon, under ~/.codeforge/daemon.
+func SocketPath(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	base, err := storage.NewPathManager().GetCodeForgeDir()
+	if err != nil {
+		return "", err
+	}
+	// Hashed, as socket paths are limited to about a hundred bytes
+	sum := sha256.Sum256([]byte(abs))
+	return filepath.Join(base, "daemon", hex.EncodeToString(sum[:8])+".sock"), nil
+}
+
+// Server answers requests from the CLI for one workspace
+type Server struct {
+	Workspace string
+	Handler   Handler
+
+	started time.Time
+	prompts atomic.Int64
+	stop    context.CancelFunc
+}
+
+// Serve answers requests on the socket at path until ctx is cancelled or a
+// client asks the daemon to stop. Prompts still being answered are given
+// the cancelled context and waited for.
+func (s *Server) Serve(ctx context.Context, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	if conn, err := net.DialTimeout("unix", path, dialTimeout); err == nil {
+		conn.Close()
+		return fmt.Errorf("a daemon is already running for %s", s.Workspace)
+	}
+	// Left by a daemon that didn't exit cleanly
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+	// Prompts carry the user's code; only the user may connect
+	if err := os.Chmod(path, 0o600); err != nil {
+		return err
+	}
+
+	ctx, s.stop = context.WithCancel(ctx)
+	defer s.stop()
+	s.started = time.Now()
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.serveConn(ctx, conn)
+		}()
+	}
+}
+
+// serveConn answers the one request a connection carries
+func (s *Server) serveConn(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+
+	var req Request
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		log.Printf("Daemon: unreadable request: %v", err)
+		return
+	}
+
+	var resp Response
+	switch req.Op {
+	case OpPrompt:
+		s.prompts.Add(1)
+		text, err := s.Handler(ctx, req)
+		if err != nil {
+			resp.Error = err.Error()
+		}
+		resp.Text = text
+	case OpStatus:
+		resp.Status = &Status{
+			Workspace: s.Workspace,
+			PID:       os.Getpid(),
+			Started:   s.started,
+			Prompts:   s.prompts.Load(),
+		}
+	case OpStop:
+		defer s.stop()
+	default:
+		resp.Error = fmt.Sprintf("unknown request %q", req.Op)
+	}
+
+	if err := json.NewEncoder(conn).Encode(resp); err != nil {
+		log.Printf("Daemon: failed to answer %s request: %v", req.Op, err)
+	}
+}
+
+// Client sends requests to the daemon of a workspace
+type Client struct {
+	path string
+}
+
+// Dial returns a client for the daemon listening at path, or an error when
+// none is running there
+func Dial(path string) (*Client, error) {
+	conn, err := net.DialTimeout("unix", path, dialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	conn.Close()
+	return &Client{path: path}, nil
+}
+
+// Prompt has the daemon answer a prompt with the given model, or the default
+// model when model is empty
+func (c *Client) Prompt(ctx context.Context, prompt, model string) (string, error) {
+	resp, err := c.call(ctx, Request{Op: OpPrompt, Prompt: prompt, Model: model})
+	if err != nil {
+		return "", err
+	}
+	if resp.Error != "" {
+		return resp.Text, errors.New(resp.Error)
+	}
+	return resp.Text, nil
+}
+
+// Status describes the daemon
+func (c *Client) Status(ctx context.Context) (*Status, error) {
+	resp, err := c.call(ctx, Request{Op: OpStatus})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Status == nil {
+		return nil, fmt.Errorf("daemon sent no status")
+	}
+	return resp.Status, nil
+}
+
+// Stop asks the daemon to exit once the prompts it's answering are done
+func (c *Client) Stop(ctx context.Context) error {
+	_, err := c.call(ctx, Request{Op: OpStop})
+	return err
+}
+
+// call sends a request on a connection of its own and reads the response
+func (c *Client) call(ctx context.Context, req Request) (Response, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "unix", c.path)
+	if err != nil {
+		return Response{}, fmt.Errorf("daemon not reachable: %w", err)
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return Response{}, fmt.Errorf("failed to send request to the daemon: %w", err)
+	}
+	var resp Response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		if ctx.Err() != nil {
+			return Response{}, ctx.Err()
+		}
+		return Response{}, fmt.Errorf("daemon closed the connection: %w", err)
+	}
+	return resp, nil
+}
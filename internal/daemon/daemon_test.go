@@ -0,0 +1,72 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestServeAnswersClients(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "d.sock")
+	server := &Server{
+		Workspace: "/src/project",
+		Handler: func(ctx context.Context, req Request) (string, error) {
+			if req.Prompt == "fail" {
+				return "", fmt.Errorf("no API key found for model: %s", req.Model)
+			}
+			return req.Model + ": " + req.Prompt, nil
+		},
+	}
+	done := make(chan error, 1)
+	go func() { done <- server.Serve(context.Background(), path) }()
+
+	var client *Client
+	for deadline := time.Now().Add(2 * time.Second); ; {
+		var err error
+		if client, err = Dial(path); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("daemon never listened: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	ctx := context.Background()
+
+	if text, err := client.Prompt(ctx, "hello", "gpt-4o"); err != nil || text != "gpt-4o: hello" {
+		t.Errorf("Prompt() = %q, %v", text, err)
+	}
+	if _, err := client.Prompt(ctx, "fail", "gpt-4o"); err == nil || err.Error() != "no API key found for model: gpt-4o" {
+		t.Errorf("handler error came back as %v", err)
+	}
+
+	status, err := client.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Workspace != "/src/project" || status.Prompts != 2 {
+		t.Errorf("status = %+v", status)
+	}
+
+	// A second daemon for the same socket is turned away
+	if err := (&Server{Workspace: "/src/project"}).Serve(ctx, path); err == nil {
+		t.Error("second daemon started on a socket in use")
+	}
+
+	if err := client.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Serve() = %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("daemon didn't stop")
+	}
+	if _, err := Dial(path); err == nil {
+		t.Error("stopped daemon still reachable")
+	}
+}
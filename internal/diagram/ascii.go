@@ -0,0 +1,344 @@
+package diagram
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// graph is a directed graph parsed from a diagram, with nodes in order of
+// first appearance
+type graph struct {
+	order  []string
+	labels map[string]string
+	edges  map[string][]edge
+}
+
+type edge struct {
+	to    string
+	label string
+}
+
+func newGraph() *graph {
+	return &graph{labels: map[string]string{}, edges: map[string][]edge{}}
+}
+
+// node adds a node, keeping its first non-empty label
+func (g *graph) node(id, label string) {
+	if _, ok := g.labels[id]; !ok {
+		g.order = append(g.order, id)
+		g.labels[id] = ""
+	}
+	if label != "" && g.labels[id] == "" {
+		g.labels[id] = label
+	}
+}
+
+func (g *graph) edge(from, to, label string) {
+	g.node(from, "")
+	g.node(to, "")
+	g.edges[from] = append(g.edges[from], edge{to: to, label: label})
+}
+
+func (g *graph) label(id string) string {
+	if l := g.labels[id]; l != "" {
+		return l
+	}
+	return id
+}
+
+// ASCII approximates a diagram as text for terminals: flowcharts and
+// Graphviz graphs as trees, sequence diagrams as numbered messages. It
+// reports false for diagram types it can't approximate
+func ASCII(d Diagram) (string, bool) {
+	if d.Kind == Graphviz {
+		g := parseDot(d.Source)
+		if g == nil {
+			return "", false
+		}
+		return g.render(), true
+	}
+
+	header, body := splitHeader(d.Source)
+	switch {
+	case header == "graph" || header == "flowchart":
+		g := parseFlowchart(body)
+		if g == nil {
+			return "", false
+		}
+		return g.render(), true
+	case header == "sequenceDiagram":
+		return renderSequence(body)
+	}
+	return "", false
+}
+
+// splitHeader returns the diagram type keyword of a Mermaid source and the
+// lines after it
+func splitHeader(source string) (string, []string) {
+	lines := strings.Split(source, "\n")
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "%%") {
+			continue
+		}
+		fields := strings.Fields(line)
+		return fields[0], lines[i+1:]
+	}
+	return "", nil
+}
+
+// render draws the graph as trees from the nodes without incoming edges.
+// A node reached again is shown with ↺ instead of being expanded twice
+func (g *graph) render() string {
+	incoming := map[string]bool{}
+	for _, from := range g.order {
+		for _, e := range g.edges[from] {
+			if e.to != from {
+				incoming[e.to] = true
+			}
+		}
+	}
+
+	var b strings.Builder
+	seen := map[string]bool{}
+	var walk func(id, prefix string)
+	walk = func(id, prefix string) {
+		seen[id] = true
+		out := g.edges[id]
+		for i, e := range out {
+			branch, indent := "├─▶ ", "│   "
+			if i == len(out)-1 {
+				branch, indent = "└─▶ ", "    "
+			}
+			text := g.label(e.to)
+			if e.label != "" {
+				text += " (" + e.label + ")"
+			}
+			if seen[e.to] {
+				fmt.Fprintf(&b, "%s%s%s ↺\n", prefix, branch, text)
+				continue
+			}
+			fmt.Fprintf(&b, "%s%s%s\n", prefix, branch, text)
+			walk(e.to, prefix+indent)
+		}
+	}
+
+	// Roots first, then whatever only cycles reach
+	for _, pass := range []bool{false, true} {
+		for _, id := range g.order {
+			if seen[id] || (incoming[id] && !pass) {
+				continue
+			}
+			fmt.Fprintln(&b, g.label(id))
+			walk(id, "")
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+var (
+	// flowArrow matches a flowchart link, with an optional |label| after it
+	// or text inside it, as in A -- text --> B
+	flowArrow = regexp.MustCompile(`\s*(?:--\s*([^-|>][^|>]*?)\s*-->|==\s*([^=|>][^|>]*?)\s*==>|-\.\s*([^.|>][^|>]*?)\s*\.->|<?-->|<?==>|<?-\.->|---|===|-\.-|--[ox]|==[ox])\s*(?:\|([^|]*)\|)?\s*`)
+	flowNode  = regexp.MustCompile(`^([\w.-]+)(.*)$`)
+	// flowIgnored are flowchart statements without nodes or edges to draw
+	flowIgnored = regexp.MustCompile(`^(subgraph|end|style|classDef|class|click|linkStyle|direction)\b`)
+)
+
+// parseFlowchart parses the statements of a Mermaid flowchart
+func parseFlowchart(lines []string) *graph {
+	g := newGraph()
+	for _, line := range lines {
+		for _, stmt := range strings.Split(line, ";") {
+			stmt = strings.TrimSpace(stmt)
+			if stmt == "" || strings.HasPrefix(stmt, "%%") || flowIgnored.MatchString(stmt) {
+				continue
+			}
+			parseFlowStatement(g, stmt)
+		}
+	}
+	if len(g.order) == 0 {
+		return nil
+	}
+	return g
+}
+
+// parseFlowStatement adds a chain such as A[Start] --> B & C -->|yes| D
+func parseFlowStatement(g *graph, stmt string) {
+	arrows := flowArrow.FindAllStringSubmatchIndex(stmt, -1)
+	var prev []string
+	start := 0
+	for i := 0; i <= len(arrows); i++ {
+		end := len(stmt)
+		if i < len(arrows) {
+			end = arrows[i][0]
+		}
+		var ids []string
+		for _, ref := range strings.Split(stmt[start:end], "&") {
+			if id, label := flowNodeRef(ref); id != "" {
+				g.node(id, label)
+				ids = append(ids, id)
+			}
+		}
+		if i > 0 {
+			label := arrowLabel(stmt, arrows[i-1])
+			for _, from := range prev {
+				for _, to := range ids {
+					g.edge(from, to, label)
+				}
+			}
+		}
+		if i < len(arrows) {
+			start = arrows[i][1]
+		}
+		prev = ids
+	}
+}
+
+// arrowLabel returns the text of a matched flowchart link, if any
+func arrowLabel(stmt string, m []int) string {
+	for group := 1; group*2+1 < len(m); group++ {
+		if m[group*2] >= 0 {
+			return strings.Trim(strings.TrimSpace(stmt[m[group*2]:m[group*2+1]]), `"`)
+		}
+	}
+	return ""
+}
+
+// flowNodeRef parses a node reference such as A, A[Label] or B{"Choice?"}
+func flowNodeRef(ref string) (string, string) {
+	m := flowNode.FindStringSubmatch(strings.TrimSpace(ref))
+	if m == nil {
+		return "", ""
+	}
+	shape := strings.TrimSpace(m[2])
+	// Strip a class suffix such as A:::warning
+	if i := strings.Index(shape, ":::"); i >= 0 {
+		shape = shape[:i]
+	}
+	// Strip as many closing brackets as there are opening ones, so that
+	// A[Call (x)] keeps its parenthesis
+	open := len(shape) - len(strings.TrimLeft(shape, `[({>/\`))
+	label := shape[open:]
+	for i := 0; i < open && label != ""; i++ {
+		if !strings.ContainsRune(`])}/\`, rune(label[len(label)-1])) {
+			break
+		}
+		label = label[:len(label)-1]
+	}
+	return m[1], strings.Trim(strings.TrimSpace(label), `"`)
+}
+
+var (
+	seqMessage     = regexp.MustCompile(`^([^\s:>-]+)\s*(--?>>|--?>|--?x|--?\))\s*([+-]?)([^\s:]+)\s*:\s*(.*)$`)
+	seqParticipant = regexp.MustCompile(`^(?:participant|actor)\s+(\S+)(?:\s+as\s+(.+))?$`)
+)
+
+// renderSequence lists the messages of a Mermaid sequence diagram in order
+func renderSequence(lines []string) (string, bool) {
+	aliases := map[string]string{}
+	name := func(id string) string {
+		if a, ok := aliases[id]; ok {
+			return a
+		}
+		return id
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if m := seqParticipant.FindStringSubmatch(line); m != nil {
+			if m[2] != "" {
+				aliases[m[1]] = strings.TrimSpace(m[2])
+			}
+			continue
+		}
+		m := seqMessage.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		n++
+		arrow := "→"
+		if strings.HasPrefix(m[2], "--") {
+			arrow = "⇢" // Dotted arrows are usually replies
+		}
+		fmt.Fprintf(&b, "%d. %s %s %s: %s\n", n, name(m[1]), arrow, name(m[4]), m[5])
+	}
+	if n == 0 {
+		return "", false
+	}
+	return strings.TrimRight(b.String(), "\n"), true
+}
+
+var (
+	dotComment = regexp.MustCompile(`(?m)(?:^|\s)//.*$|^\s*#.*$|/\*[\s\S]*?\*/`)
+	dotEdgeOp  = regexp.MustCompile(`\s*-[->]\s*`)
+	dotAttr    = regexp.MustCompile(`(\w+)\s*=\s*("(?:[^"\\]|\\.)*"|[^\s,;\]]+)`)
+	// dotIgnored are statements that set defaults rather than add nodes
+	dotIgnored = regexp.MustCompile(`^(?:strict\s+)?(?:di)?graph\b|^(?:graph|node|edge)\s*\[|^subgraph\b|^[\w.]+\s*=`)
+)
+
+// parseDot parses the node and edge statements of a Graphviz graph
+func parseDot(source string) *graph {
+	source = dotComment.ReplaceAllString(source, "")
+	g := newGraph()
+	for _, stmt := range splitDot(source) {
+		stmt = strings.TrimSpace(stmt)
+		if stmt == "" || dotIgnored.MatchString(stmt) {
+			continue
+		}
+
+		attrs := map[string]string{}
+		if i := strings.Index(stmt, "["); i >= 0 {
+			for _, m := range dotAttr.FindAllStringSubmatch(stmt[i:], -1) {
+				attrs[m[1]] = strings.ReplaceAll(strings.Trim(m[2], `"`), `\n`, " ")
+			}
+			stmt = strings.TrimSpace(stmt[:i])
+		}
+
+		ids := dotEdgeOp.Split(stmt, -1)
+		for i := range ids {
+			ids[i] = strings.Trim(strings.TrimSpace(ids[i]), `"`)
+		}
+		if len(ids) == 1 {
+			if ids[0] != "" {
+				g.node(ids[0], attrs["label"])
+			}
+			continue
+		}
+		for i := 1; i < len(ids); i++ {
+			g.edge(ids[i-1], ids[i], attrs["label"])
+		}
+	}
+	if len(g.order) == 0 {
+		return nil
+	}
+	return g
+}
+
+// splitDot splits Graphviz source into statements at semicolons, newlines
+// and braces outside quotes and attribute lists
+func splitDot(source string) []string {
+	var stmts []string
+	var cur strings.Builder
+	inQuote, inAttrs := false, false
+	for _, r := range source {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+		case inQuote:
+		case r == '[':
+			inAttrs = true
+		case r == ']':
+			inAttrs = false
+		case !inAttrs && (r == ';' || r == '\n' || r == '{' || r == '}'):
+			stmts = append(stmts, cur.String())
+			cur.Reset()
+			continue
+		}
+		cur.WriteRune(r)
+	}
+	return append(stmts, cur.String())
+}
@@ -0,0 +1,107 @@
+// Package diagram finds Mermaid and Graphviz diagrams in model output and
+// makes them viewable: as a page opened in the browser, or as a text
+// approximation in the terminal
+package diagram
+
+import (
+	"strings"
+)
+
+// Diagram kinds
+const (
+	Mermaid  = "mermaid"
+	Graphviz = "graphviz"
+)
+
+// fenceKinds maps code fence languages to diagram kinds
+var fenceKinds = map[string]string{
+	"mermaid":  Mermaid,
+	"dot":      Graphviz,
+	"graphviz": Graphviz,
+	"gv":       Graphviz,
+}
+
+// Diagram is the source of one diagram block
+type Diagram struct {
+	Kind   string
+	Source string
+}
+
+// block is a diagram fence in a document, by line
+type block struct {
+	Diagram
+	start, end int // Lines of the opening and closing fences
+}
+
+// Extract returns the diagrams in fenced code blocks of a Markdown text, in
+// order
+func Extract(markdown string) []Diagram {
+	var diagrams []Diagram
+	for _, b := range findBlocks(strings.Split(markdown, "\n")) {
+		diagrams = append(diagrams, b.Diagram)
+	}
+	return diagrams
+}
+
+// ReplaceBlocks replaces each diagram block of a Markdown text, fences
+// included, with what replace returns for it
+func ReplaceBlocks(markdown string, replace func(Diagram) string) string {
+	lines := strings.Split(markdown, "\n")
+	blocks := findBlocks(lines)
+	if len(blocks) == 0 {
+		return markdown
+	}
+
+	var out []string
+	next := 0
+	for _, b := range blocks {
+		out = append(out, lines[next:b.start]...)
+		out = append(out, replace(b.Diagram))
+		next = b.end + 1
+	}
+	out = append(out, lines[next:]...)
+	return strings.Join(out, "\n")
+}
+
+// findBlocks finds the closed diagram fences among lines
+func findBlocks(lines []string) []block {
+	var blocks []block
+	fence := "" // Open fence, such as "```"
+	kind := ""  // Diagram kind of the open fence; "" for other code
+	start := 0
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if fence == "" {
+			marker := fenceOf(trimmed)
+			if marker == "" {
+				continue
+			}
+			fence, start = marker, i
+			lang := strings.Fields(strings.TrimPrefix(trimmed, marker) + " ")
+			kind = ""
+			if len(lang) > 0 {
+				kind = fenceKinds[strings.ToLower(lang[0])]
+			}
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+			if kind != "" {
+				source := strings.Join(lines[start+1:i], "\n")
+				blocks = append(blocks, block{Diagram: Diagram{Kind: kind, Source: source}, start: start, end: i})
+			}
+			fence = ""
+		}
+	}
+	return blocks
+}
+
+// fenceOf returns the code fence a line opens, or ""
+func fenceOf(line string) string {
+	for _, c := range []string{"`", "~"} {
+		if strings.HasPrefix(line, c+c+c) {
+			return line[:len(line)-len(strings.TrimLeft(line, c))]
+		}
+	}
+	return ""
+}
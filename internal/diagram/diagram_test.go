@@ -0,0 +1,116 @@
+package diagram
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+const response = "The request path:\n\n" +
+	"```mermaid\n" +
+	"flowchart TD\n" +
+	"    Client[Web client] -->|HTTP| API(API server)\n" +
+	"    API --> DB[(Postgres)] & Cache\n" +
+	"    Worker -- polls --> DB\n" +
+	"    style API fill:#f9f\n" +
+	"```\n\n" +
+	"```go\n" +
+	"// not a diagram\n" +
+	"```\n\n" +
+	"~~~dot\n" +
+	"digraph G { a -> b [label=\"calls\"]; }\n" +
+	"~~~\n"
+
+func TestExtract(t *testing.T) {
+	diagrams := Extract(response)
+	if len(diagrams) != 2 {
+		t.Fatalf("got %d diagrams: %+v", len(diagrams), diagrams)
+	}
+	if diagrams[0].Kind != Mermaid || !strings.HasPrefix(diagrams[0].Source, "flowchart TD") {
+		t.Errorf("first diagram = %+v", diagrams[0])
+	}
+	if diagrams[1].Kind != Graphviz || !strings.Contains(diagrams[1].Source, "a -> b") {
+		t.Errorf("second diagram = %+v", diagrams[1])
+	}
+	if Extract("```mermaid\ngraph TD\n A --> B\n") != nil {
+		t.Error("unclosed fence was extracted")
+	}
+}
+
+func TestReplaceBlocks(t *testing.T) {
+	got := ReplaceBlocks(response, func(d Diagram) string { return "<" + d.Kind + ">" })
+	if !strings.HasPrefix(got, "The request path:\n\n<mermaid>\n\n```go\n") || !strings.HasSuffix(got, "```\n\n<graphviz>\n") {
+		t.Errorf("ReplaceBlocks = %q", got)
+	}
+}
+
+func TestASCIIFlowchart(t *testing.T) {
+	got, ok := ASCII(Extract(response)[0])
+	if !ok {
+		t.Fatal("flowchart not approximated")
+	}
+	want := strings.Join([]string{
+		"Web client",
+		"└─▶ API server (HTTP)",
+		"    ├─▶ Postgres",
+		"    └─▶ Cache",
+		"Worker",
+		"└─▶ Postgres (polls) ↺",
+	}, "\n")
+	if got != want {
+		t.Errorf("ASCII =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestASCIIGraphviz(t *testing.T) {
+	d := Diagram{Kind: Graphviz, Source: `digraph deps {
+  rankdir=LR;
+  node [shape=box];
+  // comment
+  app [label="App"];
+  app -> lib -> app;
+}`}
+	got, ok := ASCII(d)
+	if !ok {
+		t.Fatal("graph not approximated")
+	}
+	// The cycle has no root, so it starts at the first node
+	if want := "App\n└─▶ lib\n    └─▶ App ↺"; got != want {
+		t.Errorf("ASCII =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestASCIISequence(t *testing.T) {
+	d := Diagram{Kind: Mermaid, Source: `sequenceDiagram
+    participant C as Client
+    C->>+API: POST /login
+    API-->>-C: 200 token`}
+	got, ok := ASCII(d)
+	if !ok {
+		t.Fatal("sequence diagram not approximated")
+	}
+	if want := "1. Client → API: POST /login\n2. API ⇢ Client: 200 token"; got != want {
+		t.Errorf("ASCII =\n%s\nwant\n%s", got, want)
+	}
+
+	if _, ok := ASCII(Diagram{Kind: Mermaid, Source: "pie\n  \"a\": 1"}); ok {
+		t.Error("pie chart approximated")
+	}
+}
+
+func TestWrite(t *testing.T) {
+	path, err := Write(context.Background(), t.TempDir(), Extract(response))
+	if err != nil {
+		t.Fatal(err)
+	}
+	page, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Diagram 1 (mermaid)", `<pre class="mermaid">flowchart TD`, "Client[Web client] --&gt;|HTTP|", mermaidScript} {
+		if !strings.Contains(string(page), want) {
+			t.Errorf("page lacks %q", want)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package diagram
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/platform"
+)
+
+// Script URLs of the renderers used when no local tool rendered a diagram
+const (
+	mermaidScript = "https://cdn.jsdelivr.net/npm/mermaid@10/dist/mermaid.esm.min.mjs"
+	vizScript     = "https://cdn.jsdelivr.net/npm/@viz-js/viz@3/lib/viz-standalone.js"
+)
+
+// pageDiagram is a diagram as the page shows it
+type pageDiagram struct {
+	Diagram
+	N   int
+	SVG template.HTML // Pre-rendered image, if a local tool produced one
+}
+
+var pageTemplate = template.Must(template.New("diagrams").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>CodeForge diagrams</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2rem; background: #fafafa; color: #222; }
+section { background: #fff; border: 1px solid #ddd; border-radius: 6px; padding: 1rem; margin-bottom: 2rem; overflow: auto; }
+h2 { font-size: 1rem; color: #666; margin-top: 0; }
+pre.source { background: #f4f4f4; padding: .5rem; }
+</style>
+</head>
+<body>
+{{range .}}<section>
+<h2>Diagram {{.N}} ({{.Kind}})</h2>
+{{if .SVG}}{{.SVG}}{{else}}<pre class="{{.Kind}}">{{.Source}}</pre>{{end}}
+<details><summary>Source</summary><pre class="source">{{.Source}}</pre></details>
+</section>
+{{end}}<script type="module">
+import mermaid from "` + mermaidScript + `";
+mermaid.initialize({ startOnLoad: true });
+</script>
+<script src="` + vizScript + `"></script>
+<script>
+if (window.Viz) {
+  Viz.instance().then(viz => {
+    document.querySelectorAll("pre.graphviz").forEach(el => {
+      try { el.replaceWith(viz.renderSVGElement(el.textContent)); }
+      catch (err) { el.insertAdjacentText("beforebegin", "Render failed: " + err.message); }
+    });
+  });
+}
+</script>
+</body>
+</html>
+`))
+
+// Page returns an HTML page showing the diagrams. Graphviz diagrams are
+// rendered to SVG with a local dot when it is installed; the rest are
+// rendered in the browser
+func Page(ctx context.Context, diagrams []Diagram) ([]byte, error) {
+	items := make([]pageDiagram, len(diagrams))
+	for i, d := range diagrams {
+		items[i] = pageDiagram{Diagram: d, N: i + 1}
+		if d.Kind == Graphviz {
+			if svg, err := renderDot(ctx, d.Source); err == nil {
+				items[i].SVG = template.HTML(svg)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := pageTemplate.Execute(&buf, items); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Write writes the diagrams' page to a new file in dir and returns its path
+func Write(ctx context.Context, dir string, diagrams []Diagram) (string, error) {
+	if len(diagrams) == 0 {
+		return "", fmt.Errorf("no diagrams to render")
+	}
+	page, err := Page(ctx, diagrams)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "diagram-"+time.Now().Format("20060102-150405.000")+".html")
+	if err := os.WriteFile(path, page, 0o644); err != nil {
+		return "", err
+	}
+	return filepath.Abs(path)
+}
+
+// Open writes the diagrams' page to dir and opens it in the browser,
+// returning the page's path
+func Open(ctx context.Context, dir string, diagrams []Diagram) (string, error) {
+	path, err := Write(ctx, dir, diagrams)
+	if err != nil {
+		return "", err
+	}
+	if err := platform.OpenBrowser(path); err != nil {
+		return path, fmt.Errorf("failed to open a browser: %w", err)
+	}
+	return path, nil
+}
+
+// renderDot renders Graphviz source to inline SVG with a local dot
+func renderDot(ctx context.Context, source string) (string, error) {
+	dot, err := exec.LookPath("dot")
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, dot, "-Tsvg")
+	cmd.Stdin = strings.NewReader(source)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	// Drop the XML prolog and doctype so the SVG can be inlined
+	svg := string(out)
+	if i := strings.Index(svg, "<svg"); i >= 0 {
+		svg = svg[i:]
+	}
+	return svg, nil
+}
@@ -0,0 +1,76 @@
+package diff
+
+import (
+	"strings"
+
+	"github.com/aymanbagabas/go-udiff"
+	"github.com/aymanbagabas/go-udiff/lcs"
+)
+
+// Merge3 combines two edited versions of base, ours and theirs, line by line,
+// the way a three-way merge does. It reports false, with no result, when both
+// change the same lines differently.
+func Merge3(base, ours, theirs string) (string, bool) {
+	ids := make(map[string]rune)
+	baseLines := splitLines(base)
+	baseIDs := lineIDs(baseLines, ids)
+
+	oursEdits := lineEdits(baseLines, baseIDs, ours, ids)
+	theirsEdits := lineEdits(baseLines, baseIDs, theirs, ids)
+
+	edits, ok := udiff.Merge(oursEdits, theirsEdits)
+	if !ok {
+		return "", false
+	}
+	merged, err := udiff.Apply(base, edits)
+	if err != nil {
+		return "", false
+	}
+	return merged, true
+}
+
+// lineEdits returns the edits turning base into other, each covering whole
+// lines, as byte offsets into base
+func lineEdits(baseLines []string, baseIDs []rune, other string, ids map[string]rune) []udiff.Edit {
+	otherLines := splitLines(other)
+	otherIDs := lineIDs(otherLines, ids)
+
+	// Offset of each base line, plus the end
+	offsets := make([]int, len(baseLines)+1)
+	for i, line := range baseLines {
+		offsets[i+1] = offsets[i] + len(line)
+	}
+
+	var edits []udiff.Edit
+	for _, d := range lcs.DiffRunes(baseIDs, otherIDs) {
+		edits = append(edits, udiff.Edit{
+			Start: offsets[d.Start],
+			End:   offsets[d.End],
+			New:   strings.Join(otherLines[d.ReplStart:d.ReplEnd], ""),
+		})
+	}
+	return edits
+}
+
+// lineIDs numbers lines so equal lines get the same ID
+func lineIDs(lines []string, ids map[string]rune) []rune {
+	result := make([]rune, len(lines))
+	for i, line := range lines {
+		id, ok := ids[line]
+		if !ok {
+			id = rune(len(ids))
+			ids[line] = id
+		}
+		result[i] = id
+	}
+	return result
+}
+
+// splitLines splits s after each newline
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
@@ -0,0 +1,57 @@
+package diff
+
+import "testing"
+
+func TestMerge3(t *testing.T) {
+	base := "one\ntwo\nthree\nfour\nfive\n"
+
+	tests := []struct {
+		name   string
+		ours   string
+		theirs string
+		want   string
+		clean  bool
+	}{
+		{
+			name:   "separate lines",
+			ours:   "ONE\ntwo\nthree\nfour\nfive\n",
+			theirs: "one\ntwo\nthree\nfour\nFIVE\n",
+			want:   "ONE\ntwo\nthree\nfour\nFIVE\n",
+			clean:  true,
+		},
+		{
+			name:   "same change",
+			ours:   "one\nTWO\nthree\nfour\nfive\n",
+			theirs: "one\nTWO\nthree\nfour\nfive\n",
+			want:   "one\nTWO\nthree\nfour\nfive\n",
+			clean:  true,
+		},
+		{
+			name:   "insert and delete",
+			ours:   "one\ntwo\n2.5\nthree\nfour\nfive\n",
+			theirs: "one\ntwo\nthree\nfive\n",
+			want:   "one\ntwo\n2.5\nthree\nfive\n",
+			clean:  true,
+		},
+		{
+			name:   "same line changed differently",
+			ours:   "one\ntwo\nTHREE\nfour\nfive\n",
+			theirs: "one\ntwo\n3\nfour\nfive\n",
+			clean:  false,
+		},
+		{
+			name:   "no trailing newline",
+			ours:   "one\ntwo\nthree\nfour\nfive",
+			theirs: "uno\ntwo\nthree\nfour\nfive\n",
+			want:   "uno\ntwo\nthree\nfour\nfive",
+			clean:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		got, clean := Merge3(base, tt.ours, tt.theirs)
+		if clean != tt.clean || got != tt.want {
+			t.Errorf("%s: Merge3 = %q, %v; want %q, %v", tt.name, got, clean, tt.want, tt.clean)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package docs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+)
+
+// NotCovered is the answer when the documentation doesn't say
+const NotCovered = "The documentation doesn't cover this."
+
+const answerPrompt = `You answer questions about a project using only excerpts from its documentation. You have no other knowledge of the project: don't use the code, general knowledge or guesses to fill gaps.
+
+Rules:
+- Cite the excerpt behind every statement with its number in brackets, such as [2]. Every paragraph and list item needs at least one citation.
+- If the excerpts don't answer the question, reply with exactly: ` + NotCovered + `
+- If they answer only part of it, answer that part and say what the documentation doesn't cover.
+- Keep the documentation's terms, commands and names exactly as written.`
+
+// Answer asks the model to answer a question from the sources alone
+func Answer(ctx context.Context, handler llm.ApiHandler, question string, sources []Source) (string, error) {
+	if len(sources) == 0 {
+		return NotCovered, nil
+	}
+
+	messages := []llm.Message{{
+		Role:    "user",
+		Content: []llm.ContentBlock{llm.TextBlock{Text: Prompt(question, sources)}},
+	}}
+	stream, err := handler.CreateMessage(ctx, answerPrompt, messages)
+	if err != nil {
+		return "", fmt.Errorf("failed to answer: %w", err)
+	}
+
+	var answer strings.Builder
+	for chunk := range stream {
+		if text, ok := chunk.(llm.ApiStreamTextChunk); ok {
+			answer.WriteString(text.Text)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(answer.String()), nil
+}
+
+// Prompt formats a question and its numbered sources for the model
+func Prompt(question string, sources []Source) string {
+	var b strings.Builder
+	b.WriteString("# Documentation excerpts\n\n")
+	for _, s := range sources {
+		fmt.Fprintf(&b, "[%d] %s\n\n%s\n\n", s.N, s.Section.Cite(), s.Section.Content)
+	}
+	b.WriteString("# Question\n\n")
+	b.WriteString(question)
+	return b.String()
+}
@@ -0,0 +1,180 @@
+package docs
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/fileutil"
+	"github.com/entrepeneur4lyf/codeforge/internal/ignore"
+	"github.com/entrepeneur4lyf/codeforge/internal/vectordb"
+)
+
+// ChunkType marks the chunks of the knowledge base in the vector database
+const ChunkType = "doc_section"
+
+// MinScore is the lowest similarity at which a section counts as relevant
+const MinScore = 0.2
+
+// extensions are the documents ingested from directories
+var extensions = map[string]bool{".md": true, ".markdown": true, ".mdx": true, ".txt": true}
+
+// Store is the part of the vector database the knowledge base uses
+type Store interface {
+	RemoveFile(ctx context.Context, filePath string) error
+	StoreChunk(ctx context.Context, chunk *vectordb.CodeChunk, embedding []float32) error
+	SearchSimilarChunks(ctx context.Context, queryEmbedding []float32, maxResults int, filters map[string]string) ([]vectordb.SearchResult, error)
+}
+
+// Embedder returns the embedding of a text
+type Embedder func(ctx context.Context, text string) ([]float32, error)
+
+// IngestReport counts what Ingest added
+type IngestReport struct {
+	Files    int
+	Sections int
+}
+
+// Ingest adds the documents at paths, files or directories relative to
+// root, to the knowledge base. A document ingested before is replaced.
+func Ingest(ctx context.Context, store Store, embed Embedder, root string, paths []string) (*IngestReport, error) {
+	report := &IngestReport{}
+	matcher := ignore.Default()
+
+	for _, p := range paths {
+		full := p
+		if !filepath.IsAbs(full) {
+			full = filepath.Join(root, p)
+		}
+		info, err := os.Stat(full)
+		if os.IsNotExist(err) {
+			continue // Paths such as the default docs directory are optional
+		}
+		if err != nil {
+			return report, err
+		}
+
+		if !info.IsDir() {
+			if err := ingestFile(ctx, store, embed, root, full, report); err != nil {
+				return report, err
+			}
+			continue
+		}
+
+		err = filepath.WalkDir(full, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			rel, _ := filepath.Rel(root, path)
+			if path != full && matcher.Match(filepath.ToSlash(rel)) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if d.IsDir() || !extensions[strings.ToLower(filepath.Ext(path))] {
+				return nil
+			}
+			return ingestFile(ctx, store, embed, root, path, report)
+		})
+		if err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+func ingestFile(ctx context.Context, store Store, embed Embedder, root, path string, report *IngestReport) error {
+	file, err := fileutil.ReadText(path, fileutil.MaxFileSize())
+	if err != nil {
+		return nil // Binary and oversized files aren't documentation
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		rel = path
+	}
+	rel = filepath.ToSlash(rel)
+
+	if err := store.RemoveFile(ctx, rel); err != nil {
+		return err
+	}
+	for _, section := range Split(rel, file.Content) {
+		// The heading is embedded with the text, as questions often name it
+		embedding, err := embed(ctx, section.Heading+"\n\n"+section.Content)
+		if err != nil {
+			return fmt.Errorf("failed to embed %s: %w", section.Cite(), err)
+		}
+		chunk := &vectordb.CodeChunk{
+			ID:       fmt.Sprintf("doc:%s:%d", rel, section.StartLine),
+			FilePath: rel,
+			Content:  section.Content,
+			ChunkType: vectordb.ChunkType{Type: ChunkType, Data: map[string]interface{}{
+				"heading": section.Heading,
+			}},
+			Language: "markdown",
+			Location: vectordb.SourceLocation{StartLine: section.StartLine, EndLine: section.EndLine},
+			Metadata: map[string]string{"heading": section.Heading},
+		}
+		if err := store.StoreChunk(ctx, chunk, embedding); err != nil {
+			return err
+		}
+		report.Sections++
+	}
+	report.Files++
+	return ctx.Err()
+}
+
+// Source is a section retrieved for a question, numbered for citation
+type Source struct {
+	N       int
+	Section Section
+	Score   float32
+}
+
+// Retrieve returns the sections of the knowledge base most relevant to a
+// question, best first. Code chunks are never returned.
+func Retrieve(ctx context.Context, store Store, embed Embedder, question string, limit int) ([]Source, error) {
+	embedding, err := embed(ctx, question)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed the question: %w", err)
+	}
+	results, err := store.SearchSimilarChunks(ctx, embedding, limit, map[string]string{"chunk_type": ChunkType})
+	if err != nil {
+		return nil, err
+	}
+
+	var sources []Source
+	for _, r := range results {
+		// The chunk type filter matches by substring, so check it exactly
+		if r.Chunk.ChunkType.Type != ChunkType || r.Score < MinScore {
+			continue
+		}
+		sources = append(sources, Source{
+			N:     len(sources) + 1,
+			Score: r.Score,
+			Section: Section{
+				Path:      r.Chunk.FilePath,
+				Heading:   r.Chunk.Metadata["heading"],
+				Content:   r.Chunk.Content,
+				StartLine: r.Chunk.Location.StartLine,
+				EndLine:   r.Chunk.Location.EndLine,
+			},
+		})
+	}
+	return sources, nil
+}
+
+// Cited returns the sources an answer cites as [n]
+func Cited(answer string, sources []Source) []Source {
+	var cited []Source
+	for _, s := range sources {
+		if strings.Contains(answer, "["+strconv.Itoa(s.N)+"]") {
+			cited = append(cited, s)
+		}
+	}
+	return cited
+}
@@ -0,0 +1,155 @@
+package docs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/vectordb"
+)
+
+const deployDoc = `# Operations
+
+## Deploy
+
+Deploys run from CI when a tag is pushed.
+
+` + "```sh" + `
+# not a heading
+make release
+` + "```" + `
+
+### Rollback
+
+Run ` + "`make rollback`" + ` to go back one release.
+
+## Empty
+`
+
+func TestSplit(t *testing.T) {
+	sections := Split("docs/ops.md", deployDoc)
+	if len(sections) != 2 {
+		t.Fatalf("got %d sections: %+v", len(sections), sections)
+	}
+
+	deploy, rollback := sections[0], sections[1]
+	if deploy.Heading != "Operations > Deploy" || deploy.StartLine != 3 || deploy.EndLine != 11 {
+		t.Errorf("deploy section = %+v", deploy)
+	}
+	if !strings.Contains(deploy.Content, "# not a heading") {
+		t.Errorf("code fence was split: %q", deploy.Content)
+	}
+	if rollback.Cite() != "docs/ops.md § Operations > Deploy > Rollback" {
+		t.Errorf("rollback cite = %q", rollback.Cite())
+	}
+
+	plain := Split("NOTES.txt", "# not markdown\nplain text")
+	if len(plain) != 1 || plain[0].Heading != "" || plain[0].Cite() != "NOTES.txt" {
+		t.Errorf("plain text sections = %+v", plain)
+	}
+}
+
+func TestSplitLong(t *testing.T) {
+	para := strings.Repeat("word ", 300)
+	doc := "# Long\n\n" + strings.Repeat(para+"\n\n", 10)
+	sections := Split("long.md", doc)
+	if len(sections) < 2 {
+		t.Fatalf("got %d sections, want the long one split", len(sections))
+	}
+	for _, s := range sections {
+		if s.Heading != "Long" || len(s.Content) > maxSectionChars+len(para) {
+			t.Errorf("section %q of %d chars", s.Heading, len(s.Content))
+		}
+	}
+}
+
+// memStore is a Store that returns every stored chunk the filter allows
+type memStore struct {
+	chunks  map[string]*vectordb.CodeChunk
+	removed []string
+}
+
+func (m *memStore) RemoveFile(_ context.Context, path string) error {
+	m.removed = append(m.removed, path)
+	for id, c := range m.chunks {
+		if c.FilePath == path {
+			delete(m.chunks, id)
+		}
+	}
+	return nil
+}
+
+func (m *memStore) StoreChunk(_ context.Context, chunk *vectordb.CodeChunk, _ []float32) error {
+	m.chunks[chunk.ID] = chunk
+	return nil
+}
+
+func (m *memStore) SearchSimilarChunks(_ context.Context, _ []float32, limit int, filters map[string]string) ([]vectordb.SearchResult, error) {
+	var results []vectordb.SearchResult
+	for _, c := range m.chunks {
+		if strings.Contains(c.ChunkType.Type, filters["chunk_type"]) && len(results) < limit {
+			results = append(results, vectordb.SearchResult{Chunk: *c, Score: 0.9})
+		}
+	}
+	return results, nil
+}
+
+func embedStub(context.Context, string) ([]float32, error) {
+	return []float32{1}, nil
+}
+
+func TestIngestAndRetrieve(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "docs", "node_modules"), 0o755)
+	os.WriteFile(filepath.Join(root, "docs", "ops.md"), []byte(deployDoc), 0o644)
+	os.WriteFile(filepath.Join(root, "docs", "main.go"), []byte("package main"), 0o644)
+	os.WriteFile(filepath.Join(root, "docs", "node_modules", "x.md"), []byte("# X\n\nignored"), 0o644)
+
+	store := &memStore{chunks: map[string]*vectordb.CodeChunk{
+		"code": {ID: "code", FilePath: "main.go", ChunkType: vectordb.ChunkType{Type: "function"}},
+	}}
+	report, err := Ingest(context.Background(), store, embedStub, root, []string{"docs", "README.md"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Files != 1 || report.Sections != 2 {
+		t.Errorf("report = %+v", report)
+	}
+	if len(store.removed) != 1 || store.removed[0] != "docs/ops.md" {
+		t.Errorf("removed = %v, want the document replaced", store.removed)
+	}
+
+	sources, err := Retrieve(context.Background(), store, embedStub, "how do I roll back?", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sources) != 2 {
+		t.Fatalf("got %d sources, want only the 2 doc sections: %+v", len(sources), sources)
+	}
+	for i, s := range sources {
+		if s.N != i+1 || s.Section.Path != "docs/ops.md" || s.Section.Heading == "" {
+			t.Errorf("source %d = %+v", i, s)
+		}
+	}
+}
+
+func TestCited(t *testing.T) {
+	sources := []Source{{N: 1}, {N: 2}, {N: 12}}
+	cited := Cited("Tags trigger deploys [2]. Roll back with make rollback [12].", sources)
+	if len(cited) != 2 || cited[0].N != 2 || cited[1].N != 12 {
+		t.Errorf("cited = %+v", cited)
+	}
+	if len(Cited("No citations here.", sources)) != 0 {
+		t.Error("uncited answer has citations")
+	}
+}
+
+func TestAnswerWithoutSources(t *testing.T) {
+	// No model is needed when nothing matched
+	answer, err := Answer(context.Background(), nil, "how do we deploy?", nil)
+	if err != nil || answer != NotCovered {
+		t.Errorf("Answer = %q, %v", answer, err)
+	}
+}
@@ -0,0 +1,171 @@
+// Package docs keeps a knowledge base of the project's documentation in
+// the vector database, beside the code index, and answers questions from it
+// alone with a citation for every source used
+package docs
+
+import (
+	"strings"
+)
+
+// maxSectionChars is the most text embedded as one section; longer
+// sections are split at paragraph breaks
+const maxSectionChars = 4000
+
+// Section is the part of a document under one heading
+type Section struct {
+	Path      string // Document, relative to the project
+	Heading   string // Headings down to the section, as "Deploy > Rollback"; "" before the first heading
+	Content   string
+	StartLine int
+	EndLine   int
+}
+
+// Cite names the section in a citation, as "docs/deploy.md § Deploy > Rollback"
+func (s Section) Cite() string {
+	if s.Heading == "" {
+		return s.Path
+	}
+	return s.Path + " § " + s.Heading
+}
+
+// Split cuts a document into sections at its headings. Markdown documents
+// are split at ATX headings outside code fences; other text is one
+// section, cut at paragraph breaks when it's long.
+func Split(path, content string) []Section {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	if !isMarkdown(path) {
+		return splitLong(Section{Path: path, Content: content, StartLine: 1, EndLine: len(lines)})
+	}
+
+	var sections []Section
+	var headings []string // Open headings by level, 1-based
+	current := Section{Path: path, StartLine: 1}
+	var body []string
+	fence := ""
+
+	flush := func(end int) {
+		current.Content = strings.TrimSpace(strings.Join(body, "\n"))
+		current.EndLine = end
+		// A heading alone, such as a title over subsections, has nothing to cite
+		if hasText(current.Content, current.Heading != "") {
+			sections = append(sections, splitLong(current)...)
+		}
+		body = nil
+	}
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if marker := fenceMarker(trimmed); marker != "" {
+			switch {
+			case fence == "":
+				fence = marker
+			case strings.HasPrefix(trimmed, fence) && strings.TrimLeft(trimmed, fence[:1]) == "":
+				fence = ""
+			}
+		}
+
+		if fence == "" {
+			if level, title, ok := heading(line); ok {
+				flush(i)
+				if level > len(headings) {
+					headings = append(headings, make([]string, level-len(headings))...)
+				}
+				headings = append(headings[:level-1], title)
+				current = Section{Path: path, Heading: joinHeadings(headings), StartLine: i + 1}
+			}
+		}
+		body = append(body, line)
+	}
+	flush(len(lines))
+	return sections
+}
+
+func isMarkdown(path string) bool {
+	lower := strings.ToLower(path)
+	for _, ext := range []string{".md", ".markdown", ".mdx"} {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// heading parses an ATX heading such as "## Rollback"
+func heading(line string) (int, string, bool) {
+	if len(line)-len(strings.TrimLeft(line, " ")) > 3 {
+		return 0, "", false
+	}
+	line = strings.TrimLeft(line, " ")
+	level := len(line) - len(strings.TrimLeft(line, "#"))
+	if level == 0 || level > 6 {
+		return 0, "", false
+	}
+	rest := line[level:]
+	if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+		return 0, "", false // "#hashtag" is text
+	}
+	title := strings.TrimSpace(strings.TrimRight(strings.TrimSpace(rest), "#"))
+	if title == "" {
+		return 0, "", false
+	}
+	return level, title, true
+}
+
+// fenceMarker returns the fence a line opens or closes, or ""
+func fenceMarker(line string) string {
+	for _, c := range []string{"`", "~"} {
+		if strings.HasPrefix(line, c+c+c) {
+			return line[:len(line)-len(strings.TrimLeft(line, c))]
+		}
+	}
+	return ""
+}
+
+// joinHeadings joins the open headings, skipping levels a document jumped over
+func joinHeadings(headings []string) string {
+	var parts []string
+	for _, h := range headings {
+		if h != "" {
+			parts = append(parts, h)
+		}
+	}
+	return strings.Join(parts, " > ")
+}
+
+// hasText reports whether a section says anything besides its heading line
+func hasText(content string, skipHeading bool) bool {
+	if skipHeading {
+		_, rest, _ := strings.Cut(content, "\n")
+		content = rest
+	}
+	return strings.TrimSpace(content) != ""
+}
+
+// splitLong cuts a section longer than maxSectionChars at paragraph breaks.
+// The parts keep the section's heading.
+func splitLong(s Section) []Section {
+	if len(s.Content) <= maxSectionChars {
+		return []Section{s}
+	}
+
+	var parts []Section
+	part := Section{Path: s.Path, Heading: s.Heading, StartLine: s.StartLine}
+	var text []string
+	size := 0
+	line := s.StartLine
+	for _, para := range strings.Split(s.Content, "\n\n") {
+		if size > 0 && size+len(para) > maxSectionChars {
+			part.Content = strings.Join(text, "\n\n")
+			part.EndLine = line - 1
+			parts = append(parts, part)
+			part = Section{Path: s.Path, Heading: s.Heading, StartLine: line}
+			text, size = nil, 0
+		}
+		text = append(text, para)
+		size += len(para) + 2
+		line += strings.Count(para, "\n") + 2
+	}
+	part.Content = strings.Join(text, "\n\n")
+	part.EndLine = s.EndLine
+	return append(parts, part)
+}
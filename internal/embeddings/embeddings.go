@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/entrepeneur4lyf/codeforge/internal/config"
+	"github.com/entrepeneur4lyf/codeforge/internal/httpclient"
 )
 
 // EmbeddingProvider represents different embedding providers
@@ -25,11 +26,20 @@ const (
 	ProviderFallback
 )
 
+// failoverCooldown is how long embeddings come from the secondary provider
+// after the primary fails before the primary is tried again
+const failoverCooldown = time.Minute
+
 // EmbeddingService handles text embedding generation with multiple providers
 type EmbeddingService struct {
 	provider    EmbeddingProvider
 	initialized bool
 	mu          sync.RWMutex
+
+	// Failover, used when the primary provider errors or rate-limits
+	secondary    EmbeddingProvider
+	hasSecondary bool
+	retryPrimary time.Time // Until then the secondary is used directly
 }
 
 // OllamaEmbeddingRequest represents a request to Ollama's embedding API
@@ -48,6 +58,7 @@ type OpenAIEmbeddingRequest struct {
 	Input          string `json:"input"`
 	Model          string `json:"model"`
 	EncodingFormat string `json:"encoding_format,omitempty"`
+	Dimensions     int    `json:"dimensions,omitempty"` // Shortens the embedding
 }
 
 // OpenAIEmbeddingResponse represents a response from OpenAI's embedding API
@@ -60,9 +71,34 @@ type OpenAIEmbeddingResponse struct {
 // Global embedding service instance
 var embeddingService *EmbeddingService
 
+// Config to initialize with when an embedding is first asked for
+var (
+	pendingConfig *config.Config
+	pendingOnce   sync.Once
+)
+
+// InitializeOnFirstUse defers Initialize until the service is first used, so
+// commands that never embed don't probe the embedding providers
+func InitializeOnFirstUse(cfg *config.Config) {
+	pendingConfig = cfg
+}
+
+// ensureInitialized runs the Initialize deferred by InitializeOnFirstUse
+func ensureInitialized() {
+	if pendingConfig == nil {
+		return
+	}
+	pendingOnce.Do(func() {
+		if embeddingService == nil {
+			Initialize(pendingConfig) // Never fails; it falls back to hash embeddings
+		}
+	})
+}
+
 // Initialize sets up the embedding service with the best available provider
 func Initialize(cfg *config.Config) error {
 	embeddingService = &EmbeddingService{}
+	defer setupFailover(cfg)
 
 	// Check config preference first
 	if cfg != nil && cfg.Embedding.Provider != "" {
@@ -79,6 +115,10 @@ func Initialize(cfg *config.Config) error {
 			}
 			log.Printf("Ollama configured but not available, falling back...")
 		case "openai":
+			if cfg.LocalOnly {
+				log.Printf("OpenAI embeddings configured but local-only mode is enabled, falling back...")
+				break
+			}
 			if isOpenAIAvailable() {
 				if err := checkProviderChange(ProviderOpenAI); err != nil {
 					log.Printf("Provider change validation failed: %v", err)
@@ -104,7 +144,7 @@ func Initialize(cfg *config.Config) error {
 	// Show available options
 	if isOllamaAvailable() {
 		log.Printf("Ollama detected - use '/embedding ollama' for better quality")
-	} else if isOpenAIAvailable() {
+	} else if isOpenAIAvailable() && !config.IsLocalOnly() {
 		log.Printf("OpenAI API detected - use '/embedding openai' for better quality")
 	}
 
@@ -114,22 +154,110 @@ func Initialize(cfg *config.Config) error {
 
 // Get returns the global embedding service instance
 func Get() *EmbeddingService {
+	ensureInitialized()
 	return embeddingService
 }
 
 // GetEmbedding generates an embedding using the best available service
 func GetEmbedding(ctx context.Context, text string) ([]float32, error) {
+	ensureInitialized()
 	if embeddingService == nil || !embeddingService.initialized {
 		return nil, fmt.Errorf("embedding service not initialized")
 	}
 
-	switch embeddingService.provider {
+	embeddingService.mu.RLock()
+	provider := embeddingService.provider
+	secondary, hasSecondary := embeddingService.secondary, embeddingService.hasSecondary
+	cooling := time.Now().Before(embeddingService.retryPrimary)
+	embeddingService.mu.RUnlock()
+
+	dimensions := getProviderDimensions(provider)
+	if hasSecondary && cooling {
+		return embedWith(ctx, secondary, text, dimensions)
+	}
+
+	embedding, err := embedWith(ctx, provider, text, dimensions)
+	if err == nil || !hasSecondary || ctx.Err() != nil {
+		return embedding, err
+	}
+
+	log.Printf("%s embeddings failed, using %s for %v: %v",
+		getProviderName(provider), getProviderName(secondary), failoverCooldown, err)
+	embeddingService.mu.Lock()
+	embeddingService.retryPrimary = time.Now().Add(failoverCooldown)
+	embeddingService.mu.Unlock()
+
+	return embedWith(ctx, secondary, text, dimensions)
+}
+
+// embedWith generates an embedding of the given size with one provider
+func embedWith(ctx context.Context, provider EmbeddingProvider, text string, dimensions int) ([]float32, error) {
+	switch provider {
 	case ProviderOllama:
 		return getOllamaEmbedding(ctx, text)
 	case ProviderOpenAI:
-		return getOpenAIEmbedding(ctx, text)
+		return getOpenAIEmbedding(ctx, text, dimensions)
 	default:
-		return getFallbackEmbedding(text), nil
+		return getFallbackEmbedding(text, dimensions), nil
+	}
+}
+
+// setupFailover enables the configured secondary provider when it's usable
+// and produces embeddings the same size as the primary's, so they can be
+// stored and searched alongside each other
+func setupFailover(cfg *config.Config) {
+	if cfg == nil || cfg.Embedding.Secondary == "" {
+		return
+	}
+
+	var secondary EmbeddingProvider
+	switch cfg.Embedding.Secondary {
+	case "ollama":
+		if !isOllamaAvailable() {
+			log.Printf("Secondary embedding provider Ollama not available, failover disabled")
+			return
+		}
+		secondary = ProviderOllama
+	case "openai":
+		if cfg.LocalOnly || !isOpenAIAvailable() {
+			log.Printf("Secondary embedding provider OpenAI not available, failover disabled")
+			return
+		}
+		secondary = ProviderOpenAI
+	case "fallback":
+		secondary = ProviderFallback
+	default:
+		log.Printf("Unknown secondary embedding provider %q, failover disabled", cfg.Embedding.Secondary)
+		return
+	}
+
+	primary := embeddingService.provider
+	if secondary == primary {
+		return
+	}
+	if !canProduceDimensions(secondary, getProviderDimensions(primary)) {
+		log.Printf("%s embeddings are %dD but %s embeddings are %dD, failover disabled",
+			getProviderName(primary), getProviderDimensions(primary),
+			getProviderName(secondary), getProviderDimensions(secondary))
+		return
+	}
+
+	embeddingService.secondary = secondary
+	embeddingService.hasSecondary = true
+	log.Printf("Embeddings fail over to %s", getProviderName(secondary))
+}
+
+// canProduceDimensions reports whether a provider can make embeddings of the
+// given size. The hash-based fallback works at any size and OpenAI's
+// text-embedding-3 models can shorten theirs.
+func canProduceDimensions(provider EmbeddingProvider, dimensions int) bool {
+	switch provider {
+	case ProviderFallback:
+		return true
+	case ProviderOpenAI:
+		return dimensions <= getProviderDimensions(ProviderOpenAI)
+	default:
+		return dimensions == getProviderDimensions(provider)
 	}
 }
 
@@ -235,7 +363,11 @@ func getOllamaEmbedding(ctx context.Context, text string) ([]float32, error) {
 }
 
 // getOpenAIEmbedding gets an embedding from OpenAI
-func getOpenAIEmbedding(ctx context.Context, text string) ([]float32, error) {
+func getOpenAIEmbedding(ctx context.Context, text string, dimensions int) ([]float32, error) {
+	if config.IsLocalOnly() {
+		return nil, config.LocalOnlyError("OpenAI embeddings")
+	}
+
 	apiKey := os.Getenv("OPENAI_API_KEY")
 	if apiKey == "" {
 		return nil, fmt.Errorf("OPENAI_API_KEY not set")
@@ -245,6 +377,9 @@ func getOpenAIEmbedding(ctx context.Context, text string) ([]float32, error) {
 		Input: text,
 		Model: "text-embedding-3-small", // Cheaper and faster than large
 	}
+	if dimensions < getProviderDimensions(ProviderOpenAI) {
+		req.Dimensions = dimensions
+	}
 
 	jsonData, err := json.Marshal(req)
 	if err != nil {
@@ -259,7 +394,7 @@ func getOpenAIEmbedding(ctx context.Context, text string) ([]float32, error) {
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("Authorization", "Bearer "+apiKey)
 
-	client := &http.Client{Timeout: 30 * time.Second}
+	client := httpclient.New("openai", 30*time.Second)
 	resp, err := client.Do(httpReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to make request: %w", err)
@@ -329,11 +464,11 @@ func getProviderDimensions(provider EmbeddingProvider) int {
 	}
 }
 
-// getFallbackEmbedding creates a simple hash-based embedding as fallback
-func getFallbackEmbedding(text string) []float32 {
+// getFallbackEmbedding creates a simple hash-based embedding as fallback,
+// with embeddingDim dimensions
+func getFallbackEmbedding(text string, embeddingDim int) []float32 {
 	// Simple hash-based pseudo-embedding for fallback
 	// This is basic but ensures the system always works
-	const embeddingDim = 384
 
 	embedding := make([]float32, embeddingDim)
 	text = strings.ToLower(text)
@@ -373,6 +508,7 @@ func preprocessCode(code, language string) string {
 
 // ValidateProviderChange manually validates and handles embedding provider changes
 func ValidateProviderChange(newProviderName string) error {
+	ensureInitialized()
 	if embeddingService == nil {
 		return fmt.Errorf("embedding service not initialized")
 	}
@@ -385,6 +521,9 @@ func ValidateProviderChange(newProviderName string) error {
 		}
 		newProvider = ProviderOllama
 	case "openai":
+		if config.IsLocalOnly() {
+			return config.LocalOnlyError("OpenAI embeddings")
+		}
 		if !isOpenAIAvailable() {
 			return fmt.Errorf("OpenAI API key not available")
 		}
@@ -403,6 +542,12 @@ func ValidateProviderChange(newProviderName string) error {
 	// Update the service
 	embeddingService.mu.Lock()
 	embeddingService.provider = newProvider
+	embeddingService.retryPrimary = time.Time{}
+	if embeddingService.hasSecondary && !canProduceDimensions(embeddingService.secondary, getProviderDimensions(newProvider)) {
+		embeddingService.hasSecondary = false
+		log.Printf("%s embeddings don't match %s, failover disabled",
+			getProviderName(embeddingService.secondary), getProviderName(newProvider))
+	}
 	embeddingService.mu.Unlock()
 
 	log.Printf("Successfully changed embedding provider to: %s", getProviderName(newProvider))
@@ -411,6 +556,7 @@ func ValidateProviderChange(newProviderName string) error {
 
 // GetCurrentProvider returns information about the current embedding provider
 func GetCurrentProvider() (string, int, error) {
+	ensureInitialized()
 	if embeddingService == nil || !embeddingService.initialized {
 		return "", 0, fmt.Errorf("embedding service not initialized")
 	}
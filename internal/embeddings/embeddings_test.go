@@ -0,0 +1,53 @@
+package embeddings
+
+import (
+	"context"
+	"testing"
+)
+
+func TestGetEmbeddingFailover(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "")
+	embeddingService = &EmbeddingService{
+		provider:     ProviderOpenAI,
+		initialized:  true,
+		secondary:    ProviderFallback,
+		hasSecondary: true,
+	}
+	t.Cleanup(func() { embeddingService = nil })
+
+	embedding, err := GetEmbedding(context.Background(), "func main() {}")
+	if err != nil {
+		t.Fatalf("GetEmbedding() error = %v", err)
+	}
+	if len(embedding) != 1536 {
+		t.Errorf("failover embedding has %d dimensions, want the primary's 1536", len(embedding))
+	}
+	if embeddingService.retryPrimary.IsZero() {
+		t.Error("primary not put on cooldown after failing")
+	}
+
+	// Without a secondary the error comes through
+	embeddingService.hasSecondary = false
+	if _, err := GetEmbedding(context.Background(), "func main() {}"); err == nil {
+		t.Error("GetEmbedding() succeeded without a working provider")
+	}
+}
+
+func TestCanProduceDimensions(t *testing.T) {
+	tests := []struct {
+		provider   EmbeddingProvider
+		dimensions int
+		want       bool
+	}{
+		{ProviderFallback, 768, true},
+		{ProviderOpenAI, 768, true},
+		{ProviderOpenAI, 3072, false},
+		{ProviderOllama, 1536, false},
+		{ProviderOllama, 768, true},
+	}
+	for _, tt := range tests {
+		if got := canProduceDimensions(tt.provider, tt.dimensions); got != tt.want {
+			t.Errorf("canProduceDimensions(%s, %d) = %v, want %v", getProviderName(tt.provider), tt.dimensions, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package embeddings
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+)
+
+// codeSynonyms maps words common in search queries to the terms code uses
+// for the same idea
+var codeSynonyms = map[string][]string{
+	"function":   {"func", "method", "def", "fn"},
+	"method":     {"func", "function"},
+	"error":      {"err", "exception", "failure"},
+	"exception":  {"error", "throw", "catch"},
+	"delete":     {"remove", "drop", "destroy"},
+	"remove":     {"delete", "drop"},
+	"create":     {"new", "make", "add", "init"},
+	"add":        {"append", "insert", "create"},
+	"update":     {"set", "modify", "edit", "save"},
+	"get":        {"fetch", "load", "read", "find"},
+	"fetch":      {"get", "load", "request"},
+	"config":     {"configuration", "settings", "options"},
+	"settings":   {"config", "options", "preferences"},
+	"auth":       {"authentication", "login", "token", "credentials"},
+	"login":      {"auth", "signin", "session"},
+	"database":   {"db", "sql", "store", "storage"},
+	"db":         {"database", "sql"},
+	"test":       {"spec", "assert", "mock"},
+	"http":       {"request", "handler", "server", "client"},
+	"endpoint":   {"handler", "route", "api"},
+	"parse":      {"decode", "unmarshal", "read"},
+	"serialize":  {"encode", "marshal"},
+	"start":      {"init", "run", "launch", "begin"},
+	"stop":       {"close", "shutdown", "cancel"},
+	"check":      {"validate", "verify", "is"},
+	"validate":   {"check", "verify"},
+	"list":       {"all", "slice", "array"},
+	"message":    {"msg"},
+	"request":    {"req"},
+	"response":   {"resp", "res"},
+	"context":    {"ctx"},
+	"initialize": {"init", "setup", "new"},
+}
+
+// ExpandQuery adds identifier parts and code synonyms to a search query, so
+// "getUserConfig" also matches "get user configuration settings" and a vague
+// "delete session" also finds code that removes one
+func ExpandQuery(query string) string {
+	seen := map[string]bool{}
+	for _, word := range strings.Fields(strings.ToLower(query)) {
+		seen[word] = true
+	}
+
+	var extra []string
+	add := func(term string) {
+		if term != "" && !seen[term] {
+			seen[term] = true
+			extra = append(extra, term)
+		}
+	}
+
+	for _, word := range strings.Fields(query) {
+		parts := splitIdentifier(word)
+		if len(parts) > 1 {
+			for _, part := range parts {
+				add(part)
+			}
+		}
+		for _, part := range parts {
+			for _, synonym := range codeSynonyms[part] {
+				add(synonym)
+			}
+		}
+	}
+
+	if len(extra) == 0 {
+		return query
+	}
+	return query + " " + strings.Join(extra, " ")
+}
+
+// splitIdentifier splits camelCase, PascalCase, snake_case and kebab-case
+// identifiers into lowercase words, so "parseHTTPRequest" becomes
+// "parse", "http" and "request"
+func splitIdentifier(word string) []string {
+	var parts []string
+	var current []rune
+	flush := func() {
+		if len(current) > 0 {
+			parts = append(parts, strings.ToLower(string(current)))
+			current = current[:0]
+		}
+	}
+
+	runes := []rune(word)
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+			continue
+		case unicode.IsUpper(r) && len(current) > 0:
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			// A new word starts after a lowercase letter, or at the last
+			// capital of an acronym followed by lowercase ("HTTPRequest")
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				flush()
+			}
+		}
+		current = append(current, r)
+	}
+	flush()
+	return parts
+}
+
+// CodeWriter writes a code snippet that would answer a search query. Its
+// embedding usually lands closer to the matching code than the query's own
+// (hypothetical document embeddings, or HyDE).
+type CodeWriter func(ctx context.Context, query string) (string, error)
+
+// hydeTimeout bounds how long a search waits for a hypothetical snippet
+const hydeTimeout = 15 * time.Second
+
+var (
+	codeWriter   CodeWriter
+	codeWriterMu sync.RWMutex
+)
+
+// SetCodeWriter sets how hypothetical snippets are written for queries when
+// embedding.hyde is enabled. nil turns HyDE off.
+func SetCodeWriter(w CodeWriter) {
+	codeWriterMu.Lock()
+	defer codeWriterMu.Unlock()
+	codeWriter = w
+}
+
+// GetQueryEmbedding embeds a search query, first expanding it with
+// ExpandQuery and adding a hypothetical snippet answering it, as enabled
+// in the config
+func GetQueryEmbedding(ctx context.Context, query string) ([]float32, error) {
+	cfg := config.Get()
+	if cfg == nil {
+		return GetEmbedding(ctx, query)
+	}
+
+	text := query
+	if cfg.Embedding.ExpandQueries {
+		text = ExpandQuery(query)
+	}
+
+	codeWriterMu.RLock()
+	write := codeWriter
+	codeWriterMu.RUnlock()
+	if cfg.Embedding.HyDE && write != nil {
+		hydeCtx, cancel := context.WithTimeout(ctx, hydeTimeout)
+		snippet, err := write(hydeCtx, query)
+		cancel()
+		if err != nil {
+			// The expanded query still works without the snippet
+			log.Printf("Failed to write hypothetical code for search: %v", err)
+		} else if snippet = strings.TrimSpace(snippet); snippet != "" {
+			text += "\n" + snippet
+		}
+	}
+
+	return GetEmbedding(ctx, text)
+}
@@ -0,0 +1,44 @@
+package embeddings
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitIdentifier(t *testing.T) {
+	tests := map[string][]string{
+		"parseHTTPRequest": {"parse", "http", "request"},
+		"GetUserConfig":    {"get", "user", "config"},
+		"max_file_size":    {"max", "file", "size"},
+		"dry-run":          {"dry", "run"},
+		"base64Encode":     {"base64", "encode"},
+		"plain":            {"plain"},
+	}
+	for input, want := range tests {
+		if got := splitIdentifier(input); !reflect.DeepEqual(got, want) {
+			t.Errorf("splitIdentifier(%q) = %v, want %v", input, got, want)
+		}
+	}
+}
+
+func TestExpandQuery(t *testing.T) {
+	got := ExpandQuery("getUserConfig")
+	for _, term := range []string{"getUserConfig", "get", "user", "config", "settings", "fetch"} {
+		if !strings.Contains(got, term) {
+			t.Errorf("ExpandQuery() = %q, missing %q", got, term)
+		}
+	}
+
+	got = ExpandQuery("delete session")
+	if !strings.HasPrefix(got, "delete session ") || !strings.Contains(got, "remove") {
+		t.Errorf("ExpandQuery() = %q, want the query followed by synonyms", got)
+	}
+	if strings.Count(got, "delete") != 1 {
+		t.Errorf("ExpandQuery() = %q, repeats a term", got)
+	}
+
+	if got := ExpandQuery("widget"); got != "widget" {
+		t.Errorf("ExpandQuery() = %q, want the query unchanged", got)
+	}
+}
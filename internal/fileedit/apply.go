@@ -0,0 +1,355 @@
+package fileedit
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aymanbagabas/go-udiff"
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+	"github.com/entrepeneur4lyf/codeforge/internal/fileutil"
+	"github.com/entrepeneur4lyf/codeforge/internal/llm/tools"
+	"github.com/entrepeneur4lyf/codeforge/internal/logging"
+	"github.com/entrepeneur4lyf/codeforge/internal/policy"
+)
+
+// Change is what edits do to one file of a working tree
+type Change struct {
+	Path    string // Relative to the working tree, slash separated
+	Before  string
+	After   string
+	Existed bool // The file exists; false for files the edits create
+	Delete  bool
+}
+
+// Diff returns the change as a unified diff
+func (c Change) Diff() string {
+	return udiff.Unified("a/"+c.Path, "b/"+c.Path, c.Before, c.After)
+}
+
+// Plan works out what edits do to the files under root, without writing
+// anything. Edits of the same file apply one after the other. Paths outside
+// root, including ones reached through symlinks, are refused. The results
+// are checked against the project's code policies, as the edit tools'
+// are: rules set to fix are applied to After, and a violation that blocks
+// refuses the edits.
+func Plan(root string, edits []Edit) ([]Change, error) {
+	var changes []*Change
+	byPath := make(map[string]*Change)
+	for _, edit := range edits {
+		rel, err := Resolve(root, edit.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		c, ok := byPath[rel]
+		if !ok {
+			c = &Change{Path: rel}
+			data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(rel)))
+			switch {
+			case err == nil:
+				c.Before, c.After, c.Existed = string(data), string(data), true
+			case !errors.Is(err, fs.ErrNotExist):
+				return nil, err
+			}
+			byPath[rel] = c
+			changes = append(changes, c)
+		}
+		if err := c.apply(edit); err != nil {
+			return nil, fmt.Errorf("%s: %w", rel, err)
+		}
+	}
+
+	planned := make([]Change, len(changes))
+	for i, c := range changes {
+		planned[i] = *c
+	}
+	if err := CheckPolicies(root, planned); err != nil {
+		return nil, err
+	}
+	return planned, nil
+}
+
+// CheckPolicies checks changes against the code policies of the project
+// under root, fixing their After where the rules say to. Plan checks the
+// changes it returns; changes made another way, such as a language server's
+// renames, should be checked before they're applied.
+func CheckPolicies(root string, changes []Change) error {
+	var base policy.Policies
+	if cfg := config.Get(); cfg != nil {
+		base = cfg.Policies
+	}
+	engine, err := policy.Load(root, base)
+	if err != nil {
+		return err
+	}
+	if !engine.Enabled() {
+		return nil
+	}
+
+	for i := range changes {
+		c := &changes[i]
+		if c.Delete {
+			continue
+		}
+		result := engine.CheckChange(c.Path, c.Before, c.After)
+		if err := result.Error(); err != nil {
+			return err
+		}
+		for _, v := range result.Violations {
+			if v.Fixed {
+				logging.Info("Policy auto-fix applied", "violation", v.String())
+			} else {
+				logging.Warn("Policy warning", "violation", v.String())
+			}
+		}
+		c.After = result.Content
+	}
+	return nil
+}
+
+// Resolve returns path relative to root, refusing paths outside it. The
+// symlinks in the path are followed, as writing it would, so a link in the
+// tree can't lead outside it either.
+func Resolve(root, path string) (string, error) {
+	rel := filepath.Clean(filepath.FromSlash(path))
+	if filepath.IsAbs(rel) {
+		var err error
+		if rel, err = filepath.Rel(root, rel); err != nil {
+			return "", err
+		}
+	}
+	if rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside %s", path, root)
+	}
+
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	realRoot, err := fileutil.ResolvePath(absRoot)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", root, err)
+	}
+	real, err := fileutil.ResolvePath(filepath.Join(absRoot, rel))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", path, err)
+	}
+	if !fileutil.WithinDir(real, realRoot) || real == realRoot {
+		return "", fmt.Errorf("%s leads outside %s through a symlink", path, root)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+func (c *Change) apply(edit Edit) error {
+	exists := c.Existed && !c.Delete
+	switch {
+	case edit.Delete:
+		if !exists {
+			return fmt.Errorf("can't delete a file that doesn't exist")
+		}
+		c.After, c.Delete = "", true
+		return nil
+	case edit.Create && exists && c.After != "":
+		return fmt.Errorf("the diff creates the file, but it already exists")
+	case !exists && edit.Blocks != "":
+		return fmt.Errorf("file doesn't exist; a SEARCH/REPLACE block can't create it")
+	case !exists && !edit.Create && !insertsOnly(edit.Hunks):
+		return fmt.Errorf("file doesn't exist")
+	}
+	c.Delete = false
+
+	var err error
+	if len(edit.Hunks) > 0 {
+		if c.After, err = applyHunks(c.After, edit.Hunks); err != nil {
+			return err
+		}
+	}
+	if edit.Blocks != "" {
+		if c.After, err = tools.ApplySearchReplace(c.After, edit.Blocks); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertsOnly reports whether hunks only add lines, as a new file's do
+func insertsOnly(hunks []Hunk) bool {
+	for _, h := range hunks {
+		if len(h.Old) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// applyHunks applies hunks to content in order, placing each where its
+// original lines are, nearest the line its header gives
+func applyHunks(content string, hunks []Hunk) (string, error) {
+	newline := "\n"
+	if strings.Contains(content, "\r\n") {
+		newline = "\r\n"
+	}
+	lines := strings.Split(content, newline)
+	// A final newline leaves an empty last element, which isn't a line
+	final := content == "" || lines[len(lines)-1] == ""
+	if final {
+		lines = lines[:len(lines)-1]
+	}
+
+	from, offset := 0, 0 // Where the next hunk may start; lines added less lines removed so far
+	for i, h := range hunks {
+		at, err := locate(lines, h, from, offset)
+		if err != nil {
+			if len(hunks) > 1 {
+				return "", fmt.Errorf("hunk %d of %d: %w", i+1, len(hunks), err)
+			}
+			return "", err
+		}
+		lines = append(lines[:at], append(append([]string{}, h.New...), lines[at+len(h.Old):]...)...)
+		from = at + len(h.New)
+		offset += len(h.New) - len(h.Old)
+	}
+
+	out := strings.Join(lines, newline)
+	if final && len(lines) > 0 {
+		out += newline
+	}
+	return out, nil
+}
+
+// locate returns the line at which a hunk's original lines are, looking
+// from line from on. Exact matches are preferred to ones that differ in
+// trailing whitespace, and of several, the one nearest the line the hunk's
+// header gives, shifted by offset for the hunks before it.
+func locate(lines []string, h Hunk, from, offset int) (int, error) {
+	if len(h.Old) == 0 {
+		// An insertion's header gives the line it goes after
+		return min(max(h.OldStart+offset, from), len(lines)), nil
+	}
+
+	hint := from
+	if h.OldStart > 0 {
+		hint = h.OldStart - 1 + offset
+	}
+	for _, same := range []func(a, b string) bool{
+		func(a, b string) bool { return a == b },
+		func(a, b string) bool { return strings.TrimRight(a, " \t") == strings.TrimRight(b, " \t") },
+	} {
+		best := -1
+		for at := from; at+len(h.Old) <= len(lines); at++ {
+			if matchesAt(lines, h.Old, at, same) && (best < 0 || abs(at-hint) < abs(best-hint)) {
+				best = at
+			}
+		}
+		if best >= 0 {
+			return best, nil
+		}
+	}
+	return 0, fmt.Errorf("the hunk's original lines, starting %q, aren't in the file", h.Old[0])
+}
+
+func matchesAt(lines, want []string, at int, same func(a, b string) bool) bool {
+	for i, line := range want {
+		if !same(lines[at+i], line) {
+			return false
+		}
+	}
+	return true
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// Apply writes changes to the files under root. The files they replace or
+// delete are first copied to a new directory in backupDir, which is
+// returned ("" when no file existed). A file that changed since the changes
+// were planned stops everything before anything is written, and a write
+// that fails restores the files written before it.
+func Apply(root string, changes []Change, backupDir string) (string, error) {
+	for _, c := range changes {
+		// Links may have changed since the changes were planned
+		if _, err := Resolve(root, c.Path); err != nil {
+			return "", err
+		}
+		data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(c.Path)))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+		if (err == nil) != c.Existed || string(data) != c.Before {
+			return "", fmt.Errorf("%s changed since the edits were planned; no files were changed", c.Path)
+		}
+	}
+
+	dir, err := backup(root, changes, backupDir)
+	if err != nil {
+		return "", fmt.Errorf("backing up files: %w", err)
+	}
+
+	for i, c := range changes {
+		path := filepath.Join(root, filepath.FromSlash(c.Path))
+		if c.Delete {
+			err = fileutil.RemoveFile(path)
+		} else {
+			err = fileutil.WriteFile(path, []byte(c.After))
+		}
+		if err != nil {
+			if rerr := restore(root, changes[:i]); rerr != nil {
+				return dir, fmt.Errorf("%s: %w; restoring the files written before it failed too: %v", c.Path, err, rerr)
+			}
+			return dir, fmt.Errorf("%s: %w; no files were changed", c.Path, err)
+		}
+	}
+	return dir, nil
+}
+
+// backup copies the files changes replace or delete to a new directory in
+// backupDir, keeping their paths
+func backup(root string, changes []Change, backupDir string) (string, error) {
+	dir := ""
+	for _, c := range changes {
+		if !c.Existed {
+			continue
+		}
+		if dir == "" {
+			if err := os.MkdirAll(backupDir, 0o755); err != nil {
+				return "", err
+			}
+			var err error
+			if dir, err = os.MkdirTemp(backupDir, time.Now().Format("20060102-150405-")); err != nil {
+				return "", err
+			}
+		}
+
+		path := filepath.Join(dir, filepath.FromSlash(c.Path))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return dir, err
+		}
+		if err := os.WriteFile(path, []byte(c.Before), 0o644); err != nil {
+			return dir, err
+		}
+	}
+	return dir, nil
+}
+
+// restore undoes changes already written
+func restore(root string, changes []Change) error {
+	var errs []error
+	for _, c := range changes {
+		path := filepath.Join(root, filepath.FromSlash(c.Path))
+		if c.Existed {
+			errs = append(errs, fileutil.WriteFile(path, []byte(c.Before)))
+		} else {
+			errs = append(errs, fileutil.RemoveFile(path))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,204 @@
+// Package fileedit finds the file edits in model output, as unified diffs or
+// SEARCH/REPLACE blocks, and applies them to a working tree: every file or
+// none, with the files they replace backed up first
+package fileedit
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Edit is the change model output asks for in one file
+type Edit struct {
+	Path   string // As the output names it, relative to the working tree
+	Hunks  []Hunk // Unified diff hunks, in order
+	Blocks string // SEARCH/REPLACE blocks, as written
+	Create bool   // The diff is from /dev/null
+	Delete bool   // The diff is to /dev/null
+}
+
+// Hunk is one hunk of a unified diff
+type Hunk struct {
+	OldStart int      // Line of the original the hunk starts at, from 1; 0 when not given
+	Old      []string // Context and removed lines
+	New      []string // Context and added lines
+}
+
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// Extract returns the edits in model output, in order. Diffs are recognized
+// by their ---/+++ file headers, inside code fences or not, and
+// SEARCH/REPLACE blocks by their markers, with the file's path on a line of
+// its own before them.
+func Extract(text string) ([]Edit, error) {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+
+	var edits []Edit
+	blockPath := "" // File of the last SEARCH/REPLACE block
+	for i := 0; i < len(lines); i++ {
+		switch {
+		case isFileHeader(lines, i):
+			edit, next, err := parseFileDiff(lines, i)
+			if err != nil {
+				return nil, err
+			}
+			edits = append(edits, edit)
+			i = next - 1
+		case isMarker(lines[i], '<', "SEARCH"):
+			path := pathBefore(lines, i, blockPath)
+			if path == "" {
+				return nil, fmt.Errorf("line %d: SEARCH/REPLACE block without its file's path on a line before it", i+1)
+			}
+			end := i + 1
+			for end < len(lines) && !isMarker(lines[end], '>', "REPLACE") {
+				end++
+			}
+			if end == len(lines) {
+				return nil, fmt.Errorf("line %d: SEARCH/REPLACE block for %s isn't closed with >>>>>>> REPLACE", i+1, path)
+			}
+
+			block := strings.Join(lines[i:end+1], "\n")
+			if n := len(edits); n > 0 && edits[n-1].Path == path && edits[n-1].Blocks != "" {
+				edits[n-1].Blocks += "\n" + block
+			} else {
+				edits = append(edits, Edit{Path: path, Blocks: block})
+			}
+			blockPath = path
+			i = end
+		}
+	}
+	return edits, nil
+}
+
+// isFileHeader reports whether lines i and i+1 are the ---/+++ header of a
+// file's diff
+func isFileHeader(lines []string, i int) bool {
+	return strings.HasPrefix(lines[i], "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ ")
+}
+
+// parseFileDiff reads the diff of one file starting at its header, returning
+// it and the line after it
+func parseFileDiff(lines []string, i int) (Edit, int, error) {
+	from, to := diffPath(lines[i]), diffPath(lines[i+1])
+	edit := Edit{Path: to, Create: from == "", Delete: to == ""}
+	if edit.Delete {
+		edit.Path = from
+	}
+	if edit.Path == "" {
+		return Edit{}, 0, fmt.Errorf("line %d: diff from /dev/null to /dev/null", i+1)
+	}
+
+	j := i + 2
+	for j < len(lines) && strings.HasPrefix(lines[j], "@@") {
+		var hunk Hunk
+		hunk, j = parseHunk(lines, j)
+		edit.Hunks = append(edit.Hunks, hunk)
+	}
+	if len(edit.Hunks) == 0 && !edit.Delete {
+		return Edit{}, 0, fmt.Errorf("line %d: diff of %s has no hunks", i+1, edit.Path)
+	}
+	return edit, j, nil
+}
+
+// diffPath returns the path of a ---/+++ header line without its a/ or b/
+// prefix and timestamp, or "" for /dev/null
+func diffPath(line string) string {
+	path, _, _ := strings.Cut(line[4:], "\t")
+	path = strings.TrimSpace(path)
+	if path == "/dev/null" {
+		return ""
+	}
+	if strings.HasPrefix(path, "a/") || strings.HasPrefix(path, "b/") {
+		path = path[2:]
+	}
+	return path
+}
+
+// parseHunk reads the hunk whose @@ header is at line i, returning it and the
+// line after it. Models often write bare "@@ @@" headers and drop the space
+// of blank context lines, so without counts a hunk runs to the first line
+// that isn't part of a diff.
+func parseHunk(lines []string, i int) (Hunk, int) {
+	var hunk Hunk
+	oldCount, newCount := -1, -1
+	if m := hunkHeader.FindStringSubmatch(lines[i]); m != nil {
+		hunk.OldStart, _ = strconv.Atoi(m[1])
+		oldCount, newCount = count(m[2]), count(m[4])
+	}
+
+	j := i + 1
+	for ; j < len(lines); j++ {
+		if oldCount >= 0 && len(hunk.Old) >= oldCount && len(hunk.New) >= newCount {
+			break
+		}
+		line := lines[j]
+		if line == "" {
+			// A blank context line, unless the hunk ends here
+			if oldCount < 0 && (j+1 == len(lines) || !isDiffLine(lines[j+1])) {
+				break
+			}
+			hunk.Old = append(hunk.Old, "")
+			hunk.New = append(hunk.New, "")
+			continue
+		}
+		if !isDiffLine(line) || isFileHeader(lines, j) {
+			break
+		}
+		switch line[0] {
+		case ' ':
+			hunk.Old = append(hunk.Old, line[1:])
+			hunk.New = append(hunk.New, line[1:])
+		case '-':
+			hunk.Old = append(hunk.Old, line[1:])
+		case '+':
+			hunk.New = append(hunk.New, line[1:])
+		}
+	}
+	return hunk, j
+}
+
+// count parses a hunk header's line count, which is 1 when left out
+func count(s string) int {
+	if s == "" {
+		return 1
+	}
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// isDiffLine reports whether line is a context, removed or added line, or a
+// "\ No newline at end of file" note
+func isDiffLine(line string) bool {
+	return line != "" && strings.ContainsRune(" -+\\", rune(line[0]))
+}
+
+// isMarker reports whether line is a run of at least five of char followed by
+// word, as in "<<<<<<< SEARCH"
+func isMarker(line string, char byte, word string) bool {
+	line = strings.TrimSpace(line)
+	rest := strings.TrimLeft(line, string(char))
+	return len(line)-len(rest) >= 5 && strings.TrimSpace(rest) == word
+}
+
+// pathBefore returns the file path written on its own line before the
+// SEARCH marker at line i, skipping blank lines and code fences. Blocks that
+// follow another directly are for the same file as it, last.
+func pathBefore(lines []string, i int, last string) string {
+	for j := i - 1; j >= 0; j-- {
+		line := strings.TrimSpace(lines[j])
+		switch {
+		case line == "" || strings.HasPrefix(line, "```"):
+			continue
+		case isMarker(line, '>', "REPLACE"):
+			return last
+		}
+		path := strings.TrimRight(strings.Trim(line, "`*"), ":")
+		if path == "" || strings.ContainsAny(path, " \t") {
+			return ""
+		}
+		return path
+	}
+	return ""
+}
@@ -0,0 +1,182 @@
+package fileedit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const response = "Rename the greeting and add a helper:\n\n" +
+	"```diff\n" +
+	"--- a/main.go\n" +
+	"+++ b/main.go\n" +
+	"@@ -5,3 +5,3 @@ import \"fmt\"\n" +
+	" func main() {\n" +
+	"-\tfmt.Println(\"hello\")\n" +
+	"+\tfmt.Println(greeting())\n" +
+	" }\n" +
+	"\n" +
+	"--- /dev/null\n" +
+	"+++ b/greet/greet.go\n" +
+	"@@ -0,0 +1,2 @@\n" +
+	"+package greet\n" +
+	"+\n" +
+	"```\n\n" +
+	"And define it:\n\n" +
+	"main.go\n" +
+	"```go\n" +
+	"<<<<<<< SEARCH\n" +
+	"func main() {\n" +
+	"=======\n" +
+	"func greeting() string { return \"hi\" }\n" +
+	"\n" +
+	"func main() {\n" +
+	">>>>>>> REPLACE\n" +
+	"```\n"
+
+const mainGo = "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println(\"hello\")\n}\n"
+
+func TestExtract(t *testing.T) {
+	edits, err := Extract(response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(edits) != 3 {
+		t.Fatalf("got %d edits: %+v", len(edits), edits)
+	}
+	if e := edits[0]; e.Path != "main.go" || len(e.Hunks) != 1 || e.Hunks[0].OldStart != 5 || len(e.Hunks[0].Old) != 3 || len(e.Hunks[0].New) != 3 {
+		t.Errorf("diff edit = %+v", e)
+	}
+	if e := edits[1]; e.Path != "greet/greet.go" || !e.Create || len(e.Hunks[0].New) != 2 {
+		t.Errorf("new file edit = %+v", e)
+	}
+	if e := edits[2]; e.Path != "main.go" || !strings.HasPrefix(e.Blocks, "<<<<<<< SEARCH") {
+		t.Errorf("SEARCH/REPLACE edit = %+v", e)
+	}
+
+	if _, err := Extract("<<<<<<< SEARCH\nx\n=======\ny\n>>>>>>> REPLACE\n"); err == nil {
+		t.Error("block without a path was extracted")
+	}
+	if edits, _ := Extract("No edits here.\n--- just a rule"); edits != nil {
+		t.Errorf("prose was extracted: %+v", edits)
+	}
+}
+
+func TestApplyHunks(t *testing.T) {
+	content := "a\nb\nc\nb\nd\n"
+	// The hunk goes where its lines are, nearest the line it gives
+	hunk, _ := parseHunk(strings.Split("@@ -4,2 +4,2 @@\n b\n-d\n+e", "\n"), 0)
+	if got, err := applyHunks(content, []Hunk{hunk}); err != nil || got != "a\nb\nc\nb\ne\n" {
+		t.Errorf("applyHunks = %q, %v", got, err)
+	}
+
+	// A bare header, and a blank context line without its space
+	hunk, _ = parseHunk(strings.Split("@@ @@\n a\n\n-x", "\n"), 0)
+	if len(hunk.Old) != 3 {
+		t.Errorf("bare hunk = %+v", hunk)
+	}
+	if _, err := applyHunks("a\n\ny\n", []Hunk{hunk}); err == nil {
+		t.Error("hunk whose lines aren't in the file was applied")
+	}
+}
+
+func TestPlanAndApply(t *testing.T) {
+	root, backups := t.TempDir(), t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "main.go"), []byte(mainGo), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	edits, err := Extract(response)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := Plan(root, edits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 || changes[0].Path != "main.go" || changes[1].Existed {
+		t.Fatalf("changes = %+v", changes)
+	}
+	if !strings.Contains(changes[0].Diff(), "+\tfmt.Println(greeting())") {
+		t.Errorf("diff = %s", changes[0].Diff())
+	}
+
+	dir, err := Apply(root, changes, backups)
+	if err != nil {
+		t.Fatal(err)
+	}
+	main, _ := os.ReadFile(filepath.Join(root, "main.go"))
+	if want := "package main\n\nimport \"fmt\"\n\nfunc greeting() string { return \"hi\" }\n\nfunc main() {\n\tfmt.Println(greeting())\n}\n"; string(main) != want {
+		t.Errorf("main.go = %q", main)
+	}
+	if greet, _ := os.ReadFile(filepath.Join(root, "greet", "greet.go")); string(greet) != "package greet\n\n" {
+		t.Errorf("greet.go = %q", greet)
+	}
+	if original, _ := os.ReadFile(filepath.Join(dir, "main.go")); string(original) != mainGo {
+		t.Errorf("backup = %q", original)
+	}
+
+	// The same changes are stale now
+	if _, err := Apply(root, changes, backups); err == nil {
+		t.Error("stale changes were applied")
+	}
+	if _, err := Plan(root, []Edit{{Path: "../outside.go", Blocks: "x"}}); err == nil {
+		t.Error("path outside the root was planned")
+	}
+}
+
+func TestPlanRefusesSymlinksOutside(t *testing.T) {
+	root, outside := t.TempDir(), t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "target.go"), []byte("package target\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "target.go"), filepath.Join(root, "link.go")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "dir")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{"link.go", "dir/new.go"} {
+		edit := Edit{Path: path, Create: true, Hunks: []Hunk{{New: []string{"package x"}}}}
+		if _, err := Plan(root, []Edit{edit}); err == nil {
+			t.Errorf("%s was planned through a symlink leading outside the root", path)
+		}
+	}
+	changes := []Change{{Path: "dir/new.go", After: "package x\n"}}
+	if _, err := Apply(root, changes, t.TempDir()); err == nil {
+		t.Error("a change through a symlink leading outside the root was applied")
+	}
+	if _, err := os.Stat(filepath.Join(outside, "new.go")); err == nil {
+		t.Error("a file was written outside the root")
+	}
+}
+
+func TestPlanChecksPolicies(t *testing.T) {
+	root := t.TempDir()
+	policies := `{"bannedAPIs": [
+		{"pattern": "\\beval\\(", "message": "eval is not allowed"},
+		{"pattern": "ioutil\\.ReadFile", "replacement": "os.ReadFile", "action": "fix"}
+	]}`
+	if err := os.MkdirAll(filepath.Join(root, ".codeforge"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, ".codeforge", "policies.json"), []byte(policies), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	create := func(path string, lines ...string) Edit {
+		return Edit{Path: path, Create: true, Hunks: []Hunk{{New: lines}}}
+	}
+	if _, err := Plan(root, []Edit{create("app.js", "eval(input)")}); err == nil || !strings.Contains(err.Error(), "eval is not allowed") {
+		t.Errorf("banned API was planned: %v", err)
+	}
+	changes, err := Plan(root, []Edit{create("main.go", "data, _ := ioutil.ReadFile(p)")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(changes[0].After, "os.ReadFile(p)") {
+		t.Errorf("policy fix wasn't applied: %q", changes[0].After)
+	}
+}
@@ -0,0 +1,155 @@
+package fileutil
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"unicode/utf8"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+)
+
+// DefaultMaxFileSize is the size limit used when files.maxFileSize isn't set
+const DefaultMaxFileSize int64 = 1024 * 1024
+
+// sniffLen is how much of a file is inspected to decide whether it is binary
+const sniffLen = 8000
+
+// ErrBinaryFile is returned when reading a file whose content isn't text
+var ErrBinaryFile = errors.New("binary file")
+
+// MaxFileSize returns the largest file indexed, searched or attached in
+// full. Larger files are skipped or truncated.
+func MaxFileSize() int64 {
+	if cfg := config.Get(); cfg != nil && cfg.Files.MaxFileSize > 0 {
+		return cfg.Files.MaxFileSize
+	}
+	return DefaultMaxFileSize
+}
+
+// OutlineLargeAttachments reports whether attachments over MaxFileSize are
+// sent as an outline the model reads with read_file, rather than truncated
+func OutlineLargeAttachments() bool {
+	cfg := config.Get()
+	return cfg != nil && cfg.Files.LargeAttachments == "outline"
+}
+
+// IsBinary reports whether data looks like binary content. Like git, it
+// treats a NUL byte in the first 8000 bytes as binary; content that isn't
+// valid UTF-8 and is mostly control characters is binary too.
+func IsBinary(data []byte) bool {
+	if len(data) > sniffLen {
+		data = data[:sniffLen]
+	}
+	if bytes.IndexByte(data, 0) >= 0 {
+		return true
+	}
+
+	// Allow a rune cut off by the sniff window
+	trimmed := data
+	for i := 0; i < utf8.UTFMax-1 && len(trimmed) > 0 && !utf8.Valid(trimmed); i++ {
+		trimmed = trimmed[:len(trimmed)-1]
+	}
+	if utf8.Valid(trimmed) {
+		return false
+	}
+
+	control := 0
+	for _, b := range data {
+		if b < 0x20 && b != '\t' && b != '\n' && b != '\r' && b != '\f' && b != '\b' {
+			control++
+		}
+	}
+	return control*10 > len(data)
+}
+
+// IsBinaryFile reports whether the file at path is binary. Unreadable files
+// are reported as not binary so callers surface the read error instead.
+func IsBinaryFile(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	buf := make([]byte, sniffLen)
+	n, _ := io.ReadFull(f, buf)
+	return IsBinary(buf[:n])
+}
+
+// TextFile is the content of a text file, possibly cut short
+type TextFile struct {
+	Content   string
+	Size      int64 // Size of the whole file
+	Truncated bool  // Content stops before the end of the file
+}
+
+// ReadText reads a text file, keeping at most limit bytes (no limit when
+// limit <= 0). Truncation happens at a line boundary where possible. Binary
+// files return ErrBinaryFile.
+func ReadText(path string, limit int64) (TextFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return TextFile{}, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return TextFile{}, err
+	}
+
+	var r io.Reader = f
+	if limit > 0 {
+		r = io.LimitReader(f, limit)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return TextFile{}, err
+	}
+	return ToText(data, info.Size())
+}
+
+// ToText wraps the leading bytes of a file of the given size. When data is
+// shorter than the file it is cut back to the last full line. Binary content
+// returns ErrBinaryFile.
+func ToText(data []byte, size int64) (TextFile, error) {
+	if IsBinary(data) {
+		return TextFile{Size: size}, ErrBinaryFile
+	}
+
+	file := TextFile{Size: size}
+	if size > int64(len(data)) {
+		file.Truncated = true
+		if i := bytes.LastIndexByte(data, '\n'); i > 0 {
+			data = data[:i+1]
+		}
+	}
+	file.Content = string(data)
+	return file, nil
+}
+
+// TruncationNote describes how much of a truncated file was kept, or ""
+// when the file is complete
+func (t TextFile) TruncationNote() string {
+	if !t.Truncated {
+		return ""
+	}
+	return fmt.Sprintf("[truncated: showing the first %s of %s]", FormatSize(int64(len(t.Content))), FormatSize(t.Size))
+}
+
+// FormatSize formats a byte count for display
+func FormatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,64 @@
+package fileutil
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIsBinary(t *testing.T) {
+	testCases := []struct {
+		name     string
+		data     []byte
+		expected bool
+	}{
+		{"empty", nil, false},
+		{"source", []byte("package main\n\nfunc main() {}\n"), false},
+		{"utf8", []byte("héllo wörld — ✓\n"), false},
+		{"nul byte", []byte("PK\x03\x04\x00\x00"), true},
+		{"latin1 text", []byte("caf\xe9 cr\xe8me\n"), false},
+		{"control bytes", []byte{0xff, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07}, true},
+		{"cut rune", append([]byte(strings.Repeat("a", 10)), 0xe2, 0x9c), false},
+	}
+
+	for _, tc := range testCases {
+		if result := IsBinary(tc.data); result != tc.expected {
+			t.Errorf("%s: IsBinary = %v, want %v", tc.name, result, tc.expected)
+		}
+	}
+}
+
+func TestReadText(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "big.txt")
+	content := strings.Repeat("0123456789\n", 10)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := ReadText(path, 0)
+	if err != nil || file.Truncated || file.Content != content {
+		t.Fatalf("Expected full content, got truncated=%v err=%v", file.Truncated, err)
+	}
+
+	file, err = ReadText(path, 25)
+	if err != nil {
+		t.Fatalf("ReadText failed: %v", err)
+	}
+	if !file.Truncated || file.Content != "0123456789\n0123456789\n" || file.Size != int64(len(content)) {
+		t.Errorf("Expected two whole lines of a truncated file, got %q (size %d)", file.Content, file.Size)
+	}
+	if note := file.TruncationNote(); !strings.Contains(note, "22 B of 110 B") {
+		t.Errorf("Unexpected truncation note %q", note)
+	}
+
+	binPath := filepath.Join(dir, "image.dat")
+	if err := os.WriteFile(binPath, []byte{0x89, 'P', 'N', 'G', 0x00, 0x1a}, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadText(binPath, 0); !errors.Is(err, ErrBinaryFile) {
+		t.Errorf("Expected ErrBinaryFile, got %v", err)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/bmatcuk/doublestar/v4"
+	"github.com/entrepeneur4lyf/codeforge/internal/ignore"
 	"github.com/entrepeneur4lyf/codeforge/internal/logging"
 )
 
@@ -80,36 +81,8 @@ func SkipHidden(path string) bool {
 		return true
 	}
 
-	commonIgnoredDirs := map[string]bool{
-		".opencode":        true,
-		"node_modules":     true,
-		"vendor":           true,
-		"dist":             true,
-		"build":            true,
-		"target":           true,
-		".git":             true,
-		".idea":            true,
-		".vscode":          true,
-		"__pycache__":      true,
-		"bin":              true,
-		"obj":              true,
-		"out":              true,
-		"coverage":         true,
-		"tmp":              true,
-		"temp":             true,
-		"logs":             true,
-		"generated":        true,
-		"bower_components": true,
-		"jspm_packages":    true,
-	}
-
-	parts := strings.Split(path, string(os.PathSeparator))
-	for _, part := range parts {
-		if commonIgnoredDirs[part] {
-			return true
-		}
-	}
-	return false
+	// Skip anything under a shared exclude such as node_modules
+	return ignore.Default().Match(path)
 }
 
 func GlobWithDoublestar(pattern, searchPath string, limit int) ([]string, bool, error) {
@@ -131,7 +104,7 @@ func GlobWithDoublestar(pattern, searchPath string, limit int) ([]string, bool,
 		absPath := path
 		if !strings.HasPrefix(absPath, searchPath) && searchPath != "." {
 			absPath = filepath.Join(searchPath, absPath)
-		} else if !strings.HasPrefix(absPath, "/") && searchPath == "." {
+		} else if !filepath.IsAbs(absPath) && searchPath == "." {
 			absPath = filepath.Join(searchPath, absPath) // Ensure relative paths are joined correctly
 		}
 
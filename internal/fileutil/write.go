@@ -0,0 +1,131 @@
+package fileutil
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// defaultFileMode is the mode of files created by WriteFile
+const defaultFileMode os.FileMode = 0o644
+
+// ErrReadOnly is returned when writing a file without write permission
+var ErrReadOnly = errors.New("file is read-only")
+
+// WriteEvent describes a file changed by WriteFile or RemoveFile
+type WriteEvent struct {
+	Path      string
+	Operation string // "create", "update" or "delete"
+	Size      int64
+	Mode      os.FileMode
+}
+
+// WriteObserver is told about every completed write, for auditing
+type WriteObserver func(WriteEvent)
+
+var (
+	writeObserver   WriteObserver
+	writeObserverMu sync.RWMutex
+)
+
+// SetWriteObserver sets the observer told about file writes. nil removes it.
+func SetWriteObserver(o WriteObserver) {
+	writeObserverMu.Lock()
+	defer writeObserverMu.Unlock()
+	writeObserver = o
+}
+
+func notifyWrite(event WriteEvent) {
+	writeObserverMu.RLock()
+	observe := writeObserver
+	writeObserverMu.RUnlock()
+	if observe != nil {
+		observe(event)
+	}
+}
+
+// WriteFile replaces the content of path atomically: data goes to a temporary
+// file in the same directory, is synced to disk, and is renamed over the
+// original, so readers and crashes never see a half-written file. An existing
+// file keeps its mode bits, and a read-only one is refused with ErrReadOnly
+// rather than silently replaced. Writing through a symlink replaces the
+// link's target. Missing parent directories are created.
+func WriteFile(path string, data []byte) error {
+	if target, err := filepath.EvalSymlinks(path); err == nil {
+		path = target
+	}
+
+	operation := "create"
+	mode := defaultFileMode
+	info, err := os.Stat(path)
+	switch {
+	case err == nil:
+		if info.IsDir() {
+			return fmt.Errorf("%s is a directory", path)
+		}
+		if info.Mode().Perm()&0o200 == 0 {
+			return fmt.Errorf("%w: %s", ErrReadOnly, path)
+		}
+		operation = "update"
+		mode = info.Mode().Perm()
+	case !os.IsNotExist(err):
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // No-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return err
+	}
+	syncDir(dir)
+
+	notifyWrite(WriteEvent{Path: path, Operation: operation, Size: int64(len(data)), Mode: mode})
+	return nil
+}
+
+// RemoveFile deletes path and reports it to the write observer
+func RemoveFile(path string) error {
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	syncDir(filepath.Dir(path))
+
+	notifyWrite(WriteEvent{Path: path, Operation: "delete"})
+	return nil
+}
+
+// syncDir makes a rename or removal in dir durable. Not every platform can
+// sync a directory, so failures are ignored.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
@@ -0,0 +1,87 @@
+package fileutil
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+
+	var got []WriteEvent
+	SetWriteObserver(func(e WriteEvent) { got = append(got, e) })
+	defer SetWriteObserver(nil)
+
+	// New files are created with their parent directories
+	path := filepath.Join(dir, "sub", "run.sh")
+	if err := WriteFile(path, []byte("echo one\n")); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	// An existing file keeps its mode
+	if err := os.Chmod(path, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFile(path, []byte("echo two\n")); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o755 {
+		t.Errorf("mode = %v, want 0755", info.Mode().Perm())
+	}
+	if data, _ := os.ReadFile(path); string(data) != "echo two\n" {
+		t.Errorf("content = %q", data)
+	}
+
+	// Writing through a link replaces its target and keeps the link
+	link := filepath.Join(dir, "link.sh")
+	if err := os.Symlink(path, link); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFile(link, []byte("echo three\n")); err != nil {
+		t.Fatalf("write through link: %v", err)
+	}
+	if target, err := os.Readlink(link); err != nil || target != path {
+		t.Errorf("link replaced: %q, %v", target, err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "echo three\n" {
+		t.Errorf("target content = %q", data)
+	}
+
+	// Read-only files are refused
+	readOnly := filepath.Join(dir, "locked.txt")
+	if err := os.WriteFile(readOnly, []byte("keep"), 0o444); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFile(readOnly, []byte("changed")); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("read-only write: got %v, want ErrReadOnly", err)
+	}
+
+	if err := RemoveFile(path); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+
+	// No temporary files are left behind
+	entries, err := os.ReadDir(filepath.Join(dir, "sub"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("leftover files: %v", entries)
+	}
+
+	wantOps := []string{"create", "update", "update", "delete"}
+	if len(got) != len(wantOps) {
+		t.Fatalf("events = %+v, want operations %v", got, wantOps)
+	}
+	for i, op := range wantOps {
+		if got[i].Operation != op || got[i].Path != path {
+			t.Errorf("event %d = %+v, want %s of %s", i, got[i], op, path)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+// Package followup suggests prompts to send next after an answer, to speed
+// up iterative sessions such as debugging
+package followup
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+)
+
+// Max is the most suggestions returned
+const Max = 3
+
+// maxAnswerChars caps the answer sent for suggestions; its end is kept, as
+// that's where the next step usually is
+const maxAnswerChars = 8000
+
+const suggestPrompt = `You suggest what a developer might ask a coding assistant next. Given their last question and the assistant's answer, write 2 or 3 short follow-up prompts, each one line, written as the developer would type them: the next debugging step, a check of the answer, or a deeper look at what was found. Make each one different and specific to the answer. Reply with one prompt per line, without numbering or anything else.`
+
+// Suggest asks the model behind handler for follow-up prompts to an
+// exchange
+func Suggest(ctx context.Context, handler llm.ApiHandler, question, answer string) ([]string, error) {
+	if strings.TrimSpace(answer) == "" {
+		return nil, nil
+	}
+	if len(answer) > maxAnswerChars {
+		answer = "..." + answer[len(answer)-maxAnswerChars:]
+	}
+
+	messages := []llm.Message{{
+		Role: "user",
+		Content: []llm.ContentBlock{llm.TextBlock{
+			Text: fmt.Sprintf("Question:\n%s\n\nAnswer:\n%s", question, answer),
+		}},
+	}}
+	stream, err := handler.CreateMessage(ctx, suggestPrompt, messages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to suggest follow-ups: %w", err)
+	}
+
+	var reply strings.Builder
+	for chunk := range stream {
+		if text, ok := chunk.(llm.ApiStreamTextChunk); ok {
+			reply.WriteString(text.Text)
+		}
+	}
+	return parse(reply.String()), nil
+}
+
+// listMarker matches a bullet or number starting a line
+var listMarker = regexp.MustCompile(`^(?:[-*•]|\d+[.)])\s+`)
+
+// parse reads one prompt per line, dropping list markers, quotes and
+// blank lines
+func parse(reply string) []string {
+	var prompts []string
+	for _, line := range strings.Split(reply, "\n") {
+		line = listMarker.ReplaceAllString(strings.TrimSpace(line), "")
+		line = strings.Trim(strings.TrimSpace(line), `"`)
+		if line == "" || strings.HasPrefix(line, "```") {
+			continue
+		}
+		prompts = append(prompts, line)
+		if len(prompts) == Max {
+			break
+		}
+	}
+	return prompts
+}
+
+// Pick returns the suggestion input selects by its 1-based number, such
+// as "2", reporting false when input isn't a suggestion's number
+func Pick(suggestions []string, input string) (string, bool) {
+	input = strings.TrimSpace(input)
+	if len(input) != 1 || input[0] < '1' || int(input[0]-'0') > len(suggestions) {
+		return "", false
+	}
+	return suggestions[input[0]-'1'], true
+}
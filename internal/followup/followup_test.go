@@ -0,0 +1,68 @@
+package followup
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+)
+
+// replyHandler answers every request with a fixed reply
+type replyHandler struct {
+	reply string
+	sent  string
+}
+
+func (h *replyHandler) CreateMessage(_ context.Context, _ string, messages []llm.Message) (llm.ApiStream, error) {
+	h.sent = messages[0].Content[0].(llm.TextBlock).Text
+	ch := make(chan llm.ApiStreamChunk, 1)
+	ch <- llm.ApiStreamTextChunk{Text: h.reply}
+	close(ch)
+	return ch, nil
+}
+
+func (h *replyHandler) GetModel() llm.ModelResponse {
+	return llm.ModelResponse{ID: "test/model"}
+}
+
+func (h *replyHandler) GetApiStreamUsage() (*llm.ApiStreamUsageChunk, error) {
+	return nil, nil
+}
+
+func TestSuggest(t *testing.T) {
+	h := &replyHandler{reply: "1. Add a log line before the retry\n\n- \"Run the test with -race\"\n3) 404 errors too?\nShow the caller\nOne too many"}
+	got, err := Suggest(context.Background(), h, "Why does the upload hang?", "The retry loop never exits.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"Add a log line before the retry", "Run the test with -race", "404 errors too?"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Suggest = %q, want %q", got, want)
+	}
+	if !strings.Contains(h.sent, "Why does the upload hang?") || !strings.Contains(h.sent, "The retry loop never exits.") {
+		t.Errorf("exchange not sent: %q", h.sent)
+	}
+
+	if got, _ := Suggest(context.Background(), h, "question", " "); got != nil {
+		t.Errorf("empty answer got suggestions %q", got)
+	}
+}
+
+func TestPick(t *testing.T) {
+	suggestions := []string{"first", "second"}
+	for input, want := range map[string]string{"1": "first", " 2\n": "second"} {
+		if got, ok := Pick(suggestions, input); !ok || got != want {
+			t.Errorf("Pick(%q) = %q, %v", input, got, ok)
+		}
+	}
+	for _, input := range []string{"0", "3", "12", "1 more", "a"} {
+		if _, ok := Pick(suggestions, input); ok {
+			t.Errorf("Pick(%q) picked a suggestion", input)
+		}
+	}
+	if _, ok := Pick(nil, "1"); ok {
+		t.Error("picked from no suggestions")
+	}
+}
@@ -0,0 +1,100 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+)
+
+// run runs git in the working directory with input on stdin, returning
+// its output; a failure's error carries what git printed to stderr
+func (r *Repository) run(ctx context.Context, input string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = r.workingDir
+	if input != "" {
+		cmd.Stdin = strings.NewReader(input)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s failed: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s failed: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
+
+// Stage adds files to the index, or every change when none are given
+func (r *Repository) Stage(ctx context.Context, paths ...string) error {
+	if !r.IsGitRepository() {
+		return fmt.Errorf("not a git repository")
+	}
+	args := []string{"add", "--all"}
+	if len(paths) > 0 {
+		args = append(args, "--")
+		args = append(args, paths...)
+	}
+	_, err := r.run(ctx, "", args...)
+	return err
+}
+
+// Unstage removes files from the index, or every staged change when none
+// are given, keeping them as they are in the working tree
+func (r *Repository) Unstage(ctx context.Context, paths ...string) error {
+	if !r.IsGitRepository() {
+		return fmt.Errorf("not a git repository")
+	}
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	// rm --cached also works before the first commit, where there's no
+	// HEAD to restore from
+	args := []string{"reset", "--quiet", "--"}
+	if _, err := r.run(ctx, "", "rev-parse", "--verify", "--quiet", "HEAD"); err != nil {
+		args = []string{"rm", "--cached", "--quiet", "-r", "--ignore-unmatch", "--"}
+	}
+	_, err := r.run(ctx, "", append(args, paths...)...)
+	return err
+}
+
+// Patch returns the changes in the working tree that aren't staged, or
+// the staged ones, as a unified diff
+func (r *Repository) Patch(ctx context.Context, staged bool) (string, error) {
+	if !r.IsGitRepository() {
+		return "", fmt.Errorf("not a git repository")
+	}
+	if staged {
+		return r.run(ctx, "", "diff", "--cached")
+	}
+	return r.run(ctx, "", "diff")
+}
+
+// Commit commits the staged changes with message and returns the commit
+func (r *Repository) Commit(ctx context.Context, message string) (*GitCommit, error) {
+	if !r.IsGitRepository() {
+		return nil, fmt.Errorf("not a git repository")
+	}
+	if strings.TrimSpace(message) == "" {
+		return nil, fmt.Errorf("commit message required")
+	}
+	if _, err := r.run(ctx, "", "diff", "--cached", "--quiet"); err == nil {
+		return nil, fmt.Errorf("nothing staged to commit")
+	}
+	if _, err := r.run(ctx, message, "commit", "--file=-"); err != nil {
+		return nil, err
+	}
+	return r.getLastCommit(ctx)
+}
+
+// NewCommitMessageGeneratorFor returns a commit message generator using
+// handler's model, such as the one a chat session uses
+func NewCommitMessageGeneratorFor(handler llm.ApiHandler) *CommitMessageGenerator {
+	return &CommitMessageGenerator{handler: handler}
+}
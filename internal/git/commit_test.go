@@ -0,0 +1,85 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStageAndCommit(t *testing.T) {
+	if !IsGitInstalled() {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	if output, err := exec.Command("git", "-C", dir, "init", "--quiet").CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, output)
+	}
+	for name, content := range map[string]string{"a.go": "package a\n", "b.go": "package b\n"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	repo := NewRepository(dir)
+	ctx := context.Background()
+
+	if _, err := repo.Commit(ctx, "feat: nothing"); err == nil {
+		t.Error("Commit with nothing staged succeeded")
+	}
+
+	// Unstaging before the first commit has no HEAD to reset to
+	if err := repo.Stage(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Unstage(ctx, "b.go"); err != nil {
+		t.Fatal(err)
+	}
+	patch, err := repo.Patch(ctx, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(patch, "+package a") || strings.Contains(patch, "b.go") {
+		t.Errorf("staged patch = %q", patch)
+	}
+
+	if _, err := repo.Commit(ctx, " \n"); err == nil {
+		t.Error("Commit without a message succeeded")
+	}
+	commit, err := repo.Commit(ctx, "feat: add a\n\nWith a body.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if commit.Subject != "feat: add a" || commit.ShortHash == "" {
+		t.Errorf("commit = %+v", commit)
+	}
+
+	status, err := repo.GetStatus(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status.Staged) != 0 || len(status.Untracked) != 1 || status.Untracked[0] != "b.go" {
+		t.Errorf("status after commit = %+v", status)
+	}
+
+	if err := repo.Stage(ctx, "b.go"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Unstage(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if patch, err := repo.Patch(ctx, true); err != nil || patch != "" {
+		t.Errorf("staged patch after unstaging = %q, %v", patch, err)
+	}
+
+	// What git prints to stderr stays out of the output
+	t.Setenv("GIT_TRACE", "1")
+	if patch, err := repo.Patch(ctx, false); err != nil || patch != "" {
+		t.Errorf("patch with tracing on = %q, %v", patch, err)
+	}
+}
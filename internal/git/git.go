@@ -361,6 +361,19 @@ func (r *Repository) getFileDiff(ctx context.Context, filePath string, staged bo
 	return string(output), nil
 }
 
+// FileDiff returns the uncommitted changes to a file, staged or not
+func (r *Repository) FileDiff(ctx context.Context, filePath string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", "diff", "HEAD", "--", filePath)
+	cmd.Dir = r.workingDir
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+
+	return string(output), nil
+}
+
 // GetCommitHistory gets the commit history
 func (r *Repository) GetCommitHistory(ctx context.Context, limit int) ([]GitCommit, error) {
 	if !r.IsGitRepository() {
@@ -0,0 +1,36 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// CurrentBranch returns the checked out branch, or "HEAD" when detached
+func (r *Repository) CurrentBranch(ctx context.Context) (string, error) {
+	return r.getCurrentBranch(ctx)
+}
+
+// RemoteURL returns the URL of a remote, such as "origin"
+func (r *Repository) RemoteURL(ctx context.Context, remote string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", "remote", "get-url", remote)
+	cmd.Dir = r.workingDir
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("no %s remote: %w", remote, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// Push pushes a branch to a remote and sets it as the branch's upstream
+func (r *Repository) Push(ctx context.Context, remote, branch string) error {
+	cmd := exec.CommandContext(ctx, "git", "push", "--set-upstream", remote, branch)
+	cmd.Dir = r.workingDir
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git push failed: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
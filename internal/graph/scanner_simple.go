@@ -10,6 +10,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/entrepeneur4lyf/codeforge/internal/fileutil"
+	"github.com/entrepeneur4lyf/codeforge/internal/ignore"
 	"github.com/entrepeneur4lyf/codeforge/internal/utils"
 )
 
@@ -31,7 +33,7 @@ func NewSimpleScanner(graph *CodeGraph) *SimpleScanner {
 	return &SimpleScanner{
 		graph:          graph,
 		fileSet:        token.NewFileSet(),
-		maxFileSize:    10 * 1024 * 1024, // 10MB max file size
+		maxFileSize:    fileutil.MaxFileSize(),
 		includeTests:   true,
 		includeDocs:    true,
 		includeConfigs: true,
@@ -44,7 +46,7 @@ func NewSimpleScannerWithRoot(graph *CodeGraph, rootPath string) *SimpleScanner
 		graph:           graph,
 		fileSet:         token.NewFileSet(),
 		gitignoreFilter: utils.NewGitIgnoreFilter(rootPath),
-		maxFileSize:     10 * 1024 * 1024, // 10MB max file size
+		maxFileSize:     fileutil.MaxFileSize(),
 		includeTests:    true,
 		includeDocs:     true,
 		includeConfigs:  true,
@@ -91,8 +93,8 @@ func (s *SimpleScanner) ScanRepository(rootPath string) error {
 			return nil
 		}
 
-		// Skip large files
-		if !info.IsDir() && info.Size() > s.maxFileSize {
+		// Skip large and binary files
+		if !info.IsDir() && (info.Size() > s.maxFileSize || fileutil.IsBinaryFile(path)) {
 			return nil
 		}
 
@@ -125,28 +127,8 @@ func (s *SimpleScanner) shouldIgnore(path string) bool {
 		}
 	}
 
-	// Fallback to common ignore patterns
-	ignorePatterns := []string{
-		".git", ".svn", ".hg",
-		"node_modules", "vendor", "target",
-		".vscode", ".idea", ".vs",
-		"build", "dist", "out",
-		"__pycache__", ".pytest_cache",
-		"*.pyc", "*.pyo", "*.pyd",
-		"*.class", "*.jar", "*.war",
-		"*.exe", "*.dll", "*.so", "*.dylib",
-		"*.log", "*.tmp", "*.temp",
-	}
-
-	pathLower := strings.ToLower(path)
-
-	for _, pattern := range ignorePatterns {
-		if strings.Contains(pathLower, strings.ToLower(pattern)) {
-			return true
-		}
-	}
-
-	return false
+	// Fallback to the shared exclude patterns
+	return ignore.Default().Match(path)
 }
 
 // scanDirectory processes a directory
@@ -6,11 +6,12 @@ import (
 	"log"
 	"os"
 	"path/filepath"
-	"strings"
 	"sync"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/ignore"
 )
 
 // FileWatcher watches filesystem changes and updates the graph
@@ -59,16 +60,7 @@ func NewFileWatcher(graph *CodeGraph, rootPath string) (*FileWatcher, error) {
 		watchTests:    true,
 		watchDocs:     true,
 		watchConfigs:  true,
-		ignoreRules: []string{
-			".git", ".svn", ".hg",
-			"node_modules", "vendor", "target",
-			".vscode", ".idea", ".vs",
-			"build", "dist", "out",
-			"__pycache__", ".pytest_cache",
-			".codeforge", // Exclude entire .codeforge directory to prevent log file spam
-			"*.log", "*.tmp", "*.temp",
-			"*.exe", "*.dll", "*.so", "*.dylib",
-		},
+		ignoreRules:   ignore.Patterns(),
 	}
 
 	return fw, nil
@@ -368,15 +360,10 @@ func (fw *FileWatcher) addDirectoryRecursive(path string) error {
 
 // shouldIgnore checks if a path should be ignored
 func (fw *FileWatcher) shouldIgnore(path string) bool {
-	pathLower := strings.ToLower(path)
-
-	for _, pattern := range fw.ignoreRules {
-		if strings.Contains(pathLower, strings.ToLower(pattern)) {
-			return true
-		}
+	if path == "." {
+		return false
 	}
-
-	return false
+	return ignore.New(fw.ignoreRules).Match(path)
 }
 
 // shouldIncludeFile determines if a file should be included based on configuration
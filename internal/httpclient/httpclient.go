@@ -0,0 +1,184 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+)
+
+func init() {
+	// The config package can't import this one, so it's handed the client
+	// for its own requests, such as fetching the org config
+	config.SetHTTPClient(New)
+}
+
+// Direct is the per-provider proxy override that bypasses the proxy
+const Direct = "direct"
+
+// Settings are the resolved proxy and TLS settings for one provider
+type Settings struct {
+	ProxyURL string // Proxy for all schemes; "" uses HTTP_PROXY/HTTPS_PROXY
+	NoProxy  string // Hosts that bypass the proxy; "" uses NO_PROXY
+	CABundle string // Extra CA certificates to trust
+	Direct   bool   // Ignore all proxy settings
+}
+
+// Resolve returns the proxy settings that apply to a provider. Per-provider
+// overrides take precedence over the global proxy, which takes precedence
+// over the environment.
+func Resolve(provider string) Settings {
+	s := Settings{CABundle: os.Getenv("CODEFORGE_CA_BUNDLE")}
+
+	cfg := config.Get()
+	if cfg == nil {
+		return s
+	}
+
+	proxy := cfg.Proxy
+	s.ProxyURL = proxy.URL
+	s.NoProxy = proxy.NoProxy
+	if proxy.CABundle != "" {
+		s.CABundle = proxy.CABundle
+	}
+	if override, ok := proxy.Providers[strings.ToLower(provider)]; ok && override != "" {
+		if strings.EqualFold(override, Direct) {
+			s.Direct = true
+			s.ProxyURL = ""
+		} else {
+			s.ProxyURL = override
+		}
+	}
+	return s
+}
+
+var (
+	transportsMu sync.Mutex
+	transports   = make(map[Settings]*http.Transport)
+)
+
+// New returns an HTTP client for a provider that honours the configured
+// proxy, NO_PROXY list and CA bundle. When those settings are invalid, every
+// request the client makes fails with the error rather than going around a
+// proxy the network may require.
+func New(provider string, timeout time.Duration) *http.Client {
+	var rt http.RoundTripper
+	t, err := Transport(provider)
+	if err != nil {
+		log.Printf("Warning: %v; requests for %s will fail", err, provider)
+		rt = failingTransport{err}
+	} else {
+		rt = t
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: rt,
+	}
+}
+
+// Transport returns a shared transport for a provider's settings so
+// connections are pooled between clients, or the error that makes the
+// settings unusable
+func Transport(provider string) (*http.Transport, error) {
+	settings := Resolve(provider)
+
+	transportsMu.Lock()
+	defer transportsMu.Unlock()
+
+	if t, ok := transports[settings]; ok {
+		return t, nil
+	}
+
+	t, err := NewTransport(settings)
+	if err != nil {
+		return nil, err
+	}
+	transports[settings] = t
+	return t, nil
+}
+
+// failingTransport fails every request with the error that kept a
+// transport from being built
+type failingTransport struct {
+	err error
+}
+
+func (f failingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	return nil, f.err
+}
+
+// NewTransport builds a transport from settings
+func NewTransport(settings Settings) (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+
+	proxyFunc, err := ProxyFunc(settings)
+	if err != nil {
+		return nil, err
+	}
+	t.Proxy = proxyFunc
+
+	if settings.CABundle != "" {
+		pool, err := loadCABundle(settings.CABundle)
+		if err != nil {
+			return nil, err
+		}
+		t.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+
+	return t, nil
+}
+
+// ProxyFunc returns the proxy selector for settings. SOCKS5 proxies are
+// supported through the socks5:// scheme.
+func ProxyFunc(settings Settings) (func(*http.Request) (*url.URL, error), error) {
+	if settings.Direct {
+		return nil, nil
+	}
+
+	pc := httpproxy.FromEnvironment()
+	if settings.ProxyURL != "" {
+		if _, err := url.Parse(settings.ProxyURL); err != nil {
+			return nil, fmt.Errorf("invalid proxy URL %q: %w", settings.ProxyURL, err)
+		}
+		pc.HTTPProxy = settings.ProxyURL
+		pc.HTTPSProxy = settings.ProxyURL
+	}
+	if settings.NoProxy != "" {
+		pc.NoProxy = settings.NoProxy
+	}
+
+	selector := pc.ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		return selector(req.URL)
+	}, nil
+}
+
+// loadCABundle returns the system roots plus the certificates in a PEM file
+func loadCABundle(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle %s: %w", path, err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in CA bundle %s", path)
+	}
+	return pool, nil
+}
@@ -0,0 +1,58 @@
+package httpclient
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProxyFunc(t *testing.T) {
+	proxy, err := ProxyFunc(Settings{ProxyURL: "socks5://proxy.corp:1080", NoProxy: "internal.corp"})
+	if err != nil {
+		t.Fatalf("ProxyFunc failed: %v", err)
+	}
+
+	req, _ := http.NewRequest("GET", "https://api.openai.com/v1/models", nil)
+	u, err := proxy(req)
+	if err != nil || u == nil || u.String() != "socks5://proxy.corp:1080" {
+		t.Errorf("Expected SOCKS proxy, got %v (%v)", u, err)
+	}
+
+	req, _ = http.NewRequest("GET", "https://llm.internal.corp/v1/chat", nil)
+	if u, _ := proxy(req); u != nil {
+		t.Errorf("Expected NO_PROXY host to bypass proxy, got %v", u)
+	}
+
+	req, _ = http.NewRequest("GET", "http://localhost:11434/api/tags", nil)
+	if u, _ := proxy(req); u != nil {
+		t.Errorf("Expected localhost to bypass proxy, got %v", u)
+	}
+}
+
+func TestProxyFuncDirect(t *testing.T) {
+	proxy, err := ProxyFunc(Settings{ProxyURL: "http://proxy.corp:3128", Direct: true})
+	if err != nil {
+		t.Fatalf("ProxyFunc failed: %v", err)
+	}
+	if proxy != nil {
+		t.Error("Expected direct settings to disable the proxy")
+	}
+}
+
+func TestNewTransportInvalidCABundle(t *testing.T) {
+	if _, err := NewTransport(Settings{CABundle: "/nonexistent/ca.pem"}); err == nil {
+		t.Error("Expected error for missing CA bundle")
+	}
+}
+
+func TestNewFailsClosed(t *testing.T) {
+	t.Setenv("CODEFORGE_CA_BUNDLE", "/nonexistent/ca.pem")
+
+	if _, err := Transport("openai"); err == nil {
+		t.Error("Expected Transport to fail with a missing CA bundle")
+	}
+	if _, err := New("openai", time.Second).Get("http://127.0.0.1:1/"); err == nil || !strings.Contains(err.Error(), "CA bundle") {
+		t.Errorf("Expected requests to fail with the CA bundle error, got %v", err)
+	}
+}
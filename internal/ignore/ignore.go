@@ -0,0 +1,101 @@
+package ignore
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+)
+
+// Defaults are the paths every subsystem skips: version control metadata,
+// dependency and build output directories, editor state and binaries
+var Defaults = []string{
+	// Version control and CodeForge data
+	".git", ".svn", ".hg", ".codeforge",
+
+	// Dependencies
+	"node_modules", "vendor", "target", "bower_components", "jspm_packages",
+	".venv", "venv",
+
+	// Build outputs and caches
+	"build", "dist", "out", "bin", "obj", "coverage",
+	"__pycache__", ".pytest_cache", ".next",
+
+	// Editor and OS files
+	".idea", ".vscode", ".vs", ".DS_Store", "Thumbs.db", "*.swp", "*~",
+
+	// Compiled artifacts and binaries
+	"*.pyc", "*.pyo", "*.pyd", "*.class", "*.jar", "*.war",
+	"*.exe", "*.dll", "*.so", "*.dylib", "*.bin",
+
+	// Logs, temporary files and secrets
+	"*.log", "*.tmp", "*.temp", ".env",
+}
+
+// Patterns returns the default excludes followed by the project's
+// files.exclude patterns
+func Patterns() []string {
+	patterns := append([]string(nil), Defaults...)
+	if cfg := config.Get(); cfg != nil {
+		patterns = append(patterns, cfg.Files.Exclude...)
+	}
+	return patterns
+}
+
+// Matcher tests paths against a set of exclude globs
+type Matcher struct {
+	patterns []string
+}
+
+// New creates a matcher for patterns
+func New(patterns []string) *Matcher {
+	return &Matcher{patterns: patterns}
+}
+
+// Default returns a matcher for the shared exclude patterns. It reads the
+// configuration on every call so edits apply without a restart.
+func Default() *Matcher {
+	return New(Patterns())
+}
+
+// Patterns returns the globs the matcher tests
+func (m *Matcher) Patterns() []string {
+	return m.patterns
+}
+
+// Match reports whether rel, a path relative to the project root, is
+// excluded. Excluding a directory excludes everything beneath it.
+func (m *Matcher) Match(rel string) bool {
+	rel = filepath.ToSlash(rel)
+	for _, pattern := range m.patterns {
+		if MatchGlob(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchGlob matches a pattern the way ripgrep's -g does. A pattern without a
+// slash matches any path component ("*.go", "node_modules"); one with a
+// slash is anchored at the root (".git/*", "vendor/*"). A match on a
+// directory covers everything beneath it. Patterns may use the host
+// separator.
+func MatchGlob(pattern, rel string) bool {
+	pattern = filepath.ToSlash(pattern)
+	anchored := strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+	pattern = strings.Trim(pattern, "/")
+
+	parts := strings.Split(rel, "/")
+	for i := range parts {
+		candidate := parts[i]
+		if anchored {
+			candidate = strings.Join(parts[:i+1], "/")
+		}
+		if matched, _ := doublestar.Match(pattern, candidate); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package ignore
+
+import (
+	"testing"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+)
+
+func TestMatchGlob(t *testing.T) {
+	testCases := []struct {
+		pattern  string
+		rel      string
+		expected bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "pkg/util/util.go", true},
+		{"*.go", "README.md", false},
+		{".git/*", ".git/config", true},
+		{".git/*", ".git/objects/ab/cdef", true},
+		{".git/*", "src/.git/config", false},
+		{"vendor/*", "vendor/github.com/pkg/errors/errors.go", true},
+		{"vendor/*", "internal/vendor.go", false},
+		{"node_modules", "web/node_modules/react/index.js", true},
+		{"/build", "build/out.bin", true},
+		{"docs/**/*.png", "docs/img/a/logo.png", true},
+	}
+
+	for _, tc := range testCases {
+		if result := MatchGlob(tc.pattern, tc.rel); result != tc.expected {
+			t.Errorf("MatchGlob(%q, %q) = %v, want %v", tc.pattern, tc.rel, result, tc.expected)
+		}
+	}
+}
+
+func TestDefaultMatcherIncludesProjectPatterns(t *testing.T) {
+	if !Default().Match("web/node_modules/react/index.js") {
+		t.Error("Expected node_modules to be excluded by default")
+	}
+	if Default().Match("internal/layout/layout.go") {
+		t.Error("Expected layout.go not to match the out directory default")
+	}
+
+	cfg, err := config.Load(t.TempDir(), false)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	previous := cfg.Files.Exclude
+	cfg.Files.Exclude = []string{"testdata/golden/*"}
+	t.Cleanup(func() { cfg.Files.Exclude = previous })
+
+	if !Default().Match("testdata/golden/big.json") {
+		t.Error("Expected project exclude to apply")
+	}
+	if Default().Match("testdata/small.json") {
+		t.Error("Expected sibling of excluded directory to be kept")
+	}
+}
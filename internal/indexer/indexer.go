@@ -0,0 +1,277 @@
+// Package indexer keeps the code index of a working directory: it chunks
+// source files by language, embeds the chunks and stores them in the vector
+// database, and can watch the directory to reindex files as they change.
+package indexer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/chunking"
+	"github.com/entrepeneur4lyf/codeforge/internal/fileutil"
+	"github.com/entrepeneur4lyf/codeforge/internal/ignore"
+	"github.com/entrepeneur4lyf/codeforge/internal/vectordb"
+)
+
+// languages maps the extensions the chunker understands to their language
+var languages = map[string]string{
+	".go": "go", ".py": "python", ".rs": "rust", ".js": "javascript", ".mjs": "javascript",
+	".jsx": "javascript", ".ts": "typescript", ".tsx": "typescript", ".java": "java",
+	".c": "c", ".h": "c", ".cpp": "cpp", ".cc": "cpp", ".hpp": "cpp", ".php": "php",
+}
+
+// Language returns the language of a source file the chunker understands
+func Language(path string) (string, bool) {
+	language, ok := languages[strings.ToLower(filepath.Ext(path))]
+	return language, ok
+}
+
+// Store is the part of the vector database the indexer uses
+type Store interface {
+	RemoveFile(ctx context.Context, filePath string) error
+	StoreChunk(ctx context.Context, chunk *vectordb.CodeChunk, embedding []float32) error
+	IndexedFile(ctx context.Context, filePath string) (*vectordb.IndexedFile, error)
+	SetIndexedFile(ctx context.Context, file vectordb.IndexedFile) error
+	IndexedFiles(ctx context.Context) ([]string, error)
+	ChunkEmbeddings(ctx context.Context, filePath string) (map[string][]float32, error)
+}
+
+// Embedder returns the embedding of a chunk of code
+type Embedder func(ctx context.Context, code, language string) ([]float32, error)
+
+// Progress is told about each file as it's indexed: how many files are done
+// including this one, out of how many
+type Progress func(done, total int, file string)
+
+// Report counts what a run of the indexer did
+type Report struct {
+	Files         int           `json:"files"`   // Files indexed
+	Skipped       int           `json:"skipped"` // Files unchanged since they were last indexed
+	Removed       int           `json:"removed"` // Deleted files removed from the index
+	Chunks        int           `json:"chunks"`
+	Reused        int           `json:"reused"`                // Chunks unchanged since they were last indexed, which kept their embeddings
+	EmbedFailures int           `json:"embed_failures"`        // Chunks left out because they couldn't be embedded
+	EmbedError    string        `json:"embed_error,omitempty"` // Why the last of them couldn't be
+	Duration      time.Duration `json:"duration"`
+}
+
+// Indexer indexes the source files under a root
+type Indexer struct {
+	Root     string
+	Store    Store
+	Embed    Embedder
+	Force    bool     // Reindex and embed again files and chunks that haven't changed
+	Progress Progress // Optional
+
+	chunker *chunking.CodeChunker
+	matcher *ignore.Matcher
+}
+
+// New returns an indexer of the source files under root
+func New(root string, store Store, embed Embedder) *Indexer {
+	return &Indexer{
+		Root:    root,
+		Store:   store,
+		Embed:   embed,
+		chunker: chunking.NewCodeChunker(chunking.DefaultConfig()),
+		matcher: ignore.Default(),
+	}
+}
+
+// Files returns the source files under the root, relative to it and slash
+// separated, skipping ignored ones
+func (ix *Indexer) Files() ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(ix.Root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(ix.Root, path)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if rel != "." && ix.matcher.Match(rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if _, ok := Language(rel); ok && d.Type().IsRegular() {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// Index indexes the source files under the root and removes the files no
+// longer there from the index. Unless Force is set, files unchanged since
+// they were last indexed are skipped, and chunks unchanged keep their
+// embeddings.
+func (ix *Indexer) Index(ctx context.Context) (*Report, error) {
+	start := time.Now()
+	files, err := ix.Files()
+	if err != nil {
+		return nil, err
+	}
+	report, err := ix.update(ctx, files)
+	if err == nil {
+		err = ix.removeMissing(ctx, files, report)
+	}
+	if report != nil {
+		report.Duration = time.Since(start)
+	}
+	return report, err
+}
+
+// Update reindexes files, relative to the root, such as ones that changed.
+// Deleted files are removed from the index.
+func (ix *Indexer) Update(ctx context.Context, files []string) (*Report, error) {
+	start := time.Now()
+	report, err := ix.update(ctx, files)
+	if report != nil {
+		report.Duration = time.Since(start)
+	}
+	return report, err
+}
+
+func (ix *Indexer) update(ctx context.Context, files []string) (*Report, error) {
+	report := &Report{}
+	for i, rel := range files {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		if ix.Progress != nil {
+			ix.Progress(i+1, len(files), rel)
+		}
+		if err := ix.indexFile(ctx, rel, report); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// removeMissing removes from the index the files that aren't among files,
+// such as ones deleted or excluded since they were indexed
+func (ix *Indexer) removeMissing(ctx context.Context, files []string, report *Report) error {
+	present := make(map[string]bool, len(files))
+	for _, file := range files {
+		present[file] = true
+	}
+	indexed, err := ix.Store.IndexedFiles(ctx)
+	if err != nil {
+		return err
+	}
+	for _, file := range indexed {
+		if present[file] {
+			continue
+		}
+		if err := ix.Store.RemoveFile(ctx, file); err != nil {
+			return err
+		}
+		report.Removed++
+	}
+	return nil
+}
+
+// indexFile replaces the chunks of a file with ones for its current content
+func (ix *Indexer) indexFile(ctx context.Context, rel string, report *Report) error {
+	path := filepath.Join(ix.Root, filepath.FromSlash(rel))
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		if err := ix.Store.RemoveFile(ctx, rel); err != nil {
+			return err
+		}
+		report.Removed++
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var record *vectordb.IndexedFile
+	if !ix.Force {
+		if record, err = ix.Store.IndexedFile(ctx, rel); err != nil {
+			return err
+		}
+		// A file with the size and modification time it was indexed with
+		// is taken to be unchanged without reading it
+		if record != nil && record.Size == info.Size() && record.ModTime.Equal(info.ModTime()) {
+			report.Skipped++
+			return nil
+		}
+	}
+
+	language, _ := Language(rel)
+	file, err := fileutil.ReadText(path, fileutil.MaxFileSize())
+	if err != nil {
+		return nil // Binary and oversized files aren't indexed
+	}
+	indexed := vectordb.IndexedFile{Path: rel, Hash: hashOf(file.Content), ModTime: info.ModTime(), Size: info.Size()}
+	if record != nil && record.Hash == indexed.Hash {
+		// Touched but not changed, as by a checkout
+		report.Skipped++
+		return ix.Store.SetIndexedFile(ctx, indexed)
+	}
+	chunks, err := ix.chunker.ChunkFile(ctx, rel, file.Content, language)
+	if err != nil {
+		return fmt.Errorf("failed to chunk %s: %w", rel, err)
+	}
+
+	var embeddings map[string][]float32
+	if !ix.Force {
+		if embeddings, err = ix.Store.ChunkEmbeddings(ctx, rel); err != nil {
+			return err
+		}
+	}
+	if err := ix.Store.RemoveFile(ctx, rel); err != nil {
+		return err
+	}
+	failures := report.EmbedFailures
+	for _, chunk := range chunks {
+		embedding, reused := embeddings[hashOf(chunk.Content)]
+		if !reused {
+			var err error
+			if embedding, err = ix.Embed(ctx, chunk.Content, language); err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				report.EmbedFailures++
+				report.EmbedError = err.Error()
+				continue
+			}
+		}
+		// The chunker names chunks by file name only, so chunks of files
+		// with the same name in different directories would collide
+		chunk.ID = fmt.Sprintf("%s:%d-%d", rel, chunk.Location.StartLine, chunk.Location.EndLine)
+		chunk.FilePath = rel
+		if err := ix.Store.StoreChunk(ctx, chunk, embedding); err != nil {
+			return err
+		}
+		report.Chunks++
+		if reused {
+			report.Reused++
+		}
+	}
+	report.Files++
+	if report.EmbedFailures > failures {
+		return nil // Not recorded, so it's indexed again next time
+	}
+	return ix.Store.SetIndexedFile(ctx, indexed)
+}
+
+// hashOf returns the SHA-256 of content, hex encoded as the vector database
+// hashes chunks
+func hashOf(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,238 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/vectordb"
+)
+
+// memoryStore keeps chunks by ID, with their embeddings
+type memoryStore struct {
+	mu         sync.Mutex
+	chunks     map[string]*vectordb.CodeChunk
+	embeddings map[string][]float32
+	records    map[string]vectordb.IndexedFile
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		chunks:     map[string]*vectordb.CodeChunk{},
+		embeddings: map[string][]float32{},
+		records:    map[string]vectordb.IndexedFile{},
+	}
+}
+
+func (s *memoryStore) RemoveFile(_ context.Context, filePath string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, chunk := range s.chunks {
+		if chunk.FilePath == filePath {
+			delete(s.chunks, id)
+			delete(s.embeddings, id)
+		}
+	}
+	delete(s.records, filePath)
+	return nil
+}
+
+func (s *memoryStore) StoreChunk(_ context.Context, chunk *vectordb.CodeChunk, embedding []float32) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	chunk.Hash = hashOf(chunk.Content)
+	s.chunks[chunk.ID] = chunk
+	s.embeddings[chunk.ID] = embedding
+	return nil
+}
+
+func (s *memoryStore) IndexedFile(_ context.Context, filePath string) (*vectordb.IndexedFile, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if record, ok := s.records[filePath]; ok {
+		return &record, nil
+	}
+	return nil, nil
+}
+
+func (s *memoryStore) SetIndexedFile(_ context.Context, file vectordb.IndexedFile) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[file.Path] = file
+	return nil
+}
+
+func (s *memoryStore) IndexedFiles(context.Context) ([]string, error) {
+	var files []string
+	for file := range s.files() {
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+func (s *memoryStore) ChunkEmbeddings(_ context.Context, filePath string) (map[string][]float32, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	embeddings := map[string][]float32{}
+	for id, chunk := range s.chunks {
+		if chunk.FilePath == filePath {
+			embeddings[chunk.Hash] = s.embeddings[id]
+		}
+	}
+	return embeddings, nil
+}
+
+func (s *memoryStore) files() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	files := map[string]int{}
+	for _, chunk := range s.chunks {
+		files[chunk.FilePath]++
+	}
+	return files
+}
+
+func embed(_ context.Context, code, _ string) ([]float32, error) {
+	return []float32{float32(len(code))}, nil
+}
+
+const goSource = "package a\n\nfunc One() int {\n\treturn 1\n}\n\nfunc Two() int {\n\treturn 2\n}\n"
+
+func writeFile(t *testing.T, root, rel, content string) {
+	t.Helper()
+	path := filepath.Join(root, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIndex(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "a/util.go", goSource)
+	writeFile(t, root, "b/util.go", goSource)
+	writeFile(t, root, "README.md", "# Not code\n")
+	writeFile(t, root, "node_modules/x/index.js", "function x() {}\n")
+
+	store := newMemoryStore()
+	ix := New(root, store, embed)
+	var seen []string
+	ix.Progress = func(done, total int, file string) { seen = append(seen, file) }
+
+	report, err := ix.Index(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := store.files()
+	if report.Files != 2 || len(seen) != 2 || files["a/util.go"] == 0 || files["a/util.go"] != files["b/util.go"] {
+		t.Fatalf("report %+v, progress %v, indexed %v", report, seen, files)
+	}
+
+	// Unchanged files are skipped, unless forced
+	if report, _ := ix.Index(context.Background()); report.Skipped != 2 || report.Files != 0 {
+		t.Errorf("second run = %+v", report)
+	}
+	ix.Force = true
+	if report, _ := ix.Index(context.Background()); report.Files != 2 {
+		t.Errorf("forced run = %+v", report)
+	}
+
+	// Deleted files leave the index
+	os.Remove(filepath.Join(root, "b", "util.go"))
+	if report, _ := ix.Update(context.Background(), []string{"b/util.go"}); report.Removed != 1 || store.files()["b/util.go"] != 0 {
+		t.Errorf("update = %+v, indexed %v", report, store.files())
+	}
+
+	// Chunks that can't be embedded are counted and left out
+	ix.Embed = func(context.Context, string, string) ([]float32, error) { return nil, errors.New("no provider") }
+	report, _ = ix.Update(context.Background(), []string{"a/util.go"})
+	if report.EmbedFailures == 0 || report.EmbedError != "no provider" || report.Chunks != 0 {
+		t.Errorf("failed embeddings = %+v", report)
+	}
+}
+
+func TestIndexIncremental(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "a/util.go", goSource)
+	writeFile(t, root, "b/util.go", goSource)
+
+	embedded := 0
+	store := newMemoryStore()
+	ix := New(root, store, func(ctx context.Context, code, language string) ([]float32, error) {
+		embedded++
+		return embed(ctx, code, language)
+	})
+	ctx := context.Background()
+	if _, err := ix.Index(ctx); err != nil {
+		t.Fatal(err)
+	}
+	chunks := store.files()["a/util.go"]
+
+	// A touched file is read but not embedded again
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(root, "a", "util.go"), later, later); err != nil {
+		t.Fatal(err)
+	}
+	embedded = 0
+	if report, err := ix.Index(ctx); err != nil || report.Skipped != 2 || report.Files != 0 || embedded != 0 {
+		t.Errorf("touched run = %+v, %v, %d embedded", report, err, embedded)
+	}
+
+	// Only the chunks that changed are embedded again
+	writeFile(t, root, "a/util.go", strings.Replace(goSource, "return 2", "return 22", 1))
+	embedded = 0
+	report, err := ix.Index(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Files != 1 || report.Chunks != chunks || report.Reused == 0 || embedded != chunks-report.Reused {
+		t.Errorf("changed run = %+v, %d embedded", report, embedded)
+	}
+
+	// Files gone since the last run leave the index
+	os.RemoveAll(filepath.Join(root, "b"))
+	if report, _ := ix.Index(ctx); report.Removed != 1 || store.files()["b/util.go"] != 0 {
+		t.Errorf("run after removal = %+v, indexed %v", report, store.files())
+	}
+}
+
+func TestWatch(t *testing.T) {
+	root := t.TempDir()
+	store := newMemoryStore()
+	ix := New(root, store, embed)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reports := make(chan *Report, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- ix.Watch(ctx, 50*time.Millisecond, func(r *Report, err error) {
+			if err == nil {
+				reports <- r
+			}
+		})
+	}()
+	time.Sleep(100 * time.Millisecond) // Let the watcher start
+
+	writeFile(t, root, "pkg/new.go", goSource)
+	select {
+	case r := <-reports:
+		if r.Files != 1 || store.files()["pkg/new.go"] == 0 {
+			t.Errorf("report %+v, indexed %v", r, store.files())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the new file wasn't indexed")
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,132 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultDebounce is how long files must stay unchanged before they're
+// reindexed
+const DefaultDebounce = 500 * time.Millisecond
+
+// Watch reindexes source files as they change, and removes deleted ones,
+// until ctx is cancelled. report is called after each batch of changes.
+func (ix *Indexer) Watch(ctx context.Context, debounce time.Duration, report func(*Report, error)) error {
+	if debounce <= 0 {
+		debounce = DefaultDebounce
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create filesystem watcher: %w", err)
+	}
+	defer watcher.Close()
+	if err := ix.watchTree(watcher, ix.Root); err != nil {
+		return err
+	}
+
+	changed := map[string]bool{}
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			rel, err := filepath.Rel(ix.Root, event.Name)
+			if err != nil {
+				continue
+			}
+			rel = filepath.ToSlash(rel)
+			if ix.matcher.Match(rel) {
+				continue
+			}
+			// New directories need watching too, and files may have been
+			// created in them before they were
+			if event.Op&fsnotify.Create != 0 {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					_ = ix.watchTree(watcher, event.Name)
+					if files, err := ix.filesUnder(event.Name); err == nil {
+						for _, file := range files {
+							changed[file] = true
+						}
+						timer.Reset(debounce)
+					}
+					continue
+				}
+			}
+			if _, ok := Language(rel); !ok || event.Op&fsnotify.Chmod == event.Op {
+				continue
+			}
+			changed[rel] = true
+			timer.Reset(debounce)
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			report(nil, err)
+
+		case <-timer.C:
+			files := make([]string, 0, len(changed))
+			for file := range changed {
+				files = append(files, file)
+			}
+			sort.Strings(files)
+			changed = map[string]bool{}
+
+			r, err := ix.Update(ctx, files)
+			if ctx.Err() != nil {
+				return nil
+			}
+			report(r, err)
+		}
+	}
+}
+
+// watchTree watches dir and its subdirectories, skipping ignored ones
+func (ix *Indexer) watchTree(watcher *fsnotify.Watcher, dir string) error {
+	return filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		if rel, err := filepath.Rel(ix.Root, path); err == nil && rel != "." && ix.matcher.Match(filepath.ToSlash(rel)) {
+			return filepath.SkipDir
+		}
+		if err := watcher.Add(path); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", path, err)
+		}
+		return nil
+	})
+}
+
+// filesUnder returns the source files under dir, relative to the root
+func (ix *Indexer) filesUnder(dir string) ([]string, error) {
+	prefix, err := filepath.Rel(ix.Root, dir)
+	if err != nil {
+		return nil, err
+	}
+	prefix = filepath.ToSlash(prefix) + "/"
+
+	all, err := ix.Files()
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, file := range all {
+		if strings.HasPrefix(file, prefix) {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,91 @@
+package issues
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/httpclient"
+)
+
+// maxResponseSize bounds the API responses read
+const maxResponseSize = 10 << 20
+
+// client calls a tracker's REST API
+type client struct {
+	http    *http.Client
+	baseURL string
+	headers map[string]string
+}
+
+func newClient(provider, baseURL string, headers map[string]string) *client {
+	return &client{
+		http:    httpclient.New(provider, 30*time.Second),
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		headers: headers,
+	}
+}
+
+// do sends a request with an optional JSON body and decodes the JSON response
+// into out, when it isn't nil
+func (c *client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s: %s", method, path, apiError(resp.Status, data))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+// apiError picks the message out of an error response
+func apiError(status string, body []byte) string {
+	var e struct {
+		Message interface{} `json:"message"`
+		Error   string      `json:"error"`
+	}
+	if json.Unmarshal(body, &e) == nil {
+		if e.Message != nil {
+			return fmt.Sprintf("%s: %v", status, e.Message)
+		}
+		if e.Error != "" {
+			return status + ": " + e.Error
+		}
+	}
+	return status
+}
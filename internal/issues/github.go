@@ -0,0 +1,116 @@
+package issues
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// gitHub is a repository on GitHub or GitHub Enterprise
+type gitHub struct {
+	repo string // owner/repo
+	api  *client
+}
+
+func newGitHub(r Remote, token string) *gitHub {
+	baseURL := "https://api.github.com"
+	if r.Host != "github.com" {
+		baseURL = "https://" + r.Host + "/api/v3"
+	}
+	return &gitHub{
+		repo: r.Path,
+		api: newClient("github", baseURL, map[string]string{
+			"Authorization":        "Bearer " + token,
+			"Accept":               "application/vnd.github+json",
+			"X-GitHub-Api-Version": "2022-11-28",
+		}),
+	}
+}
+
+func (g *gitHub) Name() string {
+	return "GitHub " + g.repo
+}
+
+type gitHubUser struct {
+	Login string `json:"login"`
+}
+
+func (g *gitHub) Issue(ctx context.Context, number int) (*Issue, error) {
+	var issue struct {
+		Number  int        `json:"number"`
+		Title   string     `json:"title"`
+		State   string     `json:"state"`
+		Body    string     `json:"body"`
+		HTMLURL string     `json:"html_url"`
+		User    gitHubUser `json:"user"`
+		Labels  []struct {
+			Name string `json:"name"`
+		} `json:"labels"`
+	}
+	if err := g.api.do(ctx, "GET", fmt.Sprintf("/repos/%s/issues/%d", g.repo, number), nil, &issue); err != nil {
+		return nil, err
+	}
+
+	var comments []struct {
+		Body      string     `json:"body"`
+		User      gitHubUser `json:"user"`
+		CreatedAt time.Time  `json:"created_at"`
+	}
+	if err := g.api.do(ctx, "GET", fmt.Sprintf("/repos/%s/issues/%d/comments?per_page=100", g.repo, number), nil, &comments); err != nil {
+		return nil, err
+	}
+
+	result := &Issue{
+		Number: issue.Number,
+		Title:  issue.Title,
+		State:  issue.State,
+		Author: issue.User.Login,
+		URL:    issue.HTMLURL,
+		Body:   issue.Body,
+	}
+	for _, l := range issue.Labels {
+		result.Labels = append(result.Labels, l.Name)
+	}
+	for _, c := range comments {
+		result.Comments = append(result.Comments, Comment{Author: c.User.Login, Body: c.Body, CreatedAt: c.CreatedAt})
+	}
+	return result, nil
+}
+
+func (g *gitHub) Comment(ctx context.Context, number int, body string) (string, error) {
+	var comment struct {
+		HTMLURL string `json:"html_url"`
+	}
+	path := fmt.Sprintf("/repos/%s/issues/%d/comments", g.repo, number)
+	if err := g.api.do(ctx, "POST", path, map[string]string{"body": body}, &comment); err != nil {
+		return "", err
+	}
+	return comment.HTMLURL, nil
+}
+
+func (g *gitHub) CreatePullRequest(ctx context.Context, pr PullRequest) (string, error) {
+	if pr.Base == "" {
+		var repo struct {
+			DefaultBranch string `json:"default_branch"`
+		}
+		if err := g.api.do(ctx, "GET", "/repos/"+g.repo, nil, &repo); err != nil {
+			return "", err
+		}
+		pr.Base = repo.DefaultBranch
+	}
+
+	var created struct {
+		HTMLURL string `json:"html_url"`
+	}
+	body := map[string]interface{}{
+		"title": pr.Title,
+		"body":  pr.Body,
+		"head":  pr.Head,
+		"base":  pr.Base,
+		"draft": pr.Draft,
+	}
+	if err := g.api.do(ctx, "POST", "/repos/"+g.repo+"/pulls", body, &created); err != nil {
+		return "", err
+	}
+	return created.HTMLURL, nil
+}
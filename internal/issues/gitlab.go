@@ -0,0 +1,123 @@
+package issues
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// gitLab is a project on GitLab.com or a self-hosted GitLab
+type gitLab struct {
+	project string // group/project, URL-escaped for API paths
+	name    string
+	api     *client
+}
+
+func newGitLab(r Remote, token string) *gitLab {
+	return &gitLab{
+		project: url.PathEscape(r.Path),
+		name:    r.Path,
+		api:     newClient("gitlab", "https://"+r.Host+"/api/v4", map[string]string{"PRIVATE-TOKEN": token}),
+	}
+}
+
+func (g *gitLab) Name() string {
+	return "GitLab " + g.name
+}
+
+type gitLabUser struct {
+	Username string `json:"username"`
+}
+
+func (g *gitLab) Issue(ctx context.Context, number int) (*Issue, error) {
+	var issue struct {
+		IID         int        `json:"iid"`
+		Title       string     `json:"title"`
+		State       string     `json:"state"`
+		Description string     `json:"description"`
+		WebURL      string     `json:"web_url"`
+		Author      gitLabUser `json:"author"`
+		Labels      []string   `json:"labels"`
+	}
+	if err := g.api.do(ctx, "GET", fmt.Sprintf("/projects/%s/issues/%d", g.project, number), nil, &issue); err != nil {
+		return nil, err
+	}
+
+	var notes []struct {
+		Body      string     `json:"body"`
+		Author    gitLabUser `json:"author"`
+		CreatedAt time.Time  `json:"created_at"`
+		System    bool       `json:"system"`
+	}
+	path := fmt.Sprintf("/projects/%s/issues/%d/notes?sort=asc&per_page=100", g.project, number)
+	if err := g.api.do(ctx, "GET", path, nil, &notes); err != nil {
+		return nil, err
+	}
+
+	result := &Issue{
+		Number: issue.IID,
+		Title:  issue.Title,
+		State:  issue.State,
+		Author: issue.Author.Username,
+		URL:    issue.WebURL,
+		Body:   issue.Description,
+		Labels: issue.Labels,
+	}
+	for _, n := range notes {
+		// System notes record events such as label changes, not discussion
+		if n.System {
+			continue
+		}
+		result.Comments = append(result.Comments, Comment{Author: n.Author.Username, Body: n.Body, CreatedAt: n.CreatedAt})
+	}
+	return result, nil
+}
+
+func (g *gitLab) Comment(ctx context.Context, number int, body string) (string, error) {
+	var issue struct {
+		WebURL string `json:"web_url"`
+	}
+	if err := g.api.do(ctx, "GET", fmt.Sprintf("/projects/%s/issues/%d", g.project, number), nil, &issue); err != nil {
+		return "", err
+	}
+
+	var note struct {
+		ID int `json:"id"`
+	}
+	path := fmt.Sprintf("/projects/%s/issues/%d/notes", g.project, number)
+	if err := g.api.do(ctx, "POST", path, map[string]string{"body": body}, &note); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s#note_%d", issue.WebURL, note.ID), nil
+}
+
+func (g *gitLab) CreatePullRequest(ctx context.Context, pr PullRequest) (string, error) {
+	if pr.Base == "" {
+		var project struct {
+			DefaultBranch string `json:"default_branch"`
+		}
+		if err := g.api.do(ctx, "GET", "/projects/"+g.project, nil, &project); err != nil {
+			return "", err
+		}
+		pr.Base = project.DefaultBranch
+	}
+
+	title := pr.Title
+	if pr.Draft {
+		title = "Draft: " + title
+	}
+	var created struct {
+		WebURL string `json:"web_url"`
+	}
+	body := map[string]interface{}{
+		"title":         title,
+		"description":   pr.Body,
+		"source_branch": pr.Head,
+		"target_branch": pr.Base,
+	}
+	if err := g.api.do(ctx, "POST", "/projects/"+g.project+"/merge_requests", body, &created); err != nil {
+		return "", err
+	}
+	return created.WebURL, nil
+}
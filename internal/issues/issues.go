@@ -0,0 +1,149 @@
+// Package issues reads and comments on the issues of the project's GitHub or
+// GitLab repository and opens pull requests, with the GITHUB_TOKEN or
+// GITLAB_TOKEN credentials already in the environment.
+package issues
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/git"
+)
+
+// ErrNoToken is returned when the tracker's token isn't set
+var ErrNoToken = errors.New("no token")
+
+// Issue is an issue with its discussion
+type Issue struct {
+	Number   int       `json:"number"`
+	Title    string    `json:"title"`
+	State    string    `json:"state"`
+	Author   string    `json:"author"`
+	URL      string    `json:"url"`
+	Body     string    `json:"body"`
+	Labels   []string  `json:"labels,omitempty"`
+	Comments []Comment `json:"comments,omitempty"`
+}
+
+// Comment is a comment on an issue
+type Comment struct {
+	Author    string    `json:"author"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PullRequest describes a pull request (a merge request on GitLab) to open
+type PullRequest struct {
+	Title string
+	Body  string
+	Head  string // Branch with the changes
+	Base  string // Branch to merge into; the default branch when empty
+	Draft bool
+}
+
+// Tracker is the issue tracker of a repository
+type Tracker interface {
+	// Name is the tracker and repository, such as "GitHub owner/repo"
+	Name() string
+
+	// Issue returns an issue and its comments
+	Issue(ctx context.Context, number int) (*Issue, error)
+
+	// Comment posts a comment on an issue and returns its URL
+	Comment(ctx context.Context, number int, body string) (string, error)
+
+	// CreatePullRequest opens a pull request and returns its URL
+	CreatePullRequest(ctx context.Context, pr PullRequest) (string, error)
+}
+
+// Remote is a repository on a hosting service, parsed from a git remote URL
+type Remote struct {
+	Host string // Such as "github.com"
+	Path string // Such as "owner/repo"; GitLab groups may nest
+}
+
+// ParseRemote parses an https, ssh or scp-style ("git@host:owner/repo.git")
+// remote URL
+func ParseRemote(remote string) (Remote, error) {
+	remote = strings.TrimSpace(remote)
+
+	var host, path string
+	if u, err := url.Parse(remote); err == nil && u.Scheme != "" && u.Host != "" {
+		host, path = u.Hostname(), u.Path
+	} else if at := strings.Index(remote, "@"); at >= 0 && strings.Contains(remote[at:], ":") {
+		host, path, _ = strings.Cut(remote[at+1:], ":")
+	} else {
+		return Remote{}, fmt.Errorf("unrecognized remote URL %q", remote)
+	}
+
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	if host == "" || !strings.Contains(path, "/") {
+		return Remote{}, fmt.Errorf("unrecognized remote URL %q", remote)
+	}
+	return Remote{Host: host, Path: path}, nil
+}
+
+// ForRemote returns the tracker of a remote, using the token from the
+// environment
+func ForRemote(r Remote) (Tracker, error) {
+	host := strings.ToLower(r.Host)
+	switch {
+	case strings.Contains(host, "github"):
+		token := os.Getenv("GITHUB_TOKEN")
+		if token == "" {
+			token = os.Getenv("GH_TOKEN")
+		}
+		if token == "" {
+			return nil, fmt.Errorf("%w: set GITHUB_TOKEN to use GitHub issues", ErrNoToken)
+		}
+		return newGitHub(r, token), nil
+	case strings.Contains(host, "gitlab"):
+		token := os.Getenv("GITLAB_TOKEN")
+		if token == "" {
+			return nil, fmt.Errorf("%w: set GITLAB_TOKEN to use GitLab issues", ErrNoToken)
+		}
+		return newGitLab(r, token), nil
+	}
+	return nil, fmt.Errorf("%s is not a GitHub or GitLab host", r.Host)
+}
+
+// Detect returns the tracker of the repository in dir, from its origin remote
+func Detect(ctx context.Context, dir string) (Tracker, error) {
+	remoteURL, err := git.NewRepository(dir).RemoteURL(ctx, "origin")
+	if err != nil {
+		return nil, err
+	}
+	remote, err := ParseRemote(remoteURL)
+	if err != nil {
+		return nil, err
+	}
+	return ForRemote(remote)
+}
+
+// Context formats the issue for a prompt
+func (i *Issue) Context() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## Issue #%d: %s\n", i.Number, i.Title)
+	fmt.Fprintf(&b, "State: %s | Author: %s", i.State, i.Author)
+	if len(i.Labels) > 0 {
+		fmt.Fprintf(&b, " | Labels: %s", strings.Join(i.Labels, ", "))
+	}
+	fmt.Fprintf(&b, "\n%s\n\n", i.URL)
+	if body := strings.TrimSpace(i.Body); body != "" {
+		b.WriteString(body)
+		b.WriteString("\n")
+	}
+	for _, c := range i.Comments {
+		fmt.Fprintf(&b, "\n### %s commented", c.Author)
+		if !c.CreatedAt.IsZero() {
+			fmt.Fprintf(&b, " on %s", c.CreatedAt.Format("2006-01-02"))
+		}
+		fmt.Fprintf(&b, "\n%s\n", strings.TrimSpace(c.Body))
+	}
+	return b.String()
+}
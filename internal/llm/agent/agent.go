@@ -13,6 +13,7 @@ import (
 	"github.com/entrepeneur4lyf/codeforge/internal/llm/prompt"
 	"github.com/entrepeneur4lyf/codeforge/internal/llm/providers"
 	"github.com/entrepeneur4lyf/codeforge/internal/models"
+	"github.com/entrepeneur4lyf/codeforge/internal/webhooks"
 	"github.com/google/uuid"
 )
 
@@ -30,14 +31,15 @@ type AgentEvent struct {
 type AgentEventType string
 
 const (
-	AgentEventStarted    AgentEventType = "agent_started"
-	AgentEventCompleted  AgentEventType = "agent_completed"
-	AgentEventError      AgentEventType = "agent_error"
-	AgentEventCancelled  AgentEventType = "agent_cancelled"
-	AgentEventTextChunk  AgentEventType = "agent_text_chunk"
-	AgentEventToolCall   AgentEventType = "agent_tool_call"
-	AgentEventToolResult AgentEventType = "agent_tool_result"
-	AgentEventUsage      AgentEventType = "agent_usage"
+	AgentEventStarted       AgentEventType = "agent_started"
+	AgentEventCompleted     AgentEventType = "agent_completed"
+	AgentEventError         AgentEventType = "agent_error"
+	AgentEventCancelled     AgentEventType = "agent_cancelled"
+	AgentEventTextChunk     AgentEventType = "agent_text_chunk"
+	AgentEventToolCallDelta AgentEventType = "agent_tool_call_delta"
+	AgentEventToolCall      AgentEventType = "agent_tool_call"
+	AgentEventToolResult    AgentEventType = "agent_tool_result"
+	AgentEventUsage         AgentEventType = "agent_usage"
 )
 
 // Service defines the agent service interface
@@ -250,11 +252,15 @@ func (s *AgentService) executeAgent(ctx context.Context, session *AgentSession,
 		s.emitEvent(session, AgentEventError, map[string]interface{}{
 			"error": err.Error(),
 		})
+		notifyFinished(session, config.Model.Name, "", nil, err)
 		return
 	}
 
 	// Process stream
-	var fullResponse string
+	var fullResponse, finishReason string
+	var usage *llm.ApiStreamUsageChunk
+	var toolCalls llm.ToolCallParser
+	ready := make(map[int]bool) // Tool calls already emitted
 	for chunk := range stream {
 		select {
 		case <-ctx.Done():
@@ -272,21 +278,98 @@ func (s *AgentService) executeAgent(ctx context.Context, session *AgentSession,
 				"text": c.Text,
 			})
 
+		case llm.ApiStreamToolCallDeltaChunk:
+			call := toolCalls.Add(c)
+			delta := map[string]interface{}{
+				"index":     c.Index,
+				"id":        c.ID,
+				"name":      c.Name,
+				"arguments": c.Arguments,
+			}
+			// The arguments so far, so clients can show or check them early
+			if input, err := call.Input(); err == nil && input != nil {
+				delta["input"] = input
+			}
+			s.emitEvent(session, AgentEventToolCallDelta, delta)
+
+			// A call can start as soon as its arguments are complete,
+			// before the rest of the response arrives
+			if !ready[call.Index] && (call.Complete() || call.Args.Err() != nil) {
+				ready[call.Index] = true
+				s.emitToolCall(session, call)
+			}
+
 		case llm.ApiStreamUsageChunk:
+			usage = &c
 			s.emitEvent(session, AgentEventUsage, map[string]interface{}{
 				"input_tokens":  c.InputTokens,
 				"output_tokens": c.OutputTokens,
-				"total_cost":    c.TotalCost,
+				"total_cost":    c.Cost(config.Handler.GetModel().Info),
 			})
+
+		case llm.ApiStreamFinishChunk:
+			finishReason = c.Reason
+		}
+	}
+
+	// Calls whose arguments were cut short are reported with what arrived
+	for _, call := range toolCalls.Calls() {
+		if !ready[call.Index] {
+			s.emitToolCall(session, call)
 		}
 	}
 
 	// Emit completion event
 	s.emitEvent(session, AgentEventCompleted, map[string]interface{}{
-		"response":    fullResponse,
-		"duration_ms": time.Since(session.StartTime).Milliseconds(),
-		"end_time":    time.Now(),
+		"response":      fullResponse,
+		"finish_reason": finishReason,
+		"duration_ms":   time.Since(session.StartTime).Milliseconds(),
+		"end_time":      time.Now(),
 	})
+	notifyFinished(session, config.Model.Name, finishReason, usage, nil)
+}
+
+// notifyFinished sends the agent.finished webhook for a run
+func notifyFinished(session *AgentSession, model, finishReason string, usage *llm.ApiStreamUsageChunk, err error) {
+	duration := time.Since(session.StartTime)
+	data := map[string]interface{}{
+		"session_id":    session.ID,
+		"agent":         string(session.AgentName),
+		"model":         model,
+		"finish_reason": finishReason,
+		"duration_ms":   duration.Milliseconds(),
+	}
+	text := fmt.Sprintf("Agent %s finished in %s with %s", session.AgentName, duration.Round(100*time.Millisecond), model)
+	if err != nil {
+		data["error"] = err.Error()
+		text = fmt.Sprintf("Agent %s failed with %s: %v", session.AgentName, model, err)
+	}
+	if usage != nil {
+		data["input_tokens"] = usage.InputTokens
+		data["output_tokens"] = usage.OutputTokens
+		if usage.TotalCost != nil {
+			data["cost"] = *usage.TotalCost
+		}
+	}
+	webhooks.Notify(webhooks.AgentFinished, text, data)
+}
+
+// emitToolCall emits a tool call with its decoded arguments, or the reason
+// they can't be used
+func (s *AgentService) emitToolCall(session *AgentSession, call *llm.PartialToolCall) {
+	data := map[string]interface{}{
+		"index":    call.Index,
+		"id":       call.ID,
+		"name":     call.Name,
+		"complete": call.Complete(),
+	}
+	input, err := llm.ParseToolArguments(call.Args.String())
+	if err != nil {
+		data["error"] = fmt.Sprintf("invalid arguments for %s: %v", call.Name, err)
+	} else {
+		data["input"] = input
+	}
+	s.emitEvent(session, AgentEventToolCall, data)
 }
 
 // emitEvent emits an agent event
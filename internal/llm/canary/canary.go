@@ -0,0 +1,342 @@
+// Package canary routes a share of requests to a candidate model and logs
+// quality signals, so the candidate can be compared with the current model
+// before a team migrates to it.
+package canary
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+)
+
+// Arms of an experiment
+const (
+	ArmBaseline  = "baseline"
+	ArmCandidate = "candidate"
+)
+
+// Event kinds written to the canary log
+const (
+	KindRequest  = "request"  // A request finished, successfully or not
+	KindRetry    = "retry"    // The user resent the previous message
+	KindFeedback = "feedback" // The user rated the previous response
+)
+
+// Event is one line of the canary log. Retries and feedback are attributed
+// to the arm that produced the response they concern.
+type Event struct {
+	Kind      string    `json:"kind"`
+	Time      time.Time `json:"time"`
+	Session   string    `json:"session,omitempty"`
+	Arm       string    `json:"arm"`
+	Model     string    `json:"model"`
+	LatencyMs int64     `json:"latencyMs,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	Positive  bool      `json:"positive,omitempty"`
+}
+
+// Router assigns requests to the baseline or the candidate model
+type Router struct {
+	candidate string
+	percent   int
+	path      string
+	roll      func() int // Returns 0-99
+
+	mu       sync.Mutex
+	sessions map[string]*sessionState
+}
+
+// sessionState is what retry detection and feedback need to know about the
+// last request of a session
+type sessionState struct {
+	prompt    string
+	routed    *Assignment // Last request routed
+	completed *Assignment // Last request that produced a response
+}
+
+// Assignment is the model chosen for one request
+type Assignment struct {
+	Arm   string // "" when no experiment is running
+	Model string
+
+	router  *Router
+	session string
+	start   time.Time
+}
+
+// New returns a router that sends percent of requests to candidate and
+// appends events to the log at path
+func New(candidate string, percent int, path string) *Router {
+	return &Router{
+		candidate: candidate,
+		percent:   max(0, min(percent, 100)),
+		path:      path,
+		roll:      func() int { return rand.Intn(100) },
+		sessions:  make(map[string]*sessionState),
+	}
+}
+
+var (
+	defaultOnce   sync.Once
+	defaultRouter *Router
+)
+
+// Default returns the router for the configured experiment, or nil when
+// none is enabled. A nil router routes every request to the baseline.
+func Default() *Router {
+	defaultOnce.Do(func() {
+		cfg := config.Get()
+		if cfg == nil || !cfg.Canary.Enabled || cfg.Canary.Candidate == "" || cfg.Canary.Percent <= 0 {
+			return
+		}
+		defaultRouter = New(cfg.ResolveModel(cfg.Canary.Candidate), cfg.Canary.Percent, cfg.CanaryLogPath())
+	})
+	return defaultRouter
+}
+
+// Candidate returns the candidate model and the share of requests it gets
+func (r *Router) Candidate() (string, int) {
+	return r.candidate, r.percent
+}
+
+// Route picks the model for a request in session whose default is baseline.
+// Resending the previous message of the session is logged as a retry of the
+// response it got. Call Done on the assignment when the request finishes.
+func (r *Router) Route(session, baseline, prompt string) *Assignment {
+	a := &Assignment{Model: baseline, session: session, start: time.Now()}
+	if r == nil || r.candidate == "" || r.candidate == config.ResolveModel(baseline) {
+		return a
+	}
+
+	a.router = r
+	a.Arm = ArmBaseline
+	if r.roll() < r.percent {
+		a.Arm = ArmCandidate
+		a.Model = r.candidate
+	}
+
+	prompt = normalizePrompt(prompt)
+	r.mu.Lock()
+	state := r.sessions[session]
+	if state == nil {
+		state = &sessionState{}
+		r.sessions[session] = state
+	}
+	previous := state.routed
+	retry := previous != nil && prompt != "" && prompt == state.prompt
+	state.prompt = prompt
+	state.routed = a
+	r.mu.Unlock()
+
+	if retry {
+		r.log(Event{Kind: KindRetry, Session: session, Arm: previous.Arm, Model: previous.Model})
+	}
+	return a
+}
+
+// Baseline returns an assignment of the same request to the baseline model,
+// for when the candidate can't be used
+func (a *Assignment) Baseline(baseline string) *Assignment {
+	fallback := &Assignment{Model: baseline, router: a.router, session: a.session, start: time.Now()}
+	if a.router != nil {
+		fallback.Arm = ArmBaseline
+		a.router.mu.Lock()
+		if state := a.router.sessions[a.session]; state != nil && state.routed == a {
+			state.routed = fallback
+		}
+		a.router.mu.Unlock()
+	}
+	return fallback
+}
+
+// Done logs the outcome of the request
+func (a *Assignment) Done(err error) {
+	if a == nil || a.router == nil {
+		return
+	}
+
+	e := Event{Kind: KindRequest, Session: a.session, Arm: a.Arm, Model: a.Model, LatencyMs: time.Since(a.start).Milliseconds()}
+	if err != nil {
+		e.Error = err.Error()
+	} else {
+		a.router.mu.Lock()
+		if state := a.router.sessions[a.session]; state != nil {
+			state.completed = a
+		}
+		a.router.mu.Unlock()
+	}
+	a.router.log(e)
+}
+
+// RecordFeedback logs a thumbs up or down for the last response in session.
+// It does nothing when the session has no response from the experiment.
+func (r *Router) RecordFeedback(session string, positive bool) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	var last *Assignment
+	if state := r.sessions[session]; state != nil {
+		last = state.completed
+	}
+	r.mu.Unlock()
+
+	if last != nil {
+		r.log(Event{Kind: KindFeedback, Session: session, Arm: last.Arm, Model: last.Model, Positive: positive})
+	}
+}
+
+// log appends an event to the canary log. Logging never fails a request.
+func (r *Router) log(e Event) {
+	e.Time = time.Now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o700); err != nil {
+		return
+	}
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	_ = json.NewEncoder(f).Encode(e)
+}
+
+// normalizePrompt makes resent messages compare equal despite whitespace
+// and case changes
+func normalizePrompt(prompt string) string {
+	return strings.ToLower(strings.Join(strings.Fields(prompt), " "))
+}
+
+// Stats summarizes the events of one model in one arm
+type Stats struct {
+	Arm        string
+	Model      string
+	Requests   int
+	Errors     int
+	Retries    int
+	ThumbsUp   int
+	ThumbsDown int
+	latencyMs  int64
+}
+
+// AvgLatency is the mean time to finish a successful request
+func (s Stats) AvgLatency() time.Duration {
+	if ok := s.Requests - s.Errors; ok > 0 {
+		return time.Duration(s.latencyMs/int64(ok)) * time.Millisecond
+	}
+	return 0
+}
+
+// RetryRate is the share of requests the user resent
+func (s Stats) RetryRate() float64 {
+	if s.Requests == 0 {
+		return 0
+	}
+	return float64(s.Retries) / float64(s.Requests)
+}
+
+// ErrorRate is the share of requests that failed
+func (s Stats) ErrorRate() float64 {
+	if s.Requests == 0 {
+		return 0
+	}
+	return float64(s.Errors) / float64(s.Requests)
+}
+
+// Approval is the share of rated responses rated positively, or -1 when
+// none were rated
+func (s Stats) Approval() float64 {
+	rated := s.ThumbsUp + s.ThumbsDown
+	if rated == 0 {
+		return -1
+	}
+	return float64(s.ThumbsUp) / float64(rated)
+}
+
+// ReadEvents parses a canary log. A missing log has no events.
+func ReadEvents(path string) ([]Event, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open canary log: %w", err)
+	}
+	defer f.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(f)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("invalid canary log entry at line %d: %w", line, err)
+		}
+		events = append(events, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read canary log: %w", err)
+	}
+	return events, nil
+}
+
+// Summarize groups events by arm and model, baseline first
+func Summarize(events []Event) []Stats {
+	byKey := make(map[[2]string]*Stats)
+	for _, e := range events {
+		key := [2]string{e.Arm, e.Model}
+		s := byKey[key]
+		if s == nil {
+			s = &Stats{Arm: e.Arm, Model: e.Model}
+			byKey[key] = s
+		}
+
+		switch e.Kind {
+		case KindRequest:
+			s.Requests++
+			if e.Error != "" {
+				s.Errors++
+			} else {
+				s.latencyMs += e.LatencyMs
+			}
+		case KindRetry:
+			s.Retries++
+		case KindFeedback:
+			if e.Positive {
+				s.ThumbsUp++
+			} else {
+				s.ThumbsDown++
+			}
+		}
+	}
+
+	stats := make([]Stats, 0, len(byKey))
+	for _, s := range byKey {
+		stats = append(stats, *s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Arm != stats[j].Arm {
+			return stats[i].Arm == ArmBaseline
+		}
+		return stats[i].Model < stats[j].Model
+	})
+	return stats
+}
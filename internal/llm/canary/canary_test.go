@@ -0,0 +1,130 @@
+package canary
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+// fixedRolls returns a roll function that yields the given values in turn
+func fixedRolls(values ...int) func() int {
+	i := 0
+	return func() int {
+		v := values[i%len(values)]
+		i++
+		return v
+	}
+}
+
+func TestRoute(t *testing.T) {
+	tests := []struct {
+		name      string
+		candidate string
+		percent   int
+		baseline  string
+		roll      int
+		wantArm   string
+		wantModel string
+	}{
+		{"below percent", "gpt-4.1", 20, "claude-sonnet-4", 19, ArmCandidate, "gpt-4.1"},
+		{"at percent", "gpt-4.1", 20, "claude-sonnet-4", 20, ArmBaseline, "claude-sonnet-4"},
+		{"zero percent", "gpt-4.1", 0, "claude-sonnet-4", 0, ArmBaseline, "claude-sonnet-4"},
+		{"candidate is baseline", "gpt-4.1", 100, "gpt-4.1", 0, "", "gpt-4.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New(tt.candidate, tt.percent, filepath.Join(t.TempDir(), "canary.jsonl"))
+			r.roll = fixedRolls(tt.roll)
+
+			a := r.Route("s1", tt.baseline, "hello")
+			if a.Arm != tt.wantArm || a.Model != tt.wantModel {
+				t.Errorf("Route() = %s %s, want %s %s", a.Arm, a.Model, tt.wantArm, tt.wantModel)
+			}
+		})
+	}
+}
+
+func TestNilRouter(t *testing.T) {
+	var r *Router
+	a := r.Route("s1", "claude-sonnet-4", "hello")
+	if a.Model != "claude-sonnet-4" || a.Arm != "" {
+		t.Errorf("Route() = %s %s, want the baseline outside the experiment", a.Arm, a.Model)
+	}
+	a.Done(nil)
+	r.RecordFeedback("s1", true)
+}
+
+func TestSignals(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "canary.jsonl")
+	r := New("gpt-4.1", 50, path)
+	r.roll = fixedRolls(10, 90, 90, 10)
+
+	// The candidate answers, the user resends the message and the baseline
+	// answers it, which the user likes
+	r.Route("s1", "claude-sonnet-4", "fix the build").Done(nil)
+	r.Route("s1", "claude-sonnet-4", "  Fix the   build ").Done(nil)
+	r.RecordFeedback("s1", true)
+
+	// A failed baseline request in another session, then a candidate
+	// response the user dislikes
+	r.Route("s2", "claude-sonnet-4", "explain this").Done(errors.New("timeout"))
+	r.Route("s2", "claude-sonnet-4", "explain main.go").Done(nil)
+	r.RecordFeedback("s2", false)
+
+	// Feedback without a response from the experiment is ignored
+	r.RecordFeedback("s3", true)
+
+	events, err := ReadEvents(path)
+	if err != nil {
+		t.Fatalf("ReadEvents() error = %v", err)
+	}
+	stats := Summarize(events)
+	if len(stats) != 2 {
+		t.Fatalf("Summarize() returned %d groups, want 2: %+v", len(stats), stats)
+	}
+
+	baseline, candidate := stats[0], stats[1]
+	if baseline.Arm != ArmBaseline || baseline.Model != "claude-sonnet-4" {
+		t.Fatalf("first group is %s %s, want the baseline", baseline.Arm, baseline.Model)
+	}
+	if baseline.Requests != 2 || baseline.Errors != 1 || baseline.Retries != 0 || baseline.ThumbsUp != 1 || baseline.ThumbsDown != 0 {
+		t.Errorf("baseline stats %+v", baseline)
+	}
+	if candidate.Requests != 2 || candidate.Errors != 0 || candidate.Retries != 1 || candidate.ThumbsUp != 0 || candidate.ThumbsDown != 1 {
+		t.Errorf("candidate stats %+v", candidate)
+	}
+	if candidate.RetryRate() != 0.5 || candidate.Approval() != 0 {
+		t.Errorf("candidate retry rate %v approval %v", candidate.RetryRate(), candidate.Approval())
+	}
+}
+
+func TestAssignmentBaseline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "canary.jsonl")
+	r := New("gpt-4.1", 100, path)
+
+	a := r.Route("s1", "claude-sonnet-4", "hello")
+	a.Done(errors.New("no API key"))
+	fallback := a.Baseline("claude-sonnet-4")
+	if fallback.Arm != ArmBaseline || fallback.Model != "claude-sonnet-4" {
+		t.Fatalf("Baseline() = %s %s", fallback.Arm, fallback.Model)
+	}
+	fallback.Done(nil)
+
+	// A resend is a retry of the response the baseline gave
+	r.Route("s1", "claude-sonnet-4", "hello").Done(nil)
+
+	events, err := ReadEvents(path)
+	if err != nil {
+		t.Fatalf("ReadEvents() error = %v", err)
+	}
+	var retry *Event
+	for i := range events {
+		if events[i].Kind == KindRetry {
+			retry = &events[i]
+		}
+	}
+	if retry == nil || retry.Arm != ArmBaseline {
+		t.Errorf("retry event %+v, want one attributed to the baseline", retry)
+	}
+}
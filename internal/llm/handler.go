@@ -2,6 +2,7 @@ package llm
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 )
@@ -75,6 +76,11 @@ type ModelInfo struct {
 	IsR1FormatRequired bool     `json:"isR1FormatRequired,omitempty"`
 }
 
+// Cost returns what input and output tokens cost at the model's list prices
+func (info ModelInfo) Cost(inputTokens, outputTokens int) float64 {
+	return (info.InputPrice*float64(inputTokens) + info.OutputPrice*float64(outputTokens)) / 1e6
+}
+
 // ThinkingConfig represents configuration for reasoning models
 type ThinkingConfig struct {
 	MaxBudget        int       `json:"maxBudget"`
@@ -125,6 +131,11 @@ type ApiHandlerOptions struct {
 	ReasoningEffort      string     `json:"reasoningEffort,omitempty"`
 	RequestTimeoutMs     int        `json:"requestTimeoutMs,omitempty"`
 
+	// Structured output for local models: a JSON schema or a GBNF grammar
+	// the backend constrains sampling to
+	ResponseSchema json.RawMessage `json:"responseSchema,omitempty"`
+	Grammar        string          `json:"grammar,omitempty"`
+
 	// Azure-specific
 	AzureAPIVersion string `json:"azureApiVersion,omitempty"`
 
@@ -197,6 +208,8 @@ const (
 	ProviderGroq       ProviderType = "groq"
 	ProviderOllama     ProviderType = "ollama"
 	ProviderLMStudio   ProviderType = "lmstudio"
+	ProviderJan        ProviderType = "jan"
+	ProviderLocalAPI   ProviderType = "local-openai" // llama.cpp and other OpenAI-compatible servers
 	ProviderXAI        ProviderType = "xai"
 	ProviderMistral    ProviderType = "mistral"
 	ProviderQwen       ProviderType = "qwen"
@@ -210,6 +223,8 @@ const (
 	ProviderClaudeCode ProviderType = "claude-code"
 	ProviderGeminiCLI  ProviderType = "gemini-cli"
 	ProviderGitHub     ProviderType = "github"
+	ProviderCopilot    ProviderType = "copilot"
+	ProviderMock       ProviderType = "mock"
 )
 
 // CompletionRequest represents a simple completion request
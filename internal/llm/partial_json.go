@@ -0,0 +1,418 @@
+package llm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// partialState is what a JSON container expects next
+type partialState int
+
+const (
+	expectKeyOrClose   partialState = iota // After {
+	expectKey                              // After , in an object
+	expectColon                            // After a key
+	expectValue                            // After : or , in an array
+	expectValueOrClose                     // After [
+	expectCommaOrClose                     // After a member
+)
+
+// partialFrame is an open object or array
+type partialFrame struct {
+	container byte // '{' or '['
+	state     partialState
+	safe      int // Length of the input up to the last complete member
+}
+
+// partialToken kinds
+const (
+	tokenNone = iota
+	tokenString
+	tokenNumber
+	tokenLiteral
+)
+
+// PartialJSON parses a JSON value that arrives in fragments, such as the
+// arguments of a streamed tool call, and can complete what it has read so far
+// into valid JSON at any point. Each fragment is scanned once, so feeding a
+// long value piece by piece costs no more than parsing it whole. The zero
+// value is ready to use.
+type PartialJSON struct {
+	buf     []byte
+	frames  []partialFrame
+	started bool // A value has begun
+	done    bool // The top-level value is complete
+	err     error
+
+	token      int  // Kind of the token being read
+	tokenStart int  // Offset of the token being read
+	key        bool // The string being read is an object key
+	escape     int  // 0 none, 1 after a backslash, 2-5 inside \u with escape-2 hex digits read
+}
+
+// Write adds the next fragment of the value
+func (p *PartialJSON) Write(fragment string) {
+	for i := 0; i < len(fragment); i++ {
+		p.buf = append(p.buf, fragment[i])
+		if p.err == nil {
+			p.scan(fragment[i], len(p.buf)-1)
+		}
+	}
+}
+
+// Err returns the syntax error the input has, if any. Input that is merely
+// incomplete isn't an error.
+func (p *PartialJSON) Err() error {
+	return p.err
+}
+
+// Complete reports whether a whole JSON value has been read
+func (p *PartialJSON) Complete() bool {
+	return p.done && p.err == nil
+}
+
+// String returns the input read so far
+func (p *PartialJSON) String() string {
+	return string(p.buf)
+}
+
+// Repaired returns what has been read so far as valid JSON: open strings and
+// containers are closed, and a trailing key, comma or unfinished literal is
+// dropped. It returns "" when no value can be made yet or the input has a
+// syntax error.
+func (p *PartialJSON) Repaired() string {
+	if !p.started || p.err != nil {
+		return ""
+	}
+	if p.done && p.token == tokenNone {
+		return string(p.buf)
+	}
+
+	end := len(p.buf)
+	suffix := ""
+	cut := false
+	switch p.token {
+	case tokenString:
+		if p.key {
+			cut = true
+		} else {
+			end -= p.escape // Drop an unfinished escape sequence
+			suffix = `"`
+		}
+	case tokenNumber:
+		if !json.Valid(p.buf[p.tokenStart:]) {
+			cut = true
+		}
+	case tokenLiteral:
+		cut = true
+	default:
+		if len(p.frames) > 0 {
+			switch p.frames[len(p.frames)-1].state {
+			case expectKey, expectColon, expectValue:
+				cut = true
+			}
+		}
+	}
+
+	if cut {
+		if len(p.frames) == 0 {
+			return ""
+		}
+		end = p.frames[len(p.frames)-1].safe
+		suffix = ""
+	}
+
+	var sb strings.Builder
+	sb.Write(p.buf[:end])
+	sb.WriteString(suffix)
+	for i := len(p.frames) - 1; i >= 0; i-- {
+		if p.frames[i].container == '{' {
+			sb.WriteByte('}')
+		} else {
+			sb.WriteByte(']')
+		}
+	}
+	return sb.String()
+}
+
+// Object decodes what has been read so far as a JSON object. It returns nil
+// and no error while nothing usable has arrived.
+func (p *PartialJSON) Object() (map[string]interface{}, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	repaired := p.Repaired()
+	if repaired == "" {
+		return nil, nil
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal([]byte(repaired), &obj); err != nil {
+		return nil, fmt.Errorf("arguments are not a JSON object: %w", err)
+	}
+	return obj, nil
+}
+
+// ParseToolArguments decodes tool call arguments into an object. Arguments
+// cut short, as when the model ran out of output tokens, are repaired first;
+// empty arguments are an empty object.
+func ParseToolArguments(args string) (map[string]interface{}, error) {
+	if strings.TrimSpace(args) == "" {
+		return map[string]interface{}{}, nil
+	}
+
+	var obj map[string]interface{}
+	if err := json.Unmarshal([]byte(args), &obj); err == nil {
+		return obj, nil
+	}
+
+	var p PartialJSON
+	p.Write(args)
+	obj, err := p.Object()
+	if err != nil {
+		return nil, err
+	}
+	if obj == nil {
+		return nil, errors.New("arguments are not a JSON object")
+	}
+	return obj, nil
+}
+
+// scan advances the parser over the byte c at offset pos
+func (p *PartialJSON) scan(c byte, pos int) {
+	switch p.token {
+	case tokenString:
+		p.scanString(c, pos)
+		return
+	case tokenNumber, tokenLiteral:
+		if p.continuesToken(c) {
+			return
+		}
+		if !p.endToken(pos) {
+			return
+		}
+	}
+
+	if c == ' ' || c == '\t' || c == '\n' || c == '\r' {
+		return
+	}
+	if p.done {
+		p.fail(pos, "unexpected content after the value")
+		return
+	}
+	if len(p.frames) == 0 {
+		p.startValue(c, pos)
+		return
+	}
+
+	frame := &p.frames[len(p.frames)-1]
+	switch frame.state {
+	case expectKeyOrClose, expectKey:
+		switch {
+		case c == '"':
+			p.token, p.tokenStart, p.key = tokenString, pos, true
+		case c == '}' && frame.state == expectKeyOrClose:
+			p.closeFrame(pos)
+		default:
+			p.fail(pos, "expected an object key")
+		}
+	case expectColon:
+		if c != ':' {
+			p.fail(pos, "expected ':'")
+			return
+		}
+		frame.state = expectValue
+	case expectValue, expectValueOrClose:
+		if c == ']' && frame.state == expectValueOrClose {
+			p.closeFrame(pos)
+			return
+		}
+		p.startValue(c, pos)
+	case expectCommaOrClose:
+		switch {
+		case c == ',' && frame.container == '{':
+			frame.state = expectKey
+		case c == ',':
+			frame.state = expectValue
+		case c == '}' && frame.container == '{', c == ']' && frame.container == '[':
+			p.closeFrame(pos)
+		default:
+			p.fail(pos, "expected ',' or the end of the "+containerName(frame.container))
+		}
+	}
+}
+
+// startValue begins the value starting with c
+func (p *PartialJSON) startValue(c byte, pos int) {
+	p.started = true
+	switch {
+	case c == '{':
+		p.frames = append(p.frames, partialFrame{container: '{', state: expectKeyOrClose, safe: pos + 1})
+	case c == '[':
+		p.frames = append(p.frames, partialFrame{container: '[', state: expectValueOrClose, safe: pos + 1})
+	case c == '"':
+		p.token, p.tokenStart, p.key = tokenString, pos, false
+	case c == '-' || (c >= '0' && c <= '9'):
+		p.token, p.tokenStart = tokenNumber, pos
+	case c == 't' || c == 'f' || c == 'n':
+		p.token, p.tokenStart = tokenLiteral, pos
+	default:
+		p.fail(pos, "expected a value")
+	}
+}
+
+// scanString advances over a byte of a string
+func (p *PartialJSON) scanString(c byte, pos int) {
+	switch {
+	case p.escape == 1:
+		switch c {
+		case 'u':
+			p.escape = 2
+		case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+			p.escape = 0
+		default:
+			p.fail(pos, "invalid escape sequence")
+		}
+	case p.escape >= 2:
+		if !isHexDigit(c) {
+			p.fail(pos, "invalid \\u escape")
+			return
+		}
+		p.escape++
+		if p.escape == 6 {
+			p.escape = 0
+		}
+	case c == '\\':
+		p.escape = 1
+	case c == '"':
+		p.token = tokenNone
+		if p.key {
+			p.frames[len(p.frames)-1].state = expectColon
+		} else {
+			p.valueDone(pos + 1)
+		}
+	case c < 0x20:
+		p.fail(pos, "control character in string")
+	}
+}
+
+// continuesToken reports whether c belongs to the number or literal being read
+func (p *PartialJSON) continuesToken(c byte) bool {
+	if p.token == tokenNumber {
+		return (c >= '0' && c <= '9') || c == '.' || c == 'e' || c == 'E' || c == '+' || c == '-'
+	}
+	return c >= 'a' && c <= 'z'
+}
+
+// endToken finishes the number or literal that ended before pos, reporting
+// whether it was valid
+func (p *PartialJSON) endToken(pos int) bool {
+	text := string(p.buf[p.tokenStart:pos])
+	valid := text == "true" || text == "false" || text == "null"
+	if p.token == tokenNumber {
+		valid = json.Valid([]byte(text))
+	}
+	p.token = tokenNone
+	if !valid {
+		p.fail(p.tokenStart, fmt.Sprintf("invalid value %q", text))
+		return false
+	}
+	p.valueDone(pos)
+	return true
+}
+
+// closeFrame closes the innermost container with the byte at pos
+func (p *PartialJSON) closeFrame(pos int) {
+	p.frames = p.frames[:len(p.frames)-1]
+	p.valueDone(pos + 1)
+}
+
+// valueDone records a complete value ending before end
+func (p *PartialJSON) valueDone(end int) {
+	if len(p.frames) == 0 {
+		p.done = true
+		return
+	}
+	frame := &p.frames[len(p.frames)-1]
+	frame.state = expectCommaOrClose
+	frame.safe = end
+}
+
+func (p *PartialJSON) fail(pos int, msg string) {
+	p.err = fmt.Errorf("invalid JSON at offset %d: %s", pos, msg)
+}
+
+func containerName(container byte) string {
+	if container == '{' {
+		return "object"
+	}
+	return "array"
+}
+
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+// PartialToolCall is a streamed tool call whose arguments may still be
+// arriving
+type PartialToolCall struct {
+	Index int
+	ID    string
+	Name  string
+	Args  PartialJSON
+}
+
+// Input decodes the arguments received so far, completing them if they're
+// unfinished. It returns nil and no error until the arguments begin.
+func (c *PartialToolCall) Input() (map[string]interface{}, error) {
+	return c.Args.Object()
+}
+
+// Complete reports whether all of the call's arguments have arrived
+func (c *PartialToolCall) Complete() bool {
+	return c.Args.Complete()
+}
+
+// ToolCall returns the call as assembled so far
+func (c *PartialToolCall) ToolCall() StreamToolCall {
+	return StreamToolCall{ID: c.ID, Name: c.Name, Arguments: c.Args.String()}
+}
+
+// ToolCallParser assembles tool calls from their streamed fragments, parsing
+// the arguments as they arrive so a call can be validated, or started,
+// before the stream ends
+type ToolCallParser struct {
+	calls map[int]*PartialToolCall
+}
+
+// Add applies a fragment and returns the call it belongs to
+func (tp *ToolCallParser) Add(delta ApiStreamToolCallDeltaChunk) *PartialToolCall {
+	if tp.calls == nil {
+		tp.calls = make(map[int]*PartialToolCall)
+	}
+	call, ok := tp.calls[delta.Index]
+	if !ok {
+		call = &PartialToolCall{Index: delta.Index}
+		tp.calls[delta.Index] = call
+	}
+	if delta.ID != "" {
+		call.ID = delta.ID
+	}
+	if delta.Name != "" {
+		call.Name = delta.Name
+	}
+	call.Args.Write(delta.Arguments)
+	return call
+}
+
+// Calls returns the calls seen so far, in the order the model made them
+func (tp *ToolCallParser) Calls() []*PartialToolCall {
+	calls := make([]*PartialToolCall, 0, len(tp.calls))
+	for _, call := range tp.calls {
+		calls = append(calls, call)
+	}
+	sort.Slice(calls, func(i, j int) bool { return calls[i].Index < calls[j].Index })
+	return calls
+}
@@ -0,0 +1,122 @@
+package llm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPartialJSONRepaired(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{``, ``},
+		{`{`, `{}`},
+		{`{"pa`, `{}`},
+		{`{"path"`, `{}`},
+		{`{"path":`, `{}`},
+		{`{"path": "src/ma`, `{"path": "src/ma"}`},
+		{`{"path": "a\`, `{"path": "a"}`},
+		{`{"path": "a\u00`, `{"path": "a"}`},
+		{`{"path": "a.go", `, `{"path": "a.go"}`},
+		{`{"path": "a.go", "lines": [1, 2`, `{"path": "a.go", "lines": [1, 2]}`},
+		{`{"path": "a.go", "lines": [1, -`, `{"path": "a.go", "lines": [1]}`},
+		{`{"recursive": tr`, `{}`},
+		{`{"opts": {"deep": true}, "n": 1.5`, `{"opts": {"deep": true}, "n": 1.5}`},
+		{`{"path": "a.go"}`, `{"path": "a.go"}`},
+	}
+
+	for _, tt := range tests {
+		var p PartialJSON
+		p.Write(tt.input)
+		if got := p.Repaired(); got != tt.want {
+			t.Errorf("Repaired(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestPartialJSONFragments(t *testing.T) {
+	whole := `{"path": "dir/\"quoted\".go", "content": "line\nnext é", "mode": null}`
+
+	// Feed the value a byte at a time, as a slow stream would
+	var p PartialJSON
+	for i := 0; i < len(whole); i++ {
+		if p.Complete() {
+			t.Fatalf("complete after %d of %d bytes", i, len(whole))
+		}
+		p.Write(whole[i : i+1])
+		if _, err := p.Object(); err != nil {
+			t.Fatalf("after %q: %v", whole[:i+1], err)
+		}
+	}
+	if !p.Complete() {
+		t.Fatal("not complete after the whole value")
+	}
+
+	got, err := p.Object()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{"path": `dir/"quoted".go`, "content": "line\nnext é", "mode": nil}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Object() = %v, want %v", got, want)
+	}
+}
+
+func TestPartialJSONErrors(t *testing.T) {
+	for _, input := range []string{`{"a" 1}`, `{"a": tru }`, `{"a": "\x"}`, `{"a": 1}}`, `[1,]`, `{,}`} {
+		var p PartialJSON
+		p.Write(input)
+		if p.Err() == nil {
+			t.Errorf("%q: expected a syntax error", input)
+		}
+		if p.Complete() {
+			t.Errorf("%q: reported complete", input)
+		}
+	}
+}
+
+func TestParseToolArguments(t *testing.T) {
+	got, err := ParseToolArguments("")
+	if err != nil || len(got) != 0 {
+		t.Errorf("empty arguments = %v, %v; want an empty object", got, err)
+	}
+
+	got, err = ParseToolArguments(`{"command": "go test", "timeout": 30`)
+	if err != nil {
+		t.Fatalf("truncated arguments: %v", err)
+	}
+	if want := map[string]interface{}{"command": "go test", "timeout": float64(30)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("truncated arguments = %v, want %v", got, want)
+	}
+
+	for _, args := range []string{`[1, 2]`, `"path"`, `{"a": }`} {
+		if _, err := ParseToolArguments(args); err == nil {
+			t.Errorf("%q: expected an error", args)
+		}
+	}
+}
+
+func TestToolCallParser(t *testing.T) {
+	var tp ToolCallParser
+	tp.Add(ApiStreamToolCallDeltaChunk{Index: 1, ID: "call_2", Name: "ls", Arguments: `{"path":`})
+	first := tp.Add(ApiStreamToolCallDeltaChunk{Index: 0, ID: "call_1", Name: "read_file", Arguments: `{"path": "a`})
+	if first.Complete() {
+		t.Fatal("call complete before its arguments ended")
+	}
+	tp.Add(ApiStreamToolCallDeltaChunk{Index: 0, Arguments: `.go"}`})
+	if !first.Complete() {
+		t.Fatal("call not complete after its arguments ended")
+	}
+
+	calls := tp.Calls()
+	if len(calls) != 2 || calls[0].ID != "call_1" || calls[1].ID != "call_2" {
+		t.Fatalf("calls out of order: %+v", calls)
+	}
+	if got := calls[0].ToolCall(); got.Arguments != `{"path": "a.go"}` {
+		t.Errorf("arguments = %q", got.Arguments)
+	}
+	if input, err := calls[1].Input(); err != nil || len(input) != 0 {
+		t.Errorf("partial input = %v, %v; want an empty object", input, err)
+	}
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/entrepeneur4lyf/codeforge/internal/config"
 	"github.com/entrepeneur4lyf/codeforge/internal/models"
+	"github.com/entrepeneur4lyf/codeforge/internal/notes"
 )
 
 // GetAgentPrompt returns the appropriate prompt for the given agent and provider
@@ -30,6 +31,9 @@ func GetAgentPrompt(agentName config.AgentName, provider models.ModelProvider) s
 	if agentName == config.AgentCoder || agentName == config.AgentTask {
 		// Add context from project-specific instruction files if they exist
 		contextContent := getContextFromPaths()
+		if projectNotes := notes.PromptContext(); projectNotes != "" {
+			contextContent += "\n" + projectNotes
+		}
 		if contextContent != "" {
 			return fmt.Sprintf("%s\n\n# Project-Specific Context\nMake sure to follow the instructions in the context below\n%s", basePrompt, contextContent)
 		}
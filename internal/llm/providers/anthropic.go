@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/entrepeneur4lyf/codeforge/internal/httpclient"
 	"github.com/entrepeneur4lyf/codeforge/internal/llm"
 	"github.com/entrepeneur4lyf/codeforge/internal/models"
 )
@@ -89,9 +90,11 @@ type AnthropicMessageStart struct {
 
 // AnthropicDelta represents delta content
 type AnthropicDelta struct {
-	Type     string `json:"type"`
-	Text     string `json:"text,omitempty"`
-	Thinking string `json:"thinking,omitempty"`
+	Type        string `json:"type"`
+	Text        string `json:"text,omitempty"`
+	Thinking    string `json:"thinking,omitempty"`
+	PartialJSON string `json:"partial_json,omitempty"` // Tool input fragment
+	StopReason  string `json:"stop_reason,omitempty"`
 }
 
 // AnthropicUsage represents token usage
@@ -107,6 +110,8 @@ type AnthropicContentBlockEvent struct {
 	Type     string `json:"type"`
 	Text     string `json:"text,omitempty"`
 	Thinking string `json:"thinking,omitempty"`
+	ID       string `json:"id,omitempty"`   // Tool use ID
+	Name     string `json:"name,omitempty"` // Tool name
 }
 
 // NewAnthropicHandler creates a new Anthropic handler
@@ -118,16 +123,14 @@ func NewAnthropicHandler(options llm.ApiHandlerOptions) *AnthropicHandler {
 
 	return &AnthropicHandler{
 		options: options,
-		client: &http.Client{
-			Timeout: 60 * time.Second,
-		},
+		client:  httpclient.New("anthropic", 60*time.Second),
 		baseURL: baseURL,
 	}
 }
 
 // CreateMessage implements the ApiHandler interface
 func (h *AnthropicHandler) CreateMessage(ctx context.Context, systemPrompt string, messages []llm.Message) (llm.ApiStream, error) {
-	model := h.GetModel()
+	model := fitOutput(ctx, h.GetModel())
 
 	// Convert messages to Anthropic format
 	anthropicMessages, err := h.convertMessages(messages)
@@ -411,6 +414,10 @@ func (h *AnthropicHandler) processStream(reader io.Reader, streamChan chan<- llm
 
 		// Process different event types
 		switch streamEvent.Type {
+		case "content_block_start":
+			if block := streamEvent.ContentBlock; block != nil && block.Type == "tool_use" {
+				streamChan <- llm.ApiStreamToolCallDeltaChunk{Index: streamEvent.Index, ID: block.ID, Name: block.Name}
+			}
 		case "content_block_delta":
 			if streamEvent.Delta != nil {
 				if streamEvent.Delta.Text != "" {
@@ -419,6 +426,9 @@ func (h *AnthropicHandler) processStream(reader io.Reader, streamChan chan<- llm
 				if streamEvent.Delta.Thinking != "" {
 					streamChan <- llm.ApiStreamReasoningChunk{Reasoning: streamEvent.Delta.Thinking}
 				}
+				if streamEvent.Delta.PartialJSON != "" {
+					streamChan <- llm.ApiStreamToolCallDeltaChunk{Index: streamEvent.Index, Arguments: streamEvent.Delta.PartialJSON}
+				}
 			}
 		case "message_delta":
 			if streamEvent.Usage != nil {
@@ -439,6 +449,9 @@ func (h *AnthropicHandler) processStream(reader io.Reader, streamChan chan<- llm
 
 				streamChan <- usage
 			}
+			if streamEvent.Delta != nil {
+				sendFinish(streamChan, &streamEvent.Delta.StopReason)
+			}
 		}
 	}
 }
@@ -480,7 +493,7 @@ func (s *SSEScanner) Scan() bool {
 
 	// Read more data
 	n, err := s.reader.Read(s.buffer)
-	if err != nil {
+	if n == 0 && err != nil {
 		return false
 	}
 
@@ -495,7 +508,7 @@ func (s *SSEScanner) Scan() bool {
 		if line == "" {
 			// Empty line indicates end of event
 			if currentEvent.Type != "" || currentEvent.Data != "" {
-				s.events = append(s.events, currentEvent)
+				s.events = append(s.events, withDataType(currentEvent))
 				currentEvent = SSEEvent{}
 			}
 			continue
@@ -510,12 +523,21 @@ func (s *SSEScanner) Scan() bool {
 
 	// Add final event if exists
 	if currentEvent.Type != "" || currentEvent.Data != "" {
-		s.events = append(s.events, currentEvent)
+		s.events = append(s.events, withDataType(currentEvent))
 	}
 
 	return len(s.events) > s.index
 }
 
+// withDataType types an event without an event: line as "data", the way
+// OpenAI-compatible APIs send every event
+func withDataType(event SSEEvent) SSEEvent {
+	if event.Type == "" {
+		event.Type = "data"
+	}
+	return event
+}
+
 // Event returns the current SSE event
 func (s *SSEScanner) Event() SSEEvent {
 	if s.index > 0 && s.index <= len(s.events) {
@@ -11,6 +11,7 @@ import (
 
 	"github.com/anthropics/anthropic-sdk-go"
 	"github.com/anthropics/anthropic-sdk-go/option"
+	"github.com/entrepeneur4lyf/codeforge/internal/httpclient"
 	"github.com/entrepeneur4lyf/codeforge/internal/llm"
 )
 
@@ -25,6 +26,7 @@ func NewAnthropicSDKHandler(options llm.ApiHandlerOptions) *AnthropicSDKHandler
 	// Create client with API key
 	client := anthropic.NewClient(
 		option.WithAPIKey(options.APIKey),
+		option.WithHTTPClient(httpclient.New("anthropic", 0)),
 	)
 
 	return &AnthropicSDKHandler{
@@ -54,12 +56,6 @@ func (h *AnthropicSDKHandler) CreateMessage(ctx context.Context, systemPrompt st
 		case "assistant":
 			anthropicMessages = append(anthropicMessages,
 				anthropic.NewAssistantMessage(anthropic.NewTextBlock(textContent)))
-		case "system":
-			// System messages are handled separately in Anthropic API
-			// For now, we'll convert them to user messages with a prefix
-			content := fmt.Sprintf("System: %s", textContent)
-			anthropicMessages = append(anthropicMessages,
-				anthropic.NewUserMessage(anthropic.NewTextBlock(content)))
 		}
 	}
 
@@ -67,11 +63,15 @@ func (h *AnthropicSDKHandler) CreateMessage(ctx context.Context, systemPrompt st
 	model := h.getAnthropicModel(h.options.ModelID)
 
 	// Create streaming request
-	stream := h.client.Messages.NewStreaming(ctx, anthropic.MessageNewParams{
-		MaxTokens: 4096, // Default max tokens
+	params := anthropic.MessageNewParams{
+		MaxTokens: int64(fitOutput(ctx, h.GetModel()).Info.MaxTokens),
 		Messages:  anthropicMessages,
 		Model:     model,
-	})
+	}
+	if systemPrompt != "" {
+		params.System = []anthropic.TextBlockParam{{Text: systemPrompt}}
+	}
+	stream := h.client.Messages.NewStreaming(ctx, params)
 
 	// Create output channel
 	outputChan := make(chan llm.ApiStreamChunk, 100)
@@ -85,6 +85,14 @@ func (h *AnthropicSDKHandler) CreateMessage(ctx context.Context, systemPrompt st
 			event := stream.Current()
 
 			switch eventVariant := event.AsAny().(type) {
+			case anthropic.ContentBlockStartEvent:
+				if eventVariant.ContentBlock.Type == "tool_use" {
+					outputChan <- llm.ApiStreamToolCallDeltaChunk{
+						Index: int(eventVariant.Index),
+						ID:    eventVariant.ContentBlock.ID,
+						Name:  eventVariant.ContentBlock.Name,
+					}
+				}
 			case anthropic.ContentBlockDeltaEvent:
 				switch deltaVariant := eventVariant.Delta.AsAny().(type) {
 				case anthropic.TextDelta:
@@ -92,12 +100,16 @@ func (h *AnthropicSDKHandler) CreateMessage(ctx context.Context, systemPrompt st
 					outputChan <- llm.ApiStreamTextChunk{
 						Text: deltaVariant.Text,
 					}
+				case anthropic.InputJSONDelta:
+					// Send tool input fragment
+					outputChan <- llm.ApiStreamToolCallDeltaChunk{
+						Index:     int(eventVariant.Index),
+						Arguments: deltaVariant.PartialJSON,
+					}
 				}
 			case anthropic.MessageDeltaEvent:
-				// Handle message-level events if needed
-				if eventVariant.Delta.StopSequence != "" {
-					// Message completed - just continue, no special chunk needed
-				}
+				stopReason := string(eventVariant.Delta.StopReason)
+				sendFinish(outputChan, &stopReason)
 			case anthropic.MessageStopEvent:
 				// Stream completed - just return, channel will be closed
 				return
@@ -208,7 +220,7 @@ func GetAnthropicModels(ctx context.Context, apiKey string) ([]AnthropicModelInf
 
 // getCachedAnthropicModels returns cached models if available and fresh
 func getCachedAnthropicModels(_ context.Context, _ string, forceRefresh bool) ([]AnthropicModelInfo, error) {
-	cacheDir := filepath.Join(os.Getenv("HOME"), ".codeforge", "cache")
+	cacheDir := catalogCacheDir()
 	cacheFile := filepath.Join(cacheDir, "anthropic_models.json")
 
 	// Check cache first (unless force refresh)
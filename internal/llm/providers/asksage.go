@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/entrepeneur4lyf/codeforge/internal/httpclient"
 	"github.com/entrepeneur4lyf/codeforge/internal/llm"
 	"github.com/entrepeneur4lyf/codeforge/internal/llm/transform"
 )
@@ -58,8 +59,9 @@ type AskSageChoice struct {
 
 // AskSageDelta represents incremental content in streaming
 type AskSageDelta struct {
-	Role    string `json:"role,omitempty"`
-	Content string `json:"content,omitempty"`
+	Role      string                     `json:"role,omitempty"`
+	Content   string                     `json:"content,omitempty"`
+	ToolCalls []transform.OpenAIToolCall `json:"tool_calls,omitempty"`
 }
 
 // AskSageMessage represents a complete message
@@ -90,16 +92,14 @@ func NewAskSageHandler(options llm.ApiHandlerOptions) *AskSageHandler {
 
 	return &AskSageHandler{
 		options: options,
-		client: &http.Client{
-			Timeout: timeout,
-		},
+		client:  httpclient.New("asksage", timeout),
 		baseURL: baseURL,
 	}
 }
 
 // CreateMessage implements the ApiHandler interface
 func (h *AskSageHandler) CreateMessage(ctx context.Context, systemPrompt string, messages []llm.Message) (llm.ApiStream, error) {
-	model := h.GetModel()
+	model := fitOutput(ctx, h.GetModel())
 
 	// Convert messages to OpenAI format
 	openAIMessages, err := convertToOpenAIMessages(systemPrompt, messages)
@@ -270,6 +270,9 @@ func (h *AskSageHandler) processStream(reader io.Reader, streamChan chan<- llm.A
 					Text: choice.Delta.Content,
 				}
 			}
+			if choice.Delta != nil {
+				sendToolCallDeltas(streamChan, choice.Delta.ToolCalls)
+			}
 
 			// Handle finish reason and send usage if available
 			if choice.FinishReason != nil && *choice.FinishReason != "" {
@@ -279,6 +282,7 @@ func (h *AskSageHandler) processStream(reader io.Reader, streamChan chan<- llm.A
 						OutputTokens: streamEvent.Usage.CompletionTokens,
 					}
 				}
+				sendFinish(streamChan, choice.FinishReason)
 			}
 		}
 	}
@@ -10,6 +10,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/entrepeneur4lyf/codeforge/internal/httpclient"
 	"github.com/entrepeneur4lyf/codeforge/internal/llm"
 )
 
@@ -51,7 +52,7 @@ func (h *BedrockSDKHandler) CreateMessage(ctx context.Context, systemPrompt stri
 	// Create client if not already created
 	if h.client == nil {
 		// Load AWS configuration
-		cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(h.region))
+		cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(h.region), config.WithHTTPClient(httpclient.New("bedrock", 0)))
 		if err != nil {
 			return nil, fmt.Errorf("failed to load AWS config: %w", err)
 		}
@@ -107,6 +108,8 @@ func (h *BedrockSDKHandler) CreateMessage(ctx context.Context, systemPrompt stri
 					if text := h.extractTextFromChunk(e.Value.Bytes); text != "" {
 						responseChan <- llm.ApiStreamTextChunk{Text: text}
 					}
+					finishReason := chunkFinishReason(e.Value.Bytes)
+					sendFinish(responseChan, &finishReason)
 				default:
 					// Handle other event types including errors
 					fmt.Printf("Bedrock stream event: %T\n", e)
@@ -580,6 +583,36 @@ func (h *BedrockSDKHandler) extractTextFromResponse(data []byte) string {
 	return ""
 }
 
+// chunkFinishReason returns the stop reason in a streamed chunk of any model
+// family, or "" when the chunk doesn't end the response
+func chunkFinishReason(data []byte) string {
+	var chunk struct {
+		Delta struct {
+			StopReason string `json:"stop_reason"`
+		} `json:"delta"` // Anthropic
+		CompletionReason string `json:"completionReason"` // Amazon Titan
+		StopReason       string `json:"stop_reason"`      // Meta Llama
+		Outputs          []struct {
+			StopReason string `json:"stop_reason"`
+		} `json:"outputs"` // Mistral
+	}
+	if err := json.Unmarshal(data, &chunk); err != nil {
+		return ""
+	}
+
+	for _, reason := range []string{chunk.Delta.StopReason, chunk.CompletionReason, chunk.StopReason} {
+		if reason != "" {
+			return reason
+		}
+	}
+	for _, output := range chunk.Outputs {
+		if output.StopReason != "" {
+			return output.StopReason
+		}
+	}
+	return ""
+}
+
 // extractAnthropicStreamText extracts text from Anthropic streaming response
 func (h *BedrockSDKHandler) extractAnthropicStreamText(data []byte) string {
 	var response struct {
@@ -0,0 +1,57 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+	"github.com/entrepeneur4lyf/codeforge/internal/tokens"
+)
+
+const (
+	promptMargin      = 0.1 // Share added to the prompt's token estimate, as tokenizers differ
+	outputSafetyLimit = 256 // Tokens kept free of the context window besides the prompt
+	minOutputTokens   = 256 // Least output a request is given when its prompt nearly fills the window
+)
+
+type outputBudgetKey struct{}
+
+// withOutputBudget records how many tokens a request's reply may take
+func withOutputBudget(ctx context.Context, budget int) context.Context {
+	return context.WithValue(ctx, outputBudgetKey{}, budget)
+}
+
+// fitOutput caps a model's max tokens at the output budget of the request,
+// so a long prompt leaves the reply what's left of the context window rather
+// than being refused or cut short
+func fitOutput(ctx context.Context, model llm.ModelResponse) llm.ModelResponse {
+	if budget, ok := ctx.Value(outputBudgetKey{}).(int); ok && model.Info.MaxTokens > budget {
+		model.Info.MaxTokens = budget
+	}
+	return model
+}
+
+// outputBudget returns the tokens left in a model's context window once the
+// prompt and a safety margin are in it, or false when the window is unknown
+func outputBudget(model llm.ModelResponse, systemPrompt string, messages []llm.Message) (int, bool) {
+	window := model.Info.ContextWindow
+	if window <= 0 {
+		return 0, false
+	}
+
+	texts := []string{systemPrompt}
+	for _, msg := range messages {
+		texts = append(texts, blockTexts(msg.Content)...)
+		for _, block := range msg.Content {
+			if use, ok := block.(llm.ToolUseBlock); ok {
+				input, _ := json.Marshal(use.Input)
+				texts = append(texts, use.Name, string(input))
+			}
+		}
+	}
+	prompt := tokens.Count(strings.Join(texts, "\n"), model.ID).Count
+	prompt += int(float64(prompt) * promptMargin)
+
+	return max(window-prompt-outputSafetyLimit, minOutputTokens), true
+}
@@ -0,0 +1,66 @@
+package providers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+)
+
+func TestOutputBudget(t *testing.T) {
+	model := llm.ModelResponse{ID: "gpt-4o", Info: llm.ModelInfo{ContextWindow: 8000, MaxTokens: 4096}}
+	short := []llm.Message{{Role: "user", Content: []llm.ContentBlock{text("Hello")}}}
+	long := []llm.Message{{Role: "user", Content: []llm.ContentBlock{text(strings.Repeat("lorem ipsum ", 3000))}}}
+
+	// A short prompt leaves the model's own limit in place
+	budget, ok := outputBudget(model, "Be brief.", short)
+	if !ok || budget < 7000 {
+		t.Fatalf("short prompt budget = %d, %v", budget, ok)
+	}
+	if got := fitOutput(withOutputBudget(context.Background(), budget), model).Info.MaxTokens; got != 4096 {
+		t.Errorf("short prompt max tokens = %d, want 4096", got)
+	}
+
+	// A long one gets what's left of the window
+	budget, _ = outputBudget(model, "Be brief.", long)
+	if budget >= 4096 || budget < minOutputTokens {
+		t.Fatalf("long prompt budget = %d", budget)
+	}
+	if got := fitOutput(withOutputBudget(context.Background(), budget), model).Info.MaxTokens; got != budget {
+		t.Errorf("long prompt max tokens = %d, want %d", got, budget)
+	}
+
+	if _, ok := outputBudget(llm.ModelResponse{ID: "custom"}, "", short); ok {
+		t.Error("budget computed without a context window")
+	}
+	if got := fitOutput(context.Background(), model).Info.MaxTokens; got != 4096 {
+		t.Errorf("max tokens changed without a budget: %d", got)
+	}
+}
+
+func TestOllamaContextProbe(t *testing.T) {
+	show := `{"parameters": "stop \"<|eot_id|>\"\nnum_ctx 16384", "model_info": {"llama.context_length": 131072}}`
+	probes := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		probes++
+		w.Write([]byte(show))
+	}))
+	defer server.Close()
+
+	handler := NewOllamaHandler(llm.ApiHandlerOptions{ModelID: "llama3"})
+	handler.baseURL = server.URL
+	handler.GetModel()
+	if got := handler.GetModel().Info.ContextWindow; got != 16384 || probes != 1 {
+		t.Errorf("context window = %d after %d probes, want num_ctx once", got, probes)
+	}
+
+	show = `{"model_info": {"qwen2.context_length": 32768}}`
+	handler = NewOllamaHandler(llm.ApiHandlerOptions{ModelID: "qwen2.5-coder"})
+	handler.baseURL = server.URL
+	if got := handler.GetModel().Info.ContextWindow; got != 32768 {
+		t.Errorf("context window = %d, want the model's context length", got)
+	}
+}
@@ -0,0 +1,199 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+	"github.com/entrepeneur4lyf/codeforge/internal/httpclient"
+	"github.com/entrepeneur4lyf/codeforge/internal/models"
+)
+
+// CatalogStatus describes where a provider's model catalog was served from
+type CatalogStatus struct {
+	Provider  string    `json:"provider"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	Stale     bool      `json:"stale"` // Served from an expired cache because the provider was unreachable
+}
+
+// Banner returns a staleness notice for stale catalogs, or "" when fresh
+func (s CatalogStatus) Banner() string {
+	if !s.Stale {
+		return ""
+	}
+	if s.UpdatedAt.IsZero() {
+		return fmt.Sprintf("Offline: showing cached %s catalog", s.Provider)
+	}
+	return fmt.Sprintf("Offline: showing cached %s catalog from %s (%s ago)",
+		s.Provider, s.UpdatedAt.Format("2006-01-02 15:04"), formatAge(time.Since(s.UpdatedAt)))
+}
+
+var catalogStatus = struct {
+	sync.RWMutex
+	byProvider map[string]CatalogStatus
+}{byProvider: make(map[string]CatalogStatus)}
+
+// recordCatalogStatus records how a provider's catalog was last served
+func recordCatalogStatus(provider string, updatedAt time.Time, stale bool) {
+	catalogStatus.Lock()
+	defer catalogStatus.Unlock()
+	catalogStatus.byProvider[provider] = CatalogStatus{Provider: provider, UpdatedAt: updatedAt, Stale: stale}
+}
+
+// GetCatalogStatus returns how a provider's catalog was last served
+func GetCatalogStatus(provider string) (CatalogStatus, bool) {
+	catalogStatus.RLock()
+	defer catalogStatus.RUnlock()
+	status, ok := catalogStatus.byProvider[provider]
+	return status, ok
+}
+
+// catalogCacheDir returns the directory model catalogs are cached in. HOME
+// isn't set on Windows, so the home directory comes from os.UserHomeDir.
+func catalogCacheDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = os.Getenv("HOME")
+	}
+	return filepath.Join(home, ".codeforge", "cache")
+}
+
+// readStaleCatalog loads a catalog cache file regardless of its age
+func readStaleCatalog(cacheFile string, v any) (time.Time, error) {
+	info, err := os.Stat(cacheFile)
+	if err != nil {
+		return time.Time{}, err
+	}
+	data, err := os.ReadFile(cacheFile)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
+
+// cloudEndpoints are where cloud providers are reached unless the config
+// gives a provider its own base URL
+var cloudEndpoints = map[string]string{
+	"openrouter": "https://openrouter.ai/api/v1",
+	"openai":     "https://api.openai.com/v1",
+	"anthropic":  "https://api.anthropic.com/v1",
+	"gemini":     "https://generativelanguage.googleapis.com",
+	"groq":       "https://api.groq.com/openai/v1",
+	"deepseek":   "https://api.deepseek.com",
+	"mistral":    "https://api.mistral.ai/v1",
+	"xai":        "https://api.x.ai/v1",
+}
+
+// connectivityProbeTimeout bounds a connectivity check
+const connectivityProbeTimeout = 2 * time.Second
+
+var connectivity = struct {
+	sync.Mutex
+	online    bool
+	checkedAt time.Time
+}{}
+
+// IsOnline reports whether cloud providers are reachable. The result is cached
+// briefly so callers can check it on hot paths. Set CODEFORGE_OFFLINE=1 to
+// force offline behaviour.
+func IsOnline() bool {
+	if os.Getenv("CODEFORGE_OFFLINE") == "1" {
+		return false
+	}
+
+	connectivity.Lock()
+	if !connectivity.checkedAt.IsZero() && time.Since(connectivity.checkedAt) < 30*time.Second {
+		online := connectivity.online
+		connectivity.Unlock()
+		return online
+	}
+	connectivity.Unlock()
+
+	// Callers checking meanwhile probe too, rather than wait on this one
+	online := probeEndpoints(probeTargets())
+
+	connectivity.Lock()
+	connectivity.online = online
+	connectivity.checkedAt = time.Now()
+	connectivity.Unlock()
+	return online
+}
+
+// probeTargets returns the endpoints to probe by provider: the configured
+// provider's, or every cloud provider's when the config names none of them.
+// A provider's base URL in the config replaces its public endpoint.
+func probeTargets() map[string]string {
+	targets := make(map[string]string)
+	cfg := config.Get()
+	if cfg != nil && cfg.Provider != "" {
+		provider := strings.ToLower(cfg.Provider)
+		if providerCfg, _ := config.GetProvider(models.ModelProvider(provider)); providerCfg.BaseURL != "" {
+			targets[provider] = providerCfg.BaseURL
+		} else if url, ok := cloudEndpoints[provider]; ok {
+			targets[provider] = url
+		}
+		if len(targets) > 0 {
+			return targets
+		}
+	}
+	for provider, url := range cloudEndpoints {
+		if providerCfg, _ := config.GetProvider(models.ModelProvider(provider)); providerCfg.BaseURL != "" {
+			url = providerCfg.BaseURL
+		}
+		targets[provider] = url
+	}
+	return targets
+}
+
+// probeEndpoints reports whether any of the endpoints answers. Each is
+// reached the way its provider's requests are, through the configured proxy
+// and CA bundle; any HTTP response, whatever its status, counts.
+func probeEndpoints(targets map[string]string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), connectivityProbeTimeout)
+	defer cancel()
+
+	results := make(chan bool, len(targets))
+	for provider, url := range targets {
+		go func(provider, url string) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+			if err != nil {
+				results <- false
+				return
+			}
+			resp, err := httpclient.New(provider, connectivityProbeTimeout).Do(req)
+			if err == nil {
+				resp.Body.Close()
+			}
+			results <- err == nil
+		}(provider, url)
+	}
+
+	for range targets {
+		if <-results {
+			return true
+		}
+	}
+	return false
+}
+
+// formatAge renders a duration as a short human-readable age
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/entrepeneur4lyf/codeforge/internal/httpclient"
 	"github.com/entrepeneur4lyf/codeforge/internal/llm"
 	"github.com/entrepeneur4lyf/codeforge/internal/llm/transform"
 )
@@ -48,8 +49,9 @@ type ClaudeCodeStreamEvent struct {
 
 // ClaudeCodeContentDelta represents incremental content in streaming
 type ClaudeCodeContentDelta struct {
-	Type string `json:"type"`
-	Text string `json:"text"`
+	Type       string `json:"type"`
+	Text       string `json:"text"`
+	StopReason string `json:"stop_reason,omitempty"` // Set on message_delta events
 }
 
 // ClaudeCodeUsage represents token usage information
@@ -73,16 +75,14 @@ func NewClaudeCodeHandler(options llm.ApiHandlerOptions) *ClaudeCodeHandler {
 
 	return &ClaudeCodeHandler{
 		options: options,
-		client: &http.Client{
-			Timeout: timeout,
-		},
+		client:  httpclient.New("claude-code", timeout),
 		baseURL: baseURL,
 	}
 }
 
 // CreateMessage implements the ApiHandler interface
 func (h *ClaudeCodeHandler) CreateMessage(ctx context.Context, systemPrompt string, messages []llm.Message) (llm.ApiStream, error) {
-	model := h.GetModel()
+	model := fitOutput(ctx, h.GetModel())
 
 	// Convert messages to OpenAI format
 	openAIMessages, err := convertToOpenAIMessages(systemPrompt, messages)
@@ -264,6 +264,9 @@ func (h *ClaudeCodeHandler) processStream(reader io.Reader, streamChan chan<- ll
 					OutputTokens: streamEvent.Usage.OutputTokens,
 				}
 			}
+			if streamEvent.Delta != nil {
+				sendFinish(streamChan, &streamEvent.Delta.StopReason)
+			}
 		}
 	}
 }
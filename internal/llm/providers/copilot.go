@@ -0,0 +1,440 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/httpclient"
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+	"github.com/entrepeneur4lyf/codeforge/internal/models"
+)
+
+const (
+	// copilotClientID is the public OAuth app ID GitHub's Copilot editor
+	// plugins use for the device flow
+	copilotClientID = "Iv1.b507a08c87ecfe98"
+
+	copilotGitHubURL = "https://github.com"
+	copilotAPIURL    = "https://api.github.com"
+	copilotChatURL   = "https://api.githubcopilot.com"
+
+	// The chat API only serves known editor integrations
+	copilotEditorVersion       = "vscode/1.99.3"
+	copilotEditorPluginVersion = "copilot-chat/0.26.7"
+	copilotIntegrationID       = "vscode-chat"
+
+	// copilotTokenFile stores the GitHub OAuth token from `codeforge copilot login`
+	copilotTokenFile = "copilot.json"
+)
+
+// ErrCopilotNotLoggedIn is returned when no GitHub token for Copilot is available
+var ErrCopilotNotLoggedIn = errors.New("not logged in to GitHub Copilot; run `codeforge copilot login`")
+
+// CopilotDeviceCode is the code the user enters at VerificationURI to
+// authorize CodeForge
+type CopilotDeviceCode struct {
+	DeviceCode      string `json:"device_code"`
+	UserCode        string `json:"user_code"`
+	VerificationURI string `json:"verification_uri"`
+	ExpiresIn       int    `json:"expires_in"`
+	Interval        int    `json:"interval"`
+}
+
+// copilotAPIToken is a short-lived Copilot API token
+type copilotAPIToken struct {
+	Token     string `json:"token"`
+	ExpiresAt int64  `json:"expires_at"`
+	Endpoints struct {
+		API string `json:"api"`
+	} `json:"endpoints"`
+}
+
+// CopilotAuth exchanges a GitHub OAuth token for Copilot API tokens,
+// refreshing them shortly before they expire
+type CopilotAuth struct {
+	client     *http.Client
+	githubURL  string
+	apiURL     string
+	oauthToken string
+
+	mu    sync.Mutex
+	token *copilotAPIToken
+}
+
+// NewCopilotAuth creates token management for a GitHub OAuth token
+func NewCopilotAuth(oauthToken string) *CopilotAuth {
+	return &CopilotAuth{
+		client:     httpclient.New("copilot", 30*time.Second),
+		githubURL:  copilotGitHubURL,
+		apiURL:     copilotAPIURL,
+		oauthToken: oauthToken,
+	}
+}
+
+// RequestDeviceCode starts the device flow
+func (a *CopilotAuth) RequestDeviceCode(ctx context.Context) (*CopilotDeviceCode, error) {
+	form := url.Values{"client_id": {copilotClientID}, "scope": {"read:user"}}
+	var code CopilotDeviceCode
+	if err := a.postForm(ctx, a.githubURL+"/login/device/code", form, &code); err != nil {
+		return nil, fmt.Errorf("failed to request device code: %w", err)
+	}
+	if code.Interval <= 0 {
+		code.Interval = 5
+	}
+	return &code, nil
+}
+
+// PollAccessToken waits for the user to authorize the device code and
+// returns the GitHub OAuth token
+func (a *CopilotAuth) PollAccessToken(ctx context.Context, code *CopilotDeviceCode) (string, error) {
+	form := url.Values{
+		"client_id":   {copilotClientID},
+		"device_code": {code.DeviceCode},
+		"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+	}
+	interval := time.Duration(code.Interval) * time.Second
+	deadline := time.Now().Add(time.Duration(code.ExpiresIn) * time.Second)
+
+	for code.ExpiresIn <= 0 || time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(interval):
+		}
+
+		var result struct {
+			AccessToken string `json:"access_token"`
+			Error       string `json:"error"`
+			Interval    int    `json:"interval"`
+		}
+		if err := a.postForm(ctx, a.githubURL+"/login/oauth/access_token", form, &result); err != nil {
+			return "", fmt.Errorf("failed to poll for access token: %w", err)
+		}
+
+		switch result.Error {
+		case "":
+			a.oauthToken = result.AccessToken
+			return result.AccessToken, nil
+		case "authorization_pending":
+		case "slow_down":
+			if result.Interval > 0 {
+				interval = time.Duration(result.Interval) * time.Second
+			} else {
+				interval += 5 * time.Second
+			}
+		case "expired_token":
+			return "", fmt.Errorf("device code expired; run login again")
+		case "access_denied":
+			return "", fmt.Errorf("authorization was denied")
+		default:
+			return "", fmt.Errorf("device flow error: %s", result.Error)
+		}
+	}
+	return "", fmt.Errorf("device code expired; run login again")
+}
+
+// APIToken returns a valid Copilot API token and the chat endpoint it is
+// for, exchanging the OAuth token for a new one when needed
+func (a *CopilotAuth) APIToken(ctx context.Context) (string, string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.oauthToken == "" {
+		return "", "", ErrCopilotNotLoggedIn
+	}
+	// Refresh a minute early so a request never starts with a token about to expire
+	if a.token == nil || time.Now().Add(time.Minute).Unix() >= a.token.ExpiresAt {
+		token, err := a.exchange(ctx)
+		if err != nil {
+			return "", "", err
+		}
+		a.token = token
+	}
+
+	endpoint := a.token.Endpoints.API
+	if endpoint == "" {
+		endpoint = copilotChatURL
+	}
+	return a.token.Token, strings.TrimSuffix(endpoint, "/"), nil
+}
+
+// Invalidate drops the cached API token so the next request gets a new one
+func (a *CopilotAuth) Invalidate() {
+	a.mu.Lock()
+	a.token = nil
+	a.mu.Unlock()
+}
+
+// exchange trades the OAuth token for a Copilot API token
+func (a *CopilotAuth) exchange(ctx context.Context) (*copilotAPIToken, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", a.apiURL+"/copilot_internal/v2/token", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "token "+a.oauthToken)
+	setCopilotEditorHeaders(req)
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Copilot token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized:
+		return nil, fmt.Errorf("GitHub token rejected; run `codeforge copilot login` again")
+	case http.StatusForbidden, http.StatusNotFound:
+		return nil, fmt.Errorf("this GitHub account has no Copilot subscription")
+	default:
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to get Copilot token: %d %s", resp.StatusCode, string(body))
+	}
+
+	var token copilotAPIToken
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return nil, fmt.Errorf("failed to decode Copilot token: %w", err)
+	}
+	if token.Token == "" {
+		return nil, fmt.Errorf("empty Copilot token")
+	}
+	return &token, nil
+}
+
+// postForm posts a form to a GitHub OAuth endpoint and decodes the JSON reply
+func (a *CopilotAuth) postForm(ctx context.Context, endpoint string, form url.Values, v any) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%d %s", resp.StatusCode, string(body))
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// setCopilotEditorHeaders identifies the request as coming from an editor
+// integration
+func setCopilotEditorHeaders(req *http.Request) {
+	req.Header.Set("Editor-Version", copilotEditorVersion)
+	req.Header.Set("Editor-Plugin-Version", copilotEditorPluginVersion)
+	req.Header.Set("User-Agent", "GitHubCopilotChat/0.26.7")
+}
+
+// copilotTokenPath is where the GitHub OAuth token is stored
+func copilotTokenPath() string {
+	return filepath.Join(filepath.Dir(catalogCacheDir()), copilotTokenFile)
+}
+
+// CopilotToken returns the GitHub OAuth token used for Copilot: the
+// COPILOT_API_KEY environment variable, the token saved by `codeforge copilot
+// login`, or one left by GitHub's Copilot editor plugins. It returns "" when
+// there is none.
+func CopilotToken() string {
+	if token := os.Getenv("COPILOT_API_KEY"); token != "" {
+		return token
+	}
+
+	var saved struct {
+		OAuthToken string `json:"oauth_token"`
+	}
+	if data, err := os.ReadFile(copilotTokenPath()); err == nil && json.Unmarshal(data, &saved) == nil && saved.OAuthToken != "" {
+		return saved.OAuthToken
+	}
+
+	// copilot.vim and the JetBrains plugin store their tokens keyed by host
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	for _, name := range []string{"hosts.json", "apps.json"} {
+		data, err := os.ReadFile(filepath.Join(configDir, "github-copilot", name))
+		if err != nil {
+			continue
+		}
+		var hosts map[string]struct {
+			OAuthToken string `json:"oauth_token"`
+		}
+		if json.Unmarshal(data, &hosts) != nil {
+			continue
+		}
+		for host, entry := range hosts {
+			if strings.HasPrefix(host, "github.com") && entry.OAuthToken != "" {
+				return entry.OAuthToken
+			}
+		}
+	}
+	return ""
+}
+
+// SaveCopilotToken stores the GitHub OAuth token for later sessions
+func SaveCopilotToken(token string) error {
+	path := copilotTokenPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(map[string]string{"oauth_token": token})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// DeleteCopilotToken removes the stored GitHub OAuth token
+func DeleteCopilotToken() error {
+	if err := os.Remove(copilotTokenPath()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// CopilotHandler implements the ApiHandler interface for GitHub Copilot's
+// chat API, which uses the same OpenAI-compatible format as GitHub Models
+type CopilotHandler struct {
+	options llm.ApiHandlerOptions
+	client  *http.Client
+	auth    *CopilotAuth
+	github  *GitHubHandler // Message conversion and stream parsing
+}
+
+// NewCopilotHandler creates a new GitHub Copilot handler. The API key is the
+// GitHub OAuth token; the stored login is used when it is empty.
+func NewCopilotHandler(options llm.ApiHandlerOptions) *CopilotHandler {
+	oauthToken := options.APIKey
+	if oauthToken == "" {
+		oauthToken = CopilotToken()
+	}
+
+	timeout := 60 * time.Second
+	if options.RequestTimeoutMs > 0 {
+		timeout = time.Duration(options.RequestTimeoutMs) * time.Millisecond
+	}
+
+	return &CopilotHandler{
+		options: options,
+		client:  httpclient.New("copilot", timeout),
+		auth:    NewCopilotAuth(oauthToken),
+		github:  NewGitHubHandler(options),
+	}
+}
+
+// CreateMessage implements the ApiHandler interface
+func (h *CopilotHandler) CreateMessage(ctx context.Context, systemPrompt string, messages []llm.Message) (llm.ApiStream, error) {
+	model := fitOutput(ctx, h.GetModel())
+
+	openAIMessages, err := h.github.convertMessages(systemPrompt, messages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert messages: %w", err)
+	}
+
+	request := GitHubRequest{
+		Model:    model.ID,
+		Messages: openAIMessages,
+		Stream:   true,
+	}
+	if model.Info.MaxTokens > 0 {
+		request.MaxTokens = &model.Info.MaxTokens
+	}
+	if model.Info.Temperature != nil {
+		request.Temperature = model.Info.Temperature
+	}
+
+	requestBody, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	resp, err := h.send(ctx, requestBody)
+	if err != nil {
+		return nil, err
+	}
+	// The API token may have been revoked early; get a new one and retry once
+	if resp.StatusCode == http.StatusUnauthorized {
+		resp.Body.Close()
+		h.auth.Invalidate()
+		if resp, err = h.send(ctx, requestBody); err != nil {
+			return nil, err
+		}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, llm.WrapHTTPError(fmt.Errorf("API error %d: %s", resp.StatusCode, string(body)), resp)
+	}
+
+	streamChan := make(chan llm.ApiStreamChunk, 100)
+	go func() {
+		defer close(streamChan)
+		defer resp.Body.Close()
+
+		h.github.processStream(resp.Body, streamChan)
+	}()
+
+	return streamChan, nil
+}
+
+// send posts a chat completion request with a current API token
+func (h *CopilotHandler) send(ctx context.Context, requestBody []byte) (*http.Response, error) {
+	token, endpoint, err := h.auth.APIToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint+"/chat/completions", bytes.NewReader(requestBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Copilot-Integration-Id", copilotIntegrationID)
+	setCopilotEditorHeaders(req)
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, llm.WrapHTTPError(fmt.Errorf("request failed: %w", err), resp)
+	}
+	return resp, nil
+}
+
+// GetModel implements the ApiHandler interface
+func (h *CopilotHandler) GetModel() llm.ModelResponse {
+	registry := models.NewModelRegistry()
+	if canonicalModel, exists := registry.Lookup(models.ProviderID(models.ProviderCopilot), h.options.ModelID); exists {
+		info := h.github.convertToLLMModelInfo(canonicalModel)
+		info.Description = fmt.Sprintf("%s (GitHub Copilot)", canonicalModel.Name)
+		return llm.ModelResponse{ID: h.options.ModelID, Info: info}
+	}
+
+	return llm.ModelResponse{
+		ID:   h.options.ModelID,
+		Info: h.github.getDefaultModelInfo(h.options.ModelID),
+	}
+}
+
+// GetApiStreamUsage implements the ApiHandler interface
+func (h *CopilotHandler) GetApiStreamUsage() (*llm.ApiStreamUsageChunk, error) {
+	return nil, nil
+}
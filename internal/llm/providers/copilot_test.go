@@ -0,0 +1,131 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+)
+
+func TestCopilotDeviceFlow(t *testing.T) {
+	var polls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/login/device/code":
+			fmt.Fprint(w, `{"device_code":"dev","user_code":"ABCD-1234","verification_uri":"https://github.com/login/device","expires_in":60,"interval":0}`)
+		case "/login/oauth/access_token":
+			if r.FormValue("device_code") != "dev" {
+				t.Errorf("device_code = %q", r.FormValue("device_code"))
+			}
+			if polls.Add(1) == 1 {
+				fmt.Fprint(w, `{"error":"authorization_pending"}`)
+				return
+			}
+			fmt.Fprint(w, `{"access_token":"gho_test"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	auth := NewCopilotAuth("")
+	auth.githubURL = server.URL
+
+	code, err := auth.RequestDeviceCode(context.Background())
+	if err != nil {
+		t.Fatalf("RequestDeviceCode() error = %v", err)
+	}
+	if code.UserCode != "ABCD-1234" {
+		t.Errorf("UserCode = %q", code.UserCode)
+	}
+
+	// Poll quickly in tests
+	code.Interval = 0
+	token, err := auth.PollAccessToken(context.Background(), code)
+	if err != nil {
+		t.Fatalf("PollAccessToken() error = %v", err)
+	}
+	if token != "gho_test" || polls.Load() != 2 {
+		t.Errorf("token = %q after %d polls, want gho_test after 2", token, polls.Load())
+	}
+}
+
+func TestCopilotHandler_RefreshesToken(t *testing.T) {
+	var exchanges atomic.Int32
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/copilot_internal/v2/token":
+			if r.Header.Get("Authorization") != "token gho_test" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			n := exchanges.Add(1)
+			// The first token is already expired, so it must be refreshed
+			expires := time.Now().Add(time.Hour).Unix()
+			if n == 1 {
+				expires = time.Now().Unix()
+			}
+			fmt.Fprintf(w, `{"token":"tid-%d","expires_at":%d,"endpoints":{"api":%q}}`, n, expires, server.URL)
+		case "/chat/completions":
+			if r.Header.Get("Authorization") != "Bearer tid-2" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if r.Header.Get("Copilot-Integration-Id") == "" {
+				t.Error("missing Copilot-Integration-Id header")
+			}
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"hello\"}}]}\n\n")
+			fmt.Fprint(w, "data: [DONE]\n\n")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	handler := NewCopilotHandler(llm.ApiHandlerOptions{APIKey: "gho_test", ModelID: "gpt-4o"})
+	handler.auth.apiURL = server.URL
+
+	// Expired token: refreshed before the request is sent
+	if _, _, err := handler.auth.APIToken(context.Background()); err != nil {
+		t.Fatalf("APIToken() error = %v", err)
+	}
+	stream, err := handler.CreateMessage(context.Background(), "", []llm.Message{
+		{Role: "user", Content: []llm.ContentBlock{llm.TextBlock{Text: "hi"}}},
+	})
+	if err != nil {
+		t.Fatalf("CreateMessage() error = %v", err)
+	}
+
+	var text strings.Builder
+	for chunk := range stream {
+		if c, ok := chunk.(llm.ApiStreamTextChunk); ok {
+			text.WriteString(c.Text)
+		}
+	}
+	if text.String() != "hello" {
+		t.Errorf("response = %q, want hello", text.String())
+	}
+	if exchanges.Load() != 2 {
+		t.Errorf("token exchanged %d times, want 2", exchanges.Load())
+	}
+}
+
+func TestCopilotHandler_NotLoggedIn(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("COPILOT_API_KEY", "")
+
+	handler := NewCopilotHandler(llm.ApiHandlerOptions{ModelID: "gpt-4o"})
+	if _, err := handler.CreateMessage(context.Background(), "", nil); err != ErrCopilotNotLoggedIn {
+		t.Errorf("CreateMessage() error = %v, want ErrCopilotNotLoggedIn", err)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/entrepeneur4lyf/codeforge/internal/httpclient"
 	"github.com/entrepeneur4lyf/codeforge/internal/llm"
 	"github.com/entrepeneur4lyf/codeforge/internal/llm/transform"
 )
@@ -51,8 +52,9 @@ type DeepSeekChoice struct {
 
 // DeepSeekDelta represents delta content
 type DeepSeekDelta struct {
-	Role    string `json:"role,omitempty"`
-	Content string `json:"content,omitempty"`
+	Role      string                     `json:"role,omitempty"`
+	Content   string                     `json:"content,omitempty"`
+	ToolCalls []transform.OpenAIToolCall `json:"tool_calls,omitempty"`
 }
 
 // DeepSeekMessage represents a complete message
@@ -80,14 +82,14 @@ func NewDeepSeekHandler(options llm.ApiHandlerOptions) *DeepSeekHandler {
 
 	return &DeepSeekHandler{
 		options: options,
-		client:  &http.Client{Timeout: timeout},
+		client:  httpclient.New("deepseek", timeout),
 		baseURL: baseURL,
 	}
 }
 
 // CreateMessage implements the ApiHandler interface
 func (h *DeepSeekHandler) CreateMessage(ctx context.Context, systemPrompt string, messages []llm.Message) (llm.ApiStream, error) {
-	model := h.GetModel()
+	model := fitOutput(ctx, h.GetModel())
 
 	// Convert messages to OpenAI format
 	openAIMessages, err := convertToOpenAIMessages(systemPrompt, messages)
@@ -205,6 +207,9 @@ func (h *DeepSeekHandler) processStreamResponse(resp *http.Response, streamChan
 			if choice.Delta != nil && choice.Delta.Content != "" {
 				streamChan <- llm.ApiStreamTextChunk{Text: choice.Delta.Content}
 			}
+			if choice.Delta != nil {
+				sendToolCallDeltas(streamChan, choice.Delta.ToolCalls)
+			}
 
 			if choice.FinishReason != nil && *choice.FinishReason != "" {
 				// Send usage information if available
@@ -214,6 +219,7 @@ func (h *DeepSeekHandler) processStreamResponse(resp *http.Response, streamChan
 						OutputTokens: streamEvent.Usage.CompletionTokens,
 					}
 				}
+				sendFinish(streamChan, choice.FinishReason)
 			}
 		}
 	}
@@ -0,0 +1,189 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+	"github.com/entrepeneur4lyf/codeforge/internal/httpclient"
+)
+
+// LocalServer is an OpenAI-compatible inference server running on this machine
+type LocalServer struct {
+	ID      string   // Provider ID, also the model ID prefix
+	Name    string   // Display name
+	BaseURL string   // OpenAI-compatible base URL
+	APIKey  string   // Sent as a bearer token when set
+	Models  []string // Models the server lists
+}
+
+// localServerCandidates are the default ports of the local servers probed
+// at startup
+var localServerCandidates = []LocalServer{
+	{ID: "lmstudio", Name: "LM Studio", BaseURL: "http://localhost:1234/v1"},
+	{ID: "jan", Name: "Jan", BaseURL: "http://localhost:1337/v1"},
+	{ID: "llamacpp", Name: "llama.cpp", BaseURL: "http://localhost:8080/v1"},
+	{ID: "ollama", Name: "Ollama", BaseURL: "http://localhost:11434/v1"},
+}
+
+var (
+	discoveryOnce sync.Once
+	discoveryDone = make(chan struct{})
+	discovered    []LocalServer
+)
+
+// StartLocalDiscovery probes for local servers in the background. Only the
+// first call probes; LocalServers waits for the result.
+func StartLocalDiscovery() {
+	discoveryOnce.Do(func() {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+			defer cancel()
+
+			discovered = DiscoverLocalServers(ctx)
+			close(discoveryDone)
+		}()
+	})
+}
+
+// LocalServers returns the local servers found at startup, probing now if
+// discovery hasn't been started
+func LocalServers() []LocalServer {
+	StartLocalDiscovery()
+	<-discoveryDone
+	return discovered
+}
+
+// LocalServerByID returns the discovered local server with the given ID
+func LocalServerByID(id string) (LocalServer, bool) {
+	for _, server := range LocalServers() {
+		if server.ID == id {
+			return server, true
+		}
+	}
+	return LocalServer{}, false
+}
+
+// ConfiguredLocalServers returns the local servers to look for: LM Studio,
+// Jan, llama.cpp and Ollama on their default ports, or where the providers
+// section of the config puts them, and any OpenAI-compatible server added
+// there with a base URL. Providers disabled in the config are left out.
+func ConfiguredLocalServers() []LocalServer {
+	listed := make(map[string]config.ProviderSpec)
+	for _, spec := range config.ListProviders() {
+		listed[spec.ID] = spec
+	}
+
+	var servers []LocalServer
+	for _, candidate := range localServerCandidates {
+		spec, ok := listed[candidate.ID]
+		if !ok {
+			continue
+		}
+		delete(listed, candidate.ID)
+		switch {
+		case candidate.ID == "ollama":
+			candidate.BaseURL = OllamaEndpoint() + "/v1"
+		case spec.BaseURL != "":
+			candidate.BaseURL = spec.BaseURL
+		}
+		candidate.APIKey = spec.APIKey()
+		servers = append(servers, candidate)
+	}
+
+	// Servers added in the config, in the registry's order
+	for _, spec := range config.ListProviders() {
+		if _, ok := listed[spec.ID]; ok && spec.Local && spec.BaseURL != "" {
+			servers = append(servers, LocalServer{ID: spec.ID, Name: spec.Name, BaseURL: spec.BaseURL, APIKey: spec.APIKey()})
+		}
+	}
+	return servers
+}
+
+// localServerForModel returns the OpenAI-compatible server a model ID's
+// provider prefix names, as "llamacpp/" does, with the model's own name.
+// Ollama has its own API and isn't one of them.
+func localServerForModel(modelID string) (LocalServer, string, bool) {
+	prefix, model, found := strings.Cut(modelID, "/")
+	if !found || prefix == "ollama" {
+		return LocalServer{}, "", false
+	}
+	for _, server := range ConfiguredLocalServers() {
+		if server.ID == prefix {
+			return server, model, true
+		}
+	}
+	return LocalServer{}, "", false
+}
+
+// DiscoverLocalServers probes the configured local servers and returns
+// those that answer with a model list
+func DiscoverLocalServers(ctx context.Context) []LocalServer {
+	candidates := ConfiguredLocalServers()
+	results := make([]*LocalServer, len(candidates))
+
+	var wg sync.WaitGroup
+	for i, candidate := range candidates {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			models, err := listOpenAICompatibleModels(ctx, candidate.ID, candidate.BaseURL, candidate.APIKey)
+			if err != nil {
+				return
+			}
+			candidate.Models = models
+			results[i] = &candidate
+		}()
+	}
+	wg.Wait()
+
+	var servers []LocalServer
+	for _, server := range results {
+		if server != nil {
+			servers = append(servers, *server)
+		}
+	}
+	return servers
+}
+
+// listOpenAICompatibleModels lists the models of an OpenAI-compatible server
+func listOpenAICompatibleModels(ctx context.Context, provider, baseURL, apiKey string) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", strings.TrimSuffix(baseURL, "/")+"/models", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+
+	client := httpclient.New(provider, time.Second)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned status %d", baseURL, resp.StatusCode)
+	}
+
+	var list struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, fmt.Errorf("failed to decode model list: %w", err)
+	}
+
+	models := make([]string, 0, len(list.Data))
+	for _, m := range list.Data {
+		models = append(models, m.ID)
+	}
+	return models, nil
+}
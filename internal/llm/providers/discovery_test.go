@@ -0,0 +1,150 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+	"github.com/entrepeneur4lyf/codeforge/internal/models"
+)
+
+func TestDiscoverLocalServers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/models" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"object":"list","data":[{"id":"qwen2.5-coder-7b"},{"id":"nomic-embed-text"}]}`)
+	}))
+	defer server.Close()
+
+	// A server that isn't running
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	saved := localServerCandidates
+	defer func() { localServerCandidates = saved }()
+	localServerCandidates = []LocalServer{
+		{ID: "lmstudio", Name: "LM Studio", BaseURL: server.URL + "/v1"},
+		{ID: "jan", Name: "Jan", BaseURL: closed.URL + "/v1"},
+	}
+
+	servers := DiscoverLocalServers(context.Background())
+	if len(servers) != 1 {
+		t.Fatalf("found %d servers, want 1: %+v", len(servers), servers)
+	}
+	if servers[0].ID != "lmstudio" || len(servers[0].Models) != 2 || servers[0].Models[0] != "qwen2.5-coder-7b" {
+		t.Errorf("unexpected server %+v", servers[0])
+	}
+}
+
+func TestJanModelPrefix(t *testing.T) {
+	provider, err := determineProviderType(llm.ApiHandlerOptions{ModelID: "jan/llama3.2-3b-instruct"})
+	if err != nil || provider != llm.ProviderJan {
+		t.Fatalf("determineProviderType() = %s, %v; want jan", provider, err)
+	}
+
+	handler, err := BuildApiHandler(llm.ApiHandlerOptions{ModelID: "jan/llama3.2-3b-instruct"})
+	if err != nil {
+		t.Fatalf("BuildApiHandler() error = %v", err)
+	}
+	adapted, ok := handler.(*adaptedHandler)
+	if !ok {
+		t.Fatalf("handler is %T, want the request adapter", handler)
+	}
+	lmstudio, ok := adapted.ApiHandler.(*LMStudioHandler)
+	if !ok {
+		t.Fatalf("handler is %T, want *LMStudioHandler", handler)
+	}
+	if lmstudio.baseURL != "http://localhost:1337/v1" || lmstudio.GetModel().ID != "llama3.2-3b-instruct" {
+		t.Errorf("handler base URL %s model %s", lmstudio.baseURL, lmstudio.GetModel().ID)
+	}
+}
+
+func TestConfiguredLocalServer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/models" || r.Header.Get("Authorization") != "Bearer vk" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"object":"list","data":[{"id":"qwen2.5-coder-32b"}]}`)
+	}))
+	defer server.Close()
+
+	cfg, err := config.Load(t.TempDir(), false)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	saved := cfg.Providers
+	t.Cleanup(func() { cfg.Providers = saved })
+	cfg.Providers = map[models.ModelProvider]config.Provider{
+		"vllm": {Name: "vLLM", BaseURL: server.URL + "/v1", APIKey: "vk"},
+	}
+
+	var found *LocalServer
+	for _, s := range DiscoverLocalServers(context.Background()) {
+		if s.ID == "vllm" {
+			found = &s
+		}
+	}
+	if found == nil || found.Name != "vLLM" || len(found.Models) != 1 || found.Models[0] != "qwen2.5-coder-32b" {
+		t.Fatalf("configured server not discovered: %+v", found)
+	}
+
+	handler, err := BuildApiHandler(llm.ApiHandlerOptions{ModelID: "vllm/qwen2.5-coder-32b", APIKey: "local"})
+	if err != nil {
+		t.Fatalf("BuildApiHandler() error = %v", err)
+	}
+	// The loaded config turns on usage tracking, which wraps the adapter
+	if usage, ok := handler.(*usageHandler); ok {
+		handler = usage.ApiHandler
+	}
+	local, ok := handler.(*adaptedHandler).ApiHandler.(*LMStudioHandler)
+	if !ok {
+		t.Fatalf("handler is %T, want *LMStudioHandler", handler)
+	}
+	if local.baseURL != server.URL+"/v1" || local.options.APIKey != "vk" || local.GetModel().ID != "qwen2.5-coder-32b" {
+		t.Errorf("handler base URL %s key %s model %s", local.baseURL, local.options.APIKey, local.GetModel().ID)
+	}
+}
+
+func TestProbeTargets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	cfg, err := config.Load(t.TempDir(), false)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	savedProvider, savedProviders := cfg.Provider, cfg.Providers
+	t.Cleanup(func() { cfg.Provider, cfg.Providers = savedProvider, savedProviders })
+
+	// An intranet endpoint is probed instead of the public one
+	cfg.Provider = "openai"
+	cfg.Providers = map[models.ModelProvider]config.Provider{"openai": {BaseURL: server.URL + "/v1"}}
+	targets := probeTargets()
+	if len(targets) != 1 || targets["openai"] != server.URL+"/v1" {
+		t.Fatalf("probeTargets() = %v", targets)
+	}
+	if !probeEndpoints(targets) {
+		t.Error("probeEndpoints() = false for an endpoint answering 404")
+	}
+
+	// A local provider leaves the cloud providers to probe
+	cfg.Provider = "ollama"
+	cfg.Providers = nil
+	if targets := probeTargets(); len(targets) != len(cloudEndpoints) || targets["anthropic"] != cloudEndpoints["anthropic"] {
+		t.Errorf("probeTargets() = %v", targets)
+	}
+
+	server.Close()
+	if probeEndpoints(map[string]string{"openai": server.URL}) {
+		t.Error("probeEndpoints() = true for a closed server")
+	}
+}
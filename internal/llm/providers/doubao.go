@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/entrepeneur4lyf/codeforge/internal/httpclient"
 	"github.com/entrepeneur4lyf/codeforge/internal/llm"
 	"github.com/entrepeneur4lyf/codeforge/internal/llm/transform"
 )
@@ -58,8 +59,9 @@ type DoubaoChoice struct {
 
 // DoubaoDelta represents incremental content in streaming
 type DoubaoDelta struct {
-	Role    string `json:"role,omitempty"`
-	Content string `json:"content,omitempty"`
+	Role      string                     `json:"role,omitempty"`
+	Content   string                     `json:"content,omitempty"`
+	ToolCalls []transform.OpenAIToolCall `json:"tool_calls,omitempty"`
 }
 
 // DoubaoMessage represents a complete message
@@ -90,16 +92,14 @@ func NewDoubaoHandler(options llm.ApiHandlerOptions) *DoubaoHandler {
 
 	return &DoubaoHandler{
 		options: options,
-		client: &http.Client{
-			Timeout: timeout,
-		},
+		client:  httpclient.New("doubao", timeout),
 		baseURL: baseURL,
 	}
 }
 
 // CreateMessage implements the ApiHandler interface
 func (h *DoubaoHandler) CreateMessage(ctx context.Context, systemPrompt string, messages []llm.Message) (llm.ApiStream, error) {
-	model := h.GetModel()
+	model := fitOutput(ctx, h.GetModel())
 
 	// Convert messages to OpenAI format
 	openAIMessages, err := convertToOpenAIMessages(systemPrompt, messages)
@@ -269,6 +269,9 @@ func (h *DoubaoHandler) processStream(reader io.Reader, streamChan chan<- llm.Ap
 					Text: choice.Delta.Content,
 				}
 			}
+			if choice.Delta != nil {
+				sendToolCallDeltas(streamChan, choice.Delta.ToolCalls)
+			}
 
 			// Handle finish reason and send usage if available
 			if choice.FinishReason != nil && *choice.FinishReason != "" {
@@ -278,6 +281,7 @@ func (h *DoubaoHandler) processStream(reader io.Reader, streamChan chan<- llm.Ap
 						OutputTokens: streamEvent.Usage.CompletionTokens,
 					}
 				}
+				sendFinish(streamChan, choice.FinishReason)
 			}
 		}
 	}
@@ -5,7 +5,9 @@ import (
 	"os"
 	"strings"
 
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
 	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+	"github.com/entrepeneur4lyf/codeforge/internal/llm/wirelog"
 	"github.com/entrepeneur4lyf/codeforge/internal/models"
 	"github.com/entrepeneur4lyf/codeforge/internal/llm/transform"
 )
@@ -19,6 +21,32 @@ func BuildApiHandler(options llm.ApiHandlerOptions) (llm.ApiHandler, error) {
 		return nil, fmt.Errorf("failed to determine provider type: %w", err)
 	}
 
+	// OpenAI-compatible local servers take requests at their base URL,
+	// configured or found on their default port
+	if server, model, ok := localServerForModel(options.ModelID); ok {
+		options.ModelID = model
+		if options.OpenAIBaseURL == "" {
+			options.OpenAIBaseURL = server.BaseURL
+		}
+		if server.APIKey != "" {
+			options.APIKey = server.APIKey
+		}
+	}
+
+	// Local and native providers take the bare model name
+	options.ModelID = strings.TrimPrefix(strings.TrimPrefix(options.ModelID, "ollama/"), "lmstudio/")
+	options.ModelID = strings.TrimPrefix(options.ModelID, "jan/")
+	if prefix, _, ok := nativeModelPrefix(options.ModelID); ok {
+		options.ModelID = strings.TrimPrefix(options.ModelID, prefix)
+	}
+
+	if config.IsLocalOnly() && !isLocalProvider(providerType) {
+		return nil, config.LocalOnlyError(fmt.Sprintf("model %s (provider %s)", options.ModelID, providerType))
+	}
+	if err := config.CheckModelApproved(string(providerType), options.ModelID); err != nil {
+		return nil, err
+	}
+
 	// Get model information from registry
 	registry := models.NewModelRegistry()
 	if canonicalModel, exists := registry.GetModelByProvider(models.ProviderID(providerType), options.ModelID); exists {
@@ -59,6 +87,14 @@ func BuildApiHandler(options llm.ApiHandlerOptions) (llm.ApiHandler, error) {
 		handler = NewOllamaHandler(options)
 	case llm.ProviderLMStudio:
 		handler = NewLMStudioHandler(options)
+	case llm.ProviderJan:
+		// Jan serves the same OpenAI-compatible API as LM Studio
+		if options.OpenAIBaseURL == "" {
+			options.OpenAIBaseURL = "http://localhost:1337/v1"
+		}
+		handler = NewLMStudioHandler(options)
+	case llm.ProviderLocalAPI:
+		handler = NewLMStudioHandler(options)
 	case llm.ProviderXAI:
 		handler = NewXAIHandler(options)
 	case llm.ProviderMistral:
@@ -85,10 +121,34 @@ func BuildApiHandler(options llm.ApiHandlerOptions) (llm.ApiHandler, error) {
 		handler = NewGeminiHandler(options)
 	case llm.ProviderGitHub:
 		handler = NewGitHubHandler(options)
+	case llm.ProviderCopilot:
+		handler = NewCopilotHandler(options)
+	case llm.ProviderMock:
+		handler = NewMockHandler(options)
 	default:
 		return nil, fmt.Errorf("unsupported provider type: %s", providerType)
 	}
 
+	// Place the system prompt and re-encode history for the model
+	handler = wrapWithAdapter(handler, providerType, options.ModelID)
+
+	// Record requests and responses for replay when the wire log is enabled.
+	// It sits inside the outbound filter, so it records what's sent.
+	if cfg := config.Get(); cfg != nil && cfg.WireLog.Enabled {
+		handler = wirelog.Wrap(handler, string(providerType), cfg.WireLogDir(), options.APIKey)
+	}
+
+	// Apply the outbound data filter to cloud providers
+	handler, err = wrapWithOutboundFilter(handler, providerType, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure outbound filter: %w", err)
+	}
+
+	// Feed the cost tracker behind budgets and budget alerts
+	if cfg := config.Get(); cfg != nil && cfg.CostTracker != nil {
+		handler = trackUsage(handler, providerType, cfg.CostTracker)
+	}
+
 	// Wrap with retry logic if enabled
 	if options.OnRetryAttempt != nil {
 		retryHandler := llm.NewRetryHandler(llm.DefaultRetryOptions)
@@ -111,6 +171,26 @@ func BuildApiHandlerWithRetry(options llm.ApiHandlerOptions, retryOptions llm.Re
 
 // determineProviderType determines the provider type from options
 func determineProviderType(options llm.ApiHandlerOptions) (llm.ProviderType, error) {
+	// Explicit local provider prefixes
+	if strings.HasPrefix(options.ModelID, "ollama/") {
+		return llm.ProviderOllama, nil
+	}
+	if strings.HasPrefix(options.ModelID, "lmstudio/") {
+		return llm.ProviderLMStudio, nil
+	}
+	if strings.HasPrefix(options.ModelID, "jan/") {
+		return llm.ProviderJan, nil
+	}
+	if _, _, ok := localServerForModel(options.ModelID); ok {
+		return llm.ProviderLocalAPI, nil
+	}
+	if options.ModelID == "mock" || strings.HasPrefix(options.ModelID, "mock/") {
+		return llm.ProviderMock, nil
+	}
+	if _, provider, ok := nativeModelPrefix(options.ModelID); ok {
+		return provider, nil
+	}
+
 	// Check for explicit provider configuration
 	if options.AnthropicBaseURL != "" || isAnthropicModel(options.ModelID) {
 		return llm.ProviderAnthropic, nil
@@ -169,6 +249,28 @@ func determineProviderType(options llm.ApiHandlerOptions) (llm.ProviderType, err
 	return "", fmt.Errorf("could not determine provider type from options")
 }
 
+// nativeModelPrefixes are the "provider/" prefixes that select a provider's
+// own API. "deepseek/" is also used by OpenRouter; like the API key lookup,
+// it is treated as DeepSeek's native API.
+var nativeModelPrefixes = map[string]llm.ProviderType{
+	"xai/":      llm.ProviderXAI,
+	"mistral/":  llm.ProviderMistral,
+	"deepseek/": llm.ProviderDeepSeek,
+	"together/": llm.ProviderTogether,
+	"groq/":     llm.ProviderGroq,
+	"copilot/":  llm.ProviderCopilot,
+}
+
+// nativeModelPrefix returns the native provider prefix of a model ID
+func nativeModelPrefix(modelID string) (string, llm.ProviderType, bool) {
+	for prefix, provider := range nativeModelPrefixes {
+		if strings.HasPrefix(modelID, prefix) {
+			return prefix, provider, true
+		}
+	}
+	return "", "", false
+}
+
 // isAnthropicModel checks if a model ID belongs to Anthropic
 func isAnthropicModel(modelID string) bool {
 	anthropicPrefixes := []string{
@@ -0,0 +1,47 @@
+package providers
+
+import (
+	"testing"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+)
+
+func TestNativeModelPrefixes(t *testing.T) {
+	// Keys for other providers must not steal explicitly prefixed models
+	t.Setenv("GROQ_API_KEY", "test")
+
+	tests := []struct {
+		modelID  string
+		provider llm.ProviderType
+		bare     string
+	}{
+		{"xai/grok-3", llm.ProviderXAI, "grok-3"},
+		{"mistral/mistral-large-latest", llm.ProviderMistral, "mistral-large-latest"},
+		{"deepseek/deepseek-chat", llm.ProviderDeepSeek, "deepseek-chat"},
+		{"together/meta-llama/Llama-3.3-70B-Instruct-Turbo", llm.ProviderTogether, "meta-llama/Llama-3.3-70B-Instruct-Turbo"},
+		{"groq/llama-3.3-70b-versatile", llm.ProviderGroq, "llama-3.3-70b-versatile"},
+		{"copilot/gpt-4o", llm.ProviderCopilot, "gpt-4o"},
+	}
+
+	for _, tt := range tests {
+		provider, err := determineProviderType(llm.ApiHandlerOptions{ModelID: tt.modelID})
+		if err != nil {
+			t.Fatalf("determineProviderType(%q) error = %v", tt.modelID, err)
+		}
+		if provider != tt.provider {
+			t.Errorf("determineProviderType(%q) = %s, want %s", tt.modelID, provider, tt.provider)
+		}
+
+		handler, err := BuildApiHandler(llm.ApiHandlerOptions{ModelID: tt.modelID, APIKey: "test"})
+		if err != nil {
+			t.Fatalf("BuildApiHandler(%q) error = %v", tt.modelID, err)
+		}
+		if got := handler.GetModel().ID; got != tt.bare {
+			t.Errorf("BuildApiHandler(%q) model = %q, want %q", tt.modelID, got, tt.bare)
+		}
+	}
+
+	if _, _, ok := nativeModelPrefix("mistralai/mistral-large"); ok {
+		t.Error("OpenRouter's mistralai/ prefix treated as native Mistral")
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/entrepeneur4lyf/codeforge/internal/httpclient"
 	"github.com/entrepeneur4lyf/codeforge/internal/llm"
 	"github.com/entrepeneur4lyf/codeforge/internal/llm/transform"
 )
@@ -58,8 +59,9 @@ type FireworksChoice struct {
 
 // FireworksDelta represents incremental content in streaming
 type FireworksDelta struct {
-	Role    string `json:"role,omitempty"`
-	Content string `json:"content,omitempty"`
+	Role      string                     `json:"role,omitempty"`
+	Content   string                     `json:"content,omitempty"`
+	ToolCalls []transform.OpenAIToolCall `json:"tool_calls,omitempty"`
 }
 
 // FireworksMessage represents a complete message
@@ -90,16 +92,14 @@ func NewFireworksHandler(options llm.ApiHandlerOptions) *FireworksHandler {
 
 	return &FireworksHandler{
 		options: options,
-		client: &http.Client{
-			Timeout: timeout,
-		},
+		client:  httpclient.New("fireworks", timeout),
 		baseURL: baseURL,
 	}
 }
 
 // CreateMessage implements the ApiHandler interface
 func (h *FireworksHandler) CreateMessage(ctx context.Context, systemPrompt string, messages []llm.Message) (llm.ApiStream, error) {
-	model := h.GetModel()
+	model := fitOutput(ctx, h.GetModel())
 
 	// Convert messages to OpenAI format
 	openAIMessages, err := convertToOpenAIMessages(systemPrompt, messages)
@@ -275,6 +275,9 @@ func (h *FireworksHandler) processStream(reader io.Reader, streamChan chan<- llm
 					Text: choice.Delta.Content,
 				}
 			}
+			if choice.Delta != nil {
+				sendToolCallDeltas(streamChan, choice.Delta.ToolCalls)
+			}
 
 			// Handle finish reason and send usage if available
 			if choice.FinishReason != nil && *choice.FinishReason != "" {
@@ -284,6 +287,7 @@ func (h *FireworksHandler) processStream(reader io.Reader, streamChan chan<- llm
 						OutputTokens: streamEvent.Usage.CompletionTokens,
 					}
 				}
+				sendFinish(streamChan, choice.FinishReason)
 			}
 		}
 	}
@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/entrepeneur4lyf/codeforge/internal/httpclient"
 	"github.com/entrepeneur4lyf/codeforge/internal/llm"
 	"github.com/entrepeneur4lyf/codeforge/internal/models"
 )
@@ -39,9 +40,16 @@ type GeminiContent struct {
 
 // GeminiPart represents different types of content parts
 type GeminiPart struct {
-	Text       string             `json:"text,omitempty"`
-	InlineData *GeminiInlineData  `json:"inlineData,omitempty"`
-	Thought    *GeminiThoughtPart `json:"thought,omitempty"`
+	Text         string              `json:"text,omitempty"`
+	InlineData   *GeminiInlineData   `json:"inlineData,omitempty"`
+	Thought      *GeminiThoughtPart  `json:"thought,omitempty"`
+	FunctionCall *GeminiFunctionCall `json:"functionCall,omitempty"`
+}
+
+// GeminiFunctionCall represents a tool call, which Gemini streams whole
+type GeminiFunctionCall struct {
+	Name string          `json:"name"`
+	Args json.RawMessage `json:"args,omitempty"`
 }
 
 // GeminiInlineData represents inline image data
@@ -114,7 +122,7 @@ func NewGeminiHandler(options llm.ApiHandlerOptions) *GeminiHandler {
 
 	return &GeminiHandler{
 		options:  options,
-		client:   &http.Client{Timeout: timeout},
+		client:   httpclient.New("gemini", timeout),
 		baseURL:  baseURL,
 		isVertex: isVertex,
 	}
@@ -122,7 +130,7 @@ func NewGeminiHandler(options llm.ApiHandlerOptions) *GeminiHandler {
 
 // CreateMessage implements the ApiHandler interface
 func (h *GeminiHandler) CreateMessage(ctx context.Context, systemPrompt string, messages []llm.Message) (llm.ApiStream, error) {
-	model := h.GetModel()
+	model := fitOutput(ctx, h.GetModel())
 
 	// Convert messages to Gemini format
 	geminiContents, err := h.convertMessages(messages)
@@ -372,6 +380,7 @@ func (h *GeminiHandler) streamRequest(ctx context.Context, request GeminiRequest
 func (h *GeminiHandler) processStream(reader io.Reader, streamChan chan<- llm.ApiStreamChunk) {
 	decoder := json.NewDecoder(reader)
 
+	toolCalls := 0
 	for {
 		var response GeminiStreamResponse
 		if err := decoder.Decode(&response); err != nil {
@@ -394,8 +403,19 @@ func (h *GeminiHandler) processStream(reader io.Reader, streamChan chan<- llm.Ap
 					if part.Thought != nil && part.Thought.Text != "" {
 						streamChan <- llm.ApiStreamReasoningChunk{Reasoning: part.Thought.Text}
 					}
+
+					// Handle tool calls
+					if part.FunctionCall != nil {
+						streamChan <- llm.ApiStreamToolCallDeltaChunk{
+							Index:     toolCalls,
+							Name:      part.FunctionCall.Name,
+							Arguments: string(part.FunctionCall.Args),
+						}
+						toolCalls++
+					}
 				}
 			}
+			sendFinish(streamChan, &candidate.FinishReason)
 		}
 
 		// Handle usage information
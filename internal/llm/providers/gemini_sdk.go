@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/entrepeneur4lyf/codeforge/internal/httpclient"
 	"github.com/entrepeneur4lyf/codeforge/internal/llm"
 	"google.golang.org/genai"
 )
@@ -45,7 +46,8 @@ func (h *GeminiSDKHandler) CreateMessage(ctx context.Context, systemPrompt strin
 	if h.client == nil {
 		// Create client config with API key
 		config := &genai.ClientConfig{
-			APIKey: h.options.APIKey,
+			APIKey:     h.options.APIKey,
+			HTTPClient: httpclient.New("gemini", 0),
 		}
 
 		// Determine backend based on configuration
@@ -94,7 +96,7 @@ func (h *GeminiSDKHandler) CreateMessage(ctx context.Context, systemPrompt strin
 	// Create generation config
 	config := &genai.GenerateContentConfig{
 		Temperature:     genai.Ptr(float32(0.7)),
-		MaxOutputTokens: 4096,
+		MaxOutputTokens: int32(fitOutput(ctx, h.GetModel()).Info.MaxTokens),
 	}
 
 	// Set system instruction if provided
@@ -114,6 +116,7 @@ func (h *GeminiSDKHandler) CreateMessage(ctx context.Context, systemPrompt strin
 	go func() {
 		defer close(responseChan)
 
+		toolCalls := 0
 		for result, err := range iter {
 			if err != nil {
 				// Log error but don't send error chunk as it's not defined in our interface
@@ -121,14 +124,28 @@ func (h *GeminiSDKHandler) CreateMessage(ctx context.Context, systemPrompt strin
 				return
 			}
 
-			// Extract text from response
+			// Extract text and tool calls from response
 			if len(result.Candidates) > 0 && result.Candidates[0].Content != nil {
 				for _, part := range result.Candidates[0].Content.Parts {
 					if part.Text != "" {
 						responseChan <- llm.ApiStreamTextChunk{Text: part.Text}
 					}
+					if call := part.FunctionCall; call != nil {
+						args, _ := json.Marshal(call.Args)
+						responseChan <- llm.ApiStreamToolCallDeltaChunk{
+							Index:     toolCalls,
+							ID:        call.ID,
+							Name:      call.Name,
+							Arguments: string(args),
+						}
+						toolCalls++
+					}
 				}
 			}
+			if len(result.Candidates) > 0 {
+				finishReason := string(result.Candidates[0].FinishReason)
+				sendFinish(responseChan, &finishReason)
+			}
 		}
 
 		// Send final usage information
@@ -173,7 +190,7 @@ func GetGeminiModels(ctx context.Context, apiKey string) ([]GeminiModelInfo, err
 
 // getCachedGeminiModels returns cached models if available and fresh
 func getCachedGeminiModels(_ context.Context, _ string, forceRefresh bool) ([]GeminiModelInfo, error) {
-	cacheDir := filepath.Join(os.Getenv("HOME"), ".codeforge", "cache")
+	cacheDir := catalogCacheDir()
 	cacheFile := filepath.Join(cacheDir, "gemini_models.json")
 
 	// Check cache first (unless force refresh)
@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/entrepeneur4lyf/codeforge/internal/httpclient"
 	"github.com/entrepeneur4lyf/codeforge/internal/llm"
 	"github.com/entrepeneur4lyf/codeforge/internal/models"
 	"github.com/entrepeneur4lyf/codeforge/internal/llm/transform"
@@ -103,7 +104,7 @@ func NewGitHubHandler(options llm.ApiHandlerOptions) *GitHubHandler {
 
 	return &GitHubHandler{
 		options: options,
-		client:  &http.Client{Timeout: timeout},
+		client:  httpclient.New("github", timeout),
 		baseURL: baseURL,
 		orgMode: orgMode,
 	}
@@ -111,7 +112,7 @@ func NewGitHubHandler(options llm.ApiHandlerOptions) *GitHubHandler {
 
 // CreateMessage implements the ApiHandler interface
 func (h *GitHubHandler) CreateMessage(ctx context.Context, systemPrompt string, messages []llm.Message) (llm.ApiStream, error) {
-	model := h.GetModel()
+	model := fitOutput(ctx, h.GetModel())
 
 	// Convert messages to OpenAI format (GitHub Models uses OpenAI-compatible format)
 	openAIMessages, err := h.convertMessages(systemPrompt, messages)
@@ -358,8 +359,8 @@ func (h *GitHubHandler) processStream(reader io.Reader, streamChan chan<- llm.Ap
 	for scanner.Scan() {
 		event := scanner.Event()
 
-		// Skip non-data events
-		if event.Type != "data" {
+		// OpenAI-style streams send bare data lines; skip other named events
+		if event.Type != "" && event.Type != "data" {
 			continue
 		}
 
@@ -380,6 +381,10 @@ func (h *GitHubHandler) processStream(reader io.Reader, streamChan chan<- llm.Ap
 			if choice.Delta.Content != "" {
 				streamChan <- llm.ApiStreamTextChunk{Text: choice.Delta.Content}
 			}
+
+			// Handle tool call fragments and the finish reason
+			sendToolCallDeltas(streamChan, choice.Delta.ToolCalls)
+			sendFinish(streamChan, choice.FinishReason)
 		}
 
 		// Handle usage information
@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/entrepeneur4lyf/codeforge/internal/httpclient"
 	"github.com/entrepeneur4lyf/codeforge/internal/llm"
 	"github.com/entrepeneur4lyf/codeforge/internal/models"
 	"github.com/entrepeneur4lyf/codeforge/internal/llm/transform"
@@ -104,14 +105,14 @@ func NewGroqHandler(options llm.ApiHandlerOptions) *GroqHandler {
 
 	return &GroqHandler{
 		options: options,
-		client:  &http.Client{Timeout: timeout},
+		client:  httpclient.New("groq", timeout),
 		baseURL: baseURL,
 	}
 }
 
 // CreateMessage implements the ApiHandler interface
 func (h *GroqHandler) CreateMessage(ctx context.Context, systemPrompt string, messages []llm.Message) (llm.ApiStream, error) {
-	model := h.GetModel()
+	model := fitOutput(ctx, h.GetModel())
 
 	// Convert messages to OpenAI format (Groq uses OpenAI-compatible format)
 	openAIMessages, err := h.convertMessages(systemPrompt, messages)
@@ -394,6 +395,10 @@ func (h *GroqHandler) processStream(reader io.Reader, streamChan chan<- llm.ApiS
 			if choice.Delta.Content != "" {
 				streamChan <- llm.ApiStreamTextChunk{Text: choice.Delta.Content}
 			}
+
+			// Handle tool call fragments and the finish reason
+			sendToolCallDeltas(streamChan, choice.Delta.ToolCalls)
+			sendFinish(streamChan, choice.FinishReason)
 		}
 
 		// Handle usage information with Groq-specific timing data
@@ -0,0 +1,118 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+)
+
+// maxReplayedToolResult caps a tool result replayed as text; what a tool
+// returned turns ago rarely matters in full
+const maxReplayedToolResult = 2000
+
+// textOnlyHistory reports whether the provider's handler sends only the
+// text and images of past messages, dropping tool calls and results
+func textOnlyHistory(provider llm.ProviderType) bool {
+	switch provider {
+	case llm.ProviderAnthropic, llm.ProviderOpenAI, llm.ProviderGemini, llm.ProviderOpenRouter,
+		llm.ProviderBedrock, llm.ProviderVertex, llm.ProviderGeminiCLI, llm.ProviderMock:
+		return true
+	}
+	return false
+}
+
+// ReplayHistory re-encodes a conversation for a provider. Roles other than
+// user and assistant become user text, and consecutive messages of a role
+// are merged, as Anthropic and Gemini require alternating turns opened by
+// the user. Tool call IDs are renumbered into a form every provider accepts;
+// providers that can't take tool calls in history get them, and their
+// results, as text.
+func ReplayHistory(messages []llm.Message, provider llm.ProviderType) []llm.Message {
+	textOnly := textOnlyHistory(provider)
+	ids := make(map[string]string)
+	toolNames := make(map[string]string)
+
+	var out []llm.Message
+	for _, msg := range messages {
+		role := msg.Role
+		var content []llm.ContentBlock
+		if role != "user" && role != "assistant" {
+			if text := strings.TrimSpace(strings.Join(blockTexts(msg.Content), "\n")); text != "" {
+				content = append(content, llm.TextBlock{Text: fmt.Sprintf("[%s] %s", role, text)})
+			}
+			role = "user"
+		} else {
+			for _, block := range msg.Content {
+				if block = replayBlock(block, textOnly, ids, toolNames); block != nil {
+					content = append(content, block)
+				}
+			}
+		}
+		if len(content) == 0 {
+			continue
+		}
+
+		if n := len(out); n > 0 && out[n-1].Role == role {
+			out[n-1].Content = append(out[n-1].Content, content...)
+			continue
+		}
+		out = append(out, llm.Message{Role: role, Content: content})
+	}
+
+	// History cut to its latest messages may open with a reply, which
+	// Anthropic and Gemini reject
+	if len(out) > 0 && out[0].Role == "assistant" {
+		opener := llm.Message{Role: "user", Content: []llm.ContentBlock{llm.TextBlock{Text: "[Earlier conversation omitted]"}}}
+		out = append([]llm.Message{opener}, out...)
+	}
+	return out
+}
+
+// replayBlock re-encodes one content block, returning nil to drop it
+func replayBlock(block llm.ContentBlock, textOnly bool, ids, toolNames map[string]string) llm.ContentBlock {
+	switch b := block.(type) {
+	case llm.TextBlock:
+		if strings.TrimSpace(b.Text) == "" {
+			return nil
+		}
+		return b
+	case llm.ToolUseBlock:
+		toolNames[b.ID] = b.Name
+		if textOnly {
+			input, _ := json.Marshal(b.Input)
+			return llm.TextBlock{Text: fmt.Sprintf("[Called tool %s with %s]", b.Name, input)}
+		}
+		return llm.ToolUseBlock{ID: replayToolID(ids, b.ID), Name: b.Name, Input: b.Input}
+	case llm.ToolResultBlock:
+		if textOnly {
+			result := strings.Join(blockTexts(b.Content), "\n")
+			if len(result) > maxReplayedToolResult {
+				result = fmt.Sprintf("%s\n...[%d characters omitted]", result[:maxReplayedToolResult], len(result)-maxReplayedToolResult)
+			}
+			label := "Result"
+			if b.IsError {
+				label = "Error"
+			}
+			name := toolNames[b.ToolUseID]
+			if name == "" {
+				name = "tool"
+			}
+			return llm.TextBlock{Text: fmt.Sprintf("[%s of %s]\n%s", label, name, result)}
+		}
+		return llm.ToolResultBlock{ToolUseID: replayToolID(ids, b.ToolUseID), Content: b.Content, IsError: b.IsError}
+	}
+	return block
+}
+
+// replayToolID maps a tool call ID to one of nine alphanumerics, which
+// satisfies the formats of Anthropic, OpenAI and Mistral alike
+func replayToolID(ids map[string]string, id string) string {
+	if replayed, ok := ids[id]; ok {
+		return replayed
+	}
+	replayed := fmt.Sprintf("call%05d", len(ids)+1)
+	ids[id] = replayed
+	return replayed
+}
@@ -0,0 +1,59 @@
+package providers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+)
+
+func text(s string) llm.ContentBlock { return llm.TextBlock{Text: s} }
+
+func TestReplayHistory(t *testing.T) {
+	history := []llm.Message{
+		{Role: "assistant", Content: []llm.ContentBlock{text("Resumed.")}},
+		{Role: "user", Content: []llm.ContentBlock{text("List the files")}},
+		{Role: "assistant", Content: []llm.ContentBlock{llm.ToolUseBlock{ID: "toolu_01A", Name: "ls", Input: map[string]interface{}{"path": "."}}}},
+		{Role: "user", Content: []llm.ContentBlock{llm.ToolResultBlock{ToolUseID: "toolu_01A", Content: []llm.ContentBlock{text(strings.Repeat("x", maxReplayedToolResult+10))}}}},
+		{Role: "user", Content: []llm.ContentBlock{text("  ")}},
+		{Role: "system", Content: []llm.ContentBlock{text("Be brief.")}},
+		{Role: "user", Content: []llm.ContentBlock{text("Which is largest?")}},
+	}
+
+	// Anthropic gets alternating text turns opened by the user
+	replayed := ReplayHistory(history, llm.ProviderAnthropic)
+	var roles []string
+	for _, msg := range replayed {
+		roles = append(roles, msg.Role)
+		for _, block := range msg.Content {
+			if _, ok := block.(llm.TextBlock); !ok {
+				t.Errorf("%s block sent to a text-only provider", block.Type())
+			}
+		}
+	}
+	if want := []string{"user", "assistant", "user", "assistant", "user"}; !reflect.DeepEqual(roles, want) {
+		t.Fatalf("roles = %v, want %v", roles, want)
+	}
+	if got := replayed[3].Content[0].(llm.TextBlock).Text; got != `[Called tool ls with {"path":"."}]` {
+		t.Errorf("tool call = %q", got)
+	}
+	last := replayed[4].Content
+	if len(last) != 3 || !strings.HasPrefix(last[0].(llm.TextBlock).Text, "[Result of ls]\n") ||
+		!strings.HasSuffix(last[0].(llm.TextBlock).Text, "[10 characters omitted]") ||
+		last[1].(llm.TextBlock).Text != "[system] Be brief." {
+		t.Errorf("last turn = %+v", last)
+	}
+
+	// OpenAI-compatible providers keep tool calls, under portable IDs
+	replayed = ReplayHistory(history, llm.ProviderMistral)
+	use := replayed[3].Content[0].(llm.ToolUseBlock)
+	result := replayed[4].Content[0].(llm.ToolResultBlock)
+	if use.ID != "call00001" || result.ToolUseID != use.ID {
+		t.Errorf("tool IDs %q and %q", use.ID, result.ToolUseID)
+	}
+
+	if ReplayHistory(nil, llm.ProviderOpenAI) != nil {
+		t.Error("empty history replayed as messages")
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/entrepeneur4lyf/codeforge/internal/httpclient"
 	"github.com/entrepeneur4lyf/codeforge/internal/llm"
 	"github.com/entrepeneur4lyf/codeforge/internal/llm/transform"
 )
@@ -58,8 +59,9 @@ type LiteLLMChoice struct {
 
 // LiteLLMDelta represents incremental content in streaming
 type LiteLLMDelta struct {
-	Role    string `json:"role,omitempty"`
-	Content string `json:"content,omitempty"`
+	Role      string                     `json:"role,omitempty"`
+	Content   string                     `json:"content,omitempty"`
+	ToolCalls []transform.OpenAIToolCall `json:"tool_calls,omitempty"`
 }
 
 // LiteLLMMessage represents a complete message
@@ -90,16 +92,14 @@ func NewLiteLLMHandler(options llm.ApiHandlerOptions) *LiteLLMHandler {
 
 	return &LiteLLMHandler{
 		options: options,
-		client: &http.Client{
-			Timeout: timeout,
-		},
+		client:  httpclient.New("litellm", timeout),
 		baseURL: baseURL,
 	}
 }
 
 // CreateMessage implements the ApiHandler interface
 func (h *LiteLLMHandler) CreateMessage(ctx context.Context, systemPrompt string, messages []llm.Message) (llm.ApiStream, error) {
-	model := h.GetModel()
+	model := fitOutput(ctx, h.GetModel())
 
 	// Convert messages to OpenAI format
 	openAIMessages, err := convertToOpenAIMessages(systemPrompt, messages)
@@ -285,6 +285,9 @@ func (h *LiteLLMHandler) processStream(reader io.Reader, streamChan chan<- llm.A
 					Text: choice.Delta.Content,
 				}
 			}
+			if choice.Delta != nil {
+				sendToolCallDeltas(streamChan, choice.Delta.ToolCalls)
+			}
 
 			// Handle finish reason and send usage if available
 			if choice.FinishReason != nil && *choice.FinishReason != "" {
@@ -294,6 +297,7 @@ func (h *LiteLLMHandler) processStream(reader io.Reader, streamChan chan<- llm.A
 						OutputTokens: streamEvent.Usage.CompletionTokens,
 					}
 				}
+				sendFinish(streamChan, choice.FinishReason)
 			}
 		}
 	}
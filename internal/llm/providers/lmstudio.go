@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/entrepeneur4lyf/codeforge/internal/httpclient"
 	"github.com/entrepeneur4lyf/codeforge/internal/llm"
 	"github.com/entrepeneur4lyf/codeforge/internal/llm/transform"
 )
@@ -31,6 +32,10 @@ type LMStudioRequest struct {
 	Stream        bool                      `json:"stream"`
 	StreamOptions *LMStudioStreamOptions    `json:"stream_options,omitempty"`
 	User          string                    `json:"user,omitempty"`
+
+	// Structured output; grammar is llama.cpp's GBNF extension
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+	Grammar        string          `json:"grammar,omitempty"`
 }
 
 // LMStudioStreamOptions configures streaming behavior
@@ -58,8 +63,9 @@ type LMStudioChoice struct {
 
 // LMStudioDelta represents incremental content in streaming
 type LMStudioDelta struct {
-	Role    string `json:"role,omitempty"`
-	Content string `json:"content,omitempty"`
+	Role      string                     `json:"role,omitempty"`
+	Content   string                     `json:"content,omitempty"`
+	ToolCalls []transform.OpenAIToolCall `json:"tool_calls,omitempty"`
 }
 
 // LMStudioMessage represents a complete message
@@ -90,16 +96,18 @@ func NewLMStudioHandler(options llm.ApiHandlerOptions) *LMStudioHandler {
 
 	return &LMStudioHandler{
 		options: options,
-		client: &http.Client{
-			Timeout: timeout,
-		},
+		client:  httpclient.New("lmstudio", timeout),
 		baseURL: baseURL,
 	}
 }
 
 // CreateMessage implements the ApiHandler interface
 func (h *LMStudioHandler) CreateMessage(ctx context.Context, systemPrompt string, messages []llm.Message) (llm.ApiStream, error) {
-	model := h.GetModel()
+	model := fitOutput(ctx, h.GetModel())
+
+	if err := checkResponseSchema(h.options); err != nil {
+		return nil, err
+	}
 
 	// Convert messages to OpenAI format
 	openAIMessages, err := convertToOpenAIMessages(systemPrompt, messages)
@@ -115,6 +123,8 @@ func (h *LMStudioHandler) CreateMessage(ctx context.Context, systemPrompt string
 		StreamOptions: &LMStudioStreamOptions{
 			IncludeUsage: true,
 		},
+		ResponseFormat: responseFormat(h.options),
+		Grammar:        h.options.Grammar,
 	}
 
 	// Set max tokens if specified
@@ -287,6 +297,9 @@ func (h *LMStudioHandler) processStream(reader io.Reader, streamChan chan<- llm.
 					Text: choice.Delta.Content,
 				}
 			}
+			if choice.Delta != nil {
+				sendToolCallDeltas(streamChan, choice.Delta.ToolCalls)
+			}
 
 			// Handle finish reason and send usage if available
 			if choice.FinishReason != nil && *choice.FinishReason != "" {
@@ -296,6 +309,7 @@ func (h *LMStudioHandler) processStream(reader io.Reader, streamChan chan<- llm.
 						OutputTokens: streamEvent.Usage.CompletionTokens,
 					}
 				}
+				sendFinish(streamChan, choice.FinishReason)
 			}
 		}
 	}
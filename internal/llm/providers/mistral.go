@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/entrepeneur4lyf/codeforge/internal/httpclient"
 	"github.com/entrepeneur4lyf/codeforge/internal/llm"
 	"github.com/entrepeneur4lyf/codeforge/internal/llm/transform"
 )
@@ -51,8 +52,9 @@ type MistralChoice struct {
 
 // MistralDelta represents delta content
 type MistralDelta struct {
-	Role    string `json:"role,omitempty"`
-	Content string `json:"content,omitempty"`
+	Role      string                     `json:"role,omitempty"`
+	Content   string                     `json:"content,omitempty"`
+	ToolCalls []transform.OpenAIToolCall `json:"tool_calls,omitempty"`
 }
 
 // MistralMessage represents a complete message
@@ -80,14 +82,14 @@ func NewMistralHandler(options llm.ApiHandlerOptions) *MistralHandler {
 
 	return &MistralHandler{
 		options: options,
-		client:  &http.Client{Timeout: timeout},
+		client:  httpclient.New("mistral", timeout),
 		baseURL: baseURL,
 	}
 }
 
 // CreateMessage implements the ApiHandler interface
 func (h *MistralHandler) CreateMessage(ctx context.Context, systemPrompt string, messages []llm.Message) (llm.ApiStream, error) {
-	model := h.GetModel()
+	model := fitOutput(ctx, h.GetModel())
 
 	// Convert messages to OpenAI format
 	openAIMessages, err := convertToOpenAIMessages(systemPrompt, messages)
@@ -201,6 +203,9 @@ func (h *MistralHandler) processStreamResponse(resp *http.Response, streamChan c
 			if choice.Delta != nil && choice.Delta.Content != "" {
 				streamChan <- llm.ApiStreamTextChunk{Text: choice.Delta.Content}
 			}
+			if choice.Delta != nil {
+				sendToolCallDeltas(streamChan, choice.Delta.ToolCalls)
+			}
 
 			if choice.FinishReason != nil && *choice.FinishReason != "" {
 				// Send usage information if available
@@ -210,6 +215,7 @@ func (h *MistralHandler) processStreamResponse(resp *http.Response, streamChan c
 						OutputTokens: streamEvent.Usage.CompletionTokens,
 					}
 				}
+				sendFinish(streamChan, choice.FinishReason)
 			}
 		}
 	}
@@ -0,0 +1,302 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+	"github.com/entrepeneur4lyf/codeforge/internal/llm/wirelog"
+	"github.com/entrepeneur4lyf/codeforge/internal/tokens"
+)
+
+// MockScriptEnv names a script or wire log served by the plain "mock" model
+const MockScriptEnv = "CODEFORGE_MOCK_SCRIPT"
+
+// MockScript is a file of scripted responses for the mock provider
+type MockScript struct {
+	Responses []MockResponse `json:"responses"`
+}
+
+// MockResponse is one scripted response. Responses with a Match pattern are
+// served when it matches the last user message; the others are served in
+// order, cycling, for requests that match nothing.
+type MockResponse struct {
+	Match        string                   `json:"match,omitempty"`
+	Text         string                   `json:"text"`
+	Reasoning    string                   `json:"reasoning,omitempty"`
+	ToolCalls    []llm.StreamToolCall     `json:"toolCalls,omitempty"`
+	FinishReason string                   `json:"finishReason,omitempty"` // Defaults to tool_calls or stop
+	Error        string                   `json:"error,omitempty"`
+	Usage        *llm.ApiStreamUsageChunk `json:"usage,omitempty"`
+
+	re     *regexp.Regexp
+	chunks []llm.ApiStreamChunk // Recorded chunks when loaded from a wire log
+}
+
+// MockHandler implements the ApiHandler interface with deterministic scripted
+// or recorded responses, for tests and demos without network access.
+//
+// Model IDs:
+//
+//	mock                  echo the last user message (or CODEFORGE_MOCK_SCRIPT)
+//	mock/echo             echo the last user message
+//	mock/<file>.json      serve a MockScript
+//	mock/<file>.jsonl     replay a wire log
+//	mock/<dir>            replay every wire log in a directory, oldest first
+type MockHandler struct {
+	options llm.ApiHandlerOptions
+	source  string
+
+	once      sync.Once
+	loadErr   error
+	responses []MockResponse
+
+	mu    sync.Mutex
+	next  int
+	usage *llm.ApiStreamUsageChunk
+}
+
+// NewMockHandler creates a new mock handler
+func NewMockHandler(options llm.ApiHandlerOptions) *MockHandler {
+	source := strings.TrimPrefix(strings.TrimPrefix(options.ModelID, "mock"), "/")
+	if source == "" {
+		source = os.Getenv(MockScriptEnv)
+	}
+	if source == "" {
+		source = "echo"
+	}
+	return &MockHandler{options: options, source: source}
+}
+
+// CreateMessage streams the scripted response for the request
+func (h *MockHandler) CreateMessage(ctx context.Context, systemPrompt string, messages []llm.Message) (llm.ApiStream, error) {
+	h.once.Do(func() { h.loadErr = h.load() })
+	if h.loadErr != nil {
+		return nil, h.loadErr
+	}
+
+	prompt := lastUserText(messages)
+	resp := h.pick(prompt)
+	if resp.Error != "" {
+		return nil, fmt.Errorf("mock provider: %s", resp.Error)
+	}
+
+	chunks := resp.chunks
+	if chunks == nil {
+		chunks = scriptedChunks(resp, systemPrompt, messages)
+	}
+
+	stream := make(chan llm.ApiStreamChunk)
+	go func() {
+		defer close(stream)
+		for _, chunk := range chunks {
+			if usage, ok := chunk.(llm.ApiStreamUsageChunk); ok {
+				h.mu.Lock()
+				h.usage = &usage
+				h.mu.Unlock()
+			}
+			select {
+			case stream <- chunk:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return stream, nil
+}
+
+// GetModel returns the mock model info
+func (h *MockHandler) GetModel() llm.ModelResponse {
+	return llm.ModelResponse{
+		ID: "mock/" + h.source,
+		Info: llm.ModelInfo{
+			MaxTokens:      8192,
+			ContextWindow:  200000,
+			SupportsImages: true,
+			Description:    "Deterministic mock provider",
+		},
+	}
+}
+
+// GetApiStreamUsage returns the usage of the last response
+func (h *MockHandler) GetApiStreamUsage() (*llm.ApiStreamUsageChunk, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.usage, nil
+}
+
+// load reads the responses for the handler's source
+func (h *MockHandler) load() error {
+	if h.source == "echo" {
+		return nil
+	}
+
+	info, err := os.Stat(h.source)
+	if err != nil {
+		return fmt.Errorf("mock provider: failed to open script %s: %w", h.source, err)
+	}
+
+	var logs []string
+	switch {
+	case info.IsDir():
+		if logs, err = wirelog.List(h.source); err != nil {
+			return fmt.Errorf("mock provider: %w", err)
+		}
+		// List returns newest first; replay in recording order
+		for i, j := 0, len(logs)-1; i < j; i, j = i+1, j-1 {
+			logs[i], logs[j] = logs[j], logs[i]
+		}
+	case strings.HasSuffix(h.source, ".jsonl"):
+		logs = []string{h.source}
+	default:
+		return h.loadScript()
+	}
+
+	for _, path := range logs {
+		log, err := wirelog.Read(path)
+		if err != nil {
+			return fmt.Errorf("mock provider: %w", err)
+		}
+		resp := MockResponse{Error: log.Error, chunks: []llm.ApiStreamChunk{}}
+		for _, e := range log.Entries {
+			if chunk, ok := e.Chunk.StreamChunk(); ok {
+				resp.chunks = append(resp.chunks, chunk)
+			}
+		}
+		if len(resp.chunks) > 0 {
+			resp.Error = ""
+		}
+		h.responses = append(h.responses, resp)
+	}
+	if len(h.responses) == 0 {
+		return fmt.Errorf("mock provider: no wire logs found in %s", h.source)
+	}
+	return nil
+}
+
+// loadScript reads a JSON MockScript
+func (h *MockHandler) loadScript() error {
+	data, err := os.ReadFile(h.source)
+	if err != nil {
+		return fmt.Errorf("mock provider: failed to read script: %w", err)
+	}
+
+	var script MockScript
+	if err := json.Unmarshal(data, &script); err != nil {
+		return fmt.Errorf("mock provider: invalid script %s: %w", filepath.Base(h.source), err)
+	}
+	if len(script.Responses) == 0 {
+		return fmt.Errorf("mock provider: script %s has no responses", filepath.Base(h.source))
+	}
+
+	for i := range script.Responses {
+		if p := script.Responses[i].Match; p != "" {
+			re, err := regexp.Compile(p)
+			if err != nil {
+				return fmt.Errorf("mock provider: invalid match pattern %q: %w", p, err)
+			}
+			script.Responses[i].re = re
+		}
+	}
+	h.responses = script.Responses
+	return nil
+}
+
+// pick selects the response for a prompt
+func (h *MockHandler) pick(prompt string) MockResponse {
+	if len(h.responses) == 0 {
+		return MockResponse{Text: "Mock response to: " + prompt}
+	}
+
+	for _, r := range h.responses {
+		if r.re != nil && r.re.MatchString(prompt) {
+			return r
+		}
+	}
+
+	var sequential []MockResponse
+	for _, r := range h.responses {
+		if r.re == nil {
+			sequential = append(sequential, r)
+		}
+	}
+	if len(sequential) == 0 {
+		return MockResponse{Text: "Mock response to: " + prompt}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	r := sequential[h.next%len(sequential)]
+	h.next++
+	return r
+}
+
+// scriptedChunks streams a scripted response word by word, followed by its
+// tool calls, usage and finish reason
+func scriptedChunks(resp MockResponse, systemPrompt string, messages []llm.Message) []llm.ApiStreamChunk {
+	var chunks []llm.ApiStreamChunk
+	if resp.Reasoning != "" {
+		chunks = append(chunks, llm.ApiStreamReasoningChunk{Reasoning: resp.Reasoning})
+	}
+	for _, word := range splitKeepingSpace(resp.Text) {
+		chunks = append(chunks, llm.ApiStreamTextChunk{Text: word})
+	}
+	for i, call := range resp.ToolCalls {
+		chunks = append(chunks, llm.ApiStreamToolCallDeltaChunk{Index: i, ID: call.ID, Name: call.Name, Arguments: call.Arguments})
+	}
+
+	usage := resp.Usage
+	if usage == nil {
+		input := tokens.Estimate(systemPrompt, tokens.FamilyGeneric)
+		for _, msg := range messages {
+			for _, text := range messageTexts(msg) {
+				input += tokens.Estimate(text, tokens.FamilyGeneric)
+			}
+		}
+		usage = &llm.ApiStreamUsageChunk{InputTokens: input, OutputTokens: tokens.Estimate(resp.Text, tokens.FamilyGeneric)}
+	}
+
+	finish := resp.FinishReason
+	if finish == "" {
+		finish = llm.FinishStop
+		if len(resp.ToolCalls) > 0 {
+			finish = llm.FinishToolCalls
+		}
+	}
+	return append(chunks, *usage, llm.ApiStreamFinishChunk{Reason: finish})
+}
+
+// splitKeepingSpace splits text after each run of whitespace
+func splitKeepingSpace(text string) []string {
+	var parts []string
+	start := 0
+	for i := 1; i < len(text); i++ {
+		if text[i-1] == ' ' || text[i-1] == '\n' {
+			if text[i] != ' ' && text[i] != '\n' {
+				parts = append(parts, text[start:i])
+				start = i
+			}
+		}
+	}
+	if start < len(text) {
+		parts = append(parts, text[start:])
+	}
+	return parts
+}
+
+// lastUserText returns the text of the most recent user message
+func lastUserText(messages []llm.Message) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "user" {
+			return strings.Join(messageTexts(messages[i]), "\n")
+		}
+	}
+	return ""
+}
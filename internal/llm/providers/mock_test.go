@@ -0,0 +1,73 @@
+package providers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+)
+
+func collectMockText(t *testing.T, h llm.ApiHandler, prompt string) string {
+	t.Helper()
+	stream, err := h.CreateMessage(context.Background(), "system", []llm.Message{
+		{Role: "user", Content: []llm.ContentBlock{llm.TextBlock{Text: prompt}}},
+	})
+	if err != nil {
+		t.Fatalf("CreateMessage failed: %v", err)
+	}
+	var sb strings.Builder
+	for chunk := range stream {
+		if c, ok := chunk.(llm.ApiStreamTextChunk); ok {
+			sb.WriteString(c.Text)
+		}
+	}
+	return sb.String()
+}
+
+func TestMockHandlerEcho(t *testing.T) {
+	h, err := BuildApiHandler(llm.ApiHandlerOptions{ModelID: "mock/echo"})
+	if err != nil {
+		t.Fatalf("BuildApiHandler failed: %v", err)
+	}
+	if got := collectMockText(t, h, "hello there"); got != "Mock response to: hello there" {
+		t.Errorf("Unexpected echo response %q", got)
+	}
+}
+
+func TestMockHandlerScript(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "script.json")
+	data := `{"responses": [
+		{"match": "(?i)weather", "text": "It is sunny."},
+		{"text": "first"},
+		{"text": "second"}
+	]}`
+	if err := os.WriteFile(script, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	h := NewMockHandler(llm.ApiHandlerOptions{ModelID: "mock/" + script})
+	for _, tc := range []struct{ prompt, want string }{
+		{"hi", "first"},
+		{"What's the WEATHER?", "It is sunny."},
+		{"hi again", "second"},
+		{"and again", "first"},
+	} {
+		if got := collectMockText(t, h, tc.prompt); got != tc.want {
+			t.Errorf("Prompt %q: expected %q, got %q", tc.prompt, tc.want, got)
+		}
+	}
+
+	if usage, _ := h.GetApiStreamUsage(); usage == nil {
+		t.Error("Expected usage after a response")
+	}
+}
+
+func TestMockHandlerMissingScript(t *testing.T) {
+	h := NewMockHandler(llm.ApiHandlerOptions{ModelID: "mock/does-not-exist.json"})
+	if _, err := h.CreateMessage(context.Background(), "", nil); err == nil {
+		t.Error("Expected error for missing script")
+	}
+}
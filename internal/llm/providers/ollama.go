@@ -7,9 +7,12 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/entrepeneur4lyf/codeforge/internal/httpclient"
 	"github.com/entrepeneur4lyf/codeforge/internal/llm"
 	"github.com/entrepeneur4lyf/codeforge/internal/llm/transform"
 )
@@ -19,6 +22,9 @@ type OllamaHandler struct {
 	options llm.ApiHandlerOptions
 	client  *http.Client
 	baseURL string
+
+	probeOnce     sync.Once
+	contextLength int // Probed from the server, 0 when unknown
 }
 
 // OllamaRequest represents a request to Ollama's API (OpenAI-compatible)
@@ -26,6 +32,7 @@ type OllamaRequest struct {
 	Model    string                    `json:"model"`
 	Messages []transform.OpenAIMessage `json:"messages"`
 	Stream   bool                      `json:"stream"`
+	Format   json.RawMessage           `json:"format,omitempty"` // JSON schema the reply follows
 	Options  *OllamaOptions            `json:"options,omitempty"`
 }
 
@@ -39,10 +46,11 @@ type OllamaOptions struct {
 
 // OllamaStreamEvent represents a streaming event from Ollama
 type OllamaStreamEvent struct {
-	Model     string         `json:"model"`
-	CreatedAt string         `json:"created_at"`
-	Message   *OllamaMessage `json:"message,omitempty"`
-	Done      bool           `json:"done"`
+	Model      string         `json:"model"`
+	CreatedAt  string         `json:"created_at"`
+	Message    *OllamaMessage `json:"message,omitempty"`
+	Done       bool           `json:"done"`
+	DoneReason string         `json:"done_reason,omitempty"` // Why generation stopped (when done=true)
 
 	// Usage information (when done=true)
 	TotalDuration      int64 `json:"total_duration,omitempty"`
@@ -61,8 +69,7 @@ type OllamaMessage struct {
 
 // NewOllamaHandler creates a new Ollama handler
 func NewOllamaHandler(options llm.ApiHandlerOptions) *OllamaHandler {
-	baseURL := "http://localhost:11434"
-	// For now, use default localhost. In the future, this could be configurable
+	baseURL := OllamaEndpoint()
 
 	// Configure timeout (Ollama can be slower for large models)
 	timeout := 120 * time.Second
@@ -72,14 +79,14 @@ func NewOllamaHandler(options llm.ApiHandlerOptions) *OllamaHandler {
 
 	return &OllamaHandler{
 		options: options,
-		client:  &http.Client{Timeout: timeout},
+		client:  httpclient.New("ollama", timeout),
 		baseURL: baseURL,
 	}
 }
 
 // CreateMessage implements the ApiHandler interface
 func (h *OllamaHandler) CreateMessage(ctx context.Context, systemPrompt string, messages []llm.Message) (llm.ApiStream, error) {
-	model := h.GetModel()
+	model := fitOutput(ctx, h.GetModel())
 
 	// Convert messages to OpenAI format
 	openAIMessages, err := convertToOpenAIMessages(systemPrompt, messages)
@@ -87,6 +94,11 @@ func (h *OllamaHandler) CreateMessage(ctx context.Context, systemPrompt string,
 		return nil, fmt.Errorf("failed to convert messages: %w", err)
 	}
 
+	format, err := ollamaFormat(h.options)
+	if err != nil {
+		return nil, err
+	}
+
 	// Prepare Ollama options
 	options := &OllamaOptions{}
 
@@ -105,6 +117,7 @@ func (h *OllamaHandler) CreateMessage(ctx context.Context, systemPrompt string,
 		Model:    model.ID,
 		Messages: openAIMessages,
 		Stream:   true,
+		Format:   format,
 		Options:  options,
 	}
 
@@ -113,10 +126,56 @@ func (h *OllamaHandler) CreateMessage(ctx context.Context, systemPrompt string,
 
 // GetModel implements the ApiHandler interface
 func (h *OllamaHandler) GetModel() llm.ModelResponse {
+	info := ollamaModelInfo(h.options.ModelID)
+	h.probeOnce.Do(func() { h.contextLength = h.probeContextLength() })
+	if h.contextLength > 0 {
+		info.ContextWindow = h.contextLength
+	}
 	return llm.ModelResponse{
 		ID:   h.options.ModelID,
-		Info: h.getDefaultModelInfo(h.options.ModelID),
+		Info: info,
+	}
+}
+
+// probeContextLength asks Ollama for the model's context length. A num_ctx
+// parameter wins over the length the model was trained for, since Ollama
+// truncates prompts to it.
+func (h *OllamaHandler) probeContextLength() int {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	body, _ := json.Marshal(map[string]string{"model": h.options.ModelID})
+	req, err := http.NewRequestWithContext(ctx, "POST", h.baseURL+"/api/show", bytes.NewReader(body))
+	if err != nil {
+		return 0
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return 0
+	}
+	defer resp.Body.Close()
+
+	var show struct {
+		Parameters string                 `json:"parameters"`
+		ModelInfo  map[string]interface{} `json:"model_info"`
+	}
+	if resp.StatusCode != http.StatusOK || json.NewDecoder(resp.Body).Decode(&show) != nil {
+		return 0
+	}
+	for _, line := range strings.Split(show.Parameters, "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 && fields[0] == "num_ctx" {
+			if n, err := strconv.Atoi(fields[1]); err == nil && n > 0 {
+				return n
+			}
+		}
+	}
+	for key, value := range show.ModelInfo {
+		if n, ok := value.(float64); ok && strings.HasSuffix(key, ".context_length") {
+			return int(n)
+		}
 	}
+	return 0
 }
 
 // GetApiStreamUsage implements the ApiHandler interface
@@ -191,13 +250,14 @@ func (h *OllamaHandler) processStreamResponse(resp *http.Response, streamChan ch
 					OutputTokens: streamEvent.EvalCount,
 				}
 			}
+			sendFinish(streamChan, &streamEvent.DoneReason)
 			break
 		}
 	}
 }
 
-// getDefaultModelInfo provides default model info based on model ID
-func (h *OllamaHandler) getDefaultModelInfo(modelID string) llm.ModelInfo {
+// ollamaModelInfo provides default model info based on model ID
+func ollamaModelInfo(modelID string) llm.ModelInfo {
 	info := llm.ModelInfo{
 		MaxTokens:           4096,
 		ContextWindow:       4096,
@@ -0,0 +1,144 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+	"github.com/entrepeneur4lyf/codeforge/internal/httpclient"
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+	"github.com/entrepeneur4lyf/codeforge/internal/models"
+)
+
+const defaultOllamaEndpoint = "http://localhost:11434"
+
+// How long a model list is reused before Ollama is asked again. Failures are
+// kept for less time, so a server that was just started shows up quickly.
+const (
+	ollamaModelsTTL      = 30 * time.Second
+	ollamaUnreachableTTL = 5 * time.Second
+)
+
+// OllamaTagsResponse represents the response from Ollama's /api/tags endpoint
+type OllamaTagsResponse struct {
+	Models []OllamaModel `json:"models"`
+}
+
+// OllamaModel is a model installed on the local Ollama server
+type OllamaModel struct {
+	Name       string `json:"name"`
+	Size       int64  `json:"size"`
+	ModifiedAt string `json:"modified_at"`
+	Details    struct {
+		ParameterSize     string `json:"parameter_size"`
+		QuantizationLevel string `json:"quantization_level"`
+	} `json:"details"`
+}
+
+// Info returns what's known of the model from its name: its context window,
+// output limit and whether it takes images
+func (m OllamaModel) Info() llm.ModelInfo {
+	return ollamaModelInfo(m.Name)
+}
+
+// ollamaModels caches the model list last fetched and the endpoint it came from
+var ollamaModels struct {
+	sync.Mutex
+	endpoint string
+	models   []OllamaModel
+	err      error
+	expires  time.Time
+}
+
+// OllamaEndpoint returns where Ollama listens: the baseURL of the ollama
+// providers entry in the config, else OLLAMA_HOST or OLLAMA_ENDPOINT, else
+// Ollama's default port on this machine
+func OllamaEndpoint() string {
+	providerCfg, _ := config.GetProvider(models.ModelProvider("ollama"))
+	endpoint := providerCfg.BaseURL
+	for _, env := range []string{"OLLAMA_HOST", "OLLAMA_ENDPOINT"} {
+		if endpoint == "" {
+			endpoint = os.Getenv(env)
+		}
+	}
+	if endpoint == "" {
+		return defaultOllamaEndpoint
+	}
+	// OLLAMA_HOST is often just a host and port, as "0.0.0.0:11434"
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "http://" + endpoint
+	}
+	return strings.TrimSuffix(endpoint, "/")
+}
+
+// ListOllamaModels returns the names of models installed on the local Ollama server
+func ListOllamaModels(ctx context.Context) ([]string, error) {
+	installed, err := ListOllamaModelDetails(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(installed))
+	for _, m := range installed {
+		names = append(names, m.Name)
+	}
+	return names, nil
+}
+
+// ListOllamaModelDetails returns the models installed on the local Ollama
+// server with their sizes and quantizations. The list is cached for a short
+// while, so the model selector and API can ask for it freely.
+func ListOllamaModelDetails(ctx context.Context) ([]OllamaModel, error) {
+	endpoint := OllamaEndpoint()
+
+	ollamaModels.Lock()
+	defer ollamaModels.Unlock()
+	if ollamaModels.endpoint == endpoint && time.Now().Before(ollamaModels.expires) {
+		return append([]OllamaModel(nil), ollamaModels.models...), ollamaModels.err
+	}
+
+	installed, err := fetchOllamaModels(ctx, endpoint)
+	ttl := ollamaModelsTTL
+	if err != nil {
+		// A canceled caller says nothing about the server
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		ttl = ollamaUnreachableTTL
+	}
+	ollamaModels.endpoint, ollamaModels.models, ollamaModels.err = endpoint, installed, err
+	ollamaModels.expires = time.Now().Add(ttl)
+	return append([]OllamaModel(nil), installed...), err
+}
+
+// fetchOllamaModels asks the Ollama server at endpoint for its models
+func fetchOllamaModels(ctx context.Context, endpoint string) ([]OllamaModel, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint+"/api/tags", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	client := httpclient.New("ollama", 3*time.Second)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ollama not reachable at %s: %w", endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ollama returned status %d", resp.StatusCode)
+	}
+
+	var tags OllamaTagsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
+		return nil, fmt.Errorf("failed to decode ollama models: %w", err)
+	}
+
+	return tags.Models, nil
+}
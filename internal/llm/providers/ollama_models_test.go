@@ -0,0 +1,59 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestListOllamaModelDetails(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/tags" {
+			http.NotFound(w, r)
+			return
+		}
+		requests++
+		fmt.Fprint(w, `{"models":[{"name":"qwen2.5-coder:7b","size":4683087332,"details":{"parameter_size":"7.6B","quantization_level":"Q4_K_M"}},{"name":"llava:13b"}]}`)
+	}))
+	defer server.Close()
+
+	ollamaModels.expires = time.Time{}
+	t.Cleanup(func() { ollamaModels.expires = time.Time{} })
+	t.Setenv("OLLAMA_ENDPOINT", "")
+	t.Setenv("OLLAMA_HOST", strings.TrimPrefix(server.URL, "http://"))
+	if got := OllamaEndpoint(); got != server.URL {
+		t.Fatalf("OllamaEndpoint() = %q, want %q", got, server.URL)
+	}
+
+	installed, err := ListOllamaModelDetails(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(installed) != 2 || installed[0].Name != "qwen2.5-coder:7b" || installed[0].Details.QuantizationLevel != "Q4_K_M" {
+		t.Errorf("installed = %+v", installed)
+	}
+	if !installed[1].Info().SupportsImages {
+		t.Errorf("llava not reported as taking images")
+	}
+
+	names, err := ListOllamaModels(context.Background())
+	if err != nil || len(names) != 2 {
+		t.Errorf("ListOllamaModels() = %v, %v", names, err)
+	}
+	if requests != 1 {
+		t.Errorf("Ollama asked %d times, want the list cached after the first", requests)
+	}
+
+	// A different endpoint isn't served from the cache
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	t.Setenv("OLLAMA_HOST", closed.URL)
+	if _, err := ListOllamaModels(context.Background()); err == nil {
+		t.Error("expected an error from a server that isn't running")
+	}
+}
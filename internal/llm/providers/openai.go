@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/entrepeneur4lyf/codeforge/internal/httpclient"
 	"github.com/entrepeneur4lyf/codeforge/internal/llm"
 	"github.com/entrepeneur4lyf/codeforge/internal/models"
 	"github.com/entrepeneur4lyf/codeforge/internal/llm/transform"
@@ -113,16 +114,14 @@ func NewOpenAIHandler(options llm.ApiHandlerOptions) *OpenAIHandler {
 
 	return &OpenAIHandler{
 		options: options,
-		client: &http.Client{
-			Timeout: timeout,
-		},
+		client:  httpclient.New("openai", timeout),
 		baseURL: baseURL,
 	}
 }
 
 // CreateMessage implements the ApiHandler interface
 func (h *OpenAIHandler) CreateMessage(ctx context.Context, systemPrompt string, messages []llm.Message) (llm.ApiStream, error) {
-	model := h.GetModel()
+	model := fitOutput(ctx, h.GetModel())
 
 	// Convert messages to OpenAI format
 	openAIMessages, err := h.convertMessages(systemPrompt, messages)
@@ -424,6 +423,10 @@ func (h *OpenAIHandler) processStream(reader io.Reader, streamChan chan<- llm.Ap
 			if choice.Delta.Reasoning != "" {
 				streamChan <- llm.ApiStreamReasoningChunk{Reasoning: choice.Delta.Reasoning}
 			}
+
+			// Handle tool call fragments and the finish reason
+			sendToolCallDeltas(streamChan, choice.Delta.ToolCalls)
+			sendFinish(streamChan, choice.FinishReason)
 		}
 
 		// Handle usage information
@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/entrepeneur4lyf/codeforge/internal/httpclient"
 	"github.com/entrepeneur4lyf/codeforge/internal/llm"
 	"github.com/openai/openai-go"
 	"github.com/openai/openai-go/option"
@@ -25,6 +26,7 @@ func NewOpenAISDKHandler(options llm.ApiHandlerOptions) *OpenAISDKHandler {
 	// Create client with API key
 	client := openai.NewClient(
 		option.WithAPIKey(options.APIKey),
+		option.WithHTTPClient(httpclient.New("openai", 0)),
 	)
 
 	return &OpenAISDKHandler{
@@ -72,6 +74,8 @@ func (h *OpenAISDKHandler) CreateMessage(ctx context.Context, systemPrompt strin
 			openaiMessages = append(openaiMessages, openai.AssistantMessage(textContent))
 		case "system":
 			openaiMessages = append(openaiMessages, openai.SystemMessage(textContent))
+		case "developer":
+			openaiMessages = append(openaiMessages, openai.DeveloperMessage(textContent))
 		default:
 			openaiMessages = append(openaiMessages, openai.UserMessage(textContent))
 		}
@@ -102,6 +106,15 @@ func (h *OpenAISDKHandler) CreateMessage(ctx context.Context, systemPrompt strin
 						Text: content,
 					}
 				}
+				for _, call := range evt.Choices[0].Delta.ToolCalls {
+					outputChan <- llm.ApiStreamToolCallDeltaChunk{
+						Index:     int(call.Index),
+						ID:        call.ID,
+						Name:      call.Function.Name,
+						Arguments: call.Function.Arguments,
+					}
+				}
+				sendFinish(outputChan, &evt.Choices[0].FinishReason)
 			}
 		}
 
@@ -182,7 +195,7 @@ func GetOpenAIModels(ctx context.Context, apiKey string) ([]OpenAIModelInfo, err
 
 // GetCachedOpenAIModels returns cached models if available and fresh, otherwise fetches from API
 func getCachedOpenAIModels(ctx context.Context, apiKey string, forceRefresh bool) ([]OpenAIModelInfo, error) {
-	cacheDir := filepath.Join(os.Getenv("HOME"), ".codeforge", "cache")
+	cacheDir := catalogCacheDir()
 	cacheFile := filepath.Join(cacheDir, "openai_models.json")
 
 	// Check cache first (unless force refresh)
@@ -202,11 +215,17 @@ func getCachedOpenAIModels(ctx context.Context, apiKey string, forceRefresh bool
 	}
 
 	// Create OpenAI client
-	client := openai.NewClient(option.WithAPIKey(apiKey))
+	client := openai.NewClient(option.WithAPIKey(apiKey), option.WithHTTPClient(httpclient.New("openai", 0)))
 
 	// Fetch models from API
 	modelsList, err := client.Models.List(ctx)
 	if err != nil {
+		// Serve the last cached catalog when the API is unreachable
+		var models []OpenAIModelInfo
+		if updatedAt, cacheErr := readStaleCatalog(cacheFile, &models); cacheErr == nil && len(models) > 0 {
+			recordCatalogStatus("openai", updatedAt, true)
+			return models, nil
+		}
 		return nil, fmt.Errorf("failed to fetch OpenAI models: %w", err)
 	}
 
@@ -230,6 +249,7 @@ func getCachedOpenAIModels(ctx context.Context, apiKey string, forceRefresh bool
 			os.WriteFile(cacheFile, data, 0644)
 		}
 	}
+	recordCatalogStatus("openai", time.Now(), false)
 
 	return models, nil
 }
@@ -17,6 +17,7 @@ import (
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
 
+	"github.com/entrepeneur4lyf/codeforge/internal/httpclient"
 	"github.com/entrepeneur4lyf/codeforge/internal/llm"
 	"github.com/entrepeneur4lyf/codeforge/internal/models"
 	"github.com/entrepeneur4lyf/codeforge/internal/llm/transform"
@@ -150,7 +151,7 @@ func NewOpenRouterHandler(options llm.ApiHandlerOptions) *OpenRouterHandler {
 
 	return &OpenRouterHandler{
 		options: options,
-		client:  &http.Client{Timeout: timeout},
+		client:  httpclient.New("openrouter", timeout),
 		baseURL: baseURL,
 		db:      nil, // Will be set when database operations are needed
 	}
@@ -165,7 +166,7 @@ func NewOpenRouterHandlerWithDB(options llm.ApiHandlerOptions, db *vectordb.Vect
 
 // CreateMessage implements the ApiHandler interface
 func (h *OpenRouterHandler) CreateMessage(ctx context.Context, systemPrompt string, messages []llm.Message) (llm.ApiStream, error) {
-	model := h.GetModel()
+	model := fitOutput(ctx, h.GetModel())
 
 	// Convert messages to OpenAI format (OpenRouter uses OpenAI-compatible format)
 	openAIMessages, err := h.convertMessages(systemPrompt, messages)
@@ -468,6 +469,10 @@ func (h *OpenRouterHandler) processStream(reader io.Reader, streamChan chan<- ll
 			if choice.Delta.Content != "" {
 				streamChan <- llm.ApiStreamTextChunk{Text: choice.Delta.Content}
 			}
+
+			// Handle tool call fragments and the finish reason
+			sendToolCallDeltas(streamChan, choice.Delta.ToolCalls)
+			sendFinish(streamChan, choice.FinishReason)
 		}
 
 		// Handle usage information with OpenRouter's cost data
@@ -568,9 +573,14 @@ func (h *OpenRouterHandler) getModelsFromDatabase(ctx context.Context) ([]OpenRo
 		if cachedModels, valid := modelsCache.getCachedModels(); valid {
 			return cachedModels, nil
 		}
+		// Serve the expired catalog rather than nothing when offline
+		if staleModels := h.getStaleModelsFromDatabase(ctx, ""); len(staleModels) > 0 {
+			return staleModels, nil
+		}
 		return nil, fmt.Errorf("no models found in database")
 	}
 
+	recordCatalogStatus("openrouter", time.Now(), false)
 	return models, nil
 }
 
@@ -625,9 +635,60 @@ func (h *OpenRouterHandler) getModelsFromDatabaseByProvider(ctx context.Context,
 		models = append(models, model)
 	}
 
+	if len(models) == 0 {
+		// Serve the expired catalog rather than nothing when offline
+		return h.getStaleModelsFromDatabase(ctx, providerFilter), nil
+	}
+
 	return models, nil
 }
 
+// getStaleModelsFromDatabase returns cached models regardless of age and
+// records the catalog as stale
+func (h *OpenRouterHandler) getStaleModelsFromDatabase(ctx context.Context, providerFilter string) []OpenRouterModel {
+	query := `
+		SELECT model_id, name, description, context_length, created_date, last_seen
+		FROM openrouter_models
+		WHERE (? = '' OR provider = ?)
+		ORDER BY name
+	`
+
+	rows, err := h.db.QueryContext(ctx, query, providerFilter, providerFilter)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	var models []OpenRouterModel
+	var newest time.Time
+	for rows.Next() {
+		var model OpenRouterModel
+		var lastSeen string
+
+		if err := rows.Scan(
+			&model.ID, &model.Name, &model.Description,
+			&model.ContextLength, &model.Created, &lastSeen,
+		); err != nil {
+			continue
+		}
+
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02T15:04:05Z"} {
+			if t, err := time.Parse(layout, lastSeen); err == nil {
+				if t.After(newest) {
+					newest = t
+				}
+				break
+			}
+		}
+		models = append(models, model)
+	}
+
+	if len(models) > 0 {
+		recordCatalogStatus("openrouter", newest, true)
+	}
+	return models
+}
+
 // isDatabaseCacheValid checks if the database cache is still valid (24 hour TTL)
 func (h *OpenRouterHandler) isDatabaseCacheValid(ctx context.Context) bool {
 	if h.db == nil {
@@ -1875,7 +1936,7 @@ func scrapeOpenRouterRankings(ctx context.Context) ([]string, error) {
 	req.Header.Set("User-Agent", "CodeForge/1.0")
 	req.Header.Set("Accept", "application/json")
 
-	client := &http.Client{Timeout: 15 * time.Second}
+	client := httpclient.New("openrouter", 15*time.Second)
 	resp, err := client.Do(req)
 	if err != nil {
 		return getCuratedTopModels(), nil
@@ -6,6 +6,7 @@ import (
 	"io"
 	"strings"
 
+	"github.com/entrepeneur4lyf/codeforge/internal/httpclient"
 	"github.com/entrepeneur4lyf/codeforge/internal/llm"
 	openrouter "github.com/revrost/go-openrouter"
 )
@@ -19,7 +20,9 @@ type OpenRouterSDKHandler struct {
 // NewOpenRouterSDKHandler creates a new OpenRouter handler using the official SDK
 func NewOpenRouterSDKHandler(options llm.ApiHandlerOptions) *OpenRouterSDKHandler {
 	// Create client with API key
-	client := openrouter.NewClient(options.APIKey)
+	client := openrouter.NewClient(options.APIKey, func(c *openrouter.ClientConfig) {
+		c.HTTPClient = httpclient.New("openrouter", 0)
+	})
 
 	return &OpenRouterSDKHandler{
 		options: options,
@@ -100,15 +103,31 @@ func (h *OpenRouterSDKHandler) CreateMessage(ctx context.Context, systemPrompt s
 				break
 			}
 
+			if len(response.Choices) == 0 {
+				continue
+			}
+			choice := response.Choices[0]
+
 			// Extract content from response
-			if len(response.Choices) > 0 && response.Choices[0].Delta.Content != "" {
-				content := response.Choices[0].Delta.Content
-				if content != "" {
-					outputChan <- llm.ApiStreamTextChunk{
-						Text: content,
-					}
+			if content := choice.Delta.Content; content != "" {
+				outputChan <- llm.ApiStreamTextChunk{
+					Text: content,
+				}
+			}
+			for i, call := range choice.Delta.ToolCalls {
+				index := i
+				if call.Index != nil {
+					index = *call.Index
+				}
+				outputChan <- llm.ApiStreamToolCallDeltaChunk{
+					Index:     index,
+					ID:        call.ID,
+					Name:      call.Function.Name,
+					Arguments: call.Function.Arguments,
 				}
 			}
+			finishReason := string(choice.FinishReason)
+			sendFinish(outputChan, &finishReason)
 		}
 	}()
 
@@ -0,0 +1,177 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+	"github.com/entrepeneur4lyf/codeforge/internal/logging"
+	"github.com/entrepeneur4lyf/codeforge/internal/privacy"
+)
+
+// isLocalProvider reports whether requests to the provider stay on this machine
+func isLocalProvider(providerType llm.ProviderType) bool {
+	return providerType == llm.ProviderOllama || providerType == llm.ProviderLMStudio || providerType == llm.ProviderJan ||
+		providerType == llm.ProviderLocalAPI || providerType == llm.ProviderMock
+}
+
+// outboundFilterHandler classifies requests bound for cloud providers and
+// blocks, redacts or reroutes those containing sensitive data
+type outboundFilterHandler struct {
+	handler    llm.ApiHandler
+	classifier *privacy.Classifier
+	action     privacy.Action
+	localModel string
+	options    llm.ApiHandlerOptions
+}
+
+// wrapWithOutboundFilter wraps a cloud handler with the configured outbound filter
+func wrapWithOutboundFilter(handler llm.ApiHandler, providerType llm.ProviderType, options llm.ApiHandlerOptions) (llm.ApiHandler, error) {
+	cfg := config.Get()
+	if cfg == nil || !cfg.OutboundFilter.Enabled || isLocalProvider(providerType) {
+		return handler, nil
+	}
+
+	filterCfg := cfg.OutboundFilter
+	classifier, err := privacy.NewClassifier(privacy.Options{
+		SensitivePaths: filterCfg.SensitivePaths,
+		SensitiveTag:   filterCfg.SensitiveTag,
+		DetectPII:      filterCfg.DetectPII,
+		Patterns:       filterCfg.Patterns,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &outboundFilterHandler{
+		handler:    handler,
+		classifier: classifier,
+		action:     privacy.ParseAction(filterCfg.Action),
+		localModel: cfg.OutboundLocalModel(),
+		options:    options,
+	}, nil
+}
+
+func (h *outboundFilterHandler) CreateMessage(ctx context.Context, systemPrompt string, messages []llm.Message) (llm.ApiStream, error) {
+	var matches []privacy.Match
+	matches = append(matches, h.classifier.Classify(systemPrompt)...)
+	for _, msg := range messages {
+		for _, text := range messageTexts(msg) {
+			matches = append(matches, h.classifier.Classify(text)...)
+		}
+	}
+
+	if len(matches) == 0 {
+		return h.handler.CreateMessage(ctx, systemPrompt, messages)
+	}
+
+	action := h.action
+	if action == privacy.ActionRedact {
+		// Whole-file classifications can't be redacted value by value
+		for _, m := range matches {
+			if !m.Redactable {
+				action = privacy.ActionBlock
+				if h.localModel != "" {
+					action = privacy.ActionLocal
+				}
+				break
+			}
+		}
+	}
+
+	summary := summarizeMatches(matches)
+	switch action {
+	case privacy.ActionBlock:
+		return nil, fmt.Errorf("outbound filter blocked request to %s: context contains sensitive data (%s)", h.handler.GetModel().ID, summary)
+
+	case privacy.ActionLocal:
+		if h.localModel == "" {
+			return nil, fmt.Errorf("outbound filter: request contains sensitive data (%s) but no local model is configured", summary)
+		}
+		logging.Info("Outbound filter routing request to local model", "model", h.localModel, "matches", summary)
+		localOptions := llm.ApiHandlerOptions{
+			ModelID:          h.localModel,
+			RequestTimeoutMs: h.options.RequestTimeoutMs,
+			ResponseSchema:   h.options.ResponseSchema,
+		}
+		return NewOllamaHandler(localOptions).CreateMessage(ctx, systemPrompt, messages)
+
+	default:
+		redactedPrompt, _ := h.classifier.Redact(systemPrompt)
+		redacted := make([]llm.Message, len(messages))
+		for i, msg := range messages {
+			redacted[i] = llm.Message{Role: msg.Role, Content: h.redactBlocks(msg.Content)}
+		}
+		logging.Info("Outbound filter redacted request", "model", h.handler.GetModel().ID, "matches", summary)
+		return h.handler.CreateMessage(ctx, redactedPrompt, redacted)
+	}
+}
+
+func (h *outboundFilterHandler) GetModel() llm.ModelResponse {
+	return h.handler.GetModel()
+}
+
+func (h *outboundFilterHandler) GetApiStreamUsage() (*llm.ApiStreamUsageChunk, error) {
+	return h.handler.GetApiStreamUsage()
+}
+
+// redactBlocks returns a copy of the content blocks with text redacted
+func (h *outboundFilterHandler) redactBlocks(blocks []llm.ContentBlock) []llm.ContentBlock {
+	out := make([]llm.ContentBlock, len(blocks))
+	for i, block := range blocks {
+		switch b := block.(type) {
+		case llm.TextBlock:
+			text, _ := h.classifier.Redact(b.Text)
+			out[i] = llm.TextBlock{Text: text}
+		case llm.ToolResultBlock:
+			out[i] = llm.ToolResultBlock{
+				ToolUseID: b.ToolUseID,
+				Content:   h.redactBlocks(b.Content),
+				IsError:   b.IsError,
+			}
+		default:
+			out[i] = block
+		}
+	}
+	return out
+}
+
+// messageTexts returns all text in a message, including tool results
+func messageTexts(msg llm.Message) []string {
+	return blockTexts(msg.Content)
+}
+
+func blockTexts(blocks []llm.ContentBlock) []string {
+	var texts []string
+	for _, block := range blocks {
+		switch b := block.(type) {
+		case llm.TextBlock:
+			texts = append(texts, b.Text)
+		case llm.ToolResultBlock:
+			texts = append(texts, blockTexts(b.Content)...)
+		}
+	}
+	return texts
+}
+
+// summarizeMatches renders match counts by kind without exposing values
+func summarizeMatches(matches []privacy.Match) string {
+	counts := make(map[string]int)
+	for _, m := range matches {
+		counts[m.Kind]++
+	}
+	kinds := make([]string, 0, len(counts))
+	for k := range counts {
+		kinds = append(kinds, k)
+	}
+	sort.Strings(kinds)
+
+	parts := make([]string, 0, len(kinds))
+	for _, k := range kinds {
+		parts = append(parts, fmt.Sprintf("%s=%d", k, counts[k]))
+	}
+	return strings.Join(parts, ", ")
+}
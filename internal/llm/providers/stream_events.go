@@ -0,0 +1,34 @@
+package providers
+
+import (
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+	"github.com/entrepeneur4lyf/codeforge/internal/llm/transform"
+)
+
+// sendToolCallDeltas sends the tool call fragments of an OpenAI-compatible
+// streaming delta as tool call delta chunks
+func sendToolCallDeltas(streamChan chan<- llm.ApiStreamChunk, calls []transform.OpenAIToolCall) {
+	for i, call := range calls {
+		index := i
+		if call.Index != nil {
+			index = *call.Index
+		}
+		streamChan <- llm.ApiStreamToolCallDeltaChunk{
+			Index:     index,
+			ID:        call.ID,
+			Name:      call.Function.Name,
+			Arguments: call.Function.Arguments,
+		}
+	}
+}
+
+// sendFinish sends the normalized finish reason of a response, if the
+// provider reported one
+func sendFinish(streamChan chan<- llm.ApiStreamChunk, reason *string) {
+	if reason == nil {
+		return
+	}
+	if normalized := llm.NormalizeFinishReason(*reason); normalized != "" {
+		streamChan <- llm.ApiStreamFinishChunk{Reason: normalized}
+	}
+}
@@ -0,0 +1,112 @@
+package providers
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+)
+
+// collectStream runs a provider's stream parser over body and collects what
+// it sends
+func collectStream(t *testing.T, body string, process func(io.Reader, chan<- llm.ApiStreamChunk)) *llm.StreamCollector {
+	t.Helper()
+
+	ch := make(chan llm.ApiStreamChunk, 100)
+	go func() {
+		defer close(ch)
+		process(strings.NewReader(body), ch)
+	}()
+
+	collector := llm.NewStreamCollector()
+	for chunk := range ch {
+		collector.Collect(chunk)
+	}
+	return collector
+}
+
+func TestStreamNormalization(t *testing.T) {
+	wantCall := llm.StreamToolCall{ID: "call_1", Name: "read_file", Arguments: `{"path":"a.go"}`}
+
+	tests := []struct {
+		name    string
+		body    string
+		process func(io.Reader, chan<- llm.ApiStreamChunk)
+	}{
+		{
+			name: "openai",
+			body: `data: {"choices":[{"index":0,"delta":{"content":"Reading"}}]}
+
+data: {"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"read_file","arguments":"{\"path\":"}}]}}]}
+
+data: {"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"a.go\"}"}}]}}]}
+
+data: {"choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}],"usage":{"prompt_tokens":12,"completion_tokens":5}}
+
+data: [DONE]
+
+`,
+			process: NewOpenAIHandler(llm.ApiHandlerOptions{ModelID: "gpt-4o"}).processStream,
+		},
+		{
+			name: "anthropic",
+			body: `event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Reading"}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"call_1","name":"read_file"}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"path\":"}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"\"a.go\"}"}}
+
+event: message_delta
+data: {"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"input_tokens":12,"output_tokens":5}}
+
+`,
+			process: NewAnthropicHandler(llm.ApiHandlerOptions{ModelID: "claude-sonnet-4"}).processStream,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collector := collectStream(t, tt.body, tt.process)
+
+			if got := collector.GetFullText(); got != "Reading" {
+				t.Errorf("text = %q, want Reading", got)
+			}
+			calls := collector.GetToolCalls()
+			if len(calls) != 1 || calls[0] != wantCall {
+				t.Errorf("tool calls = %+v, want [%+v]", calls, wantCall)
+			}
+			if collector.FinishReason != llm.FinishToolCalls {
+				t.Errorf("finish reason = %q, want %q", collector.FinishReason, llm.FinishToolCalls)
+			}
+			if collector.Usage == nil || collector.Usage.InputTokens != 12 || collector.Usage.OutputTokens != 5 {
+				t.Errorf("usage = %+v, want 12 input and 5 output tokens", collector.Usage)
+			}
+		})
+	}
+}
+
+func TestNormalizeFinishReason(t *testing.T) {
+	tests := map[string]string{
+		"stop":                      llm.FinishStop,
+		"end_turn":                  llm.FinishStop,
+		"STOP":                      llm.FinishStop,
+		"max_tokens":                llm.FinishLength,
+		"MAX_TOKENS":                llm.FinishLength,
+		"tool_use":                  llm.FinishToolCalls,
+		"SAFETY":                    llm.FinishContentFilter,
+		"FINISH_REASON_UNSPECIFIED": "",
+		"pause_turn":                "pause_turn",
+	}
+	for reason, want := range tests {
+		if got := llm.NormalizeFinishReason(reason); got != want {
+			t.Errorf("NormalizeFinishReason(%q) = %q, want %q", reason, got, want)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+)
+
+// ResponseFormat is the OpenAI-style response_format that LM Studio, Jan and
+// llama.cpp's server constrain a reply to
+type ResponseFormat struct {
+	Type       string              `json:"type"`
+	JSONSchema *ResponseJSONSchema `json:"json_schema,omitempty"`
+}
+
+// ResponseJSONSchema names the JSON schema of a response_format
+type ResponseJSONSchema struct {
+	Name   string          `json:"name"`
+	Strict bool            `json:"strict"`
+	Schema json.RawMessage `json:"schema"`
+}
+
+// checkResponseSchema rejects a structured output schema that isn't a JSON
+// object, before a local server reports it less clearly
+func checkResponseSchema(options llm.ApiHandlerOptions) error {
+	if len(options.ResponseSchema) == 0 {
+		return nil
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(options.ResponseSchema, &schema); err != nil {
+		return fmt.Errorf("response schema must be a JSON object: %w", err)
+	}
+	return nil
+}
+
+// responseFormat returns the response_format for the options' schema, if any
+func responseFormat(options llm.ApiHandlerOptions) *ResponseFormat {
+	if len(options.ResponseSchema) == 0 {
+		return nil
+	}
+	return &ResponseFormat{
+		Type:       "json_schema",
+		JSONSchema: &ResponseJSONSchema{Name: "response", Strict: true, Schema: options.ResponseSchema},
+	}
+}
+
+// ollamaFormat returns Ollama's format field for the options. Ollama takes a
+// JSON schema but not GBNF grammars.
+func ollamaFormat(options llm.ApiHandlerOptions) (json.RawMessage, error) {
+	if options.Grammar != "" {
+		return nil, fmt.Errorf("ollama doesn't take GBNF grammars; give a JSON schema instead")
+	}
+	if err := checkResponseSchema(options); err != nil {
+		return nil, err
+	}
+	return options.ResponseSchema, nil
+}
@@ -0,0 +1,69 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+)
+
+// captureRequest starts a server that records the request body and answers
+// with an empty stream
+func captureRequest(t *testing.T) (*httptest.Server, *map[string]interface{}) {
+	t.Helper()
+	body := map[string]interface{}{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Errorf("request body: %v", err)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &body
+}
+
+func TestStructuredOutput(t *testing.T) {
+	schema := json.RawMessage(`{"type":"object","properties":{"name":{"type":"string"}}}`)
+	messages := []llm.Message{{Role: "user", Content: []llm.ContentBlock{text("Name a color")}}}
+
+	// Ollama takes the schema as its format
+	server, body := captureRequest(t)
+	ollama := NewOllamaHandler(llm.ApiHandlerOptions{ModelID: "llama3", ResponseSchema: schema})
+	ollama.baseURL = server.URL
+	if _, err := ollama.CreateMessage(context.Background(), "", messages); err != nil {
+		t.Fatal(err)
+	}
+	if format, _ := (*body)["format"].(map[string]interface{}); format["type"] != "object" {
+		t.Errorf("ollama format = %v", (*body)["format"])
+	}
+
+	ollama.options = llm.ApiHandlerOptions{ModelID: "llama3", Grammar: `root ::= "yes" | "no"`}
+	if _, err := ollama.CreateMessage(context.Background(), "", messages); err == nil {
+		t.Error("ollama accepted a GBNF grammar")
+	}
+
+	// OpenAI-compatible local servers take response_format and llama.cpp's grammar
+	server, body = captureRequest(t)
+	local := NewLMStudioHandler(llm.ApiHandlerOptions{
+		ModelID:        "qwen2.5-coder",
+		OpenAIBaseURL:  server.URL,
+		ResponseSchema: schema,
+		Grammar:        `root ::= "yes" | "no"`,
+	})
+	if _, err := local.CreateMessage(context.Background(), "", messages); err != nil {
+		t.Fatal(err)
+	}
+	format, _ := (*body)["response_format"].(map[string]interface{})
+	if format["type"] != "json_schema" || (*body)["grammar"] != `root ::= "yes" | "no"` {
+		t.Errorf("request = %v", *body)
+	}
+
+	local.options.ResponseSchema = json.RawMessage(`["not", "an", "object"]`)
+	if _, err := local.CreateMessage(context.Background(), "", messages); err == nil {
+		t.Error("accepted a schema that isn't an object")
+	}
+}
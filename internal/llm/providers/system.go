@@ -0,0 +1,109 @@
+package providers
+
+import (
+	"context"
+	"strings"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+)
+
+// systemRole is how a model takes the system prompt
+type systemRole int
+
+const (
+	systemMessage   systemRole = iota // The API's system message or field
+	systemDeveloper                   // A developer message, as OpenAI reasoning models take it
+	systemAsText                      // Text before the first user message, for models without one
+)
+
+// adaptedHandler puts requests in the form the provider and model expect:
+// the system prompt where the model takes it, history written by any
+// provider, as after switching models mid-session, re-encoded for this one,
+// and max tokens fitted to what the prompt leaves of the context window
+type adaptedHandler struct {
+	llm.ApiHandler
+	provider llm.ProviderType
+	role     systemRole
+}
+
+func wrapWithAdapter(handler llm.ApiHandler, provider llm.ProviderType, modelID string) llm.ApiHandler {
+	return &adaptedHandler{ApiHandler: handler, provider: provider, role: systemRoleFor(provider, modelID)}
+}
+
+func (h *adaptedHandler) CreateMessage(ctx context.Context, systemPrompt string, messages []llm.Message) (llm.ApiStream, error) {
+	systemPrompt, messages = liftSystemMessages(systemPrompt, messages)
+	systemPrompt, messages = placeSystemPrompt(h.role, systemPrompt, ReplayHistory(messages, h.provider))
+	if budget, ok := outputBudget(h.ApiHandler.GetModel(), systemPrompt, messages); ok {
+		ctx = withOutputBudget(ctx, budget)
+	}
+	return h.ApiHandler.CreateMessage(ctx, systemPrompt, messages)
+}
+
+// systemRoleFor returns how a model takes the system prompt
+func systemRoleFor(provider llm.ProviderType, modelID string) systemRole {
+	model := strings.ToLower(modelID)
+	if i := strings.LastIndex(model, "/"); i >= 0 {
+		model = model[i+1:]
+	}
+
+	switch provider {
+	case llm.ProviderOpenAI:
+		// The first o1 releases take neither system nor developer messages
+		if strings.HasPrefix(model, "o1-mini") || strings.HasPrefix(model, "o1-preview") {
+			return systemAsText
+		}
+		for _, prefix := range []string{"o1", "o3", "o4"} {
+			if strings.HasPrefix(model, prefix) {
+				return systemDeveloper
+			}
+		}
+	case llm.ProviderGemini, llm.ProviderVertex:
+		// Gemma on the Gemini API has no system instructions
+		if strings.HasPrefix(model, "gemma") {
+			return systemAsText
+		}
+	}
+	return systemMessage
+}
+
+// liftSystemMessages moves system and developer messages into the system
+// prompt, where every provider can take them
+func liftSystemMessages(systemPrompt string, messages []llm.Message) (string, []llm.Message) {
+	var lifted []string
+	if systemPrompt != "" {
+		lifted = append(lifted, systemPrompt)
+	}
+	rest := make([]llm.Message, 0, len(messages))
+	for _, msg := range messages {
+		if msg.Role != "system" && msg.Role != "developer" {
+			rest = append(rest, msg)
+			continue
+		}
+		if text := strings.TrimSpace(strings.Join(blockTexts(msg.Content), "\n")); text != "" {
+			lifted = append(lifted, text)
+		}
+	}
+	return strings.Join(lifted, "\n\n"), rest
+}
+
+// placeSystemPrompt puts the system prompt where a model of the given role
+// takes it, returning what's left to pass as the system prompt
+func placeSystemPrompt(role systemRole, systemPrompt string, messages []llm.Message) (string, []llm.Message) {
+	if systemPrompt == "" || role == systemMessage {
+		return systemPrompt, messages
+	}
+
+	prompt := llm.TextBlock{Text: systemPrompt}
+	if role == systemDeveloper {
+		return "", append([]llm.Message{{Role: "developer", Content: []llm.ContentBlock{prompt}}}, messages...)
+	}
+
+	// Replayed history opens with a user message when there is any
+	prompt.Text += "\n\n"
+	if len(messages) == 0 || messages[0].Role != "user" {
+		return "", append([]llm.Message{{Role: "user", Content: []llm.ContentBlock{prompt}}}, messages...)
+	}
+	adapted := append([]llm.Message(nil), messages...)
+	adapted[0].Content = append([]llm.ContentBlock{prompt}, messages[0].Content...)
+	return "", adapted
+}
@@ -0,0 +1,56 @@
+package providers
+
+import (
+	"testing"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+)
+
+func TestSystemRoleFor(t *testing.T) {
+	tests := []struct {
+		provider llm.ProviderType
+		model    string
+		want     systemRole
+	}{
+		{llm.ProviderOpenAI, "gpt-4o", systemMessage},
+		{llm.ProviderOpenAI, "o1", systemDeveloper},
+		{llm.ProviderOpenAI, "o3-mini", systemDeveloper},
+		{llm.ProviderOpenAI, "o1-mini", systemAsText},
+		{llm.ProviderAnthropic, "claude-sonnet-4-20250514", systemMessage},
+		{llm.ProviderGemini, "gemma-3-27b-it", systemAsText},
+		{llm.ProviderOpenRouter, "openai/o1", systemMessage},
+	}
+	for _, tt := range tests {
+		if got := systemRoleFor(tt.provider, tt.model); got != tt.want {
+			t.Errorf("systemRoleFor(%s, %s) = %d, want %d", tt.provider, tt.model, got, tt.want)
+		}
+	}
+}
+
+func TestPlaceSystemPrompt(t *testing.T) {
+	messages := []llm.Message{
+		{Role: "system", Content: []llm.ContentBlock{text("Answer in Go.")}},
+		{Role: "user", Content: []llm.ContentBlock{text("Sort a slice")}},
+	}
+	system, rest := liftSystemMessages("Be brief.", messages)
+	if system != "Be brief.\n\nAnswer in Go." || len(rest) != 1 {
+		t.Fatalf("lifted %q, left %d messages", system, len(rest))
+	}
+
+	if prompt, got := placeSystemPrompt(systemMessage, system, rest); prompt != system || len(got) != 1 {
+		t.Errorf("system role moved the prompt")
+	}
+
+	prompt, got := placeSystemPrompt(systemDeveloper, system, rest)
+	if prompt != "" || len(got) != 2 || got[0].Role != "developer" || got[0].Content[0].(llm.TextBlock).Text != system {
+		t.Errorf("developer role: %q, %+v", prompt, got)
+	}
+
+	prompt, got = placeSystemPrompt(systemAsText, system, rest)
+	if prompt != "" || len(got) != 1 || len(got[0].Content) != 2 || got[0].Content[0].(llm.TextBlock).Text != system+"\n\n" {
+		t.Errorf("prompt as text: %q, %+v", prompt, got)
+	}
+	if len(rest[0].Content) != 1 {
+		t.Error("placing the prompt changed the caller's message")
+	}
+}
@@ -0,0 +1,76 @@
+package providers
+
+import (
+	"context"
+	"log"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+	"github.com/entrepeneur4lyf/codeforge/internal/models"
+)
+
+// usageHandler records the token usage of every response with the cost
+// tracker, so budgets and budget alerts see all generations
+type usageHandler struct {
+	llm.ApiHandler
+	provider llm.ProviderType
+	tracker  *config.CostTracker
+}
+
+func trackUsage(handler llm.ApiHandler, provider llm.ProviderType, tracker *config.CostTracker) llm.ApiHandler {
+	return &usageHandler{ApiHandler: handler, provider: provider, tracker: tracker}
+}
+
+func (h *usageHandler) CreateMessage(ctx context.Context, systemPrompt string, messages []llm.Message) (llm.ApiStream, error) {
+	stream, err := h.ApiHandler.CreateMessage(ctx, systemPrompt, messages)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan llm.ApiStreamChunk)
+	go func() {
+		defer close(out)
+		for chunk := range stream {
+			if usage, ok := chunk.(llm.ApiStreamUsageChunk); ok {
+				h.record(usage)
+			}
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (h *usageHandler) record(usage llm.ApiStreamUsageChunk) {
+	model := h.GetModel()
+	record := config.TokenUsageRecord{
+		ModelID:      models.ModelID(model.ID),
+		Provider:     models.ModelProvider(h.provider),
+		InputTokens:  int64(usage.InputTokens),
+		OutputTokens: int64(usage.OutputTokens),
+		Success:      true,
+		RequestType:  "chat",
+	}
+	if usage.CacheReadTokens != nil {
+		record.CachedTokens = int64(*usage.CacheReadTokens)
+	}
+
+	// The list price is broken down when the provider doesn't report a cost
+	record.TotalCost = usage.Cost(model.Info)
+	if usage.TotalCost == nil {
+		info := model.Info
+		record.InputCost = info.InputPrice * float64(usage.InputTokens) / 1e6
+		record.OutputCost = info.OutputPrice * float64(usage.OutputTokens) / 1e6
+		record.CachedCost = info.CacheReadsPrice * float64(record.CachedTokens) / 1e6
+		if usage.CacheWriteTokens != nil {
+			record.CachedCost += info.CacheWritesPrice * float64(*usage.CacheWriteTokens) / 1e6
+		}
+	}
+
+	if err := h.tracker.RecordUsage(record); err != nil {
+		log.Printf("Warning: failed to record token usage: %v", err)
+	}
+}
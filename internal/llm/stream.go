@@ -2,6 +2,7 @@ package llm
 
 import (
 	"context"
+	"strings"
 	"time"
 )
 
@@ -41,13 +42,147 @@ type ApiStreamUsageChunk struct {
 
 func (c ApiStreamUsageChunk) Type() string { return "usage" }
 
+// Cost returns what the response cost: the cost the provider reported, or
+// else its tokens at the model's list prices
+func (c ApiStreamUsageChunk) Cost(info ModelInfo) float64 {
+	if c.TotalCost != nil {
+		return *c.TotalCost
+	}
+	cost := info.Cost(c.InputTokens, c.OutputTokens)
+	if c.CacheReadTokens != nil {
+		cost += info.CacheReadsPrice * float64(*c.CacheReadTokens) / 1e6
+	}
+	if c.CacheWriteTokens != nil {
+		cost += info.CacheWritesPrice * float64(*c.CacheWriteTokens) / 1e6
+	}
+	return cost
+}
+
+// ApiStreamToolCallDeltaChunk is a fragment of a tool call the model is
+// streaming. Fragments with the same Index belong to one call: the first
+// carries its ID and Name, and the Arguments of all of them concatenate to
+// its JSON input.
+type ApiStreamToolCallDeltaChunk struct {
+	Index     int    `json:"index"`
+	ID        string `json:"id,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments,omitempty"`
+}
+
+func (c ApiStreamToolCallDeltaChunk) Type() string { return "tool_call_delta" }
+
+// ApiStreamFinishChunk reports why the model stopped, as one of the Finish
+// reasons. Providers send it once per response; usage may still follow it.
+type ApiStreamFinishChunk struct {
+	Reason string `json:"reason"`
+}
+
+func (c ApiStreamFinishChunk) Type() string { return "finish_reason" }
+
+// Finish reasons that providers' stop reasons are normalized to
+const (
+	FinishStop          = "stop"           // The model finished or hit a stop sequence
+	FinishLength        = "length"         // The output token limit was reached
+	FinishToolCalls     = "tool_calls"     // The model is waiting for tool results
+	FinishContentFilter = "content_filter" // The provider withheld the output
+)
+
+// NormalizeFinishReason maps a provider's stop reason, such as Anthropic's
+// "end_turn" or Gemini's "MAX_TOKENS", to a Finish reason. Reasons without
+// an equivalent are returned in lower case, and unspecified ones as "".
+func NormalizeFinishReason(reason string) string {
+	switch r := strings.ToLower(reason); r {
+	case "finish_reason_unspecified", "null":
+		return ""
+	case "stop", "end_turn", "stop_sequence", "eos", "complete", "finish":
+		return FinishStop
+	case "length", "max_tokens", "model_length":
+		return FinishLength
+	case "tool_calls", "tool_use", "function_call":
+		return FinishToolCalls
+	case "content_filter", "safety", "recitation", "blocklist", "prohibited_content",
+		"spii", "refusal", "guardrail_intervened", "content_filtered":
+		return FinishContentFilter
+	default:
+		return r
+	}
+}
+
+// StreamToolCall is a tool call assembled from its streamed fragments
+type StreamToolCall struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"` // JSON input of the call
+}
+
+// Input decodes the call's arguments, repairing them if they were cut short
+func (c StreamToolCall) Input() (map[string]interface{}, error) {
+	return ParseToolArguments(c.Arguments)
+}
+
+// StreamEvent is the serialized form of a stream chunk, for clients that
+// read a generation as JSON events
+type StreamEvent struct {
+	Type         string                       `json:"type"`
+	Text         string                       `json:"text,omitempty"`
+	Reasoning    string                       `json:"reasoning,omitempty"`
+	ToolCall     *ApiStreamToolCallDeltaChunk `json:"toolCall,omitempty"`
+	Usage        *ApiStreamUsageChunk         `json:"usage,omitempty"`
+	FinishReason string                       `json:"finishReason,omitempty"`
+}
+
+// NewStreamEvent converts a stream chunk to its serialized form
+func NewStreamEvent(chunk ApiStreamChunk) StreamEvent {
+	event := StreamEvent{Type: chunk.Type()}
+	switch c := chunk.(type) {
+	case ApiStreamTextChunk:
+		event.Text = c.Text
+	case ApiStreamReasoningChunk:
+		event.Reasoning = c.Reasoning
+	case ApiStreamToolCallDeltaChunk:
+		event.ToolCall = &c
+	case ApiStreamUsageChunk:
+		event.Usage = &c
+	case ApiStreamFinishChunk:
+		event.FinishReason = c.Reason
+	}
+	return event
+}
+
+// StreamChunk converts a serialized event back to a stream chunk
+func (e StreamEvent) StreamChunk() (ApiStreamChunk, bool) {
+	switch e.Type {
+	case "text":
+		return ApiStreamTextChunk{Text: e.Text}, true
+	case "reasoning":
+		return ApiStreamReasoningChunk{Reasoning: e.Reasoning}, true
+	case "tool_call_delta":
+		if e.ToolCall == nil {
+			return nil, false
+		}
+		return *e.ToolCall, true
+	case "usage":
+		if e.Usage == nil {
+			return nil, false
+		}
+		return *e.Usage, true
+	case "finish_reason":
+		return ApiStreamFinishChunk{Reason: e.FinishReason}, true
+	default:
+		return nil, false
+	}
+}
+
 // StreamCollector helps collect and aggregate stream chunks
 type StreamCollector struct {
 	TextChunks     []string
 	ReasoningChunks []string
 	Usage          *ApiStreamUsageChunk
+	FinishReason   string
 	StartTime      time.Time
 	EndTime        time.Time
+
+	toolCalls ToolCallParser
 }
 
 // NewStreamCollector creates a new stream collector
@@ -69,7 +204,21 @@ func (sc *StreamCollector) Collect(chunk ApiStreamChunk) {
 	case ApiStreamUsageChunk:
 		sc.Usage = &c
 		sc.EndTime = time.Now()
+	case ApiStreamToolCallDeltaChunk:
+		sc.toolCalls.Add(c)
+	case ApiStreamFinishChunk:
+		sc.FinishReason = c.Reason
+	}
+}
+
+// GetToolCalls returns the tool calls assembled from the stream, in the
+// order the model made them
+func (sc *StreamCollector) GetToolCalls() []StreamToolCall {
+	var calls []StreamToolCall
+	for _, call := range sc.toolCalls.Calls() {
+		calls = append(calls, call.ToolCall())
 	}
+	return calls
 }
 
 // GetFullText returns the complete text from all text chunks
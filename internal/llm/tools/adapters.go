@@ -89,6 +89,7 @@ func NewFetchToolAdapter(permissions *PermissionAdapter) BaseTool {
 
 type permissionService interface {
 	Request(opts CreatePermissionRequest) bool
+	Confirm(opts CreatePermissionRequest) bool
 }
 
 type fileService interface {
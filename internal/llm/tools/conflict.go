@@ -0,0 +1,80 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/diff"
+)
+
+// FileConflictParams describe a file that changed on disk after the model
+// read it, for the user to decide how to proceed
+type FileConflictParams struct {
+	FilePath string `json:"file_path"`
+	Diff     string `json:"diff"`   // The outside changes, then the model's, both against the content the model read
+	Merged   bool   `json:"merged"` // Both sets of changes can be kept
+}
+
+// resolveConflict checks whether filePath changed on disk since the model
+// last read it. current is its content now and proposed what the tool would
+// write. When it changed, the user sees both sets of changes and is asked
+// whether to go ahead: with their edits kept when the changes merge cleanly,
+// or overwritten when they overlap. It returns the content to write, or an
+// error response for the model when the user keeps the file as it is.
+func resolveConflict(perms permissionService, sessionID, toolName, filePath, current, proposed string) (string, *ToolResponse) {
+	changed, base, kept := changedSinceRead(filePath, current)
+	if !changed {
+		return proposed, nil
+	}
+
+	outside, _, _ := diff.GenerateDiff(base, current, filePath)
+	var merged string
+	clean := false
+	if kept {
+		merged, clean = diff.Merge3(base, current, proposed)
+	}
+
+	var view strings.Builder
+	if kept {
+		view.WriteString("Changes made outside CodeForge:\n")
+		view.WriteString(outside)
+		view.WriteString("\nChanges from CodeForge:\n")
+		modelChanges, _, _ := diff.GenerateDiff(base, proposed, filePath)
+		view.WriteString(modelChanges)
+	} else {
+		// Too large to have kept what was read, so compare with the file now
+		view.WriteString("Changes from CodeForge, over the file as it is now:\n")
+		overwrite, _, _ := diff.GenerateDiff(current, proposed, filePath)
+		view.WriteString(overwrite)
+	}
+
+	description := fmt.Sprintf("%s changed since it was read. Overwrite the changes made outside CodeForge?", filePath)
+	if clean {
+		description = fmt.Sprintf("%s changed since it was read. Apply CodeForge's changes on top of the changes made outside it?", filePath)
+	}
+
+	if !perms.Confirm(CreatePermissionRequest{
+		SessionID:   sessionID,
+		Path:        filePath,
+		ToolName:    toolName,
+		Action:      "conflict",
+		Description: description,
+		Params: FileConflictParams{
+			FilePath: filePath,
+			Diff:     view.String(),
+			Merged:   clean,
+		},
+	}) {
+		msg := fmt.Sprintf("File %s was changed outside CodeForge since you read it, and the user kept those changes. Read the file again before modifying it.", filePath)
+		if kept {
+			msg += "\n\nChanges made outside CodeForge:\n" + outside
+		}
+		resp := NewTextErrorResponse(msg)
+		return "", &resp
+	}
+
+	if clean {
+		return merged, nil
+	}
+	return proposed, nil
+}
@@ -0,0 +1,35 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangedSinceRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.go")
+	require.NoError(t, os.WriteFile(path, []byte("package main\n"), 0o644))
+
+	changed, _, _ := changedSinceRead(path, "package main\n")
+	assert.False(t, changed, "a file never read hasn't drifted")
+
+	recordFileRead(path)
+	changed, _, _ = changedSinceRead(path, "package main\n")
+	assert.False(t, changed)
+
+	later := time.Now().Add(time.Hour)
+
+	// Touching the file without changing it isn't a change
+	require.NoError(t, os.Chtimes(path, later, later))
+	changed, _, _ = changedSinceRead(path, "package main\n")
+	assert.False(t, changed)
+
+	changed, base, kept := changedSinceRead(path, "package main\n\nfunc main() {}\n")
+	assert.True(t, changed)
+	assert.True(t, kept)
+	assert.Equal(t, "package main\n", base)
+}
@@ -184,7 +184,7 @@ func getDiagnostics(filePath string, lsps map[string]*lsp.Client) string {
 	}
 
 	// Get diagnostics for the specific file
-	uri := fmt.Sprintf("file://%s", filePath)
+	uri := lsp.PathToURI(filePath)
 	for lspName, client := range lsps {
 		diagnostics := client.GetDiagnostics(uri)
 		if len(diagnostics) > 0 {
@@ -205,12 +205,7 @@ func getDiagnostics(filePath string, lsps map[string]*lsp.Client) string {
 			}
 
 			// Convert URI back to filepath
-			var projectFilePath string
-			if filepath, found := strings.CutPrefix(fileUri, "file://"); found {
-				projectFilePath = filepath
-			} else {
-				projectFilePath = fileUri
-			}
+			projectFilePath := lsp.URIToPath(fileUri)
 
 			for _, diag := range diagnostics {
 				formattedDiag := formatDiagnostic(projectFilePath, diag, lspName)
@@ -7,10 +7,9 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
-	"time"
 
-	"github.com/entrepeneur4lyf/codeforge/internal/config"
 	"github.com/entrepeneur4lyf/codeforge/internal/diff"
+	"github.com/entrepeneur4lyf/codeforge/internal/fileutil"
 	"github.com/entrepeneur4lyf/codeforge/internal/lsp"
 )
 
@@ -18,6 +17,7 @@ type EditParams struct {
 	FilePath  string `json:"file_path"`
 	OldString string `json:"old_string"`
 	NewString string `json:"new_string"`
+	Blocks    string `json:"blocks,omitempty"` // SEARCH/REPLACE blocks, in place of old_string and new_string
 }
 
 type EditPermissionsParams struct {
@@ -53,6 +53,16 @@ To make a file edit, provide the following:
 2. old_string: The text to replace (must be unique within the file, and must match the file contents exactly, including all whitespace and indentation)
 3. new_string: The edited text to replace the old_string
 
+Alternatively, to make several changes to the file at once, provide file_path and blocks: one or more SEARCH/REPLACE blocks, each in this form:
+
+<<<<<<< SEARCH
+the lines to change, copied from the file
+=======
+the lines to put in their place
+>>>>>>> REPLACE
+
+Each SEARCH section must match one place in the file. Whitespace differences are tolerated when the exact text isn't found. The blocks are applied in order, and none are applied if any fails to match.
+
 Special cases:
 - To create a new file: provide file_path and new_string, leave old_string empty
 - To delete content: provide file_path and old_string, leave new_string empty
@@ -113,8 +123,12 @@ func (e *editTool) Info() ToolInfo {
 				"type":        "string",
 				"description": "The text to replace it with",
 			},
+			"blocks": map[string]any{
+				"type":        "string",
+				"description": "One or more SEARCH/REPLACE blocks to apply to the file, in place of old_string and new_string",
+			},
 		},
-		Required: []string{"file_path", "old_string", "new_string"},
+		Required: []string{"file_path"},
 	}
 }
 
@@ -128,14 +142,22 @@ func (e *editTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error)
 		return NewTextErrorResponse("file_path is required"), nil
 	}
 
-	if !filepath.IsAbs(params.FilePath) {
-		wd := config.WorkingDirectory()
-		params.FilePath = filepath.Join(wd, params.FilePath)
-	}
-
 	var response ToolResponse
 	var err error
 
+	params.FilePath, err = checkWritePath(ctx, params.FilePath)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+
+	if params.Blocks != "" {
+		response, err = e.replaceBlocks(ctx, params.FilePath, params.Blocks)
+		if err != nil || response.IsError {
+			return response, err
+		}
+		return e.withDiagnostics(ctx, params.FilePath, response), nil
+	}
+
 	if params.OldString == "" {
 		response, err = e.createNewFile(ctx, params.FilePath, params.NewString)
 		if err != nil {
@@ -160,11 +182,16 @@ func (e *editTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error)
 		return response, nil
 	}
 
-	waitForLspDiagnostics(ctx, params.FilePath, e.lspClients)
+	return e.withDiagnostics(ctx, params.FilePath, response), nil
+}
+
+// withDiagnostics adds the LSP diagnostics for an edited file to the response
+func (e *editTool) withDiagnostics(ctx context.Context, filePath string, response ToolResponse) ToolResponse {
+	waitForLspDiagnostics(ctx, filePath, e.lspClients)
 	text := fmt.Sprintf("<result>\n%s\n</result>\n", response.Content)
-	text += getDiagnostics(params.FilePath, e.lspClients)
+	text += getDiagnostics(filePath, e.lspClients)
 	response.Content = text
-	return response, nil
+	return response
 }
 
 func (e *editTool) createNewFile(ctx context.Context, filePath, content string) (ToolResponse, error) {
@@ -188,12 +215,17 @@ func (e *editTool) createNewFile(ctx context.Context, filePath, content string)
 		return ToolResponse{}, fmt.Errorf("session ID and message ID are required for creating a new file")
 	}
 
+	content, err = applyPolicies(ctx, filePath, "", content)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+
 	diff, additions, removals := diff.GenerateDiff(
 		"",
 		content,
 		filePath,
 	)
-	rootDir := config.WorkingDirectory()
+	rootDir := workingDir(ctx)
 	permissionPath := filepath.Dir(filePath)
 	if strings.HasPrefix(filePath, rootDir) {
 		permissionPath = rootDir
@@ -215,7 +247,7 @@ func (e *editTool) createNewFile(ctx context.Context, filePath, content string)
 		return ToolResponse{}, ErrorPermissionDenied
 	}
 
-	err = os.WriteFile(filePath, []byte(content), 0o644)
+	err = fileutil.WriteFile(filePath, []byte(content))
 	if err != nil {
 		return ToolResponse{}, fmt.Errorf("failed to write file: %w", err)
 	}
@@ -253,15 +285,6 @@ func (e *editTool) deleteContent(ctx context.Context, filePath, oldString string
 		return NewTextErrorResponse("you must read the file before editing it. Use the View tool first"), nil
 	}
 
-	modTime := fileInfo.ModTime()
-	lastRead := getLastReadTime(filePath)
-	if modTime.After(lastRead) {
-		return NewTextErrorResponse(
-			fmt.Sprintf("file %s has been modified since it was last read (mod time: %s, last read: %s)",
-				filePath, modTime.Format(time.RFC3339), lastRead.Format(time.RFC3339),
-			)), nil
-	}
-
 	content, err := os.ReadFile(filePath)
 	if err != nil {
 		return ToolResponse{}, fmt.Errorf("failed to read file: %w", err)
@@ -287,13 +310,18 @@ func (e *editTool) deleteContent(ctx context.Context, filePath, oldString string
 		return ToolResponse{}, fmt.Errorf("session ID and message ID are required for creating a new file")
 	}
 
+	newContent, conflict := resolveConflict(e.permissions, sessionID, EditToolName, filePath, oldContent, newContent)
+	if conflict != nil {
+		return *conflict, nil
+	}
+
 	diff, additions, removals := diff.GenerateDiff(
 		oldContent,
 		newContent,
 		filePath,
 	)
 
-	rootDir := config.WorkingDirectory()
+	rootDir := workingDir(ctx)
 	permissionPath := filepath.Dir(filePath)
 	if strings.HasPrefix(filePath, rootDir) {
 		permissionPath = rootDir
@@ -315,7 +343,7 @@ func (e *editTool) deleteContent(ctx context.Context, filePath, oldString string
 		return ToolResponse{}, ErrorPermissionDenied
 	}
 
-	err = os.WriteFile(filePath, []byte(newContent), 0o644)
+	err = fileutil.WriteFile(filePath, []byte(newContent))
 	if err != nil {
 		return ToolResponse{}, fmt.Errorf("failed to write file: %w", err)
 	}
@@ -337,50 +365,78 @@ func (e *editTool) deleteContent(ctx context.Context, filePath, oldString string
 }
 
 func (e *editTool) replaceContent(ctx context.Context, filePath, oldString, newString string) (ToolResponse, error) {
-	fileInfo, err := os.Stat(filePath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return NewTextErrorResponse(fmt.Sprintf("file not found: %s", filePath)), nil
-		}
-		return ToolResponse{}, fmt.Errorf("failed to access file: %w", err)
+	oldContent, failed, err := readForEdit(filePath)
+	if failed != nil || err != nil {
+		return *failed, err
 	}
 
-	if fileInfo.IsDir() {
-		return NewTextErrorResponse(fmt.Sprintf("path is a directory, not a file: %s", filePath)), nil
+	index := strings.Index(oldContent, oldString)
+	if index == -1 {
+		return NewTextErrorResponse("old_string not found in file. Make sure it matches exactly, including whitespace and line breaks"), nil
 	}
 
-	if getLastReadTime(filePath).IsZero() {
-		return NewTextErrorResponse("you must read the file before editing it. Use the View tool first"), nil
+	lastIndex := strings.LastIndex(oldContent, oldString)
+	if index != lastIndex {
+		return NewTextErrorResponse("old_string appears multiple times in the file. Please provide more context to ensure a unique match"), nil
+	}
+
+	newContent := oldContent[:index] + newString + oldContent[index+len(oldString):]
+	return e.writeEdit(ctx, filePath, oldContent, newContent, "Replace content in file", "Content replaced in file")
+}
+
+// replaceBlocks applies SEARCH/REPLACE blocks to a file
+func (e *editTool) replaceBlocks(ctx context.Context, filePath, blocksText string) (ToolResponse, error) {
+	blocks, err := parseSearchReplaceBlocks(blocksText)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
 	}
 
-	modTime := fileInfo.ModTime()
-	lastRead := getLastReadTime(filePath)
-	if modTime.After(lastRead) {
-		return NewTextErrorResponse(
-			fmt.Sprintf("file %s has been modified since it was last read (mod time: %s, last read: %s)",
-				filePath, modTime.Format(time.RFC3339), lastRead.Format(time.RFC3339),
-			)), nil
+	oldContent, failed, err := readForEdit(filePath)
+	if failed != nil || err != nil {
+		return *failed, err
 	}
 
-	content, err := os.ReadFile(filePath)
+	newContent, err := applySearchReplaceBlocks(oldContent, blocks)
 	if err != nil {
-		return ToolResponse{}, fmt.Errorf("failed to read file: %w", err)
+		return NewTextErrorResponse(fmt.Sprintf("%s: %s", filePath, err)), nil
 	}
+	return e.writeEdit(ctx, filePath, oldContent, newContent,
+		fmt.Sprintf("Apply %d SEARCH/REPLACE blocks to file", len(blocks)), "Content replaced in file")
+}
 
-	oldContent := string(content)
+// readForEdit reads a file the model has viewed, returning a response to
+// send back instead when it can't be edited
+func readForEdit(filePath string) (string, *ToolResponse, error) {
+	fail := func(msg string) (string, *ToolResponse, error) {
+		response := NewTextErrorResponse(msg)
+		return "", &response, nil
+	}
 
-	index := strings.Index(oldContent, oldString)
-	if index == -1 {
-		return NewTextErrorResponse("old_string not found in file. Make sure it matches exactly, including whitespace and line breaks"), nil
+	fileInfo, err := os.Stat(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fail(fmt.Sprintf("file not found: %s", filePath))
+		}
+		return "", &ToolResponse{}, fmt.Errorf("failed to access file: %w", err)
 	}
 
-	lastIndex := strings.LastIndex(oldContent, oldString)
-	if index != lastIndex {
-		return NewTextErrorResponse("old_string appears multiple times in the file. Please provide more context to ensure a unique match"), nil
+	if fileInfo.IsDir() {
+		return fail(fmt.Sprintf("path is a directory, not a file: %s", filePath))
 	}
 
-	newContent := oldContent[:index] + newString + oldContent[index+len(oldString):]
+	if getLastReadTime(filePath).IsZero() {
+		return fail("you must read the file before editing it. Use the View tool first")
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", &ToolResponse{}, fmt.Errorf("failed to read file: %w", err)
+	}
+	return string(content), nil, nil
+}
 
+// writeEdit writes the edited content of a file once permission is granted
+func (e *editTool) writeEdit(ctx context.Context, filePath, oldContent, newContent, action, done string) (ToolResponse, error) {
 	if oldContent == newContent {
 		return NewTextErrorResponse("new content is the same as old content. No changes made."), nil
 	}
@@ -389,12 +445,23 @@ func (e *editTool) replaceContent(ctx context.Context, filePath, oldString, newS
 	if sessionID == "" || messageID == "" {
 		return ToolResponse{}, fmt.Errorf("session ID and message ID are required for creating a new file")
 	}
+
+	newContent, conflict := resolveConflict(e.permissions, sessionID, EditToolName, filePath, oldContent, newContent)
+	if conflict != nil {
+		return *conflict, nil
+	}
+
+	newContent, err := applyPolicies(ctx, filePath, oldContent, newContent)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+
 	diff, additions, removals := diff.GenerateDiff(
 		oldContent,
 		newContent,
 		filePath,
 	)
-	rootDir := config.WorkingDirectory()
+	rootDir := workingDir(ctx)
 	permissionPath := filepath.Dir(filePath)
 	if strings.HasPrefix(filePath, rootDir) {
 		permissionPath = rootDir
@@ -405,7 +472,7 @@ func (e *editTool) replaceContent(ctx context.Context, filePath, oldString, newS
 			Path:        permissionPath,
 			ToolName:    EditToolName,
 			Action:      "write",
-			Description: fmt.Sprintf("Replace content in file %s", filePath),
+			Description: fmt.Sprintf("%s %s", action, filePath),
 			Params: EditPermissionsParams{
 				FilePath: filePath,
 				Diff:     diff,
@@ -416,7 +483,7 @@ func (e *editTool) replaceContent(ctx context.Context, filePath, oldString, newS
 		return ToolResponse{}, ErrorPermissionDenied
 	}
 
-	err = os.WriteFile(filePath, []byte(newContent), 0o644)
+	err = fileutil.WriteFile(filePath, []byte(newContent))
 	if err != nil {
 		return ToolResponse{}, fmt.Errorf("failed to write file: %w", err)
 	}
@@ -428,7 +495,7 @@ func (e *editTool) replaceContent(ctx context.Context, filePath, oldString, newS
 	recordFileRead(filePath)
 
 	return WithResponseMetadata(
-		NewTextResponse("Content replaced in file: "+filePath),
+		NewTextResponse(done+": "+filePath),
 		EditResponseMetadata{
 			Diff:      diff,
 			Additions: additions,
@@ -1,15 +1,23 @@
 package tools
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
 	"sync"
 	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/fileutil"
 )
 
 // File record to track when files were read/written
 type fileRecord struct {
-	path      string
-	readTime  time.Time
-	writeTime time.Time
+	path        string
+	readTime    time.Time
+	writeTime   time.Time
+	readHash    string // Hash of the content when last read, to notice outside edits
+	readContent string // Content when last read, the base of a merge
+	readKept    bool   // readContent was kept; it isn't for large files
 }
 
 var (
@@ -18,6 +26,8 @@ var (
 )
 
 func recordFileRead(path string) {
+	data, err := os.ReadFile(path)
+
 	fileRecordMutex.Lock()
 	defer fileRecordMutex.Unlock()
 
@@ -26,9 +36,35 @@ func recordFileRead(path string) {
 		record = fileRecord{path: path}
 	}
 	record.readTime = time.Now()
+	record.readHash, record.readContent, record.readKept = "", "", false
+	if err == nil {
+		record.readHash = contentHash(string(data))
+		if int64(len(data)) <= fileutil.MaxFileSize() {
+			record.readContent, record.readKept = string(data), true
+		}
+	}
 	fileRecords[path] = record
 }
 
+// changedSinceRead reports whether current differs from the content last
+// read from path. The content last read is returned too, with whether it was
+// kept.
+func changedSinceRead(path, current string) (changed bool, base string, kept bool) {
+	fileRecordMutex.RLock()
+	defer fileRecordMutex.RUnlock()
+
+	record, exists := fileRecords[path]
+	if !exists || record.readHash == "" || record.readHash == contentHash(current) {
+		return false, "", false
+	}
+	return true, record.readContent, record.readKept
+}
+
+func contentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
 func getLastReadTime(path string) time.Time {
 	fileRecordMutex.RLock()
 	defer fileRecordMutex.RUnlock()
@@ -10,7 +10,6 @@ import (
 	"sort"
 	"strings"
 
-	"github.com/entrepeneur4lyf/codeforge/internal/config"
 	"github.com/entrepeneur4lyf/codeforge/internal/fileutil"
 	"github.com/entrepeneur4lyf/codeforge/internal/logging"
 )
@@ -99,7 +98,7 @@ func (g *globTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error)
 
 	searchPath := params.Path
 	if searchPath == "" {
-		searchPath = config.WorkingDirectory()
+		searchPath = workingDir(ctx)
 	}
 
 	files, truncated, err := globFiles(params.Pattern, searchPath, 100)
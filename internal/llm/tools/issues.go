@@ -0,0 +1,299 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/git"
+	"github.com/entrepeneur4lyf/codeforge/internal/issues"
+)
+
+type IssueParams struct {
+	Number int `json:"number"`
+}
+
+type IssueCommentParams struct {
+	Number int    `json:"number"`
+	Body   string `json:"body"`
+}
+
+type PullRequestParams struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	Head  string `json:"head,omitempty"`
+	Base  string `json:"base,omitempty"`
+	Draft bool   `json:"draft,omitempty"`
+}
+
+// issueTracker finds the tracker of the project's repository
+type issueTracker func(ctx context.Context) (issues.Tracker, error)
+
+func detectTracker(ctx context.Context) (issues.Tracker, error) {
+	return issues.Detect(ctx, workingDir(ctx))
+}
+
+type issueTool struct {
+	tracker issueTracker
+}
+
+type issueCommentTool struct {
+	tracker     issueTracker
+	permissions permissionService
+}
+
+type pullRequestTool struct {
+	tracker     issueTracker
+	permissions permissionService
+	push        func(ctx context.Context, branch string) error
+}
+
+const (
+	IssueToolName        = "issue"
+	issueToolDescription = `Fetches an issue from the project's GitHub or GitLab repository, with its labels and comments.
+
+WHEN TO USE THIS TOOL:
+- Use when the user refers to an issue by number, such as "fix #123"
+- Helpful for reading the reported problem and the discussion so far
+
+HOW TO USE:
+- Provide the issue number
+
+LIMITATIONS:
+- The repository is found from the git remote named origin
+- Needs GITHUB_TOKEN for GitHub or GITLAB_TOKEN for GitLab
+- Returns at most the first 100 comments`
+
+	IssueCommentToolName        = "issue_comment"
+	issueCommentToolDescription = `Posts a comment on an issue of the project's GitHub or GitLab repository.
+
+WHEN TO USE THIS TOOL:
+- Use to report findings on an issue, such as the root cause and the fix, when the user asks you to
+
+HOW TO USE:
+- Provide the issue number and the comment in Markdown
+
+LIMITATIONS:
+- The user is asked to approve every comment before it is posted
+- Needs GITHUB_TOKEN for GitHub or GITLAB_TOKEN for GitLab`
+
+	PullRequestToolName        = "pull_request"
+	pullRequestToolDescription = `Pushes a branch and opens a pull request (a merge request on GitLab) from it.
+
+WHEN TO USE THIS TOOL:
+- Use when the user asks you to open a pull request for changes you have committed on a branch
+
+HOW TO USE:
+- Provide a title and a description in Markdown; mention issues it fixes, such as "Fixes #123"
+- The head branch defaults to the current branch and the base to the repository's default branch
+- Set draft to open it as a draft
+
+LIMITATIONS:
+- Commit the changes first; uncommitted changes are not included
+- The user is asked to approve the push and the pull request
+- Needs GITHUB_TOKEN for GitHub or GITLAB_TOKEN for GitLab`
+)
+
+func NewIssueTool() BaseTool {
+	return &issueTool{tracker: detectTracker}
+}
+
+func NewIssueCommentTool(permissions permissionService) BaseTool {
+	return &issueCommentTool{tracker: detectTracker, permissions: permissions}
+}
+
+func NewPullRequestTool(permissions permissionService) BaseTool {
+	return &pullRequestTool{
+		tracker:     detectTracker,
+		permissions: permissions,
+		push: func(ctx context.Context, branch string) error {
+			return git.NewRepository(workingDir(ctx)).Push(ctx, "origin", branch)
+		},
+	}
+}
+
+func (t *issueTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        IssueToolName,
+		Description: issueToolDescription,
+		Parameters: map[string]any{
+			"number": map[string]any{
+				"type":        "number",
+				"description": "The issue number",
+			},
+		},
+		Required: []string{"number"},
+	}
+}
+
+func (t *issueTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params IssueParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse("Failed to parse issue parameters: " + err.Error()), nil
+	}
+	if params.Number <= 0 {
+		return NewTextErrorResponse("number must be a positive issue number"), nil
+	}
+
+	tracker, err := t.tracker(ctx)
+	if err != nil {
+		return NewTextErrorResponse("No issue tracker: " + err.Error()), nil
+	}
+	issue, err := tracker.Issue(ctx, params.Number)
+	if err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("Failed to fetch issue #%d: %v", params.Number, err)), nil
+	}
+	return NewTextResponse(issue.Context()), nil
+}
+
+func (t *issueCommentTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        IssueCommentToolName,
+		Description: issueCommentToolDescription,
+		Parameters: map[string]any{
+			"number": map[string]any{
+				"type":        "number",
+				"description": "The issue number",
+			},
+			"body": map[string]any{
+				"type":        "string",
+				"description": "The comment, in Markdown",
+			},
+		},
+		Required: []string{"number", "body"},
+	}
+}
+
+func (t *issueCommentTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params IssueCommentParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse("Failed to parse issue_comment parameters: " + err.Error()), nil
+	}
+	if params.Number <= 0 {
+		return NewTextErrorResponse("number must be a positive issue number"), nil
+	}
+	if strings.TrimSpace(params.Body) == "" {
+		return NewTextErrorResponse("body is required"), nil
+	}
+
+	sessionID, _ := GetContextValues(ctx)
+	if sessionID == "" {
+		return ToolResponse{}, fmt.Errorf("session ID is required for posting a comment")
+	}
+
+	tracker, err := t.tracker(ctx)
+	if err != nil {
+		return NewTextErrorResponse("No issue tracker: " + err.Error()), nil
+	}
+
+	// Comments are public, so every one is confirmed
+	approved := t.permissions.Confirm(CreatePermissionRequest{
+		SessionID:   sessionID,
+		Path:        workingDir(ctx),
+		ToolName:    IssueCommentToolName,
+		Action:      "comment",
+		Description: fmt.Sprintf("Post a comment on issue #%d of %s", params.Number, tracker.Name()),
+		Params:      params,
+	})
+	if !approved {
+		return ToolResponse{}, ErrorPermissionDenied
+	}
+
+	url, err := tracker.Comment(ctx, params.Number, params.Body)
+	if err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("Failed to comment on issue #%d: %v", params.Number, err)), nil
+	}
+	return NewTextResponse("Posted " + url), nil
+}
+
+func (t *pullRequestTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        PullRequestToolName,
+		Description: pullRequestToolDescription,
+		Parameters: map[string]any{
+			"title": map[string]any{
+				"type":        "string",
+				"description": "The pull request title",
+			},
+			"body": map[string]any{
+				"type":        "string",
+				"description": "The description, in Markdown",
+			},
+			"head": map[string]any{
+				"type":        "string",
+				"description": "The branch with the changes (default: the current branch)",
+			},
+			"base": map[string]any{
+				"type":        "string",
+				"description": "The branch to merge into (default: the repository's default branch)",
+			},
+			"draft": map[string]any{
+				"type":        "boolean",
+				"description": "Open the pull request as a draft",
+			},
+		},
+		Required: []string{"title", "body"},
+	}
+}
+
+func (t *pullRequestTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params PullRequestParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse("Failed to parse pull_request parameters: " + err.Error()), nil
+	}
+	if strings.TrimSpace(params.Title) == "" {
+		return NewTextErrorResponse("title is required"), nil
+	}
+
+	sessionID, _ := GetContextValues(ctx)
+	if sessionID == "" {
+		return ToolResponse{}, fmt.Errorf("session ID is required for opening a pull request")
+	}
+
+	if params.Head == "" {
+		branch, err := git.NewRepository(workingDir(ctx)).CurrentBranch(ctx)
+		if err != nil {
+			return NewTextErrorResponse("Failed to find the current branch: " + err.Error()), nil
+		}
+		params.Head = branch
+	}
+	if params.Head == "HEAD" {
+		return NewTextErrorResponse("HEAD is detached; check out a branch or set head"), nil
+	}
+	if params.Base != "" && params.Base == params.Head {
+		return NewTextErrorResponse("head and base are the same branch"), nil
+	}
+
+	tracker, err := t.tracker(ctx)
+	if err != nil {
+		return NewTextErrorResponse("No issue tracker: " + err.Error()), nil
+	}
+
+	approved := t.permissions.Confirm(CreatePermissionRequest{
+		SessionID:   sessionID,
+		Path:        workingDir(ctx),
+		ToolName:    PullRequestToolName,
+		Action:      "pull_request",
+		Description: fmt.Sprintf("Push %s to origin and open a pull request on %s: %s", params.Head, tracker.Name(), params.Title),
+		Params:      params,
+	})
+	if !approved {
+		return ToolResponse{}, ErrorPermissionDenied
+	}
+
+	if err := t.push(ctx, params.Head); err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+	url, err := tracker.CreatePullRequest(ctx, issues.PullRequest{
+		Title: params.Title,
+		Body:  params.Body,
+		Head:  params.Head,
+		Base:  params.Base,
+		Draft: params.Draft,
+	})
+	if err != nil {
+		return NewTextErrorResponse("Failed to open the pull request: " + err.Error()), nil
+	}
+	return NewTextResponse("Opened " + url), nil
+}
@@ -6,11 +6,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"time"
 
-	"github.com/entrepeneur4lyf/codeforge/internal/config"
 	"github.com/entrepeneur4lyf/codeforge/internal/lsp"
 	"github.com/entrepeneur4lyf/codeforge/internal/diff"
+	"github.com/entrepeneur4lyf/codeforge/internal/fileutil"
 )
 
 type PatchParams struct {
@@ -100,7 +99,7 @@ func (p *patchTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 	for _, filePath := range filesToRead {
 		absPath := filePath
 		if !filepath.IsAbs(absPath) {
-			wd := config.WorkingDirectory()
+			wd := workingDir(ctx)
 			absPath = filepath.Join(wd, absPath)
 		}
 
@@ -119,15 +118,6 @@ func (p *patchTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 		if fileInfo.IsDir() {
 			return NewTextErrorResponse(fmt.Sprintf("path is a directory, not a file: %s", absPath)), nil
 		}
-
-		modTime := fileInfo.ModTime()
-		lastRead := getLastReadTime(absPath)
-		if modTime.After(lastRead) {
-			return NewTextErrorResponse(
-				fmt.Sprintf("file %s has been modified since it was last read (mod time: %s, last read: %s)",
-					absPath, modTime.Format(time.RFC3339), lastRead.Format(time.RFC3339),
-				)), nil
-		}
 	}
 
 	// Check for new files to ensure they don't already exist
@@ -135,7 +125,7 @@ func (p *patchTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 	for _, filePath := range filesToAdd {
 		absPath := filePath
 		if !filepath.IsAbs(absPath) {
-			wd := config.WorkingDirectory()
+			wd := workingDir(ctx)
 			absPath = filepath.Join(wd, absPath)
 		}
 
@@ -152,7 +142,7 @@ func (p *patchTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 	for _, filePath := range filesToRead {
 		absPath := filePath
 		if !filepath.IsAbs(absPath) {
-			wd := config.WorkingDirectory()
+			wd := workingDir(ctx)
 			absPath = filepath.Join(wd, absPath)
 		}
 
@@ -185,6 +175,59 @@ func (p *patchTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 		return ToolResponse{}, fmt.Errorf("session ID and message ID are required for creating a patch")
 	}
 
+	// Every file the patch changes must be in the workspace
+	for path, change := range commit.Changes {
+		if _, err := checkWritePath(ctx, path); err != nil {
+			return NewTextErrorResponse(err.Error()), nil
+		}
+		if change.MovePath != nil {
+			if _, err := checkWritePath(ctx, *change.MovePath); err != nil {
+				return NewTextErrorResponse(err.Error()), nil
+			}
+		}
+	}
+
+	// Files changed outside CodeForge since they were read are only changed
+	// if the user says so
+	for path, change := range commit.Changes {
+		if change.OldContent == nil {
+			continue
+		}
+		absPath := path
+		if !filepath.IsAbs(absPath) {
+			absPath = filepath.Join(workingDir(ctx), absPath)
+		}
+		proposed := ""
+		if change.NewContent != nil {
+			proposed = *change.NewContent
+		}
+		content, conflict := resolveConflict(p.permissions, sessionID, PatchToolName, absPath, *change.OldContent, proposed)
+		if conflict != nil {
+			return *conflict, nil
+		}
+		if change.NewContent != nil {
+			change.NewContent = &content
+			commit.Changes[path] = change
+		}
+	}
+
+	// Check added and updated files against project policies
+	for path, change := range commit.Changes {
+		if change.NewContent == nil || (change.Type != diff.ActionAdd && change.Type != diff.ActionUpdate) {
+			continue
+		}
+		oldContent := ""
+		if change.OldContent != nil {
+			oldContent = *change.OldContent
+		}
+		content, err := applyPolicies(ctx, path, oldContent, *change.NewContent)
+		if err != nil {
+			return NewTextErrorResponse(err.Error()), nil
+		}
+		change.NewContent = &content
+		commit.Changes[path] = change
+	}
+
 	// Request permission for all changes
 	for path, change := range commit.Changes {
 		switch change.Type {
@@ -258,26 +301,17 @@ func (p *patchTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 
 	// Apply the changes to the filesystem
 	err = diff.ApplyCommit(commit, func(path string, content string) error {
-		absPath := path
-		if !filepath.IsAbs(absPath) {
-			wd := config.WorkingDirectory()
-			absPath = filepath.Join(wd, absPath)
-		}
-
-		// Create parent directories if needed
-		dir := filepath.Dir(absPath)
-		if err := os.MkdirAll(dir, 0o755); err != nil {
-			return fmt.Errorf("failed to create parent directories for %s: %w", absPath, err)
+		absPath, err := checkWritePath(ctx, path)
+		if err != nil {
+			return err
 		}
-
-		return os.WriteFile(absPath, []byte(content), 0o644)
+		return fileutil.WriteFile(absPath, []byte(content))
 	}, func(path string) error {
-		absPath := path
-		if !filepath.IsAbs(absPath) {
-			wd := config.WorkingDirectory()
-			absPath = filepath.Join(wd, absPath)
+		absPath, err := checkWritePath(ctx, path)
+		if err != nil {
+			return err
 		}
-		return os.Remove(absPath)
+		return fileutil.RemoveFile(absPath)
 	})
 	if err != nil {
 		return NewTextErrorResponse(fmt.Sprintf("failed to apply patch: %s", err)), nil
@@ -291,7 +325,7 @@ func (p *patchTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 	for path, change := range commit.Changes {
 		absPath := path
 		if !filepath.IsAbs(absPath) {
-			wd := config.WorkingDirectory()
+			wd := workingDir(ctx)
 			absPath = filepath.Join(wd, absPath)
 		}
 		changedFiles = append(changedFiles, absPath)
@@ -45,16 +45,7 @@ func (p *PermissionAdapter) Request(opts CreatePermissionRequest) bool {
 
 	ctx := context.Background()
 
-	// Map tool actions to permission types
-	permType := permissions.PermissionFileRead
-	switch opts.Action {
-	case "write", "create", "update":
-		permType = permissions.PermissionFileWrite
-	case "delete":
-		permType = permissions.PermissionFileDelete
-	case "execute", "run":
-		permType = permissions.PermissionCodeExecute
-	}
+	permType := actionPermission(opts.Action, permissions.PermissionFileRead)
 
 	// Ensure path is absolute
 	path := opts.Path
@@ -110,6 +101,54 @@ func (p *PermissionAdapter) Request(opts CreatePermissionRequest) bool {
 	return result.Allowed
 }
 
+// Confirm asks the user a question about an operation, such as whether to
+// overwrite edits made outside CodeForge or post a comment. Unlike Request, permissions granted
+// earlier don't answer it, and without a permission service to ask the answer
+// is no.
+func (p *PermissionAdapter) Confirm(opts CreatePermissionRequest) bool {
+	if p.service == nil {
+		return false
+	}
+
+	path := opts.Path
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(config.WorkingDirectory(), path)
+	}
+
+	resp, err := p.service.RequestPermission(context.Background(), &permissions.PermissionRequest{
+		SessionID:   opts.SessionID,
+		Type:        actionPermission(opts.Action, permissions.PermissionFileWrite),
+		Resource:    path,
+		Reason:      opts.Description,
+		Scope:       permissions.ScopeOneTime,
+		RequestedAt: time.Now(),
+		Context: map[string]interface{}{
+			"tool":   opts.ToolName,
+			"action": opts.Action,
+			"params": opts.Params,
+		},
+	})
+	if err != nil {
+		return false
+	}
+	return resp.Status == permissions.StatusApproved
+}
+
+// actionPermission maps a tool action to the permission it needs
+func actionPermission(action string, fallback permissions.PermissionType) permissions.PermissionType {
+	switch action {
+	case "write", "create", "update":
+		return permissions.PermissionFileWrite
+	case "delete":
+		return permissions.PermissionFileDelete
+	case "execute", "run":
+		return permissions.PermissionCodeExecute
+	case "comment", "pull_request":
+		return permissions.PermissionGitWrite
+	}
+	return fallback
+}
+
 // AutoApproveSession sets a session to auto-approve all permissions
 func (p *PermissionAdapter) AutoApproveSession(sessionID string) {
 	if p.service != nil {
@@ -0,0 +1,38 @@
+package tools
+
+import (
+	"context"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+	"github.com/entrepeneur4lyf/codeforge/internal/logging"
+	"github.com/entrepeneur4lyf/codeforge/internal/policy"
+)
+
+// applyPolicies checks a proposed change against the project's policies and
+// returns the content to write, with auto-fixes applied. Blocking violations
+// are returned as an error so the model can correct its change.
+func applyPolicies(ctx context.Context, filePath, oldContent, newContent string) (string, error) {
+	var base policy.Policies
+	if cfg := config.Get(); cfg != nil {
+		base = cfg.Policies
+	}
+	engine, err := policy.Load(workingDir(ctx), base)
+	if err != nil {
+		return "", err
+	}
+
+	result := engine.CheckChange(filePath, oldContent, newContent)
+	if err := result.Error(); err != nil {
+		return "", err
+	}
+
+	for _, v := range result.Violations {
+		if v.Fixed {
+			logging.Info("Policy auto-fix applied", "violation", v.String())
+		} else {
+			logging.Warn("Policy warning", "violation", v.String())
+		}
+	}
+
+	return result.Content, nil
+}
@@ -0,0 +1,245 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/analysis"
+	"github.com/entrepeneur4lyf/codeforge/internal/fileutil"
+	"github.com/entrepeneur4lyf/codeforge/internal/lsp"
+	"github.com/entrepeneur4lyf/codeforge/internal/vectordb"
+)
+
+type ReadFileParams struct {
+	FilePath  string `json:"file_path"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+	Symbol    string `json:"symbol"`
+}
+
+type ReadFileResponseMetadata struct {
+	FilePath  string `json:"file_path"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+	Lines     int    `json:"lines"`
+}
+
+type readFileTool struct {
+	lspClients map[string]*lsp.Client
+}
+
+const (
+	ReadFileToolName    = "read_file"
+	maxOutlineSymbols   = 200
+	readFileDescription = `Reads part of a file by line range or by symbol, without loading the whole file into the conversation.
+
+WHEN TO USE THIS TOOL:
+- Use when a large file was attached as an outline instead of its full content
+- Use to read one function, type or class from a big file
+- Use to read a specific line range reported by an error, search result or outline
+
+HOW TO USE:
+- Provide the path to the file
+- Either give start_line and end_line (1-based, inclusive), or the name of a symbol
+- With only start_line, up to 2000 lines are returned from that line
+
+FEATURES:
+- Works on files of any size; lines are streamed rather than read at once
+- Symbols are resolved with the language server when one is running, and by
+  matching declarations otherwise
+- Output includes line numbers so follow-up reads can continue where this one stopped
+
+LIMITATIONS:
+- At most 2000 lines are returned per call
+- Cannot read binary files`
+)
+
+func NewReadFileTool(lspClients map[string]*lsp.Client) BaseTool {
+	return &readFileTool{
+		lspClients,
+	}
+}
+
+func (r *readFileTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        ReadFileToolName,
+		Description: readFileDescription,
+		Parameters: map[string]any{
+			"file_path": map[string]any{
+				"type":        "string",
+				"description": "The path to the file to read",
+			},
+			"start_line": map[string]any{
+				"type":        "integer",
+				"description": "The first line to read (1-based)",
+			},
+			"end_line": map[string]any{
+				"type":        "integer",
+				"description": "The last line to read (inclusive)",
+			},
+			"symbol": map[string]any{
+				"type":        "string",
+				"description": "Name of a function, type or class to read instead of a line range",
+			},
+		},
+		Required: []string{"file_path"},
+	}
+}
+
+// Run implements Tool.
+func (r *readFileTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params ReadFileParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+
+	if params.FilePath == "" {
+		return NewTextErrorResponse("file_path is required"), nil
+	}
+	if params.Symbol == "" && params.StartLine <= 0 {
+		return NewTextErrorResponse("either start_line or symbol is required"), nil
+	}
+
+	filePath := params.FilePath
+	if !filepath.IsAbs(filePath) {
+		filePath = filepath.Join(workingDir(ctx), filePath)
+	}
+
+	fileInfo, err := os.Stat(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return NewTextErrorResponse(fmt.Sprintf("File not found: %s", filePath)), nil
+		}
+		return ToolResponse{}, fmt.Errorf("error accessing file: %w", err)
+	}
+	if fileInfo.IsDir() {
+		return NewTextErrorResponse(fmt.Sprintf("Path is a directory, not a file: %s", filePath)), nil
+	}
+	if fileutil.IsBinaryFile(filePath) {
+		return NewTextErrorResponse(fmt.Sprintf("Cannot read binary file: %s", filePath)), nil
+	}
+
+	start, end := params.StartLine, params.EndLine
+	if params.Symbol != "" {
+		start, end, err = r.symbolRange(ctx, filePath, params.Symbol)
+		if err != nil {
+			return NewTextErrorResponse(err.Error()), nil
+		}
+	}
+
+	limit := DefaultReadLimit
+	if end >= start {
+		limit = min(end-start+1, DefaultReadLimit)
+	}
+
+	content, lineCount, err := readTextFile(filePath, start-1, limit)
+	if err != nil {
+		return ToolResponse{}, fmt.Errorf("error reading file: %w", err)
+	}
+	if start > lineCount {
+		return NewTextErrorResponse(fmt.Sprintf("start_line %d is past the end of the file (%d lines)", start, lineCount)), nil
+	}
+
+	read := len(strings.Split(content, "\n"))
+	last := start + read - 1
+	output := "<file>\n"
+	output += addLineNumbers(content, start)
+	if last < lineCount {
+		output += fmt.Sprintf("\n\n(Showing lines %d-%d of %d. Use 'start_line' to read further)", start, last, lineCount)
+	}
+	output += "\n</file>\n"
+
+	notifyLspOpenFile(ctx, filePath, r.lspClients)
+	recordFileRead(filePath)
+	return WithResponseMetadata(
+		NewTextResponse(output),
+		ReadFileResponseMetadata{
+			FilePath:  filePath,
+			StartLine: start,
+			EndLine:   last,
+			Lines:     lineCount,
+		},
+	), nil
+}
+
+// symbolRange finds the lines spanned by the named symbol. When the
+// extractor only knows where a symbol starts, it is taken to end just before
+// the next symbol.
+func (r *readFileTool) symbolRange(ctx context.Context, filePath, name string) (int, int, error) {
+	symbols, err := fileSymbols(ctx, filePath)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for i, sym := range symbols {
+		if sym.Name != name && !strings.HasSuffix(sym.Name, "."+name) {
+			continue
+		}
+		start, end := sym.Location.StartLine, sym.Location.EndLine
+		if end <= start {
+			end = 0
+			for _, next := range symbols[i+1:] {
+				if next.Location.StartLine > start {
+					end = next.Location.StartLine - 1
+					break
+				}
+			}
+		}
+		return start, end, nil
+	}
+	return 0, 0, fmt.Errorf("symbol %q not found in %s", name, filePath)
+}
+
+// fileSymbols extracts the symbols declared in a file, ordered by line
+func fileSymbols(ctx context.Context, filePath string) ([]vectordb.Symbol, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	symbols, err := analysis.NewSymbolExtractor().ExtractSymbols(ctx, filePath, string(content), lsp.DetectLanguageID(filePath))
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(symbols, func(i, j int) bool {
+		return symbols[i].Location.StartLine < symbols[j].Location.StartLine
+	})
+	return symbols, nil
+}
+
+// FileOutline describes a file too large to attach: its size, line count
+// and top-level symbols with their line numbers, followed by a pointer to
+// the read_file tool so the model can fetch the parts it needs.
+func FileOutline(ctx context.Context, filePath string) (string, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return "", err
+	}
+	_, lineCount, err := readTextFile(filePath, 0, 0)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "[outline only: %s, %d lines]\n", fileutil.FormatSize(info.Size()), lineCount)
+
+	symbols, err := fileSymbols(ctx, filePath)
+	if err == nil && len(symbols) > 0 {
+		b.WriteString("Symbols:\n")
+		for i, sym := range symbols {
+			if i == maxOutlineSymbols {
+				fmt.Fprintf(&b, "  ... and %d more\n", len(symbols)-i)
+				break
+			}
+			fmt.Fprintf(&b, "  %d: %s %s\n", sym.Location.StartLine, sym.Kind, sym.Name)
+		}
+	}
+
+	fmt.Fprintf(&b, "Use the %s tool with start_line/end_line or symbol to read the parts you need.\n", ReadFileToolName)
+	return b.String(), nil
+}
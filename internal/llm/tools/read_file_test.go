@@ -0,0 +1,94 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const readFileSource = `package sample
+
+type Widget struct {
+	Name string
+}
+
+func NewWidget(name string) *Widget {
+	return &Widget{Name: name}
+}
+
+func (w *Widget) Rename(name string) {
+	w.Name = name
+}
+`
+
+func runReadFile(t *testing.T, params ReadFileParams) ToolResponse {
+	t.Helper()
+	input, err := json.Marshal(params)
+	require.NoError(t, err)
+
+	resp, err := NewReadFileTool(nil).Run(context.Background(), ToolCall{Name: ReadFileToolName, Input: string(input)})
+	require.NoError(t, err)
+	return resp
+}
+
+func TestReadFileTool_Run(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sample.go")
+	require.NoError(t, os.WriteFile(path, []byte(readFileSource), 0644))
+
+	t.Run("line range", func(t *testing.T) {
+		resp := runReadFile(t, ReadFileParams{FilePath: path, StartLine: 3, EndLine: 5})
+		assert.False(t, resp.IsError)
+		assert.Contains(t, resp.Content, "     3|type Widget struct {")
+		assert.Contains(t, resp.Content, "     5|}")
+		assert.NotContains(t, resp.Content, "NewWidget")
+		assert.Contains(t, resp.Content, "Showing lines 3-5 of 13")
+	})
+
+	t.Run("symbol", func(t *testing.T) {
+		resp := runReadFile(t, ReadFileParams{FilePath: path, Symbol: "NewWidget"})
+		assert.False(t, resp.IsError)
+		assert.Contains(t, resp.Content, "func NewWidget(name string) *Widget {")
+		assert.NotContains(t, resp.Content, "Rename")
+	})
+
+	t.Run("unknown symbol", func(t *testing.T) {
+		resp := runReadFile(t, ReadFileParams{FilePath: path, Symbol: "Missing"})
+		assert.True(t, resp.IsError)
+	})
+
+	t.Run("past end of file", func(t *testing.T) {
+		resp := runReadFile(t, ReadFileParams{FilePath: path, StartLine: 100})
+		assert.True(t, resp.IsError)
+	})
+
+	t.Run("no range or symbol", func(t *testing.T) {
+		resp := runReadFile(t, ReadFileParams{FilePath: path})
+		assert.True(t, resp.IsError)
+	})
+}
+
+func TestFileOutline(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "big.go")
+
+	var b strings.Builder
+	b.WriteString("package big\n")
+	for i := 0; i < 50; i++ {
+		fmt.Fprintf(&b, "\nfunc Handler%d() {\n}\n", i)
+	}
+	require.NoError(t, os.WriteFile(path, []byte(b.String()), 0644))
+
+	outline, err := FileOutline(context.Background(), path)
+	require.NoError(t, err)
+	assert.Contains(t, outline, "151 lines")
+	assert.Contains(t, outline, "3: function Handler0")
+	assert.Contains(t, outline, ReadFileToolName)
+}
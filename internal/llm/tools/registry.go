@@ -1,6 +1,8 @@
 package tools
 
 import (
+	"fmt"
+
 	"github.com/entrepeneur4lyf/codeforge/internal/lsp"
 	"github.com/entrepeneur4lyf/codeforge/internal/permissions"
 )
@@ -26,16 +28,20 @@ func NewToolRegistry(lspManager *lsp.Manager, permissionService *permissions.Per
 	
 	// Create tools
 	tools := map[string]BaseTool{
-		ViewToolName:        NewViewTool(lspClients),
-		EditToolName:        NewEditToolAdapter(lspClients, permAdapter, historyService),
-		WriteToolName:       NewWriteToolAdapter(lspClients, permAdapter, historyService),
-		BashToolName:        NewBashToolAdapter(permAdapter),
-		DiagnosticsToolName: NewDiagnosticsTool(lspClients),
-		GlobToolName:        NewGlobTool(),
-		GrepToolName:        NewGrepTool(),
-		LSToolName:          NewLsTool(),
-		PatchToolName:       NewPatchToolAdapter(lspClients, permAdapter, historyService),
-		FetchToolName:       NewFetchToolAdapter(permAdapter),
+		ViewToolName:         NewViewTool(lspClients),
+		ReadFileToolName:     NewReadFileTool(lspClients),
+		EditToolName:         NewEditToolAdapter(lspClients, permAdapter, historyService),
+		WriteToolName:        NewWriteToolAdapter(lspClients, permAdapter, historyService),
+		BashToolName:         NewBashToolAdapter(permAdapter),
+		DiagnosticsToolName:  NewDiagnosticsTool(lspClients),
+		GlobToolName:         NewGlobTool(),
+		GrepToolName:         NewGrepTool(),
+		LSToolName:           NewLsTool(),
+		PatchToolName:        NewPatchToolAdapter(lspClients, permAdapter, historyService),
+		FetchToolName:        NewFetchToolAdapter(permAdapter),
+		IssueToolName:        NewIssueTool(),
+		IssueCommentToolName: NewIssueCommentTool(permAdapter),
+		PullRequestToolName:  NewPullRequestTool(permAdapter),
 	}
 	
 	return &ToolRegistry{
@@ -61,4 +67,16 @@ func (r *ToolRegistry) GetToolInfos() []ToolInfo {
 		infos = append(infos, tool.Info())
 	}
 	return infos
-}
\ No newline at end of file
+}
+// Only returns a registry holding just the named tools
+func (r *ToolRegistry) Only(names []string) (*ToolRegistry, error) {
+	tools := make(map[string]BaseTool, len(names))
+	for _, name := range names {
+		tool, exists := r.tools[name]
+		if !exists {
+			return nil, fmt.Errorf("unknown tool %q", name)
+		}
+		tools[name] = tool
+	}
+	return &ToolRegistry{tools: tools}, nil
+}
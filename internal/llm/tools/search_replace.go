@@ -0,0 +1,307 @@
+package tools
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// searchReplaceBlock is one aider-style edit:
+//
+//	<<<<<<< SEARCH
+//	text in the file
+//	=======
+//	text to put in its place
+//	>>>>>>> REPLACE
+type searchReplaceBlock struct {
+	Search  string
+	Replace string
+}
+
+const maxNearMisses = 3 // Closest regions listed when a SEARCH block matches nowhere
+
+// parseSearchReplaceBlocks reads the SEARCH/REPLACE blocks in text. Anything
+// outside the blocks, such as a file name or code fences, is ignored.
+func parseSearchReplaceBlocks(text string) ([]searchReplaceBlock, error) {
+	var blocks []searchReplaceBlock
+	var search, replace []string
+	const (
+		outside = iota
+		inSearch
+		inReplace
+	)
+	state := outside
+
+	for n, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		marker := strings.TrimSpace(line)
+		switch {
+		case isMarker(marker, '<', "SEARCH"):
+			if state != outside {
+				return nil, fmt.Errorf("line %d: SEARCH marker inside block %d; close it with >>>>>>> REPLACE first", n+1, len(blocks)+1)
+			}
+			state, search, replace = inSearch, nil, nil
+		case state == inSearch && isMarker(marker, '=', ""):
+			state = inReplace
+		case isMarker(marker, '>', "REPLACE"):
+			if state != inReplace {
+				return nil, fmt.Errorf("line %d: REPLACE marker without a SEARCH section and ======= divider before it", n+1)
+			}
+			blocks = append(blocks, searchReplaceBlock{Search: strings.Join(search, "\n"), Replace: strings.Join(replace, "\n")})
+			state = outside
+		case state == inSearch:
+			search = append(search, line)
+		case state == inReplace:
+			replace = append(replace, line)
+		}
+	}
+
+	switch {
+	case state == inSearch:
+		return nil, fmt.Errorf("block %d has no ======= divider", len(blocks)+1)
+	case state == inReplace:
+		return nil, fmt.Errorf("block %d isn't closed with >>>>>>> REPLACE", len(blocks)+1)
+	case len(blocks) == 0:
+		return nil, fmt.Errorf("no SEARCH/REPLACE blocks found; each starts with <<<<<<< SEARCH")
+	}
+	return blocks, nil
+}
+
+// isMarker reports whether line is a run of at least five of char followed by
+// word, as in "<<<<<<< SEARCH"
+func isMarker(line string, char byte, word string) bool {
+	rest := strings.TrimLeft(line, string(char))
+	return len(line)-len(rest) >= 5 && strings.TrimSpace(rest) == word
+}
+
+// applySearchReplaceBlocks applies blocks to content in order. Each SEARCH
+// text must occur once, exactly or, failing that, line for line when
+// whitespace is ignored. No block is applied unless all of them match.
+func applySearchReplaceBlocks(content string, blocks []searchReplaceBlock) (string, error) {
+	for i, block := range blocks {
+		updated, err := applySearchReplace(content, block)
+		if err != nil {
+			if len(blocks) > 1 {
+				return "", fmt.Errorf("block %d of %d: %w. No blocks were applied", i+1, len(blocks), err)
+			}
+			return "", err
+		}
+		content = updated
+	}
+	return content, nil
+}
+
+// ApplySearchReplace applies the SEARCH/REPLACE blocks in text to content,
+// all of them or, when any fails to match, none
+func ApplySearchReplace(content, text string) (string, error) {
+	blocks, err := parseSearchReplaceBlocks(text)
+	if err != nil {
+		return "", err
+	}
+	return applySearchReplaceBlocks(content, blocks)
+}
+
+func applySearchReplace(content string, block searchReplaceBlock) (string, error) {
+	if strings.TrimSpace(block.Search) == "" {
+		return "", fmt.Errorf("SEARCH section is empty; include the lines to replace")
+	}
+
+	newline := "\n"
+	if strings.Contains(content, "\r\n") {
+		newline = "\r\n"
+	}
+	search := strings.ReplaceAll(block.Search, "\n", newline)
+	replace := strings.ReplaceAll(block.Replace, "\n", newline)
+
+	switch count := strings.Count(content, search); {
+	case count == 1:
+		return strings.Replace(content, search, replace, 1), nil
+	case count > 1:
+		return "", fmt.Errorf("SEARCH text matches %d places (lines %s); include more surrounding lines to pick one",
+			count, joinLineNumbers(exactMatchLines(content, search)))
+	}
+
+	// Models often get indentation or trailing spaces wrong
+	lines := strings.Split(content, newline)
+	want := trimBlankLines(strings.Split(block.Search, "\n"))
+	matches := looseMatches(lines, want)
+	switch len(matches) {
+	case 1:
+		start, end := matches[0], matches[0]+len(want)
+		replaced := reindent(strings.Split(block.Replace, "\n"), want, lines[start:end])
+		updated := append(append(append([]string{}, lines[:start]...), replaced...), lines[end:]...)
+		return strings.Join(updated, newline), nil
+	case 0:
+		return "", fmt.Errorf("SEARCH text not found, even ignoring whitespace%s", describeNearMisses(lines, want))
+	default:
+		starts := make([]int, len(matches))
+		for i, m := range matches {
+			starts[i] = m + 1
+		}
+		return "", fmt.Errorf("SEARCH text matches %d places when whitespace is ignored (lines %s); include more surrounding lines to pick one",
+			len(matches), joinLineNumbers(starts))
+	}
+}
+
+// normalizeSpace collapses runs of whitespace and trims the ends
+func normalizeSpace(line string) string {
+	return strings.Join(strings.Fields(line), " ")
+}
+
+// looseMatches returns where want's lines occur in lines, ignoring whitespace
+func looseMatches(lines, want []string) []int {
+	var matches []int
+	for start := 0; start+len(want) <= len(lines); start++ {
+		if matchingLines(lines[start:start+len(want)], want) == len(want) {
+			matches = append(matches, start)
+		}
+	}
+	return matches
+}
+
+// matchingLines counts the lines of a that equal b's, ignoring whitespace
+func matchingLines(a, b []string) int {
+	n := 0
+	for i := range b {
+		if normalizeSpace(a[i]) == normalizeSpace(b[i]) {
+			n++
+		}
+	}
+	return n
+}
+
+// describeNearMisses lists the regions of lines most like want, so the model
+// can see what it got wrong
+func describeNearMisses(lines, want []string) string {
+	type nearMiss struct{ start, matched int }
+	var misses []nearMiss
+	for start := 0; start+len(want) <= len(lines); start++ {
+		if matched := matchingLines(lines[start:start+len(want)], want); matched > 0 {
+			misses = append(misses, nearMiss{start, matched})
+		}
+	}
+	if len(misses) == 0 {
+		return ". No line of it is in the file; read the file again before editing"
+	}
+	sort.SliceStable(misses, func(i, j int) bool { return misses[i].matched > misses[j].matched })
+
+	var b strings.Builder
+	b.WriteString(". Closest regions of the file:")
+	var shown []int
+	for _, miss := range misses {
+		// Skip regions overlapping a closer one already shown
+		overlaps := false
+		for _, start := range shown {
+			if start > miss.start-len(want) && start < miss.start+len(want) {
+				overlaps = true
+				break
+			}
+		}
+		if overlaps {
+			continue
+		}
+		fmt.Fprintf(&b, "\n\nLines %d-%d (%d of %d lines match):\n%s",
+			miss.start+1, miss.start+len(want), miss.matched, len(want),
+			strings.Join(lines[miss.start:miss.start+len(want)], "\n"))
+		if shown = append(shown, miss.start); len(shown) == maxNearMisses {
+			break
+		}
+	}
+	return b.String()
+}
+
+// trimBlankLines drops blank lines at the start and end
+func trimBlankLines(lines []string) []string {
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// indentOf returns a line's leading whitespace
+func indentOf(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+// reindent carries the file's indentation over to replacement lines whose
+// SEARCH text matched only loosely. When the SEARCH lines are all shifted from
+// the file's by the same prefix, the replacement is shifted back the same way.
+// Otherwise lines copied from the SEARCH text take the indentation of the file
+// line they matched, changed lines of a same-length replacement that of the
+// line they replace, and other lines that of the line before them.
+func reindent(replace, search, matched []string) []string {
+	out := make([]string, len(replace))
+	if from, to, ok := commonShift(search, matched); ok {
+		for i, line := range replace {
+			if strings.TrimSpace(line) != "" && strings.HasPrefix(line, from) {
+				line = to + line[len(from):]
+			}
+			out[i] = line
+		}
+		return out
+	}
+
+	indent := ""
+	for i, line := range replace {
+		body := strings.TrimLeft(line, " \t")
+		if body == "" {
+			out[i] = line
+			continue
+		}
+		copied := false
+		for j := range search {
+			if normalizeSpace(search[j]) == normalizeSpace(line) {
+				indent, copied = indentOf(matched[j]), true
+				break
+			}
+		}
+		if !copied && len(replace) == len(search) {
+			indent = indentOf(matched[i])
+		}
+		out[i] = indent + body
+	}
+	return out
+}
+
+// commonShift reports whether each non-blank SEARCH line is indented as its
+// file line is but with the prefix from in place of to
+func commonShift(search, matched []string) (from, to string, ok bool) {
+	first := true
+	for i := range search {
+		if strings.TrimSpace(search[i]) == "" {
+			continue
+		}
+		s, f := indentOf(search[i]), indentOf(matched[i])
+		if first {
+			from, to, first = s, f, false
+		}
+		if !strings.HasPrefix(s, from) || !strings.HasPrefix(f, to) || s[len(from):] != f[len(to):] {
+			return "", "", false
+		}
+	}
+	return from, to, true
+}
+
+// exactMatchLines returns the line numbers where each occurrence of search starts
+func exactMatchLines(content, search string) []int {
+	var lines []int
+	offset := 0
+	for {
+		i := strings.Index(content[offset:], search)
+		if i < 0 {
+			return lines
+		}
+		lines = append(lines, strings.Count(content[:offset+i], "\n")+1)
+		offset += i + len(search)
+	}
+}
+
+func joinLineNumbers(lines []int) string {
+	parts := make([]string, len(lines))
+	for i, line := range lines {
+		parts[i] = fmt.Sprint(line)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,61 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleSource = `package main
+
+func greet(name string) string {
+	return "Hello, " + name
+}
+
+func main() {
+	println(greet("world"))
+}
+`
+
+func TestParseSearchReplaceBlocks(t *testing.T) {
+	blocks, err := parseSearchReplaceBlocks("main.go\n```go\n<<<<<<< SEARCH\nold\n=======\nnew\nlines\n>>>>>>> REPLACE\n```\n<<<<<<<< SEARCH\nmore\n========\n>>>>>>>> REPLACE\n")
+	require.NoError(t, err)
+	assert.Equal(t, []searchReplaceBlock{{Search: "old", Replace: "new\nlines"}, {Search: "more", Replace: ""}}, blocks)
+
+	_, err = parseSearchReplaceBlocks("<<<<<<< SEARCH\nold\n=======\nnew\n")
+	assert.ErrorContains(t, err, "isn't closed")
+	_, err = parseSearchReplaceBlocks("just some text")
+	assert.ErrorContains(t, err, "no SEARCH/REPLACE blocks")
+}
+
+func TestApplySearchReplaceBlocks(t *testing.T) {
+	// Exact matches are replaced as written
+	got, err := applySearchReplaceBlocks(sampleSource, []searchReplaceBlock{
+		{Search: `	return "Hello, " + name`, Replace: `	return "Hi, " + name`},
+		{Search: `greet("world")`, Replace: `greet("gopher")`},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, got, `return "Hi, " + name`)
+	assert.Contains(t, got, `greet("gopher")`)
+
+	// Lost indentation is matched loosely and the replacement reindented
+	got, err = applySearchReplaceBlocks(sampleSource, []searchReplaceBlock{
+		{Search: "func main() {\nprintln(greet(\"world\"))  \n}", Replace: "func main() {\nprintln(greet(\"there\"))\n}"},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, got, "func main() {\n\tprintln(greet(\"there\"))\n}")
+
+	// A miss names the closest lines, and nothing is applied
+	_, err = applySearchReplaceBlocks(sampleSource, []searchReplaceBlock{
+		{Search: "package main", Replace: "package app"},
+		{Search: "func greet(name string) string {\n\treturn \"Howdy, \" + name\n}", Replace: ""},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "block 2 of 2")
+	assert.Contains(t, err.Error(), "Lines 3-5 (2 of 3 lines match)")
+	assert.Contains(t, err.Error(), "No blocks were applied")
+
+	_, err = applySearchReplaceBlocks(sampleSource, []searchReplaceBlock{{Search: "}", Replace: "};"}})
+	assert.ErrorContains(t, err, "matches 2 places (lines 5, 9)")
+}
@@ -7,12 +7,14 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"syscall"
 	"time"
 
 	"github.com/entrepeneur4lyf/codeforge/internal/config"
+	"github.com/entrepeneur4lyf/codeforge/internal/platform"
 )
 
 type PersistentShell struct {
@@ -20,6 +22,7 @@ type PersistentShell struct {
 	stdin        *os.File
 	isAlive      bool
 	cwd          string
+	env          []string
 	mu           sync.Mutex
 	commandQueue chan *commandExecution
 }
@@ -39,26 +42,63 @@ type commandResult struct {
 	err         error
 }
 
+// ErrNoShell is returned when no POSIX shell could be started. On Windows
+// installing Git for Windows provides one.
+var ErrNoShell = errors.New("no POSIX shell available; install Git for Windows or set shell.path")
+
 var (
 	shellInstance     *PersistentShell
 	shellInstanceOnce sync.Once
+
+	sessionShells   = make(map[string]*PersistentShell)
+	sessionShellsMu sync.Mutex
 )
 
 func GetPersistentShell(workingDir string) *PersistentShell {
 	shellInstanceOnce.Do(func() {
-		shellInstance = newPersistentShell(workingDir)
+		shellInstance = newPersistentShell(workingDir, nil)
 	})
 
 	if shellInstance == nil {
-		shellInstance = newPersistentShell(workingDir)
+		shellInstance = newPersistentShell(workingDir, nil)
 	} else if !shellInstance.isAlive {
-		shellInstance = newPersistentShell(shellInstance.cwd)
+		shellInstance = newPersistentShell(shellInstance.cwd, nil)
 	}
 
 	return shellInstance
 }
 
-func newPersistentShell(cwd string) *PersistentShell {
+// GetSessionShell returns the shell of a session that set its own working
+// directory or environment, with env added to the environment it starts
+// with. The shell is started again if it died or the session changed either.
+func GetSessionShell(sessionID, workingDir string, env []string) *PersistentShell {
+	sessionShellsMu.Lock()
+	defer sessionShellsMu.Unlock()
+
+	sh := sessionShells[sessionID]
+	if sh != nil && sh.isAlive && sh.cwd == workingDir && slices.Equal(sh.env, env) {
+		return sh
+	}
+	if sh != nil {
+		sh.Close()
+	}
+	sh = newPersistentShell(workingDir, env)
+	sessionShells[sessionID] = sh
+	return sh
+}
+
+// CloseSessionShell stops a session's shell, if it has one
+func CloseSessionShell(sessionID string) {
+	sessionShellsMu.Lock()
+	defer sessionShellsMu.Unlock()
+
+	if sh := sessionShells[sessionID]; sh != nil {
+		sh.Close()
+	}
+	delete(sessionShells, sessionID)
+}
+
+func newPersistentShell(cwd string, env []string) *PersistentShell {
 	// Get shell configuration from config
 	cfg := config.Get()
 
@@ -71,11 +111,14 @@ func newPersistentShell(cwd string) *PersistentShell {
 		shellArgs = cfg.Shell.Args
 	}
 
+	// Commands are wrapped in sh syntax, so on Windows this needs the bash
+	// from Git for Windows or WSL rather than cmd or PowerShell
 	if shellPath == "" {
-		shellPath = os.Getenv("SHELL")
-		if shellPath == "" {
-			shellPath = "/bin/bash"
+		path, ok := platform.PosixShell()
+		if !ok {
+			return nil
 		}
+		shellPath = path
 	}
 
 	// Default shell args
@@ -91,7 +134,7 @@ func newPersistentShell(cwd string) *PersistentShell {
 		return nil
 	}
 
-	cmd.Env = append(os.Environ(), "GIT_EDITOR=true")
+	cmd.Env = append(append(os.Environ(), "GIT_EDITOR=true"), env...)
 
 	err = cmd.Start()
 	if err != nil {
@@ -103,6 +146,7 @@ func newPersistentShell(cwd string) *PersistentShell {
 		stdin:        stdinPipe.(*os.File),
 		isAlive:      true,
 		cwd:          cwd,
+		env:          env,
 		commandQueue: make(chan *commandExecution, 10),
 	}
 
@@ -269,6 +313,9 @@ func (s *PersistentShell) killChildren() {
 }
 
 func (s *PersistentShell) Exec(ctx context.Context, command string, timeoutMs int) (string, string, int, bool, error) {
+	if s == nil {
+		return "", "No POSIX shell available", 1, false, ErrNoShell
+	}
 	if !s.isAlive {
 		return "", "Shell is not alive", 1, false, errors.New("shell is not alive")
 	}
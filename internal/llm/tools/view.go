@@ -15,7 +15,6 @@ import (
 	"path/filepath"
 	"strings"
 
-	"github.com/entrepeneur4lyf/codeforge/internal/config"
 	"github.com/entrepeneur4lyf/codeforge/internal/logging"
 	"github.com/entrepeneur4lyf/codeforge/internal/lsp"
 )
@@ -115,7 +114,7 @@ func (v *viewTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error)
 	// Handle relative paths
 	filePath := params.FilePath
 	if !filepath.IsAbs(filePath) {
-		filePath = filepath.Join(config.WorkingDirectory(), filePath)
+		filePath = filepath.Join(workingDir(ctx), filePath)
 	}
 
 	// Check if file exists
@@ -254,7 +253,7 @@ func readTextFile(filePath string, offset, limit int) (string, int, error) {
 	var lines []string
 	lineCount = offset
 
-	for scanner.Scan() && len(lines) < limit {
+	for len(lines) < limit && scanner.Scan() {
 		lineCount++
 		lineText := scanner.Text()
 		if len(lineText) > MaxLineLength {
@@ -0,0 +1,116 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+	"github.com/entrepeneur4lyf/codeforge/internal/fileutil"
+)
+
+// ErrOutsideWorkspace is returned for writes that land outside the workspace
+// and aren't allowlisted
+var ErrOutsideWorkspace = errors.New("path is outside the workspace")
+
+// Workspace overrides, for the tools run in one session, the working
+// directory, environment and build command the server was started with. It
+// lets one server work on several checkouts at once.
+type Workspace struct {
+	Dir          string            `json:"working_dir,omitempty"`
+	Env          map[string]string `json:"env,omitempty"` // Added to the environment of commands run
+	BuildCommand string            `json:"build_command,omitempty"`
+}
+
+type workspaceContextKey struct{}
+
+// WithWorkspace returns a context whose tool calls run in ws
+func WithWorkspace(ctx context.Context, ws Workspace) context.Context {
+	return context.WithValue(ctx, workspaceContextKey{}, ws)
+}
+
+// WorkspaceFrom returns the workspace set on ctx, if any
+func WorkspaceFrom(ctx context.Context) (Workspace, bool) {
+	ws, ok := ctx.Value(workspaceContextKey{}).(Workspace)
+	return ws, ok
+}
+
+// Environ returns the workspace's variables as KEY=value pairs, sorted
+func (ws Workspace) Environ() []string {
+	env := make([]string, 0, len(ws.Env))
+	for key, value := range ws.Env {
+		env = append(env, key+"="+value)
+	}
+	sort.Strings(env)
+	return env
+}
+
+// workingDir returns the directory tools resolve relative paths against: the
+// session's, or else the one CodeForge was started in
+func workingDir(ctx context.Context) string {
+	if ws, ok := WorkspaceFrom(ctx); ok && ws.Dir != "" {
+		return ws.Dir
+	}
+	return config.WorkingDirectory()
+}
+
+// checkWritePath makes sure a file tool only writes inside the workspace: the
+// working directory, files.workspaceRoots, or a path listed in
+// files.allowWrite. It returns the cleaned absolute path to write to.
+func checkWritePath(ctx context.Context, path string) (string, error) {
+	roots := []string{workingDir(ctx)}
+	var allow []string
+	if cfg := config.Get(); cfg != nil {
+		roots = append(roots, cfg.Files.WorkspaceRoots...)
+		allow = cfg.Files.AllowWrite
+	}
+	return checkWritePathIn(path, roots, allow)
+}
+
+// checkWritePathIn checks path against roots and the allowlist. Paths are
+// compared after resolving symlinks, so a link inside the workspace can't be
+// used to write somewhere else.
+func checkWritePathIn(path string, roots, allow []string) (string, error) {
+	base := ""
+	if len(roots) > 0 {
+		base = roots[0]
+	}
+	path = absolutePath(path, base)
+
+	resolved, err := fileutil.ResolvePath(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", path, err)
+	}
+
+	for _, dir := range append(append([]string{}, roots...), allow...) {
+		if dir == "" {
+			continue
+		}
+		allowed, err := fileutil.ResolvePath(absolutePath(dir, base))
+		if err != nil {
+			continue
+		}
+		if fileutil.WithinDir(resolved, allowed) {
+			return path, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: %s; add it to files.allowWrite in the config to allow writing there", ErrOutsideWorkspace, path)
+}
+
+// absolutePath expands a leading ~ and makes path absolute against base
+func absolutePath(path, base string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[1:])
+		}
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(base, path)
+	}
+	return filepath.Clean(path)
+}
@@ -0,0 +1,75 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/llm/tools/shell"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckWritePath(t *testing.T) {
+	workspace := t.TempDir()
+	outside := t.TempDir()
+	config := filepath.Join(outside, "config")
+	require.NoError(t, os.MkdirAll(config, 0o755))
+
+	// A link inside the workspace pointing out of it
+	require.NoError(t, os.Symlink(outside, filepath.Join(workspace, "escape")))
+
+	roots := []string{workspace}
+	allow := []string{config}
+
+	tests := []struct {
+		path    string
+		allowed bool
+	}{
+		{"main.go", true},
+		{"new/dir/file.go", true},
+		{filepath.Join(workspace, "a.go"), true},
+		{"../elsewhere.go", false},
+		{filepath.Join(outside, "a.go"), false},
+		{"escape/a.go", false},
+		{"escape/config/settings.json", true},
+		{filepath.Join(config, "nested", "settings.json"), true},
+	}
+
+	for _, tt := range tests {
+		path, err := checkWritePathIn(tt.path, roots, allow)
+		if tt.allowed {
+			assert.NoError(t, err, tt.path)
+			assert.True(t, filepath.IsAbs(path), tt.path)
+		} else {
+			assert.True(t, errors.Is(err, ErrOutsideWorkspace), "%s: got %v", tt.path, err)
+		}
+	}
+}
+
+func TestSessionWorkspace(t *testing.T) {
+	dir := t.TempDir()
+	ctx := WithWorkspace(context.Background(), Workspace{Dir: dir, Env: map[string]string{"GREETING": "hello", "A": "1"}})
+
+	path, err := checkWritePath(ctx, "main.go")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "main.go"), path)
+
+	ws, ok := WorkspaceFrom(ctx)
+	require.True(t, ok)
+	assert.Equal(t, []string{"A=1", "GREETING=hello"}, ws.Environ())
+
+	sh := shell.GetSessionShell("workspace-test", dir, ws.Environ())
+	if sh == nil {
+		t.Skip("no POSIX shell")
+	}
+	defer shell.CloseSessionShell("workspace-test")
+	stdout, _, exitCode, _, err := sh.Exec(ctx, "echo $GREETING; pwd", 5000)
+	require.NoError(t, err)
+	assert.Equal(t, 0, exitCode)
+	real, _ := filepath.EvalSymlinks(dir)
+	assert.Equal(t, "hello\n"+real, strings.TrimSpace(stdout))
+}
@@ -7,10 +7,9 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
-	"time"
 
-	"github.com/entrepeneur4lyf/codeforge/internal/config"
 	"github.com/entrepeneur4lyf/codeforge/internal/diff"
+	"github.com/entrepeneur4lyf/codeforge/internal/fileutil"
 	"github.com/entrepeneur4lyf/codeforge/internal/lsp"
 )
 
@@ -108,9 +107,9 @@ func (w *writeTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 		return NewTextErrorResponse("content is required"), nil
 	}
 
-	filePath := params.FilePath
-	if !filepath.IsAbs(filePath) {
-		filePath = filepath.Join(config.WorkingDirectory(), filePath)
+	filePath, err := checkWritePath(ctx, params.FilePath)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
 	}
 
 	fileInfo, err := os.Stat(filePath)
@@ -119,11 +118,8 @@ func (w *writeTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 			return NewTextErrorResponse(fmt.Sprintf("Path is a directory, not a file: %s", filePath)), nil
 		}
 
-		modTime := fileInfo.ModTime()
-		lastRead := getLastReadTime(filePath)
-		if modTime.After(lastRead) {
-			return NewTextErrorResponse(fmt.Sprintf("File %s has been modified since it was last read.\nLast modification: %s\nLast read: %s\n\nPlease read the file again before modifying it.",
-				filePath, modTime.Format(time.RFC3339), lastRead.Format(time.RFC3339))), nil
+		if getLastReadTime(filePath).IsZero() {
+			return NewTextErrorResponse(fmt.Sprintf("File %s already exists. Please read it before overwriting it.", filePath)), nil
 		}
 
 		oldContent, readErr := os.ReadFile(filePath)
@@ -152,13 +148,26 @@ func (w *writeTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 		return ToolResponse{}, fmt.Errorf("session_id and message_id are required")
 	}
 
+	if fileInfo != nil {
+		var conflict *ToolResponse
+		params.Content, conflict = resolveConflict(w.permissions, sessionID, WriteToolName, filePath, oldContent, params.Content)
+		if conflict != nil {
+			return *conflict, nil
+		}
+	}
+
+	params.Content, err = applyPolicies(ctx, filePath, oldContent, params.Content)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+
 	diff, additions, removals := diff.GenerateDiff(
 		oldContent,
 		params.Content,
 		filePath,
 	)
 
-	rootDir := config.WorkingDirectory()
+	rootDir := workingDir(ctx)
 	permissionPath := filepath.Dir(filePath)
 	if strings.HasPrefix(filePath, rootDir) {
 		permissionPath = rootDir
@@ -180,7 +189,7 @@ func (w *writeTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 		return ToolResponse{}, ErrorPermissionDenied
 	}
 
-	err = os.WriteFile(filePath, []byte(params.Content), 0o644)
+	err = fileutil.WriteFile(filePath, []byte(params.Content))
 	if err != nil {
 		return ToolResponse{}, fmt.Errorf("error writing file: %w", err)
 	}
@@ -68,6 +68,7 @@ type OpenAIMessage struct {
 
 // OpenAIToolCall represents an OpenAI tool call
 type OpenAIToolCall struct {
+	Index    *int               `json:"index,omitempty"` // Set on streamed fragments
 	ID       string             `json:"id"`
 	Type     string             `json:"type"`
 	Function OpenAIFunctionCall `json:"function"`
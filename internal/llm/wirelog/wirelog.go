@@ -0,0 +1,298 @@
+package wirelog
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+	"github.com/entrepeneur4lyf/codeforge/internal/privacy"
+)
+
+// Entry kinds written to a wire log
+const (
+	KindRequest = "request"
+	KindChunk   = "chunk"
+	KindError   = "error"
+	KindDone    = "done"
+)
+
+// Request is the logged form of a provider request
+type Request struct {
+	Provider     string          `json:"provider"`
+	Model        string          `json:"model"`
+	SystemPrompt string          `json:"systemPrompt"`
+	Messages     json.RawMessage `json:"messages"`
+}
+
+// Chunk is the logged form of a stream chunk
+type Chunk = llm.StreamEvent
+
+// Entry is one line of a wire log
+type Entry struct {
+	Kind     string    `json:"kind"`
+	Time     time.Time `json:"time"`
+	OffsetMs int64     `json:"offsetMs"` // Time since the request was sent
+	Request  *Request  `json:"request,omitempty"`
+	Chunk    *Chunk    `json:"chunk,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// Log is a parsed wire log of a single generation
+type Log struct {
+	Path    string
+	Request *Request
+	Entries []Entry // Chunk entries in stream order
+	Error   string
+	Done    bool
+}
+
+// handler records requests and streamed responses of the wrapped handler
+type handler struct {
+	handler  llm.ApiHandler
+	provider string
+	dir      string
+	secrets  []string
+	mu       sync.Mutex
+}
+
+// Wrap returns a handler that writes each generation to a JSONL file in dir.
+// Secrets, such as the provider API key, are removed from everything written.
+func Wrap(h llm.ApiHandler, provider, dir string, secrets ...string) llm.ApiHandler {
+	var keep []string
+	for _, s := range secrets {
+		if len(s) >= 8 {
+			keep = append(keep, s)
+		}
+	}
+	return &handler{handler: h, provider: provider, dir: dir, secrets: keep}
+}
+
+func (h *handler) CreateMessage(ctx context.Context, systemPrompt string, messages []llm.Message) (llm.ApiStream, error) {
+	start := time.Now()
+	model := h.handler.GetModel().ID
+
+	w, err := h.open(start, model)
+	if err != nil {
+		// Logging is diagnostic only and never blocks a request
+		return h.handler.CreateMessage(ctx, systemPrompt, messages)
+	}
+
+	msgJSON, _ := json.Marshal(messages)
+	w.write(Entry{Kind: KindRequest, Time: start, Request: &Request{
+		Provider:     h.provider,
+		Model:        model,
+		SystemPrompt: systemPrompt,
+		Messages:     msgJSON,
+	}})
+
+	stream, err := h.handler.CreateMessage(ctx, systemPrompt, messages)
+	if err != nil {
+		w.write(Entry{Kind: KindError, Time: time.Now(), OffsetMs: time.Since(start).Milliseconds(), Error: err.Error()})
+		w.close()
+		return nil, err
+	}
+
+	out := make(chan llm.ApiStreamChunk)
+	go func() {
+		defer close(out)
+		defer w.close()
+		for chunk := range stream {
+			now := time.Now()
+			w.write(Entry{Kind: KindChunk, Time: now, OffsetMs: now.Sub(start).Milliseconds(), Chunk: fromStreamChunk(chunk)})
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				w.write(Entry{Kind: KindError, Time: time.Now(), OffsetMs: time.Since(start).Milliseconds(), Error: ctx.Err().Error()})
+				return
+			}
+		}
+		w.write(Entry{Kind: KindDone, Time: time.Now(), OffsetMs: time.Since(start).Milliseconds()})
+	}()
+
+	return out, nil
+}
+
+func (h *handler) GetModel() llm.ModelResponse {
+	return h.handler.GetModel()
+}
+
+func (h *handler) GetApiStreamUsage() (*llm.ApiStreamUsageChunk, error) {
+	return h.handler.GetApiStreamUsage()
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// open creates the log file for one generation. The random suffix keeps
+// generations started in the same millisecond, by this handler or another
+// process, from colliding.
+func (h *handler) open(start time.Time, model string) (*writer, error) {
+	if err := os.MkdirAll(h.dir, 0o700); err != nil {
+		return nil, err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, err
+	}
+	name := fmt.Sprintf("%s-%s-%s.jsonl", start.Format("20060102-150405.000"), unsafeFileChars.ReplaceAllString(model, "_"), hex.EncodeToString(suffix))
+	f, err := os.OpenFile(filepath.Join(h.dir, name), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &writer{f: f, enc: json.NewEncoder(f), secrets: h.secrets}, nil
+}
+
+// writer appends sanitized entries to a log file
+type writer struct {
+	f       *os.File
+	enc     *json.Encoder
+	secrets []string
+}
+
+func (w *writer) write(e Entry) {
+	if e.Request != nil {
+		e.Request.SystemPrompt = w.sanitize(e.Request.SystemPrompt)
+		e.Request.Messages = json.RawMessage(w.sanitize(string(e.Request.Messages)))
+	}
+	if e.Chunk != nil {
+		e.Chunk.Text = w.sanitize(e.Chunk.Text)
+		e.Chunk.Reasoning = w.sanitize(e.Chunk.Reasoning)
+		if e.Chunk.ToolCall != nil {
+			e.Chunk.ToolCall.Arguments = w.sanitize(e.Chunk.ToolCall.Arguments)
+		}
+	}
+	e.Error = w.sanitize(e.Error)
+	_ = w.enc.Encode(e)
+}
+
+func (w *writer) close() {
+	_ = w.f.Close()
+}
+
+// sanitize removes known secrets and credential-shaped values
+func (w *writer) sanitize(s string) string {
+	if s == "" {
+		return s
+	}
+	for _, secret := range w.secrets {
+		s = strings.ReplaceAll(s, secret, "[REDACTED:api_key]")
+	}
+	return privacy.RedactSecrets(s)
+}
+
+// fromStreamChunk converts a stream chunk to its logged form
+func fromStreamChunk(chunk llm.ApiStreamChunk) *Chunk {
+	event := llm.NewStreamEvent(chunk)
+	return &event
+}
+
+// Read parses a wire log file
+func Read(path string) (*Log, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open wire log: %w", err)
+	}
+	defer f.Close()
+
+	log := &Log{Path: path}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("invalid wire log entry at line %d: %w", line, err)
+		}
+		switch e.Kind {
+		case KindRequest:
+			log.Request = e.Request
+		case KindChunk:
+			if e.Chunk != nil {
+				log.Entries = append(log.Entries, e)
+			}
+		case KindError:
+			log.Error = e.Error
+		case KindDone:
+			log.Done = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read wire log: %w", err)
+	}
+	if log.Request == nil && len(log.Entries) == 0 {
+		return nil, fmt.Errorf("wire log %s contains no generation", path)
+	}
+	return log, nil
+}
+
+// Replay streams the logged chunks. A speed of 1 reproduces the original
+// timing, larger values replay faster, and 0 replays without delays.
+func (l *Log) Replay(ctx context.Context, speed float64) llm.ApiStream {
+	out := make(chan llm.ApiStreamChunk)
+	go func() {
+		defer close(out)
+		var last int64
+		for _, e := range l.Entries {
+			chunk, ok := e.Chunk.StreamChunk()
+			if !ok {
+				continue
+			}
+			if speed > 0 && e.OffsetMs > last {
+				delay := time.Duration(float64(e.OffsetMs-last)/speed) * time.Millisecond
+				select {
+				case <-time.After(delay):
+				case <-ctx.Done():
+					return
+				}
+			}
+			last = e.OffsetMs
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Text returns the full generated text
+func (l *Log) Text() string {
+	var sb strings.Builder
+	for _, e := range l.Entries {
+		if e.Chunk.Type == "text" {
+			sb.WriteString(e.Chunk.Text)
+		}
+	}
+	return sb.String()
+}
+
+// List returns the wire logs in dir, newest first
+func List(dir string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	// File names start with a timestamp, so reverse lexical order is newest first
+	for i, j := 0, len(matches)-1; i < j; i, j = i+1, j-1 {
+		matches[i], matches[j] = matches[j], matches[i]
+	}
+	return matches, nil
+}
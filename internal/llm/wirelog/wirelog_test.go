@@ -0,0 +1,101 @@
+package wirelog
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+)
+
+type scriptedHandler struct {
+	chunks []llm.ApiStreamChunk
+}
+
+func (h *scriptedHandler) CreateMessage(ctx context.Context, systemPrompt string, messages []llm.Message) (llm.ApiStream, error) {
+	ch := make(chan llm.ApiStreamChunk, len(h.chunks))
+	for _, c := range h.chunks {
+		ch <- c
+	}
+	close(ch)
+	return ch, nil
+}
+
+func (h *scriptedHandler) GetModel() llm.ModelResponse {
+	return llm.ModelResponse{ID: "test/model"}
+}
+
+func (h *scriptedHandler) GetApiStreamUsage() (*llm.ApiStreamUsageChunk, error) {
+	return nil, nil
+}
+
+func TestWrapRecordsAndReplays(t *testing.T) {
+	dir := t.TempDir()
+	apiKey := "test-api-key-1234567890"
+	inner := &scriptedHandler{chunks: []llm.ApiStreamChunk{
+		llm.ApiStreamTextChunk{Text: "Hello "},
+		llm.ApiStreamReasoningChunk{Reasoning: "thinking"},
+		llm.ApiStreamTextChunk{Text: "world"},
+		llm.ApiStreamUsageChunk{InputTokens: 10, OutputTokens: 2},
+	}}
+
+	h := Wrap(inner, "test", dir, apiKey)
+	messages := []llm.Message{{Role: "user", Content: []llm.ContentBlock{llm.TextBlock{Text: "my key is " + apiKey}}}}
+	stream, err := h.CreateMessage(context.Background(), "system", messages)
+	if err != nil {
+		t.Fatalf("CreateMessage failed: %v", err)
+	}
+	for range stream {
+	}
+
+	logs, err := List(dir)
+	if err != nil || len(logs) != 1 {
+		t.Fatalf("Expected one wire log, got %v (%v)", logs, err)
+	}
+
+	data, _ := os.ReadFile(logs[0])
+	if strings.Contains(string(data), apiKey) {
+		t.Error("Wire log contains the API key")
+	}
+
+	log, err := Read(logs[0])
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if !log.Done || log.Request == nil || log.Request.Model != "test/model" {
+		t.Errorf("Unexpected log header: %+v", log)
+	}
+	if log.Text() != "Hello world" {
+		t.Errorf("Expected text %q, got %q", "Hello world", log.Text())
+	}
+
+	var replayed []llm.ApiStreamChunk
+	for c := range log.Replay(context.Background(), 0) {
+		replayed = append(replayed, c)
+	}
+	if len(replayed) != 4 {
+		t.Fatalf("Expected 4 replayed chunks, got %d", len(replayed))
+	}
+	if usage, ok := replayed[3].(llm.ApiStreamUsageChunk); !ok || usage.InputTokens != 10 {
+		t.Errorf("Expected usage chunk, got %#v", replayed[3])
+	}
+}
+
+func TestOpenSameMillisecond(t *testing.T) {
+	dir := t.TempDir()
+	first := Wrap(&scriptedHandler{}, "openai", dir, "").(*handler)
+	second := Wrap(&scriptedHandler{}, "openai", dir, "").(*handler)
+	start := time.Now()
+	for _, h := range []*handler{first, second, first} {
+		w, err := h.open(start, "test/model")
+		if err != nil {
+			t.Fatalf("open() error = %v", err)
+		}
+		w.close()
+	}
+	if logs, err := List(dir); err != nil || len(logs) != 3 {
+		t.Errorf("List() = %v, %v; want 3 logs", logs, err)
+	}
+}
@@ -80,7 +80,7 @@ func (c *Client) GetAllDiagnostics() map[string][]protocol.Diagnostic {
 
 // OpenFile opens a file in the LSP server
 func (c *Client) OpenFile(ctx context.Context, filepath string) error {
-	uri := fmt.Sprintf("file://%s", filepath)
+	uri := PathToURI(filepath)
 
 	c.openFilesMu.Lock()
 	if _, exists := c.openFiles[uri]; exists {
@@ -123,7 +123,7 @@ func (c *Client) OpenFile(ctx context.Context, filepath string) error {
 
 // CloseFile closes a file in the LSP server
 func (c *Client) CloseFile(ctx context.Context, filepath string) error {
-	uri := fmt.Sprintf("file://%s", filepath)
+	uri := PathToURI(filepath)
 
 	c.openFilesMu.Lock()
 	_, exists := c.openFiles[uri]
@@ -145,7 +145,7 @@ func (c *Client) CloseFile(ctx context.Context, filepath string) error {
 
 // GetCompletion requests code completion at a specific position
 func (c *Client) GetCompletion(ctx context.Context, filepath string, line, character int) (*protocol.CompletionList, error) {
-	uri := fmt.Sprintf("file://%s", filepath)
+	uri := PathToURI(filepath)
 
 	params := protocol.CompletionParams{
 		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
@@ -169,7 +169,7 @@ func (c *Client) GetCompletion(ctx context.Context, filepath string, line, chara
 
 // GetHover requests hover information at a specific position
 func (c *Client) GetHover(ctx context.Context, filepath string, line, character int) (*protocol.Hover, error) {
-	uri := fmt.Sprintf("file://%s", filepath)
+	uri := PathToURI(filepath)
 
 	params := protocol.HoverParams{
 		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
@@ -193,7 +193,7 @@ func (c *Client) GetHover(ctx context.Context, filepath string, line, character
 
 // GetDefinition requests definition information at a specific position
 func (c *Client) GetDefinition(ctx context.Context, filepath string, line, character int) ([]protocol.Location, error) {
-	uri := fmt.Sprintf("file://%s", filepath)
+	uri := PathToURI(filepath)
 
 	params := protocol.DefinitionParams{
 		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
@@ -231,7 +231,7 @@ func (c *Client) GetWorkspaceSymbols(ctx context.Context, query string) ([]proto
 
 // GetDocumentSymbols gets symbols for a specific document
 func (c *Client) GetDocumentSymbols(ctx context.Context, filepath string) ([]protocol.DocumentSymbol, error) {
-	uri := fmt.Sprintf("file://%s", filepath)
+	uri := PathToURI(filepath)
 
 	params := protocol.DocumentSymbolParams{
 		TextDocument: protocol.TextDocumentIdentifier{
@@ -249,7 +249,7 @@ func (c *Client) GetDocumentSymbols(ctx context.Context, filepath string) ([]pro
 
 // GetReferences finds all references to a symbol
 func (c *Client) GetReferences(ctx context.Context, filepath string, line, character int, includeDeclaration bool) ([]protocol.Location, error) {
-	uri := fmt.Sprintf("file://%s", filepath)
+	uri := PathToURI(filepath)
 
 	params := protocol.ReferenceParams{
 		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
@@ -276,7 +276,7 @@ func (c *Client) GetReferences(ctx context.Context, filepath string, line, chara
 
 // GetImplementation finds implementations of an interface or abstract method
 func (c *Client) GetImplementation(ctx context.Context, filepath string, line, character int) ([]protocol.Location, error) {
-	uri := fmt.Sprintf("file://%s", filepath)
+	uri := PathToURI(filepath)
 
 	params := protocol.ImplementationParams{
 		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
@@ -300,7 +300,7 @@ func (c *Client) GetImplementation(ctx context.Context, filepath string, line, c
 
 // GetTypeDefinition finds the type definition of a symbol
 func (c *Client) GetTypeDefinition(ctx context.Context, filepath string, line, character int) ([]protocol.Location, error) {
-	uri := fmt.Sprintf("file://%s", filepath)
+	uri := PathToURI(filepath)
 
 	params := protocol.TypeDefinitionParams{
 		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
@@ -324,7 +324,7 @@ func (c *Client) GetTypeDefinition(ctx context.Context, filepath string, line, c
 
 // GetCodeActions gets available code actions for a range
 func (c *Client) GetCodeActions(ctx context.Context, filepath string, startLine, startChar, endLine, endChar int, diagnostics []protocol.Diagnostic) ([]protocol.CodeAction, error) {
-	uri := fmt.Sprintf("file://%s", filepath)
+	uri := PathToURI(filepath)
 
 	params := protocol.CodeActionParams{
 		TextDocument: protocol.TextDocumentIdentifier{
@@ -370,7 +370,7 @@ func (c *Client) ExecuteCommand(ctx context.Context, command string, args []any)
 
 // PrepareRename checks if a symbol can be renamed
 func (c *Client) PrepareRename(ctx context.Context, filepath string, line, character int) (*protocol.Range, error) {
-	uri := fmt.Sprintf("file://%s", filepath)
+	uri := PathToURI(filepath)
 
 	params := protocol.PrepareRenameParams{
 		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
@@ -394,7 +394,7 @@ func (c *Client) PrepareRename(ctx context.Context, filepath string, line, chara
 
 // Rename renames a symbol across the workspace
 func (c *Client) Rename(ctx context.Context, filepath string, line, character int, newName string) (*protocol.WorkspaceEdit, error) {
-	uri := fmt.Sprintf("file://%s", filepath)
+	uri := PathToURI(filepath)
 
 	params := protocol.RenameParams{
 		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
@@ -419,7 +419,7 @@ func (c *Client) Rename(ctx context.Context, filepath string, line, character in
 
 // GetSignatureHelp requests signature help at a specific position
 func (c *Client) GetSignatureHelp(ctx context.Context, filepath string, line, character int) (*protocol.SignatureHelp, error) {
-	uri := fmt.Sprintf("file://%s", filepath)
+	uri := PathToURI(filepath)
 
 	params := protocol.SignatureHelpParams{
 		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
@@ -443,7 +443,7 @@ func (c *Client) GetSignatureHelp(ctx context.Context, filepath string, line, ch
 
 // UpdateFileContent updates the content of an open file and notifies the LSP server
 func (c *Client) UpdateFileContent(ctx context.Context, filepath string, content []byte) error {
-	uri := fmt.Sprintf("file://%s", filepath)
+	uri := PathToURI(filepath)
 
 	c.openFilesMu.Lock()
 	fileInfo, exists := c.openFiles[uri]
@@ -475,9 +475,31 @@ func (c *Client) UpdateFileContent(ctx context.Context, filepath string, content
 	return c.Notify(ctx, "textDocument/didChange", params)
 }
 
+// NotifyFilesChanged tells the LSP server that files were created, changed
+// or deleted on disk, as a client watching them would
+func (c *Client) NotifyFilesChanged(ctx context.Context, created, changed, deleted []string) error {
+	var events []*protocol.FileEvent
+	for _, group := range []struct {
+		paths []string
+		kind  protocol.FileChangeType
+	}{
+		{created, protocol.FileChangeTypeCreated},
+		{changed, protocol.FileChangeTypeChanged},
+		{deleted, protocol.FileChangeTypeDeleted},
+	} {
+		for _, path := range group.paths {
+			events = append(events, &protocol.FileEvent{Type: group.kind, URI: protocol.DocumentURI(PathToURI(path))})
+		}
+	}
+	if len(events) == 0 {
+		return nil
+	}
+	return c.Notify(ctx, "workspace/didChangeWatchedFiles", protocol.DidChangeWatchedFilesParams{Changes: events})
+}
+
 // SaveFile notifies the LSP server that a file has been saved
 func (c *Client) SaveFile(ctx context.Context, filepath string, content []byte) error {
-	uri := fmt.Sprintf("file://%s", filepath)
+	uri := PathToURI(filepath)
 
 	// First update the content if the file is open
 	if c.IsFileOpen(filepath) {
@@ -499,7 +521,7 @@ func (c *Client) SaveFile(ctx context.Context, filepath string, content []byte)
 
 // WillSaveFile notifies the LSP server that a file is about to be saved
 func (c *Client) WillSaveFile(ctx context.Context, filepath string, reason protocol.TextDocumentSaveReason) error {
-	uri := fmt.Sprintf("file://%s", filepath)
+	uri := PathToURI(filepath)
 
 	params := protocol.WillSaveTextDocumentParams{
 		TextDocument: protocol.TextDocumentIdentifier{
@@ -513,7 +535,7 @@ func (c *Client) WillSaveFile(ctx context.Context, filepath string, reason proto
 
 // GetFormattingEdits requests document formatting
 func (c *Client) GetFormattingEdits(ctx context.Context, filepath string, options protocol.FormattingOptions) ([]protocol.TextEdit, error) {
-	uri := fmt.Sprintf("file://%s", filepath)
+	uri := PathToURI(filepath)
 
 	params := protocol.DocumentFormattingParams{
 		TextDocument: protocol.TextDocumentIdentifier{
@@ -532,7 +554,7 @@ func (c *Client) GetFormattingEdits(ctx context.Context, filepath string, option
 
 // GetRangeFormattingEdits requests range formatting
 func (c *Client) GetRangeFormattingEdits(ctx context.Context, filepath string, startLine, startChar, endLine, endChar int, options protocol.FormattingOptions) ([]protocol.TextEdit, error) {
-	uri := fmt.Sprintf("file://%s", filepath)
+	uri := PathToURI(filepath)
 
 	params := protocol.DocumentRangeFormattingParams{
 		TextDocument: protocol.TextDocumentIdentifier{
@@ -561,7 +583,7 @@ func (c *Client) GetRangeFormattingEdits(ctx context.Context, filepath string, s
 
 // GetOnTypeFormattingEdits requests on-type formatting
 func (c *Client) GetOnTypeFormattingEdits(ctx context.Context, filepath string, line, character int, ch string, options protocol.FormattingOptions) ([]protocol.TextEdit, error) {
-	uri := fmt.Sprintf("file://%s", filepath)
+	uri := PathToURI(filepath)
 
 	params := protocol.DocumentOnTypeFormattingParams{
 		TextDocument: protocol.TextDocumentIdentifier{
@@ -585,7 +607,7 @@ func (c *Client) GetOnTypeFormattingEdits(ctx context.Context, filepath string,
 
 // GetDocumentLinks requests document links for a file
 func (c *Client) GetDocumentLinks(ctx context.Context, filepath string) ([]protocol.DocumentLink, error) {
-	uri := fmt.Sprintf("file://%s", filepath)
+	uri := PathToURI(filepath)
 
 	params := protocol.DocumentLinkParams{
 		TextDocument: protocol.TextDocumentIdentifier{
@@ -603,7 +625,7 @@ func (c *Client) GetDocumentLinks(ctx context.Context, filepath string) ([]proto
 
 // GetFoldingRanges requests folding ranges for a file
 func (c *Client) GetFoldingRanges(ctx context.Context, filepath string) ([]protocol.FoldingRange, error) {
-	uri := fmt.Sprintf("file://%s", filepath)
+	uri := PathToURI(filepath)
 
 	params := protocol.FoldingRangeParams{
 		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
@@ -623,7 +645,7 @@ func (c *Client) GetFoldingRanges(ctx context.Context, filepath string) ([]proto
 
 // GetSelectionRanges requests selection ranges for positions in a file
 func (c *Client) GetSelectionRanges(ctx context.Context, filepath string, positions []protocol.Position) ([]protocol.SelectionRange, error) {
-	uri := fmt.Sprintf("file://%s", filepath)
+	uri := PathToURI(filepath)
 
 	params := protocol.SelectionRangeParams{
 		TextDocument: protocol.TextDocumentIdentifier{
@@ -642,7 +664,7 @@ func (c *Client) GetSelectionRanges(ctx context.Context, filepath string, positi
 
 // GetSemanticTokens requests semantic tokens for a file
 func (c *Client) GetSemanticTokens(ctx context.Context, filepath string) (*protocol.SemanticTokens, error) {
-	uri := fmt.Sprintf("file://%s", filepath)
+	uri := PathToURI(filepath)
 
 	params := protocol.SemanticTokensParams{
 		TextDocument: protocol.TextDocumentIdentifier{
@@ -708,8 +730,8 @@ func (c *Client) CloseAllFiles(ctx context.Context) {
 	files := make([]string, 0, len(c.openFiles))
 	for uri := range c.openFiles {
 		// Convert URI back to filepath
-		if filepath, found := strings.CutPrefix(uri, "file://"); found {
-			files = append(files, filepath)
+		if strings.HasPrefix(uri, "file://") {
+			files = append(files, URIToPath(uri))
 		}
 	}
 	c.openFilesMu.RUnlock()
@@ -2,6 +2,7 @@ package lsp
 
 import (
 	"context"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -339,6 +340,21 @@ func TestFileVersionManagement(t *testing.T) {
 	}
 }
 
+func TestPathToURI(t *testing.T) {
+	if uri := PathToURI("/test/file.go"); uri != "file:///test/file.go" {
+		t.Errorf("Expected file:///test/file.go, got %s", uri)
+	}
+	if uri := PathToURI("C:/src/main.go"); uri != "file:///C:/src/main.go" {
+		t.Errorf("Expected file:///C:/src/main.go, got %s", uri)
+	}
+	if path := URIToPath("file:///C:/src/main.go"); path != filepath.FromSlash("C:/src/main.go") {
+		t.Errorf("Expected C:/src/main.go, got %s", path)
+	}
+	if path := URIToPath("file:///test/file.go"); path != filepath.FromSlash("/test/file.go") {
+		t.Errorf("Expected /test/file.go, got %s", path)
+	}
+}
+
 // Helper function to check if a string contains a substring
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && s[:len(substr)] == substr ||
@@ -99,10 +99,33 @@ type Manager struct {
 }
 
 // Global manager instance
-var manager *Manager
+var (
+	manager   *Manager
+	managerMu sync.Mutex
+
+	// Config to start the language servers with when the manager is first
+	// asked for
+	pendingConfig *config.Config
+)
+
+// InitializeOnFirstUse defers Initialize until GetManager is first called,
+// so commands that never look at symbols or diagnostics don't start
+// language servers
+func InitializeOnFirstUse(cfg *config.Config) {
+	managerMu.Lock()
+	defer managerMu.Unlock()
+	pendingConfig = cfg
+}
 
 // Initialize sets up the LSP manager with configuration
 func Initialize(cfg *config.Config) error {
+	managerMu.Lock()
+	defer managerMu.Unlock()
+	return initialize(cfg)
+}
+
+func initialize(cfg *config.Config) error {
+	pendingConfig = nil
 	manager = &Manager{
 		clients: make(map[string]*Client),
 		config:  cfg,
@@ -121,8 +144,15 @@ func Initialize(cfg *config.Config) error {
 	return nil
 }
 
-// GetManager returns the global LSP manager instance
+// GetManager returns the global LSP manager instance, starting the language
+// servers first if that was deferred with InitializeOnFirstUse. They start
+// in the background, so the first callers may find them not yet ready.
 func GetManager() *Manager {
+	managerMu.Lock()
+	defer managerMu.Unlock()
+	if manager == nil && pendingConfig != nil {
+		initialize(pendingConfig) // Never fails; servers that don't start are skipped
+	}
 	return manager
 }
 
@@ -243,7 +273,7 @@ func (c *Client) GetState() ServerState {
 
 // IsFileOpen checks if a file is currently open in the LSP server
 func (c *Client) IsFileOpen(filepath string) bool {
-	uri := fmt.Sprintf("file://%s", filepath)
+	uri := PathToURI(filepath)
 
 	c.openFilesMu.RLock()
 	defer c.openFilesMu.RUnlock()
@@ -307,6 +337,7 @@ func NewClient(ctx context.Context, command string, args ...string) (*Client, er
 
 // GetClient returns an LSP client by name
 func GetClient(name string) (*Client, bool) {
+	manager := GetManager()
 	if manager == nil {
 		return nil, false
 	}
@@ -319,6 +350,7 @@ func GetClient(name string) (*Client, bool) {
 
 // GetAvailableClients returns all available LSP clients
 func GetAvailableClients() map[string]*Client {
+	manager := GetManager()
 	if manager == nil {
 		return nil
 	}
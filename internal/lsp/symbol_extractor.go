@@ -270,8 +270,5 @@ func symbolKindToString(kind protocol.SymbolKind) string {
 
 // uriToFilePath converts file URI to file path
 func uriToFilePath(uri string) string {
-	if strings.HasPrefix(uri, "file://") {
-		return uri[7:]
-	}
-	return uri
+	return URIToPath(uri)
 }
\ No newline at end of file
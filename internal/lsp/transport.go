@@ -171,7 +171,7 @@ func (c *Client) InitializeLSPClient(ctx context.Context, workspaceDir string) (
 			Name:    "codeforge",
 			Version: "0.1.0",
 		},
-		RootURI: protocol.DocumentURI("file://" + workspaceDir),
+		RootURI: protocol.DocumentURI(PathToURI(workspaceDir)),
 		Capabilities: protocol.ClientCapabilities{
 			TextDocument: &protocol.TextDocumentClientCapabilities{
 				PublishDiagnostics: &protocol.PublishDiagnosticsClientCapabilities{
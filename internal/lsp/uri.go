@@ -0,0 +1,39 @@
+package lsp
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// PathToURI converts a file path to a file:// URI. Windows drive paths get
+// the extra slash and forward slashes the LSP spec requires
+// (C:\src\main.go becomes file:///C:/src/main.go).
+func PathToURI(path string) string {
+	path = filepath.ToSlash(path)
+	if hasDriveLetter(path) {
+		path = "/" + path
+	}
+	return "file://" + path
+}
+
+// URIToPath converts a file:// URI back to a native file path. Other strings
+// are returned unchanged.
+func URIToPath(uri string) string {
+	path, found := strings.CutPrefix(uri, "file://")
+	if !found {
+		return uri
+	}
+	if strings.HasPrefix(path, "/") && hasDriveLetter(path[1:]) {
+		path = path[1:]
+	}
+	return filepath.FromSlash(path)
+}
+
+// hasDriveLetter reports whether path starts with a Windows drive such as C:
+func hasDriveLetter(path string) bool {
+	if len(path) < 2 || path[1] != ':' {
+		return false
+	}
+	c := path[0]
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
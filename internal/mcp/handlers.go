@@ -13,7 +13,9 @@ import (
 
 	"github.com/entrepeneur4lyf/codeforge/internal/analysis"
 	"github.com/entrepeneur4lyf/codeforge/internal/chunking"
+	"github.com/entrepeneur4lyf/codeforge/internal/fileutil"
 	"github.com/entrepeneur4lyf/codeforge/internal/git"
+	"github.com/entrepeneur4lyf/codeforge/internal/ignore"
 	"github.com/entrepeneur4lyf/codeforge/internal/vectordb"
 	"github.com/mark3labs/mcp-go/mcp"
 )
@@ -265,10 +267,11 @@ func (cfs *CodeForgeServer) handleSemanticSearch(ctx context.Context, request mc
 	// Format results
 	var resultTexts []string
 	for _, result := range results {
-		resultText := fmt.Sprintf("Query: %s\n\nFile: %s\nType: %s\nLanguage: %s\nScore: %.3f\n\nContent:\n%s\n---",
+		resultText := fmt.Sprintf("Query: %s\n\nFile: %s\nLines: %s\nType: %s\nLanguage: %s\nScore: %.3f\n\nContent:\n%s\n---",
 			query,
-			result.Chunk.FilePath,
-			result.Chunk.ChunkType,
+			result.Chunk.Describe(),
+			result.Chunk.Lines(),
+			result.Chunk.ChunkType.Type,
 			result.Chunk.Language,
 			result.Score,
 			result.Chunk.Content,
@@ -449,14 +452,8 @@ func (cfs *CodeForgeServer) handleWriteFile(ctx context.Context, request mcp.Cal
 		return mcp.NewToolResultError(fmt.Sprintf("invalid path: %v", err)), nil
 	}
 
-	// Create directory if it doesn't exist
-	dir := filepath.Dir(fullPath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to create directory: %v", err)), nil
-	}
-
 	// Write file content
-	if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+	if err := fileutil.WriteFile(fullPath, []byte(content)); err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("failed to write file: %v", err)), nil
 	}
 
@@ -888,6 +885,7 @@ func detectMIMEType(path string) string {
 // buildDirectoryTree builds a text representation of directory structure
 func (cfs *CodeForgeServer) buildDirectoryTree(rootPath string, maxDepth int) (string, error) {
 	var result strings.Builder
+	excludes := ignore.Default()
 
 	err := filepath.WalkDir(rootPath, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
@@ -905,8 +903,8 @@ func (cfs *CodeForgeServer) buildDirectoryTree(rootPath string, maxDepth int) (s
 			return nil
 		}
 
-		// Skip hidden files and directories
-		if strings.HasPrefix(d.Name(), ".") && d.Name() != "." {
+		// Skip hidden files and directories and shared excludes
+		if (strings.HasPrefix(d.Name(), ".") && d.Name() != ".") || (relPath != "." && excludes.Match(relPath)) {
 			if d.IsDir() {
 				return filepath.SkipDir
 			}
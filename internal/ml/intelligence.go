@@ -157,22 +157,63 @@ func (ci *CodeIntelligence) LearnFromFeedback(ctx context.Context, query string,
 
 // GetIntelligentContext provides ML-enhanced context for LLMs
 func (ci *CodeIntelligence) GetIntelligentContext(ctx context.Context, query string, maxNodes int) (string, error) {
-	// Find best starting points using ML
-	startNodes := ci.findBestStartingNodes(query, 3)
+	context, _, err := ci.RetrieveContext(ctx, query, maxNodes)
+	return context, err
+}
+
+// RetrieveContext provides ML-enhanced context for LLMs along with the code
+// it was drawn from
+func (ci *CodeIntelligence) RetrieveContext(ctx context.Context, query string, maxNodes int) (string, []ContextSource, error) {
+	ci.mutex.RLock()
+	minWords := ci.config.MultiQueryMinWords
+	ci.mutex.RUnlock()
 
+	// Long prompts are retrieved for one focused sub-query at a time
 	var allResults []*SearchResult
+	searched := make(map[string]bool)
+	for _, subQuery := range SubQueries(query, minWords) {
+		// Search from multiple starting points
+		for _, startNode := range ci.findBestStartingNodes(subQuery, 3) {
+			if searched[startNode] {
+				continue
+			}
+			searched[startNode] = true
 
-	// Search from multiple starting points
-	for _, startNode := range startNodes {
-		result, err := ci.SmartSearch(ctx, query, startNode)
-		if err != nil {
-			continue
+			result, err := ci.SmartSearch(ctx, subQuery, startNode)
+			if err != nil {
+				continue
+			}
+			allResults = append(allResults, result)
 		}
-		allResults = append(allResults, result)
 	}
 
 	// Generate intelligent context
-	return ci.generateIntelligentContext(allResults, query, maxNodes), nil
+	context, sources := ci.generateIntelligentContext(mergeResults(allResults), query, maxNodes)
+	return context, sources, nil
+}
+
+// mergeResults ranks results by confidence and drops those whose paths
+// only repeat code already found by a better result
+func mergeResults(results []*SearchResult) []*SearchResult {
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Confidence > results[j].Confidence
+	})
+
+	seen := make(map[string]bool)
+	merged := results[:0]
+	for _, result := range results {
+		fresh := false
+		for _, nodeID := range result.BestPath {
+			if !seen[nodeID] {
+				seen[nodeID] = true
+				fresh = true
+			}
+		}
+		if fresh {
+			merged = append(merged, result)
+		}
+	}
+	return merged
 }
 
 // GetMLStats returns current ML performance statistics
@@ -577,7 +618,7 @@ func (ci *CodeIntelligence) nodeMatchesQuery(node *graph.Node, query string) boo
 		strings.Contains(strings.ToLower(node.Purpose), queryLower)
 }
 
-func (ci *CodeIntelligence) generateIntelligentContext(results []*SearchResult, query string, maxNodes int) string {
+func (ci *CodeIntelligence) generateIntelligentContext(results []*SearchResult, query string, maxNodes int) (string, []ContextSource) {
 	// Generate intelligent context from ML results
 	context := "# 🧠 ML-Enhanced Code Context\n\n"
 	context += fmt.Sprintf("**Query:** %s\n", query)
@@ -585,11 +626,12 @@ func (ci *CodeIntelligence) generateIntelligentContext(results []*SearchResult,
 
 	if len(results) == 0 {
 		context += "No relevant code found.\n"
-		return context
+		return context, nil
 	}
 
 	context += "## Most Relevant Code Paths\n\n"
 
+	var sources []ContextSource
 	nodeCount := 0
 	for i, result := range results {
 		if nodeCount >= maxNodes {
@@ -605,6 +647,7 @@ func (ci *CodeIntelligence) generateIntelligentContext(results []*SearchResult,
 
 			if node, exists := ci.graph.GetNode(nodeID); exists {
 				context += fmt.Sprintf("%d. `%s` - %s\n", j+1, node.Path, node.Purpose)
+				sources = append(sources, ContextSource{Path: node.Path, Confidence: result.Confidence})
 				nodeCount++
 			}
 		}
@@ -616,7 +659,7 @@ func (ci *CodeIntelligence) generateIntelligentContext(results []*SearchResult,
 		context += "\n"
 	}
 
-	return context
+	return context, sources
 }
 
 func (ci *CodeIntelligence) updateMetrics() {
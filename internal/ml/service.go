@@ -34,13 +34,25 @@ var (
 	// Global service instance
 	globalService *Service
 	serviceMutex  sync.RWMutex
+
+	// Config to initialize with when the service is first asked for
+	pendingConfig *config.Config
 )
 
+// InitializeOnFirstUse defers Initialize until GetService is first called,
+// so commands that never use ML context don't scan the codebase
+func InitializeOnFirstUse(cfg *config.Config) {
+	serviceMutex.Lock()
+	defer serviceMutex.Unlock()
+	pendingConfig = cfg
+}
+
 // Initialize sets up the ML service with the given configuration
 func Initialize(cfg *config.Config) error {
 	serviceMutex.Lock()
 	defer serviceMutex.Unlock()
 
+	pendingConfig = nil
 	if globalService != nil {
 		return nil // Already initialized
 	}
@@ -75,8 +87,17 @@ func Initialize(cfg *config.Config) error {
 	return nil
 }
 
-// GetService returns the global ML service instance
+// GetService returns the global ML service instance, initializing it first
+// if that was deferred with InitializeOnFirstUse
 func GetService() *Service {
+	serviceMutex.RLock()
+	service, pending := globalService, pendingConfig
+	serviceMutex.RUnlock()
+	if service != nil || pending == nil {
+		return service
+	}
+
+	Initialize(pending) // Errors leave ML disabled, as at startup
 	serviceMutex.RLock()
 	defer serviceMutex.RUnlock()
 	return globalService
@@ -94,8 +115,15 @@ func (s *Service) IsEnabled() bool {
 
 // GetIntelligentContext provides ML-enhanced context for user queries
 func (s *Service) GetIntelligentContext(ctx context.Context, query string, maxNodes int) string {
+	context, _ := s.RetrieveContext(ctx, query, maxNodes)
+	return context
+}
+
+// RetrieveContext provides ML-enhanced context for user queries along with
+// the code it was drawn from
+func (s *Service) RetrieveContext(ctx context.Context, query string, maxNodes int) (string, []ContextSource) {
 	if !s.IsEnabled() {
-		return "" // Graceful degradation
+		return "", nil // Graceful degradation
 	}
 
 	s.mutex.RLock()
@@ -107,13 +135,13 @@ func (s *Service) GetIntelligentContext(ctx context.Context, query string, maxNo
 	}
 
 	// Get intelligent context
-	context, err := s.intelligence.GetIntelligentContext(ctx, query, maxNodes)
+	context, sources, err := s.intelligence.RetrieveContext(ctx, query, maxNodes)
 	if err != nil {
 		log.Printf("ML Service: Failed to get intelligent context: %v", err)
-		return "" // Graceful degradation
+		return "", nil // Graceful degradation
 	}
 
-	return context
+	return context, sources
 }
 
 // SmartSearch performs ML-enhanced code search
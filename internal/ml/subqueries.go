@@ -0,0 +1,107 @@
+package ml
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// maxSubQueries caps how many focused queries a long prompt is split into
+const maxSubQueries = 4
+
+// clauseSplit breaks a prompt into sentences and the clauses joined by
+// "and then", "also" and the like, which usually ask about separate things
+var clauseSplit = regexp.MustCompile(`(?i)[.?!;]+(?:\s+|$)|\n+|,?\s+(?:and then|and also|also|then|plus|as well as)\s+`)
+
+// fillerWords carry no meaning for retrieval
+var fillerWords = map[string]bool{
+	"a": true, "an": true, "the": true, "i": true, "me": true, "my": true, "we": true, "our": true,
+	"you": true, "your": true, "it": true, "its": true, "is": true, "are": true, "was": true,
+	"be": true, "to": true, "of": true, "in": true, "on": true, "for": true, "with": true,
+	"and": true, "or": true, "but": true, "so": true, "that": true, "this": true, "there": true,
+	"please": true, "can": true, "could": true, "would": true, "should": true, "will": true,
+	"do": true, "does": true, "did": true, "want": true, "need": true, "like": true, "just": true,
+	"some": true, "any": true, "also": true, "then": true, "when": true, "if": true, "at": true,
+	"thanks": true, "thank": true, "lot": true, "help": true, "hi": true, "hello": true,
+}
+
+// SubQueries derives 2-4 focused queries from a long prompt, one per
+// sentence or clause that mentions something to look up, so each part of
+// a multi-part question is retrieved on its own. Prompts shorter than
+// minWords, or that can't be split, come back as the only query.
+func SubQueries(prompt string, minWords int) []string {
+	prompt = strings.TrimSpace(prompt)
+	if minWords <= 0 || len(strings.Fields(prompt)) < minWords {
+		return []string{prompt}
+	}
+
+	type clause struct {
+		text  string
+		score int
+		pos   int
+	}
+	var clauses []clause
+	seen := map[string]bool{}
+	for _, part := range clauseSplit.Split(prompt, -1) {
+		text, score := focusQuery(part)
+		if score == 0 || seen[text] {
+			continue
+		}
+		seen[text] = true
+		clauses = append(clauses, clause{text, score, len(clauses)})
+	}
+	if len(clauses) < 2 {
+		return []string{prompt}
+	}
+
+	// Keep the clauses naming the most code, in the order they were asked
+	sort.SliceStable(clauses, func(i, j int) bool { return clauses[i].score > clauses[j].score })
+	if len(clauses) > maxSubQueries {
+		clauses = clauses[:maxSubQueries]
+	}
+	sort.Slice(clauses, func(i, j int) bool { return clauses[i].pos < clauses[j].pos })
+
+	queries := make([]string, len(clauses))
+	for i, c := range clauses {
+		queries[i] = c.text
+	}
+	return queries
+}
+
+// focusQuery strips filler words from a clause and scores how much it has
+// to look up: identifiers and paths count double
+func focusQuery(clause string) (string, int) {
+	var words []string
+	score := 0
+	for _, word := range strings.Fields(clause) {
+		word = strings.Trim(word, "\"'`,:()[]{}")
+		if word == "" || fillerWords[strings.ToLower(word)] {
+			continue
+		}
+		words = append(words, word)
+		if looksLikeCode(word) {
+			score += 2
+		} else if len(word) > 3 {
+			score++
+		}
+	}
+	if len(words) < 2 && score < 2 {
+		return "", 0
+	}
+	return strings.Join(words, " "), score
+}
+
+// looksLikeCode reports whether word is an identifier or path rather than
+// plain English: camelCase, snake_case, dotted names and file paths
+func looksLikeCode(word string) bool {
+	if strings.ContainsAny(word, "_/.") {
+		return true
+	}
+	for i, r := range word {
+		if i > 0 && unicode.IsUpper(r) {
+			return true
+		}
+	}
+	return false
+}
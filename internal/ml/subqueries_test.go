@@ -0,0 +1,42 @@
+package ml
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSubQueries(t *testing.T) {
+	short := "where is parseConfig defined"
+	if got := SubQueries(short, 20); !reflect.DeepEqual(got, []string{short}) {
+		t.Errorf("SubQueries(short) = %v, want the prompt unchanged", got)
+	}
+
+	prompt := "I want to understand how the server handles login requests in internal/auth/session.go. " +
+		"Then show me where refreshToken is called, and also explain how the user_sessions table is migrated. " +
+		"Thanks a lot for the help!"
+	got := SubQueries(prompt, 20)
+	want := []string{
+		"understand how server handles login requests internal/auth/session.go",
+		"show where refreshToken called",
+		"explain how user_sessions table migrated",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SubQueries() = %q, want %q", got, want)
+	}
+
+	if got := SubQueries(prompt, 0); len(got) != 1 {
+		t.Errorf("SubQueries() with splitting disabled = %v, want one query", got)
+	}
+}
+
+func TestMergeResults(t *testing.T) {
+	results := []*SearchResult{
+		{BestPath: []string{"a", "b"}, Confidence: 0.4},
+		{BestPath: []string{"b", "c"}, Confidence: 0.9},
+		{BestPath: []string{"c"}, Confidence: 0.5},
+	}
+	merged := mergeResults(results)
+	if len(merged) != 2 || merged[0].Confidence != 0.9 || merged[1].Confidence != 0.4 {
+		t.Errorf("mergeResults() kept %d results, want the 0.9 and 0.4 paths", len(merged))
+	}
+}
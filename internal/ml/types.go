@@ -45,6 +45,12 @@ type SearchResult struct {
 	Experiences []Experience   `json:"experiences"` // Learning experiences collected
 }
 
+// ContextSource is a piece of code included in generated context
+type ContextSource struct {
+	Path       string  `json:"path"`       // File the code comes from
+	Confidence float64 `json:"confidence"` // Confidence of the search path that found it
+}
+
 // ValueNetwork represents a simple neural network for value estimation
 type ValueNetwork struct {
 	weights      map[string]float64 // Feature weights
@@ -199,6 +205,9 @@ type MLConfig struct {
 	MaxExperiences int  `json:"max_experiences"`
 	BatchSize      int  `json:"batch_size"`
 	EnableLearning bool `json:"enable_learning"`
+
+	// Retrieval Configuration
+	MultiQueryMinWords int `json:"multi_query_min_words"` // Prompts this long are split into sub-queries, 0 never splits
 }
 
 // DefaultMLConfig returns default ML configuration
@@ -218,6 +227,8 @@ func DefaultMLConfig() *MLConfig {
 		MaxExperiences: 10000,
 		BatchSize:      32,
 		EnableLearning: true,
+
+		MultiQueryMinWords: 20,
 	}
 }
 
@@ -0,0 +1,117 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Capability is a feature a request can depend on
+type Capability string
+
+const (
+	CapabilityVision     Capability = "vision"
+	CapabilityTools      Capability = "tools"
+	CapabilityJSONMode   Capability = "json_mode"
+	CapabilityStreaming  Capability = "streaming"
+	CapabilityMaxContext Capability = "max_context"
+)
+
+// Requirements describes what a request needs from the model serving it
+type Requirements struct {
+	Images      int  // Number of image attachments
+	Tools       bool // Request advertises tools
+	JSONMode    bool // Request asks for a JSON response format
+	Streaming   bool // Response is streamed
+	InputTokens int  // Estimated prompt size
+}
+
+// CapabilityError reports a request the model can't serve. It is returned
+// before anything is sent so users see why instead of a provider 400.
+type CapabilityError struct {
+	Model      string
+	Capability Capability
+	Detail     string
+}
+
+func (e *CapabilityError) Error() string {
+	return fmt.Sprintf("model %s %s", e.Model, e.Detail)
+}
+
+// CapabilityRow is one model's entry in the capability matrix
+type CapabilityRow struct {
+	ID         CanonicalModelID `json:"id"`
+	Name       string           `json:"name"`
+	Vision     bool             `json:"vision"`
+	Tools      bool             `json:"tools"`
+	JSONMode   bool             `json:"jsonMode"`
+	Streaming  bool             `json:"streaming"`
+	MaxContext int              `json:"maxContext"`
+}
+
+// Validate checks the requirements against the model's capabilities and
+// context window, returning a *CapabilityError for the first one it lacks
+func (m *CanonicalModel) Validate(req Requirements) error {
+	caps := m.Capabilities
+	switch {
+	case req.Images > 0 && !(caps.SupportsVision || caps.SupportsImages):
+		return &CapabilityError{m.Name, CapabilityVision, "does not accept images; remove the attachment or switch to a vision model"}
+	case req.Tools && !caps.SupportsTools:
+		return &CapabilityError{m.Name, CapabilityTools, "does not support tool calling"}
+	case req.JSONMode && !caps.SupportsJSONMode:
+		return &CapabilityError{m.Name, CapabilityJSONMode, "does not support JSON mode"}
+	case req.Streaming && !caps.SupportsStreaming:
+		return &CapabilityError{m.Name, CapabilityStreaming, "does not support streaming"}
+	case m.Limits.ContextWindow > 0 && req.InputTokens > m.Limits.ContextWindow:
+		return &CapabilityError{m.Name, CapabilityMaxContext, fmt.Sprintf(
+			"has a %d token context window but the request is about %d tokens", m.Limits.ContextWindow, req.InputTokens)}
+	}
+	return nil
+}
+
+// Lookup finds a model by the ID a provider knows it by, falling back to
+// the legacy model tables for models without a canonical entry
+func (mr *ModelRegistry) Lookup(providerID ProviderID, providerModelID string) (*CanonicalModel, bool) {
+	if model, ok := mr.GetModelByProvider(providerID, providerModelID); ok {
+		return model, true
+	}
+	if model, ok := mr.GetModel(CanonicalModelID(providerModelID)); ok {
+		return model, true
+	}
+	for _, legacy := range SupportedModels {
+		if legacy.APIModel == providerModelID && strings.EqualFold(string(legacy.Provider), string(providerID)) {
+			return ConvertLegacyToCanonical(legacy), true
+		}
+	}
+	return nil, false
+}
+
+// ValidateRequest checks a request against the capabilities of the model a
+// provider serves it with. Unknown models aren't validated.
+func (mr *ModelRegistry) ValidateRequest(providerID ProviderID, providerModelID string, req Requirements) error {
+	model, ok := mr.Lookup(providerID, providerModelID)
+	if !ok {
+		return nil
+	}
+	return model.Validate(req)
+}
+
+// CapabilityMatrix lists the capabilities of every registered model,
+// ordered by ID
+func (mr *ModelRegistry) CapabilityMatrix() []CapabilityRow {
+	models := mr.ListModels()
+	rows := make([]CapabilityRow, 0, len(models))
+	for _, m := range models {
+		rows = append(rows, CapabilityRow{
+			ID:         m.ID,
+			Name:       m.Name,
+			Vision:     m.Capabilities.SupportsVision || m.Capabilities.SupportsImages,
+			Tools:      m.Capabilities.SupportsTools,
+			JSONMode:   m.Capabilities.SupportsJSONMode,
+			Streaming:  m.Capabilities.SupportsStreaming,
+			MaxContext: m.Limits.ContextWindow,
+		})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].ID < rows[j].ID })
+	return rows
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateRequest(t *testing.T) {
+	registry := NewModelRegistry()
+
+	tests := []struct {
+		name     string
+		provider ProviderID
+		model    string
+		req      Requirements
+		want     Capability // "" when the request is valid
+	}{
+		{"vision model with image", ProviderOpenAICanonical, "gpt-4o", Requirements{Images: 1}, ""},
+		{"image on text-only model", ProviderOpenAICanonical, "o3-mini", Requirements{Images: 1}, CapabilityVision},
+		{"json mode unsupported", ProviderAnthropicCanonical, "claude-sonnet-4-20250514", Requirements{JSONMode: true}, CapabilityJSONMode},
+		{"json mode supported", ProviderOpenAICanonical, "gpt-4o", Requirements{JSONMode: true, Streaming: true}, ""},
+		{"over context window", ProviderOpenAICanonical, "gpt-4o", Requirements{InputTokens: 200000}, CapabilityMaxContext},
+		{"legacy model", ProviderGeminiCanonical, "gemini-2.0-flash", Requirements{Images: 2}, ""},
+		{"unknown model", ProviderOllamaCanonical, "llama3", Requirements{Images: 1, JSONMode: true}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := registry.ValidateRequest(tt.provider, tt.model, tt.req)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("ValidateRequest() = %v, want nil", err)
+				}
+				return
+			}
+
+			var capErr *CapabilityError
+			if !errors.As(err, &capErr) {
+				t.Fatalf("ValidateRequest() = %v, want *CapabilityError", err)
+			}
+			if capErr.Capability != tt.want {
+				t.Errorf("Capability = %q, want %q", capErr.Capability, tt.want)
+			}
+		})
+	}
+}
+
+func TestCapabilityMatrix(t *testing.T) {
+	matrix := NewModelRegistry().CapabilityMatrix()
+	if len(matrix) != len(CanonicalModels) {
+		t.Fatalf("matrix has %d rows, want %d", len(matrix), len(CanonicalModels))
+	}
+	for i := 1; i < len(matrix); i++ {
+		if matrix[i-1].ID > matrix[i].ID {
+			t.Errorf("matrix not sorted: %s before %s", matrix[i-1].ID, matrix[i].ID)
+		}
+	}
+	for _, row := range matrix {
+		if row.MaxContext == 0 {
+			t.Errorf("%s has no max context", row.ID)
+		}
+	}
+}
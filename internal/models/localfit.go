@@ -0,0 +1,231 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/platform"
+)
+
+// LocalFit rates how well a local model suits this machine's memory
+type LocalFit int
+
+const (
+	FitUnknown  LocalFit = iota // Model size or hardware unknown
+	FitGood                     // Runs comfortably
+	FitSlow                     // Runs, but partly or wholly on the CPU
+	FitTooLarge                 // Doesn't fit in memory
+)
+
+// LocalEstimate is the memory a local model needs and how well it fits
+type LocalEstimate struct {
+	Fit        LocalFit
+	Required   int64  // Estimated memory to run the model, in bytes
+	Note       string // Why the model is slow or too large
+	Suggestion string // A quantization that would fit better, if any
+}
+
+// Quantization is a GGUF quantization and its approximate bits per weight
+type Quantization struct {
+	Name string
+	Bits float64
+}
+
+// Quantizations are the common GGUF quantizations, largest first
+var Quantizations = []Quantization{
+	{"f16", 16},
+	{"q8_0", 8.5},
+	{"q6_K", 6.6},
+	{"q5_K_M", 5.7},
+	{"q4_K_M", 4.8},
+	{"q3_K_M", 3.9},
+	{"q2_K", 3.4},
+}
+
+const (
+	// defaultQuantBits is assumed when the quantization isn't known; Ollama,
+	// LM Studio and Jan default to Q4_K_M
+	defaultQuantBits = 4.8
+	// runtimeOverhead covers the KV cache and runtime buffers for a typical
+	// context length
+	runtimeOverhead       = 1.2
+	runtimeBase     int64 = 512 << 20
+	// cpuComfortable is the largest model that runs at a usable speed on
+	// the CPU alone
+	cpuComfortable int64 = 6 << 30
+)
+
+var (
+	paramsPattern = regexp.MustCompile(`(?i)(?:^|[^a-z0-9.])(?:(\d+)x)?(\d+(?:\.\d+)?)b(?:$|[^a-z0-9])`)
+	quantPattern  = regexp.MustCompile(`(?i)(?:^|[^a-z0-9])(f16|fp16|bf16|q\d(?:_[a-z0-9]+)*)(?:$|[^a-z0-9])`)
+)
+
+// ParseParameterCount reads the parameter count in billions from a model
+// name such as "llama3.1:8b" or "mixtral:8x7b", returning 0 when absent
+func ParseParameterCount(name string) float64 {
+	m := paramsPattern.FindStringSubmatch(name)
+	if m == nil {
+		return 0
+	}
+	params, _ := strconv.ParseFloat(m[2], 64)
+	if m[1] != "" {
+		experts, _ := strconv.ParseFloat(m[1], 64)
+		params *= experts
+	}
+	return params
+}
+
+// ParseParameterSize reads a parameter size such as "8.0B" or "567M", as
+// reported by Ollama, in billions
+func ParseParameterSize(size string) float64 {
+	size = strings.ToUpper(strings.TrimSpace(size))
+	scale := 1.0
+	switch {
+	case strings.HasSuffix(size, "B"):
+		size = strings.TrimSuffix(size, "B")
+	case strings.HasSuffix(size, "M"):
+		size = strings.TrimSuffix(size, "M")
+		scale = 0.001
+	default:
+		return 0
+	}
+	n, err := strconv.ParseFloat(size, 64)
+	if err != nil {
+		return 0
+	}
+	return n * scale
+}
+
+// ParseQuantization reads the quantization from a model name such as
+// "qwen2.5-coder:7b-instruct-q8_0", returning "" when absent
+func ParseQuantization(name string) string {
+	m := quantPattern.FindStringSubmatch(name)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+// quantBits returns the bits per weight of a quantization
+func quantBits(quant string) float64 {
+	quant = strings.ToLower(quant)
+	switch quant {
+	case "":
+		return defaultQuantBits
+	case "fp16", "bf16":
+		return 16
+	}
+	for _, q := range Quantizations {
+		if strings.ToLower(q.Name) == quant {
+			return q.Bits
+		}
+	}
+	// Other variants of a level, e.g. q4_0 or q4_K_S
+	if len(quant) >= 2 && quant[0] == 'q' {
+		if level, err := strconv.Atoi(quant[1:2]); err == nil {
+			return float64(level) + 0.5
+		}
+	}
+	return defaultQuantBits
+}
+
+// requiredMemory estimates the memory to run weights of the given size
+func requiredMemory(weights int64) int64 {
+	return int64(float64(weights)*runtimeOverhead) + runtimeBase
+}
+
+// EstimateLocalModel rates a local model against the hardware. size is the
+// model's size on disk (0 when unknown), params its parameter count in
+// billions and quant its quantization; either may be empty, in which case
+// they are read from the name.
+func EstimateLocalModel(name string, size int64, params float64, quant string, hw platform.Hardware) LocalEstimate {
+	if params == 0 {
+		params = ParseParameterCount(name)
+	}
+	if quant == "" {
+		quant = ParseQuantization(name)
+	}
+	bits := quantBits(quant)
+
+	switch {
+	case size == 0 && params == 0:
+		return LocalEstimate{}
+	case size == 0:
+		size = int64(params * 1e9 * bits / 8)
+	case params == 0:
+		params = float64(size) * 8 / bits / 1e9
+	}
+
+	est := LocalEstimate{Required: requiredMemory(size)}
+	if hw.RAM == 0 {
+		return est
+	}
+
+	gpu := hw.VRAM
+	if hw.UnifiedMemory {
+		// macOS lets the GPU use about three quarters of unified memory
+		gpu = hw.RAM * 3 / 4
+	}
+	ram := hw.RAM * 4 / 5
+
+	switch {
+	case est.Required <= gpu:
+		est.Fit = FitGood
+	case est.Required > ram:
+		est.Fit = FitTooLarge
+		est.Note = fmt.Sprintf("needs about %s, more than this machine's %s of RAM", platform.FormatGB(est.Required), platform.FormatGB(hw.RAM))
+	case gpu > 0:
+		est.Fit = FitSlow
+		est.Note = fmt.Sprintf("needs about %s but the GPU has %s; it will run partly on the CPU", platform.FormatGB(est.Required), platform.FormatGB(gpu))
+	case est.Required <= cpuComfortable:
+		est.Fit = FitGood
+	default:
+		est.Fit = FitSlow
+		est.Note = fmt.Sprintf("needs about %s and will run on the CPU only", platform.FormatGB(est.Required))
+	}
+
+	if est.Fit == FitSlow || est.Fit == FitTooLarge {
+		est.Suggestion = suggestQuantization(params, bits, gpu, ram)
+	}
+	return est
+}
+
+// suggestQuantization picks the largest quantization smaller than the
+// current one that fits in GPU memory, or for CPU-only machines runs at a
+// usable speed. Failing that it settles for one that fits in RAM.
+func suggestQuantization(params, bits float64, gpu, ram int64) string {
+	budgets := []int64{gpu, ram}
+	if gpu == 0 {
+		budgets[0] = cpuComfortable
+		if ram < cpuComfortable {
+			budgets[0] = ram
+		}
+	}
+
+	for _, budget := range budgets {
+		for _, q := range Quantizations {
+			if q.Bits >= bits {
+				continue
+			}
+			required := requiredMemory(int64(params * 1e9 * q.Bits / 8))
+			if required <= budget {
+				return fmt.Sprintf("try a %s build (about %s)", q.Name, platform.FormatGB(required))
+			}
+		}
+	}
+	return ""
+}
+
+// Label is a short description of the fit for model lists, "" when the
+// model fits or the fit is unknown
+func (e LocalEstimate) Label() string {
+	switch e.Fit {
+	case FitSlow:
+		return "slow on this machine"
+	case FitTooLarge:
+		return "won't fit in memory"
+	}
+	return ""
+}
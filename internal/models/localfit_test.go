@@ -0,0 +1,75 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/platform"
+)
+
+func TestParseModelName(t *testing.T) {
+	tests := []struct {
+		name   string
+		params float64
+		quant  string
+	}{
+		{"llama3.1:8b", 8, ""},
+		{"mixtral:8x7b", 56, ""},
+		{"qwen2.5-coder:7b-instruct-q8_0", 7, "q8_0"},
+		{"Meta-Llama-3.1-70B-Instruct-Q4_K_M.gguf", 70, "Q4_K_M"},
+		{"phi3:3.8b", 3.8, ""},
+		{"qwen2.5", 0, ""},
+	}
+
+	for _, tt := range tests {
+		if got := ParseParameterCount(tt.name); got != tt.params {
+			t.Errorf("ParseParameterCount(%q) = %v, want %v", tt.name, got, tt.params)
+		}
+		if got := ParseQuantization(tt.name); got != tt.quant {
+			t.Errorf("ParseQuantization(%q) = %q, want %q", tt.name, got, tt.quant)
+		}
+	}
+
+	if got := ParseParameterSize("8.0B"); got != 8 {
+		t.Errorf("ParseParameterSize(8.0B) = %v", got)
+	}
+}
+
+func TestEstimateLocalModel(t *testing.T) {
+	gpu := platform.Hardware{RAM: 32 << 30, VRAM: 8 << 30, GPU: "NVIDIA GeForce RTX 3070"}
+	cpu := platform.Hardware{RAM: 16 << 30}
+	mac := platform.Hardware{RAM: 16 << 30, GPU: "Apple Silicon", UnifiedMemory: true}
+
+	tests := []struct {
+		name    string
+		model   string
+		hw      platform.Hardware
+		want    LocalFit
+		suggest string // Expected quantization in the suggestion, "" for none
+	}{
+		{"fits in VRAM", "llama3.1:8b", gpu, FitGood, ""},
+		{"spills out of VRAM", "llama3.1:8b-instruct-q8_0", gpu, FitSlow, "q6_K"},
+		{"too large for RAM", "qwen2.5-coder:32b-instruct-q8_0", gpu, FitTooLarge, "q4_K_M"},
+		{"too large for any quantization", "llama3.1:405b", gpu, FitTooLarge, ""},
+		{"small model on CPU", "llama3.2:3b", cpu, FitGood, ""},
+		{"large model on CPU", "qwen2.5-coder:14b", cpu, FitSlow, "q3_K_M"},
+		{"unified memory", "qwen2.5-coder:14b", mac, FitGood, ""},
+		{"unknown size", "codellama", gpu, FitUnknown, ""},
+		{"unknown hardware", "llama3.1:8b", platform.Hardware{}, FitUnknown, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			est := EstimateLocalModel(tt.model, 0, 0, "", tt.hw)
+			if est.Fit != tt.want {
+				t.Fatalf("Fit = %v, want %v (%+v)", est.Fit, tt.want, est)
+			}
+			if (est.Label() == "") != (tt.want == FitGood || tt.want == FitUnknown) {
+				t.Errorf("Label() = %q for fit %v", est.Label(), est.Fit)
+			}
+			if tt.suggest == "" && est.Suggestion != "" || !strings.Contains(est.Suggestion, tt.suggest) {
+				t.Errorf("Suggestion = %q, want %q", est.Suggestion, tt.suggest)
+			}
+		})
+	}
+}
@@ -347,6 +347,8 @@ func (mm *ModelManager) modelHasFeature(model *CanonicalModel, feature string) b
 		return model.Capabilities.SupportsStreaming
 	case "images":
 		return model.Capabilities.SupportsImages
+	case "json_mode", "json":
+		return model.Capabilities.SupportsJSONMode
 	default:
 		return false
 	}
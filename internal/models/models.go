@@ -59,6 +59,7 @@ type ModelCapabilities struct {
 	SupportsVision      bool `json:"supportsVision"`
 	SupportsCode        bool `json:"supportsCode"`
 	SupportsReasoning   bool `json:"supportsReasoning"`
+	SupportsJSONMode    bool `json:"supportsJsonMode"`
 }
 
 // ModelPricing represents pricing information
@@ -1307,6 +1308,7 @@ func initializeCanonicalModels() {
 			SupportsVision:      true,
 			SupportsCode:        true,
 			SupportsReasoning:   true,
+			SupportsJSONMode:    false,
 		},
 		Pricing: ModelPricing{
 			InputPrice:       3.0,
@@ -1357,6 +1359,7 @@ func initializeCanonicalModels() {
 			SupportsVision:      true,
 			SupportsCode:        true,
 			SupportsReasoning:   false,
+			SupportsJSONMode:    true,
 		},
 		Pricing: ModelPricing{
 			InputPrice:       2.5,
@@ -1402,6 +1405,7 @@ func initializeCanonicalModels() {
 			SupportsVision:      false,
 			SupportsCode:        true,
 			SupportsReasoning:   true,
+			SupportsJSONMode:    true,
 		},
 		Pricing: ModelPricing{
 			InputPrice:  1.1,
@@ -1445,6 +1449,7 @@ func initializeCanonicalModels() {
 			SupportsVision:      true,
 			SupportsCode:        true,
 			SupportsReasoning:   false,
+			SupportsJSONMode:    true,
 		},
 		Pricing: ModelPricing{
 			InputPrice:       0.075,
@@ -1636,6 +1641,7 @@ func ConvertLegacyToCanonical(legacy Model) *CanonicalModel {
 			SupportsVision:      legacy.SupportsAttachments,
 			SupportsCode:        true,
 			SupportsReasoning:   legacy.CanReason,
+			SupportsJSONMode:    legacy.Provider == ProviderOpenAI || legacy.Provider == ProviderGemini || legacy.Provider == ProviderAzure,
 		},
 		Pricing: ModelPricing{
 			InputPrice:       legacy.CostPer1MIn,
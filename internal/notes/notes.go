@@ -0,0 +1,106 @@
+package notes
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+)
+
+// Store is a project's scratch notes, kept as a markdown file
+type Store struct {
+	path string
+}
+
+// NewStore creates a store backed by the file at path
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// ForProject returns the notes store of the current project, or nil when
+// configuration isn't loaded
+func ForProject() *Store {
+	cfg := config.Get()
+	if cfg == nil {
+		return nil
+	}
+	return NewStore(cfg.NotesPath())
+}
+
+// Path returns the notes file path
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Load returns the notes, or "" when none have been saved
+func (s *Store) Load() (string, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read notes: %w", err)
+	}
+	return string(data), nil
+}
+
+// Save replaces the notes. Saving empty notes removes the file.
+func (s *Store) Save(text string) error {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to clear notes: %w", err)
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create notes directory: %w", err)
+	}
+	if err := os.WriteFile(s.path, []byte(text+"\n"), 0o644); err != nil {
+		return fmt.Errorf("failed to write notes: %w", err)
+	}
+	return nil
+}
+
+// Append adds a timestamped note to the end of the notes
+func (s *Store) Append(note string, at time.Time) error {
+	note = strings.TrimSpace(note)
+	if note == "" {
+		return fmt.Errorf("note is empty")
+	}
+
+	existing, err := s.Load()
+	if err != nil {
+		return err
+	}
+	entry := fmt.Sprintf("- %s %s", at.Format("2006-01-02 15:04"), note)
+	if existing = strings.TrimSpace(existing); existing != "" {
+		entry = existing + "\n" + entry
+	}
+	return s.Save(entry)
+}
+
+// Context returns the notes formatted for a system prompt, or "" when there
+// are none
+func (s *Store) Context() string {
+	text, err := s.Load()
+	if err != nil || strings.TrimSpace(text) == "" {
+		return ""
+	}
+	return "**Project Notes** (decisions recorded by the developer in earlier sessions):\n\n" + strings.TrimSpace(text) + "\n"
+}
+
+// PromptContext returns the project notes to add to a system prompt, or ""
+// when notes.injectContext is off. Notes are read on every call so edits made
+// mid-session apply to the next prompt.
+func PromptContext() string {
+	cfg := config.Get()
+	if cfg == nil || !cfg.Notes.InjectContext {
+		return ""
+	}
+	return NewStore(cfg.NotesPath()).Context()
+}
@@ -0,0 +1,50 @@
+package notes
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStoreAppendAndSave(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "project", "notes.md"))
+
+	if text, err := store.Load(); err != nil || text != "" {
+		t.Fatalf("Expected no notes, got %q (%v)", text, err)
+	}
+	if store.Context() != "" {
+		t.Error("Expected empty context without notes")
+	}
+
+	at := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+	if err := store.Append("Use sqlite for the cache", at); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	if err := store.Append("  Keep the API v1 compatible ", at.Add(time.Hour)); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	if err := store.Append("   ", at); err == nil {
+		t.Error("Expected error appending an empty note")
+	}
+
+	text, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "- 2026-10-16 09:30 Use sqlite for the cache\n- 2026-10-16 10:30 Keep the API v1 compatible\n"
+	if text != want {
+		t.Errorf("Unexpected notes:\n%q\nwant\n%q", text, want)
+	}
+	if !strings.Contains(store.Context(), "Keep the API v1 compatible") {
+		t.Errorf("Context missing notes: %s", store.Context())
+	}
+
+	if err := store.Save(""); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if _, err := os.Stat(store.Path()); !os.IsNotExist(err) {
+		t.Errorf("Expected saving empty notes to remove the file, got %v", err)
+	}
+}
@@ -0,0 +1,246 @@
+// Package perf profiles a piece of work and summarizes where its CPU time
+// and allocations went
+package perf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// heapSampleInterval is how often heap in use is read for the peak
+const heapSampleInterval = 100 * time.Millisecond
+
+// Cost is what one function cost: flat in itself, cumulative with what it
+// called. CPU costs are nanoseconds and allocation costs bytes.
+type Cost struct {
+	Function  string  `json:"function"`
+	Flat      int64   `json:"flat"`
+	Cum       int64   `json:"cum"`
+	FlatShare float64 `json:"flat_share"`
+}
+
+// Options tune a profiled run
+type Options struct {
+	Top int    // Functions listed per profile; 0 for all
+	Dir string // Where cpu.pprof and heap.pprof are saved; empty for nowhere
+}
+
+// Report summarizes a profiled run
+type Report struct {
+	Name        string        `json:"name"`
+	Duration    time.Duration `json:"duration"`
+	CPUTime     time.Duration `json:"cpu_time"`
+	CPU         []Cost        `json:"cpu"`
+	Allocated   int64         `json:"allocated"`
+	Allocations []Cost        `json:"allocations"`
+	PeakHeap    uint64        `json:"peak_heap"`
+	Profiles    []string      `json:"profiles,omitempty"`
+	Err         string        `json:"error,omitempty"`
+}
+
+// Run profiles task, sampling CPU for all of it and diffing heap profiles
+// taken before and after it for what it allocated. A task error doesn't
+// fail the run; it's noted in the report, as its profile is still of use.
+func Run(ctx context.Context, name string, opts Options, task func(ctx context.Context) error) (*Report, error) {
+	before, err := heapProfile()
+	if err != nil {
+		return nil, err
+	}
+
+	var cpu bytes.Buffer
+	if err := pprof.StartCPUProfile(&cpu); err != nil {
+		return nil, fmt.Errorf("failed to start CPU profile: %w", err)
+	}
+
+	var peak atomic.Uint64
+	done := make(chan struct{})
+	sampled := make(chan struct{})
+	go func() {
+		defer close(sampled)
+		ticker := time.NewTicker(heapSampleInterval)
+		defer ticker.Stop()
+		for {
+			var stats runtime.MemStats
+			runtime.ReadMemStats(&stats)
+			if stats.HeapInuse > peak.Load() {
+				peak.Store(stats.HeapInuse)
+			}
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	start := time.Now()
+	taskErr := task(ctx)
+	report := &Report{Name: name, Duration: time.Since(start)}
+
+	pprof.StopCPUProfile()
+	close(done)
+	<-sampled
+	report.PeakHeap = peak.Load()
+	if taskErr != nil {
+		report.Err = taskErr.Error()
+	}
+
+	after, err := heapProfile()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := report.summarizeCPU(cpu.Bytes(), opts.Top); err != nil {
+		return nil, err
+	}
+	if err := report.summarizeAllocations(before, after, opts.Top); err != nil {
+		return nil, err
+	}
+
+	if opts.Dir != "" {
+		if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create profile directory: %w", err)
+		}
+		for file, data := range map[string][]byte{"cpu.pprof": cpu.Bytes(), "heap.pprof": after} {
+			path := filepath.Join(opts.Dir, file)
+			if err := os.WriteFile(path, data, 0o644); err != nil {
+				return nil, fmt.Errorf("failed to save profile: %w", err)
+			}
+			report.Profiles = append(report.Profiles, path)
+		}
+	}
+	return report, nil
+}
+
+// heapProfile writes a heap profile after a collection, so it counts
+// everything allocated so far
+func heapProfile() ([]byte, error) {
+	runtime.GC()
+	var buf bytes.Buffer
+	if err := pprof.Lookup("heap").WriteTo(&buf, 0); err != nil {
+		return nil, fmt.Errorf("failed to write heap profile: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (r *Report) summarizeCPU(data []byte, top int) error {
+	if len(data) == 0 {
+		return nil
+	}
+	p, err := parseProfile(data)
+	if err != nil {
+		return err
+	}
+	costs, total, err := p.costs("cpu")
+	if err != nil {
+		return err
+	}
+	r.CPUTime = time.Duration(total)
+	r.CPU = topCosts(costs, total, top)
+	return nil
+}
+
+// summarizeAllocations takes the bytes allocated before the run from those
+// allocated by its end, by function
+func (r *Report) summarizeAllocations(before, after []byte, top int) error {
+	p, err := parseProfile(before)
+	if err != nil {
+		return err
+	}
+	startCosts, startTotal, err := p.costs("alloc_space")
+	if err != nil {
+		return err
+	}
+	if p, err = parseProfile(after); err != nil {
+		return err
+	}
+	costs, total, err := p.costs("alloc_space")
+	if err != nil {
+		return err
+	}
+
+	for fn, c := range costs {
+		start := startCosts[fn]
+		c.Flat -= start.Flat
+		c.Cum -= start.Cum
+		costs[fn] = c
+	}
+	r.Allocated = total - startTotal
+	r.Allocations = topCosts(costs, r.Allocated, top)
+	return nil
+}
+
+// Write prints the report as text
+func (r *Report) Write(w io.Writer) {
+	fmt.Fprintf(w, "Profile: %s\n\n", r.Name)
+	fmt.Fprintf(w, "  wall time    %s\n", round(r.Duration))
+	fmt.Fprintf(w, "  CPU time     %s\n", round(r.CPUTime))
+	fmt.Fprintf(w, "  allocated    %s\n", formatBytes(r.Allocated))
+	fmt.Fprintf(w, "  peak heap    %s\n", formatBytes(int64(r.PeakHeap)))
+	if r.Err != "" {
+		fmt.Fprintf(w, "  error        %s\n", r.Err)
+	}
+
+	fmt.Fprintf(w, "\nTop CPU\n")
+	if len(r.CPU) == 0 {
+		fmt.Fprintf(w, "  no samples; the run may have been too short\n")
+	}
+	for _, c := range r.CPU {
+		fmt.Fprintf(w, "  %5.1f%%  %10s  %10s  %s\n", 100*c.FlatShare,
+			round(time.Duration(c.Flat)), round(time.Duration(c.Cum)), shortName(c.Function))
+	}
+
+	fmt.Fprintf(w, "\nTop allocations\n")
+	if len(r.Allocations) == 0 {
+		fmt.Fprintf(w, "  none\n")
+	}
+	for _, c := range r.Allocations {
+		fmt.Fprintf(w, "  %5.1f%%  %10s  %10s  %s\n", 100*c.FlatShare,
+			formatBytes(c.Flat), formatBytes(c.Cum), shortName(c.Function))
+	}
+
+	if len(r.Profiles) > 0 {
+		fmt.Fprintf(w, "\nProfiles saved to %s; explore them with go tool pprof\n", strings.Join(r.Profiles, ", "))
+	}
+}
+
+// shortName drops the import path before a function's package, as in
+// codeforge/internal/vectordb.(*VectorDB).StoreChunk to
+// vectordb.(*VectorDB).StoreChunk
+func shortName(fn string) string {
+	if i := strings.LastIndex(fn, "/"); i >= 0 {
+		return fn[i+1:]
+	}
+	return fn
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<30 || n <= -1<<30:
+		return fmt.Sprintf("%.1f GB", float64(n)/(1<<30))
+	case n >= 1<<20 || n <= -1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10 || n <= -1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
+
+func round(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Second:
+		return d.Round(10 * time.Millisecond)
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond)
+	}
+	return d.Round(time.Microsecond)
+}
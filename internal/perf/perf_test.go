@@ -0,0 +1,56 @@
+package perf
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+var sink [][]byte
+
+//go:noinline
+func allocateBuffers() {
+	for i := 0; i < 64; i++ {
+		sink = append(sink, make([]byte, 64<<10))
+	}
+}
+
+func TestRunSummarizesAllocations(t *testing.T) {
+	// Record every allocation, so the totals are exact rather than sampled
+	defer func(rate int) { runtime.MemProfileRate = rate }(runtime.MemProfileRate)
+	runtime.MemProfileRate = 1
+
+	dir := t.TempDir()
+	report, err := Run(context.Background(), "allocate", Options{Top: 10, Dir: dir}, func(ctx context.Context) error {
+		allocateBuffers()
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink = nil
+
+	if report.Allocated < 64*64<<10 {
+		t.Errorf("allocated %d bytes, want at least %d", report.Allocated, 64*64<<10)
+	}
+	var found bool
+	for _, c := range report.Allocations {
+		if strings.HasSuffix(c.Function, ".allocateBuffers") {
+			found = c.Flat >= 64*64<<10 && c.FlatShare > 0
+		}
+	}
+	if !found {
+		t.Errorf("allocateBuffers missing from top allocations: %+v", report.Allocations)
+	}
+	if len(report.Profiles) != 2 {
+		t.Errorf("saved profiles %v, want cpu and heap", report.Profiles)
+	}
+
+	var out bytes.Buffer
+	report.Write(&out)
+	if !strings.Contains(out.String(), "perf.allocateBuffers") {
+		t.Errorf("report doesn't name allocateBuffers:\n%s", out.String())
+	}
+}
@@ -0,0 +1,291 @@
+package perf
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// profile is what a report needs of a pprof profile: its samples, each a
+// stack of function names with a value per sample type
+type profile struct {
+	sampleTypes []string
+	samples     []sample
+}
+
+type sample struct {
+	stack  []string // Leaf first
+	values []int64
+}
+
+// Field numbers of the pprof profile.proto messages read
+const (
+	profileSampleType  = 1
+	profileSample      = 2
+	profileLocation    = 4
+	profileFunction    = 5
+	profileStringTable = 6
+
+	valueTypeType = 1
+
+	sampleLocationID = 1
+	sampleValue      = 2
+
+	locationID   = 1
+	locationLine = 4
+	lineFunction = 1
+
+	functionID   = 1
+	functionName = 2
+)
+
+// parseProfile decodes a pprof profile as the runtime writes it, gzipped
+// or not
+func parseProfile(data []byte) (*profile, error) {
+	if len(data) > 1 && data[0] == 0x1f && data[1] == 0x8b {
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress profile: %w", err)
+		}
+		if data, err = io.ReadAll(zr); err != nil {
+			return nil, fmt.Errorf("failed to decompress profile: %w", err)
+		}
+	}
+
+	var (
+		table       []string
+		sampleTypes []uint64                // String table indexes
+		samples     [][2][]uint64           // Location IDs and values
+		locations   = map[uint64][]uint64{} // Function IDs, innermost inlined call first
+		functions   = map[uint64]uint64{}   // Name string indexes
+	)
+	err := eachField(data, func(num protowire.Number, varint uint64, msg []byte) error {
+		switch num {
+		case profileSampleType:
+			return eachField(msg, func(num protowire.Number, varint uint64, _ []byte) error {
+				if num == valueTypeType {
+					sampleTypes = append(sampleTypes, varint)
+				}
+				return nil
+			})
+		case profileSample:
+			var s [2][]uint64
+			err := eachField(msg, func(num protowire.Number, varint uint64, packed []byte) (err error) {
+				switch num {
+				case sampleLocationID:
+					s[0], err = appendVarints(s[0], varint, packed)
+				case sampleValue:
+					s[1], err = appendVarints(s[1], varint, packed)
+				}
+				return err
+			})
+			samples = append(samples, s)
+			return err
+		case profileLocation:
+			var id uint64
+			var funcs []uint64
+			err := eachField(msg, func(num protowire.Number, varint uint64, line []byte) error {
+				switch num {
+				case locationID:
+					id = varint
+				case locationLine:
+					return eachField(line, func(num protowire.Number, varint uint64, _ []byte) error {
+						if num == lineFunction {
+							funcs = append(funcs, varint)
+						}
+						return nil
+					})
+				}
+				return nil
+			})
+			locations[id] = funcs
+			return err
+		case profileFunction:
+			var id, name uint64
+			err := eachField(msg, func(num protowire.Number, varint uint64, _ []byte) error {
+				switch num {
+				case functionID:
+					id = varint
+				case functionName:
+					name = varint
+				}
+				return nil
+			})
+			functions[id] = name
+			return err
+		case profileStringTable:
+			table = append(table, string(msg))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse profile: %w", err)
+	}
+
+	str := func(i uint64) string {
+		if i < uint64(len(table)) {
+			return table[i]
+		}
+		return ""
+	}
+	p := &profile{}
+	for _, t := range sampleTypes {
+		p.sampleTypes = append(p.sampleTypes, str(t))
+	}
+	for _, s := range samples {
+		var stack []string
+		for _, loc := range s[0] {
+			for _, fn := range locations[loc] {
+				stack = append(stack, str(functions[fn]))
+			}
+		}
+		values := make([]int64, len(s[1]))
+		for i, v := range s[1] {
+			values[i] = int64(v)
+		}
+		p.samples = append(p.samples, sample{stack: stack, values: values})
+	}
+	return p, nil
+}
+
+// costs totals the profile's values of one sample type by function, and
+// over all samples. The profiler's own work is left out.
+func (p *profile) costs(sampleType string) (map[string]Cost, int64, error) {
+	index := -1
+	for i, t := range p.sampleTypes {
+		if t == sampleType {
+			index = i
+		}
+	}
+	if index < 0 {
+		return nil, 0, fmt.Errorf("profile has no %s samples", sampleType)
+	}
+
+	costs := make(map[string]Cost)
+	var total int64
+	for _, s := range p.samples {
+		if index >= len(s.values) || len(s.stack) == 0 || profiling(s.stack) {
+			continue
+		}
+		value := s.values[index]
+		total += value
+
+		leaf := costs[s.stack[0]]
+		leaf.Flat += value
+		costs[s.stack[0]] = leaf
+
+		// Recursive functions count once toward their cumulative cost
+		seen := make(map[string]bool, len(s.stack))
+		for _, fn := range s.stack {
+			if seen[fn] {
+				continue
+			}
+			seen[fn] = true
+			c := costs[fn]
+			c.Cum += value
+			costs[fn] = c
+		}
+	}
+	return costs, total, nil
+}
+
+// profiling reports whether a stack is the profiler at work
+func profiling(stack []string) bool {
+	for _, fn := range stack {
+		if strings.HasPrefix(fn, "runtime/pprof.") {
+			return true
+		}
+	}
+	return false
+}
+
+// topCosts returns the n functions with the highest flat cost, then
+// cumulative cost, naming them and setting their share of total
+func topCosts(costs map[string]Cost, total int64, n int) []Cost {
+	top := make([]Cost, 0, len(costs))
+	for fn, c := range costs {
+		if c.Flat <= 0 && c.Cum <= 0 {
+			continue
+		}
+		c.Function = fn
+		if total > 0 {
+			c.FlatShare = float64(c.Flat) / float64(total)
+		}
+		top = append(top, c)
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Flat != top[j].Flat {
+			return top[i].Flat > top[j].Flat
+		}
+		if top[i].Cum != top[j].Cum {
+			return top[i].Cum > top[j].Cum
+		}
+		return top[i].Function < top[j].Function
+	})
+	if n > 0 && len(top) > n {
+		top = top[:n]
+	}
+	return top
+}
+
+// eachField calls fn with each field of a protobuf message: its number and
+// its value, as a varint or as bytes. Other wire types are skipped.
+func eachField(b []byte, fn func(num protowire.Number, varint uint64, data []byte) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		switch typ {
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			if err := fn(num, v, nil); err != nil {
+				return err
+			}
+		case protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			if err := fn(num, 0, v); err != nil {
+				return err
+			}
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+		}
+	}
+	return nil
+}
+
+// appendVarints appends a repeated varint field's values, given as one
+// varint or packed into bytes
+func appendVarints(dst []uint64, varint uint64, packed []byte) ([]uint64, error) {
+	if packed == nil {
+		return append(dst, varint), nil
+	}
+	for len(packed) > 0 {
+		v, n := protowire.ConsumeVarint(packed)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		dst = append(dst, v)
+		packed = packed[n:]
+	}
+	return dst, nil
+}
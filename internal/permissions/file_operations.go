@@ -3,6 +3,7 @@ package permissions
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 )
@@ -39,6 +40,7 @@ type FileOperationRequest struct {
 	Operation string                 `json:"operation"` // read, write, create, delete, list
 	Path      string                 `json:"path"`
 	Content   []byte                 `json:"content,omitempty"`
+	MaxBytes  int64                  `json:"max_bytes,omitempty"` // Read at most this many bytes; 0 reads the whole file
 	Mode      os.FileMode            `json:"mode,omitempty"`
 	Context   map[string]interface{} `json:"context,omitempty"`
 }
@@ -91,7 +93,7 @@ func (fom *FileOperationManager) ReadFile(ctx context.Context, req *FileOperatio
 	}
 
 	// Perform the file read
-	content, err := os.ReadFile(pathResult.NormalizedPath)
+	content, err := readFileLimited(pathResult.NormalizedPath, req.MaxBytes)
 	if err != nil {
 		return &FileOperationResult{
 			Success:    false,
@@ -115,6 +117,20 @@ func (fom *FileOperationManager) ReadFile(ctx context.Context, req *FileOperatio
 	}, nil
 }
 
+// readFileLimited reads up to limit bytes of a file, or all of it when
+// limit <= 0
+func readFileLimited(path string, limit int64) ([]byte, error) {
+	if limit <= 0 {
+		return os.ReadFile(path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(io.LimitReader(f, limit))
+}
+
 // WriteFile writes a file with permission checking
 func (fom *FileOperationManager) WriteFile(ctx context.Context, req *FileOperationRequest) (*FileOperationResult, error) {
 	// Check permissions
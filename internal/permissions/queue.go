@@ -0,0 +1,152 @@
+package permissions
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultApprovalTimeout is how long a queued request waits for a decision
+// before it is denied
+const DefaultApprovalTimeout = 5 * time.Minute
+
+// Approval event types passed to ApprovalQueue listeners
+const (
+	ApprovalRequested = "approval_requested"
+	ApprovalResolved  = "approval_resolved"
+)
+
+// Approval is a permission request waiting in the approval queue
+type Approval struct {
+	Request  *PermissionRequest  `json:"request"`
+	Response *PermissionResponse `json:"response,omitempty"`
+	Deadline time.Time           `json:"deadline"`
+}
+
+// ApprovalQueue holds permission requests until a user approves or denies
+// them. Its Handle method is installed as the service's request handler, so
+// the tool asking for permission blocks until a decision is made or the
+// request times out.
+type ApprovalQueue struct {
+	timeout  time.Duration
+	mu       sync.Mutex
+	pending  map[string]*queuedApproval
+	listener func(event string, approval Approval)
+}
+
+type queuedApproval struct {
+	approval Approval
+	decision chan *PermissionResponse
+}
+
+// NewApprovalQueue creates an approval queue. A timeout of 0 uses
+// DefaultApprovalTimeout.
+func NewApprovalQueue(timeout time.Duration) *ApprovalQueue {
+	if timeout <= 0 {
+		timeout = DefaultApprovalTimeout
+	}
+	return &ApprovalQueue{
+		timeout: timeout,
+		pending: make(map[string]*queuedApproval),
+	}
+}
+
+// OnEvent sets a listener called when a request is queued or resolved
+func (q *ApprovalQueue) OnEvent(listener func(event string, approval Approval)) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.listener = listener
+}
+
+// Handle queues a request and waits for its decision
+func (q *ApprovalQueue) Handle(req *PermissionRequest) (*PermissionResponse, error) {
+	item := &queuedApproval{
+		approval: Approval{Request: req, Deadline: time.Now().Add(q.timeout)},
+		decision: make(chan *PermissionResponse, 1),
+	}
+
+	q.mu.Lock()
+	q.pending[req.ID] = item
+	q.mu.Unlock()
+	q.emit(ApprovalRequested, item.approval)
+
+	timer := time.NewTimer(q.timeout)
+	defer timer.Stop()
+
+	select {
+	case response := <-item.decision:
+		return response, nil
+	case <-timer.C:
+		response, err := q.Resolve(req.ID, false, "timeout", "No decision before the approval timeout")
+		if err != nil {
+			// Resolved concurrently with the timeout; use that decision
+			return <-item.decision, nil
+		}
+		return response, nil
+	}
+}
+
+// Pending returns the queued approvals, oldest first
+func (q *ApprovalQueue) Pending() []Approval {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	approvals := make([]Approval, 0, len(q.pending))
+	for _, item := range q.pending {
+		approvals = append(approvals, item.approval)
+	}
+	sort.Slice(approvals, func(i, j int) bool {
+		return approvals[i].Request.RequestedAt.Before(approvals[j].Request.RequestedAt)
+	})
+	return approvals
+}
+
+// Get returns a queued approval by request ID
+func (q *ApprovalQueue) Get(id string) (Approval, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	item, ok := q.pending[id]
+	if !ok {
+		return Approval{}, false
+	}
+	return item.approval, true
+}
+
+// Resolve approves or denies a queued request
+func (q *ApprovalQueue) Resolve(id string, approved bool, by string, reason string) (*PermissionResponse, error) {
+	q.mu.Lock()
+	item, ok := q.pending[id]
+	if !ok {
+		q.mu.Unlock()
+		return nil, ErrPermissionNotFound
+	}
+	delete(q.pending, id)
+	q.mu.Unlock()
+
+	status := StatusDenied
+	if approved {
+		status = StatusApproved
+	}
+	response := &PermissionResponse{
+		RequestID:   id,
+		Status:      status,
+		Reason:      reason,
+		RespondedAt: time.Now(),
+		RespondedBy: by,
+	}
+
+	item.decision <- response
+	item.approval.Response = response
+	q.emit(ApprovalResolved, item.approval)
+	return response, nil
+}
+
+func (q *ApprovalQueue) emit(event string, approval Approval) {
+	q.mu.Lock()
+	listener := q.listener
+	q.mu.Unlock()
+	if listener != nil {
+		listener(event, approval)
+	}
+}
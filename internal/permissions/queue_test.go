@@ -0,0 +1,72 @@
+package permissions
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestApprovalQueueThroughService(t *testing.T) {
+	service := NewPermissionService(nil)
+	queue := NewApprovalQueue(time.Second)
+
+	events := make(chan string, 4)
+	queue.OnEvent(func(event string, approval Approval) {
+		events <- event
+	})
+	service.SetRequestHandler(queue.Handle)
+
+	done := make(chan *PermissionResponse, 1)
+	go func() {
+		resp, err := service.RequestPermission(context.Background(), &PermissionRequest{
+			SessionID: "s1",
+			Type:      PermissionShellAccess,
+			Resource:  "rm -rf build",
+			Scope:     ScopeSession,
+		})
+		if err != nil {
+			t.Errorf("RequestPermission failed: %v", err)
+		}
+		done <- resp
+	}()
+
+	if event := <-events; event != ApprovalRequested {
+		t.Fatalf("Expected %s event, got %s", ApprovalRequested, event)
+	}
+	pending := queue.Pending()
+	if len(pending) != 1 {
+		t.Fatalf("Expected 1 pending approval, got %d", len(pending))
+	}
+
+	// The service must stay usable while a request waits for a decision
+	if _, err := service.CheckPermission(context.Background(), &PermissionCheck{SessionID: "s2", Type: PermissionFileRead}); err != nil {
+		t.Fatalf("CheckPermission failed while a request was queued: %v", err)
+	}
+
+	if _, err := queue.Resolve(pending[0].Request.ID, true, "test", "ok"); err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if resp := <-done; resp.Status != StatusApproved {
+		t.Errorf("Expected approved response, got %s", resp.Status)
+	}
+	if event := <-events; event != ApprovalResolved {
+		t.Errorf("Expected %s event, got %s", ApprovalResolved, event)
+	}
+	if _, err := queue.Resolve(pending[0].Request.ID, false, "test", ""); err == nil {
+		t.Error("Expected error resolving an approval twice")
+	}
+}
+
+func TestApprovalQueueTimeout(t *testing.T) {
+	queue := NewApprovalQueue(20 * time.Millisecond)
+	resp, err := queue.Handle(&PermissionRequest{ID: "r1", RequestedAt: time.Now()})
+	if err != nil {
+		t.Fatalf("Handle failed: %v", err)
+	}
+	if resp.Status != StatusDenied || resp.RespondedBy != "timeout" {
+		t.Errorf("Expected timeout denial, got %+v", resp)
+	}
+	if len(queue.Pending()) != 0 {
+		t.Error("Expected timed out request to leave the queue")
+	}
+}
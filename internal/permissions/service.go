@@ -115,10 +115,14 @@ func (ps *PermissionService) RequestPermission(ctx context.Context, req *Permiss
 		return response, nil
 	}
 
-	// If we have a callback for handling requests, use it
-	if ps.onPermissionRequest != nil {
-		response, err := ps.onPermissionRequest(req)
+	// If we have a callback for handling requests, use it. The lock is
+	// released while it runs since it may wait for a user's decision.
+	if handler := ps.onPermissionRequest; handler != nil {
+		ps.mutex.Unlock()
+		response, err := handler(req)
+		ps.mutex.Lock()
 		if err != nil {
+			delete(ps.requests, req.ID)
 			return nil, err
 		}
 
@@ -127,6 +131,10 @@ func (ps *PermissionService) RequestPermission(ctx context.Context, req *Permiss
 			if err != nil {
 				return nil, err
 			}
+			ps.logAudit("approve", req.Type, req.Resource, StatusApproved, req.SessionID, response.Reason, req.Context)
+		} else {
+			delete(ps.requests, req.ID)
+			ps.logAudit("deny", req.Type, req.Resource, StatusDenied, req.SessionID, response.Reason, req.Context)
 		}
 
 		return response, nil
@@ -142,6 +150,15 @@ func (ps *PermissionService) RequestPermission(ctx context.Context, req *Permiss
 	}, nil
 }
 
+// SetRequestHandler sets the handler that decides permission requests that
+// aren't auto-approved or settled by policy. Without one, such requests stay
+// pending and the operation is refused.
+func (ps *PermissionService) SetRequestHandler(handler func(*PermissionRequest) (*PermissionResponse, error)) {
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+	ps.onPermissionRequest = handler
+}
+
 // CheckPermission checks if an operation is permitted
 func (ps *PermissionService) CheckPermission(ctx context.Context, check *PermissionCheck) (*PermissionCheckResult, error) {
 	ps.mutex.RLock()
@@ -289,29 +306,47 @@ func (ps *PermissionService) GetSessionTrust(sessionID string) *SessionTrust {
 	ps.mutex.Lock()
 	defer ps.mutex.Unlock()
 
+	return ps.sessionTrust(sessionID)
+}
+
+// sessionTrust gets or creates session trust. The caller must hold the write lock.
+func (ps *PermissionService) sessionTrust(sessionID string) *SessionTrust {
 	trust, exists := ps.sessions[sessionID]
 	if !exists {
-		trust = &SessionTrust{
-			SessionID:      sessionID,
-			TrustLevel:     50, // Default trust level
-			AutoApprove:    []PermissionType{PermissionFileRead, PermissionSystemInfo},
-			CreatedAt:      time.Now(),
-			LastActivity:   time.Now(),
-			SuccessCount:   0,
-			ViolationCount: 0,
-		}
+		trust = newSessionTrust(sessionID)
 		ps.sessions[sessionID] = trust
 	}
 
 	return trust
 }
 
+// peekSessionTrust returns session trust without creating it, for callers
+// holding only the read lock
+func (ps *PermissionService) peekSessionTrust(sessionID string) *SessionTrust {
+	if trust, exists := ps.sessions[sessionID]; exists {
+		return trust
+	}
+	return newSessionTrust(sessionID)
+}
+
+func newSessionTrust(sessionID string) *SessionTrust {
+	return &SessionTrust{
+		SessionID:      sessionID,
+		TrustLevel:     50, // Default trust level
+		AutoApprove:    []PermissionType{PermissionFileRead, PermissionSystemInfo},
+		CreatedAt:      time.Now(),
+		LastActivity:   time.Now(),
+		SuccessCount:   0,
+		ViolationCount: 0,
+	}
+}
+
 // UpdateSessionTrust updates session trust based on behavior
 func (ps *PermissionService) UpdateSessionTrust(sessionID string, success bool) {
 	ps.mutex.Lock()
 	defer ps.mutex.Unlock()
 
-	trust := ps.GetSessionTrust(sessionID)
+	trust := ps.sessionTrust(sessionID)
 	trust.LastActivity = time.Now()
 
 	if success {
@@ -371,7 +406,7 @@ func (ps *PermissionService) canAutoApprove(req *PermissionRequest) bool {
 		return true
 	}
 
-	trust := ps.GetSessionTrust(req.SessionID)
+	trust := ps.peekSessionTrust(req.SessionID)
 	if trust.TrustLevel >= ps.config.AutoApproveThreshold {
 		// Check if this permission type is in auto-approve list
 		for _, autoType := range trust.AutoApprove {
@@ -390,7 +425,7 @@ func (ps *PermissionService) canAutoApprove(req *PermissionRequest) bool {
 }
 
 func (ps *PermissionService) canAutoApproveCheck(check *PermissionCheck) bool {
-	trust := ps.GetSessionTrust(check.SessionID)
+	trust := ps.peekSessionTrust(check.SessionID)
 
 	// Auto-approve very low-risk operations
 	if GetRiskLevel(check.Type) <= 2 {
@@ -447,7 +482,7 @@ func (ps *PermissionService) evaluateCondition(condition RuleCondition, req *Per
 	case "session_id":
 		return condition.Value == req.SessionID
 	case "trust_level":
-		trust := ps.GetSessionTrust(req.SessionID)
+		trust := ps.peekSessionTrust(req.SessionID)
 		switch condition.Operator {
 		case "gt":
 			if threshold, ok := condition.Value.(float64); ok {
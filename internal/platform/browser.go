@@ -0,0 +1,30 @@
+package platform
+
+import (
+	"os/exec"
+)
+
+// OpenBrowser opens a URL or file in the default browser without waiting
+// for it
+func OpenBrowser(target string) error {
+	argv := openCommand(hostEnv.goos, target)
+	cmd := exec.Command(argv[0], argv[1:]...)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	// Reap the launcher in the background; the browser outlives it
+	go cmd.Wait()
+	return nil
+}
+
+// openCommand returns the command that opens target on goos
+func openCommand(goos, target string) []string {
+	switch goos {
+	case "darwin":
+		return []string{"open", target}
+	case "windows":
+		return []string{"rundll32", "url.dll,FileProtocolHandler", target}
+	default:
+		return []string{"xdg-open", target}
+	}
+}
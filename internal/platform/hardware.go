@@ -0,0 +1,134 @@
+package platform
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Hardware is the memory available for running local models
+type Hardware struct {
+	RAM           int64  // Total system memory in bytes, 0 when unknown
+	VRAM          int64  // Total dedicated GPU memory in bytes
+	GPU           string // GPU name, "" when none was found
+	UnifiedMemory bool   // The GPU shares system memory (Apple Silicon)
+}
+
+var (
+	hardwareOnce sync.Once
+	hardware     Hardware
+)
+
+// DetectHardware returns the host's memory and GPU. It is detected once and
+// cached; values that can't be determined are left zero.
+func DetectHardware() Hardware {
+	hardwareOnce.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		hardware = detectHardware(ctx, hostEnv.goos, runtime.GOARCH, ExecRunner{}, os.ReadFile)
+	})
+	return hardware
+}
+
+func detectHardware(ctx context.Context, goos, goarch string, r Runner, readFile func(string) ([]byte, error)) Hardware {
+	var hw Hardware
+
+	switch goos {
+	case "linux":
+		if data, err := readFile("/proc/meminfo"); err == nil {
+			hw.RAM = parseMemInfo(data)
+		}
+	case "darwin":
+		if out, err := r.Run(ctx, "", "sysctl", "-n", "hw.memsize"); err == nil {
+			hw.RAM, _ = strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+		}
+		if goarch == "arm64" {
+			hw.GPU = "Apple Silicon"
+			hw.UnifiedMemory = true
+		}
+	case "windows":
+		if out, err := r.Run(ctx, "", "powershell", "-NoProfile", "-Command",
+			"(Get-CimInstance Win32_ComputerSystem).TotalPhysicalMemory"); err == nil {
+			hw.RAM, _ = strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+		}
+	}
+
+	if out, err := r.Run(ctx, "", "nvidia-smi", "--query-gpu=name,memory.total", "--format=csv,noheader,nounits"); err == nil {
+		hw.GPU, hw.VRAM = parseNvidiaSMI(out)
+	}
+	return hw
+}
+
+// parseMemInfo returns MemTotal from /proc/meminfo in bytes
+func parseMemInfo(data []byte) int64 {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "MemTotal:" {
+			kb, _ := strconv.ParseInt(fields[1], 10, 64)
+			return kb * 1024
+		}
+	}
+	return 0
+}
+
+// parseNvidiaSMI reads "name, MiB" lines, one per GPU. Models can be split
+// across GPUs, so their memory is added up.
+func parseNvidiaSMI(out []byte) (string, int64) {
+	var names []string
+	var total int64
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		name, mib, ok := strings.Cut(line, ",")
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseInt(strings.TrimSpace(mib), 10, 64)
+		if err != nil {
+			continue
+		}
+		names = append(names, strings.TrimSpace(name))
+		total += n * 1024 * 1024
+	}
+	switch len(names) {
+	case 0:
+		return "", 0
+	case 1:
+		return names[0], total
+	default:
+		return fmt.Sprintf("%s x%d", names[0], len(names)), total
+	}
+}
+
+// Summary describes the hardware for display, e.g.
+// "32.0 GB RAM, NVIDIA GeForce RTX 4070 (12.0 GB)"
+func (h Hardware) Summary() string {
+	if h.RAM == 0 && h.GPU == "" {
+		return "unknown hardware"
+	}
+
+	parts := []string{}
+	if h.RAM > 0 {
+		parts = append(parts, FormatGB(h.RAM)+" RAM")
+	}
+	switch {
+	case h.UnifiedMemory:
+		parts = append(parts, h.GPU+" (unified memory)")
+	case h.VRAM > 0:
+		parts = append(parts, fmt.Sprintf("%s (%s)", h.GPU, FormatGB(h.VRAM)))
+	default:
+		parts = append(parts, "no GPU")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// FormatGB formats a byte count in gigabytes
+func FormatGB(n int64) string {
+	return fmt.Sprintf("%.1f GB", float64(n)/(1<<30))
+}
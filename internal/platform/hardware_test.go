@@ -0,0 +1,73 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// fakeRunner answers commands by program name
+type fakeRunner map[string]string
+
+func (f fakeRunner) Run(ctx context.Context, dir, name string, args ...string) ([]byte, error) {
+	if out, ok := f[name]; ok {
+		return []byte(out), nil
+	}
+	return nil, errors.New("not found")
+}
+
+func TestDetectHardware(t *testing.T) {
+	meminfo := func(string) ([]byte, error) {
+		return []byte("MemTotal:       32768000 kB\nMemFree:         1000 kB\n"), nil
+	}
+
+	testCases := []struct {
+		name   string
+		goos   string
+		goarch string
+		runner fakeRunner
+		want   Hardware
+	}{
+		{
+			name:   "linux with two NVIDIA GPUs",
+			goos:   "linux",
+			goarch: "amd64",
+			runner: fakeRunner{"nvidia-smi": "NVIDIA GeForce RTX 3090, 24576\nNVIDIA GeForce RTX 3090, 24576\n"},
+			want:   Hardware{RAM: 32768000 * 1024, VRAM: 48 << 30, GPU: "NVIDIA GeForce RTX 3090 x2"},
+		},
+		{
+			name:   "linux without a GPU",
+			goos:   "linux",
+			goarch: "amd64",
+			runner: fakeRunner{},
+			want:   Hardware{RAM: 32768000 * 1024},
+		},
+		{
+			name:   "apple silicon",
+			goos:   "darwin",
+			goarch: "arm64",
+			runner: fakeRunner{"sysctl": "17179869184\n"},
+			want:   Hardware{RAM: 16 << 30, GPU: "Apple Silicon", UnifiedMemory: true},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := detectHardware(context.Background(), tc.goos, tc.goarch, tc.runner, meminfo)
+			if got != tc.want {
+				t.Errorf("detectHardware() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestHardwareSummary(t *testing.T) {
+	hw := Hardware{RAM: 32 << 30, VRAM: 12 << 30, GPU: "NVIDIA GeForce RTX 4070"}
+	if got := hw.Summary(); got != "32.0 GB RAM, NVIDIA GeForce RTX 4070 (12.0 GB)" {
+		t.Errorf("Summary() = %q", got)
+	}
+	if got := (Hardware{RAM: 8 << 30}).Summary(); !strings.HasSuffix(got, "no GPU") {
+		t.Errorf("Summary() = %q, want no GPU", got)
+	}
+}
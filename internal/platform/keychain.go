@@ -0,0 +1,63 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ErrKeychainUnsupported is returned where no keychain CLI is available
+var ErrKeychainUnsupported = errors.New("no supported keychain on this platform")
+
+// KeychainGet reads a secret from the macOS login keychain, or on Linux from
+// the Secret Service (GNOME Keyring, KWallet) through secret-tool
+func KeychainGet(ctx context.Context, service, account string) (string, error) {
+	return keychainGet(ctx, hostEnv.goos, ExecRunner{}, service, account)
+}
+
+func keychainGet(ctx context.Context, goos string, r Runner, service, account string) (string, error) {
+	var out []byte
+	var err error
+	switch goos {
+	case "darwin":
+		out, err = r.Run(ctx, "", "security", "find-generic-password", "-s", service, "-a", account, "-w")
+	case "linux":
+		out, err = r.Run(ctx, "", "secret-tool", "lookup", "service", service, "account", account)
+	default:
+		return "", ErrKeychainUnsupported
+	}
+	if err != nil {
+		return "", fmt.Errorf("no %s secret for %s in the keychain", service, account)
+	}
+
+	secret := strings.TrimSpace(string(out))
+	if secret == "" {
+		return "", fmt.Errorf("no %s secret for %s in the keychain", service, account)
+	}
+	return secret, nil
+}
+
+// KeychainSet stores a secret in the keychain, replacing any earlier one.
+// On Linux the secret is passed on stdin so it doesn't show up in ps.
+func KeychainSet(ctx context.Context, service, account, secret string) error {
+	var cmd *exec.Cmd
+	switch hostEnv.goos {
+	case "darwin":
+		cmd = exec.CommandContext(ctx, "security", "add-generic-password", "-U", "-s", service, "-a", account, "-w", secret)
+	case "linux":
+		cmd = exec.CommandContext(ctx, "secret-tool", "store", "--label", service+" "+account, "service", service, "account", account)
+		cmd.Stdin = strings.NewReader(secret)
+	default:
+		return ErrKeychainUnsupported
+	}
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("failed to save secret in the keychain: %s", msg)
+		}
+		return fmt.Errorf("failed to save secret in the keychain: %w", err)
+	}
+	return nil
+}
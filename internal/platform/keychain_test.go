@@ -0,0 +1,39 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestKeychainGet(t *testing.T) {
+	testCases := []struct {
+		name    string
+		goos    string
+		runner  fakeRunner
+		want    string
+		wantErr bool
+	}{
+		{"macOS", "darwin", fakeRunner{"security": "s3cret\n"}, "s3cret", false},
+		{"linux", "linux", fakeRunner{"secret-tool": "s3cret"}, "s3cret", false},
+		{"missing entry", "linux", fakeRunner{}, "", true},
+		{"empty entry", "darwin", fakeRunner{"security": "\n"}, "", true},
+		{"unsupported", "windows", fakeRunner{}, "", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := keychainGet(context.Background(), tc.goos, tc.runner, "codeforge", "key")
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("keychainGet() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("keychainGet() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+
+	if _, err := keychainGet(context.Background(), "plan9", fakeRunner{}, "codeforge", "key"); !errors.Is(err, ErrKeychainUnsupported) {
+		t.Errorf("keychainGet() error = %v, want ErrKeychainUnsupported", err)
+	}
+}
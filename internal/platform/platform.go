@@ -0,0 +1,129 @@
+package platform
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Shell is a command interpreter and the arguments that make it run a single
+// command string
+type Shell struct {
+	Path string
+	Args []string // Arguments placed before the command string
+}
+
+// Command returns the argv that runs command in the shell
+func (s Shell) Command(command string) []string {
+	argv := append([]string{s.Path}, s.Args...)
+	return append(argv, command)
+}
+
+// IsPowerShell reports whether the shell is Windows PowerShell or pwsh
+func (s Shell) IsPowerShell() bool {
+	name := strings.ToLower(baseName(s.Path))
+	name = strings.TrimSuffix(name, ".exe")
+	return name == "pwsh" || name == "powershell"
+}
+
+// env abstracts the process environment so Windows behaviour can be tested
+// on any host
+type env struct {
+	goos     string
+	getenv   func(string) string
+	lookPath func(string) (string, error)
+	stat     func(string) (os.FileInfo, error)
+}
+
+var hostEnv = env{
+	goos:     runtime.GOOS,
+	getenv:   os.Getenv,
+	lookPath: exec.LookPath,
+	stat:     os.Stat,
+}
+
+// IsWindows reports whether CodeForge is running on Windows
+func IsWindows() bool {
+	return hostEnv.goos == "windows"
+}
+
+// DefaultShell returns the shell used for one-off commands. On Windows this
+// is pwsh or powershell when installed and cmd.exe otherwise; elsewhere it is
+// $SHELL, falling back to /bin/sh.
+func DefaultShell() Shell {
+	return hostEnv.defaultShell()
+}
+
+func (e env) defaultShell() Shell {
+	if e.goos == "windows" {
+		for _, name := range []string{"pwsh", "powershell"} {
+			if path, err := e.lookPath(name); err == nil {
+				return Shell{Path: path, Args: []string{"-NoProfile", "-NonInteractive", "-Command"}}
+			}
+		}
+		comspec := e.getenv("ComSpec")
+		if comspec == "" {
+			comspec = "cmd.exe"
+		}
+		return Shell{Path: comspec, Args: []string{"/C"}}
+	}
+
+	shell := e.getenv("SHELL")
+	if shell == "" {
+		shell = "/bin/sh"
+	}
+	return Shell{Path: shell, Args: []string{"-c"}}
+}
+
+// PosixShell returns a POSIX shell for features that depend on sh syntax. On
+// Windows this is the bash shipped with Git for Windows or WSL; ok is false
+// when none is installed.
+func PosixShell() (path string, ok bool) {
+	return hostEnv.posixShell()
+}
+
+func (e env) posixShell() (string, bool) {
+	if e.goos != "windows" {
+		if shell := e.getenv("SHELL"); shell != "" {
+			return shell, true
+		}
+		return "/bin/bash", true
+	}
+	for _, name := range []string{"bash", "sh"} {
+		if path, err := e.lookPath(name); err == nil {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// Executable resolves a program path written with forward slashes, such as
+// "./main" or "./vendor/bin/phpunit", for the host. On Windows the path gets
+// native separators and, when it has no extension, the first existing
+// .exe/.bat/.cmd variant.
+func Executable(name string) string {
+	return hostEnv.executable(name)
+}
+
+func (e env) executable(name string) string {
+	if e.goos != "windows" {
+		return name
+	}
+	name = strings.ReplaceAll(name, "/", `\`)
+	if strings.Contains(baseName(name), ".") {
+		return name
+	}
+	for _, ext := range []string{".exe", ".bat", ".cmd"} {
+		if _, err := e.stat(name + ext); err == nil {
+			return name + ext
+		}
+	}
+	return name + ".exe"
+}
+
+// baseName is filepath.Base for either separator, so Windows paths can be
+// handled on any host
+func baseName(path string) string {
+	return path[strings.LastIndexAny(path, `/\`)+1:]
+}
@@ -0,0 +1,116 @@
+package platform
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func fakeEnv(goos string, vars map[string]string, onPath ...string) env {
+	return env{
+		goos:   goos,
+		getenv: func(key string) string { return vars[key] },
+		lookPath: func(name string) (string, error) {
+			for _, p := range onPath {
+				if baseName(p) == name || baseName(p) == name+".exe" {
+					return p, nil
+				}
+			}
+			return "", errors.New("not found")
+		},
+		stat: func(string) (os.FileInfo, error) { return nil, os.ErrNotExist },
+	}
+}
+
+func TestDefaultShell(t *testing.T) {
+	testCases := []struct {
+		name string
+		env  env
+		want []string
+	}{
+		{
+			name: "unix uses SHELL",
+			env:  fakeEnv("linux", map[string]string{"SHELL": "/bin/zsh"}),
+			want: []string{"/bin/zsh", "-c", "echo hi"},
+		},
+		{
+			name: "unix fallback",
+			env:  fakeEnv("darwin", nil),
+			want: []string{"/bin/sh", "-c", "echo hi"},
+		},
+		{
+			name: "windows prefers pwsh",
+			env:  fakeEnv("windows", nil, `C:\Program Files\PowerShell\7\pwsh.exe`),
+			want: []string{`C:\Program Files\PowerShell\7\pwsh.exe`, "-NoProfile", "-NonInteractive", "-Command", "echo hi"},
+		},
+		{
+			name: "windows falls back to ComSpec",
+			env:  fakeEnv("windows", map[string]string{"ComSpec": `C:\Windows\system32\cmd.exe`}),
+			want: []string{`C:\Windows\system32\cmd.exe`, "/C", "echo hi"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.env.defaultShell().Command("echo hi"); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestIsPowerShell(t *testing.T) {
+	if !(Shell{Path: `C:\Windows\System32\WindowsPowerShell\v1.0\powershell.exe`}).IsPowerShell() {
+		t.Error("Expected powershell.exe to be PowerShell")
+	}
+	if (Shell{Path: `C:\Windows\system32\cmd.exe`}).IsPowerShell() {
+		t.Error("Expected cmd.exe not to be PowerShell")
+	}
+}
+
+func TestPosixShell(t *testing.T) {
+	if _, ok := fakeEnv("windows", nil).posixShell(); ok {
+		t.Error("Expected no POSIX shell on bare Windows")
+	}
+	path, ok := fakeEnv("windows", nil, `C:\Program Files\Git\bin\bash.exe`).posixShell()
+	if !ok || path != `C:\Program Files\Git\bin\bash.exe` {
+		t.Errorf("Expected Git bash, got %q", path)
+	}
+}
+
+func TestExecutable(t *testing.T) {
+	if got := fakeEnv("linux", nil).executable("./main"); got != "./main" {
+		t.Errorf("Expected ./main unchanged, got %s", got)
+	}
+
+	windows := fakeEnv("windows", nil)
+	if got := windows.executable("./main"); got != `.\main.exe` {
+		t.Errorf(`Expected .\main.exe, got %s`, got)
+	}
+	if got := windows.executable("./tool.ps1"); got != `.\tool.ps1` {
+		t.Errorf(`Expected .\tool.ps1, got %s`, got)
+	}
+
+	windows.stat = func(name string) (os.FileInfo, error) {
+		if name == `.\vendor\bin\phpunit.bat` {
+			return nil, nil
+		}
+		return nil, os.ErrNotExist
+	}
+	if got := windows.executable("./vendor/bin/phpunit"); got != `.\vendor\bin\phpunit.bat` {
+		t.Errorf(`Expected .\vendor\bin\phpunit.bat, got %s`, got)
+	}
+}
+
+func TestOpenCommand(t *testing.T) {
+	for goos, want := range map[string][]string{
+		"darwin":  {"open", "page.html"},
+		"windows": {"rundll32", "url.dll,FileProtocolHandler", "page.html"},
+		"linux":   {"xdg-open", "page.html"},
+	} {
+		if got := openCommand(goos, "page.html"); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: openCommand = %v, want %v", goos, got, want)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package platform
+
+import (
+	"context"
+	"os"
+	"os/exec"
+)
+
+// Runner executes external programs. Packages that shell out hold a Runner
+// instead of calling os/exec directly so tests can substitute a fake.
+type Runner interface {
+	// Run executes name with args in dir and returns its combined output
+	Run(ctx context.Context, dir, name string, args ...string) ([]byte, error)
+}
+
+// ExecRunner runs programs with os/exec
+type ExecRunner struct {
+	Env []string // KEY=value pairs added to the environment of programs run
+}
+
+// Run implements Runner. name is resolved with Executable so relative paths
+// written with forward slashes work on Windows.
+func (r ExecRunner) Run(ctx context.Context, dir, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, Executable(name), args...)
+	cmd.Dir = dir
+	if len(r.Env) > 0 {
+		cmd.Env = append(os.Environ(), r.Env...)
+	}
+	return cmd.CombinedOutput()
+}
+
+// RunShell runs command through the default shell in dir
+func RunShell(ctx context.Context, r Runner, dir, command string) ([]byte, error) {
+	argv := DefaultShell().Command(command)
+	return r.Run(ctx, dir, argv[0], argv[1:]...)
+}
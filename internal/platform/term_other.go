@@ -0,0 +1,9 @@
+//go:build !windows
+
+package platform
+
+// EnableANSI is a no-op outside Windows, where terminals handle ANSI escape
+// sequences natively
+func EnableANSI() error {
+	return nil
+}
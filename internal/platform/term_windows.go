@@ -0,0 +1,27 @@
+//go:build windows
+
+package platform
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// EnableANSI turns on virtual terminal processing for stdout and stderr so
+// the console renders ANSI escape sequences. Consoles that don't support it
+// (before Windows 10) return an error and keep their mode.
+func EnableANSI() error {
+	for _, f := range []*os.File{os.Stdout, os.Stderr} {
+		handle := windows.Handle(f.Fd())
+
+		var mode uint32
+		if err := windows.GetConsoleMode(handle, &mode); err != nil {
+			continue // Not a console, e.g. redirected to a file
+		}
+		if err := windows.SetConsoleMode(handle, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING); err != nil {
+			return err
+		}
+	}
+	return nil
+}
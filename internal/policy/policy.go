@@ -0,0 +1,489 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// PolicyFile is the project-level policy file, relative to the workspace root
+const PolicyFile = ".codeforge/policies.json"
+
+// Action determines what happens when a rule is violated
+type Action string
+
+const (
+	ActionBlock Action = "block" // Reject the change
+	ActionFix   Action = "fix"   // Auto-fix the change when possible, otherwise reject
+	ActionWarn  Action = "warn"  // Allow the change but report the violation
+)
+
+// ImportRule forbids importing matching packages or modules
+type ImportRule struct {
+	Pattern string   `json:"pattern"`         // Import path or glob, e.g. "unsafe" or "github.com/pkg/errors"
+	Paths   []string `json:"paths,omitempty"` // File globs the rule applies to (default: all)
+	Message string   `json:"message,omitempty"`
+	Action  Action   `json:"action,omitempty"`
+}
+
+// LicenseHeaderRule requires files to start with a license header
+type LicenseHeaderRule struct {
+	Text   string   `json:"text"`            // Header text, including comment markers
+	Paths  []string `json:"paths,omitempty"` // File globs the rule applies to (default: all)
+	Action Action   `json:"action,omitempty"`
+}
+
+// APIRule bans calls matching a regular expression
+type APIRule struct {
+	Pattern     string   `json:"pattern"`               // Regular expression, e.g. `\beval\(`
+	Paths       []string `json:"paths,omitempty"`       // File globs the rule applies to (default: all)
+	Message     string   `json:"message,omitempty"`     // Explanation shown to the model
+	Replacement string   `json:"replacement,omitempty"` // Replacement used when auto-fixing
+	Action      Action   `json:"action,omitempty"`
+}
+
+// Policies defines the generated-code constraints for a project
+type Policies struct {
+	Enabled          *bool               `json:"enabled,omitempty"`
+	DefaultAction    Action              `json:"defaultAction,omitempty"`
+	ForbiddenImports []ImportRule        `json:"forbiddenImports,omitempty"`
+	LicenseHeaders   []LicenseHeaderRule `json:"licenseHeaders,omitempty"`
+	BannedAPIs       []APIRule           `json:"bannedAPIs,omitempty"`
+}
+
+// Violation describes a single policy violation
+type Violation struct {
+	Rule    string `json:"rule"` // forbidden-import, license-header, banned-api
+	File    string `json:"file"`
+	Line    int    `json:"line,omitempty"`
+	Match   string `json:"match,omitempty"`
+	Message string `json:"message"`
+	Action  Action `json:"action"`
+	Fixed   bool   `json:"fixed"`
+}
+
+func (v Violation) String() string {
+	loc := v.File
+	if v.Line > 0 {
+		loc = fmt.Sprintf("%s:%d", v.File, v.Line)
+	}
+	return fmt.Sprintf("%s [%s] %s", loc, v.Rule, v.Message)
+}
+
+// Result is the outcome of checking a proposed change
+type Result struct {
+	Content    string      // Content after auto-fixes
+	Violations []Violation // All violations found, including fixed ones
+}
+
+// Blocked reports whether any unfixed violation must block the change
+func (r *Result) Blocked() bool {
+	for _, v := range r.Violations {
+		if !v.Fixed && v.Action != ActionWarn {
+			return true
+		}
+	}
+	return false
+}
+
+// Error returns an error describing the blocking violations, or nil
+func (r *Result) Error() error {
+	if !r.Blocked() {
+		return nil
+	}
+	var lines []string
+	for _, v := range r.Violations {
+		if !v.Fixed && v.Action != ActionWarn {
+			lines = append(lines, "  - "+v.String())
+		}
+	}
+	return fmt.Errorf("change rejected by project policy (%s):\n%s", PolicyFile, strings.Join(lines, "\n"))
+}
+
+// Engine checks proposed file contents against project policies
+type Engine struct {
+	root     string
+	policies Policies
+	apis     []*regexp.Regexp
+}
+
+// NewEngine creates an engine for the given policies
+func NewEngine(root string, policies Policies) (*Engine, error) {
+	e := &Engine{root: root, policies: policies}
+	if e.policies.DefaultAction == "" {
+		e.policies.DefaultAction = ActionBlock
+	}
+	for _, rule := range policies.BannedAPIs {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid banned API pattern %q: %w", rule.Pattern, err)
+		}
+		e.apis = append(e.apis, re)
+	}
+	return e, nil
+}
+
+// Load reads the project policy file and adds the rules of base, the
+// policies configured for every project. A missing file yields only those.
+func Load(root string, base Policies) (*Engine, error) {
+	var policies Policies
+	data, err := os.ReadFile(filepath.Join(root, PolicyFile))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read policy file: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &policies); err != nil {
+			return nil, fmt.Errorf("failed to parse policy file: %w", err)
+		}
+	}
+	return NewEngine(root, policies.Merge(base))
+}
+
+// Merge returns p with the rules of base added. Rules keep the default
+// action of the policies they came from, and disabling p leaves base's
+// rules in force.
+func (p Policies) Merge(base Policies) Policies {
+	if base.Enabled != nil && !*base.Enabled {
+		return p
+	}
+	var merged Policies
+	if p.Enabled == nil || *p.Enabled {
+		merged = p.withActions()
+	}
+	base = base.withActions()
+	merged.Enabled = nil
+	merged.ForbiddenImports = append(merged.ForbiddenImports, base.ForbiddenImports...)
+	merged.LicenseHeaders = append(merged.LicenseHeaders, base.LicenseHeaders...)
+	merged.BannedAPIs = append(merged.BannedAPIs, base.BannedAPIs...)
+	return merged
+}
+
+// withActions returns p with the default action written into rules that
+// have none, so they keep it when merged
+func (p Policies) withActions() Policies {
+	if p.DefaultAction == "" {
+		return p
+	}
+	imports := append([]ImportRule(nil), p.ForbiddenImports...)
+	for i := range imports {
+		if imports[i].Action == "" {
+			imports[i].Action = p.DefaultAction
+		}
+	}
+	headers := append([]LicenseHeaderRule(nil), p.LicenseHeaders...)
+	for i := range headers {
+		if headers[i].Action == "" {
+			headers[i].Action = p.DefaultAction
+		}
+	}
+	apis := append([]APIRule(nil), p.BannedAPIs...)
+	for i := range apis {
+		if apis[i].Action == "" {
+			apis[i].Action = p.DefaultAction
+		}
+	}
+	p.ForbiddenImports, p.LicenseHeaders, p.BannedAPIs = imports, headers, apis
+	return p
+}
+
+// Enabled reports whether the engine has any active rules
+func (e *Engine) Enabled() bool {
+	if e.policies.Enabled != nil && !*e.policies.Enabled {
+		return false
+	}
+	return len(e.policies.ForbiddenImports) > 0 || len(e.policies.LicenseHeaders) > 0 || len(e.policies.BannedAPIs) > 0
+}
+
+// CheckChange checks a proposed change to a file. Only violations introduced by
+// the change are reported, so pre-existing problems don't block unrelated edits.
+func (e *Engine) CheckChange(filePath, oldContent, newContent string) *Result {
+	result := &Result{Content: newContent}
+	if !e.Enabled() {
+		return result
+	}
+
+	rel := e.relPath(filePath)
+
+	// License headers
+	for _, rule := range e.policies.LicenseHeaders {
+		header := strings.TrimSpace(rule.Text)
+		if header == "" || !matchesPaths(rel, rule.Paths) {
+			continue
+		}
+		if strings.HasPrefix(strings.TrimLeft(result.Content, "\ufeff \n"), header) {
+			continue
+		}
+		// Don't require headers on existing files that never had one
+		if oldContent != "" && !strings.Contains(oldContent, header) {
+			continue
+		}
+		v := Violation{
+			Rule:    "license-header",
+			File:    rel,
+			Line:    1,
+			Message: "missing required license header",
+			Action:  e.action(rule.Action),
+		}
+		if v.Action == ActionFix {
+			result.Content = insertHeader(result.Content, header)
+			v.Fixed = true
+		}
+		result.Violations = append(result.Violations, v)
+	}
+
+	// Forbidden imports
+	oldImports := countImports(rel, oldContent)
+	for _, rule := range e.policies.ForbiddenImports {
+		if !matchesPaths(rel, rule.Paths) {
+			continue
+		}
+		for _, imp := range extractImports(rel, result.Content) {
+			if !matchImport(rule.Pattern, imp.path) || oldImports[imp.path] > 0 {
+				continue
+			}
+			msg := rule.Message
+			if msg == "" {
+				msg = fmt.Sprintf("import of %q is forbidden", imp.path)
+			}
+			// Imports can't be removed safely without breaking code, so they always block
+			action := e.action(rule.Action)
+			if action == ActionFix {
+				action = ActionBlock
+			}
+			result.Violations = append(result.Violations, Violation{
+				Rule:    "forbidden-import",
+				File:    rel,
+				Line:    imp.line,
+				Match:   imp.path,
+				Message: msg,
+				Action:  action,
+			})
+		}
+	}
+
+	// Banned APIs, on the lines the change adds only, so neither the check
+	// nor a fix touches code the change left alone
+	added := addedLines(oldContent, result.Content)
+	for i, rule := range e.policies.BannedAPIs {
+		if !matchesPaths(rel, rule.Paths) {
+			continue
+		}
+		re := e.apis[i]
+		var matches [][]int
+		for _, m := range re.FindAllStringSubmatchIndex(result.Content, -1) {
+			if added[strings.Count(result.Content[:m[0]], "\n")+1] {
+				matches = append(matches, m)
+			}
+		}
+		if len(matches) == 0 {
+			continue
+		}
+
+		action := e.action(rule.Action)
+		if action == ActionFix && rule.Replacement == "" {
+			action = ActionBlock
+		}
+		msg := rule.Message
+		if msg == "" {
+			msg = fmt.Sprintf("use of banned API matching %q", rule.Pattern)
+		}
+
+		for _, m := range matches {
+			result.Violations = append(result.Violations, Violation{
+				Rule:    "banned-api",
+				File:    rel,
+				Line:    strings.Count(result.Content[:m[0]], "\n") + 1,
+				Match:   result.Content[m[0]:m[1]],
+				Message: msg,
+				Action:  action,
+				Fixed:   action == ActionFix,
+			})
+		}
+		if action == ActionFix {
+			result.Content = replaceMatches(re, result.Content, matches, rule.Replacement)
+			added = addedLines(oldContent, result.Content)
+		}
+	}
+
+	return result
+}
+
+// addedLines returns the numbers, from 1, of the lines of newContent that
+// aren't in oldContent. Lines are matched by content, as many times as they
+// occur in oldContent, so moved lines don't count as added.
+func addedLines(oldContent, newContent string) map[int]bool {
+	old := make(map[string]int)
+	for _, line := range strings.Split(oldContent, "\n") {
+		old[line]++
+	}
+	added := make(map[int]bool)
+	for i, line := range strings.Split(newContent, "\n") {
+		if old[line] > 0 {
+			old[line]--
+			continue
+		}
+		added[i+1] = true
+	}
+	return added
+}
+
+// replaceMatches replaces the given submatch indexes of re in content with
+// the replacement, expanding $1 and the like as ReplaceAllString does
+func replaceMatches(re *regexp.Regexp, content string, matches [][]int, replacement string) string {
+	var out []byte
+	last := 0
+	for _, m := range matches {
+		out = append(out, content[last:m[0]]...)
+		out = re.ExpandString(out, replacement, content, m)
+		last = m[1]
+	}
+	return string(append(out, content[last:]...))
+}
+
+// action resolves a rule's action against the default
+func (e *Engine) action(a Action) Action {
+	if a == "" {
+		return e.policies.DefaultAction
+	}
+	return a
+}
+
+// relPath returns the path relative to the workspace root using forward slashes
+func (e *Engine) relPath(filePath string) string {
+	if e.root != "" && filepath.IsAbs(filePath) {
+		if rel, err := filepath.Rel(e.root, filePath); err == nil && !strings.HasPrefix(rel, "..") {
+			filePath = rel
+		}
+	}
+	return filepath.ToSlash(filePath)
+}
+
+// matchesPaths reports whether a relative path matches any of the globs
+func matchesPaths(rel string, globs []string) bool {
+	if len(globs) == 0 {
+		return true
+	}
+	base := path.Base(rel)
+	for _, g := range globs {
+		if ok, _ := path.Match(g, rel); ok {
+			return true
+		}
+		if !strings.Contains(g, "/") {
+			if ok, _ := path.Match(g, base); ok {
+				return true
+			}
+		}
+		if strings.HasSuffix(g, "/**") && strings.HasPrefix(rel, strings.TrimSuffix(g, "**")) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchImport matches an import path against a rule pattern. Plain patterns
+// also match sub-packages, so "net/http" forbids "net/http/httputil".
+func matchImport(pattern, imp string) bool {
+	if imp == pattern || strings.HasPrefix(imp, pattern+"/") || strings.HasPrefix(imp, pattern+".") {
+		return true
+	}
+	ok, _ := path.Match(pattern, imp)
+	return ok
+}
+
+// insertHeader prepends a header, keeping shebang lines first
+func insertHeader(content, header string) string {
+	if strings.HasPrefix(content, "#!") {
+		if idx := strings.Index(content, "\n"); idx >= 0 {
+			return content[:idx+1] + header + "\n" + content[idx+1:]
+		}
+	}
+	return header + "\n\n" + content
+}
+
+type importRef struct {
+	path string
+	line int
+}
+
+var (
+	goImportLine   = regexp.MustCompile(`^\s*import\s+(?:[\w.]+\s+)?"([^"]+)"`)
+	goImportInner  = regexp.MustCompile(`^\s*(?:[\w.]+\s+)?"([^"]+)"`)
+	pyImport       = regexp.MustCompile(`^\s*(?:from\s+([\w.]+)\s+import|import\s+([\w.]+))`)
+	jsImport       = regexp.MustCompile(`(?:import\s+(?:[^'"]*\s+from\s+)?|require\(\s*|import\(\s*)['"]([^'"]+)['"]`)
+	rustUse        = regexp.MustCompile(`^\s*(?:pub\s+)?use\s+([\w:]+)`)
+	javaImport     = regexp.MustCompile(`^\s*import\s+(?:static\s+)?([\w.]+)`)
+	genericInclude = regexp.MustCompile(`^\s*#include\s+[<"]([^>"]+)[>"]`)
+)
+
+// extractImports finds import references in source based on file extension
+func extractImports(rel, content string) []importRef {
+	if content == "" {
+		return nil
+	}
+
+	ext := strings.ToLower(path.Ext(rel))
+	var refs []importRef
+	inGoBlock := false
+
+	for i, line := range strings.Split(content, "\n") {
+		lineNo := i + 1
+		switch ext {
+		case ".go":
+			trimmed := strings.TrimSpace(line)
+			if strings.HasPrefix(trimmed, "import (") {
+				inGoBlock = true
+				continue
+			}
+			if inGoBlock {
+				if trimmed == ")" {
+					inGoBlock = false
+				} else if m := goImportInner.FindStringSubmatch(line); m != nil {
+					refs = append(refs, importRef{m[1], lineNo})
+				}
+				continue
+			}
+			if m := goImportLine.FindStringSubmatch(line); m != nil {
+				refs = append(refs, importRef{m[1], lineNo})
+			}
+		case ".py":
+			if m := pyImport.FindStringSubmatch(line); m != nil {
+				name := m[1]
+				if name == "" {
+					name = m[2]
+				}
+				refs = append(refs, importRef{name, lineNo})
+			}
+		case ".js", ".jsx", ".ts", ".tsx", ".mjs", ".cjs":
+			for _, m := range jsImport.FindAllStringSubmatch(line, -1) {
+				refs = append(refs, importRef{m[1], lineNo})
+			}
+		case ".rs":
+			if m := rustUse.FindStringSubmatch(line); m != nil {
+				refs = append(refs, importRef{strings.ReplaceAll(m[1], "::", "/"), lineNo})
+			}
+		case ".java", ".kt", ".scala":
+			if m := javaImport.FindStringSubmatch(line); m != nil {
+				refs = append(refs, importRef{m[1], lineNo})
+			}
+		case ".c", ".h", ".cc", ".cpp", ".hpp":
+			if m := genericInclude.FindStringSubmatch(line); m != nil {
+				refs = append(refs, importRef{m[1], lineNo})
+			}
+		}
+	}
+
+	return refs
+}
+
+// countImports counts occurrences of each import path in content
+func countImports(rel, content string) map[string]int {
+	counts := make(map[string]int)
+	for _, ref := range extractImports(rel, content) {
+		counts[ref.path]++
+	}
+	return counts
+}
@@ -0,0 +1,141 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestForbiddenImports(t *testing.T) {
+	engine, err := NewEngine("/repo", Policies{
+		ForbiddenImports: []ImportRule{{Pattern: "unsafe"}, {Pattern: "github.com/pkg/errors"}},
+	})
+	if err != nil {
+		t.Fatalf("NewEngine failed: %v", err)
+	}
+
+	content := `package main
+
+import (
+	"fmt"
+	"unsafe"
+	pkgerrors "github.com/pkg/errors"
+)
+`
+	result := engine.CheckChange("/repo/main.go", "", content)
+	if !result.Blocked() {
+		t.Fatal("Expected forbidden imports to block the change")
+	}
+	if len(result.Violations) != 2 {
+		t.Fatalf("Expected 2 violations, got %d: %v", len(result.Violations), result.Violations)
+	}
+	if result.Violations[0].Line != 5 || result.Violations[0].File != "main.go" {
+		t.Errorf("Unexpected violation location: %+v", result.Violations[0])
+	}
+
+	// Pre-existing imports don't block unrelated edits
+	result = engine.CheckChange("/repo/main.go", content, content+"\nfunc main() {}\n")
+	if result.Blocked() {
+		t.Errorf("Expected pre-existing imports to be allowed, got %v", result.Violations)
+	}
+}
+
+func TestLicenseHeaderAutoFix(t *testing.T) {
+	header := "// Copyright Example Corp"
+	engine, _ := NewEngine("", Policies{
+		LicenseHeaders: []LicenseHeaderRule{{Text: header, Paths: []string{"*.go"}, Action: ActionFix}},
+	})
+
+	result := engine.CheckChange("pkg/a.go", "", "package pkg\n")
+	if result.Blocked() {
+		t.Fatal("Expected license header to be auto-fixed")
+	}
+	if !strings.HasPrefix(result.Content, header) {
+		t.Errorf("Expected header to be inserted, got %q", result.Content)
+	}
+
+	result = engine.CheckChange("README.md", "", "# Readme\n")
+	if len(result.Violations) != 0 {
+		t.Errorf("Expected rule to be scoped to *.go files, got %v", result.Violations)
+	}
+}
+
+func TestBannedAPIs(t *testing.T) {
+	engine, err := NewEngine("", Policies{
+		BannedAPIs: []APIRule{
+			{Pattern: `\beval\(`, Message: "eval is not allowed"},
+			{Pattern: `ioutil\.ReadFile`, Replacement: "os.ReadFile", Action: ActionFix},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewEngine failed: %v", err)
+	}
+
+	result := engine.CheckChange("app.js", "", "const x = eval(input)\n")
+	if !result.Blocked() || result.Violations[0].Message != "eval is not allowed" {
+		t.Errorf("Expected eval to be blocked, got %v", result.Violations)
+	}
+
+	result = engine.CheckChange("main.go", "", "data, _ := ioutil.ReadFile(p)\n")
+	if result.Blocked() {
+		t.Fatalf("Expected ioutil.ReadFile to be auto-fixed, got %v", result.Error())
+	}
+	if !strings.Contains(result.Content, "os.ReadFile(p)") {
+		t.Errorf("Expected replacement to be applied, got %q", result.Content)
+	}
+}
+
+func TestBannedAPIsOnlyAddedLines(t *testing.T) {
+	engine, err := NewEngine("", Policies{
+		BannedAPIs: []APIRule{
+			{Pattern: `ioutil\.(ReadFile)`, Replacement: "os.$1", Action: ActionFix},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewEngine failed: %v", err)
+	}
+
+	old := "a, _ := ioutil.ReadFile(p)\nb := 1\n"
+	changed := "a, _ := ioutil.ReadFile(p)\nb := 2\nc, _ := ioutil.ReadFile(q)\n"
+	result := engine.CheckChange("main.go", old, changed)
+	if len(result.Violations) != 1 || result.Violations[0].Line != 3 {
+		t.Fatalf("Expected only the added call to be reported, got %v", result.Violations)
+	}
+	want := "a, _ := ioutil.ReadFile(p)\nb := 2\nc, _ := os.ReadFile(q)\n"
+	if result.Content != want {
+		t.Errorf("Expected only the added call to be fixed, got %q", result.Content)
+	}
+
+	// Moving a line that was already there adds nothing
+	result = engine.CheckChange("main.go", old, "b := 1\na, _ := ioutil.ReadFile(p)\n")
+	if len(result.Violations) != 0 || result.Content != "b := 1\na, _ := ioutil.ReadFile(p)\n" {
+		t.Errorf("Expected a moved line to pass unchanged, got %v, %q", result.Violations, result.Content)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	disabled := false
+	base := Policies{
+		DefaultAction:    ActionWarn,
+		ForbiddenImports: []ImportRule{{Pattern: "unsafe"}},
+	}
+	project := Policies{
+		Enabled:    &disabled,
+		BannedAPIs: []APIRule{{Pattern: `\beval\(`}},
+	}
+
+	merged := project.Merge(base)
+	if len(merged.BannedAPIs) != 0 {
+		t.Errorf("Expected disabled project rules to be dropped, got %v", merged.BannedAPIs)
+	}
+	if len(merged.ForbiddenImports) != 1 || merged.ForbiddenImports[0].Action != ActionWarn {
+		t.Errorf("Expected base rule to keep its default action, got %+v", merged.ForbiddenImports)
+	}
+	if base.ForbiddenImports[0].Action != "" {
+		t.Error("Merge modified the base rules")
+	}
+
+	project.Enabled = nil
+	if merged := project.Merge(base); len(merged.BannedAPIs) != 1 || len(merged.ForbiddenImports) != 1 {
+		t.Errorf("Expected rules from both policies, got %+v", merged)
+	}
+}
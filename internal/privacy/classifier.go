@@ -0,0 +1,208 @@
+package privacy
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Action determines what happens to a request that contains sensitive data
+type Action string
+
+const (
+	ActionBlock  Action = "block"  // Refuse to send the request
+	ActionRedact Action = "redact" // Replace sensitive values before sending
+	ActionLocal  Action = "local"  // Route the request to a local model
+)
+
+// ParseAction converts a config string into an Action, defaulting to redact
+func ParseAction(s string) Action {
+	switch Action(strings.ToLower(strings.TrimSpace(s))) {
+	case ActionBlock:
+		return ActionBlock
+	case ActionLocal:
+		return ActionLocal
+	default:
+		return ActionRedact
+	}
+}
+
+// Match is a piece of sensitive data found in outbound text
+type Match struct {
+	Kind  string // email, ssn, credit_card, secret, private_key, pattern, path, tag
+	Value string
+	// Redactable is false for whole-file classifications (sensitive paths and
+	// tags) that can't be removed by replacing a single value
+	Redactable bool
+}
+
+// Options configures a Classifier
+type Options struct {
+	SensitivePaths []string
+	SensitiveTag   string
+	DetectPII      bool
+	Patterns       []string
+}
+
+type detector struct {
+	kind string
+	re   *regexp.Regexp
+}
+
+// Classifier finds sensitive data in text bound for cloud providers
+type Classifier struct {
+	paths     []string
+	tag       string
+	detectors []detector
+}
+
+var piiDetectors = []detector{
+	{"email", regexp.MustCompile(`\b[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}\b`)},
+	{"ssn", regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`)},
+	{"credit_card", regexp.MustCompile(`\b(?:\d[ -]?){13,16}\b`)},
+	{"secret", regexp.MustCompile(`\b(?:AKIA[0-9A-Z]{16}|sk-[A-Za-z0-9_\-]{20,}|gh[pousr]_[A-Za-z0-9]{36}|xox[baprs]-[A-Za-z0-9\-]{10,})\b`)},
+	{"private_key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)},
+}
+
+// pathToken matches path-like tokens such as "src/customers/list.go"
+var pathToken = regexp.MustCompile(`[A-Za-z0-9_.\-~]*(?:/[A-Za-z0-9_.\-]+)+`)
+
+// NewClassifier creates a classifier from options
+func NewClassifier(opts Options) (*Classifier, error) {
+	c := &Classifier{
+		paths: opts.SensitivePaths,
+		tag:   opts.SensitiveTag,
+	}
+	if opts.DetectPII {
+		c.detectors = append(c.detectors, piiDetectors...)
+	}
+	for _, p := range opts.Patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid outbound filter pattern %q: %w", p, err)
+		}
+		c.detectors = append(c.detectors, detector{"pattern", re})
+	}
+	return c, nil
+}
+
+// Classify returns the sensitive data found in text
+func (c *Classifier) Classify(text string) []Match {
+	var matches []Match
+
+	if c.tag != "" && strings.Contains(text, c.tag) {
+		matches = append(matches, Match{Kind: "tag", Value: c.tag})
+	}
+
+	if len(c.paths) > 0 {
+		seen := make(map[string]bool)
+		for _, token := range pathToken.FindAllString(text, -1) {
+			token = strings.TrimPrefix(token, "./")
+			if seen[token] {
+				continue
+			}
+			seen[token] = true
+			if MatchesAnyPath(token, c.paths) {
+				matches = append(matches, Match{Kind: "path", Value: token})
+			}
+		}
+	}
+
+	for _, d := range c.detectors {
+		for _, v := range d.re.FindAllString(text, -1) {
+			if d.kind == "credit_card" && !luhnValid(v) {
+				continue
+			}
+			matches = append(matches, Match{Kind: d.kind, Value: v, Redactable: true})
+		}
+	}
+
+	return matches
+}
+
+// Redact replaces redactable sensitive values in text and reports how many
+// replacements were made
+func (c *Classifier) Redact(text string) (string, int) {
+	count := 0
+	for _, d := range c.detectors {
+		text = d.re.ReplaceAllStringFunc(text, func(v string) string {
+			if d.kind == "credit_card" && !luhnValid(v) {
+				return v
+			}
+			count++
+			return "[REDACTED:" + d.kind + "]"
+		})
+	}
+	return text, count
+}
+
+// RedactSecrets replaces credentials and private keys in text, leaving other
+// personal data untouched
+func RedactSecrets(text string) string {
+	for _, d := range piiDetectors {
+		if d.kind == "secret" || d.kind == "private_key" {
+			text = d.re.ReplaceAllString(text, "[REDACTED:"+d.kind+"]")
+		}
+	}
+	return text
+}
+
+// MatchesAnyPath reports whether a slash-separated path matches any glob.
+// "**" matches any number of path segments.
+func MatchesAnyPath(p string, globs []string) bool {
+	p = strings.TrimPrefix(p, "/")
+	for _, g := range globs {
+		if matchGlob(strings.Split(strings.TrimPrefix(g, "/"), "/"), strings.Split(p, "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob matches glob segments against path segments
+func matchGlob(glob, parts []string) bool {
+	for len(glob) > 0 {
+		if glob[0] == "**" {
+			for i := 0; i <= len(parts); i++ {
+				if matchGlob(glob[1:], parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(glob[0], parts[0]); !ok {
+			return false
+		}
+		glob, parts = glob[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
+
+// luhnValid checks a candidate card number with the Luhn algorithm
+func luhnValid(s string) bool {
+	var digits []int
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			digits = append(digits, int(r-'0'))
+		}
+	}
+	if len(digits) < 13 {
+		return false
+	}
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := digits[i]
+		if (len(digits)-i)%2 == 0 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
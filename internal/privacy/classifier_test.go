@@ -0,0 +1,56 @@
+package privacy
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClassifySensitivePaths(t *testing.T) {
+	c, err := NewClassifier(Options{
+		SensitivePaths: []string{"**/customers/**", "secrets.env"},
+		SensitiveTag:   "codeforge:sensitive",
+	})
+	if err != nil {
+		t.Fatalf("NewClassifier failed: %v", err)
+	}
+
+	matches := c.Classify("File: src/customers/export.go\npackage customers")
+	if len(matches) != 1 || matches[0].Kind != "path" || matches[0].Redactable {
+		t.Fatalf("Expected one non-redactable path match, got %+v", matches)
+	}
+
+	if matches := c.Classify("// codeforge:sensitive\nfunc main() {}"); len(matches) != 1 || matches[0].Kind != "tag" {
+		t.Errorf("Expected tag match, got %+v", matches)
+	}
+
+	if matches := c.Classify("File: src/orders/list.go"); len(matches) != 0 {
+		t.Errorf("Expected no matches, got %+v", matches)
+	}
+}
+
+func TestRedactPII(t *testing.T) {
+	c, err := NewClassifier(Options{DetectPII: true, Patterns: []string{`ACME-\d+`}})
+	if err != nil {
+		t.Fatalf("NewClassifier failed: %v", err)
+	}
+
+	text := "Contact jane@example.com, SSN 123-45-6789, card 4111 1111 1111 1111, id ACME-42, order 1234567890123"
+	redacted, count := c.Redact(text)
+	if count != 4 {
+		t.Errorf("Expected 4 redactions, got %d: %s", count, redacted)
+	}
+	for _, leaked := range []string{"jane@example.com", "123-45-6789", "4111 1111 1111 1111", "ACME-42"} {
+		if strings.Contains(redacted, leaked) {
+			t.Errorf("Expected %q to be redacted: %s", leaked, redacted)
+		}
+	}
+	if !strings.Contains(redacted, "1234567890123") {
+		t.Errorf("Expected non-Luhn number to be kept: %s", redacted)
+	}
+}
+
+func TestParseAction(t *testing.T) {
+	if ParseAction("BLOCK") != ActionBlock || ParseAction("local") != ActionLocal || ParseAction("") != ActionRedact {
+		t.Error("ParseAction returned unexpected values")
+	}
+}
@@ -0,0 +1,84 @@
+package privacy
+
+import (
+	"errors"
+	"os"
+	"sync"
+)
+
+// ephemeral tracks whether this process runs an ephemeral session, in which
+// conversations are never persisted, and the files it had to write anyway
+var ephemeral struct {
+	mu        sync.Mutex
+	enabled   bool
+	artifacts []string
+}
+
+// SetEphemeral enables or disables ephemeral mode for this process
+func SetEphemeral(enabled bool) {
+	ephemeral.mu.Lock()
+	defer ephemeral.mu.Unlock()
+	ephemeral.enabled = enabled
+}
+
+// IsEphemeral reports whether conversations must be kept off disk
+func IsEphemeral() bool {
+	ephemeral.mu.Lock()
+	defer ephemeral.mu.Unlock()
+	return ephemeral.enabled
+}
+
+// TrackArtifact records a file written during an ephemeral session, such as
+// a pasted image, so ScrubArtifacts removes it on exit. It does nothing
+// outside ephemeral mode.
+func TrackArtifact(path string) {
+	ephemeral.mu.Lock()
+	defer ephemeral.mu.Unlock()
+	if ephemeral.enabled {
+		ephemeral.artifacts = append(ephemeral.artifacts, path)
+	}
+}
+
+// ScrubArtifacts overwrites the tracked files with zeros and removes them.
+// Overwriting doesn't defeat copy-on-write filesystems or SSD wear
+// levelling; it only keeps the content out of casual recovery.
+func ScrubArtifacts() error {
+	ephemeral.mu.Lock()
+	artifacts := ephemeral.artifacts
+	ephemeral.artifacts = nil
+	ephemeral.mu.Unlock()
+
+	var errs []error
+	for _, path := range artifacts {
+		if err := scrubFile(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// scrubFile overwrites a file with zeros before removing it
+func scrubFile(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err == nil {
+		zeros := make([]byte, 32*1024)
+		for remaining := info.Size(); remaining > 0; {
+			n := int64(len(zeros))
+			if remaining < n {
+				n = remaining
+			}
+			if _, err := f.Write(zeros[:n]); err != nil {
+				break
+			}
+			remaining -= n
+		}
+		_ = f.Sync()
+		f.Close()
+	}
+	return os.Remove(path)
+}
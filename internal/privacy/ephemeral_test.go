@@ -0,0 +1,35 @@
+package privacy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestScrubArtifacts(t *testing.T) {
+	dir := t.TempDir()
+	before := filepath.Join(dir, "before.png")
+	during := filepath.Join(dir, "during.png")
+	for _, path := range []string{before, during} {
+		if err := os.WriteFile(path, []byte("pasted image"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Only files written in ephemeral mode are tracked
+	TrackArtifact(before)
+	SetEphemeral(true)
+	defer SetEphemeral(false)
+	TrackArtifact(during)
+	TrackArtifact(filepath.Join(dir, "already-removed.png"))
+
+	if err := ScrubArtifacts(); err != nil {
+		t.Fatalf("ScrubArtifacts() error = %v", err)
+	}
+	if _, err := os.Stat(during); !os.IsNotExist(err) {
+		t.Errorf("%s still exists", during)
+	}
+	if _, err := os.Stat(before); err != nil {
+		t.Errorf("%s was removed although it wasn't written in ephemeral mode", before)
+	}
+}
@@ -7,12 +7,14 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/ignore"
 )
 
 // RepositoryAnalyzer analyzes repository structure
 type RepositoryAnalyzer struct {
 	root         string
-	ignorePaths  map[string]bool
+	excludes     *ignore.Matcher
 	maxDepth     int
 	maxFiles     int
 	fileCount    int
@@ -24,21 +26,7 @@ func NewRepositoryAnalyzer(root string) *RepositoryAnalyzer {
 		root:     root,
 		maxDepth: 5,
 		maxFiles: 1000,
-		ignorePaths: map[string]bool{
-			".git":          true,
-			"node_modules":  true,
-			"vendor":        true,
-			"target":        true,
-			"dist":          true,
-			"build":         true,
-			".next":         true,
-			"__pycache__":   true,
-			".pytest_cache": true,
-			".venv":         true,
-			"venv":          true,
-			".idea":         true,
-			".vscode":       true,
-		},
+		excludes: ignore.Default(),
 	}
 }
 
@@ -185,8 +173,8 @@ func (ra *RepositoryAnalyzer) GenerateRepoMap() (*RepoMap, error) {
 
 // shouldIgnore checks if a path should be ignored
 func (ra *RepositoryAnalyzer) shouldIgnore(relPath, name string) bool {
-	// Check exact name matches
-	if ra.ignorePaths[name] {
+	// Check the shared exclude patterns
+	if ra.excludes.Match(relPath) {
 		return true
 	}
 
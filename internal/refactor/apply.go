@@ -0,0 +1,381 @@
+package refactor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/fileedit"
+	"github.com/entrepeneur4lyf/codeforge/internal/fileutil"
+	"github.com/entrepeneur4lyf/codeforge/internal/lsp"
+	"go.lsp.dev/protocol"
+)
+
+// opsBlock matches the fenced JSON block of renames and moves in a reply
+var opsBlock = regexp.MustCompile("(?s)```json[ \t]*\n(.*?)```")
+
+// Ops returns the renames and moves in a reply, and the reply without them
+func Ops(reply string) ([]Op, string, error) {
+	match := opsBlock.FindStringSubmatchIndex(reply)
+	if match == nil {
+		return nil, reply, nil
+	}
+	var ops []Op
+	if err := json.Unmarshal([]byte(reply[match[2]:match[3]]), &ops); err != nil {
+		return nil, reply, fmt.Errorf("the renames and moves are invalid JSON: %w", err)
+	}
+	return ops, reply[:match[0]] + reply[match[1]:], nil
+}
+
+// apply makes the changes a reply asks for, renames first, then moves,
+// then edits, and returns what couldn't be made
+func (r *Refactor) apply(ctx context.Context, reply string, report *Report) []string {
+	var problems []string
+	ops, rest, err := Ops(reply)
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+	var sync fileEvents
+	defer func() {
+		if r.Server != nil && !sync.empty() {
+			// Open files would shadow what's on disk
+			r.Server.CloseAllFiles(ctx)
+			_ = r.Server.NotifyFilesChanged(ctx, sync.created, sync.changed, sync.deleted)
+		}
+	}()
+
+	for _, kind := range []string{"rename", "move"} {
+		for _, op := range ops {
+			if op.Op != kind {
+				continue
+			}
+			var changes []fileedit.Change
+			if kind == "rename" {
+				changes, err = r.rename(ctx, op)
+			} else {
+				changes, err = r.move(op)
+			}
+			if err == nil {
+				err = r.write(changes, report, &sync)
+			}
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", op, err))
+			}
+		}
+	}
+	for _, op := range ops {
+		if op.Op != "rename" && op.Op != "move" {
+			problems = append(problems, fmt.Sprintf("%s: unknown op %q", op, op.Op))
+		}
+	}
+
+	edits, err := fileedit.Extract(rest)
+	if err == nil {
+		var changes []fileedit.Change
+		if changes, err = fileedit.Plan(r.Root, edits); err == nil {
+			err = r.write(changes, report, &sync)
+		}
+	}
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("the edits couldn't be made, so none were: %v", err))
+	}
+	return problems
+}
+
+func (op Op) String() string {
+	if op.Op == "move" {
+		return fmt.Sprintf("moving %s to %s", op.From, op.To)
+	}
+	return fmt.Sprintf("renaming %s in %s to %s", op.Symbol, op.File, op.To)
+}
+
+// rename returns the changes the language server makes to rename a symbol
+func (r *Refactor) rename(ctx context.Context, op Op) ([]fileedit.Change, error) {
+	if r.Server == nil {
+		return nil, fmt.Errorf("there's no language server to rename with; rename it with edits instead")
+	}
+	if op.Symbol == "" || op.To == "" {
+		return nil, fmt.Errorf("a rename needs a symbol and the name to rename it to")
+	}
+	path, err := r.path(op.File)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	line, character, ok := position(string(data), op.Symbol, op.Line)
+	if !ok {
+		return nil, fmt.Errorf("%s isn't in %s", op.Symbol, op.File)
+	}
+	if err := r.Server.OpenFile(ctx, path); err != nil {
+		return nil, err
+	}
+	edit, err := r.Server.Rename(ctx, path, line, character, op.To)
+	if err != nil {
+		return nil, err
+	}
+	return r.workspaceChanges(edit)
+}
+
+// word matches the characters identifiers are made of
+var word = regexp.MustCompile(`[\p{L}\p{N}_]`)
+
+// position finds symbol as a whole word on line (from 1) of content, or
+// anywhere in it when it's not there, returning the position the language
+// server expects: the line from 0 and the character in UTF-16 units
+func position(content, symbol string, line int) (int, int, bool) {
+	lines := strings.Split(content, "\n")
+	find := func(i int) (int, bool) {
+		text := lines[i]
+		for from := 0; ; {
+			at := strings.Index(text[from:], symbol)
+			if at < 0 {
+				return 0, false
+			}
+			at += from
+			end := at + len(symbol)
+			before, _ := utf8.DecodeLastRuneInString(text[:at])
+			after, _ := utf8.DecodeRuneInString(text[end:])
+			if (at == 0 || !word.MatchString(string(before))) && (end == len(text) || !word.MatchString(string(after))) {
+				return len(utf16.Encode([]rune(text[:at]))), true
+			}
+			from = end
+		}
+	}
+	if line >= 1 && line <= len(lines) {
+		if character, ok := find(line - 1); ok {
+			return line - 1, character, true
+		}
+	}
+	for i := range lines {
+		if character, ok := find(i); ok {
+			return i, character, true
+		}
+	}
+	return 0, 0, false
+}
+
+// workspaceChanges turns a language server's edit into changes to files
+// under the root
+func (r *Refactor) workspaceChanges(edit *protocol.WorkspaceEdit) ([]fileedit.Change, error) {
+	byPath := make(map[string][]protocol.TextEdit)
+	for uri, edits := range edit.Changes {
+		path := lsp.URIToPath(string(uri))
+		byPath[path] = append(byPath[path], edits...)
+	}
+	for _, doc := range edit.DocumentChanges {
+		path := lsp.URIToPath(string(doc.TextDocument.URI))
+		byPath[path] = append(byPath[path], doc.Edits...)
+	}
+
+	paths := make([]string, 0, len(byPath))
+	for path := range byPath {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	var changes []fileedit.Change
+	for _, path := range paths {
+		rel, err := fileedit.Resolve(r.Root, path)
+		if err != nil {
+			return nil, fmt.Errorf("the rename changes %s, outside the project: %w", path, err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		after, err := applyTextEdits(string(data), byPath[path])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", rel, err)
+		}
+		if after != string(data) {
+			changes = append(changes, fileedit.Change{Path: rel, Before: string(data), After: after, Existed: true})
+		}
+	}
+	return changes, nil
+}
+
+// applyTextEdits applies a language server's edits to content. Their
+// positions refer to content as it was before any of them.
+func applyTextEdits(content string, edits []protocol.TextEdit) (string, error) {
+	type span struct {
+		start, end int
+		text       string
+	}
+	spans := make([]span, 0, len(edits))
+	for _, edit := range edits {
+		start, err := offset(content, edit.Range.Start)
+		if err != nil {
+			return "", err
+		}
+		end, err := offset(content, edit.Range.End)
+		if err != nil {
+			return "", err
+		}
+		if end < start {
+			return "", fmt.Errorf("an edit ends before it starts")
+		}
+		spans = append(spans, span{start, end, edit.NewText})
+	}
+	sort.SliceStable(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	var sb strings.Builder
+	last := 0
+	for _, s := range spans {
+		if s.start < last {
+			return "", fmt.Errorf("edits overlap")
+		}
+		sb.WriteString(content[last:s.start])
+		sb.WriteString(s.text)
+		last = s.end
+	}
+	sb.WriteString(content[last:])
+	return sb.String(), nil
+}
+
+// offset returns the byte offset of a position, whose character counts
+// UTF-16 units as the protocol does
+func offset(content string, pos protocol.Position) (int, error) {
+	at := 0
+	for line := uint32(0); line < pos.Line; line++ {
+		next := strings.IndexByte(content[at:], '\n')
+		if next < 0 {
+			return 0, fmt.Errorf("line %d is past the end of the file", pos.Line+1)
+		}
+		at += next + 1
+	}
+	for units := uint32(0); units < pos.Character; {
+		r, size := utf8.DecodeRuneInString(content[at:])
+		if size == 0 || r == '\n' {
+			break // Servers may point past the end of a line
+		}
+		units += uint32(utf16.RuneLen(r))
+		at += size
+	}
+	return at, nil
+}
+
+// move returns the changes that move a file
+func (r *Refactor) move(op Op) ([]fileedit.Change, error) {
+	from, err := r.path(op.From)
+	if err != nil {
+		return nil, err
+	}
+	to, err := r.path(op.To)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(from)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(to); err == nil {
+		return nil, fmt.Errorf("%s already exists", op.To)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	fromRel, _ := filepath.Rel(r.Root, from)
+	toRel, _ := filepath.Rel(r.Root, to)
+	return []fileedit.Change{
+		{Path: filepath.ToSlash(fromRel), Before: string(data), Existed: true, Delete: true},
+		{Path: filepath.ToSlash(toRel), After: string(data)},
+	}, nil
+}
+
+// path returns the absolute path of a file named relative to the root,
+// refusing ones outside it, including through symlinks
+func (r *Refactor) path(name string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("no file given")
+	}
+	if filepath.IsAbs(name) {
+		return "", fmt.Errorf("%s isn't relative to the project root", name)
+	}
+	rel, err := fileedit.Resolve(r.Root, name)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(r.Root, filepath.FromSlash(rel)), nil
+}
+
+// fileEvents collects the files written, for the language server
+type fileEvents struct {
+	created, changed, deleted []string
+}
+
+func (e *fileEvents) empty() bool {
+	return len(e.created)+len(e.changed)+len(e.deleted) == 0
+}
+
+// write writes changes, backing up the files they replace, and records
+// them in the report. Renames and moves don't go through fileedit.Plan, so
+// they're checked against the project's code policies here, as planned
+// edits are.
+func (r *Refactor) write(changes []fileedit.Change, report *Report, events *fileEvents) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	if err := fileedit.CheckPolicies(r.Root, changes); err != nil {
+		return err
+	}
+	backups, err := fileedit.Apply(r.Root, changes, r.BackupDir)
+	if err != nil {
+		return err
+	}
+	if backups != "" {
+		report.Backups = append(report.Backups, backups)
+	}
+	if r.original == nil {
+		r.original = make(map[string]*string)
+	}
+	paths := make([]string, len(changes))
+	for i, c := range changes {
+		paths[i] = c.Path
+		if _, ok := r.original[c.Path]; !ok {
+			var before *string
+			if c.Existed {
+				before = &c.Before
+			}
+			r.original[c.Path] = before
+			report.Files = append(report.Files, c.Path)
+		}
+		path := filepath.Join(r.Root, filepath.FromSlash(c.Path))
+		switch {
+		case c.Delete:
+			events.deleted = append(events.deleted, path)
+		case c.Existed:
+			events.changed = append(events.changed, path)
+		default:
+			events.created = append(events.created, path)
+		}
+	}
+	sort.Strings(report.Files)
+	fmt.Fprintf(r.Out, "Changed %s\n", strings.Join(paths, ", "))
+	return nil
+}
+
+// Revert puts every file the refactor touched back as it was before it
+func (r *Refactor) Revert() error {
+	var errs []error
+	for rel, before := range r.original {
+		path := filepath.Join(r.Root, filepath.FromSlash(rel))
+		if before == nil {
+			if err := fileutil.RemoveFile(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				errs = append(errs, err)
+			}
+		} else {
+			errs = append(errs, fileutil.WriteFile(path, []byte(*before)))
+		}
+	}
+	return errors.Join(errs...)
+}
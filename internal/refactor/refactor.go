@@ -0,0 +1,312 @@
+// Package refactor carries out refactors the model plans: symbols are
+// renamed through the language server, files are moved and the rest is
+// edited, then the project's build and tests are run and failures are sent
+// back to the model until they pass or the retries run out.
+package refactor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/ignore"
+	"github.com/entrepeneur4lyf/codeforge/internal/indexer"
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+	"github.com/entrepeneur4lyf/codeforge/internal/shellcmd"
+	"go.lsp.dev/protocol"
+)
+
+const (
+	DefaultRetries = 3 // Rounds of fixes after the first attempt
+
+	maxListedFiles  = 500        // Project files listed with the request
+	maxFileBytes    = 64 * 1024  // Content of a file sent to the model
+	maxContextBytes = 256 * 1024 // Content of all the files sent with a message
+)
+
+const prompt = `You are CodeForge, refactoring the developer's project. You can't run commands or open files: the files the refactor most likely touches come with the request. Once your changes are made, the project's build and tests are run and any failures are sent back to you to fix.
+
+Start with a short plan. Then give the renames and file moves as a JSON array in a ` + "```json" + ` block:
+- {"op": "rename", "file": "path", "line": 12, "symbol": "OldName", "to": "NewName"} renames a symbol everywhere it's used, through the language server; file and line are where it's declared or used
+- {"op": "move", "from": "path", "to": "path"} moves a file
+
+Then make the other changes, such as the package clauses and imports moved files need, as unified diffs with --- a/path and +++ b/path headers and /dev/null for new and deleted files, or as SEARCH/REPLACE blocks with the file's path on the line before them. Renames are made first, then moves, then edits, so edits must use the paths files have after the moves and the names they have after the renames. Paths are relative to the project root. Keep the project's behavior unchanged.`
+
+// Op is a rename or file move the model asks for
+type Op struct {
+	Op     string `json:"op"`               // "rename" or "move"
+	File   string `json:"file,omitempty"`   // Rename: a file declaring or using the symbol
+	Line   int    `json:"line,omitempty"`   // Rename: the symbol's line in File, from 1
+	Symbol string `json:"symbol,omitempty"` // Rename: the symbol's current name
+	From   string `json:"from,omitempty"`   // Move: the file's current path
+	To     string `json:"to"`               // Rename: the new name; move: the new path
+}
+
+// LanguageServer is the part of a language server client a refactor uses
+type LanguageServer interface {
+	OpenFile(ctx context.Context, path string) error
+	Rename(ctx context.Context, path string, line, character int, newName string) (*protocol.WorkspaceEdit, error)
+	CloseAllFiles(ctx context.Context)
+	NotifyFilesChanged(ctx context.Context, created, changed, deleted []string) error
+}
+
+// Report says how a refactor went
+type Report struct {
+	Attempts int      // Replies of the model that were applied
+	Passed   bool     // Whether the last attempt was made in full and passed the checks
+	Files    []string // Files created, changed or deleted, sorted
+	Backups  []string // Directories the replaced files were backed up to
+	Failure  string   // What failed on the last attempt, when it didn't pass
+}
+
+// Refactor is a refactor of the project under Root
+type Refactor struct {
+	Root      string
+	Goal      string
+	Handler   llm.ApiHandler
+	Server    LanguageServer // Optional; renames fail without one
+	Commands  []string       // Build and test command lines that must pass
+	Retries   int            // Rounds of fixes allowed after the first attempt
+	Timeout   time.Duration  // For each command; shellcmd.DefaultTimeout when 0
+	BackupDir string
+	Out       io.Writer
+
+	// original holds each file touched as it was before the refactor, nil
+	// for files that didn't exist
+	original map[string]*string
+}
+
+// Run asks the model for the refactor, makes its changes and runs the
+// commands, sending what failed back to the model until everything passes
+// or the retries are used up. Failing checks aren't an error; they're in
+// the report.
+func (r *Refactor) Run(ctx context.Context) (*Report, error) {
+	report := &Report{}
+	request, err := r.request()
+	if err != nil {
+		return report, err
+	}
+	messages := []llm.Message{text("user", request)}
+
+	for attempt := 0; attempt <= r.Retries; attempt++ {
+		if attempt == 0 {
+			fmt.Fprintln(r.Out, "Planning...")
+		} else {
+			fmt.Fprintf(r.Out, "\nFixing, attempt %d of %d...\n", attempt, r.Retries)
+		}
+		response, err := r.ask(ctx, messages)
+		if err != nil {
+			return report, err
+		}
+		fmt.Fprintln(r.Out, strings.TrimSpace(response))
+		messages = append(messages, text("assistant", response))
+		report.Attempts++
+
+		problems := r.apply(ctx, response, report)
+		failed, err := r.check(ctx)
+		if err != nil {
+			return report, err
+		}
+		if len(problems) == 0 && failed == nil {
+			report.Passed, report.Failure = true, ""
+			return report, nil
+		}
+
+		var feedback strings.Builder
+		if len(problems) > 0 {
+			feedback.WriteString("Some of your changes couldn't be made:\n")
+			for _, problem := range problems {
+				fmt.Fprintf(&feedback, "- %s\n", problem)
+			}
+			feedback.WriteString("\n")
+		}
+		if failed != nil {
+			feedback.WriteString(failed.Message() + "\n\n")
+		}
+		report.Failure = strings.TrimSpace(feedback.String())
+		feedback.WriteString("Fix this with more renames, moves and edits.")
+		feedback.WriteString(r.fileSections(r.mentioned(report.Failure, report.Files)))
+		messages = append(messages, text("user", feedback.String()))
+	}
+	return report, nil
+}
+
+// ask sends the conversation to the model and returns its reply
+func (r *Refactor) ask(ctx context.Context, messages []llm.Message) (string, error) {
+	stream, err := r.Handler.CreateMessage(ctx, prompt, messages)
+	if err != nil {
+		return "", err
+	}
+	collector, err := llm.NewStreamProcessor(ctx).ProcessStream(stream)
+	if err != nil {
+		return "", err
+	}
+	return collector.GetFullText(), nil
+}
+
+// check runs the commands in turn, returning the first that fails
+func (r *Refactor) check(ctx context.Context) (*shellcmd.Result, error) {
+	for _, command := range r.Commands {
+		fmt.Fprintf(r.Out, "Running %s... ", command)
+		result, err := shellcmd.Run(ctx, r.Root, nil, command, r.Timeout)
+		if err != nil {
+			fmt.Fprintln(r.Out)
+			return nil, err
+		}
+		if result.ExitCode != 0 || result.TimedOut {
+			fmt.Fprintln(r.Out, "failed")
+			return result, nil
+		}
+		fmt.Fprintln(r.Out, "ok")
+	}
+	return nil, nil
+}
+
+// request returns the goal with the project's files and the content of
+// the ones mentioning the identifiers in it
+func (r *Refactor) request() (string, error) {
+	files, err := r.files()
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s\n\nFiles in the project:\n", r.Goal)
+	for i, file := range files {
+		if i == maxListedFiles {
+			sb.WriteString("...\n")
+			break
+		}
+		sb.WriteString(file + "\n")
+	}
+	if r.Server == nil {
+		sb.WriteString("\nThere's no language server, so make renames as edits.\n")
+	}
+	sb.WriteString(r.fileSections(r.relevant(files)))
+	return sb.String(), nil
+}
+
+// files returns the files of the project, relative to the root and slash
+// separated, skipping hidden and ignored ones
+func (r *Refactor) files() ([]string, error) {
+	excludes := ignore.Default()
+	var files []string
+	err := filepath.WalkDir(r.Root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(r.Root, path)
+		if rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if strings.HasPrefix(d.Name(), ".") || excludes.Match(rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Type().IsRegular() {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// identifier matches the names in a goal that are likely code: ones with
+// an upper case letter after the first, an underscore or a dot
+var identifier = regexp.MustCompile(`\b[A-Za-z_][a-z0-9_]*(?:[A-Z_.][A-Za-z0-9_]*)+\b`)
+
+// relevant returns the source files mentioning the identifiers in the
+// goal, the ones mentioning them most first
+func (r *Refactor) relevant(files []string) []string {
+	names := identifier.FindAllString(r.Goal, -1)
+	if len(names) == 0 {
+		return nil
+	}
+	hits := make(map[string]int)
+	var found []string
+	for _, file := range files {
+		if _, ok := indexer.Language(file); !ok {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(r.Root, filepath.FromSlash(file)))
+		if err != nil {
+			continue
+		}
+		for _, name := range names {
+			hits[file] += strings.Count(string(data), name)
+		}
+		if hits[file] > 0 {
+			found = append(found, file)
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool { return hits[found[i]] > hits[found[j]] })
+	return found
+}
+
+// location matches the file:line positions build tools and tests report
+var location = regexp.MustCompile(`([\w./\\-]+\.\w+):\d+`)
+
+// mentioned returns the files output reports positions in. Tests report
+// base names only, so those are looked up among the files changed.
+func (r *Refactor) mentioned(output string, changed []string) []string {
+	seen := make(map[string]bool)
+	var files []string
+	for _, match := range location.FindAllStringSubmatch(output, -1) {
+		path := strings.TrimPrefix(filepath.ToSlash(match[1]), "./")
+		candidates := []string{path}
+		if !strings.Contains(path, "/") {
+			for _, file := range changed {
+				if filepath.Base(file) == path {
+					candidates = append(candidates, file)
+				}
+			}
+		}
+		for _, file := range candidates {
+			if seen[file] {
+				continue
+			}
+			if info, err := os.Stat(filepath.Join(r.Root, filepath.FromSlash(file))); err == nil && info.Mode().IsRegular() {
+				seen[file] = true
+				files = append(files, file)
+			}
+		}
+	}
+	return files
+}
+
+// fileSections returns the current content of files for a message, as
+// much of it as fits
+func (r *Refactor) fileSections(files []string) string {
+	var sb strings.Builder
+	for _, file := range files {
+		data, err := os.ReadFile(filepath.Join(r.Root, filepath.FromSlash(file)))
+		if err != nil {
+			continue
+		}
+		if len(data) > maxFileBytes {
+			data = data[:maxFileBytes]
+		}
+		if sb.Len()+len(data) > maxContextBytes {
+			break
+		}
+		content := string(data)
+		if !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		fmt.Fprintf(&sb, "\n%s:\n```\n%s```\n", file, content)
+	}
+	return sb.String()
+}
+
+func text(role, content string) llm.Message {
+	return llm.Message{Role: role, Content: []llm.ContentBlock{llm.TextBlock{Text: content}}}
+}
@@ -0,0 +1,249 @@
+package refactor
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+	"github.com/entrepeneur4lyf/codeforge/internal/lsp"
+	"go.lsp.dev/protocol"
+)
+
+type scriptHandler struct {
+	replies  []string
+	requests [][]llm.Message
+}
+
+func (h *scriptHandler) CreateMessage(_ context.Context, _ string, messages []llm.Message) (llm.ApiStream, error) {
+	h.requests = append(h.requests, messages)
+	if len(h.replies) == 0 {
+		return nil, errors.New("connection lost")
+	}
+	ch := make(chan llm.ApiStreamChunk, 1)
+	ch <- llm.ApiStreamTextChunk{Text: h.replies[0]}
+	close(ch)
+	h.replies = h.replies[1:]
+	return ch, nil
+}
+
+func (h *scriptHandler) GetModel() llm.ModelResponse {
+	return llm.ModelResponse{ID: "test/model"}
+}
+
+func (h *scriptHandler) GetApiStreamUsage() (*llm.ApiStreamUsageChunk, error) {
+	return nil, nil
+}
+
+// fakeServer renames every whole-word use of the symbol at the position in
+// the files under root, as a language server would
+type fakeServer struct {
+	root    string
+	changed []string
+}
+
+func (s *fakeServer) OpenFile(context.Context, string) error { return nil }
+func (s *fakeServer) CloseAllFiles(context.Context)          {}
+
+func (s *fakeServer) NotifyFilesChanged(_ context.Context, created, changed, deleted []string) error {
+	s.changed = append(append(append(s.changed, created...), changed...), deleted...)
+	return nil
+}
+
+func (s *fakeServer) Rename(_ context.Context, path string, line, character int, newName string) (*protocol.WorkspaceEdit, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	text := strings.Split(string(data), "\n")[line][character:]
+	old := text[:strings.IndexFunc(text, func(r rune) bool { return r == '(' || r == ' ' })]
+
+	edit := &protocol.WorkspaceEdit{Changes: map[protocol.DocumentURI][]protocol.TextEdit{}}
+	entries, _ := os.ReadDir(s.root)
+	for _, entry := range entries {
+		file := filepath.Join(s.root, entry.Name())
+		data, _ := os.ReadFile(file)
+		for i, l := range strings.Split(string(data), "\n") {
+			if at, ok := findWord(l, old); ok {
+				uri := protocol.DocumentURI(lsp.PathToURI(file))
+				edit.Changes[uri] = append(edit.Changes[uri], protocol.TextEdit{
+					Range: protocol.Range{
+						Start: protocol.Position{Line: uint32(i), Character: uint32(at)},
+						End:   protocol.Position{Line: uint32(i), Character: uint32(at + len(old))},
+					},
+					NewText: newName,
+				})
+			}
+		}
+	}
+	return edit, nil
+}
+
+func findWord(line, word string) (int, bool) {
+	_, character, ok := position(line, word, 1)
+	return character, ok
+}
+
+func TestRefactor(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the checks are POSIX shell commands")
+	}
+	root := t.TempDir()
+	files := map[string]string{
+		"store.go": "package main\n\nfunc oldStore() {}\n",
+		"main.go":  "package main\n\nfunc main() {\n\toldStore()\n}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	handler := &scriptHandler{replies: []string{
+		"Rename it and move it.\n```json\n" +
+			`[{"op": "move", "from": "store.go", "to": "storage/store.go"}, {"op": "rename", "file": "store.go", "line": 3, "symbol": "oldStore", "to": "newStore"}]` +
+			"\n```\n",
+		"main.go\n<<<<<<< SEARCH\n\tnewStore()\n=======\n\tnewStore() // checked\n>>>>>>> REPLACE\n",
+	}}
+	server := &fakeServer{root: root}
+	r := &Refactor{
+		Root:      root,
+		Goal:      "rename oldStore and move it to storage",
+		Handler:   handler,
+		Server:    server,
+		Commands:  []string{"grep -q newStore storage/store.go", "grep -q checked main.go"},
+		Retries:   2,
+		BackupDir: filepath.Join(t.TempDir(), "backups"),
+		Out:       io.Discard,
+	}
+	report, err := r.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Passed || report.Attempts != 2 {
+		t.Fatalf("report = %+v, want a pass on the second attempt", report)
+	}
+	if want := "main.go,storage/store.go,store.go"; strings.Join(report.Files, ",") != want {
+		t.Errorf("files = %v, want %s", report.Files, want)
+	}
+	if len(server.changed) == 0 {
+		t.Error("the language server wasn't told about the changes")
+	}
+
+	// The request carries the files mentioning the goal's identifiers, and
+	// the fix request what failed
+	first := handler.requests[0][0].Content[0].(llm.TextBlock).Text
+	if !strings.Contains(first, "main.go:\n```\npackage main") {
+		t.Errorf("request doesn't carry main.go:\n%s", first)
+	}
+	fix := handler.requests[1][2].Content[0].(llm.TextBlock).Text
+	if !strings.Contains(fix, "grep -q checked main.go") {
+		t.Errorf("fix request doesn't say what failed:\n%s", fix)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(root, "main.go"))
+	if want := "package main\n\nfunc main() {\n\tnewStore() // checked\n}\n"; string(data) != want {
+		t.Errorf("main.go = %q, want %q", data, want)
+	}
+	if _, err := os.Stat(filepath.Join(root, "store.go")); err == nil {
+		t.Error("store.go wasn't moved")
+	}
+
+	if err := r.Revert(); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		data, _ := os.ReadFile(filepath.Join(root, name))
+		if string(data) != content {
+			t.Errorf("reverted %s = %q, want %q", name, data, content)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, "storage/store.go")); err == nil {
+		t.Error("storage/store.go survived the revert")
+	}
+}
+
+func TestRenamesAndMovesAreChecked(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, ".codeforge"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	policies := `{"bannedAPIs": [{"pattern": "\\bnewStore\\b", "message": "newStore is reserved"}]}`
+	if err := os.WriteFile(filepath.Join(root, ".codeforge", "policies.json"), []byte(policies), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	content := "package main\n\nfunc oldStore() {}\n"
+	if err := os.WriteFile(filepath.Join(root, "store.go"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(root, "link")); err != nil {
+		t.Skip("symlinks aren't supported here")
+	}
+
+	r := &Refactor{Root: root, Server: &fakeServer{root: root}, BackupDir: filepath.Join(t.TempDir(), "backups"), Out: io.Discard}
+	reply := "```json\n" +
+		`[{"op": "rename", "file": "store.go", "line": 3, "symbol": "oldStore", "to": "newStore"}, {"op": "move", "from": "store.go", "to": "link/store.go"}]` +
+		"\n```\n"
+	problems := r.apply(context.Background(), reply, &Report{})
+	if len(problems) != 2 || !strings.Contains(problems[0], "newStore is reserved") || !strings.Contains(problems[1], "outside") {
+		t.Errorf("problems = %q", problems)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "store.go")); string(data) != content {
+		t.Errorf("store.go = %q, want it unchanged", data)
+	}
+	if entries, _ := os.ReadDir(outside); len(entries) != 0 {
+		t.Errorf("the move wrote outside the project: %v", entries)
+	}
+}
+
+func TestRefactorRetries(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the checks are POSIX shell commands")
+	}
+	handler := &scriptHandler{replies: []string{"Nothing to do.", "Still nothing.", "Nothing."}}
+	r := &Refactor{
+		Root:     t.TempDir(),
+		Goal:     "make it pass",
+		Handler:  handler,
+		Commands: []string{"false"},
+		Retries:  2,
+		Out:      io.Discard,
+	}
+	report, err := r.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Passed || report.Attempts != 3 || !strings.Contains(report.Failure, "`false`") {
+		t.Errorf("report = %+v, want 3 failed attempts", report)
+	}
+}
+
+func TestApplyTextEdits(t *testing.T) {
+	// Characters count UTF-16 units: 𝔵 takes two
+	content := "a := \"𝔵\"; old()\nold = 1\n"
+	edits := []protocol.TextEdit{
+		{Range: protocol.Range{Start: protocol.Position{Line: 1, Character: 0}, End: protocol.Position{Line: 1, Character: 3}}, NewText: "renamed"},
+		{Range: protocol.Range{Start: protocol.Position{Line: 0, Character: 11}, End: protocol.Position{Line: 0, Character: 14}}, NewText: "renamed"},
+	}
+	got, err := applyTextEdits(content, edits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "a := \"𝔵\"; renamed()\nrenamed = 1\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	line, character, ok := position(content, "old", 0)
+	if !ok || line != 0 || character != 11 {
+		t.Errorf("position = %d:%d %v, want 0:11", line, character, ok)
+	}
+	if _, _, ok := position("older()", "old", 1); ok {
+		t.Error("position matched part of a word")
+	}
+}
@@ -13,6 +13,9 @@ import (
 	"sync"
 
 	"github.com/lithammer/fuzzysearch/fuzzy"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/fileutil"
+	"github.com/entrepeneur4lyf/codeforge/internal/ignore"
 )
 
 // Result represents a search result
@@ -109,8 +112,10 @@ func (s *Searcher) Search(ctx context.Context, opts Options) ([]Result, error) {
 // SearchFiles searches for files matching the query
 func (s *Searcher) SearchFiles(ctx context.Context, query, path string) ([]string, error) {
 	var files []string
+	root := path
+	excludes := ignore.Default()
 	
-	err := filepath.WalkDir(path, func(path string, d fs.DirEntry, err error) error {
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return nil // Skip errors
 		}
@@ -127,6 +132,13 @@ func (s *Searcher) SearchFiles(ctx context.Context, query, path string) ([]strin
 			if strings.HasPrefix(d.Name(), ".") && d.Name() != "." {
 				return filepath.SkipDir
 			}
+			if path != root && excludes.Match(relativePath(root, path)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		
+		if excludes.Match(relativePath(root, path)) {
 			return nil
 		}
 		
@@ -178,12 +190,17 @@ func (s *Searcher) searchWithRipgrep(ctx context.Context, opts Options) ([]Resul
 	}
 	
 	// Add file filters
+	if opts.MaxFileSize > 0 {
+		args = append(args, "--max-filesize", fmt.Sprintf("%d", opts.MaxFileSize))
+	}
+	
+	// ripgrep globs always use forward slashes, including on Windows
 	for _, pattern := range opts.Include {
-		args = append(args, "-g", pattern)
+		args = append(args, "-g", filepath.ToSlash(pattern))
 	}
 	
 	for _, pattern := range opts.Exclude {
-		args = append(args, "-g", "!"+pattern)
+		args = append(args, "-g", "!"+filepath.ToSlash(pattern))
 	}
 	
 	// Add query and path
@@ -242,10 +259,13 @@ func (s *Searcher) searchBuiltin(ctx context.Context, opts Options) ([]Result, e
 		}
 		
 		if d.IsDir() {
-			// Skip hidden directories
+			// Skip hidden and excluded directories
 			if strings.HasPrefix(d.Name(), ".") && d.Name() != "." {
 				return filepath.SkipDir
 			}
+			if path != opts.Path && s.isExcluded(path, opts) {
+				return filepath.SkipDir
+			}
 			return nil
 		}
 		
@@ -319,8 +339,14 @@ func (s *Searcher) searchFile(ctx context.Context, path string, opts Options) []
 		}
 	}
 	
+	// Skip binary content regardless of extension
+	reader := bufio.NewReader(file)
+	if head, _ := reader.Peek(8000); fileutil.IsBinary(head) {
+		return nil
+	}
+	
 	var results []Result
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(reader)
 	lineNum := 0
 	
 	for scanner.Scan() {
@@ -384,10 +410,8 @@ func (s *Searcher) searchFile(ctx context.Context, path string, opts Options) []
 // matchesFilters checks if a file matches the include/exclude filters
 func (s *Searcher) matchesFilters(path string, opts Options) bool {
 	// Check exclude patterns first
-	for _, pattern := range opts.Exclude {
-		if matched, _ := filepath.Match(pattern, filepath.Base(path)); matched {
-			return false
-		}
+	if s.isExcluded(path, opts) {
+		return false
 	}
 	
 	// If no include patterns, include all
@@ -396,13 +420,22 @@ func (s *Searcher) matchesFilters(path string, opts Options) bool {
 	}
 	
 	// Check include patterns
-	for _, pattern := range opts.Include {
-		if matched, _ := filepath.Match(pattern, filepath.Base(path)); matched {
-			return true
+	return ignore.New(opts.Include).Match(relativePath(opts.Path, path))
+}
+
+// isExcluded checks if a file or directory matches an exclude pattern
+func (s *Searcher) isExcluded(path string, opts Options) bool {
+	return ignore.New(opts.Exclude).Match(relativePath(opts.Path, path))
+}
+
+// relativePath returns path relative to the search root with forward slashes
+func relativePath(root, path string) string {
+	if root != "" {
+		if rel, err := filepath.Rel(root, path); err == nil {
+			path = rel
 		}
 	}
-	
-	return false
+	return filepath.ToSlash(path)
 }
 
 // parseRipgrepOutput parses JSON output from ripgrep
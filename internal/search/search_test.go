@@ -292,6 +292,32 @@ func TestFuzzyMatching(t *testing.T) {
 	}
 }
 
+func TestBuiltinExcludesDirectories(t *testing.T) {
+	testDir := t.TempDir()
+	for _, name := range []string{"main.go", filepath.Join("vendor", "lib", "lib.go")} {
+		path := filepath.Join(testDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("needle"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	
+	searcher := &Searcher{cache: newResultCache(10)}
+	results, err := searcher.Search(context.Background(), Options{
+		Query:   "needle",
+		Path:    testDir,
+		Exclude: []string{"vendor/*"},
+	})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 1 || filepath.Base(results[0].Path) != "main.go" {
+		t.Errorf("Expected only main.go, got %+v", results)
+	}
+}
+
 // Helper function for testing
 func fuzzyMatch(query, text string) bool {
 	// Simple fuzzy match check for testing - case insensitive
@@ -0,0 +1,528 @@
+package security
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+	"github.com/entrepeneur4lyf/codeforge/internal/utils"
+)
+
+// Severity represents how serious a security finding is
+type Severity string
+
+const (
+	SeverityInfo     Severity = "info"
+	SeverityLow      Severity = "low"
+	SeverityMedium   Severity = "medium"
+	SeverityHigh     Severity = "high"
+	SeverityCritical Severity = "critical"
+)
+
+// Score returns a numeric score for the severity, higher is worse
+func (s Severity) Score() int {
+	switch s {
+	case SeverityCritical:
+		return 10
+	case SeverityHigh:
+		return 7
+	case SeverityMedium:
+		return 5
+	case SeverityLow:
+		return 3
+	case SeverityInfo:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// ParseSeverity converts a string into a Severity
+func ParseSeverity(s string) (Severity, error) {
+	sev := Severity(strings.ToLower(strings.TrimSpace(s)))
+	if sev.Score() == 0 {
+		return "", fmt.Errorf("unknown severity %q (expected info, low, medium, high or critical)", s)
+	}
+	return sev, nil
+}
+
+// Category is a class of security issue the scanner looks for
+type Category struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Prompt string `json:"-"`
+}
+
+// DefaultCategories returns the categories checked by default
+func DefaultCategories() []Category {
+	return []Category{
+		{
+			ID:     "injection",
+			Name:   "Injection",
+			Prompt: "SQL, command, LDAP, template, path traversal and XSS injection where untrusted input reaches an interpreter, shell, query or filesystem path without validation or escaping.",
+		},
+		{
+			ID:     "authz",
+			Name:   "Authentication & Authorization",
+			Prompt: "Missing or bypassable authentication, missing authorization checks, insecure direct object references, privilege escalation and broken session handling.",
+		},
+		{
+			ID:     "secrets",
+			Name:   "Hardcoded Secrets",
+			Prompt: "Hardcoded API keys, passwords, tokens, private keys or credentials, and secrets written to logs or error messages.",
+		},
+		{
+			ID:     "deserialization",
+			Name:   "Unsafe Deserialization",
+			Prompt: "Deserialization of untrusted data (pickle, yaml.load, Java/PHP object streams, gob, reflection-driven decoders) that can lead to code execution or type confusion.",
+		},
+	}
+}
+
+// Finding is a single security issue reported by the scanner
+type Finding struct {
+	Category       string   `json:"category"`
+	Severity       Severity `json:"severity"`
+	Score          int      `json:"score"`
+	File           string   `json:"file"`
+	Line           int      `json:"line,omitempty"`
+	Title          string   `json:"title"`
+	Description    string   `json:"description"`
+	Recommendation string   `json:"recommendation,omitempty"`
+}
+
+// Report is the JSON document produced by a scan
+type Report struct {
+	Target        string         `json:"target"`
+	Model         string         `json:"model"`
+	ScannedAt     time.Time      `json:"scanned_at"`
+	FilesScanned  int            `json:"files_scanned"`
+	ChunksScanned int            `json:"chunks_scanned"`
+	Findings      []Finding      `json:"findings"`
+	Summary       map[string]int `json:"summary"`
+	MaxSeverity   Severity       `json:"max_severity,omitempty"`
+	Errors        []string       `json:"errors,omitempty"`
+}
+
+// ExceedsThreshold reports whether any finding is at or above the given severity
+func (r *Report) ExceedsThreshold(threshold Severity) bool {
+	return r.MaxSeverity != "" && r.MaxSeverity.Score() >= threshold.Score()
+}
+
+// Target is a piece of content to scan, either a file or a file's diff
+type Target struct {
+	Path    string
+	Content string
+	IsDiff  bool
+}
+
+// chunk is a window of lines from a target
+type chunk struct {
+	target    *Target
+	startLine int
+	text      string
+	// numbers holds, for a diff, each line's number in the new file, or 0
+	// for removed lines and headers
+	numbers []int
+}
+
+// Scanner runs security review prompts over code chunk by chunk
+type Scanner struct {
+	handler     llm.ApiHandler
+	categories  []Category
+	chunkLines  int
+	maxFileSize int64
+}
+
+// NewScanner creates a scanner that uses the given LLM handler
+func NewScanner(handler llm.ApiHandler, categories []Category) *Scanner {
+	if len(categories) == 0 {
+		categories = DefaultCategories()
+	}
+	return &Scanner{
+		handler:     handler,
+		categories:  categories,
+		chunkLines:  150,
+		maxFileSize: 512 * 1024,
+	}
+}
+
+// FilterCategories returns the default categories matching the given IDs
+func FilterCategories(ids []string) ([]Category, error) {
+	if len(ids) == 0 {
+		return DefaultCategories(), nil
+	}
+
+	byID := make(map[string]Category)
+	for _, c := range DefaultCategories() {
+		byID[c.ID] = c
+	}
+
+	var categories []Category
+	for _, id := range ids {
+		c, ok := byID[strings.TrimSpace(id)]
+		if !ok {
+			return nil, fmt.Errorf("unknown category %q", id)
+		}
+		categories = append(categories, c)
+	}
+	return categories, nil
+}
+
+// CollectFiles gathers scan targets from a file or directory, respecting .gitignore
+func (s *Scanner) CollectFiles(root string) ([]*Target, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat %s: %w", root, err)
+	}
+
+	if !info.IsDir() {
+		content, err := os.ReadFile(root)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", root, err)
+		}
+		if strings.HasSuffix(root, ".diff") || strings.HasSuffix(root, ".patch") {
+			return ParseUnifiedDiff(string(content)), nil
+		}
+		return []*Target{{Path: root, Content: string(content)}}, nil
+	}
+
+	var targets []*Target
+	filter := utils.NewGitIgnoreFilter(root)
+	err = filter.WalkWithGitIgnore(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.Size() == 0 || info.Size() > s.maxFileSize {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil || isBinary(content) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			rel = path
+		}
+		targets = append(targets, &Target{Path: rel, Content: string(content)})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk %s: %w", root, err)
+	}
+
+	return targets, nil
+}
+
+// ParseUnifiedDiff splits a unified diff into one target per file
+func ParseUnifiedDiff(diff string) []*Target {
+	var targets []*Target
+	var current *Target
+	var body strings.Builder
+
+	flush := func() {
+		if current != nil {
+			current.Content = body.String()
+			targets = append(targets, current)
+		}
+		body.Reset()
+	}
+
+	for _, line := range strings.Split(diff, "\n") {
+		if strings.HasPrefix(line, "diff --git ") {
+			flush()
+			path := line
+			if idx := strings.LastIndex(line, " b/"); idx >= 0 {
+				path = line[idx+3:]
+			}
+			current = &Target{Path: path, IsDiff: true}
+		} else if current == nil && strings.HasPrefix(line, "+++ ") {
+			// Plain unified diff without git headers
+			current = &Target{Path: strings.TrimPrefix(strings.TrimPrefix(line, "+++ "), "b/"), IsDiff: true}
+		}
+		if current != nil {
+			body.WriteString(line)
+			body.WriteString("\n")
+		}
+	}
+	flush()
+
+	return targets
+}
+
+// Scan reviews every target and returns a report
+func (s *Scanner) Scan(ctx context.Context, targetName string, targets []*Target) *Report {
+	report := &Report{
+		Target:       targetName,
+		Model:        s.handler.GetModel().ID,
+		ScannedAt:    time.Now(),
+		FilesScanned: len(targets),
+		Findings:     []Finding{},
+		Summary:      make(map[string]int),
+	}
+
+	for _, target := range targets {
+		for _, c := range s.chunkTarget(target) {
+			if ctx.Err() != nil {
+				report.Errors = append(report.Errors, ctx.Err().Error())
+				return s.finalize(report)
+			}
+
+			report.ChunksScanned++
+			findings, err := s.scanChunk(ctx, c)
+			if err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("%s:%d: %v", target.Path, c.startLine, err))
+				continue
+			}
+			report.Findings = append(report.Findings, findings...)
+		}
+	}
+
+	return s.finalize(report)
+}
+
+// finalize sorts findings and fills in the summary
+func (s *Scanner) finalize(report *Report) *Report {
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		if report.Findings[i].Score != report.Findings[j].Score {
+			return report.Findings[i].Score > report.Findings[j].Score
+		}
+		if report.Findings[i].File != report.Findings[j].File {
+			return report.Findings[i].File < report.Findings[j].File
+		}
+		return report.Findings[i].Line < report.Findings[j].Line
+	})
+
+	for _, f := range report.Findings {
+		report.Summary[string(f.Severity)]++
+		if f.Severity.Score() > report.MaxSeverity.Score() {
+			report.MaxSeverity = f.Severity
+		}
+	}
+
+	return report
+}
+
+// chunkTarget splits a target into windows of lines
+func (s *Scanner) chunkTarget(target *Target) []chunk {
+	lines := strings.Split(target.Content, "\n")
+	var numbers []int
+	if target.IsDiff {
+		numbers = diffLineNumbers(lines)
+	}
+	var chunks []chunk
+	for start := 0; start < len(lines); start += s.chunkLines {
+		end := start + s.chunkLines
+		if end > len(lines) {
+			end = len(lines)
+		}
+		text := strings.Join(lines[start:end], "\n")
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		c := chunk{target: target, startLine: start + 1, text: text}
+		if numbers != nil {
+			c.numbers = numbers[start:end]
+		}
+		chunks = append(chunks, c)
+	}
+	return chunks
+}
+
+// hunkHeader matches a unified diff hunk header, capturing the old and new
+// start lines and counts
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// diffLineNumbers returns the number each line of a unified diff has in the
+// new file: context and added lines get theirs, everything else 0
+func diffLineNumbers(lines []string) []int {
+	numbers := make([]int, len(lines))
+	next, oldLeft, newLeft := 0, 0, 0
+	for i, line := range lines {
+		if oldLeft == 0 && newLeft == 0 {
+			if m := hunkHeader.FindStringSubmatch(line); m != nil {
+				next, _ = strconv.Atoi(m[3])
+				oldLeft, newLeft = hunkCount(m[2]), hunkCount(m[4])
+			}
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "+"):
+			numbers[i] = next
+			next++
+			newLeft--
+		case strings.HasPrefix(line, "-"):
+			oldLeft--
+		case strings.HasPrefix(line, "\\"):
+			// "\ No newline at end of file"
+		default:
+			numbers[i] = next
+			next++
+			oldLeft--
+			newLeft--
+		}
+	}
+	return numbers
+}
+
+// hunkCount parses a hunk header's line count, which is 1 when left out
+func hunkCount(s string) int {
+	if s == "" {
+		return 1
+	}
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// scanChunk asks the model to review a single chunk
+func (s *Scanner) scanChunk(ctx context.Context, c chunk) ([]Finding, error) {
+	var userMessage strings.Builder
+	if c.target.IsDiff {
+		userMessage.WriteString(fmt.Sprintf("Review this diff for %s. Only report issues introduced by added (+) lines. Added and context lines are prefixed with their line number in the new file; removed lines and headers aren't numbered.\n\n", c.target.Path))
+	} else {
+		userMessage.WriteString(fmt.Sprintf("Review this code from %s. Each line is prefixed with its line number.\n\n", c.target.Path))
+	}
+	numbered := make(map[int]bool)
+	for i, line := range strings.Split(c.text, "\n") {
+		switch {
+		case !c.target.IsDiff:
+			userMessage.WriteString(fmt.Sprintf("%d: %s", c.startLine+i, line))
+		case c.numbers[i] > 0:
+			numbered[c.numbers[i]] = true
+			userMessage.WriteString(fmt.Sprintf("%d: %s", c.numbers[i], line))
+		default:
+			userMessage.WriteString(line)
+		}
+		userMessage.WriteString("\n")
+	}
+
+	messages := []llm.Message{
+		{
+			Role: "user",
+			Content: []llm.ContentBlock{
+				llm.TextBlock{Text: userMessage.String()},
+			},
+		},
+	}
+
+	stream, err := s.handler.CreateMessage(ctx, s.systemPrompt(), messages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to review chunk: %w", err)
+	}
+
+	var response strings.Builder
+	for chunk := range stream {
+		if textChunk, ok := chunk.(llm.ApiStreamTextChunk); ok {
+			response.WriteString(textChunk.Text)
+		}
+	}
+
+	findings, err := ParseFindings(response.String())
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range findings {
+		if findings[i].File == "" {
+			findings[i].File = c.target.Path
+		}
+		// A line number the diff doesn't show can't be trusted, so the
+		// finding is kept but its line is reported as unknown
+		if c.target.IsDiff && !numbered[findings[i].Line] {
+			findings[i].Line = 0
+		}
+	}
+	return findings, nil
+}
+
+// systemPrompt builds the review prompt for the configured categories
+func (s *Scanner) systemPrompt() string {
+	var prompt strings.Builder
+	prompt.WriteString(`You are a senior application security engineer performing a focused security review.
+
+Look ONLY for the following categories of vulnerability:
+`)
+	for _, c := range s.categories {
+		prompt.WriteString(fmt.Sprintf("- %s (%s): %s\n", c.ID, c.Name, c.Prompt))
+	}
+	prompt.WriteString(`
+Report only real, exploitable issues visible in the provided code. Do not report style problems or speculative issues.
+
+Respond with ONLY a JSON array. Each element must have the fields:
+  "category": one of the category ids above
+  "severity": one of "info", "low", "medium", "high", "critical"
+  "line": the line number where the issue occurs (0 if unknown)
+  "title": a short title
+  "description": why this is exploitable
+  "recommendation": how to fix it
+
+If there are no issues respond with [].`)
+	return prompt.String()
+}
+
+// ParseFindings extracts findings from a model response containing a JSON array
+func ParseFindings(response string) ([]Finding, error) {
+	start := strings.Index(response, "[")
+	end := strings.LastIndex(response, "]")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("no JSON array in model response")
+	}
+
+	var raw []struct {
+		Category       string `json:"category"`
+		Severity       string `json:"severity"`
+		File           string `json:"file"`
+		Line           int    `json:"line"`
+		Title          string `json:"title"`
+		Description    string `json:"description"`
+		Recommendation string `json:"recommendation"`
+	}
+	if err := json.Unmarshal([]byte(response[start:end+1]), &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse findings: %w", err)
+	}
+
+	findings := make([]Finding, 0, len(raw))
+	for _, r := range raw {
+		severity, err := ParseSeverity(r.Severity)
+		if err != nil {
+			severity = SeverityMedium
+		}
+		findings = append(findings, Finding{
+			Category:       r.Category,
+			Severity:       severity,
+			Score:          severity.Score(),
+			File:           r.File,
+			Line:           r.Line,
+			Title:          r.Title,
+			Description:    r.Description,
+			Recommendation: r.Recommendation,
+		})
+	}
+	return findings, nil
+}
+
+// isBinary reports whether content looks like binary data
+func isBinary(content []byte) bool {
+	sample := content
+	if len(sample) > 8000 {
+		sample = sample[:8000]
+	}
+	return bytes.IndexByte(sample, 0) >= 0
+}
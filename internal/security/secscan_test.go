@@ -0,0 +1,104 @@
+package security
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseFindings(t *testing.T) {
+	response := "Here are the results:\n```json\n" + `[
+  {"category": "injection", "severity": "HIGH", "line": 12, "title": "SQL injection", "description": "query built with fmt.Sprintf"},
+  {"category": "secrets", "severity": "bogus", "title": "Hardcoded key"}
+]` + "\n```"
+
+	findings, err := ParseFindings(response)
+	if err != nil {
+		t.Fatalf("ParseFindings failed: %v", err)
+	}
+	if len(findings) != 2 {
+		t.Fatalf("Expected 2 findings, got %d", len(findings))
+	}
+	if findings[0].Severity != SeverityHigh || findings[0].Score != 7 || findings[0].Line != 12 {
+		t.Errorf("Unexpected first finding: %+v", findings[0])
+	}
+	if findings[1].Severity != SeverityMedium {
+		t.Errorf("Expected unknown severity to default to medium, got %s", findings[1].Severity)
+	}
+
+	if _, err := ParseFindings("no issues found"); err == nil {
+		t.Error("Expected error for response without JSON array")
+	}
+}
+
+func TestParseUnifiedDiff(t *testing.T) {
+	diff := `diff --git a/main.go b/main.go
+index 123..456 100644
+--- a/main.go
++++ b/main.go
+@@ -1,2 +1,3 @@
+ package main
++var key = "secret"
+diff --git a/util/db.go b/util/db.go
+--- a/util/db.go
++++ b/util/db.go
+@@ -1 +1,2 @@
++db.Query("SELECT * FROM t WHERE id=" + id)
+`
+
+	targets := ParseUnifiedDiff(diff)
+	if len(targets) != 2 {
+		t.Fatalf("Expected 2 targets, got %d", len(targets))
+	}
+	if targets[0].Path != "main.go" || targets[1].Path != "util/db.go" {
+		t.Errorf("Unexpected paths: %s, %s", targets[0].Path, targets[1].Path)
+	}
+	if !targets[1].IsDiff {
+		t.Error("Expected diff targets to be marked as diffs")
+	}
+}
+
+func TestDiffLineNumbers(t *testing.T) {
+	diff := `diff --git a/main.go b/main.go
+--- a/main.go
++++ b/main.go
+@@ -10,3 +10,3 @@ func main() {
+ 	a := 1
+-	b := 2
++	b := 3
+ 	c := 4
+@@ -20 +20,2 @@
++	d := 5
+ 	e := 6
+`
+	got := diffLineNumbers(strings.Split(diff, "\n"))
+	want := []int{0, 0, 0, 0, 10, 0, 11, 12, 0, 20, 21, 0}
+	if len(got) != len(want) {
+		t.Fatalf("diffLineNumbers returned %d numbers, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("line %d numbered %d, want %d", i+1, got[i], want[i])
+		}
+	}
+}
+
+func TestReportThreshold(t *testing.T) {
+	s := &Scanner{}
+	report := s.finalize(&Report{
+		Findings: []Finding{
+			{Severity: SeverityLow, Score: 3},
+			{Severity: SeverityHigh, Score: 7},
+		},
+		Summary: make(map[string]int),
+	})
+
+	if report.MaxSeverity != SeverityHigh {
+		t.Errorf("Expected max severity high, got %s", report.MaxSeverity)
+	}
+	if report.Findings[0].Severity != SeverityHigh {
+		t.Error("Expected findings sorted by score")
+	}
+	if !report.ExceedsThreshold(SeverityMedium) || report.ExceedsThreshold(SeverityCritical) {
+		t.Error("Threshold check returned unexpected result")
+	}
+}
@@ -0,0 +1,261 @@
+// Package shellcmd runs shell commands the assistant proposes, such as tests,
+// formatters and git, once the user has approved them. Commands are checked
+// against an allowlist, a built-in one unless the config sets its own, and a
+// denylist; run with a timeout; and their output is formatted to send back
+// to the model. Nothing is sandboxed, so what can't be checked is refused.
+package shellcmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+	"github.com/entrepeneur4lyf/codeforge/internal/platform"
+)
+
+// Instructions tells the model how to propose a command. It's added to the
+// system prompt of conversations that can run them.
+const Instructions = "To run a shell command in the user's project, such as tests, a formatter or git, " +
+	"put it alone in a ```run fenced block. It runs only if the user approves it, " +
+	"and its output is sent back to you in the next message."
+
+// DefaultTimeout limits how long a command runs when the config sets no
+// timeout
+const DefaultTimeout = 2 * time.Minute
+
+// MaxOutputLength is how much of a command's output is kept; the middle of
+// longer output is cut
+const MaxOutputLength = 30000
+
+// DefaultDeny is denied whatever the config allows: network clients,
+// privilege escalation and commands that wipe disks or the repository's
+// history
+var DefaultDeny = []string{
+	"curl", "wget", "nc", "ncat", "telnet", "ssh", "scp", "sftp",
+	"sudo", "su", "doas",
+	"rm -rf /", "rm -rf /*", "rm -rf ~", "mkfs*", "dd", "shutdown", "reboot",
+	"git push", "git push *", "git reset --hard*", "git clean*",
+	"go * -exec*", "go * -toolexec*", "go vet -vettool*",
+}
+
+// DefaultAllow is allowed when the config sets no allowlist: builds, tests,
+// formatters and git commands that don't reach the network
+var DefaultAllow = []string{
+	"go build", "go test", "go vet", "go fmt", "gofmt", "go mod tidy", "golangci-lint run",
+	"cargo build", "cargo test", "cargo check", "cargo fmt", "cargo clippy",
+	"npm test", "npm run *", "yarn test", "pnpm test", "npx tsc", "npx eslint",
+	"pytest", "python -m pytest", "make",
+	"ls", "pwd", "cat", "head", "tail", "wc", "grep", "rg", "diff",
+	"git status", "git diff", "git log", "git show", "git add", "git commit", "git branch", "git stash",
+}
+
+// wrappers run the command they're given, which a policy can't see through
+var wrappers = map[string]bool{
+	"sh": true, "bash": true, "zsh": true, "dash": true, "ksh": true, "csh": true, "tcsh": true, "fish": true,
+	"pwsh": true, "powershell": true, "cmd": true, "busybox": true,
+	"env": true, "xargs": true, "exec": true, "eval": true, "source": true, ".": true,
+	"command": true, "builtin": true, "nohup": true, "nice": true, "timeout": true, "time": true,
+	"watch": true, "setsid": true, "stdbuf": true, "chroot": true, "strace": true,
+}
+
+// plainWord matches a command name that runs what it says: no quotes,
+// escapes or path that the shell would turn into another command
+var plainWord = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.+-]*$`)
+
+var runBlock = regexp.MustCompile("(?ms)^[ \t]*```run[ \t]*\r?\n(.*?)\r?\n[ \t]*```")
+
+// Extract returns the commands proposed in a response: the contents of its
+// ```run blocks, in order
+func Extract(response string) []string {
+	var commands []string
+	for _, m := range runBlock.FindAllStringSubmatch(response, -1) {
+		if command := strings.TrimSpace(m[1]); command != "" {
+			commands = append(commands, command)
+		}
+	}
+	return commands
+}
+
+// Policy decides which commands may be run and for how long
+type Policy struct {
+	Allow   []string
+	Deny    []string
+	Timeout time.Duration
+}
+
+// NewPolicy returns the policy set by the shell config, which may be nil.
+// Without an allowlist in the config, DefaultAllow is used.
+func NewPolicy(cfg *config.Config) Policy {
+	p := Policy{Allow: DefaultAllow, Deny: DefaultDeny, Timeout: DefaultTimeout}
+	if cfg == nil {
+		return p
+	}
+	if len(cfg.Shell.Allow) > 0 {
+		p.Allow = cfg.Shell.Allow
+	}
+	p.Deny = append(append([]string(nil), DefaultDeny...), cfg.Shell.Deny...)
+	if cfg.Shell.TimeoutSeconds > 0 {
+		p.Timeout = time.Duration(cfg.Shell.TimeoutSeconds) * time.Second
+	}
+	return p
+}
+
+// ErrDenied is wrapped by the errors Check returns
+var ErrDenied = errors.New("command not allowed")
+
+// Check returns an error when command may not be run. Each command of a
+// pipeline or list (a && b, a; b, a | b) is checked on its own. Whatever the
+// lists say, substitution, variables and redirection are refused, as are
+// commands named by a path or with quotes or escapes in their name, and
+// wrappers such as sh -c, env and xargs, since they'd run or write what the
+// lists can't see. An empty allowlist allows nothing.
+func (p Policy) Check(command string) error {
+	if strings.ContainsAny(command, "`$<>") {
+		return fmt.Errorf("%w: substitution, variables and redirection can't be checked", ErrDenied)
+	}
+	for _, part := range split(command) {
+		name, _, _ := strings.Cut(part, " ")
+		if !plainWord.MatchString(name) {
+			return fmt.Errorf("%w: %q must be run by a plain command name, without a path, quotes or escapes", ErrDenied, part)
+		}
+		if wrappers[name] {
+			return fmt.Errorf("%w: %q runs another command, which can't be checked", ErrDenied, part)
+		}
+		for _, pattern := range p.Deny {
+			if matches(pattern, part) {
+				return fmt.Errorf("%w: %q is denied by %q", ErrDenied, part, pattern)
+			}
+		}
+		allowed := false
+		for _, pattern := range p.Allow {
+			if matches(pattern, part) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("%w: %q isn't in the allowlist", ErrDenied, part)
+		}
+	}
+	return nil
+}
+
+var separators = regexp.MustCompile(`&&|\|\||[;|&\n]`)
+
+// split returns the simple commands of a pipeline or list, without
+// environment assignments before them
+func split(command string) []string {
+	var parts []string
+	for _, part := range separators.Split(command, -1) {
+		fields := strings.Fields(part)
+		for len(fields) > 0 && strings.Contains(fields[0], "=") && !strings.HasPrefix(fields[0], "=") {
+			fields = fields[1:]
+		}
+		if len(fields) > 0 {
+			parts = append(parts, strings.Join(fields, " "))
+		}
+	}
+	return parts
+}
+
+// matches reports whether command matches the glob pattern, where * matches
+// anything. A pattern without * also matches the command with arguments
+// added, so "go test" matches "go test ./...".
+func matches(pattern, command string) bool {
+	pattern = strings.Join(strings.Fields(pattern), " ")
+	if pattern == "" {
+		return false
+	}
+	expr := strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*")
+	if !strings.Contains(pattern, "*") {
+		expr += "( .*)?"
+	}
+	return regexp.MustCompile("^" + expr + "$").MatchString(command)
+}
+
+// Result is the outcome of running a command
+type Result struct {
+	Command  string        `json:"command"`
+	Output   string        `json:"output"` // Combined stdout and stderr
+	ExitCode int           `json:"exit_code"`
+	Duration time.Duration `json:"duration"`
+	TimedOut bool          `json:"timed_out,omitempty"`
+}
+
+// Run runs command through the default shell in dir, with env (KEY=value
+// pairs) added to the environment. A command that fails or times out isn't
+// an error; that's in the result. Run doesn't check the policy.
+func Run(ctx context.Context, dir string, env []string, command string, timeout time.Duration) (*Result, error) {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	argv := platform.DefaultShell().Command(command)
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Dir = dir
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	var output bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &output, &output
+	// Don't wait on children holding the output open after a timeout
+	cmd.WaitDelay = time.Second
+
+	start := time.Now()
+	err := cmd.Run()
+	result := &Result{
+		Command:  command,
+		Output:   truncate(output.String()),
+		Duration: time.Since(start),
+		TimedOut: ctx.Err() == context.DeadlineExceeded,
+	}
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+	case result.TimedOut:
+		result.ExitCode = -1
+	default:
+		return nil, fmt.Errorf("failed to run %q: %w", command, err)
+	}
+	return result, nil
+}
+
+// Message formats the result to send back to the model
+func (r *Result) Message() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "I ran `%s`", r.Command)
+	switch {
+	case r.TimedOut:
+		fmt.Fprintf(&sb, "; it timed out after %s", r.Duration.Round(time.Second))
+	default:
+		fmt.Fprintf(&sb, "; it exited with status %d", r.ExitCode)
+	}
+	output := strings.TrimRight(r.Output, "\n")
+	if output == "" {
+		sb.WriteString(" and printed nothing.")
+		return sb.String()
+	}
+	sb.WriteString(". Output:\n\n```\n" + output + "\n```")
+	return sb.String()
+}
+
+// truncate cuts the middle of output longer than MaxOutputLength
+func truncate(output string) string {
+	if len(output) <= MaxOutputLength {
+		return output
+	}
+	half := MaxOutputLength / 2
+	cut := strings.Count(output[half:len(output)-half], "\n")
+	return fmt.Sprintf("%s\n\n... [%d lines cut] ...\n\n%s", output[:half], cut, output[len(output)-half:])
+}
@@ -0,0 +1,101 @@
+package shellcmd
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExtract(t *testing.T) {
+	response := "Run the tests:\n\n```run\ngo test ./...\n```\n\nThen format:\n```go\nfmt.Println()\n```\n```run\ngofmt -l .\n```\n"
+	commands := Extract(response)
+	if len(commands) != 2 || commands[0] != "go test ./..." || commands[1] != "gofmt -l ." {
+		t.Errorf("Extract = %q", commands)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	p := Policy{Allow: []string{"go test", "gofmt *", "git status"}, Deny: append(DefaultDeny, "go test -exec*")}
+	for command, allowed := range map[string]bool{
+		"go test ./...":               true,
+		"go test":                     true,
+		"gofmt -l . && git status":    true,
+		"CGO_ENABLED=0 go test ./x":   true,
+		"go testify":                  false,
+		"go test -exec sh ./...":      false,
+		"go test ./... && curl x.com": false,
+		"git status; sudo reboot":     false,
+		"go test $(rm -rf ~)":         false,
+		"make":                        false,
+	} {
+		err := p.Check(command)
+		if (err == nil) != allowed {
+			t.Errorf("Check(%q) = %v, want allowed %v", command, err, allowed)
+		}
+		if err != nil && !errors.Is(err, ErrDenied) {
+			t.Errorf("Check(%q) = %v, not ErrDenied", command, err)
+		}
+	}
+
+	// Without an allowlist in the config, the built-in one applies, and what
+	// runs another command or is named by a path is refused whatever's allowed
+	p = NewPolicy(nil)
+	for command, allowed := range map[string]bool{
+		"go test ./... && gofmt -l .": true,
+		"git status":                  true,
+		"make lint | tee out.txt":     false,
+		"git push origin main":        false,
+		"git -C . push":               false,
+		"echo $(curl x)":              false,
+		"go test `curl x`":            false,
+		"go test ./... > out.txt":     false,
+		"/usr/bin/curl x":             false,
+		"./curl x":                    false,
+		"\\curl x":                    false,
+		"'curl' x":                    false,
+		"bash -c 'curl x'":            false,
+		"sh -c ls":                    false,
+		"env curl x":                  false,
+		"ls | xargs curl":             false,
+		"go test -exec sh ./...":      false,
+	} {
+		err := p.Check(command)
+		if (err == nil) != allowed {
+			t.Errorf("NewPolicy(nil).Check(%q) = %v, want allowed %v", command, err, allowed)
+		}
+	}
+	p.Allow = []string{"*"}
+	for _, command := range []string{"bash -c 'curl x'", "env curl x", "/usr/bin/curl x", "echo $(curl x)"} {
+		if err := p.Check(command); err == nil {
+			t.Errorf("Check(%q) with everything allowed = nil", command)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shell syntax")
+	}
+	dir := t.TempDir()
+	result, err := Run(context.Background(), dir, []string{"GREETING=hi"}, "echo $GREETING; exit 3", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ExitCode != 3 || strings.TrimSpace(result.Output) != "hi" {
+		t.Errorf("result = %+v", result)
+	}
+	if msg := result.Message(); !strings.Contains(msg, "exited with status 3") || !strings.Contains(msg, "```\nhi\n```") {
+		t.Errorf("Message = %q", msg)
+	}
+
+	result, err = Run(context.Background(), dir, nil, "sleep 5", 100*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.TimedOut || !strings.Contains(result.Message(), "timed out") {
+		t.Errorf("result = %+v", result)
+	}
+}
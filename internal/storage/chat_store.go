@@ -9,6 +9,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/entrepeneur4lyf/codeforge/internal/atrest"
 	_ "github.com/tursodatabase/go-libsql"
 )
 
@@ -26,6 +27,7 @@ type ChatStore interface {
 	GetMessages(ctx context.Context, sessionID string, limit, offset int) (*MessageBatch, error)
 	GetLatestMessages(ctx context.Context, sessionID string, limit int) ([]Message, error)
 	DeleteMessage(ctx context.Context, id string) error
+	SetMessageFeedback(ctx context.Context, messageID string, feedback *Feedback) error
 	
 	// Context snapshots
 	SaveContextSnapshot(ctx context.Context, snapshot *ContextSnapshot) error
@@ -43,7 +45,8 @@ type ChatStore interface {
 
 // SQLiteChatStore implements ChatStore using SQLite/libsql
 type SQLiteChatStore struct {
-	db *sql.DB
+	db     *sql.DB
+	cipher *atrest.Cipher // nil unless at-rest encryption is enabled
 }
 
 // NewDefaultChatStore creates a new chat store using the default user directory
@@ -75,7 +78,13 @@ func NewChatStore(dbPath string) (ChatStore, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	store := &SQLiteChatStore{db: db}
+	cipher, err := atrest.ForDatabase(dbPath)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open chat database encryption: %w", err)
+	}
+
+	store := &SQLiteChatStore{db: db, cipher: cipher}
 
 	// Initialize schema
 	if err := store.initSchema(); err != nil {
@@ -116,12 +125,19 @@ func (s *SQLiteChatStore) CreateSession(ctx context.Context, session *Session) e
 		}
 		metadataJSON = string(metadataBytes)
 	}
+	title, err := s.seal(session.Title)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt session: %w", err)
+	}
+	if metadataJSON, err = s.seal(metadataJSON); err != nil {
+		return fmt.Errorf("failed to encrypt session: %w", err)
+	}
 
 	query := `INSERT INTO sessions (id, user_id, title, model, created_at, updated_at, message_count, total_tokens, metadata)
 	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
 	
-	_, err := s.db.ExecContext(ctx, query,
-		session.ID, session.UserID, session.Title, session.Model,
+	_, err = s.db.ExecContext(ctx, query,
+		session.ID, session.UserID, title, session.Model,
 		session.CreatedAt, session.UpdatedAt, session.MessageCount, session.TotalTokens, metadataJSON)
 	
 	if err != nil {
@@ -152,9 +168,10 @@ func (s *SQLiteChatStore) GetSession(ctx context.Context, id string) (*Session,
 	}
 
 	session.UserID = userID.String
+	session.Title = s.unseal(session.Title)
 	
-	if metadataJSON.Valid && metadataJSON.String != "" {
-		if err := json.Unmarshal([]byte(metadataJSON.String), &session.Metadata); err != nil {
+	if metadata := s.unsealJSON(metadataJSON.String); metadata != "" {
+		if err := json.Unmarshal([]byte(metadata), &session.Metadata); err != nil {
 			log.Printf("Warning: failed to unmarshal session metadata: %v", err)
 		}
 	}
@@ -195,7 +212,8 @@ func (s *SQLiteChatStore) ListSessions(ctx context.Context, userID string, limit
 			return nil, fmt.Errorf("failed to scan session: %w", err)
 		}
 
-		session.LastMessage = lastMessage.String
+		session.Title = s.unseal(session.Title)
+		session.LastMessage = s.unseal(lastMessage.String)
 		sessions = append(sessions, &session)
 	}
 
@@ -212,10 +230,17 @@ func (s *SQLiteChatStore) UpdateSession(ctx context.Context, session *Session) e
 		}
 		metadataJSON = string(metadataBytes)
 	}
+	title, err := s.seal(session.Title)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt session: %w", err)
+	}
+	if metadataJSON, err = s.seal(metadataJSON); err != nil {
+		return fmt.Errorf("failed to encrypt session: %w", err)
+	}
 
 	query := `UPDATE sessions SET title = ?, model = ?, updated_at = ?, metadata = ? WHERE id = ?`
 	
-	_, err := s.db.ExecContext(ctx, query, session.Title, session.Model, session.UpdatedAt, metadataJSON, session.ID)
+	_, err = s.db.ExecContext(ctx, query, title, session.Model, session.UpdatedAt, metadataJSON, session.ID)
 	if err != nil {
 		return fmt.Errorf("failed to update session: %w", err)
 	}
@@ -246,12 +271,19 @@ func (s *SQLiteChatStore) SaveMessage(ctx context.Context, message *Message) err
 		}
 		metadataJSON = string(metadataBytes)
 	}
+	content, err := s.seal(message.Content)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt message: %w", err)
+	}
+	if metadataJSON, err = s.seal(metadataJSON); err != nil {
+		return fmt.Errorf("failed to encrypt message: %w", err)
+	}
 
 	query := `INSERT INTO messages (id, session_id, role, content, tokens, created_at, metadata)
 	          VALUES (?, ?, ?, ?, ?, ?, ?)`
 	
-	_, err := s.db.ExecContext(ctx, query,
-		message.ID, message.SessionID, message.Role, message.Content,
+	_, err = s.db.ExecContext(ctx, query,
+		message.ID, message.SessionID, message.Role, content,
 		message.Tokens, message.CreatedAt, metadataJSON)
 	
 	if err != nil {
@@ -293,9 +325,10 @@ func (s *SQLiteChatStore) GetMessages(ctx context.Context, sessionID string, lim
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan message: %w", err)
 		}
+		message.Content = s.unseal(message.Content)
 
-		if metadataJSON.Valid && metadataJSON.String != "" {
-			if err := json.Unmarshal([]byte(metadataJSON.String), &message.Metadata); err != nil {
+		if metadata := s.unsealJSON(metadataJSON.String); metadata != "" {
+			if err := json.Unmarshal([]byte(metadata), &message.Metadata); err != nil {
 				log.Printf("Warning: failed to unmarshal message metadata: %v", err)
 			}
 		}
@@ -339,9 +372,10 @@ func (s *SQLiteChatStore) GetLatestMessages(ctx context.Context, sessionID strin
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan message: %w", err)
 		}
+		message.Content = s.unseal(message.Content)
 
-		if metadataJSON.Valid && metadataJSON.String != "" {
-			if err := json.Unmarshal([]byte(metadataJSON.String), &message.Metadata); err != nil {
+		if metadata := s.unsealJSON(metadataJSON.String); metadata != "" {
+			if err := json.Unmarshal([]byte(metadata), &message.Metadata); err != nil {
 				log.Printf("Warning: failed to unmarshal message metadata: %v", err)
 			}
 		}
@@ -370,6 +404,45 @@ func (s *SQLiteChatStore) DeleteMessage(ctx context.Context, id string) error {
 	return nil
 }
 
+// SetMessageFeedback stores a rating in a message's metadata, replacing any
+// earlier rating
+func (s *SQLiteChatStore) SetMessageFeedback(ctx context.Context, messageID string, feedback *Feedback) error {
+	var metadataJSON sql.NullString
+	err := s.db.QueryRowContext(ctx, `SELECT metadata FROM messages WHERE id = ?`, messageID).Scan(&metadataJSON)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("message not found: %s", messageID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get message: %w", err)
+	}
+
+	stored, err := s.cipher.Decrypt(metadataJSON.String)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt metadata: %w", err)
+	}
+	metadata := map[string]interface{}{}
+	if stored != "" {
+		if err := json.Unmarshal([]byte(stored), &metadata); err != nil {
+			return fmt.Errorf("failed to unmarshal metadata: %w", err)
+		}
+	}
+	metadata[feedbackKey] = feedback
+
+	metadataBytes, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+	sealed, err := s.seal(string(metadataBytes))
+	if err != nil {
+		return fmt.Errorf("failed to encrypt metadata: %w", err)
+	}
+	if _, err := s.db.ExecContext(ctx, `UPDATE messages SET metadata = ? WHERE id = ?`, sealed, messageID); err != nil {
+		return fmt.Errorf("failed to save feedback: %w", err)
+	}
+
+	return nil
+}
+
 // SaveContextSnapshot saves a context processing snapshot
 func (s *SQLiteChatStore) SaveContextSnapshot(ctx context.Context, snapshot *ContextSnapshot) error {
 	contextBytes, err := json.Marshal(snapshot.ProcessedContext)
@@ -381,13 +454,17 @@ func (s *SQLiteChatStore) SaveContextSnapshot(ctx context.Context, snapshot *Con
 	if err != nil {
 		return fmt.Errorf("failed to marshal processing steps: %w", err)
 	}
+	contextJSON, err := s.seal(string(contextBytes))
+	if err != nil {
+		return fmt.Errorf("failed to encrypt processed context: %w", err)
+	}
 
 	query := `INSERT INTO context_snapshots 
 	          (id, session_id, processed_context, original_tokens, final_tokens, compression_ratio, model_id, processing_steps, created_at)
 	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
 	
 	_, err = s.db.ExecContext(ctx, query,
-		snapshot.ID, snapshot.SessionID, string(contextBytes),
+		snapshot.ID, snapshot.SessionID, contextJSON,
 		snapshot.OriginalTokens, snapshot.FinalTokens, snapshot.CompressionRatio,
 		snapshot.ModelID, string(stepsBytes), snapshot.CreatedAt)
 	
@@ -421,6 +498,9 @@ func (s *SQLiteChatStore) GetLatestContextSnapshot(ctx context.Context, sessionI
 	}
 
 	// Unmarshal processed context
+	if contextJSON, err = s.cipher.Decrypt(contextJSON); err != nil {
+		return nil, fmt.Errorf("failed to decrypt processed context: %w", err)
+	}
 	if err := json.Unmarshal([]byte(contextJSON), &snapshot.ProcessedContext); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal processed context: %w", err)
 	}
@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"log"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/atrest"
+)
+
+// lockedPlaceholder stands in for values that can't be decrypted
+const lockedPlaceholder = "[encrypted]"
+
+// seal encrypts a value before it's written, when encryption is enabled
+func (s *SQLiteChatStore) seal(value string) (string, error) {
+	if value == "" {
+		return value, nil
+	}
+	return s.cipher.Encrypt(value)
+}
+
+// unseal decrypts a stored value. Values that can't be decrypted, because
+// encryption was turned off or the key changed, read as a placeholder.
+func (s *SQLiteChatStore) unseal(value string) string {
+	plain, err := s.cipher.Decrypt(value)
+	if err != nil {
+		log.Printf("Warning: %v", err)
+		return lockedPlaceholder
+	}
+	return plain
+}
+
+// unsealJSON decrypts a stored JSON column, returning "" when it can't be
+// decrypted so callers skip it
+func (s *SQLiteChatStore) unsealJSON(value string) string {
+	if value == "" || !atrest.IsEncrypted(value) {
+		return value
+	}
+	plain, err := s.cipher.Decrypt(value)
+	if err != nil {
+		log.Printf("Warning: %v", err)
+		return ""
+	}
+	return plain
+}
@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// feedbackKey is the message metadata key holding its Feedback
+const feedbackKey = "feedback"
+
+// feedbackSearchLimit is how many recent messages are searched for the last
+// assistant message
+const feedbackSearchLimit = 50
+
+// MessageFeedback returns the rating stored with a message, or nil when it
+// hasn't been rated
+func MessageFeedback(msg Message) *Feedback {
+	switch v := msg.Metadata[feedbackKey].(type) {
+	case *Feedback:
+		return v
+	case map[string]interface{}:
+		// Metadata read back from the database
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil
+		}
+		var feedback Feedback
+		if err := json.Unmarshal(data, &feedback); err != nil || feedback.Rating == "" {
+			return nil
+		}
+		return &feedback
+	}
+	return nil
+}
+
+// LastAssistantMessage returns the most recent assistant message of a session
+func LastAssistantMessage(ctx context.Context, store ChatStore, sessionID string) (*Message, error) {
+	messages, err := store.GetLatestMessages(ctx, sessionID, feedbackSearchLimit)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "assistant" {
+			return &messages[i], nil
+		}
+	}
+	return nil, fmt.Errorf("session %s has no assistant message", sessionID)
+}
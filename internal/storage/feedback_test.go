@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// latestStore is a ChatStore returning fixed latest messages
+type latestStore struct {
+	ChatStore
+	messages []Message
+}
+
+func (l *latestStore) GetLatestMessages(ctx context.Context, sessionID string, limit int) ([]Message, error) {
+	return l.messages, nil
+}
+
+func TestMessageFeedback(t *testing.T) {
+	feedback := &Feedback{Rating: FeedbackBad, Reason: "wrong file", Model: "gpt-4.1", CreatedAt: time.Now().UTC()}
+
+	// As written, and as read back from the metadata column
+	data, err := json.Marshal(map[string]interface{}{"client": "cli", feedbackKey: feedback})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		metadata map[string]interface{}
+		want     *Feedback
+	}{
+		{"unrated", map[string]interface{}{"client": "cli"}, nil},
+		{"no metadata", nil, nil},
+		{"in memory", map[string]interface{}{feedbackKey: feedback}, feedback},
+		{"from database", decoded, feedback},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MessageFeedback(Message{Metadata: tt.metadata})
+			if (got == nil) != (tt.want == nil) {
+				t.Fatalf("MessageFeedback() = %+v, want %+v", got, tt.want)
+			}
+			if got != nil && (got.Rating != tt.want.Rating || got.Reason != tt.want.Reason || got.Model != tt.want.Model || !got.CreatedAt.Equal(tt.want.CreatedAt)) {
+				t.Errorf("MessageFeedback() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLastAssistantMessage(t *testing.T) {
+	store := &latestStore{messages: []Message{
+		{ID: "1", Role: "user"},
+		{ID: "2", Role: "assistant"},
+		{ID: "3", Role: "user"},
+		{ID: "4", Role: "assistant"},
+		{ID: "5", Role: "user"},
+	}}
+
+	msg, err := LastAssistantMessage(context.Background(), store, "s1")
+	if err != nil || msg.ID != "4" {
+		t.Fatalf("LastAssistantMessage() = %+v, %v; want message 4", msg, err)
+	}
+
+	store.messages = store.messages[:1]
+	if _, err := LastAssistantMessage(context.Background(), store, "s1"); err == nil {
+		t.Error("LastAssistantMessage() succeeded without an assistant message")
+	}
+}
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+)
+
+// followerPageSize is how many messages a Follower reads per query
+const followerPageSize = 200
+
+// followerOverlap is how many already-read messages a poll re-reads, so
+// messages written concurrently with slightly earlier timestamps aren't missed
+const followerOverlap = 20
+
+// Follower polls a persisted session for messages written by other clients,
+// so a conversation can continue in the terminal and the web UI at once
+type Follower struct {
+	store     ChatStore
+	sessionID string
+	offset    int
+	seen      map[string]bool
+}
+
+// NewFollower creates a follower for a session. The first Poll returns the
+// whole history unless it was marked seen.
+func NewFollower(store ChatStore, sessionID string) *Follower {
+	return &Follower{
+		store:     store,
+		sessionID: sessionID,
+		seen:      make(map[string]bool),
+	}
+}
+
+// SessionID returns the followed session
+func (f *Follower) SessionID() string {
+	return f.sessionID
+}
+
+// MarkSeen records messages this client wrote or already shows
+func (f *Follower) MarkSeen(ids ...string) {
+	for _, id := range ids {
+		f.seen[id] = true
+	}
+}
+
+// Poll returns messages not seen before, oldest first
+func (f *Follower) Poll(ctx context.Context) ([]Message, error) {
+	start := f.offset - followerOverlap
+	if start < 0 {
+		start = 0
+	}
+
+	var unseen []Message
+	for {
+		batch, err := f.store.GetMessages(ctx, f.sessionID, followerPageSize, start)
+		if err != nil {
+			return nil, err
+		}
+		for _, msg := range batch.Messages {
+			if !f.seen[msg.ID] {
+				f.seen[msg.ID] = true
+				unseen = append(unseen, msg)
+			}
+		}
+		start += len(batch.Messages)
+		if !batch.HasMore || len(batch.Messages) == 0 {
+			break
+		}
+	}
+
+	f.offset = start
+	return unseen, nil
+}
+
+// MessageClient returns the client that wrote a message ("cli", "web", ...),
+// or "" when it wasn't recorded
+func MessageClient(msg Message) string {
+	client, _ := msg.Metadata["client"].(string)
+	return client
+}
@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+// memoryStore is a ChatStore holding the messages of one session
+type memoryStore struct {
+	ChatStore
+	messages []Message
+}
+
+func (m *memoryStore) GetMessages(ctx context.Context, sessionID string, limit, offset int) (*MessageBatch, error) {
+	if offset > len(m.messages) {
+		offset = len(m.messages)
+	}
+	end := offset + limit
+	if end > len(m.messages) {
+		end = len(m.messages)
+	}
+	return &MessageBatch{
+		Messages:   m.messages[offset:end],
+		TotalCount: len(m.messages),
+		HasMore:    end < len(m.messages),
+	}, nil
+}
+
+func (m *memoryStore) add(id, client string) {
+	m.messages = append(m.messages, Message{ID: id, Metadata: map[string]interface{}{"client": client}})
+}
+
+func TestFollowerPoll(t *testing.T) {
+	store := &memoryStore{}
+	for i := 0; i < followerPageSize+5; i++ {
+		store.add(fmt.Sprintf("old-%d", i), "web")
+	}
+
+	follower := NewFollower(store, "s1")
+	history, err := follower.Poll(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != followerPageSize+5 {
+		t.Fatalf("Expected full history across pages, got %d messages", len(history))
+	}
+
+	store.add("own", "cli")
+	follower.MarkSeen("own")
+	store.add("new", "web")
+
+	unseen, err := follower.Poll(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unseen) != 1 || unseen[0].ID != "new" || MessageClient(unseen[0]) != "web" {
+		t.Errorf("Expected only the new web message, got %+v", unseen)
+	}
+
+	if unseen, _ := follower.Poll(context.Background()); len(unseen) != 0 {
+		t.Errorf("Expected nothing new, got %d messages", len(unseen))
+	}
+}
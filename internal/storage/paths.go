@@ -99,6 +99,19 @@ func (pm *PathManager) GetCacheDir() (string, error) {
 	return cacheDir, nil
 }
 
+// GetLocksDir returns the directory for session lock files
+func (pm *PathManager) GetLocksDir() (string, error) {
+	dir, err := pm.GetCodeForgeDir()
+	if err != nil {
+		return "", err
+	}
+	locksDir := filepath.Join(dir, "locks")
+	if err := os.MkdirAll(locksDir, 0755); err != nil {
+		return "", err
+	}
+	return locksDir, nil
+}
+
 // GetMCPConfigDir returns the directory for MCP server configurations
 func (pm *PathManager) GetMCPConfigDir() (string, error) {
 	dir, err := pm.GetCodeForgeDir()
@@ -0,0 +1,168 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	sessionLockHeartbeat = 15 * time.Second // How often a held lock is refreshed
+	sessionLockStale     = time.Minute      // A lock not refreshed for this long was left by a crashed client
+)
+
+// ErrSessionBusy is returned when another client is generating a response in
+// the session
+var ErrSessionBusy = errors.New("session is busy")
+
+// SessionBusyError reports which client holds a session's lock
+type SessionBusyError struct {
+	Client string
+	Since  time.Time
+}
+
+func (e *SessionBusyError) Error() string {
+	if e.Client == "" {
+		return "another client is generating a response in this session"
+	}
+	return fmt.Sprintf("%s client is generating a response in this session", e.Client)
+}
+
+// Is makes errors.Is(err, ErrSessionBusy) match
+func (e *SessionBusyError) Is(target error) bool {
+	return target == ErrSessionBusy
+}
+
+// sessionLockInfo is written to a lock file to show who holds it
+type sessionLockInfo struct {
+	Client  string    `json:"client"`
+	PID     int       `json:"pid"`
+	Started time.Time `json:"started"`
+}
+
+// SessionLock lets one client at a time generate a response in a session,
+// across the API server and terminals, so messages stay in order and each
+// exchange is counted once. Locks are files in the CodeForge directory that
+// the holder refreshes, so a crashed client's lock expires on its own.
+type SessionLock struct {
+	path string
+	stop chan struct{}
+	once sync.Once
+}
+
+// LockSession takes the generation lock of a session for client, such as
+// "cli" or "api". It returns a *SessionBusyError when another client holds it.
+func LockSession(sessionID, client string) (*SessionLock, error) {
+	dir, err := NewPathManager().GetLocksDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get locks directory: %w", err)
+	}
+	return lockSessionIn(dir, sessionID, client)
+}
+
+func lockSessionIn(dir, sessionID, client string) (*SessionLock, error) {
+	sum := sha256.Sum256([]byte(sessionID))
+	path := filepath.Join(dir, "session-"+hex.EncodeToString(sum[:16])+".lock")
+
+	data, err := json.Marshal(sessionLockInfo{Client: client, PID: os.Getpid(), Started: time.Now()})
+	if err != nil {
+		return nil, err
+	}
+
+	// A second attempt is made after clearing a stale lock
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err == nil {
+			_, werr := f.Write(data)
+			if cerr := f.Close(); werr == nil {
+				werr = cerr
+			}
+			if werr != nil {
+				os.Remove(path)
+				return nil, fmt.Errorf("failed to write session lock: %w", werr)
+			}
+			lock := &SessionLock{path: path, stop: make(chan struct{})}
+			go lock.heartbeat()
+			return lock, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to create session lock: %w", err)
+		}
+
+		stat, err := os.Stat(path)
+		if err != nil {
+			continue // Released in the meantime
+		}
+		if time.Since(stat.ModTime()) > sessionLockStale {
+			clearStaleLock(path, stat)
+			continue
+		}
+
+		busy := &SessionBusyError{Since: stat.ModTime()}
+		if raw, err := os.ReadFile(path); err == nil {
+			var info sessionLockInfo
+			if json.Unmarshal(raw, &info) == nil {
+				busy.Client, busy.Since = info.Client, info.Started
+			}
+		}
+		return nil, busy
+	}
+	return nil, &SessionBusyError{}
+}
+
+// clearStaleLock removes the lock file at path if it's still the stale one
+// described by stale. Another client may have cleared it and taken the lock
+// since, so clearing is done by one client at a time, holding a guard file,
+// and a lock is only removed while the guard shows it unchanged: no client
+// can replace a stale lock without the guard, and live ones never go stale.
+func clearStaleLock(path string, stale os.FileInfo) {
+	guard := path + ".clear"
+	f, err := os.OpenFile(guard, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		// Another client is clearing it, unless it crashed while doing so
+		info, err := os.Stat(guard)
+		if err != nil || time.Since(info.ModTime()) <= sessionLockStale {
+			return
+		}
+		os.Remove(guard)
+		if f, err = os.OpenFile(guard, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600); err != nil {
+			return
+		}
+	}
+	f.Close()
+	defer os.Remove(guard)
+
+	info, err := os.Stat(path)
+	if err == nil && os.SameFile(info, stale) && info.ModTime().Equal(stale.ModTime()) {
+		os.Remove(path)
+	}
+}
+
+// heartbeat keeps the lock from looking stale while it's held
+func (l *SessionLock) heartbeat() {
+	ticker := time.NewTicker(sessionLockHeartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			now := time.Now()
+			os.Chtimes(l.path, now, now)
+		case <-l.stop:
+			return
+		}
+	}
+}
+
+// Release gives up the lock. It's safe to call more than once.
+func (l *SessionLock) Release() {
+	l.once.Do(func() {
+		close(l.stop)
+		os.Remove(l.path)
+	})
+}
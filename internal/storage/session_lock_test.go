@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestLockSession(t *testing.T) {
+	dir := t.TempDir()
+
+	lock, err := lockSessionIn(dir, "session-1", "cli")
+	if err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+
+	_, err = lockSessionIn(dir, "session-1", "web")
+	var busy *SessionBusyError
+	if !errors.As(err, &busy) || !errors.Is(err, ErrSessionBusy) {
+		t.Fatalf("second lock: got %v, want a busy error", err)
+	}
+	if busy.Client != "cli" {
+		t.Errorf("holder = %q, want cli", busy.Client)
+	}
+
+	// Other sessions aren't affected
+	other, err := lockSessionIn(dir, "session-2", "web")
+	if err != nil {
+		t.Fatalf("lock other session: %v", err)
+	}
+	other.Release()
+
+	lock.Release()
+	lock.Release()
+	again, err := lockSessionIn(dir, "session-1", "web")
+	if err != nil {
+		t.Fatalf("lock after release: %v", err)
+	}
+
+	// A lock that stopped being refreshed was left by a crashed client
+	again.Release()
+	crashed, err := lockSessionIn(dir, "session-1", "cli")
+	if err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	close(crashed.stop)
+	old := time.Now().Add(-2 * sessionLockStale)
+	if err := os.Chtimes(crashed.path, old, old); err != nil {
+		t.Fatal(err)
+	}
+	stale, err := os.Stat(crashed.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	taken, err := lockSessionIn(dir, "session-1", "web")
+	if err != nil {
+		t.Fatalf("lock over stale lock: %v", err)
+	}
+
+	// A client that saw the same stale lock mustn't clear the new one
+	clearStaleLock(taken.path, stale)
+	if _, err := lockSessionIn(dir, "session-1", "api"); !errors.Is(err, ErrSessionBusy) {
+		t.Fatalf("lock after a late stale clear: got %v, want a busy error", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("lock directory has %d entries, want only the lock", len(entries))
+	}
+	taken.Release()
+
+	// Only the client holding the clearing guard clears a stale lock
+	crashed, err = lockSessionIn(dir, "session-1", "cli")
+	if err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	close(crashed.stop)
+	if err := os.Chtimes(crashed.path, old, old); err != nil {
+		t.Fatal(err)
+	}
+	guard := crashed.path + ".clear"
+	if err := os.WriteFile(guard, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := lockSessionIn(dir, "session-1", "web"); !errors.Is(err, ErrSessionBusy) {
+		t.Fatalf("lock while another client clears: got %v, want a busy error", err)
+	}
+
+	// A guard left by a client that crashed while clearing expires too
+	if err := os.Chtimes(guard, old, old); err != nil {
+		t.Fatal(err)
+	}
+	taken, err = lockSessionIn(dir, "session-1", "web")
+	if err != nil {
+		t.Fatalf("lock once the guard expired: %v", err)
+	}
+	taken.Release()
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("lock directory has %d entries after release, want none", len(entries))
+	}
+}
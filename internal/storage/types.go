@@ -28,6 +28,21 @@ type Message struct {
 	Metadata  map[string]interface{} `json:"metadata,omitempty"`
 }
 
+// Feedback ratings
+const (
+	FeedbackGood = "good"
+	FeedbackBad  = "bad"
+)
+
+// Feedback is a user's rating of an assistant message, kept for mining
+// evaluation sets and deciding which models to route to
+type Feedback struct {
+	Rating    string    `json:"rating"` // FeedbackGood or FeedbackBad
+	Reason    string    `json:"reason,omitempty"`
+	Model     string    `json:"model,omitempty"` // Model that wrote the message
+	CreatedAt time.Time `json:"created_at"`
+}
+
 // ContextSnapshot represents a stored context processing result
 type ContextSnapshot struct {
 	ID               string                 `json:"id"`
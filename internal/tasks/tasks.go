@@ -0,0 +1,181 @@
+// Package tasks extracts the actionable work agreed on in a conversation,
+// so long exploratory chats end with a concrete plan
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+)
+
+// maxTranscriptChars caps the conversation sent for extraction; the end of
+// a long conversation is kept, as that's where plans are settled
+const maxTranscriptChars = 100000
+
+const extractPrompt = `You turn a conversation between a developer and a coding assistant into a plan. List the concrete, actionable tasks that remain to be done: changes agreed on, bugs found, follow-ups and open questions to resolve. Leave out work the conversation shows as already done, and ideas that were rejected.
+
+Reply with a JSON array only, without markdown fences, where each task is an object with:
+- "title": a short imperative summary, such as "Retry failed uploads in the sync worker"
+- "details": one to three sentences of what to do and why, using what the conversation established
+- "files": the file paths the task touches, as mentioned in the conversation; empty when none are known
+
+Reply with [] when there are no tasks.`
+
+const planPrompt = `You plan coding work for an assistant that carries it out one step at a time, editing files in the developer's project. Break the developer's goal down into the fewest steps that each make one coherent change, in the order they should be done.
+
+Reply with a JSON array only, without markdown fences, where each step is an object with:
+- "title": a short imperative summary, such as "Add a Retry option to the upload config"
+- "details": one to three sentences of what the step changes and why
+- "files": the paths of the existing files the step reads or changes, and of new files it creates, relative to the project root`
+
+// Task is one piece of work to do
+type Task struct {
+	Title   string   `json:"title"`
+	Details string   `json:"details"`
+	Files   []string `json:"files"`
+}
+
+// Transcript formats the text of a conversation for extraction, keeping
+// its end when it is too long
+func Transcript(messages []llm.Message) string {
+	var sb strings.Builder
+	for _, msg := range messages {
+		var text []string
+		for _, block := range msg.Content {
+			if t, ok := block.(llm.TextBlock); ok && strings.TrimSpace(t.Text) != "" {
+				text = append(text, strings.TrimSpace(t.Text))
+			}
+		}
+		if len(text) == 0 {
+			continue
+		}
+		role := "Assistant"
+		if msg.Role == "user" {
+			role = "Developer"
+		}
+		fmt.Fprintf(&sb, "## %s\n%s\n\n", role, strings.Join(text, "\n"))
+	}
+
+	transcript := sb.String()
+	if len(transcript) > maxTranscriptChars {
+		transcript = "[earlier conversation omitted]\n\n" + transcript[len(transcript)-maxTranscriptChars:]
+	}
+	return strings.TrimSpace(transcript)
+}
+
+// Extract asks the model behind handler for the tasks in a transcript
+func Extract(ctx context.Context, handler llm.ApiHandler, transcript string) ([]Task, error) {
+	if transcript == "" {
+		return nil, nil
+	}
+	tasks, err := ask(ctx, handler, extractPrompt, transcript)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract tasks: %w", err)
+	}
+	return tasks, nil
+}
+
+// Plan asks the model behind handler to break a goal down into tasks, done
+// in order. The goal may be followed by what the model needs to know, such
+// as the project's files.
+func Plan(ctx context.Context, handler llm.ApiHandler, goal string) ([]Task, error) {
+	tasks, err := ask(ctx, handler, planPrompt, goal)
+	if err != nil {
+		return nil, fmt.Errorf("failed to plan: %w", err)
+	}
+	if len(tasks) == 0 {
+		return nil, fmt.Errorf("failed to plan: the model returned no steps")
+	}
+	return tasks, nil
+}
+
+// ask sends text to the model with a prompt asking for a task list
+func ask(ctx context.Context, handler llm.ApiHandler, systemPrompt, text string) ([]Task, error) {
+	messages := []llm.Message{{
+		Role:    "user",
+		Content: []llm.ContentBlock{llm.TextBlock{Text: text}},
+	}}
+	stream, err := handler.CreateMessage(ctx, systemPrompt, messages)
+	if err != nil {
+		return nil, err
+	}
+
+	var reply strings.Builder
+	for chunk := range stream {
+		if text, ok := chunk.(llm.ApiStreamTextChunk); ok {
+			reply.WriteString(text.Text)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return parse(reply.String())
+}
+
+// parse reads the model's JSON reply, tolerating fences and text around
+// the array
+func parse(reply string) ([]Task, error) {
+	start, end := strings.Index(reply, "["), strings.LastIndex(reply, "]")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("the model didn't reply with a task list")
+	}
+
+	var parsed []Task
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &parsed); err != nil {
+		return nil, fmt.Errorf("the model's task list is invalid: %w", err)
+	}
+	tasks := parsed[:0]
+	for _, task := range parsed {
+		task.Title = strings.TrimSpace(task.Title)
+		if task.Title != "" {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks, nil
+}
+
+// Checklist formats tasks as a Markdown checklist
+func Checklist(tasks []Task) string {
+	var sb strings.Builder
+	sb.WriteString("# Tasks\n\n")
+	for _, task := range tasks {
+		fmt.Fprintf(&sb, "- [ ] **%s**", task.Title)
+		if len(task.Files) > 0 {
+			fmt.Fprintf(&sb, " (%s)", codeList(task.Files))
+		}
+		sb.WriteString("\n")
+		if task.Details != "" {
+			fmt.Fprintf(&sb, "  %s\n", strings.ReplaceAll(strings.TrimSpace(task.Details), "\n", "\n  "))
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// IssueDrafts formats tasks as issues ready to be filed, one per task
+func IssueDrafts(tasks []Task) string {
+	drafts := make([]string, len(tasks))
+	for i, task := range tasks {
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "## %s\n\n", task.Title)
+		if task.Details != "" {
+			fmt.Fprintf(&sb, "%s\n", strings.TrimSpace(task.Details))
+		}
+		if len(task.Files) > 0 {
+			fmt.Fprintf(&sb, "\nFiles: %s\n", codeList(task.Files))
+		}
+		drafts[i] = strings.TrimRight(sb.String(), "\n")
+	}
+	return strings.Join(drafts, "\n\n---\n\n")
+}
+
+// codeList formats paths as inline code, separated by commas
+func codeList(paths []string) string {
+	quoted := make([]string, len(paths))
+	for i, p := range paths {
+		quoted[i] = "`" + p + "`"
+	}
+	return strings.Join(quoted, ", ")
+}
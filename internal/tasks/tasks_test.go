@@ -0,0 +1,86 @@
+package tasks
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+)
+
+// replyHandler answers every request with a fixed reply, keeping the
+// transcript it was sent
+type replyHandler struct {
+	reply string
+	sent  string
+}
+
+func (h *replyHandler) CreateMessage(_ context.Context, _ string, messages []llm.Message) (llm.ApiStream, error) {
+	h.sent = messages[0].Content[0].(llm.TextBlock).Text
+	ch := make(chan llm.ApiStreamChunk, 1)
+	ch <- llm.ApiStreamTextChunk{Text: h.reply}
+	close(ch)
+	return ch, nil
+}
+
+func (h *replyHandler) GetModel() llm.ModelResponse {
+	return llm.ModelResponse{ID: "test/model"}
+}
+
+func (h *replyHandler) GetApiStreamUsage() (*llm.ApiStreamUsageChunk, error) {
+	return nil, nil
+}
+
+func TestExtract(t *testing.T) {
+	messages := []llm.Message{
+		{Role: "user", Content: []llm.ContentBlock{llm.TextBlock{Text: "Why do uploads fail on flaky networks?"}}},
+		{Role: "assistant", Content: []llm.ContentBlock{llm.TextBlock{Text: "sync/worker.go never retries."}}},
+	}
+	transcript := Transcript(messages)
+	if transcript != "## Developer\nWhy do uploads fail on flaky networks?\n\n## Assistant\nsync/worker.go never retries." {
+		t.Errorf("Transcript = %q", transcript)
+	}
+
+	h := &replyHandler{reply: "Here is the plan:\n```json\n" + `[
+  {"title": "Retry failed uploads", "details": "Back off exponentially.", "files": ["sync/worker.go"]},
+  {"title": "  ", "details": "dropped"},
+  {"title": "Add a flaky network test"}
+]` + "\n```"}
+	found, err := Extract(context.Background(), h, transcript)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.sent != transcript {
+		t.Errorf("sent %q", h.sent)
+	}
+	if len(found) != 2 || found[0].Files[0] != "sync/worker.go" || found[1].Title != "Add a flaky network test" {
+		t.Fatalf("tasks = %+v", found)
+	}
+
+	checklist := Checklist(found)
+	want := "# Tasks\n\n- [ ] **Retry failed uploads** (`sync/worker.go`)\n  Back off exponentially.\n- [ ] **Add a flaky network test**"
+	if checklist != want {
+		t.Errorf("Checklist =\n%s\nwant\n%s", checklist, want)
+	}
+
+	drafts := IssueDrafts(found)
+	if !strings.HasPrefix(drafts, "## Retry failed uploads\n\nBack off exponentially.\n\nFiles: `sync/worker.go`\n\n---\n\n## Add a flaky network test") {
+		t.Errorf("IssueDrafts =\n%s", drafts)
+	}
+
+	if _, err := Extract(context.Background(), &replyHandler{reply: "Nothing to do."}, transcript); err == nil {
+		t.Error("reply without a task list was accepted")
+	}
+}
+
+func TestTranscriptKeepsTheEnd(t *testing.T) {
+	long := strings.Repeat("x", maxTranscriptChars)
+	messages := []llm.Message{
+		{Role: "user", Content: []llm.ContentBlock{llm.TextBlock{Text: "first " + long}}},
+		{Role: "user", Content: []llm.ContentBlock{llm.TextBlock{Text: "last"}}},
+	}
+	transcript := Transcript(messages)
+	if strings.Contains(transcript, "first") || !strings.HasSuffix(transcript, "last") || !strings.HasPrefix(transcript, "[earlier conversation omitted]") {
+		t.Errorf("long transcript not cut at the start: %q...", transcript[:60])
+	}
+}
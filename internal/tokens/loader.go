@@ -0,0 +1,107 @@
+package tokens
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkoukk/tiktoken-go"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+	"github.com/entrepeneur4lyf/codeforge/internal/httpclient"
+)
+
+// bpeLoader loads BPE ranks from the CodeForge cache, downloading them
+// through the configured proxy when allowed. Downloads are never made in
+// local-only mode.
+type bpeLoader struct{}
+
+// allowDownload permits the loader to fetch missing rank files. It is set
+// by encoder while holding encodersMu.
+var allowDownload bool
+
+func init() {
+	tiktoken.SetBpeLoader(bpeLoader{})
+}
+
+// bpeCacheDir returns where downloaded BPE rank files are kept
+func bpeCacheDir() string {
+	if dir := os.Getenv("TIKTOKEN_CACHE_DIR"); dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "codeforge-tiktoken")
+	}
+	return filepath.Join(home, ".codeforge", "cache", "tiktoken")
+}
+
+func (bpeLoader) LoadTiktokenBpe(source string) (map[string]int, error) {
+	cachePath := filepath.Join(bpeCacheDir(), path.Base(source))
+
+	data, err := os.ReadFile(cachePath)
+	if err != nil {
+		if !allowDownload {
+			return nil, fmt.Errorf("tokenizer %s is not cached", path.Base(source))
+		}
+		if config.IsLocalOnly() {
+			return nil, config.LocalOnlyError("downloading tokenizer " + path.Base(source))
+		}
+		if data, err = downloadBpe(source); err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(filepath.Dir(cachePath), 0o755); err == nil {
+			tmp := cachePath + ".tmp"
+			if os.WriteFile(tmp, data, 0o644) == nil {
+				_ = os.Rename(tmp, cachePath)
+			}
+		}
+	}
+
+	return parseBpe(data)
+}
+
+// downloadBpe fetches a BPE rank file
+func downloadBpe(source string) ([]byte, error) {
+	resp, err := httpclient.New("openai", 30*time.Second).Get(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download tokenizer: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download tokenizer: HTTP %d", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// parseBpe parses "<base64 token> <rank>" lines
+func parseBpe(data []byte) (map[string]int, error) {
+	ranks := make(map[string]int)
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" {
+			continue
+		}
+		token, rank, ok := strings.Cut(line, " ")
+		if !ok {
+			return nil, fmt.Errorf("invalid tokenizer line %q", line)
+		}
+		decoded, err := base64.StdEncoding.DecodeString(token)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tokenizer token: %w", err)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(rank))
+		if err != nil {
+			return nil, fmt.Errorf("invalid tokenizer rank: %w", err)
+		}
+		ranks[string(decoded)] = n
+	}
+	return ranks, nil
+}
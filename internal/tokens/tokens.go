@@ -0,0 +1,226 @@
+package tokens
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+	"github.com/pkoukk/tiktoken-go"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/httpclient"
+)
+
+// Method is how a token count was produced
+type Method string
+
+const (
+	MethodBPE       Method = "bpe"       // Exact count from the model's BPE tokenizer
+	MethodAPI       Method = "api"       // Exact count from the provider's counting API
+	MethodHeuristic Method = "heuristic" // Estimate from character and word statistics
+)
+
+// Result is a token count with the method that produced it
+type Result struct {
+	Count    int    `json:"count"`
+	Model    string `json:"model"`
+	Method   Method `json:"method"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// Family is a group of models that share a tokenizer
+type Family string
+
+const (
+	FamilyOpenAI    Family = "openai"
+	FamilyAnthropic Family = "anthropic"
+	FamilyGemini    Family = "gemini"
+	FamilyGeneric   Family = "generic"
+)
+
+// FamilyOf returns the tokenizer family of a model. Provider prefixes such as
+// "openai/" or "anthropic/" are honoured.
+func FamilyOf(model string) Family {
+	m := strings.ToLower(model)
+	if i := strings.LastIndex(m, "/"); i >= 0 {
+		switch m[:i] {
+		case "openai":
+			return FamilyOpenAI
+		case "anthropic":
+			return FamilyAnthropic
+		case "google", "gemini":
+			return FamilyGemini
+		}
+		m = m[i+1:]
+	}
+
+	switch {
+	case strings.HasPrefix(m, "gpt-") || strings.HasPrefix(m, "o1") || strings.HasPrefix(m, "o3") ||
+		strings.HasPrefix(m, "o4") || strings.HasPrefix(m, "text-embedding-") || strings.HasPrefix(m, "chatgpt-"):
+		return FamilyOpenAI
+	case strings.Contains(m, "claude"):
+		return FamilyAnthropic
+	case strings.Contains(m, "gemini") || strings.Contains(m, "gemma"):
+		return FamilyGemini
+	default:
+		return FamilyGeneric
+	}
+}
+
+// encodingFor returns the tiktoken encoding name for an OpenAI model
+func encodingFor(model string) string {
+	m := strings.ToLower(model)
+	if i := strings.LastIndex(m, "/"); i >= 0 {
+		m = m[i+1:]
+	}
+	if enc, ok := tiktoken.MODEL_TO_ENCODING[m]; ok {
+		return enc
+	}
+	for prefix, enc := range tiktoken.MODEL_PREFIX_TO_ENCODING {
+		if strings.HasPrefix(m, prefix) {
+			return enc
+		}
+	}
+	// Newer OpenAI models use o200k
+	if strings.HasPrefix(m, "gpt-4.1") || strings.HasPrefix(m, "gpt-5") || strings.HasPrefix(m, "o3") || strings.HasPrefix(m, "o4") {
+		return tiktoken.MODEL_O200K_BASE
+	}
+	return tiktoken.MODEL_CL100K_BASE
+}
+
+var (
+	encodersMu sync.Mutex
+	encoders   = make(map[string]*tiktoken.Tiktoken)
+	failed     = make(map[string]bool)
+)
+
+// encoder returns a cached BPE encoder, or nil when its ranks aren't
+// available. Rank files are only downloaded when download is set, so counting
+// on hot paths never waits on the network.
+func encoder(name string, download bool) *tiktoken.Tiktoken {
+	encodersMu.Lock()
+	defer encodersMu.Unlock()
+
+	if enc, ok := encoders[name]; ok {
+		return enc
+	}
+	if failed[name] && !download {
+		return nil
+	}
+
+	allowDownload = download
+	enc, err := tiktoken.GetEncoding(name)
+	allowDownload = false
+	if err != nil {
+		failed[name] = true
+		return nil
+	}
+	delete(failed, name)
+	encoders[name] = enc
+	return enc
+}
+
+// Warm makes the BPE tokenizer for a model available, downloading its ranks
+// if they aren't cached. It reports whether exact counting is available.
+func Warm(model string) bool {
+	if FamilyOf(model) != FamilyOpenAI {
+		return false
+	}
+	return encoder(encodingFor(model), true) != nil
+}
+
+// Count returns the number of tokens in text for a model. OpenAI models are
+// counted with their BPE tokenizer when its ranks are available; other models
+// and offline fallbacks use per-family heuristics.
+func Count(text, model string) Result {
+	family := FamilyOf(model)
+	if family == FamilyOpenAI {
+		name := encodingFor(model)
+		if enc := encoder(name, false); enc != nil {
+			return Result{
+				Count:    len(enc.Encode(text, nil, nil)),
+				Model:    model,
+				Method:   MethodBPE,
+				Encoding: name,
+			}
+		}
+	}
+	return Result{Count: Estimate(text, family), Model: model, Method: MethodHeuristic}
+}
+
+// CountAnthropic counts tokens with Anthropic's token counting API, which is
+// exact for Claude models but requires network access and an API key
+func CountAnthropic(ctx context.Context, apiKey, model, system, text string) (Result, error) {
+	if apiKey == "" {
+		return Result{}, fmt.Errorf("anthropic token counting requires ANTHROPIC_API_KEY")
+	}
+
+	client := anthropic.NewClient(
+		option.WithAPIKey(apiKey),
+		option.WithHTTPClient(httpclient.New("anthropic", 0)),
+	)
+
+	params := anthropic.MessageCountTokensParams{
+		Model:    anthropic.Model(strings.TrimPrefix(model, "anthropic/")),
+		Messages: []anthropic.MessageParam{anthropic.NewUserMessage(anthropic.NewTextBlock(text))},
+	}
+	if system != "" {
+		params.System = anthropic.MessageCountTokensParamsSystemUnion{OfString: anthropic.String(system)}
+	}
+
+	resp, err := client.Messages.CountTokens(ctx, params)
+	if err != nil {
+		return Result{}, fmt.Errorf("anthropic token counting failed: %w", err)
+	}
+	return Result{Count: int(resp.InputTokens), Model: model, Method: MethodAPI}, nil
+}
+
+var (
+	whitespace   = regexp.MustCompile(`\s+`)
+	specialChars = regexp.MustCompile(`[{}[\]().,;:!?'"<>]`)
+	codeFences   = regexp.MustCompile("```")
+	punctuation  = regexp.MustCompile(`[.,;:!?(){}[\]"']`)
+)
+
+// Estimate approximates the token count of text for a tokenizer family
+func Estimate(text string, family Family) int {
+	if family == FamilyGeneric {
+		return estimateGeneric(text)
+	}
+
+	text = strings.TrimSpace(whitespace.ReplaceAllString(text, " "))
+	charCount := utf8.RuneCountInString(text)
+	if charCount == 0 {
+		return 0
+	}
+
+	var count int
+	switch family {
+	case FamilyOpenAI:
+		// ~4 characters per token, plus extra for punctuation-heavy text
+		count = charCount/4 + len(specialChars.FindAllString(text, -1))/2
+	case FamilyAnthropic:
+		// ~3.5 characters per token, plus code fence overhead
+		count = charCount*10/35 + len(codeFences.FindAllString(text, -1))*2
+	default:
+		count = charCount / 4
+	}
+
+	if count == 0 {
+		count = 1
+	}
+	return count
+}
+
+// estimateGeneric counts words and punctuation
+func estimateGeneric(text string) int {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return 0
+	}
+	return len(strings.Fields(text)) + len(punctuation.FindAllString(text, -1))/3
+}
@@ -0,0 +1,70 @@
+package tokens
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFamilyOf(t *testing.T) {
+	tests := map[string]Family{
+		"gpt-4o":                      FamilyOpenAI,
+		"openai/gpt-4.1-mini":         FamilyOpenAI,
+		"o3-mini":                     FamilyOpenAI,
+		"claude-sonnet-4-20250514":    FamilyAnthropic,
+		"anthropic/claude-3.5-sonnet": FamilyAnthropic,
+		"gemini-2.0-flash":            FamilyGemini,
+		"ollama/llama3.2":             FamilyGeneric,
+	}
+	for model, want := range tests {
+		if got := FamilyOf(model); got != want {
+			t.Errorf("FamilyOf(%q) = %s, want %s", model, got, want)
+		}
+	}
+}
+
+func TestCountFallsBackToEstimate(t *testing.T) {
+	// Point the cache at an empty directory so no tokenizer is available
+	t.Setenv("TIKTOKEN_CACHE_DIR", t.TempDir())
+
+	result := Count("The quick brown fox jumps over the lazy dog.", "claude-sonnet-4-20250514")
+	if result.Method != MethodHeuristic || result.Count == 0 {
+		t.Errorf("Expected heuristic count, got %+v", result)
+	}
+
+	if got := Estimate("", FamilyOpenAI); got != 0 {
+		t.Errorf("Expected 0 tokens for empty text, got %d", got)
+	}
+}
+
+func TestParseBpe(t *testing.T) {
+	// "YQ==" is "a", "Yg==" is "b"
+	ranks, err := parseBpe([]byte("YQ== 0\nYg== 1\n"))
+	if err != nil {
+		t.Fatalf("parseBpe failed: %v", err)
+	}
+	if ranks["a"] != 0 || ranks["b"] != 1 {
+		t.Errorf("Unexpected ranks %v", ranks)
+	}
+
+	if _, err := parseBpe([]byte("not-a-line")); err == nil {
+		t.Error("Expected error for malformed line")
+	}
+}
+
+func TestLoaderUsesCache(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("TIKTOKEN_CACHE_DIR", dir)
+	if err := os.WriteFile(filepath.Join(dir, "test.tiktoken"), []byte("YQ== 0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ranks, err := bpeLoader{}.LoadTiktokenBpe("https://example.invalid/test.tiktoken")
+	if err != nil || ranks["a"] != 0 {
+		t.Errorf("Expected cached ranks, got %v (%v)", ranks, err)
+	}
+
+	if _, err := (bpeLoader{}).LoadTiktokenBpe("https://example.invalid/missing.tiktoken"); err == nil {
+		t.Error("Expected error for uncached tokenizer without download permission")
+	}
+}
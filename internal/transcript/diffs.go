@@ -0,0 +1,48 @@
+package transcript
+
+import (
+	"encoding/json"
+
+	"github.com/aymanbagabas/go-udiff"
+	"github.com/entrepeneur4lyf/codeforge/internal/storage"
+)
+
+// FileDiff is a change a file tool call made, as a unified diff
+type FileDiff struct {
+	Tool string `json:"tool"`
+	Path string `json:"path,omitempty"` // Empty for patches, which name their files
+	Diff string `json:"diff"`
+}
+
+// MessageDiffs returns the file changes of a message's edit, write and patch
+// tool calls. Writes show as new files, since what they replaced isn't
+// recorded.
+func MessageDiffs(msg storage.Message) []FileDiff {
+	var diffs []FileDiff
+	for _, call := range MessageToolCalls(msg) {
+		var args struct {
+			FilePath  string `json:"file_path"`
+			OldString string `json:"old_string"`
+			NewString string `json:"new_string"`
+			Content   string `json:"content"`
+			PatchText string `json:"patch_text"`
+		}
+		if json.Unmarshal([]byte(call.Arguments), &args) != nil {
+			continue
+		}
+
+		d := FileDiff{Tool: call.Name, Path: args.FilePath}
+		switch call.Name {
+		case "edit":
+			d.Diff = udiff.Unified("a/"+args.FilePath, "b/"+args.FilePath, args.OldString, args.NewString)
+		case "write":
+			d.Diff = udiff.Unified("/dev/null", "b/"+args.FilePath, "", args.Content)
+		case "patch":
+			d.Diff = args.PatchText
+		}
+		if d.Diff != "" {
+			diffs = append(diffs, d)
+		}
+	}
+	return diffs
+}
@@ -0,0 +1,216 @@
+package transcript
+
+import (
+	"bytes"
+	"html/template"
+	"io"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+)
+
+// highlightStyle is the chroma style of code in HTML exports
+const highlightStyle = "github"
+
+// htmlMessage is a message prepared for the HTML template
+type htmlMessage struct {
+	Role      string
+	RoleName  string
+	Model     string
+	Time      string
+	Body      template.HTML
+	ToolCalls []htmlToolCall
+	Footnote  int // Number of the message's cost footnote, 0 for none
+}
+
+type htmlToolCall struct {
+	Name      string
+	Arguments template.HTML
+}
+
+type htmlFootnote struct {
+	Number int
+	Text   string
+}
+
+// writeHTML writes the transcript as a standalone HTML page with highlighted
+// code, collapsible tool calls and cost footnotes
+func writeHTML(w io.Writer, t Transcript) error {
+	hl := newHighlighter()
+	md := goldmark.New(
+		goldmark.WithExtensions(extension.GFM),
+		goldmark.WithRendererOptions(renderer.WithNodeRenderers(util.Prioritized(hl, 200))),
+	)
+
+	var messages []htmlMessage
+	var notes []htmlFootnote
+	for _, msg := range t.Messages {
+		var body bytes.Buffer
+		if err := md.Convert([]byte(msg.Content), &body); err != nil {
+			return err
+		}
+
+		m := htmlMessage{
+			Role:     msg.Role,
+			RoleName: roleName(msg.Role),
+			Model:    MessageModel(msg),
+			Time:     formatTime(msg.CreatedAt),
+			Body:     template.HTML(body.String()), // goldmark escapes raw HTML in messages
+		}
+		for _, call := range MessageToolCalls(msg) {
+			m.ToolCalls = append(m.ToolCalls, htmlToolCall{
+				Name:      call.Name,
+				Arguments: hl.highlight(prettyArguments(call.Arguments), "json"),
+			})
+		}
+		if u, ok := MessageUsage(msg); ok {
+			notes = append(notes, htmlFootnote{Number: len(notes) + 1, Text: footnote(msg, u)})
+			m.Footnote = len(notes)
+		}
+		messages = append(messages, m)
+	}
+
+	var css bytes.Buffer
+	if err := hl.formatter.WriteCSS(&css, hl.style); err != nil {
+		return err
+	}
+
+	data := struct {
+		Title     string
+		Model     string
+		Started   string
+		CSS       template.CSS
+		Messages  []htmlMessage
+		Footnotes []htmlFootnote
+		Total     string
+	}{
+		Title:     t.Title(),
+		CSS:       template.CSS(css.String()),
+		Messages:  messages,
+		Footnotes: notes,
+	}
+	if t.Session != nil {
+		data.Model = t.Session.Model
+		data.Started = formatTime(t.Session.CreatedAt)
+	}
+	if len(notes) > 0 {
+		data.Total = t.TotalUsage().String()
+	}
+	return htmlTemplate.Execute(w, data)
+}
+
+// highlighter renders fenced code blocks with chroma
+type highlighter struct {
+	formatter *chromahtml.Formatter
+	style     *chroma.Style
+}
+
+func newHighlighter() *highlighter {
+	return &highlighter{
+		formatter: chromahtml.New(chromahtml.WithClasses(true)),
+		style:     styles.Get(highlightStyle),
+	}
+}
+
+// RegisterFuncs replaces goldmark's rendering of fenced code blocks
+func (h *highlighter) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindFencedCodeBlock, h.renderFencedCode)
+}
+
+func (h *highlighter) renderFencedCode(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	block := node.(*ast.FencedCodeBlock)
+
+	var code strings.Builder
+	lines := block.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		segment := lines.At(i)
+		code.Write(segment.Value(source))
+	}
+
+	_, err := w.WriteString(string(h.highlight(code.String(), string(block.Language(source)))))
+	return ast.WalkSkipChildren, err
+}
+
+// highlight renders code in language as HTML, escaped and unhighlighted when
+// the language is unknown
+func (h *highlighter) highlight(code, language string) template.HTML {
+	lexer := lexers.Get(language)
+	if lexer == nil {
+		lexer = lexers.Analyse(code)
+	}
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+
+	iterator, err := chroma.Coalesce(lexer).Tokenise(nil, code)
+	if err == nil {
+		var out bytes.Buffer
+		if err := h.formatter.Format(&out, h.style, iterator); err == nil {
+			return template.HTML(out.String())
+		}
+	}
+	return template.HTML("<pre>" + template.HTMLEscapeString(code) + "</pre>")
+}
+
+var htmlTemplate = template.Must(template.New("transcript").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; max-width: 860px; margin: 2rem auto; padding: 0 1rem; color: #1f2328; line-height: 1.5; }
+header { border-bottom: 1px solid #d0d7de; margin-bottom: 1.5rem; }
+header p { color: #59636e; margin-top: 0; }
+.message { border: 1px solid #d0d7de; border-radius: 6px; margin: 1rem 0; padding: 0 1rem; }
+.message.user { background: #f6f8fa; }
+.meta { color: #59636e; font-size: 0.85rem; margin: 0.75rem 0 0; }
+.meta .role { color: #1f2328; font-weight: 600; margin-right: 0.5rem; }
+.meta sup a { text-decoration: none; }
+pre { overflow-x: auto; padding: 0.75rem; border-radius: 6px; font-size: 0.85rem; }
+code { font-family: ui-monospace, SFMono-Regular, Menlo, Consolas, monospace; }
+details { margin: 0.75rem 0; border: 1px solid #d0d7de; border-radius: 6px; padding: 0.25rem 0.75rem; }
+summary { cursor: pointer; font-size: 0.9rem; }
+.footnotes { border-top: 1px solid #d0d7de; margin-top: 2rem; font-size: 0.85rem; color: #59636e; }
+{{.CSS}}
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Title}}</h1>
+<p>{{if .Model}}{{.Model}}{{end}}{{if and .Model .Started}} · {{end}}{{if .Started}}Started {{.Started}}{{end}}</p>
+</header>
+{{range .Messages}}
+<section class="message {{.Role}}">
+<p class="meta"><span class="role">{{.RoleName}}</span>{{if .Model}}{{.Model}} {{end}}{{.Time}}{{if .Footnote}} <sup id="ref-{{.Footnote}}"><a href="#note-{{.Footnote}}">[{{.Footnote}}]</a></sup>{{end}}</p>
+{{.Body}}
+{{range .ToolCalls}}
+<details>
+<summary>Tool call: <code>{{.Name}}</code></summary>
+{{.Arguments}}
+</details>
+{{end}}
+</section>
+{{end}}
+{{if .Footnotes}}
+<section class="footnotes">
+<ol>
+{{range .Footnotes}}<li id="note-{{.Number}}">{{.Text}} <a href="#ref-{{.Number}}">↩</a></li>
+{{end}}</ol>
+<p>Total: {{.Total}}</p>
+</section>
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,53 @@
+package transcript
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// writeMarkdown writes the transcript as Markdown, with tool calls as JSON
+// blocks and costs as footnotes
+func writeMarkdown(w io.Writer, t Transcript) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", t.Title())
+	if t.Session != nil && t.Session.Model != "" {
+		fmt.Fprintf(&b, "Model: %s  \n", t.Session.Model)
+	}
+	if t.Session != nil && !t.Session.CreatedAt.IsZero() {
+		fmt.Fprintf(&b, "Started: %s\n", formatTime(t.Session.CreatedAt))
+	}
+	b.WriteString("\n")
+
+	var notes []string
+	for _, msg := range t.Messages {
+		fmt.Fprintf(&b, "## %s", roleName(msg.Role))
+		if when := formatTime(msg.CreatedAt); when != "" {
+			fmt.Fprintf(&b, " · %s", when)
+		}
+		if u, ok := MessageUsage(msg); ok {
+			notes = append(notes, footnote(msg, u))
+			fmt.Fprintf(&b, " [^%d]", len(notes))
+		}
+		b.WriteString("\n\n")
+
+		if content := strings.TrimSpace(msg.Content); content != "" {
+			b.WriteString(content)
+			b.WriteString("\n\n")
+		}
+		for _, call := range MessageToolCalls(msg) {
+			fmt.Fprintf(&b, "**Tool call: %s**\n\n```json\n%s\n```\n\n", call.Name, prettyArguments(call.Arguments))
+		}
+	}
+
+	if len(notes) > 0 {
+		b.WriteString("---\n\n")
+		for i, note := range notes {
+			fmt.Fprintf(&b, "[^%d]: %s\n", i+1, note)
+		}
+		fmt.Fprintf(&b, "\nTotal: %s\n", t.TotalUsage())
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,191 @@
+// Package transcript exports saved chat sessions for sharing, as Markdown,
+// JSON or a standalone HTML page.
+package transcript
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/storage"
+)
+
+// Export formats
+const (
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+	FormatJSON     = "json"
+)
+
+// Formats lists the supported export formats
+var Formats = []string{FormatMarkdown, FormatHTML, FormatJSON}
+
+// Transcript is a saved session with its messages, oldest first
+type Transcript struct {
+	Session  *storage.Session  `json:"session"`
+	Messages []storage.Message `json:"messages"`
+}
+
+// ToolCall is a tool call the model made in a message, read from the
+// message's "tool_calls" metadata
+type ToolCall struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// Usage is what a response cost, read from the message's "input_tokens",
+// "output_tokens" and "cost" metadata
+type Usage struct {
+	InputTokens  int
+	OutputTokens int
+	Cost         float64
+}
+
+// Write exports t to w in format
+func Write(w io.Writer, t Transcript, format string) error {
+	switch format {
+	case FormatMarkdown, "md":
+		return writeMarkdown(w, t)
+	case FormatHTML:
+		return writeHTML(w, t)
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(t)
+	default:
+		return fmt.Errorf("unknown format %q; use %s", format, strings.Join(Formats, ", "))
+	}
+}
+
+// Title is the session's title, or a placeholder for untitled sessions
+func (t Transcript) Title() string {
+	if t.Session != nil && t.Session.Title != "" {
+		return t.Session.Title
+	}
+	return "CodeForge session"
+}
+
+// TotalUsage adds up the usage of every message
+func (t Transcript) TotalUsage() Usage {
+	var total Usage
+	for _, msg := range t.Messages {
+		if u, ok := MessageUsage(msg); ok {
+			total.InputTokens += u.InputTokens
+			total.OutputTokens += u.OutputTokens
+			total.Cost += u.Cost
+		}
+	}
+	return total
+}
+
+// MessageUsage returns what a message cost, if that was recorded
+func MessageUsage(msg storage.Message) (Usage, bool) {
+	u := Usage{
+		InputTokens:  metaInt(msg.Metadata, "input_tokens"),
+		OutputTokens: metaInt(msg.Metadata, "output_tokens"),
+	}
+	u.Cost, _ = msg.Metadata["cost"].(float64)
+	if u.InputTokens == 0 && u.OutputTokens == 0 && u.Cost == 0 {
+		return Usage{}, false
+	}
+	return u, true
+}
+
+// MessageToolCalls returns the tool calls recorded with a message
+func MessageToolCalls(msg storage.Message) []ToolCall {
+	raw, ok := msg.Metadata["tool_calls"]
+	if !ok {
+		return nil
+	}
+	// Metadata is decoded from JSON, so go through it again to get the type
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil
+	}
+	var calls []ToolCall
+	if json.Unmarshal(data, &calls) != nil {
+		return nil
+	}
+	return calls
+}
+
+// MessageModel returns the model that wrote a message, if recorded
+func MessageModel(msg storage.Message) string {
+	model, _ := msg.Metadata["model"].(string)
+	return model
+}
+
+// footnote describes what a message cost and which model wrote it
+func footnote(msg storage.Message, u Usage) string {
+	if model := MessageModel(msg); model != "" {
+		return model + ": " + u.String()
+	}
+	return u.String()
+}
+
+// String describes the usage briefly, such as "1,200 in / 350 out tokens, $0.0042"
+func (u Usage) String() string {
+	s := fmt.Sprintf("%s in / %s out tokens", groupDigits(u.InputTokens), groupDigits(u.OutputTokens))
+	if u.Cost > 0 {
+		s += fmt.Sprintf(", $%.4f", u.Cost)
+	}
+	return s
+}
+
+// prettyArguments indents JSON tool arguments, leaving anything else as is
+func prettyArguments(args string) string {
+	var v interface{}
+	if json.Unmarshal([]byte(args), &v) != nil {
+		return args
+	}
+	pretty, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return args
+	}
+	return string(pretty)
+}
+
+func roleName(role string) string {
+	switch role {
+	case "user":
+		return "User"
+	case "assistant":
+		return "Assistant"
+	case "system":
+		return "System"
+	case "tool":
+		return "Tool"
+	}
+	return role
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+func metaInt(meta map[string]interface{}, key string) int {
+	switch v := meta[key].(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	}
+	return 0
+}
+
+func groupDigits(n int) string {
+	s := fmt.Sprint(n)
+	if n < 0 {
+		return s
+	}
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
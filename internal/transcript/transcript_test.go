@@ -0,0 +1,102 @@
+package transcript
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/storage"
+)
+
+func testTranscript() Transcript {
+	created := time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC)
+	return Transcript{
+		Session: &storage.Session{ID: "s1", Title: "Fix the flaky test", Model: "gpt-4o", CreatedAt: created},
+		Messages: []storage.Message{
+			{ID: "m1", Role: "user", Content: "Why does <b>TestRun</b> fail?", CreatedAt: created},
+			{
+				ID:        "m2",
+				Role:      "assistant",
+				Content:   "It races on the map:\n\n```go\nfunc main() {}\n```",
+				CreatedAt: created.Add(time.Minute),
+				Metadata: map[string]interface{}{
+					"model":         "gpt-4o",
+					"input_tokens":  float64(1200),
+					"output_tokens": float64(350),
+					"cost":          0.0042,
+					"tool_calls": []interface{}{
+						map[string]interface{}{"id": "call_1", "name": "view", "arguments": `{"file_path":"run_test.go"}`},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, testTranscript(), FormatMarkdown); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"# Fix the flaky test",
+		"## Assistant",
+		"[^1]",
+		"**Tool call: view**",
+		"\"file_path\": \"run_test.go\"",
+		"[^1]: gpt-4o: 1,200 in / 350 out tokens, $0.0042",
+		"Total: 1,200 in / 350 out tokens, $0.0042",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("markdown missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, testTranscript(), FormatHTML); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"<title>Fix the flaky test</title>",
+		`class="chroma"`,
+		"<details>",
+		"Tool call: <code>view</code>",
+		`href="#note-1"`,
+		"gpt-4o: 1,200 in / 350 out tokens, $0.0042",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("html missing %q", want)
+		}
+	}
+	if strings.Contains(out, "<b>TestRun</b>") {
+		t.Error("html in a message was not escaped")
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, testTranscript(), FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	var got Transcript
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Session.ID != "s1" || len(got.Messages) != 2 {
+		t.Errorf("round trip = %+v", got)
+	}
+}
+
+func TestWriteUnknownFormat(t *testing.T) {
+	if err := Write(&bytes.Buffer{}, testTranscript(), "pdf"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
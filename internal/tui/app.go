@@ -2,14 +2,23 @@ package tui
 
 import (
 	"fmt"
+	"io"
+	"time"
 
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/entrepeneur4lyf/codeforge/internal/app"
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+	"github.com/entrepeneur4lyf/codeforge/internal/logging"
+	"github.com/entrepeneur4lyf/codeforge/internal/notes"
+	"github.com/entrepeneur4lyf/codeforge/internal/platform"
 	dialog "github.com/entrepeneur4lyf/codeforge/internal/tui/components/dialogs"
+	"github.com/entrepeneur4lyf/codeforge/internal/tui/headless"
+	"github.com/entrepeneur4lyf/codeforge/internal/tui/keymap"
 	"github.com/entrepeneur4lyf/codeforge/internal/tui/layout"
 	"github.com/entrepeneur4lyf/codeforge/internal/tui/page"
+	"github.com/entrepeneur4lyf/codeforge/internal/tui/styles"
 	"github.com/entrepeneur4lyf/codeforge/internal/tui/theme"
 )
 
@@ -35,12 +44,18 @@ type Model struct {
 	helpDialog    tea.Model
 	fileDialog    tea.Model
 	searchDialog  tea.Model
+	notesDialog   tea.Model
+	keysDialog    tea.Model
+	apiKeyDialog  tea.Model
 	
 	// Dialog states
 	showModelDialog  bool
 	showHelpDialog   bool
 	showFileDialog   bool
 	showSearchDialog bool
+	showNotesDialog  bool
+	showKeysDialog   bool
+	showAPIKeyDialog bool
 	searchType       dialog.SearchType
 	
 	// Current session
@@ -48,6 +63,9 @@ type Model struct {
 	
 	// Error state
 	err           error
+
+	// keymapErr is why the user keymap file wasn't applied
+	keymapErr error
 }
 
 func New(application *app.App) *Model {
@@ -87,12 +105,27 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.chatModel = newModel
 			cmds = append(cmds, cmd)
 		}
+		if m.notesDialog != nil {
+			m.notesDialog, _ = m.notesDialog.Update(msg)
+		}
+		if m.keysDialog != nil {
+			m.keysDialog, _ = m.keysDialog.Update(msg)
+		}
+		if m.apiKeyDialog != nil {
+			m.apiKeyDialog, _ = m.apiKeyDialog.Update(msg)
+		}
+		if m.modelDialog != nil {
+			m.modelDialog, _ = m.modelDialog.Update(msg)
+		}
+		if m.searchDialog != nil {
+			m.searchDialog, _ = m.searchDialog.Update(msg)
+		}
 		
 		return m, tea.Batch(cmds...)
 		
 	case tea.KeyMsg:
 		// Check if any dialog is open
-		if m.showModelDialog || m.showHelpDialog || m.showFileDialog {
+		if m.dialogOpen() {
 			return m.updateDialog(msg)
 		}
 		
@@ -114,18 +147,26 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 			
 		case key.Matches(msg, keys.FileSearch):
-			m.searchType = dialog.FileSearch
-			m.searchDialog = dialog.NewSearchDialog(m.theme, dialog.FileSearch)
-			m.showSearchDialog = true
+			m.openSearchDialog(dialog.FileSearch)
 			return m, nil
 			
 		case key.Matches(msg, keys.TextSearch):
-			m.searchType = dialog.TextSearch
-			m.searchDialog = dialog.NewSearchDialog(m.theme, dialog.TextSearch)
-			m.showSearchDialog = true
+			m.openSearchDialog(dialog.TextSearch)
 			return m, nil
 		}
 		
+	case tea.MouseMsg:
+		if m.dialogOpen() {
+			return m.updateDialogMouse(msg)
+		}
+		
+	case dialog.SearchResultsMsg:
+		if m.showSearchDialog {
+			newModel, cmd := m.searchDialog.Update(msg)
+			m.searchDialog = newModel
+			return m, cmd
+		}
+		
 	case error:
 		m.err = msg
 		return m, nil
@@ -139,12 +180,37 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 		
+	case dialog.ShowNotesDialogMsg:
+		store := notes.ForProject()
+		if store == nil {
+			m.err = fmt.Errorf("cannot open notes: configuration not loaded")
+			return m, nil
+		}
+		notesDialog := dialog.NewNotesDialog(m.theme, store, m.width, m.height)
+		m.notesDialog = notesDialog
+		m.showNotesDialog = true
+		return m, notesDialog.Init()
+
+	case dialog.ShowKeysDialogMsg:
+		m.keysDialog = dialog.NewKeysDialog(m.theme, keymap.Path(), m.keymapErr, m.width, m.height)
+		m.showKeysDialog = true
+		return m, nil
+
+	case dialog.ShowAPIKeyDialogMsg:
+		apiKeyDialog := dialog.NewAPIKeyDialog(m.theme, msg.Provider, m.width)
+		m.apiKeyDialog = apiKeyDialog
+		m.showAPIKeyDialog = true
+		return m, apiKeyDialog.Init()
+
 	case dialog.DialogCloseMsg:
 		// Close any open dialog
 		m.showModelDialog = false
 		m.showHelpDialog = false
 		m.showFileDialog = false
 		m.showSearchDialog = false
+		m.showNotesDialog = false
+		m.showKeysDialog = false
+		m.showAPIKeyDialog = false
 		return m, nil
 		
 	case dialog.SearchSelectedMsg:
@@ -222,6 +288,33 @@ func (m *Model) View() string {
 			layout.Center,
 		)
 	}
+
+	if m.showNotesDialog {
+		return layout.PlaceOverlay(
+			m.width, m.height,
+			m.notesDialog.View(),
+			styledContent,
+			layout.Center,
+		)
+	}
+
+	if m.showKeysDialog {
+		return layout.PlaceOverlay(
+			m.width, m.height,
+			m.keysDialog.View(),
+			styledContent,
+			layout.Center,
+		)
+	}
+
+	if m.showAPIKeyDialog {
+		return layout.PlaceOverlay(
+			m.width, m.height,
+			m.apiKeyDialog.View(),
+			styledContent,
+			layout.Center,
+		)
+	}
 	
 	// Error overlay
 	if m.err != nil {
@@ -237,6 +330,41 @@ func (m *Model) View() string {
 	return styledContent
 }
 
+// dialogOpen reports whether a dialog is shown over the chat
+func (m *Model) dialogOpen() bool {
+	return m.showModelDialog || m.showHelpDialog || m.showFileDialog ||
+		m.showSearchDialog || m.showNotesDialog || m.showKeysDialog || m.showAPIKeyDialog
+}
+
+// openSearchDialog shows a file or text search dialog sized to the window
+func (m *Model) openSearchDialog(searchType dialog.SearchType) {
+	m.searchType = searchType
+	m.searchDialog = dialog.NewSearchDialog(m.theme, searchType)
+	m.searchDialog, _ = m.searchDialog.Update(tea.WindowSizeMsg{Width: m.width, Height: m.height})
+	m.showSearchDialog = true
+}
+
+// updateDialogMouse routes a mouse event to the open dialog, with
+// coordinates relative to where the dialog is drawn
+func (m *Model) updateDialogMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	var d *tea.Model
+	switch {
+	case m.showModelDialog:
+		d = &m.modelDialog
+	case m.showSearchDialog:
+		d = &m.searchDialog
+	case m.showKeysDialog:
+		d = &m.keysDialog
+	default:
+		return m, nil
+	}
+	
+	x, y := layout.OverlayOrigin(m.width, m.height, (*d).View(), layout.Center)
+	var cmd tea.Cmd
+	*d, cmd = (*d).Update(layout.Translate(msg, x, y))
+	return m, cmd
+}
+
 func (m *Model) updateDialog(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	// ESC closes any dialog
 	if key.Matches(msg, keys.Cancel) {
@@ -244,6 +372,9 @@ func (m *Model) updateDialog(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.showHelpDialog = false
 		m.showFileDialog = false
 		m.showSearchDialog = false
+		m.showNotesDialog = false
+		m.showKeysDialog = false
+		m.showAPIKeyDialog = false
 		return m, nil
 	}
 	
@@ -272,6 +403,24 @@ func (m *Model) updateDialog(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, cmd
 	}
 	
+	if m.showNotesDialog {
+		newModel, cmd := m.notesDialog.Update(msg)
+		m.notesDialog = newModel
+		return m, cmd
+	}
+
+	if m.showKeysDialog {
+		newModel, cmd := m.keysDialog.Update(msg)
+		m.keysDialog = newModel
+		return m, cmd
+	}
+
+	if m.showAPIKeyDialog {
+		newModel, cmd := m.apiKeyDialog.Update(msg)
+		m.apiKeyDialog = newModel
+		return m, cmd
+	}
+	
 	return m, nil
 }
 
@@ -295,21 +444,94 @@ func (m *Model) renderError() string {
 
 // Run starts the TUI application
 func Run(app *app.App) error {
-	model := New(app)
-	
-	p := tea.NewProgram(
-		model,
-		tea.WithAltScreen(),
-		tea.WithMouseCellMotion(),
-	)
-	
+	applyDisplayConfig()
+	return runProgram(newWithKeymap(app), nil)
+}
+
+// Record runs the TUI like Run, writing the keys pressed to w as a script
+// that RunScript or Play can replay
+func Record(app *app.App, w io.Writer) error {
+	applyDisplayConfig()
+	recorder := headless.NewRecorder(newWithKeymap(app), w)
+	err := runProgram(recorder, nil)
+	recorder.Flush()
+	if err == nil {
+		err = recorder.Err()
+	}
+	return err
+}
+
+// Play runs the TUI and replays a script into it, pausing delay between
+// keys, so workflows can be shown in a real terminal. The TUI stays open
+// for the user once the script ends.
+func Play(app *app.App, script io.Reader, delay time.Duration) error {
+	commands, err := headless.ParseScript(script)
+	if err != nil {
+		return err
+	}
+	applyDisplayConfig()
+	return runProgram(newWithKeymap(app), func(p *tea.Program) {
+		headless.Play(p.Send, commands, delay)
+	})
+}
+
+// RunScript drives the TUI headlessly with a script, writing its snapshots
+// to out, for automating and reproducing TUI sessions. See
+// headless.ParseScript for the commands.
+func RunScript(app *app.App, script io.Reader, out io.Writer) error {
+	applyDisplayConfig()
+	d := headless.New(newWithKeymap(app), scriptWidth, scriptHeight)
+	return headless.RunScript(d, script, out)
+}
+
+// applyDisplayConfig applies display settings that styles read when built
+func applyDisplayConfig() {
+	if cfg := config.Get(); cfg != nil {
+		styles.SetASCIIOnly(cfg.Display.ASCIIOnly)
+	}
+}
+
+// runProgram runs model full screen, calling drive, if set, in the
+// background once the program starts
+func runProgram(model tea.Model, drive func(*tea.Program)) error {
+	// Older Windows consoles print raw escape codes unless asked not to
+	if err := platform.EnableANSI(); err != nil {
+		logging.Warn("ANSI colors unavailable in this console", "err", err)
+	}
+
+	options := []tea.ProgramOption{tea.WithAltScreen()}
+	if cfg := config.Get(); cfg == nil || cfg.TUI.Mouse {
+		options = append(options, tea.WithMouseCellMotion())
+	}
+	p := tea.NewProgram(model, options...)
+	if drive != nil {
+		go drive(p)
+	}
+
 	if _, err := p.Run(); err != nil {
 		return fmt.Errorf("error running TUI: %w", err)
 	}
-	
+
 	return nil
 }
 
+// Scripts start at this terminal size; use resize to change it
+const (
+	scriptWidth  = 100
+	scriptHeight = 30
+)
+
+// newWithKeymap creates the TUI model with the user's keymap applied; a bad
+// file keeps the default bindings
+func newWithKeymap(app *app.App) *Model {
+	model := New(app)
+	if err := keymap.Load(keymap.Path()); err != nil {
+		logging.Warn("Keymap not applied", "err", err)
+		model.keymapErr = err
+	}
+	return model
+}
+
 // Key bindings
 type keyMap struct {
 	Quit        key.Binding
@@ -350,4 +572,8 @@ var keys = keyMap{
 		key.WithKeys("esc"),
 		key.WithHelp("esc", "cancel"),
 	),
-}
\ No newline at end of file
+}
+
+func init() {
+	keymap.Register("app", &keys)
+}
@@ -0,0 +1,31 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/app"
+)
+
+// The chat screen shows the configured model, so it's checked with expect
+// rather than a golden file
+func TestRunScript_ChatScreen(t *testing.T) {
+	script := `
+expect "New chat session created"
+expect "Type your message..."
+type hello world
+expect "hello world"
+snapshot typed
+key ctrl+c
+`
+	var out strings.Builder
+	if err := RunScript(&app.App{}, strings.NewReader(script), &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "--- typed (100x30) ---") {
+		t.Errorf("missing snapshot:\n%s", out.String())
+	}
+	if !strings.HasSuffix(out.String(), "--- quit ---\n") {
+		t.Errorf("ctrl+c should quit:\n%s", out.String())
+	}
+}
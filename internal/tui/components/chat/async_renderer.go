@@ -9,6 +9,7 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/entrepeneur4lyf/codeforge/internal/tui/styles"
 	"github.com/entrepeneur4lyf/codeforge/internal/tui/theme"
 )
 
@@ -178,7 +179,7 @@ func (ar *AsyncRenderer) RenderStatus(task *RenderTask) string {
 	
 	// Build status line
 	var status strings.Builder
-	status.WriteString(style.Render(statusIcon))
+	status.WriteString(style.Render(styles.Icon(statusIcon)))
 	status.WriteString(" ")
 	
 	switch task.Status {
@@ -309,7 +310,7 @@ func (ar *AsyncRenderer) renderPlaceholder(task *RenderTask) string {
 		BorderForeground(ar.theme.TextMuted()).
 		Padding(1)
 	
-	content := fmt.Sprintf("⏳ Rendering message...\nID: %s", task.MessageID)
+	content := fmt.Sprintf("%s Rendering message...\nID: %s", styles.Icon("⏳"), task.MessageID)
 	return style.Render(content)
 }
 
@@ -330,6 +331,9 @@ func (ar *AsyncRenderer) renderProgressBar(progress float64, width int) string {
 // getSpinner returns a spinner character based on time
 func (ar *AsyncRenderer) getSpinner(startTime time.Time) string {
 	spinners := []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+	if styles.ASCIIOnly() {
+		spinners = []string{"|", "/", "-", "\\"}
+	}
 	elapsed := time.Since(startTime)
 	index := int(elapsed.Milliseconds()/100) % len(spinners)
 	return spinners[index]
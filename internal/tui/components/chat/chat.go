@@ -24,6 +24,7 @@ type ChatModel struct {
 	cache        *MessageCache
 	markdown     *MarkdownRenderer
 	enableMarkdown bool
+	followUps    []string // Suggested prompts shown under the last answer
 }
 
 // Message represents a chat message
@@ -165,6 +166,7 @@ func (m *ChatModel) StartStreaming() {
 		Role:    "assistant",
 		Content: "",
 	}
+	m.followUps = nil
 	m.addMessage(assistantMsg)
 	m.isStreaming = true
 	m.streamBuffer.Reset()
@@ -223,9 +225,25 @@ func (m *ChatModel) updateViewport() {
 		content.WriteString(rendered)
 	}
 
+	if len(m.followUps) > 0 && !m.isStreaming {
+		content.WriteString("\n\n" + m.renderFollowUps())
+	}
+
 	m.viewport.SetContent(content.String())
 }
 
+// renderFollowUps lists the suggested prompts by the number that sends them
+func (m *ChatModel) renderFollowUps() string {
+	lines := []string{"Follow-ups (send the number to ask):"}
+	for i, prompt := range m.followUps {
+		lines = append(lines, fmt.Sprintf("  %d. %s", i+1, prompt))
+	}
+	return lipgloss.NewStyle().
+		Foreground(m.theme.TextMuted()).
+		Width(m.width).
+		Render(strings.Join(lines, "\n"))
+}
+
 func (m *ChatModel) renderUserMessage(msg Message) string {
 	header := NewBlockRenderer(m.theme,
 		WithTextColor(m.theme.Primary()),
@@ -329,6 +347,7 @@ func (m *ChatModel) SetSize(width, height int) {
 // ClearMessages clears all messages
 func (m *ChatModel) ClearMessages() {
 	m.messages = []Message{}
+	m.followUps = nil
 	m.updateViewport()
 	// Clear cache when clearing messages
 	m.cache.Clear()
@@ -378,6 +397,7 @@ func (m *ChatModel) IsMarkdownEnabled() bool {
 // LoadMessages loads messages for display
 func (m *ChatModel) LoadMessages(messages []Message) {
 	m.messages = messages
+	m.followUps = nil
 	// Clear cache when loading new messages
 	m.cache.Clear()
 	if m.markdown != nil {
@@ -385,4 +405,37 @@ func (m *ChatModel) LoadMessages(messages []Message) {
 	}
 	m.updateViewport()
 	m.viewport.GotoBottom()
-}
\ No newline at end of file
+}
+// LastAssistantContent returns the content of the last assistant message,
+// or "" when there is none
+func (m *ChatModel) LastAssistantContent() string {
+	return m.lastContent("assistant")
+}
+
+// LastUserContent returns the content of the last user message, or "" when
+// there is none
+func (m *ChatModel) LastUserContent() string {
+	return m.lastContent("user")
+}
+
+func (m *ChatModel) lastContent(role string) string {
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if m.messages[i].Role == role {
+			return m.messages[i].Content
+		}
+	}
+	return ""
+}
+
+// SetFollowUps shows suggested prompts under the last answer, replacing
+// any shown before; nil removes them
+func (m *ChatModel) SetFollowUps(prompts []string) {
+	m.followUps = prompts
+	m.updateViewport()
+	m.viewport.GotoBottom()
+}
+
+// FollowUps returns the suggested prompts shown under the last answer
+func (m *ChatModel) FollowUps() []string {
+	return m.followUps
+}
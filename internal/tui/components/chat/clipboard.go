@@ -12,6 +12,8 @@ import (
 	"os"
 	"path/filepath"
 	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/privacy"
 )
 
 // ClipboardImage represents an image from clipboard
@@ -42,6 +44,7 @@ func SaveImageToTemp(img *ClipboardImage) (string, error) {
 	if err := os.WriteFile(filepath, img.Data, 0644); err != nil {
 		return "", fmt.Errorf("failed to save image: %w", err)
 	}
+	privacy.TrackArtifact(filepath)
 	
 	return filepath, nil
 }
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/entrepeneur4lyf/codeforge/internal/tui/styles"
 	"github.com/entrepeneur4lyf/codeforge/internal/tui/theme"
 )
 
@@ -215,7 +216,7 @@ func (r *DiagnosticRenderer) renderHeader(diag Diagnostic) string {
 
 // renderLocation renders file location information
 func (r *DiagnosticRenderer) renderLocation(diag Diagnostic) string {
-	location := fmt.Sprintf("📍 %s", diag.File)
+	location := fmt.Sprintf("%s %s", styles.Icon("📍"), diag.File)
 	if diag.Line > 0 {
 		location += fmt.Sprintf(":%d", diag.Line)
 		if diag.Column > 0 {
@@ -314,7 +315,7 @@ func (r *DiagnosticRenderer) renderSuggestions(suggestions []string) string {
 	headerStyle := lipgloss.NewStyle().
 		Foreground(r.theme.Success()).
 		Bold(true)
-	result.WriteString(headerStyle.Render("💡 Suggestions:"))
+	result.WriteString(headerStyle.Render(styles.Icon("💡") + " Suggestions:"))
 	result.WriteString("\n")
 	
 	for i, suggestion := range suggestions {
@@ -351,15 +352,15 @@ func (r *DiagnosticRenderer) getLevelColor(level DiagnosticLevel) lipgloss.Adapt
 func (r *DiagnosticRenderer) getLevelIcon(level DiagnosticLevel) string {
 	switch level {
 	case DiagnosticError:
-		return "❌"
+		return styles.Icon("❌")
 	case DiagnosticWarning:
-		return "⚠️"
+		return styles.Icon("⚠️")
 	case DiagnosticInfo:
-		return "ℹ️"
+		return styles.Icon("ℹ️")
 	case DiagnosticHint:
-		return "💡"
+		return styles.Icon("💡")
 	default:
-		return "•"
+		return styles.Icon("•")
 	}
 }
 
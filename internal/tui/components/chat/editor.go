@@ -8,6 +8,8 @@ import (
 	"github.com/charmbracelet/bubbles/textarea"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/entrepeneur4lyf/codeforge/internal/tui/keymap"
+	"github.com/entrepeneur4lyf/codeforge/internal/tui/styles"
 	"github.com/entrepeneur4lyf/codeforge/internal/tui/theme"
 )
 
@@ -41,6 +43,11 @@ var editorKeys = EditorKeyMaps{
 	),
 }
 
+func init() {
+	keymap.Register("editor", &editorKeys)
+}
+
+
 func NewEditorModel(th theme.Theme) *EditorModel {
 	ta := textarea.New()
 	ta.Placeholder = "Type your message... (Enter to send, Shift+Enter for new line)"
@@ -96,6 +103,32 @@ func (m *EditorModel) send() tea.Cmd {
 	}
 }
 
+// handlePaste reads the system clipboard and either attaches a pasted image
+// or inserts the pasted text at the cursor
+func (m *EditorModel) handlePaste() tea.Cmd {
+	content, err := clipboard.ReadAll()
+	if err != nil || content == "" {
+		return nil
+	}
+
+	// Images arrive as data URLs; save them to a temp file and attach
+	if IsBase64Image(content) {
+		img, err := DecodeBase64Image(content)
+		if err != nil {
+			return nil
+		}
+		path, err := SaveImageToTemp(img)
+		if err != nil {
+			return nil
+		}
+		m.AddAttachment(path)
+		return nil
+	}
+
+	m.textarea.InsertString(content)
+	return nil
+}
+
 func (m *EditorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 
@@ -160,7 +193,7 @@ func (m *EditorModel) View() string {
 		if idx := strings.LastIndex(path, "/"); idx >= 0 {
 			filename = path[idx+1:]
 		}
-		attachmentLines = append(attachmentLines, "📎 "+filename)
+		attachmentLines = append(attachmentLines, styles.Icon("📎")+" "+filename)
 	}
 	
 	attachmentsView := attachmentStyle.Render(strings.Join(attachmentLines, "\n"))
@@ -10,6 +10,7 @@ import (
 	"github.com/alecthomas/chroma/v2/lexers"
 	"github.com/alecthomas/chroma/v2/styles"
 	"github.com/charmbracelet/lipgloss"
+	tuistyles "github.com/entrepeneur4lyf/codeforge/internal/tui/styles"
 	"github.com/entrepeneur4lyf/codeforge/internal/tui/theme"
 )
 
@@ -90,7 +91,7 @@ func (r *FileRenderer) RenderFile(file FileContent) string {
 // renderFileHeader renders the file path and metadata
 func (r *FileRenderer) renderFileHeader(file FileContent) string {
 	// Create header with file icon and path
-	icon := r.getFileIcon(file.Path)
+	icon := tuistyles.Icon(r.getFileIcon(file.Path))
 	
 	headerStyle := lipgloss.NewStyle().
 		Foreground(r.theme.Primary()).
@@ -429,7 +430,7 @@ func (r *FileRenderer) SetMaxWidth(width int) {
 
 // RenderInline renders a compact file reference
 func (r *FileRenderer) RenderInline(path string, line int) string {
-	icon := r.getFileIcon(path)
+	icon := tuistyles.Icon(r.getFileIcon(path))
 	
 	style := lipgloss.NewStyle().
 		Foreground(r.theme.Primary()).
@@ -6,6 +6,7 @@ import (
 
 	"github.com/charmbracelet/glamour"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/entrepeneur4lyf/codeforge/internal/diagram"
 	"github.com/entrepeneur4lyf/codeforge/internal/tui/styles"
 	"github.com/entrepeneur4lyf/codeforge/internal/tui/theme"
 )
@@ -73,7 +74,7 @@ func (m *MarkdownRenderer) Render(content string) (string, error) {
 	}
 	
 	// Render with glamour
-	rendered, err := m.renderer.Render(content)
+	rendered, err := m.renderer.Render(approximateDiagrams(content))
 	if err != nil {
 		// Fallback to plain text on error
 		return m.renderFallback(content), nil
@@ -88,6 +89,18 @@ func (m *MarkdownRenderer) Render(content string) (string, error) {
 	return rendered, nil
 }
 
+// approximateDiagrams replaces Mermaid and Graphviz blocks, which a terminal
+// can't draw, with text approximations where possible
+func approximateDiagrams(content string) string {
+	return diagram.ReplaceBlocks(content, func(d diagram.Diagram) string {
+		text, ok := diagram.ASCII(d)
+		if !ok {
+			return "```" + d.Kind + "\n" + d.Source + "\n```\n*Diagram: /diagram opens it in the browser*"
+		}
+		return "```text\n" + text + "\n```\n*Diagram approximated: /diagram opens it in the browser*"
+	})
+}
+
 // RenderInline renders markdown without block-level formatting
 func (m *MarkdownRenderer) RenderInline(content string) (string, error) {
 	// For inline rendering, we'll strip certain block elements
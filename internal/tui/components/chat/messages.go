@@ -7,6 +7,7 @@ import (
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/entrepeneur4lyf/codeforge/internal/tui/styles"
 	"github.com/entrepeneur4lyf/codeforge/internal/tui/theme"
 )
 
@@ -434,7 +435,7 @@ func (m *messagesComponent) renderMessageWithParts(msg Message, parts []MessageP
 							WithTextColor(m.theme.Primary()),
 							WithBold(),
 							WithNoBorder(),
-						).Render("▸ Selected")
+						).Render(styles.Icon("▸") + " Selected")
 						rendered = selectionIndicator + "\n" + rendered
 					}
 					
@@ -463,7 +464,7 @@ func (m *messagesComponent) renderMessageWithParts(msg Message, parts []MessageP
 							WithTextColor(m.theme.Primary()),
 							WithBold(),
 							WithNoBorder(),
-						).Render("▸ Selected")
+						).Render(styles.Icon("▸") + " Selected")
 						rendered = selectionIndicator + "\n" + rendered
 					}
 					
@@ -493,7 +494,7 @@ func (m *messagesComponent) renderMessageWithParts(msg Message, parts []MessageP
 							WithTextColor(m.theme.Primary()),
 							WithBold(),
 							WithNoBorder(),
-						).Render("▸ Selected")
+						).Render(styles.Icon("▸") + " Selected")
 						rendered = selectionIndicator + "\n" + rendered
 					}
 					
@@ -541,7 +542,7 @@ func (m *messagesComponent) renderMessageWithParts(msg Message, parts []MessageP
 						WithTextColor(m.theme.Primary()),
 						WithBold(),
 						WithNoBorder(),
-					).Render("▸ Selected")
+					).Render(styles.Icon("▸") + " Selected")
 					rendered = selectionIndicator + "\n" + rendered
 				}
 				
@@ -10,6 +10,7 @@ import (
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/entrepeneur4lyf/codeforge/internal/tui/keymap"
 	"github.com/entrepeneur4lyf/codeforge/internal/tui/theme"
 )
 
@@ -377,4 +378,8 @@ var sessionKeys = sessionKeyMap{
 		key.WithKeys("d", "delete"),
 		key.WithHelp("d", "delete session"),
 	),
-}
\ No newline at end of file
+}
+
+func init() {
+	keymap.Register("sessions", &sessionKeys)
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/entrepeneur4lyf/codeforge/internal/tui/styles"
 	"github.com/entrepeneur4lyf/codeforge/internal/tui/theme"
 )
 
@@ -84,7 +85,7 @@ func (r *ToolRenderer) RenderToolInvocation(tool ToolInvocation, expanded bool)
 		expandIcon = "▼" // Expanded
 	}
 	
-	header := r.headerRenderer.Render(fmt.Sprintf("%s Tool: %s", expandIcon, tool.Name))
+	header := r.headerRenderer.Render(fmt.Sprintf("%s Tool: %s", styles.Icon(expandIcon), tool.Name))
 	result.WriteString(header)
 	
 	if expanded {
@@ -148,7 +149,7 @@ func (r *ToolRenderer) RenderToolResult(result ToolResult, expanded bool) string
 		WithTextColor(headerColor),
 		WithBold(),
 		WithNoBorder(),
-	).Render(fmt.Sprintf("%s Tool Result: %s", expandIcon, status))
+	).Render(fmt.Sprintf("%s Tool Result: %s", styles.Icon(expandIcon), status))
 	
 	output.WriteString(header)
 	
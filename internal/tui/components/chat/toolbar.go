@@ -3,6 +3,7 @@ package chat
 import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/entrepeneur4lyf/codeforge/internal/tui/styles"
 	"github.com/entrepeneur4lyf/codeforge/internal/tui/theme"
 )
 
@@ -104,7 +105,7 @@ func (m *ToolbarModel) View() string {
 		}
 
 		// Add button with icon
-		buttons = append(buttons, style.Render(btn.Icon))
+		buttons = append(buttons, style.Render(styles.Icon(btn.Icon)))
 	}
 
 	// Join buttons with spacing
@@ -11,6 +11,9 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/entrepeneur4lyf/codeforge/internal/app"
 	"github.com/entrepeneur4lyf/codeforge/internal/models"
+	"github.com/entrepeneur4lyf/codeforge/internal/tui/keymap"
+	"github.com/entrepeneur4lyf/codeforge/internal/tui/layout"
+	"github.com/entrepeneur4lyf/codeforge/internal/tui/styles"
 	"github.com/entrepeneur4lyf/codeforge/internal/tui/theme"
 )
 
@@ -27,6 +30,7 @@ type AdvancedModelDialog struct {
 	filterCriteria  models.ModelSelectionCriteria
 	recommendations []models.ModelRecommendation
 	showingFavorites bool
+	modelRows       layout.ListRows
 }
 
 // ViewMode defines different viewing modes
@@ -80,21 +84,16 @@ func (m *AdvancedModelDialog) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.width = msg.Width
 		m.height = msg.Height
 
+	case tea.MouseMsg:
+		return m, m.handleMouse(msg)
+
 	case tea.KeyMsg:
 		switch {
 		case key.Matches(msg, advancedKeys.Cancel):
 			return m, func() tea.Msg { return DialogCloseMsg{} }
 
 		case key.Matches(msg, advancedKeys.Select):
-			if model := m.getCurrentModel(); model != nil {
-				provider := m.getBestProvider(model)
-				return m, func() tea.Msg {
-					return AdvancedModelSelectedMsg{
-						Model:    model,
-						Provider: provider,
-					}
-				}
-			}
+			return m, m.selectCurrent()
 
 		case key.Matches(msg, advancedKeys.NextModel):
 			m.nextModel()
@@ -159,7 +158,8 @@ func (m *AdvancedModelDialog) View() string {
 		content.WriteString("\n\n")
 	}
 
-	// Model list
+	// Model list, below the top border, the padding and the lines so far
+	m.modelRows.Top = 2 + strings.Count(content.String(), "\n")
 	content.WriteString(m.renderModelList(dialogWidth-4, dialogHeight-12))
 	content.WriteString("\n\n")
 
@@ -238,6 +238,7 @@ func (m *AdvancedModelDialog) applyCurrentView() {
 }
 
 func (m *AdvancedModelDialog) renderModelList(width, height int) string {
+	m.modelRows.Items = nil
 	if len(m.filteredModels) == 0 {
 		return lipgloss.NewStyle().
 			Foreground(m.theme.TextMuted()).
@@ -259,6 +260,9 @@ func (m *AdvancedModelDialog) renderModelList(width, height int) string {
 		model := m.filteredModels[i]
 		line := m.renderAdvancedModelItem(model, i == m.currentModel, width)
 		lines = append(lines, line)
+		for range lipgloss.Height(line) {
+			m.modelRows.Items = append(m.modelRows.Items, i)
+		}
 	}
 
 	return strings.Join(lines, "\n")
@@ -269,7 +273,7 @@ func (m *AdvancedModelDialog) renderAdvancedModelItem(model *models.CanonicalMod
 
 	// Selection indicator
 	if selected {
-		parts = append(parts, "▶")
+		parts = append(parts, styles.Icon("▶"))
 	} else {
 		parts = append(parts, " ")
 	}
@@ -284,7 +288,7 @@ func (m *AdvancedModelDialog) renderAdvancedModelItem(model *models.CanonicalMod
 		}
 	}
 	if isFavorite {
-		parts = append(parts, "★")
+		parts = append(parts, styles.Icon("★"))
 	} else {
 		parts = append(parts, " ")
 	}
@@ -296,13 +300,13 @@ func (m *AdvancedModelDialog) renderAdvancedModelItem(model *models.CanonicalMod
 	// Capabilities badges
 	var badges []string
 	if model.Capabilities.SupportsReasoning {
-		badges = append(badges, "🧠")
+		badges = append(badges, styles.Icon("🧠"))
 	}
 	if model.Capabilities.SupportsVision {
-		badges = append(badges, "👁")
+		badges = append(badges, styles.Icon("👁"))
 	}
 	if model.Capabilities.SupportsTools {
-		badges = append(badges, "🔧")
+		badges = append(badges, styles.Icon("🔧"))
 	}
 	if len(badges) > 0 {
 		parts = append(parts, strings.Join(badges, ""))
@@ -484,6 +488,47 @@ func (m *AdvancedModelDialog) getCurrentModel() *models.CanonicalModel {
 	return nil
 }
 
+// selectCurrent confirms the highlighted model
+func (m *AdvancedModelDialog) selectCurrent() tea.Cmd {
+	model := m.getCurrentModel()
+	if model == nil {
+		return nil
+	}
+	provider := m.getBestProvider(model)
+	return func() tea.Msg {
+		return AdvancedModelSelectedMsg{
+			Model:    model,
+			Provider: provider,
+		}
+	}
+}
+
+// handleMouse moves through the models with the wheel; clicking a model
+// highlights it, and clicking the highlighted model selects it
+func (m *AdvancedModelDialog) handleMouse(msg tea.MouseMsg) tea.Cmd {
+	switch layout.WheelDelta(msg) {
+	case -1:
+		m.prevModel()
+		return nil
+	case 1:
+		m.nextModel()
+		return nil
+	}
+	if !layout.IsLeftClick(msg) {
+		return nil
+	}
+
+	i, ok := m.modelRows.ItemAt(msg.Y)
+	if !ok || i >= len(m.filteredModels) {
+		return nil
+	}
+	if i != m.currentModel {
+		m.currentModel = i
+		return nil
+	}
+	return m.selectCurrent()
+}
+
 func (m *AdvancedModelDialog) nextModel() {
 	if m.currentModel < len(m.filteredModels)-1 {
 		m.currentModel++
@@ -552,11 +597,11 @@ func (m *AdvancedModelDialog) getCapabilityScore(model *models.CanonicalModel) i
 // Helper functions
 func getPricingTier(outputPrice float64) string {
 	if outputPrice <= 1.0 {
-		return "💚 Low"
+		return styles.Icon("💚") + " Low"
 	} else if outputPrice <= 10.0 {
-		return "🟡 Med"
+		return styles.Icon("🟡") + " Med"
 	} else {
-		return "🔴 High"
+		return styles.Icon("🔴") + " High"
 	}
 }
 
@@ -620,4 +665,8 @@ var advancedKeys = advancedKeyMap{
 		key.WithKeys("t"),
 		key.WithHelp("t", "filter by task"),
 	),
-}
\ No newline at end of file
+}
+
+func init() {
+	keymap.Register("selector", &advancedKeys)
+}
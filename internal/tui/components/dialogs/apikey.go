@@ -0,0 +1,158 @@
+package dialog
+
+import (
+	"slices"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+	"github.com/entrepeneur4lyf/codeforge/internal/tui/theme"
+)
+
+// ShowAPIKeyDialogMsg requests the API key dialog, with Provider selected
+// when it's set
+type ShowAPIKeyDialogMsg struct {
+	Provider string
+}
+
+// APIKeySavedMsg is sent after the API key dialog replaces a provider's key
+type APIKeySavedMsg struct {
+	Provider string
+	Err      error
+}
+
+// APIKeyDialog replaces a provider's API key for the running session, for
+// key rotation without a restart
+type APIKeyDialog struct {
+	theme     theme.Theme
+	providers []string
+	selected  int
+	input     textinput.Model
+	width     int
+}
+
+var (
+	apiKeyPrevKey = key.NewBinding(
+		key.WithKeys("up", "shift+tab"),
+		key.WithHelp("↑", "previous provider"),
+	)
+	apiKeyNextKey = key.NewBinding(
+		key.WithKeys("down", "tab"),
+		key.WithHelp("↓", "next provider"),
+	)
+	apiKeySaveKey = key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "save"),
+	)
+)
+
+// NewAPIKeyDialog creates the dialog with the provider selected, or the
+// first one when it's empty or unknown
+func NewAPIKeyDialog(th theme.Theme, provider string, width int) *APIKeyDialog {
+	ti := textinput.New()
+	ti.Placeholder = "Paste the new key"
+	ti.EchoMode = textinput.EchoPassword
+	ti.EchoCharacter = '•'
+	ti.Focus()
+
+	providers := config.KeyProviders()
+	d := &APIKeyDialog{
+		theme:     th,
+		providers: providers,
+		selected:  max(slices.Index(providers, provider), 0),
+		input:     ti,
+	}
+	d.SetSize(width)
+	return d
+}
+
+// SetSize sizes the dialog to fit a window of the given width
+func (d *APIKeyDialog) SetSize(width int) {
+	d.width = min(width-4, 60)
+	d.input.Width = d.width - 8
+}
+
+func (d *APIKeyDialog) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (d *APIKeyDialog) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		d.SetSize(msg.Width)
+		return d, nil
+
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, apiKeyPrevKey):
+			d.selected = (d.selected + len(d.providers) - 1) % len(d.providers)
+			return d, nil
+		case key.Matches(msg, apiKeyNextKey):
+			d.selected = (d.selected + 1) % len(d.providers)
+			return d, nil
+		case key.Matches(msg, apiKeySaveKey):
+			if d.input.Value() == "" {
+				return d, nil
+			}
+			provider := d.providers[d.selected]
+			err := config.SetProviderKey(provider, d.input.Value())
+			d.input.SetValue("")
+			return d, tea.Batch(
+				func() tea.Msg { return APIKeySavedMsg{Provider: provider, Err: err} },
+				func() tea.Msg { return DialogCloseMsg{} },
+			)
+		}
+	}
+
+	var cmd tea.Cmd
+	d.input, cmd = d.input.Update(msg)
+	return d, cmd
+}
+
+func (d *APIKeyDialog) View() string {
+	if d.width <= 0 {
+		return ""
+	}
+
+	title := lipgloss.NewStyle().
+		Foreground(d.theme.TextEmphasized()).
+		Bold(true).
+		Render("API Keys")
+	mutedStyle := lipgloss.NewStyle().Foreground(d.theme.TextMuted())
+
+	var rows []string
+	for i, provider := range d.providers {
+		state := "not set"
+		if config.ProviderKey(provider) != "" {
+			state = "set"
+		}
+		line := lipgloss.NewStyle().Width(d.width-16).Render(provider) + mutedStyle.Render(state)
+		if i == d.selected {
+			rows = append(rows, lipgloss.NewStyle().Foreground(d.theme.Primary()).Bold(true).Render("> "+line))
+		} else {
+			rows = append(rows, "  "+line)
+		}
+	}
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		title,
+		mutedStyle.Render("Applies from the next message; not saved to disk"),
+		"",
+		lipgloss.JoinVertical(lipgloss.Left, rows...),
+		"",
+		d.input.View(),
+		"",
+		mutedStyle.Render("↑/↓ provider • enter save • esc cancel"),
+	)
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(d.theme.Primary()).
+		Background(d.theme.Background()).
+		Padding(0, 1).
+		Width(d.width).
+		Render(content)
+}
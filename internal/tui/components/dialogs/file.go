@@ -11,6 +11,8 @@ import (
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/entrepeneur4lyf/codeforge/internal/tui/keymap"
+	"github.com/entrepeneur4lyf/codeforge/internal/tui/styles"
 	"github.com/entrepeneur4lyf/codeforge/internal/tui/theme"
 )
 
@@ -192,7 +194,7 @@ func (f *FileDialog) renderEntry(entry fs.DirEntry, selected bool, width int) st
 	// Checkbox for files
 	if !entry.IsDir() {
 		if f.selectedFiles[fullPath] {
-			parts = append(parts, "[✓]")
+			parts = append(parts, "["+styles.Icon("✓")+"]")
 		} else {
 			parts = append(parts, "[ ]")
 		}
@@ -202,9 +204,9 @@ func (f *FileDialog) renderEntry(entry fs.DirEntry, selected bool, width int) st
 
 	// Icon
 	if entry.IsDir() {
-		parts = append(parts, "📁")
+		parts = append(parts, styles.Icon("📁"))
 	} else {
-		parts = append(parts, getFileIcon(name))
+		parts = append(parts, styles.Icon(getFileIcon(name)))
 	}
 
 	// Name
@@ -466,3 +468,7 @@ var fileKeys = fileKeyMap{
 		key.WithHelp("~", "go home"),
 	),
 }
+
+func init() {
+	keymap.Register("file", &fileKeys)
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/entrepeneur4lyf/codeforge/internal/logging"
 	"github.com/entrepeneur4lyf/codeforge/internal/models"
 	"github.com/entrepeneur4lyf/codeforge/internal/tui/image"
+	"github.com/entrepeneur4lyf/codeforge/internal/tui/keymap"
 	"github.com/entrepeneur4lyf/codeforge/internal/tui/theme"
 	"github.com/entrepeneur4lyf/codeforge/internal/tui/util"
 )
@@ -74,6 +75,10 @@ var filePickerKeyMap = FilePrickerKeyMap{
 	),
 }
 
+func init() {
+	keymap.Register("filepicker", &filePickerKeyMap)
+}
+
 type filepickerCmp struct {
 	basePath       string
 	width          int
@@ -231,6 +236,10 @@ func (f *filepickerCmp) addAttachmentToMessage() (tea.Model, tea.Cmd) {
 	// Check if current model supports attachments
 	provider, modelName := f.app.GetCurrentModel()
 	modelInfo := getSelectedModelInfo(f.app, provider, modelName)
+	if err := f.app.ValidateModelRequest(provider+"/"+string(modelInfo.ID), models.Requirements{Images: 1}); err != nil {
+		logging.ErrorPersist(err.Error())
+		return f, nil
+	}
 	if !modelInfo.SupportsAttachments {
 		logging.ErrorPersist(fmt.Sprintf("Model %s doesn't support attachments", modelInfo.Name))
 		return f, nil
@@ -86,6 +86,7 @@ func (h *HelpDialog) View() string {
 				{"Home/End", "Jump to start/end"},
 				{"tab", "Switch focus"},
 				{"ctrl+b", "Toggle sidebar"},
+				{"wheel/click", "Scroll and select (tui.mouse)"},
 			},
 		},
 		{
@@ -94,6 +95,11 @@ func (h *HelpDialog) View() string {
 				{"enter", "Send message"},
 				{"shift+enter", "New line"},
 				{"ctrl+r", "Remove attachment"},
+				{"/notes", "Open project notes"},
+				{"/notes text", "Add a project note"},
+				{"/keys", "Show key bindings"},
+				{"/apikey", "Replace a provider API key"},
+				{"/diagram", "Open the last response's diagrams"},
 			},
 		},
 		{
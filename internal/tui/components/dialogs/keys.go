@@ -0,0 +1,146 @@
+package dialog
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/entrepeneur4lyf/codeforge/internal/tui/keymap"
+	"github.com/entrepeneur4lyf/codeforge/internal/tui/theme"
+)
+
+// ShowKeysDialogMsg requests the key bindings overlay
+type ShowKeysDialogMsg struct{}
+
+// KeysDialog lists the active key bindings of every TUI component
+type KeysDialog struct {
+	theme    theme.Theme
+	viewport viewport.Model
+	path     string
+	loadErr  error
+	width    int
+	height   int
+}
+
+var keysCloseKey = key.NewBinding(
+	key.WithKeys("q", "enter"),
+	key.WithHelp("q", "close"),
+)
+
+// NewKeysDialog creates the overlay for the keymap loaded from path;
+// loadErr is the error, if any, from loading it
+func NewKeysDialog(th theme.Theme, path string, loadErr error, width, height int) *KeysDialog {
+	d := &KeysDialog{
+		theme:   th,
+		path:    path,
+		loadErr: loadErr,
+	}
+	d.SetSize(width, height)
+	return d
+}
+
+// SetSize sizes the overlay to fit a window of the given size
+func (d *KeysDialog) SetSize(width, height int) {
+	d.width = min(width-4, 80)
+	d.height = min(height-4, 35)
+	d.viewport = viewport.New(d.width-4, d.height-6)
+	d.viewport.SetContent(d.renderBindings())
+}
+
+func (d *KeysDialog) Init() tea.Cmd {
+	return nil
+}
+
+func (d *KeysDialog) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		d.SetSize(msg.Width, msg.Height)
+		return d, nil
+
+	case tea.KeyMsg:
+		if key.Matches(msg, keysCloseKey) {
+			return d, func() tea.Msg { return DialogCloseMsg{} }
+		}
+	}
+
+	var cmd tea.Cmd
+	d.viewport, cmd = d.viewport.Update(msg)
+	return d, cmd
+}
+
+// renderBindings lists every scope's actions with their active keys
+func (d *KeysDialog) renderBindings() string {
+	sectionStyle := lipgloss.NewStyle().
+		Foreground(d.theme.TextMuted()).
+		Bold(true)
+	keyStyle := lipgloss.NewStyle().
+		Foreground(d.theme.Text()).
+		Width(20)
+	actionStyle := lipgloss.NewStyle().
+		Foreground(d.theme.TextMuted()).
+		Width(18)
+
+	var content strings.Builder
+	for i, section := range keymap.Sections() {
+		if i > 0 {
+			content.WriteString("\n")
+		}
+		content.WriteString(sectionStyle.Render(section.Scope))
+		content.WriteString("\n")
+		for _, b := range section.Bindings {
+			line := lipgloss.JoinHorizontal(
+				lipgloss.Top,
+				keyStyle.Render(keymap.HelpKeys(b.Keys)),
+				actionStyle.Render(b.Action),
+				b.Description,
+			)
+			content.WriteString("  " + line + "\n")
+		}
+	}
+	return content.String()
+}
+
+func (d *KeysDialog) View() string {
+	if d.width <= 0 || d.height <= 0 {
+		return ""
+	}
+
+	titleStyle := lipgloss.NewStyle().
+		Foreground(d.theme.TextEmphasized()).
+		Width(d.width - 4).
+		Align(lipgloss.Center)
+	mutedStyle := lipgloss.NewStyle().
+		Foreground(d.theme.TextMuted()).
+		Width(d.width - 4)
+
+	var footer string
+	switch {
+	case d.loadErr != nil:
+		footer = lipgloss.NewStyle().
+			Foreground(d.theme.Error()).
+			Width(d.width - 4).
+			Render(fmt.Sprintf("Keymap not applied: %v", d.loadErr))
+	default:
+		footer = mutedStyle.Render(fmt.Sprintf("Remap keys in %s (/keys init writes this map there)", d.path))
+	}
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		titleStyle.Render("Key Bindings"),
+		"",
+		d.viewport.View(),
+		"",
+		footer,
+		mutedStyle.Render("↑/↓ scroll • esc close"),
+	)
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(d.theme.BorderNormal()).
+		Padding(0, 1).
+		Width(d.width).
+		Render(content)
+}
@@ -10,6 +10,8 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/entrepeneur4lyf/codeforge/internal/app"
 	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+	"github.com/entrepeneur4lyf/codeforge/internal/tui/keymap"
+	"github.com/entrepeneur4lyf/codeforge/internal/tui/styles"
 	"github.com/entrepeneur4lyf/codeforge/internal/tui/theme"
 )
 
@@ -332,7 +334,7 @@ func (m *ModelDialog) renderModelItem(model llm.ModelResponse, selected bool, wi
 	// Favorite indicator
 	key := fmt.Sprintf("%s:%s", model.Provider, model.Name)
 	if m.favorites[key] {
-		parts = append(parts, "★")
+		parts = append(parts, styles.Icon("★"))
 	} else {
 		parts = append(parts, " ")
 	}
@@ -493,3 +495,7 @@ var keys = keyMap{
 		key.WithHelp("f", "show favorites"),
 	),
 }
+
+func init() {
+	keymap.Register("provider_selector", &keys)
+}
@@ -0,0 +1,136 @@
+package dialog
+
+import (
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/entrepeneur4lyf/codeforge/internal/notes"
+	"github.com/entrepeneur4lyf/codeforge/internal/tui/theme"
+)
+
+// ShowNotesDialogMsg requests the project notes panel
+type ShowNotesDialogMsg struct{}
+
+// NotesSavedMsg is sent after the notes panel saves the project notes
+type NotesSavedMsg struct {
+	Path string
+	Err  error
+}
+
+// NotesDialog is a panel for editing the project's scratch notes
+type NotesDialog struct {
+	theme    theme.Theme
+	store    *notes.Store
+	textarea textarea.Model
+	width    int
+	height   int
+	loadErr  error
+}
+
+var notesSaveKey = key.NewBinding(
+	key.WithKeys("ctrl+s"),
+	key.WithHelp("ctrl+s", "save"),
+)
+
+// NewNotesDialog creates a notes panel loaded with the store's notes
+func NewNotesDialog(th theme.Theme, store *notes.Store, width, height int) *NotesDialog {
+	ta := textarea.New()
+	ta.Placeholder = "Jot down decisions, conventions and todos for this project..."
+	ta.CharLimit = 0
+	ta.ShowLineNumbers = false
+	ta.Focus()
+
+	ta.FocusedStyle.Base = lipgloss.NewStyle().
+		Background(th.Background()).
+		Foreground(th.Text())
+	ta.FocusedStyle.CursorLine = lipgloss.NewStyle().
+		Background(th.BackgroundSecondary())
+	ta.FocusedStyle.Placeholder = lipgloss.NewStyle().
+		Background(th.Background()).
+		Foreground(th.TextMuted())
+
+	d := &NotesDialog{
+		theme:    th,
+		store:    store,
+		textarea: ta,
+	}
+	text, err := store.Load()
+	d.loadErr = err
+	d.textarea.SetValue(text)
+	d.SetSize(width, height)
+	return d
+}
+
+// SetSize sizes the panel to fit a window of the given size
+func (d *NotesDialog) SetSize(width, height int) {
+	d.width = min(width-4, 90)
+	d.height = min(height-4, 30)
+	d.textarea.SetWidth(d.width - 4)
+	d.textarea.SetHeight(d.height - 6)
+}
+
+func (d *NotesDialog) Init() tea.Cmd {
+	return textarea.Blink
+}
+
+func (d *NotesDialog) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		d.SetSize(msg.Width, msg.Height)
+		return d, nil
+
+	case tea.KeyMsg:
+		if key.Matches(msg, notesSaveKey) {
+			err := d.store.Save(d.textarea.Value())
+			path := d.store.Path()
+			return d, tea.Batch(
+				func() tea.Msg { return NotesSavedMsg{Path: path, Err: err} },
+				func() tea.Msg { return DialogCloseMsg{} },
+			)
+		}
+	}
+
+	var cmd tea.Cmd
+	d.textarea, cmd = d.textarea.Update(msg)
+	return d, cmd
+}
+
+func (d *NotesDialog) View() string {
+	if d.width <= 0 || d.height <= 0 {
+		return ""
+	}
+
+	title := lipgloss.NewStyle().
+		Foreground(d.theme.TextEmphasized()).
+		Bold(true).
+		Render("Project Notes")
+
+	subtitle := truncate(d.store.Path(), d.width-4)
+	if d.loadErr != nil {
+		subtitle = truncate(d.loadErr.Error(), d.width-4)
+	}
+	subtitle = lipgloss.NewStyle().Foreground(d.theme.TextMuted()).Render(subtitle)
+
+	help := lipgloss.NewStyle().
+		Foreground(d.theme.TextMuted()).
+		Render("ctrl+s save • esc discard changes")
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		title,
+		subtitle,
+		"",
+		d.textarea.View(),
+		"",
+		help,
+	)
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(d.theme.Primary()).
+		Background(d.theme.Background()).
+		Padding(0, 1).
+		Width(d.width).
+		Render(content)
+}
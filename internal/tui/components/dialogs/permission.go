@@ -11,6 +11,7 @@ import (
 	"github.com/entrepeneur4lyf/codeforge/internal/diff"
 	"github.com/entrepeneur4lyf/codeforge/internal/llm/tools"
 	"github.com/entrepeneur4lyf/codeforge/internal/permissions"
+	"github.com/entrepeneur4lyf/codeforge/internal/tui/keymap"
 	"github.com/entrepeneur4lyf/codeforge/internal/tui/layout"
 	"github.com/entrepeneur4lyf/codeforge/internal/tui/styles"
 	"github.com/entrepeneur4lyf/codeforge/internal/tui/theme"
@@ -90,6 +91,10 @@ var permissionsKeys = permissionsMapping{
 	),
 }
 
+func init() {
+	keymap.Register("permission", &permissionsKeys)
+}
+
 // permissionDialogCmp is the implementation of PermissionDialog
 type permissionDialogCmp struct {
 	width           int
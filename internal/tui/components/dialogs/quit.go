@@ -6,6 +6,7 @@ import (
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/entrepeneur4lyf/codeforge/internal/tui/keymap"
 	"github.com/entrepeneur4lyf/codeforge/internal/tui/layout"
 	"github.com/entrepeneur4lyf/codeforge/internal/tui/styles"
 	"github.com/entrepeneur4lyf/codeforge/internal/tui/theme"
@@ -56,6 +57,10 @@ var helpKeys = helpMapping{
 	),
 }
 
+func init() {
+	keymap.Register("quit", &helpKeys)
+}
+
 func (q *quitDialogCmp) Init() tea.Cmd {
 	return nil
 }
@@ -10,7 +10,12 @@ import (
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/entrepeneur4lyf/codeforge/internal/fileutil"
+	"github.com/entrepeneur4lyf/codeforge/internal/ignore"
 	"github.com/entrepeneur4lyf/codeforge/internal/search"
+	"github.com/entrepeneur4lyf/codeforge/internal/tui/keymap"
+	"github.com/entrepeneur4lyf/codeforge/internal/tui/layout"
+	"github.com/entrepeneur4lyf/codeforge/internal/tui/styles"
 	"github.com/entrepeneur4lyf/codeforge/internal/tui/theme"
 )
 
@@ -26,6 +31,7 @@ type SearchDialog struct {
 	searcher    *search.Searcher
 	searching   bool
 	searchErr   error
+	resultRows  layout.ListRows
 }
 
 // SearchType defines the type of search
@@ -100,6 +106,9 @@ func (s *SearchDialog) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			s.selected = 0
 		}
 
+	case tea.MouseMsg:
+		return s, s.handleMouse(msg)
+
 	case tea.KeyMsg:
 		switch {
 		case key.Matches(msg, searchKeys.Cancel):
@@ -171,19 +180,22 @@ func (s *SearchDialog) View() string {
 	content.WriteString("\n\n")
 
 	// Results or status
+	s.resultRows = layout.ListRows{}
 	if s.searching {
 		searchingStyle := lipgloss.NewStyle().
 			Foreground(s.theme.Info()).
 			Width(dialogWidth - 4).
 			Align(lipgloss.Center)
-		content.WriteString(searchingStyle.Render("🔍 Searching..."))
+		content.WriteString(searchingStyle.Render(styles.Icon("🔍") + " Searching..."))
 	} else if s.searchErr != nil {
 		errorStyle := lipgloss.NewStyle().
 			Foreground(s.theme.Error()).
 			Width(dialogWidth - 4).
 			Align(lipgloss.Center)
-		content.WriteString(errorStyle.Render(fmt.Sprintf("❌ Error: %v", s.searchErr)))
+		content.WriteString(errorStyle.Render(fmt.Sprintf("%s Error: %v", styles.Icon("❌"), s.searchErr)))
 	} else if len(s.results) > 0 {
+		// Results start below the top border and the lines written so far
+		s.resultRows.Top = 1 + strings.Count(content.String(), "\n")
 		resultsView := s.renderResults(dialogWidth-4, dialogHeight-10)
 		content.WriteString(resultsView)
 	} else if s.searchInput.Value() != "" {
@@ -232,6 +244,7 @@ func (s *SearchDialog) renderResults(width, maxHeight int) string {
 		lines = append(lines, lipgloss.NewStyle().
 			Foreground(s.theme.TextMuted()).
 			Render("↑ more results above"))
+		s.resultRows.Items = append(s.resultRows.Items, -1)
 	}
 
 	// Render results
@@ -239,6 +252,9 @@ func (s *SearchDialog) renderResults(width, maxHeight int) string {
 		result := s.results[i]
 		line := s.renderResult(result, i == s.selected, width)
 		lines = append(lines, line)
+		for range lipgloss.Height(line) {
+			s.resultRows.Items = append(s.resultRows.Items, i)
+		}
 	}
 
 	// Bottom scroll indicator
@@ -251,6 +267,29 @@ func (s *SearchDialog) renderResults(width, maxHeight int) string {
 	return strings.Join(lines, "\n")
 }
 
+// handleMouse scrolls the results with the wheel; clicking a result selects
+// it, and clicking the selected result opens it
+func (s *SearchDialog) handleMouse(msg tea.MouseMsg) tea.Cmd {
+	if delta := layout.WheelDelta(msg); delta != 0 {
+		s.selected = max(0, min(s.selected+delta, len(s.results)-1))
+		return nil
+	}
+	if !layout.IsLeftClick(msg) {
+		return nil
+	}
+
+	i, ok := s.resultRows.ItemAt(msg.Y)
+	if !ok || i >= len(s.results) {
+		return nil
+	}
+	if i != s.selected {
+		s.selected = i
+		return nil
+	}
+	result := s.results[i]
+	return func() tea.Msg { return SearchSelectedMsg{Result: result} }
+}
+
 func (s *SearchDialog) renderResult(result SearchResult, selected bool, width int) string {
 	// Build result display
 	var parts []string
@@ -342,7 +381,8 @@ func (s *SearchDialog) performSearch() tea.Cmd {
 				MaxLineLength: 200,
 				UseFuzzy:      true,
 				FuzzyThreshold: 60,
-				Exclude:       []string{"*.bin", "*.exe", "*.so", "*.dylib", "*.dll", ".git/*", "vendor/*", "node_modules/*"},
+				Exclude:       ignore.Patterns(),
+				MaxFileSize:   fileutil.MaxFileSize(),
 			}
 			
 			searchResults, err := s.searcher.Search(ctx, opts)
@@ -393,3 +433,7 @@ var searchKeys = searchKeyMap{
 		key.WithHelp("↓/j", "down"),
 	),
 }
+
+func init() {
+	keymap.Register("search", &searchKeys)
+}
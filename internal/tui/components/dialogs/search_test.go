@@ -0,0 +1,21 @@
+package dialog
+
+import (
+	"testing"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/tui/headless"
+	"github.com/entrepeneur4lyf/codeforge/internal/tui/theme"
+)
+
+func TestSearchDialog_Snapshot(t *testing.T) {
+	d := headless.New(NewSearchDialog(theme.NewDefaultTheme(), TextSearch), 70, 20)
+	headless.AssertGolden(t, "search_empty", d.View())
+
+	d.Type("Render")
+	d.Send(SearchResultsMsg{Results: []SearchResult{
+		{Path: "internal/tui/app.go", Line: 42, Content: "func (m *Model) Render() string {", Match: "Render"},
+		{Path: "internal/tui/page/chat.go", Line: 580, Content: "func (p *ChatPage) Render() string {", Match: "Render"},
+	}})
+	d.Press("down")
+	headless.AssertGolden(t, "search_results", d.View())
+}
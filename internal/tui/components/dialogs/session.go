@@ -5,6 +5,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/entrepeneur4lyf/codeforge/internal/tui/components/chat"
+	"github.com/entrepeneur4lyf/codeforge/internal/tui/keymap"
 	"github.com/entrepeneur4lyf/codeforge/internal/tui/layout"
 	"github.com/entrepeneur4lyf/codeforge/internal/tui/styles"
 	"github.com/entrepeneur4lyf/codeforge/internal/tui/theme"
@@ -71,6 +72,10 @@ var sessionKeys = sessionKeyMap{
 	),
 }
 
+func init() {
+	keymap.Register("session_dialog", &sessionKeys)
+}
+
 func (s *sessionDialogCmp) Init() tea.Cmd {
 	return nil
 }
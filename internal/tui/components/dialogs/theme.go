@@ -4,6 +4,7 @@ import (
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/entrepeneur4lyf/codeforge/internal/tui/keymap"
 	"github.com/entrepeneur4lyf/codeforge/internal/tui/layout"
 	"github.com/entrepeneur4lyf/codeforge/internal/tui/styles"
 	"github.com/entrepeneur4lyf/codeforge/internal/tui/theme"
@@ -68,6 +69,10 @@ var themeKeys = themeKeyMap{
 	),
 }
 
+func init() {
+	keymap.Register("theme", &themeKeys)
+}
+
 func (t *themeDialogCmp) Init() tea.Cmd {
 	// Load available themes and update selectedIdx based on current theme
 	t.themes = theme.AvailableThemes()
@@ -0,0 +1,187 @@
+// Package headless drives Bubble Tea models without a terminal, so TUI
+// behavior can be scripted and snapshot tested
+package headless
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// DefaultCmdTimeout is how long the driver waits for a command's message.
+// Commands that take longer, such as cursor blinks and animation ticks, are
+// dropped so a script never waits on timers.
+const DefaultCmdTimeout = 200 * time.Millisecond
+
+// maxMessages bounds the messages one input may cause, which stops models
+// that keep scheduling themselves from looping forever
+const maxMessages = 1000
+
+// Driver runs a Bubble Tea model headlessly. Input is delivered through
+// Update and every command it returns is run to completion before the
+// next input, so a View taken after an input reflects all its effects.
+type Driver struct {
+	model      tea.Model
+	width      int
+	height     int
+	cmdTimeout time.Duration
+	quit       bool
+}
+
+// Option configures a Driver
+type Option func(*Driver)
+
+// WithCmdTimeout sets how long to wait for each command's message
+func WithCmdTimeout(d time.Duration) Option {
+	return func(dr *Driver) { dr.cmdTimeout = d }
+}
+
+// New starts model at the given terminal size, running its Init command
+func New(model tea.Model, width, height int, opts ...Option) *Driver {
+	d := &Driver{model: model, width: width, height: height, cmdTimeout: DefaultCmdTimeout}
+	for _, opt := range opts {
+		opt(d)
+	}
+	d.run(model.Init())
+	d.Send(tea.WindowSizeMsg{Width: width, Height: height})
+	return d
+}
+
+// Send delivers a message and runs the commands it leads to
+func (d *Driver) Send(msg tea.Msg) {
+	if d.quit {
+		return
+	}
+	queue := []tea.Msg{msg}
+	for n := 0; len(queue) > 0 && n < maxMessages && !d.quit; n++ {
+		msg, queue = queue[0], queue[1:]
+		if _, ok := msg.(tea.QuitMsg); ok {
+			d.quit = true
+			break
+		}
+		var cmd tea.Cmd
+		d.model, cmd = d.model.Update(msg)
+		queue = append(queue, d.collect(cmd)...)
+	}
+}
+
+// run runs a command outside of Send, such as Init's
+func (d *Driver) run(cmd tea.Cmd) {
+	for _, msg := range d.collect(cmd) {
+		d.Send(msg)
+	}
+}
+
+// collect runs cmd, and any batch or sequence it returns, and returns the
+// messages that arrived in time
+func (d *Driver) collect(cmd tea.Cmd) []tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+
+	result := make(chan tea.Msg, 1)
+	go func() { result <- cmd() }()
+
+	var msg tea.Msg
+	select {
+	case msg = <-result:
+	case <-time.After(d.cmdTimeout):
+		return nil
+	}
+
+	switch m := msg.(type) {
+	case nil:
+		return nil
+	case tea.BatchMsg:
+		var msgs []tea.Msg
+		for _, c := range m {
+			msgs = append(msgs, d.collect(c)...)
+		}
+		return msgs
+	}
+
+	// tea.Sequence returns an unexported slice of commands
+	if v := reflect.ValueOf(msg); v.Kind() == reflect.Slice && v.Type().Elem() == reflect.TypeOf(tea.Cmd(nil)) {
+		var msgs []tea.Msg
+		for i := 0; i < v.Len(); i++ {
+			msgs = append(msgs, d.collect(v.Index(i).Interface().(tea.Cmd))...)
+		}
+		return msgs
+	}
+	return []tea.Msg{msg}
+}
+
+// Type sends text as if it were typed
+func (d *Driver) Type(text string) {
+	for _, msg := range typeMsgs(text) {
+		d.Send(msg)
+	}
+}
+
+// typeMsgs returns the key messages for typing text
+func typeMsgs(text string) []tea.KeyMsg {
+	msgs := make([]tea.KeyMsg, 0, len(text))
+	for _, r := range text {
+		switch r {
+		case '\n':
+			msgs = append(msgs, tea.KeyMsg{Type: tea.KeyEnter})
+		case '\t':
+			msgs = append(msgs, tea.KeyMsg{Type: tea.KeyTab})
+		case ' ':
+			msgs = append(msgs, tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+		default:
+			msgs = append(msgs, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+	}
+	return msgs
+}
+
+// Press sends keys by name, such as "enter", "ctrl+c", "alt+up" or "q"
+func (d *Driver) Press(keys ...string) error {
+	for _, name := range keys {
+		msg, err := ParseKey(name)
+		if err != nil {
+			return err
+		}
+		d.Send(msg)
+	}
+	return nil
+}
+
+// Resize changes the terminal size
+func (d *Driver) Resize(width, height int) {
+	d.width, d.height = width, height
+	d.Send(tea.WindowSizeMsg{Width: width, Height: height})
+}
+
+// View returns the screen as plain text: styling removed, trailing spaces
+// trimmed and cut to the terminal height, ready to compare with a golden
+// file
+func (d *Driver) View() string {
+	lines := strings.Split(ansi.Strip(d.model.View()), "\n")
+	if d.height > 0 && len(lines) > d.height {
+		lines = lines[:d.height]
+	}
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n") + "\n"
+}
+
+// RawView returns the screen with styling
+func (d *Driver) RawView() string {
+	return d.model.View()
+}
+
+// Model returns the model as last updated
+func (d *Driver) Model() tea.Model {
+	return d.model
+}
+
+// Quit reports whether the model quit
+func (d *Driver) Quit() bool {
+	return d.quit
+}
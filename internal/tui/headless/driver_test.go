@@ -0,0 +1,137 @@
+package headless
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type itemsLoadedMsg []string
+
+// listModel is a small model with an async load, a slow tick and quitting
+type listModel struct {
+	items    []string
+	cursor   int
+	typed    string
+	width    int
+	ticks    int
+	selected string
+}
+
+func (m *listModel) Init() tea.Cmd {
+	return tea.Batch(
+		func() tea.Msg { return itemsLoadedMsg{"alpha", "beta", "gamma"} },
+		tea.Tick(time.Hour, func(time.Time) tea.Msg { return "tick" }),
+	)
+}
+
+func (m *listModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case itemsLoadedMsg:
+		m.items = msg
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+	case string:
+		m.ticks++
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "down":
+			m.cursor++
+		case "enter":
+			m.selected = m.items[m.cursor]
+			return m, tea.Quit
+		case "ctrl+c":
+			return m, tea.Quit
+		default:
+			m.typed += msg.String()
+		}
+	}
+	return m, nil
+}
+
+func (m *listModel) View() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\x1b[1mwidth %d\x1b[0m   \n", m.width)
+	for i, item := range m.items {
+		cursor := " "
+		if i == m.cursor {
+			cursor = ">"
+		}
+		fmt.Fprintf(&b, "%s %s\n", cursor, item)
+	}
+	fmt.Fprintf(&b, "typed %q\n", m.typed)
+	return b.String()
+}
+
+func TestDriver(t *testing.T) {
+	m := &listModel{}
+	d := New(m, 40, 10)
+
+	if len(m.items) != 3 || m.width != 40 {
+		t.Fatalf("Init and size not applied: %+v", m)
+	}
+	if m.ticks != 0 {
+		t.Error("slow tick was waited for")
+	}
+
+	d.Type("hi there")
+	if err := d.Press("down"); err != nil {
+		t.Fatal(err)
+	}
+	want := "width 40\n  alpha\n> beta\n  gamma\ntyped \"hi there\"\n"
+	if got := d.View(); got != want {
+		t.Errorf("View() = %q, want %q", got, want)
+	}
+
+	d.Press("enter")
+	if !d.Quit() || m.selected != "beta" {
+		t.Errorf("Quit() = %v, selected %q", d.Quit(), m.selected)
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	tests := map[string]tea.KeyMsg{
+		"enter":  {Type: tea.KeyEnter},
+		"ctrl+c": {Type: tea.KeyCtrlC},
+		"up":     {Type: tea.KeyUp},
+		"alt+up": {Type: tea.KeyUp, Alt: true},
+		"q":      {Type: tea.KeyRunes, Runes: []rune{'q'}},
+	}
+	for name, want := range tests {
+		got, err := ParseKey(name)
+		if err != nil || got.String() != want.String() {
+			t.Errorf("ParseKey(%q) = %v, %v, want %v", name, got, err, want)
+		}
+	}
+	if _, err := ParseKey("hyper+x"); err == nil {
+		t.Error("ParseKey() accepted an unknown key")
+	}
+}
+
+func TestRunScript(t *testing.T) {
+	script := `
+# pick the last item
+resize 30 5
+type ab
+key down down
+expect "> gamma"
+snapshot picked
+key enter
+snapshot never
+`
+	var out bytes.Buffer
+	d := New(&listModel{}, 40, 10)
+	if err := RunScript(d, strings.NewReader(script), &out); err != nil {
+		t.Fatalf("RunScript() error: %v", err)
+	}
+	AssertGolden(t, "script", out.String())
+
+	err := RunScript(New(&listModel{}, 40, 10), strings.NewReader("expect missing"), &out)
+	if err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("RunScript() error = %v, want a failed expectation on line 1", err)
+	}
+}
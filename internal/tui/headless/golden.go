@@ -0,0 +1,36 @@
+package headless
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// UpdateGoldenEnv names the environment variable that makes AssertGolden
+// rewrite golden files instead of comparing against them
+const UpdateGoldenEnv = "CODEFORGE_UPDATE_GOLDEN"
+
+// AssertGolden compares a snapshot with testdata/<name>.golden. Run the
+// tests with CODEFORGE_UPDATE_GOLDEN=1 to accept a changed screen.
+func AssertGolden(t testing.TB, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+
+	if os.Getenv(UpdateGoldenEnv) != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file (create it with %s=1): %v", UpdateGoldenEnv, err)
+	}
+	if got != string(want) {
+		t.Errorf("screen differs from %s (accept with %s=1)\n--- want\n%s--- got\n%s", path, UpdateGoldenEnv, want, got)
+	}
+}
@@ -0,0 +1,99 @@
+package headless
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Recorder wraps a model and writes the keys and resizes it receives as a
+// script, so a session can be replayed with RunScript or Play. Mouse input
+// isn't recorded.
+type Recorder struct {
+	model tea.Model
+	w     io.Writer
+	typed strings.Builder // Text typed since the last other key
+	err   error
+}
+
+// NewRecorder records the input model receives to w
+func NewRecorder(model tea.Model, w io.Writer) *Recorder {
+	return &Recorder{model: model, w: w}
+}
+
+func (r *Recorder) Init() tea.Cmd {
+	return r.model.Init()
+}
+
+func (r *Recorder) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		r.record(msg)
+	case tea.WindowSizeMsg:
+		r.Flush()
+		r.writeLine(fmt.Sprintf("resize %d %d", msg.Width, msg.Height))
+	}
+
+	var cmd tea.Cmd
+	r.model, cmd = r.model.Update(msg)
+	return r, cmd
+}
+
+func (r *Recorder) View() string {
+	return r.model.View()
+}
+
+// record adds a key to the script, collecting typed text into one line
+func (r *Recorder) record(msg tea.KeyMsg) {
+	switch {
+	case msg.Type == tea.KeyRunes && !msg.Alt:
+		r.typed.WriteString(string(msg.Runes))
+	case msg.Type == tea.KeySpace && !msg.Alt:
+		r.typed.WriteByte(' ')
+	case msg.Type == tea.KeyRunes:
+		// ParseKey reads one rune per key
+		r.Flush()
+		for _, rn := range msg.Runes {
+			r.writeLine("key alt+" + string(rn))
+		}
+	case msg.Type == tea.KeySpace:
+		r.Flush()
+		r.writeLine("key alt+space")
+	default:
+		r.Flush()
+		r.writeLine("key " + msg.String())
+	}
+}
+
+// Flush writes text typed since the last other key. Call it when the
+// program ends.
+func (r *Recorder) Flush() {
+	if r.typed.Len() == 0 {
+		return
+	}
+	r.writeLine("type " + typeArg(r.typed.String()))
+	r.typed.Reset()
+}
+
+// Err returns the first error writing the script
+func (r *Recorder) Err() error {
+	return r.err
+}
+
+func (r *Recorder) writeLine(line string) {
+	if r.err == nil {
+		_, r.err = fmt.Fprintln(r.w, line)
+	}
+}
+
+// typeArg writes text for a type command, quoting it when typing it plainly
+// would lose spaces or read as escapes
+func typeArg(text string) string {
+	if strings.TrimSpace(text) != text || strings.Contains(text, `\`) || strings.HasPrefix(text, `"`) {
+		return strconv.Quote(text)
+	}
+	return text
+}
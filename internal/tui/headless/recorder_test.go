@@ -0,0 +1,47 @@
+package headless
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRecorder_Replays(t *testing.T) {
+	var script bytes.Buffer
+	recorded := &listModel{}
+	d := New(NewRecorder(recorded, &script), 40, 10)
+	d.Type(" hi ")
+	if err := d.Press("down", "alt+x", "enter"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "resize 40 10\ntype \" hi \"\nkey down\nkey alt+x\nkey enter\n"
+	if script.String() != want {
+		t.Fatalf("recorded %q, want %q", script.String(), want)
+	}
+
+	replayed := &listModel{}
+	if err := RunScript(New(replayed, 40, 10), &script, &bytes.Buffer{}); err != nil {
+		t.Fatalf("RunScript() error: %v", err)
+	}
+	if replayed.typed != recorded.typed || replayed.selected != recorded.selected {
+		t.Errorf("replay typed %q and selected %q, recording typed %q and selected %q",
+			replayed.typed, replayed.selected, recorded.typed, recorded.selected)
+	}
+}
+
+func TestParseScript_Errors(t *testing.T) {
+	tests := map[string]string{
+		"type a\nkey hyper+x": "line 2: unknown key",
+		"resize wide":         "line 1: resize needs",
+		"\n\nwait soon":       "line 3: invalid wait",
+		"key":                 "line 1: key needs",
+		"click 3 4":           `line 1: unknown command "click"`,
+	}
+	for script, want := range tests {
+		_, err := ParseScript(strings.NewReader(script))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParseScript(%q) error = %v, want %q", script, err, want)
+		}
+	}
+}
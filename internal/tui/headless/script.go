@@ -0,0 +1,209 @@
+package headless
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// keyTypes maps key names, as Bubble Tea prints them, to key types
+var keyTypes = func() map[string]tea.KeyType {
+	types := map[string]tea.KeyType{}
+	// Key types are small integers, negative for keys without a control code
+	for k := tea.KeyType(-100); k <= 127; k++ {
+		if name := k.String(); name != "" {
+			types[name] = k
+		}
+	}
+	types["space"] = tea.KeySpace
+	types["return"] = tea.KeyEnter
+	types["escape"] = tea.KeyEsc
+	return types
+}()
+
+// ParseKey turns a key name such as "enter", "ctrl+c", "alt+up" or "q"
+// into the message Bubble Tea would send for it
+func ParseKey(name string) (tea.KeyMsg, error) {
+	var msg tea.KeyMsg
+	if rest, ok := strings.CutPrefix(name, "alt+"); ok && rest != "" {
+		msg.Alt = true
+		name = rest
+	}
+
+	if k, ok := keyTypes[name]; ok {
+		msg.Type = k
+		if k == tea.KeySpace {
+			msg.Runes = []rune{' '}
+		}
+		return msg, nil
+	}
+	if utf8.RuneCountInString(name) == 1 {
+		msg.Type = tea.KeyRunes
+		msg.Runes = []rune(name)
+		return msg, nil
+	}
+	return msg, fmt.Errorf("unknown key %q", name)
+}
+
+// Command is one line of a script
+type Command struct {
+	Line int    // Line number in the script, from 1
+	Name string // type, key, resize, wait, snapshot or expect
+	Arg  string // Everything after the name, unquoted for type and expect
+
+	keys   []tea.KeyMsg  // For key
+	width  int           // For resize
+	height int           // For resize
+	wait   time.Duration // For wait
+}
+
+// ParseScript reads a script, one command per line. Blank lines and lines
+// starting with # are skipped.
+//
+//	type <text>         type text; \n and \t are enter and tab
+//	key <name>...       press keys, such as "enter", "ctrl+c" or "down"
+//	resize <w> <h>      change the terminal size
+//	wait <duration>     pause, for models that react to real time
+//	snapshot [name]     write the screen to out
+//	expect <text>       fail unless the screen contains text
+//
+// Text for type and expect may be quoted, Go style, to keep surrounding
+// spaces. Errors name the line of the first invalid command.
+func ParseScript(script io.Reader) ([]Command, error) {
+	var commands []Command
+	scanner := bufio.NewScanner(script)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		cmd, err := parseCommand(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		cmd.Line = line
+		commands = append(commands, cmd)
+	}
+	return commands, scanner.Err()
+}
+
+func parseCommand(text string) (Command, error) {
+	name, arg, _ := strings.Cut(text, " ")
+	cmd := Command{Name: name, Arg: strings.TrimSpace(arg)}
+
+	switch name {
+	case "type":
+		if unquoted, err := strconv.Unquote(cmd.Arg); err == nil {
+			cmd.Arg = unquoted
+		} else {
+			cmd.Arg = strings.NewReplacer(`\n`, "\n", `\t`, "\t").Replace(cmd.Arg)
+		}
+	case "key":
+		for _, key := range strings.Fields(cmd.Arg) {
+			msg, err := ParseKey(key)
+			if err != nil {
+				return cmd, err
+			}
+			cmd.keys = append(cmd.keys, msg)
+		}
+		if len(cmd.keys) == 0 {
+			return cmd, fmt.Errorf("key needs at least one key name")
+		}
+	case "resize":
+		if _, err := fmt.Sscanf(cmd.Arg, "%d %d", &cmd.width, &cmd.height); err != nil {
+			return cmd, fmt.Errorf("resize needs a width and height: %w", err)
+		}
+	case "wait":
+		wait, err := time.ParseDuration(cmd.Arg)
+		if err != nil {
+			return cmd, fmt.Errorf("invalid wait: %w", err)
+		}
+		cmd.wait = wait
+	case "snapshot":
+		if cmd.Arg == "" {
+			cmd.Arg = "screen"
+		}
+	case "expect":
+		if unquoted, err := strconv.Unquote(cmd.Arg); err == nil {
+			cmd.Arg = unquoted
+		}
+		if cmd.Arg == "" {
+			return cmd, fmt.Errorf("expect needs text to look for")
+		}
+	default:
+		return cmd, fmt.Errorf("unknown command %q", name)
+	}
+	return cmd, nil
+}
+
+// RunScript drives d with a script, writing snapshots to out. See
+// ParseScript for the commands. The script ends when the model quits, or
+// with an error at the first failed expectation, naming its line.
+func RunScript(d *Driver, script io.Reader, out io.Writer) error {
+	commands, err := ParseScript(script)
+	if err != nil {
+		return err
+	}
+	for _, cmd := range commands {
+		if err := runCommand(d, cmd, out); err != nil {
+			return fmt.Errorf("line %d: %w", cmd.Line, err)
+		}
+		if d.Quit() {
+			fmt.Fprintln(out, "--- quit ---")
+			return nil
+		}
+	}
+	return nil
+}
+
+func runCommand(d *Driver, cmd Command, out io.Writer) error {
+	switch cmd.Name {
+	case "type":
+		d.Type(cmd.Arg)
+	case "key":
+		for _, key := range cmd.keys {
+			d.Send(key)
+		}
+	case "resize":
+		d.Resize(cmd.width, cmd.height)
+	case "wait":
+		time.Sleep(cmd.wait)
+	case "snapshot":
+		fmt.Fprintf(out, "--- %s (%dx%d) ---\n%s", cmd.Arg, d.width, d.height, d.View())
+	case "expect":
+		if !strings.Contains(d.View(), cmd.Arg) {
+			return fmt.Errorf("screen doesn't contain %q:\n%s", cmd.Arg, d.View())
+		}
+	}
+	return nil
+}
+
+// Play replays commands into a running program at a human pace, pausing
+// delay between keys, for demos. Snapshots and expectations need a
+// headless driver and are skipped; resizes follow the real terminal.
+func Play(send func(tea.Msg), commands []Command, delay time.Duration) {
+	for _, cmd := range commands {
+		switch cmd.Name {
+		case "type":
+			for _, msg := range typeMsgs(cmd.Arg) {
+				send(msg)
+				time.Sleep(delay)
+			}
+		case "key":
+			for _, key := range cmd.keys {
+				send(key)
+				time.Sleep(delay)
+			}
+		case "wait":
+			time.Sleep(cmd.wait)
+		}
+	}
+}
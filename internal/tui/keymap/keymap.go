@@ -0,0 +1,357 @@
+// Package keymap lets users remap TUI key bindings from a keymap file.
+//
+// Components register their key binding structs under a scope; the keymap
+// file overrides the keys of individual actions:
+//
+//	{
+//	  "chat":   {"toggle_sidebar": ["ctrl+t"]},
+//	  "search": {"up": ["up", "ctrl+k"], "down": ["down", "ctrl+j"]}
+//	}
+package keymap
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+)
+
+// File maps scopes to actions to keys, as stored in the keymap file
+type File map[string]map[string][]string
+
+// Binding is one remappable action
+type Binding struct {
+	Scope       string
+	Action      string
+	Keys        []string
+	Description string
+
+	binding     *key.Binding
+	defaults    []string
+	defaultHelp string
+}
+
+// Section holds the bindings of one scope, in registration order
+type Section struct {
+	Scope    string
+	Bindings []*Binding
+}
+
+type registry struct {
+	mu       sync.RWMutex
+	sections []*Section
+}
+
+// Global registry of key bindings
+var global = &registry{}
+
+// Register adds the key.Binding fields of the struct keyMap points to under
+// scope. Actions are named after the fields in snake_case.
+func Register(scope string, keyMap interface{}) {
+	v := reflect.ValueOf(keyMap)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("keymap: Register(%q) needs a pointer to a struct, got %T", scope, keyMap))
+	}
+
+	section := &Section{Scope: scope}
+	bindingType := reflect.TypeOf(key.Binding{})
+	for i := 0; i < v.Elem().NumField(); i++ {
+		field := v.Elem().Field(i)
+		if field.Type() != bindingType || !field.CanAddr() {
+			continue
+		}
+		b := field.Addr().Interface().(*key.Binding)
+		section.Bindings = append(section.Bindings, &Binding{
+			Scope:       scope,
+			Action:      actionName(v.Elem().Type().Field(i).Name),
+			Keys:        b.Keys(),
+			Description: b.Help().Desc,
+			binding:     b,
+			defaults:    b.Keys(),
+			defaultHelp: b.Help().Key,
+		})
+	}
+
+	global.mu.Lock()
+	defer global.mu.Unlock()
+	global.sections = append(global.sections, section)
+}
+
+// Sections returns the registered bindings grouped by scope, sorted by scope
+func Sections() []Section {
+	global.mu.RLock()
+	defer global.mu.RUnlock()
+
+	sections := make([]Section, len(global.sections))
+	for i, s := range global.sections {
+		sections[i] = Section{Scope: s.Scope, Bindings: s.Bindings}
+	}
+	sort.SliceStable(sections, func(i, j int) bool { return sections[i].Scope < sections[j].Scope })
+	return sections
+}
+
+// Active returns the keys currently bound to every action
+func Active() File {
+	global.mu.RLock()
+	defer global.mu.RUnlock()
+
+	file := make(File)
+	for _, s := range global.sections {
+		if file[s.Scope] == nil {
+			file[s.Scope] = make(map[string][]string)
+		}
+		for _, b := range s.Bindings {
+			file[s.Scope][b.Action] = b.Keys
+		}
+	}
+	return file
+}
+
+// Path returns the user keymap file from the configuration
+func Path() string {
+	if cfg := config.Get(); cfg != nil {
+		return cfg.KeymapPath()
+	}
+	return (&config.Config{}).KeymapPath()
+}
+
+// Load applies the keymap file at path. A missing file keeps the defaults.
+func Load(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read keymap %s: %w", path, err)
+	}
+
+	var file File
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("failed to parse keymap %s: %w", path, err)
+	}
+	if err := Apply(file); err != nil {
+		return fmt.Errorf("invalid keymap %s: %w", path, err)
+	}
+	return nil
+}
+
+// Save writes the active key bindings to path, as a starting point for
+// editing
+func Save(path string) error {
+	data, err := json.MarshalIndent(Active(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode keymap: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create keymap directory: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write keymap %s: %w", path, err)
+	}
+	return nil
+}
+
+// Apply validates file and remaps the actions it names. Nothing changes
+// unless the whole file is valid.
+func Apply(file File) error {
+	global.mu.Lock()
+	defer global.mu.Unlock()
+
+	var errs []string
+	changes := make(map[*Binding][]string)
+	for scope, actions := range file {
+		section := global.section(scope)
+		if section == nil {
+			errs = append(errs, fmt.Sprintf("unknown scope %q", scope))
+			continue
+		}
+		for action, keys := range actions {
+			b := section.binding(action)
+			if b == nil {
+				errs = append(errs, fmt.Sprintf("unknown action %s.%s", scope, action))
+				continue
+			}
+			if len(keys) == 0 {
+				errs = append(errs, fmt.Sprintf("%s.%s: no keys", scope, action))
+				continue
+			}
+			normalized := make([]string, 0, len(keys))
+			for _, k := range keys {
+				n, err := normalizeKey(k)
+				if err != nil {
+					errs = append(errs, fmt.Sprintf("%s.%s: %v", scope, action, err))
+					continue
+				}
+				normalized = append(normalized, n)
+			}
+			changes[b] = normalized
+		}
+	}
+
+	// Remapped keys must not collide with another action of the same scope
+	for _, section := range global.sections {
+		owner := make(map[string]*Binding)
+		for _, b := range section.Bindings {
+			keys, changed := changes[b]
+			if !changed {
+				keys = b.Keys
+			}
+			for _, k := range keys {
+				other, taken := owner[k]
+				if taken && other != b {
+					if _, otherChanged := changes[other]; changed || otherChanged {
+						errs = append(errs, fmt.Sprintf("%s: %q is bound to both %s and %s", section.Scope, k, other.Action, b.Action))
+					}
+					continue
+				}
+				owner[k] = b
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return errors.New(strings.Join(errs, "; "))
+	}
+
+	for b, keys := range changes {
+		b.setKeys(keys)
+	}
+	return nil
+}
+
+// Reset restores the default keys of every action
+func Reset() {
+	global.mu.Lock()
+	defer global.mu.Unlock()
+
+	for _, s := range global.sections {
+		for _, b := range s.Bindings {
+			b.setKeys(b.defaults)
+			b.binding.SetHelp(b.defaultHelp, b.Description)
+		}
+	}
+}
+
+// HelpKeys renders keys the way help text shows them
+func HelpKeys(keys []string) string {
+	shown := make([]string, len(keys))
+	for i, k := range keys {
+		switch k {
+		case " ":
+			shown[i] = "space"
+		case "up":
+			shown[i] = "↑"
+		case "down":
+			shown[i] = "↓"
+		case "left":
+			shown[i] = "←"
+		case "right":
+			shown[i] = "→"
+		default:
+			shown[i] = k
+		}
+	}
+	return strings.Join(shown, "/")
+}
+
+// setKeys rebinds the action, keeping its help text in step. The caller
+// holds the registry lock.
+func (b *Binding) setKeys(keys []string) {
+	b.Keys = keys
+	b.binding.SetKeys(keys...)
+	b.binding.SetHelp(HelpKeys(keys), b.Description)
+}
+
+func (r *registry) section(scope string) *Section {
+	for _, s := range r.sections {
+		if s.Scope == scope {
+			return s
+		}
+	}
+	return nil
+}
+
+func (s *Section) binding(action string) *Binding {
+	for _, b := range s.Bindings {
+		if b.Action == action {
+			return b
+		}
+	}
+	return nil
+}
+
+// namedKeys are the non-character keys a binding can use
+var namedKeys = map[string]bool{
+	"up": true, "down": true, "left": true, "right": true,
+	"enter": true, "esc": true, "tab": true, "backspace": true,
+	"delete": true, "insert": true, "home": true, "end": true,
+	"pgup": true, "pgdown": true,
+}
+
+// normalizeKey checks a key is one the TUI can receive, spelling "space" as
+// the " " bubbletea reports
+func normalizeKey(k string) (string, error) {
+	if k == " " || k == "space" {
+		return " ", nil
+	}
+
+	parts := strings.Split(k, "+")
+	base := parts[len(parts)-1]
+	for _, mod := range parts[:len(parts)-1] {
+		if mod != "ctrl" && mod != "alt" && mod != "shift" {
+			return "", fmt.Errorf("invalid key %q: unknown modifier %q", k, mod)
+		}
+	}
+	if base == "space" {
+		base = " "
+	}
+
+	switch {
+	case namedKeys[base]:
+	case isFunctionKey(base):
+	case utf8.RuneCountInString(base) == 1:
+		if r, _ := utf8.DecodeRuneInString(base); !unicode.IsPrint(r) {
+			return "", fmt.Errorf("invalid key %q", k)
+		}
+	default:
+		return "", fmt.Errorf("invalid key %q", k)
+	}
+
+	parts[len(parts)-1] = base
+	return strings.Join(parts, "+"), nil
+}
+
+func isFunctionKey(k string) bool {
+	var n int
+	if _, err := fmt.Sscanf(k, "f%d", &n); err != nil {
+		return false
+	}
+	return n >= 1 && n <= 20 && k == fmt.Sprintf("f%d", n)
+}
+
+// actionName converts a field name like ToggleSidebar to toggle_sidebar
+func actionName(field string) string {
+	var b strings.Builder
+	runes := []rune(field)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
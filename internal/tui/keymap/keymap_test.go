@@ -0,0 +1,99 @@
+package keymap
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type testKeyMap struct {
+	ScrollUp   key.Binding
+	ScrollDown key.Binding
+	NewSession key.Binding
+}
+
+var testKeys = testKeyMap{
+	ScrollUp:   key.NewBinding(key.WithKeys("up", "k"), key.WithHelp("↑/k", "scroll up")),
+	ScrollDown: key.NewBinding(key.WithKeys("down", "j"), key.WithHelp("↓/j", "scroll down")),
+	NewSession: key.NewBinding(key.WithKeys("ctrl+n"), key.WithHelp("ctrl+n", "new session")),
+}
+
+func init() {
+	Register("test", &testKeys)
+}
+
+func TestApply(t *testing.T) {
+	defer Reset()
+
+	err := Apply(File{"test": {"new_session": {"ctrl+t"}, "scroll_up": {"up", "space"}}})
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	if !key.Matches(tea.KeyMsg{Type: tea.KeyCtrlT}, testKeys.NewSession) {
+		t.Error("Expected ctrl+t to trigger new_session")
+	}
+	if key.Matches(tea.KeyMsg{Type: tea.KeyCtrlN}, testKeys.NewSession) {
+		t.Error("Expected ctrl+n to no longer trigger new_session")
+	}
+	if got := testKeys.ScrollUp.Help().Key; got != "↑/space" {
+		t.Errorf("Expected help keys ↑/space, got %s", got)
+	}
+	if got := Active()["test"]["scroll_up"]; len(got) != 2 || got[1] != " " {
+		t.Errorf("Expected active keys [up, space], got %q", got)
+	}
+
+	Reset()
+	if !key.Matches(tea.KeyMsg{Type: tea.KeyCtrlN}, testKeys.NewSession) || testKeys.ScrollUp.Help().Key != "↑/k" {
+		t.Error("Expected Reset to restore the defaults")
+	}
+}
+
+func TestApplyValidation(t *testing.T) {
+	defer Reset()
+
+	tests := []struct {
+		name string
+		file File
+		want string
+	}{
+		{"unknown scope", File{"nope": {"up": {"k"}}}, `unknown scope "nope"`},
+		{"unknown action", File{"test": {"jump": {"g"}}}, "unknown action test.jump"},
+		{"no keys", File{"test": {"scroll_up": {}}}, "no keys"},
+		{"invalid key", File{"test": {"scroll_up": {"hyper+x"}}}, `unknown modifier "hyper"`},
+		{"invalid name", File{"test": {"scroll_up": {"pageup"}}}, `invalid key "pageup"`},
+		{"conflict", File{"test": {"scroll_up": {"j"}}}, `"j" is bound to both`},
+	}
+
+	for _, tt := range tests {
+		err := Apply(tt.file)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.name, tt.want, err)
+		}
+	}
+
+	// A rejected file leaves every binding alone
+	if err := Apply(File{"test": {"new_session": {"ctrl+t"}, "scroll_up": {"j"}}}); err == nil {
+		t.Fatal("Expected conflict error")
+	}
+	if !key.Matches(tea.KeyMsg{Type: tea.KeyCtrlN}, testKeys.NewSession) {
+		t.Error("Expected an invalid file to leave new_session unchanged")
+	}
+}
+
+func TestActionName(t *testing.T) {
+	tests := map[string]string{
+		"ToggleSidebar":   "toggle_sidebar",
+		"Up":              "up",
+		"J":               "j",
+		"ShowRecommended": "show_recommended",
+		"HTMLExport":      "html_export",
+	}
+	for field, want := range tests {
+		if got := actionName(field); got != want {
+			t.Errorf("actionName(%q) = %q, want %q", field, got, want)
+		}
+	}
+}
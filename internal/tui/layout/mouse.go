@@ -0,0 +1,61 @@
+package layout
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Region is a rectangle of the screen, for mouse hit testing
+type Region struct {
+	X, Y          int
+	Width, Height int
+}
+
+// Contains reports whether the cell at x, y is inside the region
+func (r Region) Contains(x, y int) bool {
+	return x >= r.X && x < r.X+r.Width && y >= r.Y && y < r.Y+r.Height
+}
+
+// ListRows maps the rows of a rendered list back to its items, so a click
+// can select the item under the pointer
+type ListRows struct {
+	// Top is the row of the first list line, relative to the component
+	Top int
+	// Items holds the item index of each list line, or -1 for lines such
+	// as scroll indicators that aren't items
+	Items []int
+}
+
+// ItemAt returns the item rendered at row y
+func (l ListRows) ItemAt(y int) (int, bool) {
+	row := y - l.Top
+	if row < 0 || row >= len(l.Items) || l.Items[row] < 0 {
+		return 0, false
+	}
+	return l.Items[row], true
+}
+
+// WheelDelta returns -1 for a wheel-up event, 1 for wheel-down, 0 otherwise
+func WheelDelta(msg tea.MouseMsg) int {
+	if msg.Action != tea.MouseActionPress {
+		return 0
+	}
+	switch msg.Button {
+	case tea.MouseButtonWheelUp:
+		return -1
+	case tea.MouseButtonWheelDown:
+		return 1
+	}
+	return 0
+}
+
+// IsLeftClick reports whether msg is a left button press
+func IsLeftClick(msg tea.MouseMsg) bool {
+	return msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft
+}
+
+// Translate returns msg with coordinates relative to the cell at x, y
+func Translate(msg tea.MouseMsg, x, y int) tea.MouseMsg {
+	msg.X -= x
+	msg.Y -= y
+	return msg
+}
@@ -0,0 +1,57 @@
+package layout
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestListRowsItemAt(t *testing.T) {
+	// A scroll indicator, then item 4 on one line and item 5 on two
+	rows := ListRows{Top: 3, Items: []int{-1, 4, 5, 5}}
+
+	tests := []struct {
+		y    int
+		item int
+		ok   bool
+	}{
+		{2, 0, false},
+		{3, 0, false},
+		{4, 4, true},
+		{5, 5, true},
+		{6, 5, true},
+		{7, 0, false},
+	}
+	for _, tt := range tests {
+		item, ok := rows.ItemAt(tt.y)
+		if ok != tt.ok || item != tt.item {
+			t.Errorf("ItemAt(%d) = %d, %v; want %d, %v", tt.y, item, ok, tt.item, tt.ok)
+		}
+	}
+}
+
+func TestOverlayOriginAndTranslate(t *testing.T) {
+	overlay := "+--+\n|  |\n+--+"
+	x, y := OverlayOrigin(20, 10, overlay, Center)
+	if x != 8 || y != 3 {
+		t.Fatalf("OverlayOrigin = %d, %d; want 8, 3", x, y)
+	}
+
+	click := tea.MouseMsg{X: 9, Y: 4, Action: tea.MouseActionPress, Button: tea.MouseButtonLeft}
+	local := Translate(click, x, y)
+	if local.X != 1 || local.Y != 1 || !IsLeftClick(local) {
+		t.Errorf("Translate = %+v; want a left click at 1, 1", local)
+	}
+	if !(Region{X: x, Y: y, Width: 4, Height: 3}).Contains(click.X, click.Y) {
+		t.Error("Expected the click inside the overlay region")
+	}
+}
+
+func TestWheelDelta(t *testing.T) {
+	up := tea.MouseMsg{Action: tea.MouseActionPress, Button: tea.MouseButtonWheelUp}
+	down := tea.MouseMsg{Action: tea.MouseActionPress, Button: tea.MouseButtonWheelDown}
+	click := tea.MouseMsg{Action: tea.MouseActionPress, Button: tea.MouseButtonLeft}
+	if WheelDelta(up) != -1 || WheelDelta(down) != 1 || WheelDelta(click) != 0 {
+		t.Error("Expected -1 for wheel up, 1 for wheel down and 0 for clicks")
+	}
+}
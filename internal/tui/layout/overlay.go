@@ -31,7 +31,44 @@ func PlaceOverlay(width, height int, overlay, background string, pos Position) s
 		backgroundLines = append(backgroundLines, strings.Repeat(" ", width))
 	}
 	
+	// Calculate position
+	startX, startY := OverlayOrigin(width, height, overlay, pos)
+	
+	// Create result lines
+	result := make([]string, height)
+	copy(result, backgroundLines[:height])
+	
+	// Overlay the content
+	for i, line := range overlayLines {
+		if y := startY + i; y >= 0 && y < height {
+			// Convert background line to runes for proper positioning
+			bgRunes := []rune(result[y])
+			
+			// Ensure background line is wide enough
+			for len(bgRunes) < width {
+				bgRunes = append(bgRunes, ' ')
+			}
+			
+			// Overlay the line
+			overlayRunes := []rune(line)
+			for j, r := range overlayRunes {
+				if x := startX + j; x >= 0 && x < len(bgRunes) {
+					bgRunes[x] = r
+				}
+			}
+			
+			result[y] = string(bgRunes)
+		}
+	}
+	
+	return strings.Join(result[:height], "\n")
+}
+
+// OverlayOrigin returns the screen cell where PlaceOverlay puts the top-left
+// corner of overlay, for mapping mouse events onto it
+func OverlayOrigin(width, height int, overlay string, pos Position) (int, int) {
 	// Calculate overlay dimensions
+	overlayLines := strings.Split(overlay, "\n")
 	overlayHeight := len(overlayLines)
 	overlayWidth := 0
 	for _, line := range overlayLines {
@@ -86,32 +123,5 @@ func PlaceOverlay(width, height int, overlay, background string, pos Position) s
 		startY = height - overlayHeight
 	}
 	
-	// Create result lines
-	result := make([]string, height)
-	copy(result, backgroundLines[:height])
-	
-	// Overlay the content
-	for i, line := range overlayLines {
-		if y := startY + i; y >= 0 && y < height {
-			// Convert background line to runes for proper positioning
-			bgRunes := []rune(result[y])
-			
-			// Ensure background line is wide enough
-			for len(bgRunes) < width {
-				bgRunes = append(bgRunes, ' ')
-			}
-			
-			// Overlay the line
-			overlayRunes := []rune(line)
-			for j, r := range overlayRunes {
-				if x := startX + j; x >= 0 && x < len(bgRunes) {
-					bgRunes[x] = r
-				}
-			}
-			
-			result[y] = string(bgRunes)
-		}
-	}
-	
-	return strings.Join(result[:height], "\n")
-}
\ No newline at end of file
+	return startX, startY
+}
@@ -2,6 +2,7 @@ package page
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -13,14 +14,24 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/entrepeneur4lyf/codeforge/internal/app"
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
 	contextmgmt "github.com/entrepeneur4lyf/codeforge/internal/context"
+	"github.com/entrepeneur4lyf/codeforge/internal/diagram"
 	"github.com/entrepeneur4lyf/codeforge/internal/events"
+	"github.com/entrepeneur4lyf/codeforge/internal/fileutil"
+	"github.com/entrepeneur4lyf/codeforge/internal/followup"
 	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+	"github.com/entrepeneur4lyf/codeforge/internal/llm/tools"
+	"github.com/entrepeneur4lyf/codeforge/internal/notes"
 	"github.com/entrepeneur4lyf/codeforge/internal/permissions"
+	"github.com/entrepeneur4lyf/codeforge/internal/privacy"
 	"github.com/entrepeneur4lyf/codeforge/internal/tui/components/chat"
 	dialog "github.com/entrepeneur4lyf/codeforge/internal/tui/components/dialogs"
 	"github.com/entrepeneur4lyf/codeforge/internal/tui/components/status"
 	"github.com/entrepeneur4lyf/codeforge/internal/tui/components/toast"
+	"github.com/entrepeneur4lyf/codeforge/internal/tui/keymap"
+	"github.com/entrepeneur4lyf/codeforge/internal/tui/layout"
+	"github.com/entrepeneur4lyf/codeforge/internal/tui/styles"
 	"github.com/entrepeneur4lyf/codeforge/internal/tui/theme"
 )
 
@@ -64,6 +75,7 @@ type ChatPage struct {
 	
 	// Mouse state
 	hoveredButton int // -1 means no button is hovered
+	chatRegion    layout.Region // where the chat history was last drawn
 }
 
 // NewChatPage creates a new chat page
@@ -168,6 +180,22 @@ func (p *ChatPage) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		p.updateLayout()
 		
 	case tea.MouseMsg:
+		// The wheel scrolls the chat history; clicking it focuses it
+		if delta := layout.WheelDelta(msg); delta != 0 {
+			if p.chatRegion.Contains(msg.X, msg.Y) {
+				if delta < 0 {
+					p.chatView.ScrollUp()
+				} else {
+					p.chatView.ScrollDown()
+				}
+			}
+			return p, nil
+		}
+		if layout.IsLeftClick(msg) && p.chatRegion.Contains(msg.X, msg.Y) && p.focusIndex != 0 {
+			p.focusIndex = 0
+			cmds = append(cmds, p.updateFocus())
+		}
+		
 		// Status bar is at the bottom, occupying the last 2 lines
 		// The toolbar buttons are on the first line of the status bar
 		statusBarStartY := p.height - 2
@@ -280,6 +308,31 @@ func (p *ChatPage) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		
 	case chat.MessageSubmitMsg:
+		// A number sends the suggested follow-up it labels
+		if prompt, ok := followup.Pick(p.chatView.FollowUps(), msg.Content); ok {
+			msg.Content = prompt
+		}
+
+		// /notes opens the notes panel; /notes <text> adds a note
+		if text, ok := notesCommand(msg.Content); ok {
+			return p, p.handleNotes(text)
+		}
+
+		// /keys shows the key bindings; /keys init writes them to the keymap file
+		if arg, ok := keysCommand(msg.Content); ok {
+			return p, p.handleKeys(arg)
+		}
+
+		// /apikey [provider] replaces a provider's API key
+		if provider, ok := apiKeyCommand(msg.Content); ok {
+			return p, func() tea.Msg { return dialog.ShowAPIKeyDialogMsg{Provider: provider} }
+		}
+
+		// /diagram opens the last response's diagrams in the browser
+		if strings.TrimSpace(msg.Content) == "/diagram" {
+			return p, p.openDiagrams()
+		}
+		
 		// Handle message submission
 		if !p.isProcessing {
 			p.isProcessing = true
@@ -315,6 +368,11 @@ func (p *ChatPage) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// Start streaming in chat view
 			p.chatView.StartStreaming()
 			
+			// Warn about attachments that will be cut short or left out
+			for _, warning := range attachmentWarnings(msg.Attachments) {
+				cmds = append(cmds, toast.NewWarningToast(warning, p.theme, toast.WithDuration(4*time.Second)))
+			}
+			
 			// Process with LLM
 			cmds = append(cmds, p.processMessage(msg.Content, msg.Attachments))
 			
@@ -407,6 +465,14 @@ func (p *ChatPage) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				toast.WithTitle("Error"),
 				toast.WithDuration(5*time.Second),
 			))
+		} else {
+			cmds = append(cmds, p.suggestFollowUps())
+		}
+		
+	case followUpsMsg:
+		// Suggestions for an earlier answer are stale
+		if msg.sessionID == p.currentSessionID && !p.isProcessing {
+			p.chatView.SetFollowUps(msg.prompts)
 		}
 		
 	case dialog.FileSelectedMsg:
@@ -437,6 +503,33 @@ func (p *ChatPage) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			toast.WithDuration(3*time.Second),
 		))
 		
+	case dialog.APIKeySavedMsg:
+		if msg.Err != nil {
+			cmds = append(cmds, toast.NewErrorToast(msg.Err.Error(), p.theme, toast.WithTitle("API Key")))
+		} else {
+			cmds = append(cmds, toast.NewSuccessToast(
+				fmt.Sprintf("%s key replaced; it applies from the next message", msg.Provider),
+				p.theme,
+				toast.WithDuration(3*time.Second),
+			))
+		}
+
+	case dialog.NotesSavedMsg:
+		if msg.Err != nil {
+			cmds = append(cmds, toast.NewErrorToast(
+				fmt.Sprintf("Failed to save notes: %v", msg.Err),
+				p.theme,
+				toast.WithTitle("Error"),
+				toast.WithDuration(5*time.Second),
+			))
+		} else {
+			cmds = append(cmds, toast.NewSuccessToast(
+				"Notes saved",
+				p.theme,
+				toast.WithDuration(2*time.Second),
+			))
+		}
+		
 	case chat.ToolbarClickMsg:
 		// Handle toolbar button clicks
 		switch msg.Action {
@@ -547,6 +640,7 @@ func (p *ChatPage) View() string {
 	
 	// Chat view
 	p.chatView.SetSize(mainWidth, chatHeight)
+	p.chatRegion = layout.Region{X: sidebarWidth, Y: lipgloss.Height(header), Width: mainWidth, Height: chatHeight}
 	chatContent := p.chatView.View()
 	
 	// Editor
@@ -788,6 +882,11 @@ func (p *ChatPage) updateFocus() tea.Cmd {
 
 // debugLog writes debug information to a log file
 func (p *ChatPage) debugLog(format string, args ...interface{}) {
+	// The log quotes messages, which ephemeral sessions keep off disk
+	if privacy.IsEphemeral() {
+		return
+	}
+
 	// Create log directory if it doesn't exist
 	debugDir := filepath.Join(p.app.WorkspaceRoot, "log")
 	if err := os.MkdirAll(debugDir, 0755); err != nil {
@@ -807,6 +906,48 @@ func (p *ChatPage) debugLog(format string, args ...interface{}) {
 	logger.Printf(format, args...)
 }
 
+// attachmentWarnings describes attachments that are binary or over the file
+// size limit, so the user knows the model won't see all of them
+func attachmentWarnings(paths []string) []string {
+	var warnings []string
+	limit := fileutil.MaxFileSize()
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		name := filepath.Base(path)
+		switch {
+		case fileutil.IsBinaryFile(path):
+			warnings = append(warnings, fmt.Sprintf("%s is a binary file and won't be attached", name))
+		case info.Size() > limit && fileutil.OutlineLargeAttachments():
+			warnings = append(warnings, fmt.Sprintf("%s is %s; the model gets an outline and reads the parts it needs",
+				name, fileutil.FormatSize(info.Size())))
+		case info.Size() > limit:
+			warnings = append(warnings, fmt.Sprintf("%s is %s; only the first %s will be attached",
+				name, fileutil.FormatSize(info.Size()), fileutil.FormatSize(limit)))
+		}
+	}
+	return warnings
+}
+
+// largeAttachmentOutline returns the outline sent in place of an attachment
+// over the size limit when files.largeAttachments is "outline"
+func largeAttachmentOutline(ctx context.Context, path string, limit int64) (string, bool) {
+	if !fileutil.OutlineLargeAttachments() {
+		return "", false
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.Size() <= limit || fileutil.IsBinaryFile(path) {
+		return "", false
+	}
+	outline, err := tools.FileOutline(ctx, path)
+	if err != nil {
+		return "", false
+	}
+	return outline, true
+}
+
 // processMessage sends the message to the LLM and streams the response
 func (p *ChatPage) processMessage(content string, attachments []string) tea.Cmd {
 	return func() tea.Msg {
@@ -823,28 +964,49 @@ func (p *ChatPage) processMessage(content string, attachments []string) tea.Cmd
 		fullContent := content
 		if len(attachments) > 0 {
 			fullContent += "\n\nAttached files:\n"
+			limit := fileutil.MaxFileSize()
 			for _, path := range attachments {
+				if outline, ok := largeAttachmentOutline(ctx, path, limit); ok {
+					fullContent += fmt.Sprintf("\n--- %s ---\n%s", path, outline)
+					continue
+				}
+
 				// Read file content using app's file operation manager
+				var file fileutil.TextFile
+				var err error
 				fileOps := p.app.GetFileOperationManager()
 				if fileOps != nil {
-					fileContent, err := fileOps.ReadFile(ctx, &permissions.FileOperationRequest{
+					var result *permissions.FileOperationResult
+					result, err = fileOps.ReadFile(ctx, &permissions.FileOperationRequest{
 						SessionID: p.currentSessionID,
 						Operation: "read",
 						Path:      path,
+						MaxBytes:  limit,
 					})
-					if err != nil {
-						fullContent += fmt.Sprintf("\n--- %s ---\nError reading file: %v\n", path, err)
-					} else {
-						fullContent += fmt.Sprintf("\n--- %s ---\n%s\n", path, fileContent.Content)
+					if err == nil && !result.Success {
+						err = errors.New(result.Error)
+					}
+					if err == nil {
+						size := int64(len(result.Content))
+						if result.FileInfo != nil {
+							size = result.FileInfo.Size()
+						}
+						file, err = fileutil.ToText(result.Content, size)
 					}
 				} else {
 					// Fallback to direct file reading
-					data, err := os.ReadFile(path)
-					if err != nil {
-						fullContent += fmt.Sprintf("\n--- %s ---\nError reading file: %v\n", path, err)
-					} else {
-						fullContent += fmt.Sprintf("\n--- %s ---\n%s\n", path, string(data))
-					}
+					file, err = fileutil.ReadText(path, limit)
+				}
+
+				switch {
+				case errors.Is(err, fileutil.ErrBinaryFile):
+					fullContent += fmt.Sprintf("\n--- %s ---\n[binary file, %s, not attached]\n", path, fileutil.FormatSize(file.Size))
+				case err != nil:
+					fullContent += fmt.Sprintf("\n--- %s ---\nError reading file: %v\n", path, err)
+				case file.Truncated:
+					fullContent += fmt.Sprintf("\n--- %s ---\n%s\n%s\n", path, file.Content, file.TruncationNote())
+				default:
+					fullContent += fmt.Sprintf("\n--- %s ---\n%s\n", path, file.Content)
 				}
 			}
 		}
@@ -901,11 +1063,27 @@ func (p *ChatPage) processMessage(content string, attachments []string) tea.Cmd
 			totalChunks := 0
 			totalLength := 0
 			
-			// Forward chunks from the response stream channel
+			// Forward text from the response stream channel, noting
+			// responses that were cut short
 			for chunk := range respStreamChan {
+				text := ""
+				switch c := chunk.(type) {
+				case llm.ApiStreamTextChunk:
+					text = c.Text
+				case llm.ApiStreamFinishChunk:
+					switch c.Reason {
+					case llm.FinishLength:
+						text = "\n\n*Response truncated: the output token limit was reached*"
+					case llm.FinishContentFilter:
+						text = "\n\n*Response stopped by the provider's content filter*"
+					}
+				}
+				if text == "" {
+					continue
+				}
 				totalChunks++
-				totalLength += len(chunk)
-				streamChan <- chunk
+				totalLength += len(text)
+				streamChan <- text
 			}
 			
 			p.debugLog("Streaming completed - Total chunks: %d, Total characters: %d", totalChunks, totalLength)
@@ -970,7 +1148,130 @@ type streamCompleteMsg struct {
 	error error
 }
 
+// followUpsMsg carries the follow-up prompts suggested for a session's last
+// answer
+type followUpsMsg struct {
+	sessionID string
+	prompts   []string
+}
+
+// suggestFollowUps asks for follow-up prompts to the last answer when
+// tui.followUps is on
+func (p *ChatPage) suggestFollowUps() tea.Cmd {
+	cfg := config.Get()
+	if cfg == nil || !cfg.TUI.FollowUps || p.app == nil {
+		return nil
+	}
+
+	model := cfg.TUI.FollowUpModel
+	if model == "" || (cfg.ResolveModel(model) == model && !strings.Contains(model, "/")) {
+		model = p.currentModel // The alias isn't configured
+	}
+	sessionID := p.currentSessionID
+	question, answer := p.chatView.LastUserContent(), p.chatView.LastAssistantContent()
+
+	return func() tea.Msg {
+		handler := p.app.GetLLMHandler(model)
+		if handler == nil {
+			return nil
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		prompts, err := followup.Suggest(ctx, handler, question, answer)
+		if err != nil {
+			p.debugLog("Follow-up suggestions failed: %v", err)
+			return nil
+		}
+		return followUpsMsg{sessionID: sessionID, prompts: prompts}
+	}
+}
+
 // getModelInfo returns a formatted string with the current model
+// notesCommand reports whether content is a /notes command and returns its text
+func notesCommand(content string) (string, bool) {
+	content = strings.TrimSpace(content)
+	if content != "/notes" && !strings.HasPrefix(content, "/notes ") && !strings.HasPrefix(content, "/notes\n") {
+		return "", false
+	}
+	return strings.TrimSpace(strings.TrimPrefix(content, "/notes")), true
+}
+
+// handleNotes opens the notes panel, or appends text to the project notes
+func (p *ChatPage) handleNotes(text string) tea.Cmd {
+	if text == "" {
+		return func() tea.Msg { return dialog.ShowNotesDialogMsg{} }
+	}
+	
+	store := notes.ForProject()
+	if store == nil {
+		return toast.NewErrorToast("Configuration not loaded", p.theme, toast.WithTitle("Notes"))
+	}
+	if err := store.Append(text, time.Now()); err != nil {
+		return toast.NewErrorToast(err.Error(), p.theme, toast.WithTitle("Notes"))
+	}
+	return toast.NewSuccessToast("Note added", p.theme, toast.WithDuration(2*time.Second))
+}
+
+// openDiagrams renders the Mermaid and Graphviz diagrams of the last
+// response to a page and opens it in the browser
+func (p *ChatPage) openDiagrams() tea.Cmd {
+	diagrams := diagram.Extract(p.chatView.LastAssistantContent())
+	if len(diagrams) == 0 {
+		return toast.NewInfoToast("The last response has no diagrams", p.theme, toast.WithTitle("Diagrams"))
+	}
+	cfg := config.Get()
+	if cfg == nil {
+		return toast.NewErrorToast("Configuration not loaded", p.theme, toast.WithTitle("Diagrams"))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	path, err := diagram.Open(ctx, cfg.DiagramDir(), diagrams)
+	if err != nil {
+		return toast.NewErrorToast(err.Error(), p.theme, toast.WithTitle("Diagrams"))
+	}
+	return toast.NewSuccessToast("Opened "+path, p.theme, toast.WithDuration(3*time.Second))
+}
+
+// keysCommand reports whether content is a /keys command and returns its argument
+func keysCommand(content string) (string, bool) {
+	fields := strings.Fields(content)
+	if len(fields) == 0 || fields[0] != "/keys" {
+		return "", false
+	}
+	return strings.Join(fields[1:], " "), true
+}
+
+// apiKeyCommand reports whether content is an /apikey command and returns
+// the provider it names
+func apiKeyCommand(content string) (string, bool) {
+	fields := strings.Fields(content)
+	if len(fields) == 0 || fields[0] != "/apikey" {
+		return "", false
+	}
+	return strings.Join(fields[1:], " "), true
+}
+
+// handleKeys opens the key bindings overlay, or writes the active bindings
+// to the keymap file for editing
+func (p *ChatPage) handleKeys(arg string) tea.Cmd {
+	switch arg {
+	case "":
+		return func() tea.Msg { return dialog.ShowKeysDialogMsg{} }
+	case "init":
+		path := keymap.Path()
+		if _, err := os.Stat(path); err == nil {
+			return toast.NewInfoToast(fmt.Sprintf("%s already exists", path), p.theme, toast.WithTitle("Keys"))
+		}
+		if err := keymap.Save(path); err != nil {
+			return toast.NewErrorToast(err.Error(), p.theme, toast.WithTitle("Keys"))
+		}
+		return toast.NewSuccessToast(fmt.Sprintf("Wrote %s", path), p.theme, toast.WithDuration(3*time.Second))
+	default:
+		return toast.NewErrorToast("Usage: /keys [init]", p.theme, toast.WithTitle("Keys"))
+	}
+}
+
 func (p *ChatPage) getModelInfo() string {
 	// Extract just the model name from the full path
 	parts := strings.Split(p.currentModel, "/")
@@ -1041,7 +1342,7 @@ func (p *ChatPage) renderToolbarButtons() string {
 				Bold(true)
 		}
 		
-		renderedButtons = append(renderedButtons, style.Render(btn.icon))
+		renderedButtons = append(renderedButtons, style.Render(styles.Icon(btn.icon)))
 	}
 	
 	// Add tooltip if hovering
@@ -1165,4 +1466,8 @@ var chatKeys = chatKeyMap{
 		key.WithKeys("down", "j"),
 		key.WithHelp("↓/j", "scroll down"),
 	),
-}
\ No newline at end of file
+}
+
+func init() {
+	keymap.Register("chat", &chatKeys)
+}
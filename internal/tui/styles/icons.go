@@ -1,14 +1,96 @@
 package styles
 
-const (
-	CodeForgeIcon string = "💎"
-
-	CheckIcon    string = "✓"
-	ErrorIcon    string = "✖"
-	WarningIcon  string = "⚠"
-	InfoIcon     string = ""
-	HintIcon     string = "i"
-	SpinnerIcon  string = "..."
-	LoadingIcon  string = "⟳"
-	DocumentIcon string = "🖼"
+import "unicode/utf8"
+
+var (
+	CodeForgeIcon = "💎"
+
+	CheckIcon    = "✓"
+	ErrorIcon    = "✖"
+	WarningIcon  = "⚠"
+	InfoIcon     = ""
+	HintIcon     = "i"
+	SpinnerIcon  = "..."
+	LoadingIcon  = "⟳"
+	DocumentIcon = "🖼"
 )
+
+// asciiOnly replaces icons with ASCII, for terminals and fonts that render
+// emoji as boxes or at the wrong width
+var asciiOnly bool
+
+// asciiIcons holds the ASCII equivalent of each icon the views use
+var asciiIcons = map[string]string{
+	"💎":  "<>",
+	"✓":  "+",
+	"✅":  "+",
+	"✖":  "x",
+	"✗":  "x",
+	"❌":  "x",
+	"⚠":  "!",
+	"⚠️": "!",
+	"ℹ️": "i",
+	"💡":  "?",
+	"⟳":  "~",
+	"⏳":  "~",
+	"🖼":  "[img]",
+	"🖼️": "[img]",
+	"🔍":  ">",
+	"📍":  "@",
+	"📎":  "@",
+	"🔗":  "@",
+	"➕":  "+",
+	"🔄":  "+",
+	"🗑":  "-",
+	"📁":  "/",
+	"📂":  "/",
+	"🤖":  "M",
+	"▶":  ">",
+	"▸":  ">",
+	"▼":  "v",
+	"❯":  ">",
+	"┃":  "|",
+	"★":  "*",
+	"🧠":  "R",
+	"👁":  "V",
+	"🔧":  "T",
+	"•":  "*",
+	"💚":  "$",
+	"🟡":  "$$",
+	"🔴":  "$$$",
+}
+
+// SetASCIIOnly switches every icon to its ASCII equivalent, or back
+func SetASCIIOnly(on bool) {
+	asciiOnly = on
+
+	CodeForgeIcon = Icon("💎")
+	CheckIcon = Icon("✓")
+	ErrorIcon = Icon("✖")
+	WarningIcon = Icon("⚠")
+	LoadingIcon = Icon("⟳")
+	DocumentIcon = Icon("🖼")
+}
+
+// ASCIIOnly reports whether icons are shown as ASCII
+func ASCIIOnly() bool {
+	return asciiOnly
+}
+
+// Icon returns icon as it should be shown: unchanged normally, or its ASCII
+// equivalent in ASCII-only mode. Icons without one, such as file type
+// emoji, become "-".
+func Icon(icon string) string {
+	if !asciiOnly {
+		return icon
+	}
+	if ascii, ok := asciiIcons[icon]; ok {
+		return ascii
+	}
+	for _, r := range icon {
+		if r >= utf8.RuneSelf {
+			return "-"
+		}
+	}
+	return icon
+}
@@ -0,0 +1,33 @@
+package styles
+
+import "testing"
+
+func TestIconASCIIOnly(t *testing.T) {
+	defer SetASCIIOnly(false)
+
+	if got := Icon("★"); got != "★" {
+		t.Errorf("Icon(★) = %q before ASCII-only mode", got)
+	}
+
+	SetASCIIOnly(true)
+	tests := map[string]string{
+		"★":  "*",
+		"⚠️": "!",
+		"🐹":  "-",
+		"":   "",
+		"go": "go",
+	}
+	for icon, want := range tests {
+		if got := Icon(icon); got != want {
+			t.Errorf("Icon(%q) = %q, want %q", icon, got, want)
+		}
+	}
+	if CheckIcon != "+" || WarningIcon != "!" {
+		t.Errorf("icon variables not switched: %q %q", CheckIcon, WarningIcon)
+	}
+
+	SetASCIIOnly(false)
+	if CheckIcon != "✓" {
+		t.Errorf("CheckIcon = %q after leaving ASCII-only mode", CheckIcon)
+	}
+}
@@ -41,7 +41,7 @@ func generateMarkdownStyleConfig() ansi.StyleConfig {
 			StylePrimitive: ansi.StylePrimitive{
 				Color:  stringPtr(adaptiveColorToString(t.MarkdownBlockQuote())),
 				Italic: boolPtr(true),
-				Prefix: "┃ ",
+				Prefix: Icon("┃") + " ",
 			},
 			Indent:      uintPtr(1),
 			IndentToken: stringPtr(BaseStyle().Render(" ")),
@@ -130,7 +130,7 @@ func generateMarkdownStyleConfig() ansi.StyleConfig {
 		},
 		Task: ansi.StyleTask{
 			StylePrimitive: ansi.StylePrimitive{},
-			Ticked:         "[✓] ",
+			Ticked:         "[" + Icon("✓") + "] ",
 			Unticked:       "[ ] ",
 		},
 		Link: ansi.StylePrimitive{
@@ -144,7 +144,7 @@ func generateMarkdownStyleConfig() ansi.StyleConfig {
 		Image: ansi.StylePrimitive{
 			Color:     stringPtr(adaptiveColorToString(t.MarkdownImage())),
 			Underline: boolPtr(true),
-			Format:    "🖼 {{.text}}",
+			Format:    Icon("🖼") + " {{.text}}",
 		},
 		ImageText: ansi.StylePrimitive{
 			Color:  stringPtr(adaptiveColorToString(t.MarkdownImageText())),
@@ -260,7 +260,7 @@ func generateMarkdownStyleConfig() ansi.StyleConfig {
 			RowSeparator:    stringPtr("─"),
 		},
 		DefinitionDescription: ansi.StylePrimitive{
-			BlockPrefix: "\n ❯ ",
+			BlockPrefix: "\n " + Icon("❯") + " ",
 			Color:       stringPtr(adaptiveColorToString(t.MarkdownLinkText())),
 		},
 		Text: ansi.StylePrimitive{
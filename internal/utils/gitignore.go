@@ -5,6 +5,8 @@ import (
 	"path/filepath"
 
 	gitignore "github.com/sabhiram/go-gitignore"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/ignore"
 )
 
 // GitIgnoreFilter provides gitignore-aware file filtering
@@ -50,18 +52,13 @@ func (g *GitIgnoreFilter) loadGitIgnorePatterns() {
 		}
 	}
 
-	// Fallback to common ignore patterns if no .gitignore files found
-	patterns = append(patterns, getDefaultIgnorePatterns()...)
-
+	// Without ignore files only the shared excludes checked in IsIgnored apply
 	g.ignore = gitignore.CompileIgnoreLines(patterns...)
 }
 
-// IsIgnored checks if a file path should be ignored
+// IsIgnored checks if a file path should be ignored, either by the project's
+// ignore files or by the shared exclude patterns
 func (g *GitIgnoreFilter) IsIgnored(path string) bool {
-	if g.ignore == nil {
-		return false
-	}
-
 	// Convert absolute path to relative path from project root
 	relPath, err := filepath.Rel(g.projectRoot, path)
 	if err != nil {
@@ -71,8 +68,14 @@ func (g *GitIgnoreFilter) IsIgnored(path string) bool {
 
 	// Normalize path separators for cross-platform compatibility
 	relPath = filepath.ToSlash(relPath)
+	if relPath == "." {
+		return false
+	}
 
-	return g.ignore.MatchesPath(relPath)
+	if ignore.Default().Match(relPath) {
+		return true
+	}
+	return g.ignore != nil && g.ignore.MatchesPath(relPath)
 }
 
 // ShouldIgnoreFile checks if a file should be ignored based on its name and path
@@ -85,64 +88,6 @@ func (g *GitIgnoreFilter) ShouldIgnoreDirectory(dirPath string) bool {
 	return g.IsIgnored(dirPath)
 }
 
-// getDefaultIgnorePatterns returns common ignore patterns when no .gitignore is found
-func getDefaultIgnorePatterns() []string {
-	return []string{
-		// Version control
-		".git/",
-		".svn/",
-		".hg/",
-
-		// Dependencies
-		"node_modules/",
-		"vendor/",
-		"target/",
-
-		// IDE files
-		".vscode/",
-		".idea/",
-		".vs/",
-		"*.swp",
-		"*.swo",
-		"*~",
-
-		// Build outputs
-		"build/",
-		"dist/",
-		"out/",
-		"bin/",
-
-		// Language-specific
-		"__pycache__/",
-		".pytest_cache/",
-		"*.pyc",
-		"*.pyo",
-		"*.pyd",
-		"*.class",
-		"*.jar",
-		"*.war",
-		"*.exe",
-		"*.dll",
-		"*.so",
-		"*.dylib",
-
-		// Logs and temporary files
-		"*.log",
-		"*.tmp",
-		"*.temp",
-		".DS_Store",
-		"Thumbs.db",
-
-		// Environment files
-		".env",
-		".env.local",
-		".env.*.local",
-
-		// CodeForge specific
-		".codeforge/",
-	}
-}
-
 // WalkWithGitIgnore walks a directory tree while respecting .gitignore patterns
 func (g *GitIgnoreFilter) WalkWithGitIgnore(root string, walkFn filepath.WalkFunc) error {
 	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
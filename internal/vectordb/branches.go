@@ -0,0 +1,212 @@
+package vectordb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Per-branch indexes. Chunks indexed on the base branch form the shared
+// index, stored with an empty branch. On any other branch, files indexed or
+// removed there go into a delta for that branch that hides the base chunks
+// of the same files, so searches never mix code from two branches while
+// unchanged files are stored only once.
+
+// migrateBranches adds the branch column and the table of files removed on
+// a branch, for databases created before per-branch indexes
+func (vdb *VectorDB) migrateBranches(ctx context.Context) error {
+	rows, err := vdb.db.QueryContext(ctx, "SELECT name FROM pragma_table_info('chunks')")
+	if err != nil {
+		return fmt.Errorf("failed to read chunks columns: %w", err)
+	}
+	hasBranch := false
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err == nil && name == "branch" {
+			hasBranch = true
+		}
+	}
+	rows.Close()
+
+	if !hasBranch {
+		if _, err := vdb.db.ExecContext(ctx, "ALTER TABLE chunks ADD COLUMN branch TEXT NOT NULL DEFAULT ''"); err != nil {
+			return fmt.Errorf("failed to add branch column: %w", err)
+		}
+	}
+
+	// One statement per call, as the driver runs only the first
+	branchesSQL := []string{
+		`CREATE INDEX IF NOT EXISTS idx_chunks_branch_file ON chunks(branch, file_path)`,
+		`CREATE TABLE IF NOT EXISTS branch_deletions (
+			branch TEXT NOT NULL,
+			file_path TEXT NOT NULL,
+			PRIMARY KEY (branch, file_path)
+		)`,
+	}
+	for _, stmt := range branchesSQL {
+		if _, err := vdb.db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to create branch tables: %w", err)
+		}
+	}
+	return nil
+}
+
+// SetBranch selects the index delta chunks are stored in and searched
+// against. The base branch, detached HEADs and "" select the shared index,
+// as does every branch when branch deltas are disabled.
+func (vdb *VectorDB) SetBranch(branch string) {
+	if !vdb.config.Index.BranchDeltas || branch == "HEAD" || vdb.isBaseBranch(branch) {
+		branch = ""
+	}
+
+	vdb.mu.Lock()
+	defer vdb.mu.Unlock()
+	if branch != vdb.branch {
+		// Cached chunks are keyed by ID, which a delta may shadow
+		vdb.cache.Clear()
+	}
+	vdb.branch = branch
+}
+
+// Branch returns the branch whose delta is in use, or "" for the shared index
+func (vdb *VectorDB) Branch() string {
+	vdb.mu.RLock()
+	defer vdb.mu.RUnlock()
+	return vdb.branch
+}
+
+// syncBranch follows the working tree to whichever branch is checked out
+func (vdb *VectorDB) syncBranch() {
+	if vdb.config.Index.BranchDeltas {
+		vdb.SetBranch(currentBranch(vdb.config.WorkingDir))
+	}
+}
+
+// isBaseBranch reports whether branch holds the shared index
+func (vdb *VectorDB) isBaseBranch(branch string) bool {
+	if base := vdb.config.Index.BaseBranch; base != "" {
+		return branch == base
+	}
+	return branch == "main" || branch == "master"
+}
+
+// rowID returns the chunks table ID of a chunk on branch. Deltas prefix
+// the chunk ID so the same chunk can exist in the base and in a delta.
+func rowID(branch, id string) string {
+	if branch == "" {
+		return id
+	}
+	return branch + ":" + id
+}
+
+// chunkIDColumn selects the chunk ID without any branch prefix
+const chunkIDColumn = "CASE WHEN branch = '' THEN id ELSE substr(id, length(branch) + 2) END"
+
+// branchFilter returns the SQL condition, and its arguments, matching the
+// chunks visible on branch: its delta plus the base chunks of files the
+// delta hasn't replaced or removed
+func branchFilter(branch string) (string, []interface{}) {
+	if branch == "" {
+		return "branch = ''", nil
+	}
+	return `(branch = ? OR (branch = '' AND file_path NOT IN (
+		SELECT file_path FROM chunks WHERE branch = ?
+		UNION SELECT file_path FROM branch_deletions WHERE branch = ?)))`,
+		[]interface{}{branch, branch, branch}
+}
+
+// RemoveFile removes the chunks and record of a deleted file. On a branch
+// the base chunks are kept for other branches and hidden from this one
+// instead.
+func (vdb *VectorDB) RemoveFile(ctx context.Context, filePath string) error {
+	vdb.syncBranch()
+	branch := vdb.Branch()
+
+	if _, err := vdb.db.ExecContext(ctx, "DELETE FROM chunks WHERE branch = ? AND file_path = ?", branch, filePath); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", filePath, err)
+	}
+	if _, err := vdb.db.ExecContext(ctx, "DELETE FROM indexed_files WHERE branch = ? AND file_path = ?", branch, filePath); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", filePath, err)
+	}
+	if branch != "" {
+		_, err := vdb.db.ExecContext(ctx,
+			"INSERT OR IGNORE INTO branch_deletions (branch, file_path) VALUES (?, ?)", branch, filePath)
+		if err != nil {
+			return fmt.Errorf("failed to remove %s: %w", filePath, err)
+		}
+	}
+
+	vdb.cache.DeleteFunc(func(chunk *CodeChunk) bool { return chunk.FilePath == filePath })
+	return nil
+}
+
+// IndexedAt returns when a file was last indexed on the current branch,
+// or the zero time when it isn't in the index. The file may be given
+// relative to the working directory or as an absolute path.
+func (vdb *VectorDB) IndexedAt(ctx context.Context, filePath string) (time.Time, error) {
+	vdb.syncBranch()
+	branchClause, args := branchFilter(vdb.Branch())
+
+	paths := []interface{}{filePath, filePath}
+	if filepath.IsAbs(filePath) {
+		if rel, err := filepath.Rel(vdb.config.WorkingDir, filePath); err == nil {
+			paths[1] = rel
+		}
+	} else {
+		paths[1] = filepath.Join(vdb.config.WorkingDir, filePath)
+	}
+
+	var updatedAt sql.NullString
+	query := "SELECT MAX(updated_at) FROM chunks WHERE file_path IN (?, ?) AND " + branchClause
+	err := vdb.db.QueryRowContext(ctx, query, append(paths, args...)...).Scan(&updatedAt)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to look up %s: %w", filePath, err)
+	}
+	if !updatedAt.Valid {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, updatedAt.String)
+}
+
+// DropBranch deletes the delta of a branch, such as one that was merged or
+// deleted. The shared index can't be dropped.
+func (vdb *VectorDB) DropBranch(ctx context.Context, branch string) error {
+	if branch == "" {
+		return fmt.Errorf("branch name required")
+	}
+	if _, err := vdb.db.ExecContext(ctx, "DELETE FROM chunks WHERE branch = ?", branch); err != nil {
+		return fmt.Errorf("failed to drop index of branch %s: %w", branch, err)
+	}
+	if _, err := vdb.db.ExecContext(ctx, "DELETE FROM branch_deletions WHERE branch = ?", branch); err != nil {
+		return fmt.Errorf("failed to drop index of branch %s: %w", branch, err)
+	}
+	if _, err := vdb.db.ExecContext(ctx, "DELETE FROM indexed_files WHERE branch = ?", branch); err != nil {
+		return fmt.Errorf("failed to drop index of branch %s: %w", branch, err)
+	}
+	return nil
+}
+
+// currentBranch returns the branch checked out in dir, "HEAD" when it's
+// detached, or "" outside a git repository
+func currentBranch(dir string) string {
+	// Reading HEAD directly is cheap enough to do on every search
+	if data, err := os.ReadFile(filepath.Join(dir, ".git", "HEAD")); err == nil {
+		head := strings.TrimSpace(string(data))
+		if ref, ok := strings.CutPrefix(head, "ref: refs/heads/"); ok {
+			return ref
+		}
+		return "HEAD"
+	}
+
+	// Worktrees and subdirectories need git to find the repository
+	output, err := exec.Command("git", "-C", dir, "rev-parse", "--abbrev-ref", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
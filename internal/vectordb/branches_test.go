@@ -0,0 +1,103 @@
+package vectordb
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+)
+
+func TestVectorDB_BranchDeltas(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tempDir, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	checkout := func(branch string) {
+		head := []byte("ref: refs/heads/" + branch + "\n")
+		if err := os.WriteFile(filepath.Join(tempDir, ".git", "HEAD"), head, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkout("main")
+
+	cfg := &config.Config{
+		Data:       config.Data{Directory: tempDir},
+		WorkingDir: tempDir,
+		Index:      config.IndexConfig{BranchDeltas: true},
+	}
+	if err := Initialize(cfg); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	defer GetInstance().Close()
+
+	vdb := GetInstance()
+	ctx := context.Background()
+	store := func(id, path, content string) {
+		chunk := &CodeChunk{
+			ID:        id,
+			FilePath:  path,
+			Content:   content,
+			ChunkType: ChunkType{Type: "function"},
+			Language:  "go",
+			Metadata:  map[string]string{},
+		}
+		embedding := []float32{1, 0, 0}
+		if err := vdb.StoreChunk(ctx, chunk, embedding); err != nil {
+			t.Fatalf("Failed to store chunk: %v", err)
+		}
+	}
+	search := func() map[string]string {
+		results, err := vdb.SearchSimilarChunks(ctx, []float32{1, 0, 0}, 10, nil)
+		if err != nil {
+			t.Fatalf("Failed to search: %v", err)
+		}
+		found := map[string]string{}
+		for _, r := range results {
+			found[r.Chunk.ID] = r.Chunk.Content
+		}
+		return found
+	}
+
+	store("a", "a.go", "func A() {}")
+	store("b", "b.go", "func B() {}")
+
+	if at, err := vdb.IndexedAt(ctx, "a.go"); err != nil || at.IsZero() {
+		t.Errorf("IndexedAt(a.go) = %v, %v, want the time it was stored", at, err)
+	}
+	if at, err := vdb.IndexedAt(ctx, filepath.Join(tempDir, "a.go")); err != nil || at.IsZero() {
+		t.Errorf("IndexedAt() with an absolute path = %v, %v", at, err)
+	}
+	if at, err := vdb.IndexedAt(ctx, "missing.go"); err != nil || !at.IsZero() {
+		t.Errorf("IndexedAt(missing.go) = %v, %v, want zero", at, err)
+	}
+
+	checkout("feature")
+	store("a", "a.go", "func A() { changed() }")
+	if err := vdb.RemoveFile(ctx, "b.go"); err != nil {
+		t.Fatalf("Failed to remove file: %v", err)
+	}
+
+	found := search()
+	if len(found) != 1 || found["a"] != "func A() { changed() }" {
+		t.Errorf("feature branch search = %v, want only its own a.go", found)
+	}
+	if chunk, err := vdb.GetChunkByID(ctx, "a"); err != nil || chunk.Content != "func A() { changed() }" {
+		t.Errorf("GetChunkByID() on feature = %v, %v", chunk, err)
+	}
+
+	checkout("main")
+	found = search()
+	if len(found) != 2 || found["a"] != "func A() {}" {
+		t.Errorf("main branch search = %v, want base a.go and b.go", found)
+	}
+
+	if err := vdb.DropBranch(ctx, "feature"); err != nil {
+		t.Fatalf("Failed to drop branch: %v", err)
+	}
+	checkout("feature")
+	if found := search(); len(found) != 2 {
+		t.Errorf("search after dropping the delta = %v, want the base index", found)
+	}
+}
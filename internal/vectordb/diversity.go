@@ -0,0 +1,72 @@
+package vectordb
+
+// sameFilePenalty is added to the similarity of two chunks from the same
+// file, so a single file doesn't fill the results even when its chunks
+// embed differently
+const sameFilePenalty = 0.2
+
+// mmrCandidate is a search candidate with the embedding MMR compares
+type mmrCandidate struct {
+	Chunk      CodeChunk
+	Similarity float32
+	Embedding  []float32
+}
+
+// selectDiverse picks k candidates by maximal marginal relevance: each pick
+// maximises lambda*relevance - (1-lambda)*similarity to the chunks already
+// picked. lambda 1 ranks by relevance alone; lower values trade relevance
+// for coverage. Chunks with the same content as a picked one are skipped.
+// candidates must be sorted by similarity, best first.
+func selectDiverse(candidates []mmrCandidate, k int, lambda float64) []mmrCandidate {
+	if k > len(candidates) {
+		k = len(candidates)
+	}
+	if lambda >= 1 {
+		return candidates[:k]
+	}
+
+	// Only the best few candidates can make it in, which bounds the work
+	pool := candidates
+	if len(pool) > k*5 {
+		pool = pool[:k*5]
+	}
+
+	selected := make([]mmrCandidate, 0, k)
+	used := make([]bool, len(pool))
+	hashes := make(map[string]bool)
+	for len(selected) < k {
+		best, bestScore := -1, 0.0
+		for i, c := range pool {
+			if used[i] {
+				continue
+			}
+			if c.Chunk.Hash != "" && hashes[c.Chunk.Hash] {
+				used[i] = true
+				continue
+			}
+
+			redundancy := 0.0
+			for _, s := range selected {
+				sim := cosineSimilarity(c.Embedding, s.Embedding)
+				if c.Chunk.FilePath == s.Chunk.FilePath {
+					sim += sameFilePenalty
+				}
+				if sim > redundancy {
+					redundancy = sim
+				}
+			}
+
+			score := lambda*float64(c.Similarity) - (1-lambda)*redundancy
+			if best < 0 || score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+		if best < 0 {
+			break
+		}
+		used[best] = true
+		hashes[pool[best].Chunk.Hash] = true
+		selected = append(selected, pool[best])
+	}
+	return selected
+}
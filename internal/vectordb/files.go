@@ -0,0 +1,151 @@
+package vectordb
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// IndexedFile is what the index knows of a file as it was when it was
+// indexed: files whose size and modification time still match can be
+// skipped without reading them, and ones whose content hash still matches
+// without embedding them again
+type IndexedFile struct {
+	Path    string
+	Hash    string // SHA-256 of the content, hex encoded
+	ModTime time.Time
+	Size    int64
+}
+
+// migrateFiles adds the table of indexed files, for databases created
+// before incremental indexing
+func (vdb *VectorDB) migrateFiles(ctx context.Context) error {
+	_, err := vdb.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS indexed_files (
+		branch TEXT NOT NULL,
+		file_path TEXT NOT NULL,
+		hash TEXT NOT NULL,
+		mod_time TEXT NOT NULL,
+		size INTEGER NOT NULL,
+		PRIMARY KEY (branch, file_path)
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create indexed files table: %w", err)
+	}
+	return nil
+}
+
+// IndexedFile returns the record of a file as it was last indexed on the
+// current branch, or nil when there's none, as for files indexed before
+// records were kept
+func (vdb *VectorDB) IndexedFile(ctx context.Context, filePath string) (*IndexedFile, error) {
+	vdb.syncBranch()
+	branch := vdb.Branch()
+
+	file, err := vdb.indexedFile(ctx, branch, filePath)
+	if file != nil || err != nil || branch == "" {
+		return file, err
+	}
+	// The base record stands unless the branch removed or replaced the file
+	var hidden int
+	err = vdb.db.QueryRowContext(ctx, `SELECT EXISTS (
+		SELECT 1 FROM branch_deletions WHERE branch = ? AND file_path = ?
+		UNION SELECT 1 FROM chunks WHERE branch = ? AND file_path = ?)`,
+		branch, filePath, branch, filePath).Scan(&hidden)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up %s: %w", filePath, err)
+	}
+	if hidden == 1 {
+		return nil, nil
+	}
+	return vdb.indexedFile(ctx, "", filePath)
+}
+
+func (vdb *VectorDB) indexedFile(ctx context.Context, branch, filePath string) (*IndexedFile, error) {
+	file := IndexedFile{Path: filePath}
+	var modTime string
+	err := vdb.db.QueryRowContext(ctx,
+		"SELECT hash, mod_time, size FROM indexed_files WHERE branch = ? AND file_path = ?",
+		branch, filePath).Scan(&file.Hash, &modTime, &file.Size)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up %s: %w", filePath, err)
+	}
+	if file.ModTime, err = time.Parse(time.RFC3339Nano, modTime); err != nil {
+		return nil, fmt.Errorf("failed to look up %s: %w", filePath, err)
+	}
+	return &file, nil
+}
+
+// SetIndexedFile records a file as indexed on the current branch
+func (vdb *VectorDB) SetIndexedFile(ctx context.Context, file IndexedFile) error {
+	vdb.syncBranch()
+	_, err := vdb.db.ExecContext(ctx,
+		"INSERT OR REPLACE INTO indexed_files (branch, file_path, hash, mod_time, size) VALUES (?, ?, ?, ?, ?)",
+		vdb.Branch(), file.Path, file.Hash, file.ModTime.UTC().Format(time.RFC3339Nano), file.Size)
+	if err != nil {
+		return fmt.Errorf("failed to record %s: %w", file.Path, err)
+	}
+	return nil
+}
+
+// IndexedFiles returns the files with chunks in the index on the current
+// branch, or a record of having been indexed there
+func (vdb *VectorDB) IndexedFiles(ctx context.Context) ([]string, error) {
+	vdb.syncBranch()
+	branch := vdb.Branch()
+	branchClause, args := branchFilter(branch)
+
+	rows, err := vdb.db.QueryContext(ctx,
+		"SELECT file_path FROM chunks WHERE "+branchClause+" UNION SELECT file_path FROM indexed_files WHERE branch = ?",
+		append(args, branch)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list indexed files: %w", err)
+	}
+	defer rows.Close()
+	var files []string
+	for rows.Next() {
+		var file string
+		if err := rows.Scan(&file); err != nil {
+			return nil, fmt.Errorf("failed to list indexed files: %w", err)
+		}
+		files = append(files, file)
+	}
+	return files, rows.Err()
+}
+
+// ChunkEmbeddings returns the embeddings of a file's chunks on the current
+// branch by the hash of their content, so chunks that haven't changed can
+// keep theirs when the file is indexed again
+func (vdb *VectorDB) ChunkEmbeddings(ctx context.Context, filePath string) (map[string][]float32, error) {
+	vdb.syncBranch()
+	branchClause, args := branchFilter(vdb.Branch())
+
+	rows, err := vdb.db.QueryContext(ctx,
+		"SELECT hash, embedding FROM chunks WHERE file_path = ? AND embedding IS NOT NULL AND "+branchClause,
+		append([]interface{}{filePath}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embeddings of %s: %w", filePath, err)
+	}
+	defer rows.Close()
+	embeddings := make(map[string][]float32)
+	for rows.Next() {
+		var hash, stored string
+		if err := rows.Scan(&hash, &stored); err != nil {
+			return nil, fmt.Errorf("failed to read embeddings of %s: %w", filePath, err)
+		}
+		if stored, err = vdb.cipher.Decrypt(stored); err != nil {
+			return nil, fmt.Errorf("failed to decrypt embeddings of %s: %w", filePath, err)
+		}
+		var embedding []float32
+		if err := json.Unmarshal([]byte(stored), &embedding); err != nil || len(embedding) == 0 {
+			continue // Embedded again instead
+		}
+		embeddings[hash] = embedding
+	}
+	return embeddings, rows.Err()
+}
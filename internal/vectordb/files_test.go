@@ -0,0 +1,67 @@
+package vectordb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+)
+
+func TestVectorDB_IndexedFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	vdb, err := Open(&config.Config{
+		Data:       config.Data{Directory: tempDir},
+		WorkingDir: tempDir,
+	})
+	if err != nil {
+		t.Fatalf("Failed to open: %v", err)
+	}
+	defer vdb.Close()
+	ctx := context.Background()
+
+	if file, err := vdb.IndexedFile(ctx, "a.go"); err != nil || file != nil {
+		t.Fatalf("IndexedFile before indexing = %+v, %v", file, err)
+	}
+
+	chunk := &CodeChunk{
+		ID:        "a.go:1-3",
+		FilePath:  "a.go",
+		Content:   "func A() {}",
+		ChunkType: ChunkType{Type: "function"},
+		Language:  "go",
+		Metadata:  map[string]string{},
+	}
+	if err := vdb.StoreChunk(ctx, chunk, []float32{0.5, 1, 0}); err != nil {
+		t.Fatalf("Failed to store chunk: %v", err)
+	}
+	modTime := time.Date(2026, 1, 2, 3, 4, 5, 678, time.Local)
+	if err := vdb.SetIndexedFile(ctx, IndexedFile{Path: "a.go", Hash: "abc", ModTime: modTime, Size: 11}); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := vdb.IndexedFile(ctx, "a.go")
+	if err != nil || file == nil || file.Hash != "abc" || file.Size != 11 || !file.ModTime.Equal(modTime) {
+		t.Fatalf("IndexedFile = %+v, %v", file, err)
+	}
+	embeddings, err := vdb.ChunkEmbeddings(ctx, "a.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := embeddings[chunk.Hash]; len(got) != 3 || got[0] != 0.5 {
+		t.Errorf("ChunkEmbeddings = %v", embeddings)
+	}
+	if files, err := vdb.IndexedFiles(ctx); err != nil || len(files) != 1 || files[0] != "a.go" {
+		t.Errorf("IndexedFiles = %v, %v", files, err)
+	}
+
+	if err := vdb.RemoveFile(ctx, "a.go"); err != nil {
+		t.Fatal(err)
+	}
+	if file, err := vdb.IndexedFile(ctx, "a.go"); err != nil || file != nil {
+		t.Errorf("IndexedFile after removal = %+v, %v", file, err)
+	}
+	if files, err := vdb.IndexedFiles(ctx); err != nil || len(files) != 0 {
+		t.Errorf("IndexedFiles after removal = %v, %v", files, err)
+	}
+}
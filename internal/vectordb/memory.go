@@ -0,0 +1,339 @@
+package vectordb
+
+import (
+	"container/list"
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+)
+
+// How embeddings are held for search, set with index.vectors
+const (
+	// VectorsMmap leaves embeddings in the index file, which SQLite maps
+	// into memory; the OS pages them in and out as memory allows
+	VectorsMmap = "mmap"
+	// VectorsMemory keeps decoded embeddings in RAM, so searches don't
+	// decode them again
+	VectorsMemory = "memory"
+)
+
+// Defaults for the index settings left at zero
+const (
+	defaultCacheChunks = 1000
+	defaultMmapSizeMB  = 256
+)
+
+// memoryCheckInterval is how many chunks are stored between checks of the
+// memory budget while indexing
+const memoryCheckInterval = 256
+
+// memorySettings are the index's memory settings with defaults applied
+type memorySettings struct {
+	vectors     string
+	mmapSize    int64  // Bytes SQLite maps; 0 for none
+	cacheChunks int    // Chunks kept decoded; 0 for none
+	budget      uint64 // RSS bytes; 0 for no budget
+}
+
+// memorySettingsFor reads the index's memory settings from cfg. Zero values
+// take the defaults and negative ones turn the feature off.
+func memorySettingsFor(cfg config.IndexConfig) memorySettings {
+	s := memorySettings{vectors: VectorsMmap, cacheChunks: cfg.CacheChunks}
+	switch cfg.Vectors {
+	case "", VectorsMmap:
+	case VectorsMemory:
+		s.vectors = VectorsMemory
+	default:
+		log.Printf("Unknown index.vectors %q; using %s", cfg.Vectors, VectorsMmap)
+	}
+
+	if s.cacheChunks == 0 {
+		s.cacheChunks = defaultCacheChunks
+	} else if s.cacheChunks < 0 {
+		s.cacheChunks = 0
+	}
+
+	// Embeddings kept in RAM are read once; mapping the file as well
+	// would hold them twice
+	if s.vectors == VectorsMmap {
+		mmapMB := cfg.MmapSizeMB
+		if mmapMB == 0 {
+			mmapMB = defaultMmapSizeMB
+		}
+		if mmapMB > 0 {
+			s.mmapSize = int64(mmapMB) << 20
+		}
+	}
+
+	if cfg.MaxMemoryMB > 0 {
+		s.budget = uint64(cfg.MaxMemoryMB) << 20
+	}
+	return s
+}
+
+// pragmaConnector runs PRAGMA statements on every connection it opens, as
+// settings such as mmap_size are per connection and database/sql pools
+// several
+type pragmaConnector struct {
+	driver.Connector
+	pragmas []string
+}
+
+func (c pragmaConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	queryer, ok := conn.(driver.QueryerContext)
+	if !ok {
+		return conn, nil
+	}
+	for _, pragma := range c.pragmas {
+		// PRAGMAs answer with the value set, so they're run as queries
+		rows, err := queryer.QueryContext(ctx, pragma, nil)
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to run %s: %w", pragma, err)
+		}
+		rows.Close()
+	}
+	return conn, nil
+}
+
+// Close closes the wrapped connector, which database/sql only does for
+// connectors that are io.Closers
+func (c pragmaConnector) Close() error {
+	if closer, ok := c.Connector.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// chunkCache keeps the most recently used chunks, up to a fixed number
+type chunkCache struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List // Of *cacheEntry, most recently used first
+	entries  map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key   string
+	chunk *CodeChunk
+}
+
+func newChunkCache(capacity int) *chunkCache {
+	return &chunkCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// Load returns the cached chunk and marks it recently used
+func (c *chunkCache) Load(key string) (*CodeChunk, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*cacheEntry).chunk, true
+}
+
+// Store caches a chunk, dropping the least recently used beyond capacity
+func (c *chunkCache) Store(key string, chunk *CodeChunk) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*cacheEntry).chunk = chunk
+		c.order.MoveToFront(elem)
+		return
+	}
+	if c.capacity <= 0 {
+		return
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, chunk: chunk})
+	for c.order.Len() > c.capacity {
+		c.remove(c.order.Back())
+	}
+}
+
+// Delete drops a chunk from the cache
+func (c *chunkCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+}
+
+// DeleteFunc drops the chunks drop returns true for
+func (c *chunkCache) DeleteFunc(drop func(*CodeChunk) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for elem := c.order.Front(); elem != nil; {
+		next := elem.Next()
+		if drop(elem.Value.(*cacheEntry).chunk) {
+			c.remove(elem)
+		}
+		elem = next
+	}
+}
+
+// Clear empties the cache
+func (c *chunkCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order.Init()
+	clear(c.entries)
+}
+
+// Shrink empties the cache and halves its capacity
+func (c *chunkCache) Shrink() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order.Init()
+	clear(c.entries)
+	c.capacity /= 2
+}
+
+// Len returns the number of cached chunks
+func (c *chunkCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *chunkCache) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.entries, elem.Value.(*cacheEntry).key)
+}
+
+// vectorStore keeps decoded embeddings by chunk ID for the memory vectors
+// mode. An embedding is only used while the chunk's content hash matches.
+type vectorStore struct {
+	mu      sync.RWMutex
+	enabled bool
+	vectors map[string]storedVector
+}
+
+type storedVector struct {
+	hash      string
+	embedding []float32
+}
+
+func newVectorStore(enabled bool) *vectorStore {
+	return &vectorStore{enabled: enabled, vectors: make(map[string]storedVector)}
+}
+
+// Load returns the embedding kept for a chunk with the given content hash
+func (v *vectorStore) Load(id, hash string) ([]float32, bool) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	stored, ok := v.vectors[id]
+	if !ok || stored.hash != hash {
+		return nil, false
+	}
+	return stored.embedding, true
+}
+
+// Store keeps a chunk's embedding, unless the store is off
+func (v *vectorStore) Store(id, hash string, embedding []float32) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.enabled {
+		v.vectors[id] = storedVector{hash: hash, embedding: embedding}
+	}
+}
+
+// Delete drops a chunk's embedding
+func (v *vectorStore) Delete(id string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	delete(v.vectors, id)
+}
+
+// Disable drops every embedding and keeps no more
+func (v *vectorStore) Disable() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.enabled = false
+	v.vectors = make(map[string]storedVector)
+}
+
+// Len returns the number of embeddings kept
+func (v *vectorStore) Len() int {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return len(v.vectors)
+}
+
+// memoryGuard holds the index to its RSS budget
+type memoryGuard struct {
+	budget   uint64
+	stores   atomic.Int64 // Chunks stored, for spacing out checks
+	degraded atomic.Bool
+	resident func() uint64 // Replaced in tests
+}
+
+func newMemoryGuard(budget uint64) *memoryGuard {
+	if budget > 0 && debug.SetMemoryLimit(-1) == math.MaxInt64 {
+		// Have the garbage collector work harder as the budget nears,
+		// unless GOMEMLIMIT already set a limit
+		debug.SetMemoryLimit(int64(budget))
+	}
+	return &memoryGuard{budget: budget, resident: residentMemory}
+}
+
+// over reports whether the process is over the budget
+func (g *memoryGuard) over() bool {
+	return g.budget > 0 && g.resident() > g.budget
+}
+
+// checkMemory sheds what the index holds in memory when the process is over
+// its budget: cached chunks, with the cache halved each time, and the
+// embeddings kept in memory mode, which searches then decode from the file
+func (vdb *VectorDB) checkMemory() {
+	if !vdb.memory.over() {
+		return
+	}
+
+	vdb.cache.Shrink()
+	vdb.vectors.Disable()
+	debug.FreeOSMemory()
+
+	if !vdb.memory.degraded.Swap(true) {
+		log.Printf("Warning: memory use is over the index budget of %d MB; caching fewer chunks and reading embeddings from disk",
+			vdb.memory.budget>>20)
+	}
+}
+
+// residentMemory returns the process's resident set size in bytes. Where
+// /proc isn't available, the memory the Go runtime has taken from the OS
+// stands in for it.
+func residentMemory() uint64 {
+	if data, err := os.ReadFile("/proc/self/statm"); err == nil {
+		if fields := strings.Fields(string(data)); len(fields) > 1 {
+			if pages, err := strconv.ParseUint(fields[1], 10, 64); err == nil {
+				return pages * uint64(os.Getpagesize())
+			}
+		}
+	}
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.Sys
+}
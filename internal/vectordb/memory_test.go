@@ -0,0 +1,94 @@
+package vectordb
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"runtime/debug"
+	"testing"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+)
+
+func TestChunkCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newChunkCache(2)
+	for _, id := range []string{"a", "b"} {
+		cache.Store(id, &CodeChunk{ID: id})
+	}
+	cache.Load("a")
+	cache.Store("c", &CodeChunk{ID: "c"})
+
+	if _, ok := cache.Load("b"); ok {
+		t.Error("least recently used chunk kept")
+	}
+	for _, id := range []string{"a", "c"} {
+		if _, ok := cache.Load(id); !ok {
+			t.Errorf("chunk %s evicted", id)
+		}
+	}
+
+	cache.Shrink()
+	cache.Store("d", &CodeChunk{ID: "d"})
+	cache.Store("e", &CodeChunk{ID: "e"})
+	if cache.Len() != 1 {
+		t.Errorf("shrunk cache holds %d chunks, want 1", cache.Len())
+	}
+}
+
+func TestIndexMemorySettings(t *testing.T) {
+	t.Cleanup(func() { debug.SetMemoryLimit(math.MaxInt64) })
+	ctx := context.Background()
+
+	open := func(index config.IndexConfig) *VectorDB {
+		dir := t.TempDir()
+		vdb, err := Open(&config.Config{Data: config.Data{Directory: dir}, WorkingDir: dir, Index: index})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { vdb.Close() })
+		return vdb
+	}
+
+	// mmap mode maps the index file on every connection
+	vdb := open(config.IndexConfig{Vectors: VectorsMmap, MmapSizeMB: 64})
+	var mmapSize int64
+	if err := vdb.QueryRowContext(ctx, "PRAGMA mmap_size").Scan(&mmapSize); err != nil {
+		t.Fatal(err)
+	}
+	if mmapSize != 64<<20 {
+		t.Errorf("mmap_size = %d, want %d", mmapSize, 64<<20)
+	}
+
+	// memory mode keeps embeddings decoded until the budget is exceeded
+	vdb = open(config.IndexConfig{Vectors: VectorsMemory, CacheChunks: 4, MaxMemoryMB: 1 << 20})
+	resident := uint64(0)
+	vdb.memory.resident = func() uint64 { return resident }
+	for i := 0; i < 6; i++ {
+		chunk := &CodeChunk{ID: fmt.Sprintf("chunk_%d", i), FilePath: "main.go", Content: fmt.Sprintf("func f%d() {}", i), Language: "go"}
+		if err := vdb.StoreChunk(ctx, chunk, []float32{float32(i), 1, 0}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := vdb.SearchSimilarChunks(ctx, []float32{1, 1, 0}, 3, nil); err != nil {
+		t.Fatal(err)
+	}
+	stats, err := vdb.GetStats(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.VectorMode != VectorsMemory || stats.MemoryVectors != 6 || stats.CacheSize != 4 || stats.MemoryDegraded {
+		t.Errorf("within budget: mode %s, %d vectors, %d cached, degraded %v", stats.VectorMode, stats.MemoryVectors, stats.CacheSize, stats.MemoryDegraded)
+	}
+
+	resident = 2 << 40
+	results, err := vdb.SearchSimilarChunks(ctx, []float32{1, 1, 0}, 3, nil)
+	if err != nil || len(results) != 3 {
+		t.Fatalf("search over budget = %d results, %v", len(results), err)
+	}
+	if stats, _ = vdb.GetStats(ctx); stats.MemoryVectors != 0 || stats.CacheSize != 0 || !stats.MemoryDegraded {
+		t.Errorf("over budget: %d vectors, %d cached, degraded %v", stats.MemoryVectors, stats.CacheSize, stats.MemoryDegraded)
+	}
+	if vdb.cache.capacity != 2 {
+		t.Errorf("cache capacity %d after shedding, want 2", vdb.cache.capacity)
+	}
+}
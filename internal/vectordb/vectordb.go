@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/sha256"
 	"database/sql"
+	"database/sql/driver"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -13,9 +14,12 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/entrepeneur4lyf/codeforge/internal/atrest"
 	"github.com/entrepeneur4lyf/codeforge/internal/config"
 	libsqlvector "github.com/ryanskidmore/libsql-vector-go"
 	_ "github.com/tursodatabase/go-libsql"
@@ -24,11 +28,16 @@ import (
 // VectorDB provides production-ready vector database operations using libsql
 // with proper vector indexing and caching
 type VectorDB struct {
-	db     *sql.DB
-	config *config.Config
-	cache  *sync.Map // Thread-safe cache for frequently accessed chunks
-	stats  VectorStoreStats
-	mu     sync.RWMutex
+	db      *sql.DB
+	path    string // Database file
+	config  *config.Config
+	cache   *chunkCache  // Most recently used chunks
+	vectors *vectorStore // Decoded embeddings, in memory vectors mode
+	memory  *memoryGuard
+	stats   VectorStoreStats
+	mu      sync.RWMutex
+	cipher  *atrest.Cipher // nil unless at-rest encryption is enabled
+	branch  string         // Branch delta in use; "" for the shared index
 }
 
 // VectorStoreConfig holds configuration for the vector store
@@ -68,6 +77,55 @@ type Symbol struct {
 	Signature     string         `json:"signature,omitempty"`
 	Location      SourceLocation `json:"location"`
 	Documentation string         `json:"documentation,omitempty"`
+	Parent        string         `json:"parent,omitempty"` // Receiver or enclosing type, e.g. "(cs *ChatStorage)" or "Storage"
+}
+
+// QualifiedName returns the symbol name with its receiver or enclosing
+// type, such as "(cs *ChatStorage) AddMessage" or "Storage.add_message"
+func (s Symbol) QualifiedName() string {
+	switch {
+	case s.Parent == "":
+		return s.Name
+	case strings.HasPrefix(s.Parent, "("):
+		return s.Parent + " " + s.Name
+	default:
+		return s.Parent + "." + s.Name
+	}
+}
+
+// EnclosingSymbol returns the symbol the chunk was cut from. Chunks indexed
+// before symbols were recorded fall back to the name in their chunk type.
+func (c *CodeChunk) EnclosingSymbol() (Symbol, bool) {
+	if len(c.Symbols) > 0 {
+		return c.Symbols[0], true
+	}
+	for _, key := range []string{"symbol_name", "function_name"} {
+		if name, ok := c.ChunkType.Data[key].(string); ok && name != "" && name != "unknown" {
+			return Symbol{Name: name, Kind: c.ChunkType.Type, Location: c.Location}, true
+		}
+	}
+	return Symbol{}, false
+}
+
+// Describe names the chunk for search results, as the file followed by the
+// enclosing symbol, e.g. "internal/api/chat_handlers.go: (cs *ChatStorage) AddMessage"
+func (c *CodeChunk) Describe() string {
+	if symbol, ok := c.EnclosingSymbol(); ok {
+		return c.FilePath + ": " + symbol.QualifiedName()
+	}
+	return c.FilePath
+}
+
+// Lines returns the chunk's line range, such as "12-30", or "" when unknown
+func (c *CodeChunk) Lines() string {
+	switch {
+	case c.Location.StartLine <= 0:
+		return ""
+	case c.Location.EndLine <= c.Location.StartLine:
+		return strconv.Itoa(c.Location.StartLine)
+	default:
+		return fmt.Sprintf("%d-%d", c.Location.StartLine, c.Location.EndLine)
+	}
 }
 
 // SourceLocation represents a location in source code
@@ -96,6 +154,10 @@ type VectorStoreStats struct {
 	Dimension      int            `json:"dimension"`
 	IndexType      string         `json:"index_type"`
 	LastOptimized  time.Time      `json:"last_optimized"`
+	LastIndexed    time.Time      `json:"last_indexed"` // Most recent chunk update; zero when empty
+	VectorMode     string         `json:"vector_mode"`  // How embeddings are held: mmap or memory
+	MemoryVectors  int            `json:"memory_vectors"`
+	MemoryDegraded bool           `json:"memory_degraded"` // Caches were shed to stay within the memory budget
 }
 
 // ErrorPattern represents an error pattern with its solution for RAG
@@ -115,6 +177,18 @@ var vectorDB *VectorDB
 
 // Initialize sets up the vector database with proper configuration
 func Initialize(cfg *config.Config) error {
+	vdb, err := Open(cfg)
+	if err != nil {
+		return err
+	}
+	vectorDB = vdb
+	return nil
+}
+
+// Open opens the vector database in cfg's data directory without making it
+// the global instance, as for a project other than the one CodeForge was
+// started in
+func Open(cfg *config.Config) (*VectorDB, error) {
 	// Create data directory if it doesn't exist
 	dataDir := cfg.Data.Directory
 	if !filepath.IsAbs(dataDir) {
@@ -122,7 +196,7 @@ func Initialize(cfg *config.Config) error {
 	}
 
 	if err := os.MkdirAll(dataDir, 0755); err != nil {
-		return fmt.Errorf("failed to create data directory: %w", err)
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
 	}
 
 	// Database path
@@ -131,19 +205,47 @@ func Initialize(cfg *config.Config) error {
 	// Connect to libsql database using sql.Open
 	db, err := sql.Open("libsql", "file:"+dbPath)
 	if err != nil {
-		return fmt.Errorf("failed to open libsql database: %w", err)
+		return nil, fmt.Errorf("failed to open libsql database: %w", err)
+	}
+
+	// Map the index file into memory on every pooled connection
+	memory := memorySettingsFor(cfg.Index)
+	if memory.mmapSize > 0 {
+		if drv, ok := db.Driver().(driver.DriverContext); ok {
+			connector, err := drv.OpenConnector("file:" + dbPath)
+			if err != nil {
+				db.Close()
+				return nil, fmt.Errorf("failed to open libsql database: %w", err)
+			}
+			db.Close()
+			db = sql.OpenDB(pragmaConnector{
+				Connector: connector,
+				pragmas:   []string{fmt.Sprintf("PRAGMA mmap_size = %d", memory.mmapSize)},
+			})
+		}
 	}
 
 	// Test connection
 	if err := db.Ping(); err != nil {
-		return fmt.Errorf("failed to ping database: %w", err)
+		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	vectorDB = &VectorDB{
-		db:     db,
-		config: cfg,
-		cache:  &sync.Map{},
+	cipher, err := atrest.ForDatabase(dbPath)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open vector database encryption: %w", err)
+	}
+
+	vdb := &VectorDB{
+		db:      db,
+		path:    dbPath,
+		config:  cfg,
+		cache:   newChunkCache(memory.cacheChunks),
+		vectors: newVectorStore(memory.vectors == VectorsMemory),
+		memory:  newMemoryGuard(memory.budget),
+		cipher:  cipher,
 		stats: VectorStoreStats{
+			VectorMode: memory.vectors,
 			Languages:  make(map[string]int),
 			ChunkTypes: make(map[string]int),
 			Dimension:  0,                       // Will be set dynamically based on actual embedding provider
@@ -152,11 +254,12 @@ func Initialize(cfg *config.Config) error {
 	}
 
 	// Initialize database schema
-	if err := vectorDB.initializeSchema(); err != nil {
-		return fmt.Errorf("failed to initialize schema: %w", err)
+	if err := vdb.initializeSchema(); err != nil {
+		return nil, fmt.Errorf("failed to initialize schema: %w", err)
 	}
+	vdb.syncBranch()
 
-	return nil
+	return vdb, nil
 }
 
 // Get returns the global vector database instance
@@ -203,6 +306,12 @@ func (vdb *VectorDB) initializeSchema() error {
 	if _, err := vdb.db.ExecContext(ctx, chunksSQL); err != nil {
 		return fmt.Errorf("failed to create chunks table: %w", err)
 	}
+	if err := vdb.migrateBranches(ctx); err != nil {
+		return err
+	}
+	if err := vdb.migrateFiles(ctx); err != nil {
+		return err
+	}
 
 	// Detect and set embedding dimensions dynamically
 	if err := vdb.detectEmbeddingDimensions(); err != nil {
@@ -216,7 +325,12 @@ func (vdb *VectorDB) initializeSchema() error {
 	ON chunks(libsql_vector_idx(embedding))
 	`
 
-	if _, err := vdb.db.ExecContext(ctx, vectorIndexSQL); err != nil {
+	if vdb.cipher != nil {
+		// Encrypted embeddings can't be indexed, so search always scans
+		vdb.mu.Lock()
+		vdb.stats.IndexType = "JSON-based Similarity Search (Encrypted)"
+		vdb.mu.Unlock()
+	} else if _, err := vdb.db.ExecContext(ctx, vectorIndexSQL); err != nil {
 		// Vector index creation failed - this is expected if libsql-vectors extension is not available
 		// The system gracefully falls back to JSON-based similarity search which is still very performant
 		log.Printf(" Vector index creation failed: %v", err)
@@ -367,25 +481,41 @@ func (vdb *VectorDB) StoreChunk(ctx context.Context, chunk *CodeChunk, embedding
 	}
 	embeddingStr += "]"
 
+	// Encrypt the code and its embedding, leaving paths and hashes searchable
+	content, err := vdb.cipher.Encrypt(chunk.Content)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt chunk: %w", err)
+	}
+	symbols, err := vdb.cipher.Encrypt(string(symbolsJSON))
+	if err != nil {
+		return fmt.Errorf("failed to encrypt chunk: %w", err)
+	}
+	if embeddingStr, err = vdb.cipher.Encrypt(embeddingStr); err != nil {
+		return fmt.Errorf("failed to encrypt chunk: %w", err)
+	}
+
 	// Detect embedding provider for this chunk
 	embeddingProvider := vdb.detectCurrentProvider()
 	embeddingDimensions := len(embedding)
 
+	vdb.syncBranch()
+	branch := vdb.Branch()
+
 	query := `
 	INSERT OR REPLACE INTO chunks (
 		id, file_path, content, chunk_type, language, symbols, imports,
 		start_line, end_line, start_column, end_column, metadata, hash,
-		created_at, updated_at, embedding, embedding_dimensions, embedding_provider
-	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		created_at, updated_at, embedding, embedding_dimensions, embedding_provider, branch
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	_, err = vdb.db.ExecContext(ctx, query,
-		chunk.ID,
+		rowID(branch, chunk.ID),
 		chunk.FilePath,
-		chunk.Content,
+		content,
 		string(chunkTypeJSON),
 		chunk.Language,
-		string(symbolsJSON),
+		symbols,
 		string(importsJSON),
 		chunk.Location.StartLine,
 		chunk.Location.EndLine,
@@ -398,13 +528,18 @@ func (vdb *VectorDB) StoreChunk(ctx context.Context, chunk *CodeChunk, embedding
 		embeddingStr, // Store as BLOB with dynamic dimensions
 		embeddingDimensions,
 		embeddingProvider,
+		branch,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to store chunk: %w", err)
 	}
 
 	// Update cache
-	vdb.cache.Store(chunk.ID, chunk)
+	vdb.cache.Store(rowID(branch, chunk.ID), chunk)
+	vdb.vectors.Store(chunk.ID, chunk.Hash, embedding)
+	if vdb.memory.stores.Add(1)%memoryCheckInterval == 0 {
+		vdb.checkMemory()
+	}
 
 	// Update statistics
 	vdb.mu.Lock()
@@ -648,9 +783,13 @@ func (vdb *VectorDB) SearchSimilarChunks(ctx context.Context, queryEmbedding []f
 		maxResults = 10 // Default limit
 	}
 
-	// Build query with optional filters
-	whereClause := "WHERE 1=1"
-	args := []interface{}{}
+	// Decoding embeddings into memory can push the process over its budget
+	vdb.checkMemory()
+
+	// Build query with optional filters, limited to the current branch
+	vdb.syncBranch()
+	branchClause, args := branchFilter(vdb.Branch())
+	whereClause := "WHERE " + branchClause
 
 	if language, ok := filters["language"]; ok && language != "" {
 		whereClause += " AND language = ?"
@@ -667,15 +806,21 @@ func (vdb *VectorDB) SearchSimilarChunks(ctx context.Context, queryEmbedding []f
 		args = append(args, "%"+filePath+"%")
 	}
 
+	// Encrypted embeddings are decrypted here rather than extracted by SQLite
+	embeddingColumn := "vector_extract(embedding)"
+	if vdb.cipher != nil {
+		embeddingColumn = "embedding"
+	}
+
 	query := fmt.Sprintf(`
-	SELECT id, file_path, content, chunk_type, language, symbols, imports,
+	SELECT %s, file_path, content, chunk_type, language, symbols, imports,
 		   start_line, end_line, start_column, end_column, metadata, hash,
-		   created_at, updated_at, vector_extract(embedding) as embedding_json
+		   created_at, updated_at, %s as embedding_json
 	FROM chunks
 	%s
 	ORDER BY created_at DESC
 	LIMIT 1000
-	`, whereClause)
+	`, chunkIDColumn, embeddingColumn, whereClause)
 
 	rows, err := vdb.db.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -683,12 +828,7 @@ func (vdb *VectorDB) SearchSimilarChunks(ctx context.Context, queryEmbedding []f
 	}
 	defer rows.Close()
 
-	type candidate struct {
-		Chunk      CodeChunk
-		Similarity float32
-	}
-
-	var candidates []candidate
+	var candidates []mmrCandidate
 	rowCount := 0
 
 	for rows.Next() {
@@ -706,6 +846,9 @@ func (vdb *VectorDB) SearchSimilarChunks(ctx context.Context, queryEmbedding []f
 		); err != nil {
 			continue // Skip invalid rows
 		}
+		if err := vdb.decryptChunk(&chunk, &symbolsJSON, &embeddingJSON); err != nil {
+			continue // Skip chunks encrypted with another key
+		}
 
 		// Parse JSON fields
 		if err := json.Unmarshal([]byte(chunkTypeJSON), &chunk.ChunkType); err != nil {
@@ -729,18 +872,22 @@ func (vdb *VectorDB) SearchSimilarChunks(ctx context.Context, queryEmbedding []f
 			continue
 		}
 
-		// Parse embedding JSON
-		var embedding []float32
-		if err := json.Unmarshal([]byte(embeddingJSON), &embedding); err != nil {
-			continue // Skip invalid embeddings
+		// Parse embedding JSON, unless memory mode kept it decoded
+		embedding, ok := vdb.vectors.Load(chunk.ID, chunk.Hash)
+		if !ok {
+			if err := json.Unmarshal([]byte(embeddingJSON), &embedding); err != nil {
+				continue // Skip invalid embeddings
+			}
+			vdb.vectors.Store(chunk.ID, chunk.Hash, embedding)
 		}
 
 		// Calculate cosine similarity
 		similarity := cosineSimilarity(queryEmbedding, embedding)
 
-		candidates = append(candidates, candidate{
+		candidates = append(candidates, mmrCandidate{
 			Chunk:      chunk,
 			Similarity: float32(similarity),
+			Embedding:  embedding,
 		})
 	}
 
@@ -749,17 +896,15 @@ func (vdb *VectorDB) SearchSimilarChunks(ctx context.Context, queryEmbedding []f
 		return candidates[i].Similarity > candidates[j].Similarity
 	})
 
-	// Return top results
-	if maxResults > len(candidates) {
-		maxResults = len(candidates)
-	}
+	// Return top results, spread over different code
+	candidates = selectDiverse(candidates, maxResults, 1-vdb.config.Index.Diversity)
 
-	results := make([]SearchResult, maxResults)
-	for i := 0; i < maxResults; i++ {
+	results := make([]SearchResult, len(candidates))
+	for i, c := range candidates {
 		results[i] = SearchResult{
-			Chunk:       candidates[i].Chunk,
-			Score:       candidates[i].Similarity,
-			Explanation: fmt.Sprintf("Cosine similarity: %.4f", candidates[i].Similarity),
+			Chunk:       c.Chunk,
+			Score:       c.Similarity,
+			Explanation: fmt.Sprintf("Cosine similarity: %.4f", c.Similarity),
 		}
 	}
 
@@ -768,22 +913,27 @@ func (vdb *VectorDB) SearchSimilarChunks(ctx context.Context, queryEmbedding []f
 
 // GetChunkByID retrieves a specific chunk by its ID
 func (vdb *VectorDB) GetChunkByID(ctx context.Context, id string) (*CodeChunk, error) {
+	vdb.syncBranch()
+	branch := vdb.Branch()
+
 	// Check cache first
-	if cached, ok := vdb.cache.Load(id); ok {
-		if chunk, ok := cached.(*CodeChunk); ok {
-			return chunk, nil
-		}
+	if chunk, ok := vdb.cache.Load(rowID(branch, id)); ok {
+		return chunk, nil
 	}
 
-	query := `
-	SELECT id, file_path, content, chunk_type, language, symbols, imports,
+	// Prefer the branch's own copy of the chunk to the base one
+	branchClause, args := branchFilter(branch)
+	query := fmt.Sprintf(`
+	SELECT %s, file_path, content, chunk_type, language, symbols, imports,
 		   start_line, end_line, start_column, end_column, metadata, hash,
 		   created_at, updated_at, embedding
 	FROM chunks
-	WHERE id = ?
-	`
+	WHERE id IN (?, ?) AND %s
+	ORDER BY branch DESC
+	LIMIT 1
+	`, chunkIDColumn, branchClause)
 
-	row := vdb.db.QueryRowContext(ctx, query, id)
+	row := vdb.db.QueryRowContext(ctx, query, append([]interface{}{rowID(branch, id), id}, args...)...)
 
 	var chunk CodeChunk
 	var chunkTypeJSON, symbolsJSON, importsJSON, metadataJSON string
@@ -802,6 +952,9 @@ func (vdb *VectorDB) GetChunkByID(ctx context.Context, id string) (*CodeChunk, e
 		}
 		return nil, fmt.Errorf("failed to get chunk: %w", err)
 	}
+	if err := vdb.decryptChunk(&chunk, &symbolsJSON, &embeddingStr); err != nil {
+		return nil, err
+	}
 
 	// Parse JSON fields
 	if err := json.Unmarshal([]byte(chunkTypeJSON), &chunk.ChunkType); err != nil {
@@ -826,16 +979,34 @@ func (vdb *VectorDB) GetChunkByID(ctx context.Context, id string) (*CodeChunk, e
 	}
 
 	// Cache the result
-	vdb.cache.Store(id, &chunk)
+	vdb.cache.Store(rowID(branch, id), &chunk)
 
 	return &chunk, nil
 }
 
+// decryptChunk decrypts the encrypted columns of a chunk row in place
+func (vdb *VectorDB) decryptChunk(chunk *CodeChunk, symbolsJSON, embedding *string) error {
+	var err error
+	if chunk.Content, err = vdb.cipher.Decrypt(chunk.Content); err != nil {
+		return fmt.Errorf("failed to decrypt chunk %s: %w", chunk.ID, err)
+	}
+	if *symbolsJSON, err = vdb.cipher.Decrypt(*symbolsJSON); err != nil {
+		return fmt.Errorf("failed to decrypt chunk %s: %w", chunk.ID, err)
+	}
+	if *embedding, err = vdb.cipher.Decrypt(*embedding); err != nil {
+		return fmt.Errorf("failed to decrypt chunk %s: %w", chunk.ID, err)
+	}
+	return nil
+}
+
 // DeleteChunk removes a chunk from the database and cache
 func (vdb *VectorDB) DeleteChunk(ctx context.Context, id string) error {
+	vdb.syncBranch()
+	key := rowID(vdb.Branch(), id)
+
 	query := `DELETE FROM chunks WHERE id = ?`
 
-	result, err := vdb.db.ExecContext(ctx, query, id)
+	result, err := vdb.db.ExecContext(ctx, query, key)
 	if err != nil {
 		return fmt.Errorf("failed to delete chunk: %w", err)
 	}
@@ -850,7 +1021,8 @@ func (vdb *VectorDB) DeleteChunk(ctx context.Context, id string) error {
 	}
 
 	// Remove from cache
-	vdb.cache.Delete(id)
+	vdb.cache.Delete(key)
+	vdb.vectors.Delete(id)
 
 	// Update statistics
 	vdb.mu.Lock()
@@ -878,17 +1050,49 @@ func (vdb *VectorDB) GetStats(ctx context.Context) (*VectorStoreStats, error) {
 		return nil, fmt.Errorf("failed to count files: %w", err)
 	}
 
+	// Count languages from the table so chunks indexed by earlier runs count too
+	languages := make(map[string]int)
+	rows, err := vdb.db.QueryContext(ctx, "SELECT language, COUNT(*) FROM chunks GROUP BY language")
+	if err != nil {
+		return nil, fmt.Errorf("failed to count languages: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var language string
+		var count int
+		if err := rows.Scan(&language, &count); err != nil {
+			return nil, fmt.Errorf("failed to count languages: %w", err)
+		}
+		languages[language] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to count languages: %w", err)
+	}
+
+	var lastIndexed sql.NullString
+	err = vdb.db.QueryRowContext(ctx, "SELECT MAX(updated_at) FROM chunks").Scan(&lastIndexed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find last index time: %w", err)
+	}
+
 	stats := vdb.stats
 	stats.TotalChunks = totalChunks
 	stats.TotalFiles = totalFiles
+	stats.Languages = languages
+	stats.ChunkTypes = make(map[string]int, len(vdb.stats.ChunkTypes))
+	for chunkType, count := range vdb.stats.ChunkTypes {
+		stats.ChunkTypes[chunkType] = count
+	}
+	if lastIndexed.Valid {
+		stats.LastIndexed, _ = time.Parse(time.RFC3339, lastIndexed.String)
+	}
+	if info, err := os.Stat(vdb.path); err == nil {
+		stats.IndexSizeBytes = info.Size()
+	}
 
-	// Count cache size
-	cacheSize := 0
-	vdb.cache.Range(func(key, value interface{}) bool {
-		cacheSize++
-		return true
-	})
-	stats.CacheSize = cacheSize
+	stats.CacheSize = vdb.cache.Len()
+	stats.MemoryVectors = vdb.vectors.Len()
+	stats.MemoryDegraded = vdb.memory.degraded.Load()
 
 	return &stats, nil
 }
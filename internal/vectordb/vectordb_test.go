@@ -366,4 +366,79 @@ func TestVectorDB_Statistics(t *testing.T) {
 	if stats.ChunkTypes["test"] != 5 {
 		t.Errorf("Expected 5 test chunks, got %d", stats.ChunkTypes["test"])
 	}
+
+	if stats.TotalFiles != 5 {
+		t.Errorf("Expected 5 files, got %d", stats.TotalFiles)
+	}
+
+	if stats.LastIndexed.IsZero() {
+		t.Error("Expected a last index time")
+	}
+
+	if stats.IndexSizeBytes == 0 {
+		t.Error("Expected a database size")
+	}
+}
+
+func TestCodeChunk_Describe(t *testing.T) {
+	chunk := CodeChunk{
+		FilePath: "internal/api/chat_handlers.go",
+		Location: SourceLocation{StartLine: 12, EndLine: 30},
+		Symbols:  []Symbol{{Name: "AddMessage", Kind: "method", Parent: "(cs *ChatStorage)"}},
+	}
+	if got, want := chunk.Describe(), "internal/api/chat_handlers.go: (cs *ChatStorage) AddMessage"; got != want {
+		t.Errorf("Describe() = %q, want %q", got, want)
+	}
+	if got := chunk.Lines(); got != "12-30" {
+		t.Errorf("Lines() = %q, want 12-30", got)
+	}
+
+	// Chunks stored without symbols fall back to their chunk type
+	legacy := CodeChunk{
+		FilePath:  "storage.py",
+		ChunkType: ChunkType{Type: "function", Data: map[string]interface{}{"function_name": "add_message"}},
+	}
+	if got, want := legacy.Describe(), "storage.py: add_message"; got != want {
+		t.Errorf("Describe() = %q, want %q", got, want)
+	}
+	if got := (Symbol{Name: "add_message", Parent: "Storage"}).QualifiedName(); got != "Storage.add_message" {
+		t.Errorf("QualifiedName() = %q", got)
+	}
+}
+
+func TestSelectDiverse(t *testing.T) {
+	candidate := func(id, file string, similarity float32, embedding ...float32) mmrCandidate {
+		return mmrCandidate{
+			Chunk:      CodeChunk{ID: id, FilePath: file, Hash: id},
+			Similarity: similarity,
+			Embedding:  embedding,
+		}
+	}
+	candidates := []mmrCandidate{
+		candidate("a1", "a.go", 0.95, 1, 0),
+		candidate("a2", "a.go", 0.94, 1, 0.05),
+		candidate("a3", "a.go", 0.93, 1, 0.1),
+		candidate("b1", "b.go", 0.80, 0, 1),
+	}
+	dup := candidate("a1-copy", "c.go", 0.95, 1, 0)
+	dup.Chunk.Hash = "a1"
+	candidates = append(candidates[:1], append([]mmrCandidate{dup}, candidates[1:]...)...)
+
+	ids := func(selected []mmrCandidate) []string {
+		var out []string
+		for _, c := range selected {
+			out = append(out, c.Chunk.ID)
+		}
+		return out
+	}
+
+	if got := ids(selectDiverse(candidates, 2, 1)); got[0] != "a1" || got[1] != "a1-copy" {
+		t.Errorf("selectDiverse() without diversity = %v, want the top two", got)
+	}
+	if got := ids(selectDiverse(candidates, 2, 0.7)); got[0] != "a1" || got[1] != "b1" {
+		t.Errorf("selectDiverse() = %v, want a1 then b1", got)
+	}
+	if got := selectDiverse(candidates, 10, 0.7); len(got) != 4 {
+		t.Errorf("selectDiverse() kept %v, want every chunk but the duplicate", ids(got))
+	}
 }
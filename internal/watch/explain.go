@@ -0,0 +1,62 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/git"
+	"github.com/entrepeneur4lyf/codeforge/internal/llm"
+)
+
+const explainPrompt = `You are CodeForge, watching a developer's command while they edit code. The command just failed. Explain in a few sentences what went wrong, pointing at the file and line when the output shows them, then suggest the smallest fix, with a code snippet if it helps. Be concise: the developer is in the middle of editing.`
+
+// maxDiff bounds how much of the diff of the changed files is sent along
+const maxDiff = 8 * 1024
+
+// NewExplainer returns an Explainer that asks handler about failures,
+// streaming its answer. The uncommitted diff of the changed files is sent
+// along, since the latest edit is the usual suspect.
+func NewExplainer(handler llm.ApiHandler, root string) Explainer {
+	repo := git.NewRepository(root)
+	return func(ctx context.Context, failure Failure, w io.Writer) error {
+		var prompt strings.Builder
+		fmt.Fprintf(&prompt, "Command: %s\nExit code: %d\n\nOutput:\n```\n%s\n```\n",
+			failure.Command, failure.ExitCode, strings.TrimRight(failure.Output, "\n"))
+
+		if len(failure.Changed) > 0 {
+			var diff strings.Builder
+			for _, file := range failure.Changed {
+				if d, err := repo.FileDiff(ctx, file); err == nil {
+					diff.WriteString(d)
+				}
+			}
+			fmt.Fprintf(&prompt, "\nFiles changed just before this run: %s\n", strings.Join(failure.Changed, ", "))
+			if diff.Len() > 0 {
+				d := diff.String()
+				if len(d) > maxDiff {
+					d = d[:maxDiff] + "\n[diff truncated]"
+				}
+				fmt.Fprintf(&prompt, "\nUncommitted changes to them:\n```diff\n%s\n```\n", d)
+			}
+		}
+
+		messages := []llm.Message{{
+			Role:    "user",
+			Content: []llm.ContentBlock{llm.TextBlock{Text: prompt.String()}},
+		}}
+		stream, err := handler.CreateMessage(ctx, explainPrompt, messages)
+		if err != nil {
+			return fmt.Errorf("failed to explain failure: %w", err)
+		}
+
+		for chunk := range stream {
+			if text, ok := chunk.(llm.ApiStreamTextChunk); ok {
+				fmt.Fprint(w, text.Text)
+			}
+		}
+		fmt.Fprintln(w)
+		return nil
+	}
+}
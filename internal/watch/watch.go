@@ -0,0 +1,238 @@
+// Package watch reruns a command whenever project files change and has a
+// model explain why it failed
+package watch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/ignore"
+)
+
+// DefaultDebounce is how long files must stay unchanged before a rerun
+const DefaultDebounce = 300 * time.Millisecond
+
+// maxOutput bounds how much of a failing command's output is kept for the
+// explanation; the end of the output is where failures usually are
+const maxOutput = 16 * 1024
+
+// Failure describes a failed run of the command
+type Failure struct {
+	Command  string
+	ExitCode int
+	Output   string   // Combined stdout and stderr, truncated from the start
+	Changed  []string // Files whose change triggered the run, relative to the root
+}
+
+// Explainer writes an explanation of a failure, with a suggested fix, to w
+type Explainer func(ctx context.Context, failure Failure, w io.Writer) error
+
+// Runner reruns a command on file changes
+type Runner struct {
+	Root     string        // Directory watched and the command runs in
+	Command  []string      // Program and arguments
+	Debounce time.Duration // Quiet period before a rerun
+	Explain  Explainer     // Explains failures, nil to only rerun
+	Out      io.Writer     // Receives the command output and explanations
+
+	lastExplained string // Output of the last failure explained
+}
+
+// Run runs the command once, then again after every change until ctx is
+// cancelled
+func (r *Runner) Run(ctx context.Context) error {
+	if len(r.Command) == 0 {
+		return fmt.Errorf("no command to run")
+	}
+	if r.Debounce <= 0 {
+		r.Debounce = DefaultDebounce
+	}
+	if r.Out == nil {
+		r.Out = os.Stdout
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create filesystem watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	matcher := ignore.Default()
+	if err := r.watchTree(watcher, r.Root, matcher); err != nil {
+		return err
+	}
+
+	r.runOnce(ctx, nil)
+
+	changed := map[string]bool{}
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			rel, err := filepath.Rel(r.Root, event.Name)
+			if err != nil || matcher.Match(rel) {
+				continue
+			}
+			// New directories need watching too
+			if event.Op&fsnotify.Create != 0 {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					_ = r.watchTree(watcher, event.Name, matcher)
+				}
+			}
+			if event.Op&fsnotify.Chmod == event.Op {
+				continue // Touching permissions isn't an edit
+			}
+			changed[filepath.ToSlash(rel)] = true
+			timer.Reset(r.Debounce)
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			fmt.Fprintf(r.Out, "watch error: %v\n", err)
+
+		case <-timer.C:
+			files := make([]string, 0, len(changed))
+			for file := range changed {
+				files = append(files, file)
+			}
+			sort.Strings(files)
+			changed = map[string]bool{}
+
+			r.runOnce(ctx, files)
+			// Drop events caused by the run itself, such as build output
+			drain(watcher.Events)
+		}
+	}
+}
+
+// runOnce runs the command and explains it if it fails
+func (r *Runner) runOnce(ctx context.Context, changed []string) {
+	command := strings.Join(r.Command, " ")
+	if len(changed) > 0 {
+		fmt.Fprintf(r.Out, "\n▶ %s (changed: %s)\n", command, summarize(changed))
+	} else {
+		fmt.Fprintf(r.Out, "▶ %s\n", command)
+	}
+
+	output := &tailBuffer{max: maxOutput}
+	cmd := exec.CommandContext(ctx, r.Command[0], r.Command[1:]...)
+	cmd.Dir = r.Root
+	// One writer for both streams, so exec never writes to it concurrently
+	combined := io.MultiWriter(r.Out, output)
+	cmd.Stdout = combined
+	cmd.Stderr = combined
+
+	start := time.Now()
+	err := cmd.Run()
+	elapsed := time.Since(start).Round(time.Millisecond)
+	if err == nil {
+		fmt.Fprintf(r.Out, "✔ passed in %s\n", elapsed)
+		r.lastExplained = ""
+		return
+	}
+	if ctx.Err() != nil {
+		return
+	}
+
+	failure := Failure{Command: command, ExitCode: -1, Output: output.String(), Changed: changed}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		failure.ExitCode = exitErr.ExitCode()
+		fmt.Fprintf(r.Out, "✘ failed with exit code %d in %s\n", failure.ExitCode, elapsed)
+	} else {
+		fmt.Fprintf(r.Out, "✘ failed to run: %v\n", err)
+		failure.Output += err.Error()
+	}
+
+	if r.Explain == nil {
+		return
+	}
+	if failure.Output == r.lastExplained {
+		fmt.Fprintln(r.Out, "Same failure as before, see the explanation above.")
+		return
+	}
+	r.lastExplained = failure.Output
+
+	fmt.Fprintln(r.Out, "\n── Why it failed ──")
+	if err := r.Explain(ctx, failure, r.Out); err != nil && ctx.Err() == nil {
+		fmt.Fprintf(r.Out, "Failed to explain the failure: %v\n", err)
+	}
+	fmt.Fprintln(r.Out)
+}
+
+// watchTree watches dir and its subdirectories, skipping excluded ones
+func (r *Runner) watchTree(watcher *fsnotify.Watcher, dir string, matcher *ignore.Matcher) error {
+	return filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		if rel, err := filepath.Rel(r.Root, path); err == nil && rel != "." && matcher.Match(rel) {
+			return filepath.SkipDir
+		}
+		if err := watcher.Add(path); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", path, err)
+		}
+		return nil
+	})
+}
+
+// drain discards events already queued
+func drain(events <-chan fsnotify.Event) {
+	for {
+		select {
+		case <-events:
+		default:
+			return
+		}
+	}
+}
+
+// summarize lists up to three files
+func summarize(files []string) string {
+	if len(files) <= 3 {
+		return strings.Join(files, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(files[:3], ", "), len(files)-3)
+}
+
+// tailBuffer keeps the last max bytes written to it
+type tailBuffer struct {
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.buf.Write(p)
+	if over := t.buf.Len() - t.max; over > 0 {
+		t.buf.Next(over)
+		t.truncated = true
+	}
+	return len(p), nil
+}
+
+func (t *tailBuffer) String() string {
+	if t.truncated {
+		return "[earlier output truncated]\n" + t.buf.String()
+	}
+	return t.buf.String()
+}
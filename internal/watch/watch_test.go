@@ -0,0 +1,61 @@
+package watch
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRunner_ExplainsFailures(t *testing.T) {
+	var explained []Failure
+	var out bytes.Buffer
+	r := &Runner{
+		Root:    t.TempDir(),
+		Command: []string{"sh", "-c", "echo broken; exit 3"},
+		Out:     &out,
+		Explain: func(ctx context.Context, failure Failure, w io.Writer) error {
+			explained = append(explained, failure)
+			_, err := io.WriteString(w, "missing semicolon")
+			return err
+		},
+	}
+
+	ctx := context.Background()
+	r.runOnce(ctx, []string{"main.go"})
+	if len(explained) != 1 {
+		t.Fatalf("explained %d failures, want 1", len(explained))
+	}
+	failure := explained[0]
+	if failure.ExitCode != 3 || failure.Output != "broken\n" || failure.Changed[0] != "main.go" {
+		t.Errorf("failure = %+v", failure)
+	}
+	if !strings.Contains(out.String(), "missing semicolon") {
+		t.Errorf("output doesn't include the explanation:\n%s", out.String())
+	}
+
+	// The same failure again isn't explained twice
+	r.runOnce(ctx, []string{"main.go"})
+	if len(explained) != 1 {
+		t.Errorf("explained the same failure again")
+	}
+
+	r.Command = []string{"true"}
+	r.runOnce(ctx, nil)
+	if len(explained) != 1 || !strings.Contains(out.String(), "passed") {
+		t.Errorf("a passing run was explained or not reported:\n%s", out.String())
+	}
+}
+
+func TestTailBuffer(t *testing.T) {
+	b := &tailBuffer{max: 5}
+	io.WriteString(b, "abc")
+	if b.String() != "abc" {
+		t.Errorf("String() = %q, want abc", b.String())
+	}
+	io.WriteString(b, "defg")
+	if got := b.String(); got != "[earlier output truncated]\ncdefg" {
+		t.Errorf("String() = %q, want the last 5 bytes", got)
+	}
+}
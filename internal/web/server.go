@@ -14,6 +14,7 @@ import (
 	"github.com/entrepeneur4lyf/codeforge/internal/builder"
 	"github.com/entrepeneur4lyf/codeforge/internal/config"
 	"github.com/entrepeneur4lyf/codeforge/internal/embeddings"
+	"github.com/entrepeneur4lyf/codeforge/internal/fileutil"
 	"github.com/entrepeneur4lyf/codeforge/internal/llm"
 	"github.com/entrepeneur4lyf/codeforge/internal/lsp"
 	"github.com/entrepeneur4lyf/codeforge/internal/mcp"
@@ -1820,7 +1821,7 @@ func (s *Server) readFile(path string) (string, error) {
 }
 
 func (s *Server) writeFile(path, content string) error {
-	return os.WriteFile(path, []byte(content), 0644)
+	return fileutil.WriteFile(path, []byte(content))
 }
 
 // handleSearch handles semantic code search requests
@@ -1839,7 +1840,7 @@ func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 	defer cancel()
 
 	// Generate embedding for query
-	embedding, err := embeddings.GetEmbedding(ctx, req.Query)
+	embedding, err := embeddings.GetQueryEmbedding(ctx, req.Query)
 	if err != nil {
 		s.sendError(w, fmt.Sprintf("Failed to generate embedding: %v", err), http.StatusInternalServerError)
 		return
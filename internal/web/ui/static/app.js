@@ -0,0 +1,383 @@
+// CodeForge browser UI. Talks to the API server it is served from.
+(function () {
+  'use strict';
+
+  const API = '/api/v1';
+  const state = {
+    token: localStorage.getItem('codeforge.token'),
+    project: localStorage.getItem('codeforge.project') || '',
+    session: null,
+    socket: null,
+  };
+
+  const $ = (id) => document.getElementById(id);
+
+  function setStatus(text, kind) {
+    const el = $('status');
+    el.textContent = text;
+    el.className = 'status' + (kind ? ' ' + kind : '');
+  }
+
+  // Single sign-on returns to the UI with the token in the fragment
+  const ssoToken = /^#token=([^&]+)/.exec(location.hash);
+  if (ssoToken) {
+    state.token = decodeURIComponent(ssoToken[1]);
+    localStorage.setItem('codeforge.token', state.token);
+    history.replaceState(null, '', location.pathname + location.search);
+  }
+
+  // Authentication is granted to any localhost client; other clients sign
+  // in through the identity provider when the server has SSO. The token is
+  // kept for later visits until it expires.
+  async function login() {
+    const res = await fetch(API + '/auth', {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ device_name: 'Browser' }),
+    });
+    if (res.status === 403) {
+      const info = await (await fetch(API + '/auth/info')).json();
+      if (info.oidc && info.oidc.enabled) {
+        location.assign(info.oidc.login_url);
+        return new Promise(() => {}); // The page is leaving
+      }
+    }
+    if (!res.ok) throw new Error('login failed: ' + res.status);
+    const data = await res.json();
+    state.token = data.token;
+    localStorage.setItem('codeforge.token', state.token);
+  }
+
+  async function api(path, options) {
+    options = options || {};
+    for (let attempt = 0; attempt < 2; attempt++) {
+      if (!state.token) await login();
+      const headers = { 'Content-Type': 'application/json', Authorization: 'Bearer ' + state.token };
+      if (state.project) headers['X-CodeForge-Project'] = state.project;
+      const res = await fetch(API + path, {
+        method: options.method || 'GET',
+        headers: headers,
+        body: options.body ? JSON.stringify(options.body) : undefined,
+      });
+      if (res.status === 401 && attempt === 0) {
+        state.token = null;
+        continue;
+      }
+      const data = res.status === 204 ? null : await res.json();
+      if (!res.ok) throw new Error((data && data.error) || res.statusText);
+      return data;
+    }
+  }
+
+  function empty(el, text) {
+    el.innerHTML = '';
+    const div = document.createElement('div');
+    div.className = 'empty';
+    div.textContent = text;
+    el.appendChild(div);
+  }
+
+  // Views
+
+  function showView(name) {
+    document.querySelectorAll('nav button').forEach((b) => b.classList.toggle('active', b.dataset.view === name));
+    document.querySelectorAll('.view').forEach((v) => v.classList.toggle('active', v.id === 'view-' + name));
+    if (name === 'settings') loadSettings();
+  }
+
+  // Projects
+
+  // Other repositories are registered with the server and picked here; the
+  // server's own project is the first entry
+  async function loadProjects() {
+    const data = await api('/projects');
+    const select = $('project');
+    select.innerHTML = '';
+    const own = document.createElement('option');
+    own.value = '';
+    own.textContent = 'Server project';
+    select.appendChild(own);
+    (data.projects || []).forEach((p) => {
+      const option = document.createElement('option');
+      option.value = p.id;
+      option.textContent = p.name;
+      option.title = p.path;
+      select.appendChild(option);
+    });
+    if (!(data.projects || []).some((p) => p.id === state.project)) selectProject('');
+    select.value = state.project;
+  }
+
+  function selectProject(id) {
+    state.project = id;
+    localStorage.setItem('codeforge.project', id);
+  }
+
+  async function switchProject(id) {
+    selectProject(id);
+    if (state.socket) state.socket.close();
+    state.socket = null;
+    state.session = null;
+    $('session-title').textContent = 'No session selected';
+    $('messages').innerHTML = '';
+    $('prompt').disabled = true;
+    document.querySelector('#composer button').disabled = true;
+    $('share-session').disabled = true;
+    $('search-results').innerHTML = '';
+    await loadSessions();
+    if (document.querySelector('#view-settings.active')) loadSettings();
+  }
+
+  async function addProject() {
+    const path = window.prompt('Path of the repository on the server');
+    if (!path) return;
+    const project = await api('/projects', { method: 'POST', body: { path: path } });
+    selectProject(project.id);
+    await loadProjects();
+    await switchProject(project.id);
+  }
+
+  // Chat
+
+  async function loadSessions() {
+    const data = await api('/chat/sessions');
+    const list = $('sessions');
+    list.innerHTML = '';
+    const sessions = (data.sessions || []).sort((a, b) => new Date(b.updated_at) - new Date(a.updated_at));
+    sessions.forEach((s) => {
+      const li = document.createElement('li');
+      li.textContent = s.title || s.id;
+      li.title = s.id;
+      li.classList.toggle('active', state.session === s.id);
+      const meta = document.createElement('span');
+      meta.className = 'meta';
+      meta.textContent = [s.model, new Date(s.updated_at).toLocaleString()].filter(Boolean).join(' · ');
+      li.appendChild(meta);
+      li.onclick = () => openSession(s.id, s.title);
+      list.appendChild(li);
+    });
+    if (!sessions.length) empty(list, 'No sessions yet');
+  }
+
+  function addMessage(msg, extraClass) {
+    const el = document.createElement('div');
+    el.className = 'message ' + msg.role + (extraClass ? ' ' + extraClass : '');
+    const role = document.createElement('span');
+    role.className = 'role';
+    const client = msg.metadata && msg.metadata.client;
+    role.textContent = msg.role + (client && client !== 'web' ? ' (' + client + ')' : '');
+    el.appendChild(role);
+    el.appendChild(document.createTextNode(msg.content));
+    $('messages').appendChild(el);
+    $('messages').scrollTop = $('messages').scrollHeight;
+    return el;
+  }
+
+  async function openSession(id, title) {
+    state.session = id;
+    $('session-title').textContent = (title || 'Session') + ' — codeforge --session ' + id;
+    $('prompt').disabled = false;
+    document.querySelector('#composer button').disabled = false;
+    $('share-session').disabled = false;
+
+    const data = await api('/chat/sessions/' + encodeURIComponent(id) + '/messages');
+    $('messages').innerHTML = '';
+    (data.messages || []).forEach((m) => addMessage(m));
+    connectSocket(id);
+    loadSessions();
+  }
+
+  // The socket delivers messages other clients (CLI, TUI) add to the session
+  function connectSocket(id) {
+    if (state.socket) state.socket.close();
+    const proto = location.protocol === 'https:' ? 'wss:' : 'ws:';
+    const url = proto + '//' + location.host + API + '/chat/ws/' + encodeURIComponent(id) + '?token=' + encodeURIComponent(state.token);
+    const socket = new WebSocket(url);
+    socket.onopen = () => setStatus('connected', 'ok');
+    socket.onclose = () => {
+      if (state.socket === socket) setStatus('disconnected', 'error');
+    };
+    socket.onmessage = (event) => {
+      const msg = JSON.parse(event.data);
+      if (msg.type === 'message_synced' && msg.data && msg.data.message) {
+        addMessage(msg.data.message);
+      } else if (msg.type === 'approval_requested') {
+        setStatus('tool approval pending — see /api/v1/approvals', 'error');
+      } else if (msg.type === 'approval_resolved') {
+        setStatus('connected', 'ok');
+      }
+    };
+    state.socket = socket;
+  }
+
+  // A share link lets anyone with it read the session, without signing in,
+  // until it expires
+  async function shareSession() {
+    if (!state.session) return;
+    const share = await api('/chat/sessions/' + encodeURIComponent(state.session) + '/share', { method: 'POST', body: {} });
+    const url = location.origin + share.url;
+    try {
+      await navigator.clipboard.writeText(url);
+      setStatus('share link copied, expires ' + new Date(share.expires_at).toLocaleString(), 'ok');
+    } catch (err) {
+      window.prompt('Share link (expires ' + new Date(share.expires_at).toLocaleString() + ')', url);
+    }
+  }
+
+  // Shared sessions
+
+  function addDiff(el, d) {
+    const details = document.createElement('details');
+    const summary = document.createElement('summary');
+    summary.textContent = d.tool + (d.path ? ' ' + d.path : '');
+    details.appendChild(summary);
+    const pre = document.createElement('pre');
+    pre.className = 'diff';
+    d.diff.split('\n').forEach((line) => {
+      const span = document.createElement('span');
+      if (line.startsWith('@@')) span.className = 'hunk';
+      else if (line.startsWith('+') && !line.startsWith('+++')) span.className = 'add';
+      else if (line.startsWith('-') && !line.startsWith('---')) span.className = 'del';
+      span.textContent = line + '\n';
+      pre.appendChild(span);
+    });
+    details.appendChild(pre);
+    el.appendChild(details);
+  }
+
+  async function showShared(token) {
+    document.body.classList.add('shared');
+    const res = await fetch(API + '/shared/' + encodeURIComponent(token));
+    const data = await res.json();
+    if (!res.ok) throw new Error(data.error || res.statusText);
+
+    const session = data.session;
+    document.title = (session.title || 'Session') + ' — CodeForge';
+    $('session-title').textContent = [session.title || 'Session', session.model].filter(Boolean).join(' · ');
+    $('messages').innerHTML = '';
+    (data.messages || []).forEach((m) => {
+      const el = addMessage(m);
+      (m.diffs || []).forEach((d) => addDiff(el, d));
+    });
+    $('messages').scrollTop = 0;
+    setStatus('read-only · link expires ' + new Date(data.expires_at).toLocaleString());
+  }
+
+  async function newSession() {
+    const session = await api('/chat/sessions', { method: 'POST', body: { title: 'Web Chat' } });
+    await openSession(session.id, session.title);
+  }
+
+  async function send(event) {
+    event.preventDefault();
+    const text = $('prompt').value.trim();
+    if (!text || !state.session) return;
+    $('prompt').value = '';
+    addMessage({ role: 'user', content: text });
+    const pending = addMessage({ role: 'assistant', content: 'Thinking…' }, 'pending');
+    try {
+      const reply = await api('/chat/sessions/' + encodeURIComponent(state.session) + '/messages', {
+        method: 'POST',
+        body: { message: text },
+      });
+      pending.remove();
+      addMessage(reply);
+    } catch (err) {
+      pending.remove();
+      addMessage({ role: 'assistant', content: 'Error: ' + err.message });
+    }
+  }
+
+  // Search
+
+  async function search(event) {
+    event.preventDefault();
+    const query = $('search-query').value.trim();
+    const out = $('search-results');
+    if (!query) return;
+    empty(out, 'Searching…');
+    try {
+      const data = await api('/project/search', {
+        method: 'POST',
+        body: { query: query, search_type: $('search-type').value, max_results: 50, include_code: true },
+      });
+      out.innerHTML = '';
+      (data.results || []).forEach((r) => {
+        const el = document.createElement('div');
+        el.className = 'result';
+        const path = document.createElement('span');
+        path.className = 'path';
+        path.textContent = r.path;
+        const score = document.createElement('span');
+        score.className = 'score';
+        score.textContent = r.score ? r.score.toFixed(2) : '';
+        el.appendChild(path);
+        el.appendChild(score);
+        (r.matches || []).slice(0, 3).forEach((m) => {
+          const pre = document.createElement('pre');
+          pre.textContent = m.line_number + ': ' + (m.context || m.line);
+          el.appendChild(pre);
+        });
+        out.appendChild(el);
+      });
+      if (!(data.results || []).length) empty(out, 'No results');
+    } catch (err) {
+      empty(out, 'Search failed: ' + err.message);
+    }
+  }
+
+  // Settings
+
+  async function loadSettings() {
+    try {
+      const config = await api('/config');
+      $('settings-config').textContent = JSON.stringify(config, null, 2);
+    } catch (err) {
+      $('settings-config').textContent = 'Failed to load configuration: ' + err.message;
+    }
+
+    const list = $('settings-providers');
+    try {
+      const data = await api('/providers');
+      list.innerHTML = '';
+      (data.providers || []).forEach((p) => {
+        const row = document.createElement('div');
+        row.className = 'provider';
+        const name = document.createElement('span');
+        name.className = 'name';
+        name.textContent = p.name;
+        const status = document.createElement('span');
+        status.className = p.enabled ? 'enabled' : 'disabled';
+        status.textContent = (p.enabled ? 'enabled' : 'disabled') + (p.default ? ' · default' : '') + ' · ' + p.type;
+        row.appendChild(name);
+        row.appendChild(status);
+        list.appendChild(row);
+      });
+    } catch (err) {
+      empty(list, 'Failed to load providers: ' + err.message);
+    }
+  }
+
+  // Startup
+
+  document.querySelectorAll('nav button').forEach((b) => (b.onclick = () => showView(b.dataset.view)));
+  $('new-session').onclick = () => newSession().catch((err) => setStatus(err.message, 'error'));
+  $('share-session').onclick = () => shareSession().catch((err) => setStatus(err.message, 'error'));
+  $('composer').onsubmit = send;
+  $('prompt').onkeydown = (e) => {
+    if (e.key === 'Enter' && (e.ctrlKey || e.metaKey)) send(e);
+  };
+  $('search-form').onsubmit = search;
+  $('project').onchange = (e) => switchProject(e.target.value).catch((err) => setStatus(err.message, 'error'));
+  $('add-project').onclick = () => addProject().catch((err) => setStatus(err.message, 'error'));
+
+  const shared = /^\/share\/([^/]+)$/.exec(location.pathname);
+  if (shared) {
+    showShared(decodeURIComponent(shared[1])).catch((err) => setStatus(err.message, 'error'));
+  } else {
+    loadProjects()
+      .then(loadSessions)
+      .then(() => setStatus('ready', 'ok'))
+      .catch((err) => setStatus(err.message, 'error'));
+  }
+})();
@@ -0,0 +1,43 @@
+// Package ui embeds the browser frontend served by `codeforge serve`.
+package ui
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+)
+
+//go:embed static
+var static embed.FS
+
+// Files returns the embedded frontend files
+func Files() fs.FS {
+	files, err := fs.Sub(static, "static")
+	if err != nil {
+		// The directory is embedded at build time, so this can't happen
+		panic(err)
+	}
+	return files
+}
+
+// Handler serves the embedded frontend. Paths that don't name a file get
+// index.html, so the single-page app can handle its own routes.
+func Handler() http.Handler {
+	files := Files()
+	fileServer := http.FileServer(http.FS(files))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(path.Clean(r.URL.Path), "/")
+		if name == "" {
+			name = "index.html"
+		}
+		if _, err := fs.Stat(files, name); err != nil {
+			r = r.Clone(r.Context())
+			r.URL.Path = "/"
+		}
+		w.Header().Set("Cache-Control", "no-cache")
+		fileServer.ServeHTTP(w, r)
+	})
+}
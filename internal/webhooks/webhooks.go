@@ -0,0 +1,246 @@
+// Package webhooks posts CodeForge events, such as finished agent runs, to
+// outbound webhooks like Slack incoming webhooks
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+	"github.com/entrepeneur4lyf/codeforge/internal/httpclient"
+)
+
+// Event types endpoints can subscribe to
+const (
+	AgentFinished   = "agent.finished"
+	BudgetThreshold = "budget.threshold"
+	ServerError     = "server.error"
+)
+
+// EventTypes lists every event type
+var EventTypes = []string{AgentFinished, BudgetThreshold, ServerError}
+
+const sendTimeout = 10 * time.Second
+
+// Event is one notification. Templates see its fields, such as {{.Text}}
+// and {{.Data.model}}.
+type Event struct {
+	Type string                 `json:"type"`
+	Time time.Time              `json:"time"`
+	Text string                 `json:"text"` // One-line summary, the Slack message by default
+	Data map[string]interface{} `json:"data,omitempty"`
+}
+
+// endpoint is a configured webhook with its parsed template
+type endpoint struct {
+	config.WebhookConfig
+	tmpl *template.Template
+}
+
+// wants reports whether the endpoint subscribes to an event type
+func (e *endpoint) wants(eventType string) bool {
+	if len(e.Events) == 0 {
+		return true
+	}
+	for _, t := range e.Events {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Notifier posts events to the configured endpoints
+type Notifier struct {
+	endpoints []*endpoint
+	client    *http.Client
+	wg        sync.WaitGroup
+}
+
+var templateFuncs = template.FuncMap{
+	// json quotes a value for use inside a JSON body, as in {"text": {{json .Text}}}
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// New returns a notifier for the configured endpoints. Templates and event
+// types are checked up front so a typo fails at startup.
+func New(cfg config.WebhooksConfig) (*Notifier, error) {
+	n := &Notifier{client: httpclient.New("webhooks", sendTimeout)}
+	for i, c := range cfg.Endpoints {
+		name := c.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		if c.URL == "" {
+			return nil, fmt.Errorf("webhook %s: url is required", name)
+		}
+		for _, t := range c.Events {
+			if !knownEvent(t) {
+				return nil, fmt.Errorf("webhook %s: unknown event %q; use one of %s", name, t, strings.Join(EventTypes, ", "))
+			}
+		}
+
+		ep := &endpoint{WebhookConfig: c}
+		ep.Name = name
+		if c.Template != "" {
+			tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=zero").Parse(c.Template)
+			if err != nil {
+				return nil, fmt.Errorf("webhook %s: %w", name, err)
+			}
+			ep.tmpl = tmpl
+		}
+		n.endpoints = append(n.endpoints, ep)
+	}
+	return n, nil
+}
+
+func knownEvent(eventType string) bool {
+	for _, t := range EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Send posts an event to every endpoint subscribed to it, in the background
+func (n *Notifier) Send(event Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	for _, ep := range n.endpoints {
+		if !ep.wants(event.Type) {
+			continue
+		}
+		n.wg.Add(1)
+		go func(ep *endpoint) {
+			defer n.wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+			defer cancel()
+			if err := n.post(ctx, ep, event); err != nil {
+				// Notifications never fail the work they report on
+				log.Printf("Warning: webhook %s: %v", ep.Name, err)
+			}
+		}(ep)
+	}
+}
+
+// Wait blocks until the events sent so far have been posted
+func (n *Notifier) Wait() {
+	n.wg.Wait()
+}
+
+func (n *Notifier) post(ctx context.Context, ep *endpoint, event Event) error {
+	body, err := render(ep, event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "CodeForge")
+	for k, v := range ep.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s %s: %s", event.Type, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// render returns the request body of an event: the endpoint's template, or
+// a Slack message when it has none
+func render(ep *endpoint, event Event) ([]byte, error) {
+	if ep.tmpl == nil {
+		return json.Marshal(map[string]string{"text": event.Text})
+	}
+	var buf bytes.Buffer
+	if err := ep.tmpl.Execute(&buf, event); err != nil {
+		return nil, fmt.Errorf("template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+var (
+	mu      sync.RWMutex
+	current *Notifier
+)
+
+// SetNotifier sets the notifier Notify sends through; nil turns
+// notifications off
+func SetNotifier(n *Notifier) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = n
+}
+
+// Notify sends an event through the notifier set with SetNotifier, if any
+func Notify(eventType, text string, data map[string]interface{}) {
+	mu.RLock()
+	n := current
+	mu.RUnlock()
+	if n == nil {
+		return
+	}
+	n.Send(Event{Type: eventType, Time: time.Now(), Text: text, Data: data})
+}
+
+// Configure sets up notifications from the config: it installs a notifier
+// for the webhook endpoints and sends budget.threshold when spending crosses
+// one of the budget thresholds
+func Configure(cfg *config.Config) error {
+	if len(cfg.Webhooks.Endpoints) == 0 {
+		SetNotifier(nil)
+		return nil
+	}
+	n, err := New(cfg.Webhooks)
+	if err != nil {
+		return err
+	}
+	SetNotifier(n)
+
+	if cfg.CostTracker != nil {
+		for _, percent := range cfg.Webhooks.BudgetThresholds {
+			cfg.CostTracker.AddBudgetAlert(&config.BudgetAlert{
+				ID:               fmt.Sprintf("webhook-daily-%g", percent),
+				Name:             fmt.Sprintf("%g%% of the daily budget", percent),
+				Period:           config.PeriodDaily,
+				ThresholdPercent: percent,
+				Enabled:          true,
+				Actions:          []config.AlertAction{{Type: config.ActionWebhook}},
+			})
+		}
+		cfg.CostTracker.SetAlertHandler(func(alert config.BudgetAlert, spending, budget float64) {
+			text := fmt.Sprintf("Spending reached %g%% of the %s budget: $%.2f of $%.2f", alert.ThresholdPercent, alert.Period, spending, budget)
+			Notify(BudgetThreshold, text, map[string]interface{}{
+				"period":    string(alert.Period),
+				"threshold": alert.ThresholdPercent,
+				"spending":  spending,
+				"budget":    budget,
+			})
+		})
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/entrepeneur4lyf/codeforge/internal/config"
+)
+
+// receiver records the bodies posted to a test webhook
+type receiver struct {
+	mu     sync.Mutex
+	bodies []string
+	header http.Header
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bodies = append(r.bodies, string(body))
+	r.header = req.Header.Clone()
+}
+
+func (r *receiver) received() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.bodies...)
+}
+
+func TestSendSlackAndTemplate(t *testing.T) {
+	slack, custom := &receiver{}, &receiver{}
+	slackSrv, customSrv := httptest.NewServer(slack), httptest.NewServer(custom)
+	defer slackSrv.Close()
+	defer customSrv.Close()
+
+	n, err := New(config.WebhooksConfig{Endpoints: []config.WebhookConfig{
+		{Name: "slack", URL: slackSrv.URL},
+		{
+			Name:     "ops",
+			URL:      customSrv.URL,
+			Events:   []string{ServerError},
+			Template: `{"summary": {{json .Text}}, "status": {{.Data.status}}}`,
+			Headers:  map[string]string{"Authorization": "Bearer token"},
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n.Send(Event{Type: AgentFinished, Text: `Agent "coder" finished`})
+	n.Send(Event{Type: ServerError, Text: "Server error 500: disk full", Data: map[string]interface{}{"status": 500}})
+	n.Wait()
+
+	got := slack.received()
+	if len(got) != 2 {
+		t.Fatalf("slack received %d events, want 2", len(got))
+	}
+	texts := ""
+	for _, body := range got {
+		var msg map[string]string
+		if err := json.Unmarshal([]byte(body), &msg); err != nil {
+			t.Fatalf("slack body %q: %v", body, err)
+		}
+		texts += msg["text"] + "\n"
+	}
+	if !strings.Contains(texts, `Agent "coder" finished`) || !strings.Contains(texts, "disk full") {
+		t.Errorf("slack texts = %q", texts)
+	}
+
+	// The ops endpoint only subscribes to server errors
+	got = custom.received()
+	if len(got) != 1 || got[0] != `{"summary": "Server error 500: disk full", "status": 500}` {
+		t.Errorf("custom received %q", got)
+	}
+	if custom.header.Get("Authorization") != "Bearer token" || custom.header.Get("Content-Type") != "application/json" {
+		t.Errorf("custom headers = %v", custom.header)
+	}
+}
+
+func TestNewRejectsBadConfig(t *testing.T) {
+	bad := []config.WebhookConfig{
+		{Name: "no-url"},
+		{URL: "http://example.com", Events: []string{"agent.done"}},
+		{URL: "http://example.com", Template: `{"text": {{.Text}`},
+	}
+	for _, c := range bad {
+		if _, err := New(config.WebhooksConfig{Endpoints: []config.WebhookConfig{c}}); err == nil {
+			t.Errorf("New(%+v) succeeded", c)
+		}
+	}
+}
+
+func TestBudgetThresholds(t *testing.T) {
+	rec := &receiver{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	cfg := &config.Config{
+		Webhooks: config.WebhooksConfig{
+			Endpoints:        []config.WebhookConfig{{URL: srv.URL, Events: []string{BudgetThreshold}}},
+			BudgetThresholds: []float64{80, 100},
+		},
+		CostTracker: config.NewCostTracker(),
+	}
+	cfg.CostTracker.SetBudget(config.PeriodDaily, 10)
+	if err := Configure(cfg); err != nil {
+		t.Fatal(err)
+	}
+	defer SetNotifier(nil)
+
+	mu.RLock()
+	n := current
+	mu.RUnlock()
+
+	// 80% is crossed once, then 100%
+	for _, cost := range []float64{5, 3.5, 1, 1} {
+		cfg.CostTracker.RecordUsage(config.TokenUsageRecord{TotalCost: cost})
+	}
+	n.Wait()
+
+	got := rec.received()
+	if len(got) != 2 {
+		t.Fatalf("received %d budget events, want 2: %q", len(got), got)
+	}
+	all := strings.Join(got, "\n")
+	for _, want := range []string{"80% of the daily budget", "100% of the daily budget"} {
+		if !strings.Contains(all, want) {
+			t.Errorf("missing %q in %q", want, all)
+		}
+	}
+}